@@ -114,11 +114,14 @@ func init() {
 	rootCmd.AddCommand(command.ListCommand(appManager))
 	rootCmd.AddCommand(command.DeleteCommand(appManager))
 	rootCmd.AddCommand(command.ImportCommand(appManager))
+	rootCmd.AddCommand(command.ExportRuleCommand(appManager))
+	rootCmd.AddCommand(command.ExportCommand(appManager))
 	rootCmd.AddCommand(command.StartCommand(appManager))
 	rootCmd.AddCommand(command.StopCommand(appManager))
 	rootCmd.AddCommand(command.RestartCommand(appManager))
 	rootCmd.AddCommand(command.StatusCommand(appManager))
 	rootCmd.AddCommand(command.RemoteCoderCommand(appManager))
+	rootCmd.AddCommand(command.EncryptionCommand(appManager))
 }
 
 func main() {
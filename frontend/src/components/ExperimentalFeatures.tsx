@@ -14,6 +14,7 @@ export interface ExperimentalFeaturesProps {
 
 const FEATURES = [
     { key: 'smart_compact', label: 'Smart Compact', description: 'Remove thinking blocks from conversation history to reduce context' },
+    { key: 'compact_tool_results', label: 'Compact Tool Results', description: 'Collapse superseded tool outputs and truncate large old ones in long agent sessions, leaving the prompt-cached prefix untouched' },
     { key: 'recording', label: 'Recording', description: 'Record scenario-level request/response traffic for debugging' },
 ] as const;
 
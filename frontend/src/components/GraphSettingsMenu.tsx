@@ -1,10 +1,11 @@
-import { Block as InactiveIcon, CheckCircle as ActiveIcon, Delete as DeleteIcon, Download as ExportIcon, PlayArrow as ProbeIcon, Settings as SettingsIcon, SmartDisplay as SmartIcon } from '@mui/icons-material';
+import { Block as InactiveIcon, CheckCircle as ActiveIcon, Delete as DeleteIcon, Download as ExportIcon, PushPin as AffinityIcon, PlayArrow as ProbeIcon, Settings as SettingsIcon, SmartDisplay as SmartIcon } from '@mui/icons-material';
 import { IconButton, Menu, MenuItem, Tooltip } from '@mui/material';
 import React, { useCallback } from 'react';
 
 export interface GraphSettingsMenuProps {
     // Common props
     smartEnabled: boolean;
+    sessionAffinityEnabled: boolean;
     canProbe: boolean;
     isProbing: boolean;
     allowDeleteRule: boolean;
@@ -14,6 +15,7 @@ export interface GraphSettingsMenuProps {
 
     // Callbacks
     onToggleSmartRouting: () => void;
+    onEditSessionAffinity: () => void;
     onProbe: () => void;
     onExport: () => void;
     onDelete: () => void;
@@ -22,6 +24,7 @@ export interface GraphSettingsMenuProps {
 
 export const GraphSettingsMenu: React.FC<GraphSettingsMenuProps> = ({
     smartEnabled,
+    sessionAffinityEnabled,
     canProbe,
     isProbing,
     allowDeleteRule,
@@ -29,6 +32,7 @@ export const GraphSettingsMenu: React.FC<GraphSettingsMenuProps> = ({
     allowToggleRule,
     saving,
     onToggleSmartRouting,
+    onEditSessionAffinity,
     onProbe,
     onExport,
     onDelete,
@@ -50,6 +54,11 @@ export const GraphSettingsMenu: React.FC<GraphSettingsMenuProps> = ({
         onToggleSmartRouting();
     }, [onToggleSmartRouting]);
 
+    const handleEditSessionAffinity = useCallback(() => {
+        handleMenuClose();
+        onEditSessionAffinity();
+    }, [onEditSessionAffinity]);
+
     const handleProbe = useCallback(() => {
         handleMenuClose();
         onProbe();
@@ -146,6 +155,12 @@ export const GraphSettingsMenu: React.FC<GraphSettingsMenuProps> = ({
                     </MenuItem>
                 )}
 
+                {/* Session Affinity */}
+                <MenuItem onClick={handleEditSessionAffinity} disabled={saving}>
+                    <AffinityIcon fontSize="small" sx={{ mr: 1 }} />
+                    Session Affinity{sessionAffinityEnabled ? ' (On)' : ''}
+                </MenuItem>
+
                 {/* Toggle Smart Routing */}
                 <MenuItem onClick={handleToggleSmartRouting}>
                     <SmartIcon fontSize="small" sx={{ mr: 1 }} />
@@ -14,13 +14,18 @@ export interface ConfigProvider {
 
 export interface SmartOp {
     uuid: string;
-    position: 'model' | 'thinking' | 'system' | 'user' | 'tool_use' | 'token';
+    // One of the positions served by /smart-routing/operations, e.g. model, token or time
+    position: string;
     operation: string;
     value: string;
     meta?: {
         description?: string;
         type?: 'string' | 'int' | 'bool' | 'float';
     };
+    // Group operations combine nested ops; a group sets exactly one of these
+    any?: SmartOp[];
+    all?: SmartOp[];
+    not?: SmartOp;
 }
 
 export interface SmartRouting {
@@ -30,6 +35,13 @@ export interface SmartRouting {
     services: ConfigProvider[];
 }
 
+export interface SessionAffinity {
+    enabled: boolean;
+    key?: string; // auto, header, metadata or first_message
+    header?: string;
+    ttl_seconds?: number;
+}
+
 export interface ConfigRecord {
     uuid: string;
     requestModel: string;
@@ -40,6 +52,7 @@ export interface ConfigRecord {
     // Smart routing fields
     smartEnabled?: boolean;
     smartRouting?: SmartRouting[];
+    sessionAffinity?: SessionAffinity;
 }
 
 export interface Rule {
@@ -61,4 +74,8 @@ export interface Rule {
     // Smart routing fields
     smart_enabled?: boolean;
     smart_routing?: SmartRouting[];
+    session_affinity?: SessionAffinity;
+    // Settings without an editor, sent back unchanged on save
+    failover?: unknown;
+    context_overflow?: unknown;
 }
@@ -15,6 +15,7 @@ import RoutingGraph from '@/components/RoutingGraph';
 import SmartRoutingGraph from '@/components/SmartRoutingGraph';
 import SmartRuleEditDialog from '@/components/SmartRuleEditDialog';
 import GraphSettingsMenu from '@/components/GraphSettingsMenu';
+import SessionAffinityDialog from '@/components/SessionAffinityDialog';
 
 export interface RuleCardProps {
     rule: Rule;
@@ -91,6 +92,9 @@ export const RuleCard: React.FC<RuleCardProps> = ({
     // Delete confirmation state
     const [deleteDialogOpen, setDeleteDialogOpen] = useState(false);
 
+    // Session affinity dialog state
+    const [sessionAffinityDialogOpen, setSessionAffinityDialogOpen] = useState(false);
+
     // Handler: Delete provider
     const handleDeleteProvider = useCallback(
         async (_recordId: string, providerId: string) => {
@@ -169,6 +173,7 @@ export const RuleCard: React.FC<RuleCardProps> = ({
     const extraActions = (
         <GraphSettingsMenu
             smartEnabled={isSmartMode ?? false}
+            sessionAffinityEnabled={configRecord.sessionAffinity?.enabled ?? false}
             canProbe={!!configRecord.providers[0]?.provider && !!configRecord.providers[0]?.model}
             isProbing={probeState.isProbing}
             allowDeleteRule={allowDeleteRule}
@@ -176,6 +181,7 @@ export const RuleCard: React.FC<RuleCardProps> = ({
             allowToggleRule={allowToggleRule}
             saving={saving}
             onToggleSmartRouting={() => updateField(configRecord, setConfigRecord, 'smartEnabled', !isSmartMode)}
+            onEditSessionAffinity={() => setSessionAffinityDialogOpen(true)}
             onProbe={probeState.handleProbe}
             onExport={handleExport}
             onDelete={handleDeleteButtonClick}
@@ -251,6 +257,17 @@ export const RuleCard: React.FC<RuleCardProps> = ({
                 onSave={smartHandlers.handleSaveSmartRule}
                 onCancel={smartHandlers.handleCancelSmartRuleEdit}
             />
+
+            {/* Session Affinity Dialog */}
+            <SessionAffinityDialog
+                open={sessionAffinityDialogOpen}
+                sessionAffinity={configRecord.sessionAffinity}
+                onSave={(updated) => {
+                    setSessionAffinityDialogOpen(false);
+                    updateField(configRecord, setConfigRecord, 'sessionAffinity', updated);
+                }}
+                onCancel={() => setSessionAffinityDialogOpen(false)}
+            />
         </>
     );
 };
@@ -0,0 +1,133 @@
+import {
+    Button,
+    Dialog,
+    DialogActions,
+    DialogContent,
+    DialogTitle,
+    FormControl,
+    FormControlLabel,
+    InputLabel,
+    MenuItem,
+    Select,
+    Stack,
+    Switch,
+    TextField,
+    Typography,
+} from '@mui/material';
+import React, { useEffect, useState } from 'react';
+import type { SessionAffinity } from './RoutingGraphTypes';
+
+// Conversation key sources, see typ.SessionKeySource
+const KEY_OPTIONS = [
+    { value: 'auto', label: 'Auto', description: 'Header, then client metadata, then first message' },
+    { value: 'header', label: 'Header', description: 'Conversation ID sent in a request header' },
+    { value: 'metadata', label: 'Client Metadata', description: 'Claude Code metadata.user_id, OpenAI user, Responses prompt_cache_key' },
+    { value: 'first_message', label: 'First Message', description: 'Hash of the system prompt and the first user message' },
+];
+
+const DEFAULT_HEADER = 'x-session-id';
+const DEFAULT_TTL_SECONDS = 3600;
+
+export interface SessionAffinityDialogProps {
+    open: boolean;
+    sessionAffinity?: SessionAffinity;
+    onSave: (updated: SessionAffinity) => void;
+    onCancel: () => void;
+}
+
+const SessionAffinityDialog: React.FC<SessionAffinityDialogProps> = ({
+    open,
+    sessionAffinity,
+    onSave,
+    onCancel,
+}) => {
+    const [enabled, setEnabled] = useState(false);
+    const [key, setKey] = useState('auto');
+    const [header, setHeader] = useState('');
+    const [ttlSeconds, setTtlSeconds] = useState('');
+
+    // Reset form when the dialog opens
+    useEffect(() => {
+        if (!open) return;
+        setEnabled(sessionAffinity?.enabled ?? false);
+        setKey(sessionAffinity?.key || 'auto');
+        setHeader(sessionAffinity?.header || '');
+        setTtlSeconds(sessionAffinity?.ttl_seconds ? String(sessionAffinity.ttl_seconds) : '');
+    }, [open, sessionAffinity]);
+
+    const ttl = ttlSeconds.trim() === '' ? 0 : parseInt(ttlSeconds, 10);
+    const isValid = !isNaN(ttl) && ttl >= 0;
+
+    const handleSave = () => {
+        onSave({
+            enabled,
+            key: key === 'auto' ? undefined : key,
+            header: header.trim() || undefined,
+            ttl_seconds: ttl || undefined,
+        });
+    };
+
+    return (
+        <Dialog open={open} onClose={onCancel} maxWidth="sm" fullWidth>
+            <DialogTitle>Session Affinity</DialogTitle>
+            <DialogContent>
+                <Stack spacing={3} sx={{ mt: 1 }}>
+                    <Typography variant="body2" color="text.secondary">
+                        Keep the requests of a conversation on the service that served its first request,
+                        so that upstream prompt caches stay warm.
+                    </Typography>
+
+                    <FormControlLabel
+                        control={<Switch checked={enabled} onChange={(e) => setEnabled(e.target.checked)} />}
+                        label="Enabled"
+                    />
+
+                    <FormControl size="small" fullWidth disabled={!enabled}>
+                        <InputLabel>Conversation Key</InputLabel>
+                        <Select value={key} label="Conversation Key" onChange={(e) => setKey(e.target.value)}>
+                            {KEY_OPTIONS.map((opt) => (
+                                <MenuItem key={opt.value} value={opt.value}>
+                                    {opt.label}
+                                </MenuItem>
+                            ))}
+                        </Select>
+                        <Typography variant="caption" color="text.secondary" sx={{ mt: 0.5 }}>
+                            {KEY_OPTIONS.find((opt) => opt.value === key)?.description}
+                        </Typography>
+                    </FormControl>
+
+                    {(key === 'auto' || key === 'header') && (
+                        <TextField
+                            size="small"
+                            label="Header"
+                            value={header}
+                            onChange={(e) => setHeader(e.target.value)}
+                            placeholder={DEFAULT_HEADER}
+                            disabled={!enabled}
+                        />
+                    )}
+
+                    <TextField
+                        size="small"
+                        label="Idle TTL (seconds)"
+                        value={ttlSeconds}
+                        onChange={(e) => setTtlSeconds(e.target.value.replace(/[^\d]/g, ''))}
+                        placeholder={String(DEFAULT_TTL_SECONDS)}
+                        helperText="The pin expires after this long without requests"
+                        disabled={!enabled}
+                    />
+                </Stack>
+            </DialogContent>
+            <DialogActions sx={{ px: 3, pb: 2, gap: 1, justifyContent: 'flex-end' }}>
+                <Button onClick={onCancel} color="inherit">
+                    Cancel
+                </Button>
+                <Button onClick={handleSave} variant="contained" disabled={!isValid}>
+                    Save
+                </Button>
+            </DialogActions>
+        </Dialog>
+    );
+};
+
+export default SessionAffinityDialog;
@@ -16,53 +16,56 @@ import {
 } from '@mui/material';
 import React, { useEffect, useState } from 'react';
 import { v4 as uuidv4 } from 'uuid';
+import { api } from '../services/api';
 import type { SmartRouting, SmartOp } from './RoutingGraphTypes';
 
-// Position options with descriptions
-const POSITION_OPTIONS = [
-    // { value: 'model', label: 'Model', description: 'Request model name' },
-    { value: 'context_system', label: 'System Prompt', description: 'System prompt message in context' },
-    // { value: 'context_user', label: 'User Context', description: 'User messages in context' },
-    { value: 'latest_user', label: 'Latest User Message', description: 'Latest user message' },
-    // { value: 'tool_use', label: 'Tool Name', description: 'Tool name' },
-    { value: 'thinking', label: 'Thinking', description: 'Thinking mode enabled / disable' },
-    { value: 'token', label: 'Token Count', description: 'Token count' },
-] as const;
+// Display labels of the positions, positions without one are shown by name
+const POSITION_LABELS: Record<string, string> = {
+    model: 'Model',
+    thinking: 'Thinking',
+    context_system: 'System Prompt',
+    context_user: 'User Context',
+    latest_user: 'Latest User Message',
+    tool_use: 'Tool Name',
+    token: 'Token Count',
+    api_key: 'API Key',
+    client: 'Client',
+    header: 'Header',
+    tool_count: 'Tool Count',
+    image_count: 'Image Count',
+    time: 'Time (server local)',
+    expr: 'Expression',
+};
 
-// Operation options grouped by position
-const OPERATION_OPTIONS: Record<string, Array<{ value: string; label: string; description: string; valueType: 'string' | 'int' | 'bool' }>> = {
-    model: [
-        { value: 'contains', label: 'Contains', description: 'Model name contains the value', valueType: 'string' },
-        // { value: 'glob', label: 'Glob', description: 'Model name matches glob pattern', valueType: 'string' },
-        { value: 'equals', label: 'Equals', description: 'Model name equals the value', valueType: 'string' },
-    ],
-    thinking: [
-        { value: 'enabled', label: 'Enabled', description: 'Thinking mode is enabled', valueType: 'bool' },
-        { value: 'disabled', label: 'Disabled', description: 'Thinking mode is disabled', valueType: 'bool' },
-    ],
-    context_system: [
-        { value: 'contains', label: 'Contains', description: 'Any system messages contain the value', valueType: 'string' },
-        // { value: 'regex', label: 'Regex', description: 'Any system messages match regex pattern', valueType: 'string' },
-    ],
-    context_user: [
-        { value: 'contains', label: 'Contains', description: 'Any user messages contain the value', valueType: 'string' },
-        // { value: 'regex', label: 'Regex', description: 'Combined user messages match regex pattern', valueType: 'string' },
-    ],
-    latest_user: [
-        { value: 'contains', label: 'Contains', description: 'Latest user message contains the value', valueType: 'string' },
-        // { value: 'type', label: 'Type', description: 'Latest user message content type (e.g., image)', valueType: 'string' },
-    ],
-    tool_use: [
-        { value: 'equals', label: 'Equals', description: 'Latest message is tool use and its name equals the value', valueType: 'string' },
-    ],
-    token: [
-        { value: 'ge', label: 'Greater or Equal', description: 'Token count >= value', valueType: 'int' },
-        // { value: 'gt', label: 'Greater Than', description: 'Token count > value', valueType: 'int' },
-        { value: 'le', label: 'Less or Equal', description: 'Token count <= value', valueType: 'int' },
-        // { value: 'lt', label: 'Less Than', description: 'Token count < value', valueType: 'int' },
-    ],
+// Display labels of the operations, operations without one are shown by name
+const OPERATION_LABELS: Record<string, string> = {
+    contains: 'Contains',
+    equals: 'Equals',
+    glob: 'Glob',
+    regex: 'Regex',
+    type: 'Type',
+    exists: 'Exists',
+    enabled: 'Enabled',
+    disabled: 'Disabled',
+    ge: 'Greater or Equal',
+    gt: 'Greater Than',
+    le: 'Less or Equal',
+    lt: 'Less Than',
+    between: 'Between',
+    weekday: 'Weekday',
+    match: 'Match',
 };
 
+// Operation as served by /smart-routing/operations
+interface OperationDef {
+    position: string;
+    operation: string;
+    meta?: {
+        description?: string;
+        type?: 'string' | 'int' | 'bool' | 'float';
+    };
+}
+
 export interface SmartRuleEditDialogProps {
     open: boolean;
     smartRouting: SmartRouting | null;
@@ -77,9 +80,10 @@ const SmartRuleEditDialog: React.FC<SmartRuleEditDialogProps> = ({
     onCancel,
 }) => {
     const [description, setDescription] = useState('');
+    const [operations, setOperations] = useState<OperationDef[]>([]);
     const [op, setOp] = useState<SmartOp>({
         uuid: uuidv4(),
-        position: '' as SmartOp['position'],
+        position: '',
         operation: '',
         value: '',
         meta: {
@@ -88,6 +92,19 @@ const SmartRuleEditDialog: React.FC<SmartRuleEditDialogProps> = ({
         },
     });
 
+    // Load the available operations from the server
+    useEffect(() => {
+        if (!open || operations.length > 0) return;
+        api.getSmartRoutingOperations().then((result) => {
+            if (result?.success && Array.isArray(result.data)) {
+                setOperations(result.data);
+            }
+        });
+    }, [open, operations.length]);
+
+    const positions = Array.from(new Set(operations.map((def) => def.position)));
+    const positionOperations = operations.filter((def) => def.position === op.position);
+
     // Reset form when smartRouting changes
     useEffect(() => {
         if (smartRouting) {
@@ -97,7 +114,7 @@ const SmartRuleEditDialog: React.FC<SmartRuleEditDialogProps> = ({
                 ? { ...smartRouting.ops[0] }
                 : {
                     uuid: uuidv4(),
-                    position: '' as SmartOp['position'],
+                    position: '',
                     operation: '',
                     value: '',
                     meta: {
@@ -110,7 +127,7 @@ const SmartRuleEditDialog: React.FC<SmartRuleEditDialogProps> = ({
             setDescription('');
             setOp({
                 uuid: uuidv4(),
-                position: '' as SmartOp['position'],
+                position: '',
                 operation: '',
                 value: '',
                 meta: {
@@ -167,11 +184,11 @@ const SmartRuleEditDialog: React.FC<SmartRuleEditDialogProps> = ({
         }
         // Update operation-specific metadata when operation is set
         else if (field === 'operation') {
-            const opDef = OPERATION_OPTIONS[op.position]?.find(opt => opt.value === value);
+            const opDef = positionOperations.find(def => def.operation === value);
             if (opDef) {
                 updatedOp.meta = {
-                    description: opDef.description,
-                    type: opDef.valueType,
+                    description: opDef.meta?.description || '',
+                    type: opDef.meta?.type || 'string',
                 };
                 // Clear value when operation changes
                 updatedOp.value = '';
@@ -252,11 +269,9 @@ const SmartRuleEditDialog: React.FC<SmartRuleEditDialogProps> = ({
                                         <MenuItem value="">
                                             <em>Select...</em>
                                         </MenuItem>
-                                        {POSITION_OPTIONS.map((opt) => (
-                                            <MenuItem key={opt.value} value={opt.value}>
-                                                <Tooltip title={opt.description} placement="right">
-                                                    <span style={{ width: '100%' }}>{opt.label}</span>
-                                                </Tooltip>
+                                        {positions.map((position) => (
+                                            <MenuItem key={position} value={position}>
+                                                {POSITION_LABELS[position] || position}
                                             </MenuItem>
                                         ))}
                                     </Select>
@@ -274,10 +289,10 @@ const SmartRuleEditDialog: React.FC<SmartRuleEditDialogProps> = ({
                                         <MenuItem value="">
                                             <em>Select...</em>
                                         </MenuItem>
-                                        {OPERATION_OPTIONS[op.position]?.map((opt) => (
-                                            <MenuItem key={opt.value} value={opt.value}>
-                                                <Tooltip title={opt.description} placement="right">
-                                                    <span style={{ width: '100%' }}>{opt.label}</span>
+                                        {positionOperations.map((def) => (
+                                            <MenuItem key={def.operation} value={def.operation}>
+                                                <Tooltip title={def.meta?.description || ''} placement="right">
+                                                    <span style={{ width: '100%' }}>{OPERATION_LABELS[def.operation] || def.operation}</span>
                                                 </Tooltip>
                                             </MenuItem>
                                         ))}
@@ -311,6 +326,15 @@ const SmartRuleEditDialog: React.FC<SmartRuleEditDialogProps> = ({
                                     {op.meta.description}
                                 </Typography>
                             )}
+                            {op.position === 'time' && (
+                                <Typography
+                                    variant="caption"
+                                    color="text.secondary"
+                                    sx={{ mt: 1, display: 'block' }}
+                                >
+                                    Time windows and weekdays use the time zone of the server running Tingly Box, not the client's.
+                                </Typography>
+                            )}
                         </Box>
                     </Box>
                 </Stack>
@@ -120,6 +120,9 @@ export function useRuleAutoSave({ rule, onRuleChange, showNotification }: UseRul
                         })),
                     smart_enabled: newConfigRecord.smartEnabled || false,
                     smart_routing: newConfigRecord.smartRouting || [],
+                    session_affinity: newConfigRecord.sessionAffinity,
+                    failover: rule.failover,
+                    context_overflow: rule.context_overflow,
                 };
 
                 const result = await api.updateRule(rule.uuid, ruleData);
@@ -134,6 +137,7 @@ export function useRuleAutoSave({ rule, onRuleChange, showNotification }: UseRul
                         services: ruleData.services,
                         smart_enabled: ruleData.smart_enabled,
                         smart_routing: ruleData.smart_routing,
+                        session_affinity: ruleData.session_affinity,
                     });
                     showNotification('Configuration saved successfully', 'success');
                     return true;
@@ -51,6 +51,7 @@ export function ruleToConfigRecord(rule: Rule): ConfigRecord {
         description: rule.description,
         smartEnabled: rule.smart_enabled || false,
         smartRouting: smartRouting,
+        sessionAffinity: rule.session_affinity,
     };
 }
 
@@ -528,6 +528,10 @@ export const api = {
         }
     },
 
+    getSmartRoutingOperations: async (): Promise<any> => {
+        return fetchUIAPI('/smart-routing/operations');
+    },
+
     // Scenario API
     getScenarios: async (): Promise<any> => {
         return fetchUIAPI('/scenarios');
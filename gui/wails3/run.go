@@ -265,6 +265,8 @@ func (l *appLauncher) StartGUI(appManager *command.AppManager, opts options.Star
 		server.WithHTTPSRegenerate(opts.HTTPS.Regenerate),
 		server.WithRecordMode(recordMode),
 		server.WithRecordDir(opts.RecordDir),
+		server.WithRecordRetention(opts.RecordRetention),
+		server.WithSecretExec(opts.AllowSecretExec),
 		server.WithExperimentalFeatures(opts.ExperimentalFeatures),
 	)
 
@@ -316,6 +318,8 @@ func (l *appLauncher) StartTray(appManager *command.AppManager, opts options.Sta
 		server.WithHTTPSRegenerate(opts.HTTPS.Regenerate),
 		server.WithRecordMode(recordMode),
 		server.WithRecordDir(opts.RecordDir),
+		server.WithRecordRetention(opts.RecordRetention),
+		server.WithSecretExec(opts.AllowSecretExec),
 		server.WithExperimentalFeatures(opts.ExperimentalFeatures),
 	)
 
@@ -368,6 +372,8 @@ func (l *appLauncher) StartSlim(appManager *command.AppManager, opts options.Sta
 		server.WithHTTPSRegenerate(opts.HTTPS.Regenerate),
 		server.WithRecordMode(recordMode),
 		server.WithRecordDir(opts.RecordDir),
+		server.WithRecordRetention(opts.RecordRetention),
+		server.WithSecretExec(opts.AllowSecretExec),
 		server.WithExperimentalFeatures(opts.ExperimentalFeatures),
 	)
 
@@ -94,31 +94,31 @@ func (c *AnthropicClient) HttpClient() *http.Client {
 
 // MessagesNew creates a new message request
 func (c *AnthropicClient) MessagesNew(ctx context.Context, req anthropic.MessageNewParams) (*anthropic.Message, error) {
-	return c.client.Messages.New(ctx, req)
+	return c.client.Messages.New(WithUpstreamModel(ctx, string(req.Model)), req)
 }
 
 // MessagesNewStreaming creates a new streaming message request
 func (c *AnthropicClient) MessagesNewStreaming(ctx context.Context, req anthropic.MessageNewParams) *anthropicstream.Stream[anthropic.MessageStreamEventUnion] {
-	return c.client.Messages.NewStreaming(ctx, req)
+	return c.client.Messages.NewStreaming(WithUpstreamModel(ctx, string(req.Model)), req)
 }
 
 // MessagesCountTokens counts tokens for a message request
 func (c *AnthropicClient) MessagesCountTokens(ctx context.Context, req anthropic.MessageCountTokensParams) (*anthropic.MessageTokensCount, error) {
-	return c.client.Messages.CountTokens(ctx, req)
+	return c.client.Messages.CountTokens(WithUpstreamModel(ctx, string(req.Model)), req)
 }
 
 func (c *AnthropicClient) BetaMessagesCountTokens(ctx context.Context, req anthropic.BetaMessageCountTokensParams) (*anthropic.BetaMessageTokensCount, error) {
-	return c.client.Beta.Messages.CountTokens(ctx, req)
+	return c.client.Beta.Messages.CountTokens(WithUpstreamModel(ctx, string(req.Model)), req)
 }
 
 // BetaMessagesNew creates a new beta message request
 func (c *AnthropicClient) BetaMessagesNew(ctx context.Context, req anthropic.BetaMessageNewParams) (*anthropic.BetaMessage, error) {
-	return c.client.Beta.Messages.New(ctx, req)
+	return c.client.Beta.Messages.New(WithUpstreamModel(ctx, string(req.Model)), req)
 }
 
 // BetaMessagesNewStreaming creates a new beta streaming message request
 func (c *AnthropicClient) BetaMessagesNewStreaming(ctx context.Context, req anthropic.BetaMessageNewParams) *anthropicstream.Stream[anthropic.BetaRawMessageStreamEventUnion] {
-	return c.client.Beta.Messages.NewStreaming(ctx, req)
+	return c.client.Beta.Messages.NewStreaming(WithUpstreamModel(ctx, string(req.Model)), req)
 }
 
 // SetRecordSink sets the record sink for the client
@@ -104,12 +104,17 @@ func (c *GoogleClient) Client() *genai.Client {
 
 // GenerateContent generates content using the Google API
 func (c *GoogleClient) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
-	return c.client.Models.GenerateContent(ctx, model, contents, config)
+	return c.client.Models.GenerateContent(WithUpstreamModel(ctx, model), model, contents, config)
 }
 
 // GenerateContentStream generates content using streaming
 func (c *GoogleClient) GenerateContentStream(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) iter.Seq2[*genai.GenerateContentResponse, error] {
-	return c.client.Models.GenerateContentStream(ctx, model, contents, config)
+	return c.client.Models.GenerateContentStream(WithUpstreamModel(ctx, model), model, contents, config)
+}
+
+// EmbedContent creates embeddings using the Google API (embedContent / batchEmbedContents)
+func (c *GoogleClient) EmbedContent(ctx context.Context, model string, contents []*genai.Content, config *genai.EmbedContentConfig) (*genai.EmbedContentResponse, error) {
+	return c.client.Models.EmbedContent(WithUpstreamModel(ctx, model), model, contents, config)
 }
 
 // SetRecordSink sets the record sink for the client
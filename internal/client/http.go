@@ -542,5 +542,8 @@ func CreateHTTPClientForProvider(provider *typ.Provider) *http.Client {
 		}
 	}
 
+	// Track upstream rate limit headers for load balancing
+	client.Transport = newRateLimitRoundTripper(client.Transport, provider)
+
 	return client
 }
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// Cooldowns of a provider key rejected by the upstream
+const (
+	KeyAuthCooldown      = 10 * time.Minute // 401 / 403: key revoked or without access to the model
+	KeyRateLimitCooldown = time.Minute      // 429 without a retry-after hint
+)
+
+// KeyStats reports the usage of one provider key
+type KeyStats struct {
+	KeyID         string     `json:"key_id"`
+	Name          string     `json:"name,omitempty"`
+	Weight        int        `json:"weight"`
+	Requests      int64      `json:"requests"`
+	Errors        int64      `json:"errors"`
+	InputTokens   int64      `json:"input_tokens"`
+	OutputTokens  int64      `json:"output_tokens"`
+	LastStatus    int        `json:"last_status,omitempty"`
+	LastUsed      *time.Time `json:"last_used,omitempty"`
+	Available     bool       `json:"available"`
+	DisabledUntil *time.Time `json:"disabled_until,omitempty"`
+}
+
+// keyState is the rotation and usage state of one provider key
+type keyState struct {
+	stats         KeyStats
+	current       int // smooth weighted round-robin counter
+	disabledUntil time.Time
+}
+
+// keyRotation spreads requests over the keys of each provider and takes
+// keys rejected by the upstream out of rotation for a while
+type keyRotation struct {
+	mu     sync.Mutex
+	states map[string]map[string]*keyState // provider UUID -> key ID -> state
+}
+
+func newKeyRotation() *keyRotation {
+	return &keyRotation{states: make(map[string]map[string]*keyState)}
+}
+
+func (r *keyRotation) state(providerUUID, keyID string) *keyState {
+	keys, ok := r.states[providerUUID]
+	if !ok {
+		keys = make(map[string]*keyState)
+		r.states[providerUUID] = keys
+	}
+	st, ok := keys[keyID]
+	if !ok {
+		st = &keyState{stats: KeyStats{KeyID: keyID}}
+		keys[keyID] = st
+	}
+	return st
+}
+
+// selectKey picks the next key by smooth weighted round-robin among the keys that
+// are not cooling down. When every key is cooling down, the one that recovers first is used.
+func (r *keyRotation) selectKey(provider *typ.Provider, now time.Time) *typ.ProviderKey {
+	keys := provider.RotationKeys()
+	if len(keys) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var best *typ.ProviderKey
+	var bestState *keyState
+	total := 0
+	for _, k := range keys {
+		st := r.state(provider.UUID, k.ID)
+		if now.Before(st.disabledUntil) {
+			continue
+		}
+		st.current += k.GetWeight()
+		total += k.GetWeight()
+		if bestState == nil || st.current > bestState.current {
+			best, bestState = k, st
+		}
+	}
+	if bestState != nil {
+		bestState.current -= total
+		return best
+	}
+
+	for _, k := range keys {
+		st := r.state(provider.UUID, k.ID)
+		if bestState == nil || st.disabledUntil.Before(bestState.disabledUntil) {
+			best, bestState = k, st
+		}
+	}
+	return best
+}
+
+// record accounts a finished request to the key and returns whether the key was
+// taken out of rotation because of the upstream status
+func (r *keyRotation) record(provider *typ.Provider, status int, retryUntil time.Time, inputTokens, outputTokens int, failed bool, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := r.state(provider.UUID, provider.KeyID)
+	st.stats.Requests++
+	st.stats.InputTokens += int64(inputTokens)
+	st.stats.OutputTokens += int64(outputTokens)
+	st.stats.LastUsed = &now
+	if status != 0 {
+		st.stats.LastStatus = status
+	}
+	if failed {
+		st.stats.Errors++
+	}
+
+	var until time.Time
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		until = now.Add(KeyAuthCooldown)
+	case http.StatusTooManyRequests:
+		until = now.Add(KeyRateLimitCooldown)
+		if retryUntil.After(now) {
+			until = retryUntil
+		}
+	default:
+		return false
+	}
+
+	if until.After(st.disabledUntil) {
+		st.disabledUntil = until
+	}
+	logrus.Warnf("[keys] provider %s: key %s rejected with status %d, out of rotation until %s",
+		provider.Name, provider.KeyID, status, st.disabledUntil.Format(time.RFC3339))
+	return true
+}
+
+// hasAvailableKey reports whether the provider has a key that is not cooling down
+func (r *keyRotation) hasAvailableKey(provider *typ.Provider, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, k := range provider.RotationKeys() {
+		if !now.Before(r.state(provider.UUID, k.ID).disabledUntil) {
+			return true
+		}
+	}
+	return false
+}
+
+// stats returns the usage of every rotation key of the provider
+func (r *keyRotation) stats(provider *typ.Provider, now time.Time) []KeyStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := provider.RotationKeys()
+	result := make([]KeyStats, 0, len(keys))
+	for _, k := range keys {
+		st := r.state(provider.UUID, k.ID)
+		stats := st.stats
+		stats.Name = k.Name
+		stats.Weight = k.GetWeight()
+		stats.Available = !now.Before(st.disabledUntil)
+		if !stats.Available {
+			until := st.disabledUntil
+			stats.DisabledUntil = &until
+		}
+		result = append(result, stats)
+	}
+	return result
+}
+
+// SelectKey returns a copy of the provider bound to the next key of its rotation.
+// Providers without additional keys, and copies already bound to a key, are returned as-is.
+func (p *ClientPool) SelectKey(provider *typ.Provider) *typ.Provider {
+	if p == nil || provider == nil || provider.KeyID != "" || !provider.HasKeys() {
+		return provider
+	}
+	key := p.keys.selectKey(provider, time.Now())
+	if key == nil {
+		return provider
+	}
+	return provider.WithKey(key)
+}
+
+// RecordKeyResult accounts a finished request to the key the provider copy is bound to.
+// A key rejected with 401, 403 or 429 is taken out of rotation for a while, until
+// retryUntil for a 429 when the upstream sent a retry-after hint. Returns true when
+// the key was taken out of rotation and the provider still has other keys available,
+// so the failure is handled by the rotation rather than by the service health.
+func (p *ClientPool) RecordKeyResult(provider *typ.Provider, status int, retryUntil time.Time, inputTokens, outputTokens int, failed bool) bool {
+	if p == nil || provider == nil || provider.KeyID == "" {
+		return false
+	}
+	now := time.Now()
+	if !p.keys.record(provider, status, retryUntil, inputTokens, outputTokens, failed, now) {
+		return false
+	}
+	return p.keys.hasAvailableKey(provider, now)
+}
+
+// KeyStats returns the usage of each key of the provider
+func (p *ClientPool) KeyStats(provider *typ.Provider) []KeyStats {
+	return p.keys.stats(provider, time.Now())
+}
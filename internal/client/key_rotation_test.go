@@ -0,0 +1,119 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+func newMultiKeyProvider() *typ.Provider {
+	return &typ.Provider{
+		UUID:    "multi-key-uuid",
+		Name:    "multi-key",
+		Token:   "token-default",
+		APIBase: "https://api.openai.com/v1",
+		Keys: []*typ.ProviderKey{
+			{ID: "a", Token: "token-a", Weight: 2},
+			{ID: "b", Token: "token-b"},
+			{ID: "off", Token: "token-off", Disabled: true},
+		},
+	}
+}
+
+func TestKeyRotation_Weighted(t *testing.T) {
+	r := newKeyRotation()
+	provider := newMultiKeyProvider()
+	now := time.Now()
+
+	counts := map[string]int{}
+	for i := 0; i < 40; i++ {
+		counts[r.selectKey(provider, now).ID]++
+	}
+
+	if counts[typ.DefaultProviderKeyID] != 10 || counts["a"] != 20 || counts["b"] != 10 {
+		t.Errorf("Expected requests split 10/20/10 by weight, got %v", counts)
+	}
+	if counts["off"] != 0 {
+		t.Errorf("Expected disabled key to be skipped, got %d requests", counts["off"])
+	}
+}
+
+func TestKeyRotation_CooldownOnRejectedKey(t *testing.T) {
+	r := newKeyRotation()
+	provider := newMultiKeyProvider()
+	now := time.Now()
+
+	if !r.record(provider.WithKey(provider.Keys[0]), http.StatusTooManyRequests, now.Add(30*time.Second), 0, 0, true, now) {
+		t.Fatal("Expected 429 to take the key out of rotation")
+	}
+	if !r.record(provider.WithKey(provider.Keys[1]), http.StatusUnauthorized, time.Time{}, 0, 0, true, now) {
+		t.Fatal("Expected 401 to take the key out of rotation")
+	}
+	if r.record(provider.WithKey(provider.RotationKeys()[0]), http.StatusInternalServerError, time.Time{}, 0, 0, true, now) {
+		t.Fatal("Expected 500 to keep the key in rotation")
+	}
+
+	for i := 0; i < 5; i++ {
+		if id := r.selectKey(provider, now).ID; id != typ.DefaultProviderKeyID {
+			t.Fatalf("Expected only the default key while the others cool down, got %s", id)
+		}
+	}
+
+	// The 429 cooldown follows retry-after, the 401 cooldown is longer
+	later := now.Add(time.Minute)
+	seen := map[string]bool{}
+	for i := 0; i < 5; i++ {
+		seen[r.selectKey(provider, later).ID] = true
+	}
+	if !seen["a"] || seen["b"] {
+		t.Errorf("Expected key a back and key b still cooling down, got %v", seen)
+	}
+	if !r.hasAvailableKey(provider, now) {
+		t.Error("Expected the default key to be available")
+	}
+}
+
+func TestClientPool_KeyStats(t *testing.T) {
+	pool := NewClientPool()
+	provider := newMultiKeyProvider()
+
+	bound := pool.SelectKey(provider)
+	if bound.KeyID == "" || bound == provider {
+		t.Fatal("Expected a copy bound to a key")
+	}
+	if provider.Token != "token-default" {
+		t.Errorf("Expected the configured provider to be untouched, got %q", provider.Token)
+	}
+	if pool.SelectKey(bound) != bound {
+		t.Error("Expected a bound provider to keep its key")
+	}
+
+	pool.RecordKeyResult(bound, http.StatusOK, time.Time{}, 100, 20, false)
+	pool.RecordKeyResult(bound, http.StatusForbidden, time.Time{}, 0, 0, true)
+
+	stats := pool.KeyStats(provider)
+	if len(stats) != 3 {
+		t.Fatalf("Expected stats for 3 rotation keys, got %d", len(stats))
+	}
+	for _, st := range stats {
+		if st.KeyID != bound.KeyID {
+			if st.Requests != 0 || !st.Available {
+				t.Errorf("Expected unused key %s to be idle and available, got %+v", st.KeyID, st)
+			}
+			continue
+		}
+		if st.Requests != 2 || st.Errors != 1 || st.InputTokens != 100 || st.OutputTokens != 20 {
+			t.Errorf("Unexpected usage for key %s: %+v", st.KeyID, st)
+		}
+		if st.Available || st.DisabledUntil == nil || st.LastStatus != http.StatusForbidden {
+			t.Errorf("Expected key %s to be cooling down after 403, got %+v", st.KeyID, st)
+		}
+	}
+
+	single := &typ.Provider{UUID: "single", Token: "only"}
+	if pool.SelectKey(single) != single {
+		t.Error("Expected providers without keys to be returned as-is")
+	}
+}
@@ -104,22 +104,27 @@ func (c *OpenAIClient) HttpClient() *http.Client {
 
 // ChatCompletionsNew creates a new chat completion request
 func (c *OpenAIClient) ChatCompletionsNew(ctx context.Context, req openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
-	return c.client.Chat.Completions.New(ctx, req)
+	return c.client.Chat.Completions.New(WithUpstreamModel(ctx, string(req.Model)), req)
 }
 
 // ChatCompletionsNewStreaming creates a new streaming chat completion request
 func (c *OpenAIClient) ChatCompletionsNewStreaming(ctx context.Context, req openai.ChatCompletionNewParams) *ssestream.Stream[openai.ChatCompletionChunk] {
-	return c.client.Chat.Completions.NewStreaming(ctx, req)
+	return c.client.Chat.Completions.NewStreaming(WithUpstreamModel(ctx, string(req.Model)), req)
 }
 
 // ResponsesNew creates a new Responses API request
 func (c *OpenAIClient) ResponsesNew(ctx context.Context, req responses.ResponseNewParams) (*responses.Response, error) {
-	return c.client.Responses.New(ctx, req)
+	return c.client.Responses.New(WithUpstreamModel(ctx, string(req.Model)), req)
 }
 
 // ResponsesNewStreaming creates a new streaming Responses API request
 func (c *OpenAIClient) ResponsesNewStreaming(ctx context.Context, req responses.ResponseNewParams) *ssestream.Stream[responses.ResponseStreamEventUnion] {
-	return c.client.Responses.NewStreaming(ctx, req)
+	return c.client.Responses.NewStreaming(WithUpstreamModel(ctx, string(req.Model)), req)
+}
+
+// EmbeddingsNew creates a new embeddings request
+func (c *OpenAIClient) EmbeddingsNew(ctx context.Context, req openai.EmbeddingNewParams) (*openai.CreateEmbeddingResponse, error) {
+	return c.client.Embeddings.New(WithUpstreamModel(ctx, string(req.Model)), req)
 }
 
 // SetRecordSink sets the record sink for the client
@@ -10,6 +10,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/tingly-dev/tingly-box/internal/obs"
+	"github.com/tingly-dev/tingly-box/internal/secretref"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 )
 
@@ -33,6 +34,7 @@ type ClientPool struct {
 	mutex            sync.RWMutex
 	recordSink       *obs.Sink
 	clientTTL        time.Duration
+	keys             *keyRotation
 }
 
 // NewClientPool creates a new client pool
@@ -42,6 +44,7 @@ func NewClientPool() *ClientPool {
 		anthropicClients: make(map[string]*pooledClient),
 		googleClients:    make(map[string]*pooledClient),
 		clientTTL:        DefaultClientTTL,
+		keys:             newKeyRotation(),
 	}
 	// Start cleanup task for expired clients
 	pool.StartCleanupTask(DefaultCleanupInterval)
@@ -50,8 +53,15 @@ func NewClientPool() *ClientPool {
 
 // GetOpenAIClient returns an OpenAI client wrapper for the specified provider
 func (p *ClientPool) GetOpenAIClient(provider *typ.Provider, model string) *OpenAIClient {
+	// Bind the provider to the next of its keys, then resolve secret references
+	resolved, err := ResolveProvider(p.SelectKey(provider))
+	if err != nil {
+		logrus.Errorf("Failed to resolve token for provider %s: %v", provider.Name, err)
+		return nil
+	}
+
 	// Generate unique key for provider
-	key := p.generateProviderKey(provider, model)
+	key := p.generateProviderKey(resolved, model)
 
 	// Try to get existing client with read lock first
 	p.mutex.RLock()
@@ -80,7 +90,7 @@ func (p *ClientPool) GetOpenAIClient(provider *typ.Provider, model string) *Open
 	// Create new client using factory
 	logrus.Infof("Creating new OpenAI client for provider: %s (API: %s)", provider.Name, provider.APIBase)
 
-	client, err := NewOpenAIClient(provider)
+	client, err := NewOpenAIClient(resolved)
 	if err != nil {
 		logrus.Errorf("Failed to create OpenAI client for provider %s: %v", provider.Name, err)
 		return nil
@@ -101,8 +111,15 @@ func (p *ClientPool) GetOpenAIClient(provider *typ.Provider, model string) *Open
 
 // GetAnthropicClient returns an Anthropic client wrapper for the specified provider
 func (p *ClientPool) GetAnthropicClient(provider *typ.Provider, model string) *AnthropicClient {
+	// Bind the provider to the next of its keys, then resolve secret references
+	resolved, err := ResolveProvider(p.SelectKey(provider))
+	if err != nil {
+		logrus.Errorf("Failed to resolve token for provider %s: %v", provider.Name, err)
+		return nil
+	}
+
 	// Generate unique key for provider
-	key := p.generateProviderKey(provider, model)
+	key := p.generateProviderKey(resolved, model)
 
 	// Try to get existing client with read lock first
 	p.mutex.RLock()
@@ -131,7 +148,7 @@ func (p *ClientPool) GetAnthropicClient(provider *typ.Provider, model string) *A
 	// Create new client using factory
 	logrus.Infof("Creating new Anthropic client for provider: %s (API: %s) model: %s", provider.Name, provider.APIBase, model)
 
-	client, err := NewAnthropicClient(provider)
+	client, err := NewAnthropicClient(resolved)
 	if err != nil {
 		logrus.Errorf("Failed to create Anthropic client for provider %s: %v", provider.Name, err)
 		return nil
@@ -152,8 +169,15 @@ func (p *ClientPool) GetAnthropicClient(provider *typ.Provider, model string) *A
 
 // GetGoogleClient returns a Google client wrapper for the specified provider
 func (p *ClientPool) GetGoogleClient(provider *typ.Provider, model string) *GoogleClient {
+	// Bind the provider to the next of its keys, then resolve secret references
+	resolved, err := ResolveProvider(p.SelectKey(provider))
+	if err != nil {
+		logrus.Errorf("Failed to resolve token for provider %s: %v", provider.Name, err)
+		return nil
+	}
+
 	// Generate unique key for provider
-	key := p.generateProviderKey(provider, model)
+	key := p.generateProviderKey(resolved, model)
 
 	// Try to get existing client with read lock first
 	p.mutex.RLock()
@@ -182,7 +206,7 @@ func (p *ClientPool) GetGoogleClient(provider *typ.Provider, model string) *Goog
 	// Create new client using factory
 	logrus.Infof("Creating new Google client for provider: %s (API: %s)", provider.Name, provider.APIBase)
 
-	client, err := NewGoogleClient(provider)
+	client, err := NewGoogleClient(resolved)
 	if err != nil {
 		logrus.Errorf("Failed to create Google client for provider %s: %v", provider.Name, err)
 		return nil
@@ -201,7 +225,27 @@ func (p *ClientPool) GetGoogleClient(provider *typ.Provider, model string) *Goog
 	return client
 }
 
-// generateProviderKey creates a unique key for a provider
+// ResolveProvider returns the provider with a secret reference in its token (env:, file:, exec:)
+// resolved. A resolved provider is a copy, so the reference stays in the config and the
+// secret is never written back to it. A provider without a token of its own uses its first key.
+func ResolveProvider(provider *typ.Provider) (*typ.Provider, error) {
+	if provider.Token == "" && provider.KeyID == "" && provider.HasKeys() {
+		provider = provider.WithKey(provider.RotationKeys()[0])
+	}
+	if !secretref.IsRef(provider.Token) {
+		return provider, nil
+	}
+	token, err := secretref.Resolve(provider.Token)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *provider
+	resolved.Token = token
+	return &resolved, nil
+}
+
+// generateProviderKey creates a unique key for a provider.
+// The key hashes the resolved token so that a changed secret gets a new client after a config reload.
 func (p *ClientPool) generateProviderKey(provider *typ.Provider, model string) string {
 	return fmt.Sprintf("%s:%s:%s", provider.UUID, model, hashToken(provider.Token))
 }
@@ -230,6 +274,9 @@ func (p *ClientPool) Clear() {
 
 // RemoveProvider removes a specific provider's client from the pool
 func (p *ClientPool) RemoveProvider(provider *typ.Provider, model string) {
+	if resolved, err := ResolveProvider(provider); err == nil {
+		provider = resolved
+	}
 	key := p.generateProviderKey(provider, model)
 
 	p.mutex.Lock()
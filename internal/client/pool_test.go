@@ -3,6 +3,7 @@ package client
 import (
 	"testing"
 
+	"github.com/tingly-dev/tingly-box/internal/secretref"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 )
 
@@ -217,3 +218,44 @@ func TestClientPool_Stats(t *testing.T) {
 		t.Errorf("Expected 1 provider key, got %d", len(keys))
 	}
 }
+
+func TestClientPool_SecretRefToken(t *testing.T) {
+	pool := NewClientPool()
+	secretref.Reset()
+	t.Setenv("POOL_TEST_TOKEN", "resolved-token-1")
+
+	provider := &typ.Provider{
+		UUID:    "secret-ref-uuid",
+		Name:    "secret-ref-provider",
+		Token:   "env:POOL_TEST_TOKEN",
+		APIBase: "https://api.openai.com/v1",
+	}
+
+	client1 := pool.GetOpenAIClient(provider, "")
+	if client1 == nil {
+		t.Fatal("Expected non-nil client")
+	}
+	if client1.provider.Token != "resolved-token-1" {
+		t.Errorf("Expected resolved token, got %q", client1.provider.Token)
+	}
+	if provider.Token != "env:POOL_TEST_TOKEN" {
+		t.Errorf("Expected provider to keep the reference, got %q", provider.Token)
+	}
+
+	// A changed secret is picked up once the cached values are reset (config reload)
+	t.Setenv("POOL_TEST_TOKEN", "resolved-token-2")
+	if client2 := pool.GetOpenAIClient(provider, ""); client2 != client1 {
+		t.Error("Expected cached client before reload")
+	}
+	secretref.Reset()
+	client3 := pool.GetOpenAIClient(provider, "")
+	if client3 == client1 || client3.provider.Token != "resolved-token-2" {
+		t.Error("Expected a new client with the new secret after reload")
+	}
+
+	// Unresolvable references yield no client
+	missing := &typ.Provider{UUID: "missing-ref", Name: "missing", Token: "env:POOL_TEST_MISSING", APIBase: "https://api.openai.com/v1"}
+	if pool.GetOpenAIClient(missing, "") != nil {
+		t.Error("Expected nil client for an unresolvable token reference")
+	}
+}
@@ -0,0 +1,238 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// Rate limit kinds reported by the upstream providers
+var (
+	anthropicRateLimitKinds = []string{"requests", "tokens", "input-tokens", "output-tokens"}
+	openAIRateLimitKinds    = []string{"requests", "tokens"}
+)
+
+// ParseRateLimitHeaders parses the rate limit headers of an upstream response
+// for the given API style:
+//   - retry-after / retry-after-ms for every style
+//   - anthropic-ratelimit-{kind}-{limit,remaining,reset} for Anthropic
+//   - x-ratelimit-{limit,remaining,reset}-{kind} for OpenAI
+//
+// The headroom is taken from the most constrained limit. Returns false when
+// the response carries no rate limit information.
+func ParseRateLimitHeaders(style protocol.APIStyle, header http.Header, now time.Time) (loadbalance.UpstreamRateLimit, bool) {
+	rl := loadbalance.UpstreamRateLimit{Headroom: -1}
+	found := false
+
+	if retryAfter, ok := parseRetryAfter(header, now); ok {
+		rl.RetryUntil = now.Add(retryAfter)
+		found = true
+	}
+
+	observe := func(limitValue, remainingValue string, resetAt time.Time) {
+		limit, err := strconv.ParseFloat(strings.TrimSpace(limitValue), 64)
+		if err != nil || limit <= 0 {
+			return
+		}
+		remaining, err := strconv.ParseFloat(strings.TrimSpace(remainingValue), 64)
+		if err != nil {
+			return
+		}
+		headroom := remaining / limit
+		if headroom < 0 {
+			headroom = 0
+		}
+		if rl.Headroom < 0 || headroom < rl.Headroom {
+			rl.Headroom = headroom
+			rl.ResetAt = resetAt
+		}
+		found = true
+	}
+
+	switch style {
+	case protocol.APIStyleAnthropic:
+		for _, kind := range anthropicRateLimitKinds {
+			prefix := "anthropic-ratelimit-" + kind + "-"
+			var resetAt time.Time
+			if reset := header.Get(prefix + "reset"); reset != "" {
+				resetAt, _ = time.Parse(time.RFC3339, reset)
+			}
+			observe(header.Get(prefix+"limit"), header.Get(prefix+"remaining"), resetAt)
+		}
+	case protocol.APIStyleOpenAI:
+		for _, kind := range openAIRateLimitKinds {
+			var resetAt time.Time
+			if reset := header.Get("x-ratelimit-reset-" + kind); reset != "" {
+				if d, err := time.ParseDuration(reset); err == nil {
+					resetAt = now.Add(d)
+				}
+			}
+			observe(header.Get("x-ratelimit-limit-"+kind), header.Get("x-ratelimit-remaining-"+kind), resetAt)
+		}
+	}
+
+	return rl, found
+}
+
+// parseRetryAfter parses retry-after-ms, then retry-after as delay seconds or an HTTP date
+func parseRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if ms := header.Get("retry-after-ms"); ms != "" {
+		if v, err := strconv.ParseFloat(ms, 64); err == nil && v > 0 {
+			return time.Duration(v * float64(time.Millisecond)), true
+		}
+	}
+
+	value := strings.TrimSpace(header.Get("retry-after"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0, false
+		}
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now), true
+	}
+	return 0, false
+}
+
+// upstreamRateLimits keeps the last rate limit state reported for each provider key and
+// model. Upstream limits apply per API key, so the keys of a provider are tracked apart.
+var upstreamRateLimits = struct {
+	sync.RWMutex
+	entries map[string]loadbalance.UpstreamRateLimit
+}{entries: make(map[string]loadbalance.UpstreamRateLimit)}
+
+func upstreamRateLimitKey(providerUUID, keyID, model string) string {
+	return providerUUID + ":" + keyID + ":" + model
+}
+
+// LatestUpstreamRateLimit returns the last rate limit state reported by a provider for
+// a model and a key ("" for a provider without additional keys), falling back to the
+// state of requests whose model was unknown
+func LatestUpstreamRateLimit(providerUUID, keyID, model string) (loadbalance.UpstreamRateLimit, bool) {
+	upstreamRateLimits.RLock()
+	defer upstreamRateLimits.RUnlock()
+
+	return latestUpstreamRateLimit(providerUUID, keyID, model)
+}
+
+// latestUpstreamRateLimit is LatestUpstreamRateLimit without locking
+func latestUpstreamRateLimit(providerUUID, keyID, model string) (loadbalance.UpstreamRateLimit, bool) {
+	if rl, ok := upstreamRateLimits.entries[upstreamRateLimitKey(providerUUID, keyID, model)]; ok {
+		return rl, true
+	}
+	rl, ok := upstreamRateLimits.entries[upstreamRateLimitKey(providerUUID, keyID, "")]
+	return rl, ok
+}
+
+// ServiceUpstreamRateLimit returns the rate limit state of a provider model as a whole,
+// for the configured provider (not a copy bound to a key). With several keys, the service
+// is only throttled while every key is, until the first key recovers, and only near its
+// limit while every key is, with the headroom of the least constrained key.
+func ServiceUpstreamRateLimit(provider *typ.Provider, model string) (loadbalance.UpstreamRateLimit, bool) {
+	keyIDs := []string{""}
+	if provider.HasKeys() {
+		keyIDs = keyIDs[:0]
+		for _, k := range provider.RotationKeys() {
+			keyIDs = append(keyIDs, k.ID)
+		}
+	}
+
+	upstreamRateLimits.RLock()
+	defer upstreamRateLimits.RUnlock()
+
+	var service loadbalance.UpstreamRateLimit
+	for i, keyID := range keyIDs {
+		rl, ok := latestUpstreamRateLimit(provider.UUID, keyID, model)
+		if !ok {
+			// A key without rate limit state is not limited
+			return loadbalance.UpstreamRateLimit{}, false
+		}
+		if i == 0 {
+			service = rl
+			continue
+		}
+		if rl.RetryUntil.Before(service.RetryUntil) {
+			service.RetryUntil = rl.RetryUntil
+		}
+		if rl.Headroom < 0 || service.Headroom < 0 {
+			service.Headroom, service.ResetAt = -1, time.Time{}
+		} else if rl.Headroom > service.Headroom {
+			service.Headroom, service.ResetAt = rl.Headroom, rl.ResetAt
+		}
+	}
+	return service, true
+}
+
+func storeUpstreamRateLimit(providerUUID, keyID, model string, rl loadbalance.UpstreamRateLimit) {
+	upstreamRateLimits.Lock()
+	defer upstreamRateLimits.Unlock()
+
+	upstreamRateLimits.entries[upstreamRateLimitKey(providerUUID, keyID, model)] = rl
+}
+
+// rateLimitRoundTripper records the rate limit headers of every upstream response
+// so the load balancer can steer traffic away from throttled services
+type rateLimitRoundTripper struct {
+	http.RoundTripper
+	providerUUID string
+	keyID        string // Key the client is bound to, "" for a provider without additional keys
+	apiStyle     protocol.APIStyle
+}
+
+func newRateLimitRoundTripper(transport http.RoundTripper, provider *typ.Provider) *rateLimitRoundTripper {
+	return &rateLimitRoundTripper{
+		RoundTripper: transport,
+		providerUUID: provider.UUID,
+		keyID:        provider.KeyID,
+		apiStyle:     provider.APIStyle,
+	}
+}
+
+func (t *rateLimitRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.RoundTripper.RoundTrip(req)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+
+	if rl, ok := ParseRateLimitHeaders(t.apiStyle, resp.Header, time.Now()); ok {
+		storeUpstreamRateLimit(t.providerUUID, t.keyID, upstreamRequestModel(req), rl)
+	}
+	return resp, err
+}
+
+// upstreamModelKey is the context key of the model of the upstream requests
+type upstreamModelKey struct{}
+
+// WithUpstreamModel returns a context carrying the model the upstream requests made
+// with it are for, so their rate limit headers are stored for that model
+func WithUpstreamModel(ctx context.Context, model string) context.Context {
+	return context.WithValue(ctx, upstreamModelKey{}, model)
+}
+
+// upstreamRequestModel returns the model of an upstream request, as passed by the client
+// wrapper in the request context or from the Google-style path. Returns "" when unknown.
+func upstreamRequestModel(req *http.Request) string {
+	if model, ok := req.Context().Value(upstreamModelKey{}).(string); ok && model != "" {
+		return model
+	}
+
+	if idx := strings.Index(req.URL.Path, "/models/"); idx >= 0 {
+		model := req.URL.Path[idx+len("/models/"):]
+		if end := strings.IndexAny(model, ":/"); end >= 0 {
+			model = model[:end]
+		}
+		return model
+	}
+	return ""
+}
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+func TestParseRateLimitHeaders_Anthropic(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	header := http.Header{}
+	header.Set("anthropic-ratelimit-requests-limit", "100")
+	header.Set("anthropic-ratelimit-requests-remaining", "50")
+	header.Set("anthropic-ratelimit-requests-reset", "2025-01-01T12:00:30Z")
+	header.Set("anthropic-ratelimit-tokens-limit", "10000")
+	header.Set("anthropic-ratelimit-tokens-remaining", "500")
+	header.Set("anthropic-ratelimit-tokens-reset", "2025-01-01T12:01:00Z")
+
+	rl, ok := ParseRateLimitHeaders(protocol.APIStyleAnthropic, header, now)
+	if !ok {
+		t.Fatal("Expected rate limit headers to be parsed")
+	}
+	if rl.Headroom != 0.05 {
+		t.Errorf("Expected headroom of the most constrained limit = 0.05, got %v", rl.Headroom)
+	}
+	if want := now.Add(time.Minute); !rl.ResetAt.Equal(want) {
+		t.Errorf("Expected ResetAt = %v, got %v", want, rl.ResetAt)
+	}
+	if !rl.RetryUntil.IsZero() {
+		t.Errorf("Expected no retry-after window, got %v", rl.RetryUntil)
+	}
+}
+
+func TestParseRateLimitHeaders_OpenAI(t *testing.T) {
+	now := time.Now()
+	header := http.Header{}
+	header.Set("x-ratelimit-limit-requests", "60")
+	header.Set("x-ratelimit-remaining-requests", "3")
+	header.Set("x-ratelimit-reset-requests", "6m0s")
+	header.Set("x-ratelimit-limit-tokens", "150000")
+	header.Set("x-ratelimit-remaining-tokens", "149000")
+	header.Set("x-ratelimit-reset-tokens", "20ms")
+
+	rl, ok := ParseRateLimitHeaders(protocol.APIStyleOpenAI, header, now)
+	if !ok {
+		t.Fatal("Expected rate limit headers to be parsed")
+	}
+	if rl.Headroom != 0.05 {
+		t.Errorf("Expected headroom = 0.05, got %v", rl.Headroom)
+	}
+	if want := now.Add(6 * time.Minute); !rl.ResetAt.Equal(want) {
+		t.Errorf("Expected ResetAt = %v, got %v", want, rl.ResetAt)
+	}
+}
+
+func TestParseRateLimitHeaders_RetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   time.Duration
+	}{
+		{"seconds", "retry-after", "30", 30 * time.Second},
+		{"milliseconds", "retry-after-ms", "1500", 1500 * time.Millisecond},
+		{"http date", "retry-after", "Wed, 01 Jan 2025 12:02:00 GMT", 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(tt.header, tt.value)
+
+			rl, ok := ParseRateLimitHeaders(protocol.APIStyleGoogle, header, now)
+			if !ok {
+				t.Fatal("Expected retry-after to be parsed")
+			}
+			if want := now.Add(tt.want); !rl.RetryUntil.Equal(want) {
+				t.Errorf("Expected RetryUntil = %v, got %v", want, rl.RetryUntil)
+			}
+			if rl.Headroom >= 0 {
+				t.Errorf("Expected unknown headroom, got %v", rl.Headroom)
+			}
+		})
+	}
+}
+
+func TestParseRateLimitHeaders_None(t *testing.T) {
+	header := http.Header{}
+	header.Set("content-type", "application/json")
+	// OpenAI headers are ignored for Anthropic providers
+	header.Set("x-ratelimit-remaining-requests", "0")
+
+	if _, ok := ParseRateLimitHeaders(protocol.APIStyleAnthropic, header, time.Now()); ok {
+		t.Error("Expected no rate limit information")
+	}
+}
+
+func TestRateLimitRoundTripper_StoresByModel(t *testing.T) {
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{}
+		header.Set("retry-after", "10")
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Header: header, Body: http.NoBody}, nil
+	})
+	rt := &rateLimitRoundTripper{RoundTripper: transport, providerUUID: "rl-provider", apiStyle: protocol.APIStyleGoogle}
+
+	req, _ := http.NewRequest(http.MethodPost, "https://example.com/v1beta/models/gemini-pro:generateContent", http.NoBody)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip failed: %v", err)
+	}
+
+	rl, ok := LatestUpstreamRateLimit("rl-provider", "", "gemini-pro")
+	if !ok {
+		t.Fatal("Expected rate limit state to be stored for the model")
+	}
+	if !rl.RetryUntil.After(time.Now()) {
+		t.Errorf("Expected retry-after window in the future, got %v", rl.RetryUntil)
+	}
+	if _, ok := LatestUpstreamRateLimit("rl-provider", "", "other-model"); ok {
+		t.Error("Expected no rate limit state for another model")
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRateLimitRoundTripper_ModelFromContext(t *testing.T) {
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{}
+		header.Set("retry-after", "10")
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Header: header, Body: http.NoBody}, nil
+	})
+	rt := &rateLimitRoundTripper{RoundTripper: transport, providerUUID: "rl-ctx-provider", apiStyle: protocol.APIStyleOpenAI}
+
+	req, _ := http.NewRequestWithContext(WithUpstreamModel(context.Background(), "gpt-4o"), http.MethodPost,
+		"https://example.com/v1/chat/completions", strings.NewReader(`{"model":"ignored"}`))
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip failed: %v", err)
+	}
+
+	if _, ok := LatestUpstreamRateLimit("rl-ctx-provider", "", "gpt-4o"); !ok {
+		t.Fatal("Expected rate limit state to be stored for the model of the context")
+	}
+	if _, ok := upstreamRateLimits.entries[upstreamRateLimitKey("rl-ctx-provider", "", "ignored")]; ok {
+		t.Error("Expected the request body not to be read")
+	}
+}
+
+func TestServiceUpstreamRateLimit_AggregatesKeys(t *testing.T) {
+	provider := &typ.Provider{
+		UUID:  "rl-keys-provider",
+		Token: "token-default",
+		Keys:  []*typ.ProviderKey{{ID: "second", Token: "token-second"}},
+	}
+	now := time.Now()
+
+	// One throttled key leaves the service available
+	storeUpstreamRateLimit(provider.UUID, typ.DefaultProviderKeyID, "gpt-4o", loadbalance.UpstreamRateLimit{Headroom: 0, RetryUntil: now.Add(time.Minute)})
+	if _, ok := ServiceUpstreamRateLimit(provider, "gpt-4o"); ok {
+		t.Fatal("Expected no service rate limit while a key has none")
+	}
+	if rl, ok := LatestUpstreamRateLimit(provider.UUID, "second", "gpt-4o"); ok {
+		t.Fatalf("Expected the second key not to see the state of the first, got %+v", rl)
+	}
+
+	// Once every key is throttled, the service is until the first key recovers
+	storeUpstreamRateLimit(provider.UUID, "second", "gpt-4o", loadbalance.UpstreamRateLimit{Headroom: 0.05, RetryUntil: now.Add(30 * time.Second)})
+	rl, ok := ServiceUpstreamRateLimit(provider, "gpt-4o")
+	if !ok {
+		t.Fatal("Expected a service rate limit when every key is throttled")
+	}
+	if !rl.RetryUntil.Equal(now.Add(30*time.Second)) || rl.Headroom != 0.05 {
+		t.Errorf("Expected the earliest retry and the largest headroom, got %+v", rl)
+	}
+}
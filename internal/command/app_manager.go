@@ -1,16 +1,13 @@
 package command
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
-	"strings"
 
-	"github.com/google/uuid"
 	"github.com/tingly-dev/tingly-box/internal/config"
 	"github.com/tingly-dev/tingly-box/internal/loadbalance"
 	"github.com/tingly-dev/tingly-box/internal/protocol"
 	"github.com/tingly-dev/tingly-box/internal/server"
+	smartrouting "github.com/tingly-dev/tingly-box/internal/smart_routing"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 )
 
@@ -180,6 +177,15 @@ func (am *AppManager) HasModelToken() bool {
 // Import/Export Types
 // ============
 
+// ExportVersion is the version of the JSONL export format
+const ExportVersion = "1.0"
+
+// Export kinds, recorded in the metadata line
+const (
+	ExportKindRule   = "rule"   // A rule with the providers it uses
+	ExportKindBundle = "bundle" // All rules, providers, scenarios and tool interceptor settings
+)
+
 // ExportLine represents a generic line in the export file
 type ExportLine struct {
 	Type string `json:"type"`
@@ -189,60 +195,111 @@ type ExportLine struct {
 type ExportMetadata struct {
 	Type       string `json:"type"`
 	Version    string `json:"version"`
+	Kind       string `json:"kind,omitempty"`
 	ExportedAt string `json:"exported_at"`
+	Redacted   bool   `json:"redacted,omitempty"`
 }
 
 // ExportRuleData represents the rule export data
 type ExportRuleData struct {
-	Type          string                 `json:"type"`
-	UUID          string                 `json:"uuid"`
-	Scenario      string                 `json:"scenario"`
-	RequestModel  string                 `json:"request_model"`
-	ResponseModel string                 `json:"response_model"`
-	Description   string                 `json:"description"`
-	Services      []*loadbalance.Service `json:"services"`
-	LBTactic      typ.Tactic             `json:"lb_tactic"`
-	Active        bool                   `json:"active"`
-	SmartEnabled  bool                   `json:"smart_enabled"`
-	SmartRouting  []interface{}          `json:"smart_routing"`
+	Type            string                      `json:"type"`
+	UUID            string                      `json:"uuid"`
+	Scenario        string                      `json:"scenario"`
+	RequestModel    string                      `json:"request_model"`
+	ResponseModel   string                      `json:"response_model"`
+	Description     string                      `json:"description"`
+	Services        []*loadbalance.Service      `json:"services"`
+	LBTactic        typ.Tactic                  `json:"lb_tactic"`
+	Active          bool                        `json:"active"`
+	SmartEnabled    bool                        `json:"smart_enabled"`
+	SmartRouting    []smartrouting.SmartRouting `json:"smart_routing"`
+	Failover        *typ.FailoverConfig         `json:"failover,omitempty"`
+	SessionAffinity *typ.SessionAffinityConfig  `json:"session_affinity,omitempty"`
+	ContextOverflow *typ.ContextOverflowConfig  `json:"context_overflow,omitempty"`
 }
 
 // ExportProviderData represents the provider export data
 type ExportProviderData struct {
-	Type        string           `json:"type"`
-	UUID        string           `json:"uuid"`
-	Name        string           `json:"name"`
-	APIBase     string           `json:"api_base"`
-	APIStyle    string           `json:"api_style"`
-	AuthType    string           `json:"auth_type"`
-	Token       string           `json:"token"`
-	OAuthDetail *typ.OAuthDetail `json:"oauth_detail"`
-	Enabled     bool             `json:"enabled"`
-	ProxyURL    string           `json:"proxy_url"`
-	Timeout     int64            `json:"timeout"`
-	Tags        []string         `json:"tags"`
-	Models      []string         `json:"models"`
+	Type          string             `json:"type"`
+	UUID          string             `json:"uuid"`
+	Name          string             `json:"name"`
+	APIBase       string             `json:"api_base"`
+	APIStyle      string             `json:"api_style"`
+	AuthType      string             `json:"auth_type"`
+	Token         string             `json:"token"`
+	Keys          []*typ.ProviderKey `json:"keys,omitempty"`
+	NoKeyRequired bool               `json:"no_key_required,omitempty"`
+	OAuthDetail   *typ.OAuthDetail   `json:"oauth_detail"`
+	Enabled       bool               `json:"enabled"`
+	ProxyURL      string             `json:"proxy_url"`
+	Timeout       int64              `json:"timeout"`
+	Tags          []string           `json:"tags"`
+	Models        []string           `json:"models"`
+
+	ToolInterceptor         *typ.ToolInterceptorConfig   `json:"tool_interceptor,omitempty"`
+	ToolInterceptorOverride *typ.ToolInterceptorOverride `json:"tool_interceptor_override,omitempty"`
+}
+
+// ExportScenarioData represents the scenario configuration export data
+type ExportScenarioData struct {
+	Type string `json:"type"`
+	typ.ScenarioConfig
+}
+
+// ExportToolInterceptorData represents the global tool interceptor settings export data
+type ExportToolInterceptorData struct {
+	Type string `json:"type"`
+	typ.ToolInterceptorConfig
 }
 
 // ImportOptions controls how imports are handled when conflicts occur.
 type ImportOptions struct {
 	// OnProviderConflict specifies what to do when a provider already exists.
-	// "use" - use existing provider, "skip" - skip this provider, "suffix" - create with suffixed name
+	// "use" - use existing provider, "skip" - skip this provider, "suffix" - create with suffixed name,
+	// "overwrite" - replace the existing provider, keeping its credentials when the import has none
 	OnProviderConflict string
 	// OnRuleConflict specifies what to do when a rule already exists.
 	// "skip" - skip import, "update" - update existing rule, "new" - create with new name
 	OnRuleConflict string
+	// OnSettingsConflict specifies what to do with scenarios and tool interceptor settings
+	// that are already configured (bundle import only). "use" - keep existing, "overwrite" - replace
+	OnSettingsConflict string
 	// Quiet suppresses progress output
 	Quiet bool
 }
 
+// Conflict strategies offered by the import command
+const (
+	ConflictUse       = "use"       // Keep what already exists and reference it
+	ConflictRename    = "rename"    // Import under a new name next to what exists
+	ConflictOverwrite = "overwrite" // Replace what exists with the imported data
+)
+
+// ImportOptionsForConflict returns the import options for a conflict strategy
+func ImportOptionsForConflict(strategy string) (ImportOptions, error) {
+	switch strategy {
+	case ConflictUse, "":
+		return ImportOptions{OnProviderConflict: "use", OnRuleConflict: "skip", OnSettingsConflict: "use"}, nil
+	case ConflictRename:
+		return ImportOptions{OnProviderConflict: "suffix", OnRuleConflict: "new", OnSettingsConflict: "use"}, nil
+	case ConflictOverwrite:
+		return ImportOptions{OnProviderConflict: "overwrite", OnRuleConflict: "update", OnSettingsConflict: "overwrite"}, nil
+	}
+	return ImportOptions{}, fmt.Errorf("unknown conflict strategy '%s' (use, rename or overwrite)", strategy)
+}
+
 // ImportResult contains the results of an import operation.
 type ImportResult struct {
 	RuleCreated      bool
 	RuleUpdated      bool
 	ProvidersCreated int
 	ProvidersUsed    int
+	ProvidersUpdated int
 	ProviderMap      map[string]string // old UUID -> new UUID
+
+	// ProvidersWithoutCredentials lists the created providers that have no credentials,
+	// typically imported from a redacted export
+	ProvidersWithoutCredentials []string
 }
 
 // ImportRuleFromJSONL imports a rule from JSONL format (either file content or stdin format).
@@ -263,163 +320,30 @@ func (am *AppManager) ImportRuleFromJSONL(data string, opts ImportOptions) (*Imp
 		opts.OnRuleConflict = "skip"
 	}
 
-	// Parse lines
-	scanner := bufio.NewScanner(strings.NewReader(data))
-	var metadata *ExportMetadata
-	var ruleData *ExportRuleData
-	providersData := []*ExportProviderData{}
-
-	lineNum := 0
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue // Skip empty lines
-		}
-
-		// Parse line type
-		var base ExportLine
-		if err := json.Unmarshal([]byte(line), &base); err != nil {
-			return nil, fmt.Errorf("line %d: invalid JSON: %w", lineNum, err)
-		}
-
-		switch base.Type {
-		case "metadata":
-			if err := json.Unmarshal([]byte(line), &metadata); err != nil {
-				return nil, fmt.Errorf("line %d: invalid metadata: %w", lineNum, err)
-			}
-			if metadata.Version != "1.0" {
-				return nil, fmt.Errorf("unsupported export version: %s", metadata.Version)
-			}
-
-		case "rule":
-			if err := json.Unmarshal([]byte(line), &ruleData); err != nil {
-				return nil, fmt.Errorf("line %d: invalid rule data: %w", lineNum, err)
-			}
-
-		case "provider":
-			var provider ExportProviderData
-			if err := json.Unmarshal([]byte(line), &provider); err != nil {
-				return nil, fmt.Errorf("line %d: invalid provider data: %w", lineNum, err)
-			}
-			providersData = append(providersData, &provider)
-
-		default:
-			return nil, fmt.Errorf("line %d: unknown type '%s'", lineNum, base.Type)
-		}
+	bundle, err := parseExportJSONL(data)
+	if err != nil {
+		return nil, err
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading input: %w", err)
+	if bundle.metadata != nil && bundle.metadata.Kind == ExportKindBundle {
+		return nil, fmt.Errorf("input is a full config bundle, import it with --all")
 	}
-
-	if ruleData == nil {
+	if len(bundle.rules) == 0 {
 		return nil, fmt.Errorf("no rule data found in export")
 	}
 
 	globalConfig := am.appConfig.GetGlobalConfig()
+	snapshot := globalConfig.SnapshotRouting()
 
-	// Import providers
-	for _, p := range providersData {
-		// Check if provider with same name exists
-		existingProvider, err := globalConfig.GetProviderByName(p.Name)
-		if err == nil && existingProvider != nil {
-			switch opts.OnProviderConflict {
-			case "use":
-				result.ProviderMap[p.UUID] = existingProvider.UUID
-				result.ProvidersUsed++
-				continue
-			case "skip":
-				continue
-			case "suffix":
-				// Create with suffixed name
-				suffix := 2
-				newName := fmt.Sprintf("%s-%d", p.Name, suffix)
-				for {
-					_, err := globalConfig.GetProviderByName(newName)
-					if err != nil {
-						break // Name is available
-					}
-					suffix++
-					newName = fmt.Sprintf("%s-%d", p.Name, suffix)
-				}
-				p.Name = newName
-			default:
-				result.ProviderMap[p.UUID] = existingProvider.UUID
-				result.ProvidersUsed++
-				continue
-			}
-		}
-
-		// Create new provider
-		newProvider := &typ.Provider{
-			UUID:        uuid.New().String(),
-			Name:        p.Name,
-			APIBase:     p.APIBase,
-			APIStyle:    protocol.APIStyle(p.APIStyle),
-			AuthType:    typ.AuthType(p.AuthType),
-			Token:       p.Token,
-			OAuthDetail: p.OAuthDetail,
-			Enabled:     p.Enabled,
-			ProxyURL:    p.ProxyURL,
-			Timeout:     p.Timeout,
-			Tags:        p.Tags,
-			Models:      p.Models,
-		}
-
-		if err := globalConfig.AddProvider(newProvider); err != nil {
-			return nil, fmt.Errorf("failed to add provider '%s': %w", p.Name, err)
-		}
-
-		result.ProviderMap[p.UUID] = newProvider.UUID
-		result.ProvidersCreated++
+	if err := importProviders(globalConfig, bundle.providers, opts, result); err != nil {
+		return nil, restoreAfterFailedImport(globalConfig, snapshot, err)
 	}
 
-	// Check for existing rule
-	existingRule := globalConfig.GetRuleByRequestModelAndScenario(ruleData.RequestModel, typ.RuleScenario(ruleData.Scenario))
-
-	// Remap provider UUIDs in services
-	for i := range ruleData.Services {
-		if oldUUID, ok := result.ProviderMap[ruleData.Services[i].Provider]; ok {
-			ruleData.Services[i].Provider = oldUUID
-		}
-	}
-
-	// Create rule
-	rule := typ.Rule{
-		UUID:          uuid.New().String(),
-		Scenario:      typ.RuleScenario(ruleData.Scenario),
-		RequestModel:  ruleData.RequestModel,
-		ResponseModel: ruleData.ResponseModel,
-		Description:   ruleData.Description,
-		Services:      ruleData.Services,
-		LBTactic:      ruleData.LBTactic,
-		Active:        ruleData.Active,
-	}
-
-	if existingRule != nil {
-		switch opts.OnRuleConflict {
-		case "skip":
-			return result, nil
-		case "update":
-			rule.UUID = existingRule.UUID
-			if err := globalConfig.UpdateRule(existingRule.UUID, rule); err != nil {
-				return nil, fmt.Errorf("failed to update rule: %w", err)
-			}
-			result.RuleUpdated = true
-		case "new":
-			rule.RequestModel = fmt.Sprintf("%s-imported", ruleData.RequestModel)
-			if err := globalConfig.AddRule(rule); err != nil {
-				return nil, fmt.Errorf("failed to add rule: %w", err)
-			}
-			result.RuleCreated = true
-		}
-	} else {
-		if err := globalConfig.AddRule(rule); err != nil {
-			return nil, fmt.Errorf("failed to add rule: %w", err)
-		}
-		result.RuleCreated = true
+	// The rule line is expected once; the last one wins
+	created, updated, err := importRule(globalConfig, bundle.rules[len(bundle.rules)-1], opts, result.ProviderMap)
+	if err != nil {
+		return nil, restoreAfterFailedImport(globalConfig, snapshot, err)
 	}
+	result.RuleCreated, result.RuleUpdated = created, updated
 
 	return result, nil
 }
@@ -0,0 +1,621 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/secretref"
+	serverconfig "github.com/tingly-dev/tingly-box/internal/server/config"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// maxExportLineSize bounds a single JSONL line (providers may carry long model lists)
+const maxExportLineSize = 16 * 1024 * 1024
+
+// ExportOptions controls what an export contains
+type ExportOptions struct {
+	// RedactSecrets blanks provider API keys, OAuth tokens and the search API key.
+	// Secret references (env:, file:, exec:) are kept since they hold no secret.
+	RedactSecrets bool
+}
+
+// BundleImportResult contains the results of a full config bundle import
+type BundleImportResult struct {
+	ImportResult
+	RulesCreated            int
+	RulesUpdated            int
+	RulesSkipped            int
+	ScenariosImported       int
+	ToolInterceptorImported bool
+}
+
+// exportBundle holds the parsed lines of a JSONL export
+type exportBundle struct {
+	metadata        *ExportMetadata
+	rules           []*ExportRuleData
+	providers       []*ExportProviderData
+	scenarios       []*ExportScenarioData
+	toolInterceptor *ExportToolInterceptorData
+}
+
+// ============
+// Export
+// ============
+
+// ExportRuleToJSONL exports a rule with the providers its services use as JSONL:
+// metadata, rule, then one line per provider.
+func (am *AppManager) ExportRuleToJSONL(ruleUUID string, opts ExportOptions) (string, error) {
+	globalConfig := am.appConfig.GetGlobalConfig()
+
+	rule := globalConfig.GetRuleByUUID(ruleUUID)
+	if rule == nil {
+		return "", fmt.Errorf("rule with UUID '%s' not found", ruleUUID)
+	}
+
+	lines := []interface{}{
+		newExportMetadata(ExportKindRule, opts),
+		newExportRuleData(rule),
+	}
+	for _, providerUUID := range ruleProviderUUIDs(rule) {
+		provider, err := globalConfig.GetProviderByUUID(providerUUID)
+		if err != nil {
+			continue // Services may point at a provider that was deleted since
+		}
+		lines = append(lines, newExportProviderData(provider, opts))
+	}
+
+	return encodeJSONL(lines)
+}
+
+// ExportAllToJSONL exports the whole routing configuration as a JSONL bundle:
+// metadata, all providers, all rules, scenario configurations and the global
+// tool interceptor settings.
+func (am *AppManager) ExportAllToJSONL(opts ExportOptions) (string, error) {
+	globalConfig := am.appConfig.GetGlobalConfig()
+
+	lines := []interface{}{newExportMetadata(ExportKindBundle, opts)}
+	for _, provider := range globalConfig.ListProviders() {
+		lines = append(lines, newExportProviderData(provider, opts))
+	}
+	rules := globalConfig.GetRequestConfigs()
+	for i := range rules {
+		lines = append(lines, newExportRuleData(&rules[i]))
+	}
+	for _, scenario := range globalConfig.GetScenarios() {
+		lines = append(lines, &ExportScenarioData{Type: "scenario", ScenarioConfig: scenario})
+	}
+	if ti := globalConfig.GetToolInterceptorConfig(); ti != nil {
+		data := &ExportToolInterceptorData{Type: "tool_interceptor", ToolInterceptorConfig: *ti}
+		if opts.RedactSecrets {
+			data.SearchKey = redactSecret(data.SearchKey)
+		}
+		lines = append(lines, data)
+	}
+
+	return encodeJSONL(lines)
+}
+
+func newExportMetadata(kind string, opts ExportOptions) *ExportMetadata {
+	return &ExportMetadata{
+		Type:       "metadata",
+		Version:    ExportVersion,
+		Kind:       kind,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Redacted:   opts.RedactSecrets,
+	}
+}
+
+func newExportRuleData(rule *typ.Rule) *ExportRuleData {
+	return &ExportRuleData{
+		Type:            "rule",
+		UUID:            rule.UUID,
+		Scenario:        string(rule.GetScenario()),
+		RequestModel:    rule.RequestModel,
+		ResponseModel:   rule.ResponseModel,
+		Description:     rule.Description,
+		Services:        rule.Services,
+		LBTactic:        rule.LBTactic,
+		Active:          rule.Active,
+		SmartEnabled:    rule.SmartEnabled,
+		SmartRouting:    rule.SmartRouting,
+		Failover:        rule.Failover,
+		SessionAffinity: rule.SessionAffinity,
+		ContextOverflow: rule.ContextOverflow,
+	}
+}
+
+func newExportProviderData(provider *typ.Provider, opts ExportOptions) *ExportProviderData {
+	data := &ExportProviderData{
+		Type:          "provider",
+		UUID:          provider.UUID,
+		Name:          provider.Name,
+		APIBase:       provider.APIBase,
+		APIStyle:      string(provider.APIStyle),
+		AuthType:      string(provider.AuthType),
+		Token:         provider.Token,
+		Keys:          provider.Keys,
+		NoKeyRequired: provider.NoKeyRequired,
+		OAuthDetail:   provider.OAuthDetail,
+		Enabled:       provider.Enabled,
+		ProxyURL:      provider.ProxyURL,
+		Timeout:       provider.Timeout,
+		Tags:          provider.Tags,
+		Models:        provider.Models,
+
+		ToolInterceptor:         provider.ToolInterceptor,
+		ToolInterceptorOverride: provider.ToolInterceptorOverride,
+	}
+	if data.AuthType == "" {
+		data.AuthType = string(typ.AuthTypeAPIKey)
+	}
+
+	if opts.RedactSecrets {
+		data.Token = redactSecret(data.Token)
+		if provider.Keys != nil {
+			data.Keys = make([]*typ.ProviderKey, 0, len(provider.Keys))
+			for _, k := range provider.Keys {
+				if k == nil {
+					continue
+				}
+				key := *k
+				key.Token = redactSecret(key.Token)
+				data.Keys = append(data.Keys, &key)
+			}
+		}
+		if provider.OAuthDetail != nil {
+			detail := *provider.OAuthDetail
+			detail.AccessToken = redactSecret(detail.AccessToken)
+			detail.RefreshToken = redactSecret(detail.RefreshToken)
+			data.OAuthDetail = &detail
+		}
+		if provider.ToolInterceptor != nil {
+			settings := *provider.ToolInterceptor
+			settings.SearchKey = redactSecret(settings.SearchKey)
+			data.ToolInterceptor = &settings
+		}
+	}
+	return data
+}
+
+// redactSecret blanks a literal secret and keeps a secret reference
+func redactSecret(value string) string {
+	if secretref.IsRef(value) {
+		return value
+	}
+	return ""
+}
+
+// ruleProviderUUIDs returns the providers used by the services of a rule, including
+// its smart routing services, in order of first use
+func ruleProviderUUIDs(rule *typ.Rule) []string {
+	seen := make(map[string]bool)
+	var uuids []string
+	add := func(services []*loadbalance.Service) {
+		for _, svc := range services {
+			if svc != nil && svc.Provider != "" && !seen[svc.Provider] {
+				seen[svc.Provider] = true
+				uuids = append(uuids, svc.Provider)
+			}
+		}
+	}
+	add(rule.Services)
+	for _, sr := range rule.SmartRouting {
+		add(sr.Services)
+	}
+	return uuids
+}
+
+func encodeJSONL(lines []interface{}) (string, error) {
+	var builder strings.Builder
+	for _, line := range lines {
+		data, err := json.Marshal(line)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode export: %w", err)
+		}
+		builder.Write(data)
+		builder.WriteString("\n")
+	}
+	return builder.String(), nil
+}
+
+// ============
+// Import
+// ============
+
+// ImportBundleFromJSONL imports a full config bundle produced by ExportAllToJSONL.
+// Providers are imported first so that the rules can be remapped to them.
+func (am *AppManager) ImportBundleFromJSONL(data string, opts ImportOptions) (*BundleImportResult, error) {
+	result := &BundleImportResult{
+		ImportResult: ImportResult{ProviderMap: make(map[string]string)},
+	}
+
+	// Set defaults
+	if opts.OnProviderConflict == "" {
+		opts.OnProviderConflict = "use"
+	}
+	if opts.OnRuleConflict == "" {
+		opts.OnRuleConflict = "skip"
+	}
+	if opts.OnSettingsConflict == "" {
+		opts.OnSettingsConflict = "use"
+	}
+
+	bundle, err := parseExportJSONL(data)
+	if err != nil {
+		return nil, err
+	}
+
+	globalConfig := am.appConfig.GetGlobalConfig()
+	snapshot := globalConfig.SnapshotRouting()
+	if err := importBundle(globalConfig, bundle, opts, result); err != nil {
+		return nil, restoreAfterFailedImport(globalConfig, snapshot, err)
+	}
+	return result, nil
+}
+
+// importBundle applies the lines of a config bundle to the configuration
+func importBundle(globalConfig *serverconfig.Config, bundle *exportBundle, opts ImportOptions, result *BundleImportResult) error {
+	// Like the provider credentials, the search key must not be an exec: reference
+	if ti := bundle.toolInterceptor; ti != nil {
+		if err := secretref.CheckNoExec(ti.SearchKey); err != nil {
+			return fmt.Errorf("tool interceptor settings: %w", err)
+		}
+	}
+
+	if err := importProviders(globalConfig, bundle.providers, opts, &result.ImportResult); err != nil {
+		return err
+	}
+
+	for _, ruleData := range bundle.rules {
+		created, updated, err := importRule(globalConfig, ruleData, opts, result.ProviderMap)
+		if err != nil {
+			return fmt.Errorf("rule '%s': %w", ruleData.RequestModel, err)
+		}
+		switch {
+		case created:
+			result.RulesCreated++
+		case updated:
+			result.RulesUpdated++
+		default:
+			result.RulesSkipped++
+		}
+	}
+	result.RuleCreated = result.RulesCreated > 0
+	result.RuleUpdated = result.RulesUpdated > 0
+
+	overwrite := opts.OnSettingsConflict == ConflictOverwrite
+	for _, scenario := range bundle.scenarios {
+		if !overwrite && globalConfig.GetScenarioConfig(scenario.Scenario) != nil {
+			continue
+		}
+		if err := globalConfig.SetScenarioConfig(scenario.ScenarioConfig); err != nil {
+			return fmt.Errorf("failed to import scenario '%s': %w", scenario.Scenario, err)
+		}
+		result.ScenariosImported++
+	}
+
+	if ti := bundle.toolInterceptor; ti != nil {
+		existing := globalConfig.GetToolInterceptorConfig()
+		if existing == nil || overwrite {
+			settings := ti.ToolInterceptorConfig
+			if settings.SearchKey == "" && existing != nil {
+				// Redacted export: keep the search key already configured
+				settings.SearchKey = existing.SearchKey
+			}
+			if err := globalConfig.SetToolInterceptorConfig(&settings); err != nil {
+				return fmt.Errorf("failed to import tool interceptor settings: %w", err)
+			}
+			result.ToolInterceptorImported = true
+		}
+	}
+
+	return nil
+}
+
+// restoreAfterFailedImport undoes the part of an import applied before it failed, so that
+// an import is applied all-or-nothing
+func restoreAfterFailedImport(globalConfig *serverconfig.Config, snapshot *serverconfig.RoutingSnapshot, err error) error {
+	if restoreErr := globalConfig.RestoreRouting(snapshot); restoreErr != nil {
+		return fmt.Errorf("%w (restoring the previous configuration failed: %v)", err, restoreErr)
+	}
+	return err
+}
+
+// parseExportJSONL parses the lines of a rule export or a config bundle
+func parseExportJSONL(data string) (*exportBundle, error) {
+	bundle := &exportBundle{}
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxExportLineSize)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue // Skip empty lines
+		}
+
+		// Parse line type
+		var base ExportLine
+		if err := json.Unmarshal([]byte(line), &base); err != nil {
+			return nil, fmt.Errorf("line %d: invalid JSON: %w", lineNum, err)
+		}
+
+		switch base.Type {
+		case "metadata":
+			if err := json.Unmarshal([]byte(line), &bundle.metadata); err != nil {
+				return nil, fmt.Errorf("line %d: invalid metadata: %w", lineNum, err)
+			}
+			if bundle.metadata.Version != ExportVersion {
+				return nil, fmt.Errorf("unsupported export version: %s", bundle.metadata.Version)
+			}
+
+		case "rule":
+			var rule ExportRuleData
+			if err := json.Unmarshal([]byte(line), &rule); err != nil {
+				return nil, fmt.Errorf("line %d: invalid rule data: %w", lineNum, err)
+			}
+			bundle.rules = append(bundle.rules, &rule)
+
+		case "provider":
+			var provider ExportProviderData
+			if err := json.Unmarshal([]byte(line), &provider); err != nil {
+				return nil, fmt.Errorf("line %d: invalid provider data: %w", lineNum, err)
+			}
+			bundle.providers = append(bundle.providers, &provider)
+
+		case "scenario":
+			var scenario ExportScenarioData
+			if err := json.Unmarshal([]byte(line), &scenario); err != nil {
+				return nil, fmt.Errorf("line %d: invalid scenario data: %w", lineNum, err)
+			}
+			bundle.scenarios = append(bundle.scenarios, &scenario)
+
+		case "tool_interceptor":
+			if err := json.Unmarshal([]byte(line), &bundle.toolInterceptor); err != nil {
+				return nil, fmt.Errorf("line %d: invalid tool interceptor data: %w", lineNum, err)
+			}
+
+		default:
+			return nil, fmt.Errorf("line %d: unknown type '%s'", lineNum, base.Type)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading input: %w", err)
+	}
+	return bundle, nil
+}
+
+// importProviders adds the exported providers, resolving name conflicts as configured,
+// and records the UUID each exported provider maps to
+func importProviders(globalConfig *serverconfig.Config, providers []*ExportProviderData, opts ImportOptions, result *ImportResult) error {
+	// Reject exec: references before anything is imported: a bundle must not be able to
+	// make the server run commands
+	for _, p := range providers {
+		values := []string{p.Token}
+		for _, k := range p.Keys {
+			if k != nil {
+				values = append(values, k.Token)
+			}
+		}
+		if p.ToolInterceptor != nil {
+			values = append(values, p.ToolInterceptor.SearchKey)
+		}
+		if err := secretref.CheckNoExec(values...); err != nil {
+			return fmt.Errorf("provider '%s': %w", p.Name, err)
+		}
+	}
+
+	for _, p := range providers {
+		// Check if provider with same name exists
+		existingProvider, err := globalConfig.GetProviderByName(p.Name)
+		if err == nil && existingProvider != nil {
+			switch opts.OnProviderConflict {
+			case "skip":
+				continue
+			case "suffix":
+				// Create with suffixed name
+				suffix := 2
+				newName := fmt.Sprintf("%s-%d", p.Name, suffix)
+				for {
+					_, err := globalConfig.GetProviderByName(newName)
+					if err != nil {
+						break // Name is available
+					}
+					suffix++
+					newName = fmt.Sprintf("%s-%d", p.Name, suffix)
+				}
+				p.Name = newName
+			case "overwrite":
+				updated := p.toProvider(existingProvider.UUID)
+				keepExistingCredentials(updated, existingProvider)
+				keepExistingToolInterceptor(updated, existingProvider)
+				if err := globalConfig.UpdateProvider(existingProvider.UUID, updated); err != nil {
+					return fmt.Errorf("failed to update provider '%s': %w", p.Name, err)
+				}
+				result.ProviderMap[p.UUID] = existingProvider.UUID
+				result.ProvidersUpdated++
+				continue
+			default: // "use"
+				result.ProviderMap[p.UUID] = existingProvider.UUID
+				result.ProvidersUsed++
+				continue
+			}
+		}
+
+		// Create new provider
+		newProvider := p.toProvider(uuid.New().String())
+		if err := globalConfig.AddProvider(newProvider); err != nil {
+			return fmt.Errorf("failed to add provider '%s': %w", p.Name, err)
+		}
+
+		result.ProviderMap[p.UUID] = newProvider.UUID
+		result.ProvidersCreated++
+		if !hasCredentials(newProvider) {
+			result.ProvidersWithoutCredentials = append(result.ProvidersWithoutCredentials, newProvider.Name)
+		}
+	}
+	return nil
+}
+
+// hasCredentials reports whether a provider has something to authenticate with. A redacted
+// export leaves literal credentials out and keeps secret references.
+func hasCredentials(p *typ.Provider) bool {
+	if p.NoKeyRequired {
+		return true
+	}
+	if p.AuthType == typ.AuthTypeOAuth {
+		return p.OAuthDetail != nil && (p.OAuthDetail.AccessToken != "" || p.OAuthDetail.RefreshToken != "")
+	}
+	if p.Token != "" {
+		return true
+	}
+	for _, k := range p.Keys {
+		if k != nil && k.Token != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ExportProviderData) toProvider(providerUUID string) *typ.Provider {
+	return &typ.Provider{
+		UUID:          providerUUID,
+		Name:          p.Name,
+		APIBase:       p.APIBase,
+		APIStyle:      protocol.APIStyle(p.APIStyle),
+		AuthType:      typ.AuthType(p.AuthType),
+		Token:         p.Token,
+		Keys:          p.Keys,
+		NoKeyRequired: p.NoKeyRequired,
+		OAuthDetail:   p.OAuthDetail,
+		Enabled:       p.Enabled,
+		ProxyURL:      p.ProxyURL,
+		Timeout:       p.Timeout,
+		Tags:          p.Tags,
+		Models:        p.Models,
+
+		ToolInterceptor:         p.ToolInterceptor,
+		ToolInterceptorOverride: p.ToolInterceptorOverride,
+	}
+}
+
+// keepExistingCredentials fills the credentials a redacted export left blank from the existing provider
+func keepExistingCredentials(updated, existing *typ.Provider) {
+	if updated.Token == "" {
+		updated.Token = existing.Token
+	}
+	existingKeys := make(map[string]*typ.ProviderKey, len(existing.Keys))
+	for _, k := range existing.Keys {
+		if k != nil {
+			existingKeys[k.ID] = k
+		}
+	}
+	for _, k := range updated.Keys {
+		if k != nil && k.Token == "" {
+			if old, ok := existingKeys[k.ID]; ok {
+				k.Token = old.Token
+			}
+		}
+	}
+	if updated.OAuthDetail != nil && existing.OAuthDetail != nil {
+		if updated.OAuthDetail.AccessToken == "" {
+			updated.OAuthDetail.AccessToken = existing.OAuthDetail.AccessToken
+		}
+		if updated.OAuthDetail.RefreshToken == "" {
+			updated.OAuthDetail.RefreshToken = existing.OAuthDetail.RefreshToken
+		}
+	}
+}
+
+// keepExistingToolInterceptor keeps the tool interceptor settings of the existing provider
+// when an export has none, as exports made before they were exported, and the search key
+// a redacted export left blank
+func keepExistingToolInterceptor(updated, existing *typ.Provider) {
+	if updated.ToolInterceptor == nil && updated.ToolInterceptorOverride == nil {
+		updated.ToolInterceptor = existing.ToolInterceptor
+		updated.ToolInterceptorOverride = existing.ToolInterceptorOverride
+		return
+	}
+	if updated.ToolInterceptor != nil && updated.ToolInterceptor.SearchKey == "" && existing.ToolInterceptor != nil {
+		updated.ToolInterceptor.SearchKey = existing.ToolInterceptor.SearchKey
+	}
+}
+
+// importRule adds or updates a rule according to the rule conflict option, with its
+// services remapped to the imported providers. Returns whether the rule was created or updated.
+func importRule(globalConfig *serverconfig.Config, ruleData *ExportRuleData, opts ImportOptions, providerMap map[string]string) (created, updated bool, err error) {
+	// Remap provider UUIDs in services
+	remap := func(services []*loadbalance.Service) {
+		for _, svc := range services {
+			if svc == nil {
+				continue
+			}
+			if newUUID, ok := providerMap[svc.Provider]; ok {
+				svc.Provider = newUUID
+			}
+		}
+	}
+	remap(ruleData.Services)
+	for _, sr := range ruleData.SmartRouting {
+		remap(sr.Services)
+	}
+
+	rule := typ.Rule{
+		UUID:            uuid.New().String(),
+		Scenario:        typ.RuleScenario(ruleData.Scenario),
+		RequestModel:    ruleData.RequestModel,
+		ResponseModel:   ruleData.ResponseModel,
+		Description:     ruleData.Description,
+		Services:        ruleData.Services,
+		LBTactic:        ruleData.LBTactic,
+		Active:          ruleData.Active,
+		SmartEnabled:    ruleData.SmartEnabled,
+		SmartRouting:    ruleData.SmartRouting,
+		Failover:        ruleData.Failover,
+		SessionAffinity: ruleData.SessionAffinity,
+		ContextOverflow: ruleData.ContextOverflow,
+	}
+
+	existingRule := globalConfig.GetRuleByRequestModelAndScenario(ruleData.RequestModel, typ.RuleScenario(ruleData.Scenario))
+	if existingRule == nil {
+		if err := globalConfig.AddRule(rule); err != nil {
+			return false, false, fmt.Errorf("failed to add rule: %w", err)
+		}
+		return true, false, nil
+	}
+
+	switch opts.OnRuleConflict {
+	case "update":
+		rule.UUID = existingRule.UUID
+		if err := globalConfig.UpdateRule(existingRule.UUID, rule); err != nil {
+			return false, false, fmt.Errorf("failed to update rule: %w", err)
+		}
+		return false, true, nil
+	case "new":
+		rule.RequestModel = uniqueRequestModel(globalConfig, ruleData.RequestModel+"-imported")
+		if err := globalConfig.AddRule(rule); err != nil {
+			return false, false, fmt.Errorf("failed to add rule: %w", err)
+		}
+		return true, false, nil
+	}
+	return false, false, nil // "skip"
+}
+
+// uniqueRequestModel returns name, or name with the first free numeric suffix
+func uniqueRequestModel(globalConfig *serverconfig.Config, name string) string {
+	candidate := name
+	for suffix := 2; globalConfig.IsRequestModel(candidate); suffix++ {
+		candidate = fmt.Sprintf("%s-%d", name, suffix)
+	}
+	return candidate
+}
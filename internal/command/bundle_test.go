@@ -0,0 +1,262 @@
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+func newBundleTestManager(t *testing.T) *AppManager {
+	t.Helper()
+	am, err := NewAppManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create app manager: %v", err)
+	}
+	return am
+}
+
+func TestExportImportBundleRoundTrip(t *testing.T) {
+	source := newBundleTestManager(t)
+	sourceConfig := source.AppConfig().GetGlobalConfig()
+
+	provider := &typ.Provider{
+		UUID:     "source-provider",
+		Name:     "bundle-provider",
+		APIBase:  "https://api.example.com/v1",
+		APIStyle: "openai",
+		Token:    "sk-literal-secret",
+		Keys:     []*typ.ProviderKey{{ID: "backup", Token: "env:BUNDLE_TEST_KEY"}},
+		Enabled:  true,
+	}
+	if err := sourceConfig.AddProvider(provider); err != nil {
+		t.Fatalf("failed to add provider: %v", err)
+	}
+	rule := typ.Rule{
+		UUID:         "source-rule",
+		RequestModel: "bundle-model",
+		Services:     []*loadbalance.Service{{Provider: provider.UUID, Model: "gpt-4o", Weight: 1, Active: true}},
+		Active:       true,
+	}
+	if err := sourceConfig.AddRule(rule); err != nil {
+		t.Fatalf("failed to add rule: %v", err)
+	}
+
+	data, err := source.ExportAllToJSONL(ExportOptions{RedactSecrets: true})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if strings.Contains(data, "sk-literal-secret") {
+		t.Fatalf("expected redacted export to leave out the literal token")
+	}
+	if !strings.Contains(data, "env:BUNDLE_TEST_KEY") {
+		t.Fatalf("expected redacted export to keep the secret reference")
+	}
+
+	if _, err := newBundleTestManager(t).ImportRuleFromJSONL(data, ImportOptions{}); err == nil {
+		t.Fatalf("expected a bundle to be rejected by the single rule import")
+	}
+
+	target := newBundleTestManager(t)
+	result, err := target.ImportBundleFromJSONL(data, ImportOptions{})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if result.ProvidersCreated != 1 || result.RulesCreated == 0 {
+		t.Fatalf("unexpected import result: %+v", result)
+	}
+	if len(result.ProvidersWithoutCredentials) != 0 {
+		t.Fatalf("expected the secret reference to count as credentials, got %v", result.ProvidersWithoutCredentials)
+	}
+
+	targetConfig := target.AppConfig().GetGlobalConfig()
+	imported, err := targetConfig.GetProviderByName("bundle-provider")
+	if err != nil {
+		t.Fatalf("expected provider to be imported: %v", err)
+	}
+	if imported.UUID == provider.UUID {
+		t.Fatalf("expected imported provider to get a new UUID")
+	}
+	importedRule := targetConfig.GetRuleByRequestModelAndScenario("bundle-model", rule.GetScenario())
+	if importedRule == nil {
+		t.Fatalf("expected rule to be imported")
+	}
+	if importedRule.Services[0].Provider != imported.UUID {
+		t.Fatalf("expected rule service to point at %s, got %s", imported.UUID, importedRule.Services[0].Provider)
+	}
+
+	// Importing again reuses the provider and skips the existing rule
+	again, err := target.ImportBundleFromJSONL(data, ImportOptions{})
+	if err != nil {
+		t.Fatalf("second import failed: %v", err)
+	}
+	if again.ProvidersCreated != 0 || again.ProvidersUsed != 1 || again.RulesCreated != 0 {
+		t.Fatalf("unexpected second import result: %+v", again)
+	}
+}
+
+func TestExportRuleToJSONL(t *testing.T) {
+	am := newBundleTestManager(t)
+	if _, err := am.ExportRuleToJSONL("missing", ExportOptions{}); err == nil {
+		t.Fatalf("expected an error for an unknown rule")
+	}
+}
+
+func TestImportBundleRejectsExecReferences(t *testing.T) {
+	source := newBundleTestManager(t)
+	sourceConfig := source.AppConfig().GetGlobalConfig()
+	for _, p := range []*typ.Provider{
+		{UUID: "plain", Name: "plain-provider", APIBase: "https://api.example.com/v1", APIStyle: "openai", Token: "sk-plain", Enabled: true},
+		{UUID: "exec", Name: "exec-provider", APIBase: "https://api.example.com/v1", APIStyle: "openai", Token: "exec:pass show llm/openai", Enabled: true},
+	} {
+		if err := sourceConfig.AddProvider(p); err != nil {
+			t.Fatalf("failed to add provider: %v", err)
+		}
+	}
+
+	data, err := source.ExportAllToJSONL(ExportOptions{})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	target := newBundleTestManager(t)
+	if _, err := target.ImportBundleFromJSONL(data, ImportOptions{}); err == nil || !strings.Contains(err.Error(), "exec:") {
+		t.Fatalf("expected the exec: reference to be rejected, got %v", err)
+	}
+	if _, err := target.AppConfig().GetGlobalConfig().GetProviderByName("plain-provider"); err == nil {
+		t.Fatalf("expected no provider to be imported")
+	}
+	// The search key of the tool interceptor settings is checked the same way
+	interceptor, err := json.Marshal(ExportToolInterceptorData{
+		Type:                  "tool_interceptor",
+		ToolInterceptorConfig: typ.ToolInterceptorConfig{SearchKey: "exec:pass show search"},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal tool interceptor settings: %v", err)
+	}
+	if _, err := target.ImportBundleFromJSONL(string(interceptor), ImportOptions{}); err == nil || !strings.Contains(err.Error(), "exec:") {
+		t.Fatalf("expected the exec: search key to be rejected, got %v", err)
+	}
+	if target.AppConfig().GetGlobalConfig().GetToolInterceptorConfig() != nil {
+		t.Fatalf("expected no tool interceptor settings to be imported")
+	}
+}
+
+func TestImportRedactedBundleReportsMissingCredentials(t *testing.T) {
+	source := newBundleTestManager(t)
+	if err := source.AppConfig().GetGlobalConfig().AddProvider(&typ.Provider{
+		UUID: "literal", Name: "literal-provider", APIBase: "https://api.example.com/v1", APIStyle: "openai", Token: "sk-literal", Enabled: true,
+	}); err != nil {
+		t.Fatalf("failed to add provider: %v", err)
+	}
+
+	data, err := source.ExportAllToJSONL(ExportOptions{RedactSecrets: true})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	result, err := newBundleTestManager(t).ImportBundleFromJSONL(data, ImportOptions{})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if len(result.ProvidersWithoutCredentials) != 1 || result.ProvidersWithoutCredentials[0] != "literal-provider" {
+		t.Fatalf("expected literal-provider to be reported without credentials, got %v", result.ProvidersWithoutCredentials)
+	}
+}
+
+func TestImportBundleIsAllOrNothing(t *testing.T) {
+	source := newBundleTestManager(t)
+	sourceConfig := source.AppConfig().GetGlobalConfig()
+	provider := &typ.Provider{UUID: "source-provider", Name: "bundle-provider", APIBase: "https://api.example.com/v1", APIStyle: "openai", Token: "sk-plain", Enabled: true}
+	if err := sourceConfig.AddProvider(provider); err != nil {
+		t.Fatalf("failed to add provider: %v", err)
+	}
+	if err := sourceConfig.AddRule(typ.Rule{
+		UUID:         "source-rule",
+		RequestModel: "bundle-model",
+		Services:     []*loadbalance.Service{{Provider: provider.UUID, Model: "gpt-4o", Weight: 1, Active: true}},
+		Active:       true,
+	}); err != nil {
+		t.Fatalf("failed to add rule: %v", err)
+	}
+
+	data, err := source.ExportAllToJSONL(ExportOptions{})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	// A second rule with the same request model in another scenario fails to be added,
+	// after the provider and the first rule were
+	clash, err := json.Marshal(ExportRuleData{Type: "rule", Scenario: string(typ.ScenarioClaudeCode), RequestModel: "bundle-model", Active: true})
+	if err != nil {
+		t.Fatalf("failed to marshal rule: %v", err)
+	}
+	data += "\n" + string(clash) + "\n"
+
+	target := newBundleTestManager(t)
+	targetConfig := target.AppConfig().GetGlobalConfig()
+	providers, rules := len(targetConfig.ListProviders()), len(targetConfig.GetRequestConfigs())
+
+	if _, err := target.ImportBundleFromJSONL(data, ImportOptions{}); err == nil {
+		t.Fatalf("expected the clashing rule to fail the import")
+	}
+	if _, err := targetConfig.GetProviderByName("bundle-provider"); err == nil {
+		t.Fatalf("expected the imported provider to be rolled back")
+	}
+	if len(targetConfig.ListProviders()) != providers || len(targetConfig.GetRequestConfigs()) != rules {
+		t.Fatalf("expected the configuration to be left unchanged")
+	}
+}
+
+func TestImportBundleProviderToolInterceptor(t *testing.T) {
+	newProvider := func(settings *typ.ToolInterceptorConfig) *typ.Provider {
+		return &typ.Provider{
+			UUID: "interceptor", Name: "interceptor-provider", APIBase: "https://api.example.com/v1", APIStyle: "openai",
+			Token: "sk-plain", Enabled: true, ToolInterceptor: settings,
+		}
+	}
+
+	source := newBundleTestManager(t)
+	if err := source.AppConfig().GetGlobalConfig().AddProvider(newProvider(&typ.ToolInterceptorConfig{SearchAPI: "brave", SearchKey: "sk-search"})); err != nil {
+		t.Fatalf("failed to add provider: %v", err)
+	}
+	data, err := source.ExportAllToJSONL(ExportOptions{RedactSecrets: true})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if strings.Contains(data, "sk-search") {
+		t.Fatalf("expected redacted export to leave out the search key")
+	}
+
+	// Overwriting keeps the search key the redacted export left out
+	target := newBundleTestManager(t)
+	targetConfig := target.AppConfig().GetGlobalConfig()
+	if err := targetConfig.AddProvider(newProvider(&typ.ToolInterceptorConfig{SearchAPI: "google", SearchKey: "sk-target"})); err != nil {
+		t.Fatalf("failed to add provider: %v", err)
+	}
+	if _, err := target.ImportBundleFromJSONL(data, ImportOptions{OnProviderConflict: "overwrite"}); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	imported, err := targetConfig.GetProviderByName("interceptor-provider")
+	if err != nil {
+		t.Fatalf("expected provider to exist: %v", err)
+	}
+	if imported.ToolInterceptor == nil || imported.ToolInterceptor.SearchAPI != "brave" || imported.ToolInterceptor.SearchKey != "sk-target" {
+		t.Fatalf("expected imported tool interceptor settings with the existing search key, got %+v", imported.ToolInterceptor)
+	}
+
+	// A provider search key that is an exec: reference is rejected
+	execSource := newBundleTestManager(t)
+	if err := execSource.AppConfig().GetGlobalConfig().AddProvider(newProvider(&typ.ToolInterceptorConfig{SearchKey: "exec:pass show search"})); err != nil {
+		t.Fatalf("failed to add provider: %v", err)
+	}
+	execData, err := execSource.ExportAllToJSONL(ExportOptions{})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if _, err := newBundleTestManager(t).ImportBundleFromJSONL(execData, ImportOptions{}); err == nil || !strings.Contains(err.Error(), "exec:") {
+		t.Fatalf("expected the exec: search key to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	serverconfig "github.com/tingly-dev/tingly-box/internal/server/config"
+)
+
+// EncryptionCommand manages the encryption of provider credentials in the config file
+func EncryptionCommand(appManager *AppManager) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "encryption",
+		Short: "Manage encryption of provider credentials at rest",
+		Long: `Manage the encryption of provider API keys and OAuth tokens in config.json.
+Credentials are encrypted with a provider key held outside the config file,
+either in a key file (default: provider.key in the config directory) or in the OS keyring.
+
+Examples:
+  tingly-box encryption enable
+  tingly-box encryption enable --keyring
+  tingly-box encryption rotate-key
+  tingly-box encryption disable`,
+	}
+
+	cmd.AddCommand(encryptionStatusCommand(appManager))
+	cmd.AddCommand(encryptionEnableCommand(appManager))
+	cmd.AddCommand(encryptionDisableCommand(appManager))
+	cmd.AddCommand(encryptionRotateKeyCommand(appManager))
+
+	return cmd
+}
+
+func encryptionStatusCommand(appManager *AppManager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether provider credentials are encrypted",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			globalConfig := appManager.AppConfig().GetGlobalConfig()
+			if globalConfig.IsProviderEncryptionEnabled() {
+				fmt.Println("Provider credentials are encrypted")
+			} else {
+				fmt.Println("Provider credentials are stored in plaintext")
+			}
+			fmt.Printf("Provider key: %s\n", globalConfig.GetProviderKeyLocation())
+			return nil
+		},
+	}
+}
+
+func encryptionEnableCommand(appManager *AppManager) *cobra.Command {
+	var useKeyring bool
+
+	cmd := &cobra.Command{
+		Use:   "enable",
+		Short: "Encrypt provider credentials, migrating the existing ones",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			source := serverconfig.ProviderKeySourceFile
+			if useKeyring {
+				source = serverconfig.ProviderKeySourceKeyring
+			}
+
+			globalConfig := appManager.AppConfig().GetGlobalConfig()
+			if err := globalConfig.EnableProviderEncryption(source); err != nil {
+				return fmt.Errorf("failed to enable encryption: %w", err)
+			}
+
+			fmt.Println("Provider credentials are now encrypted")
+			fmt.Printf("Provider key: %s\n", globalConfig.GetProviderKeyLocation())
+			fmt.Println("Keep a backup of the key: without it the credentials cannot be decrypted.")
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&useKeyring, "keyring", false, "hold the provider key in the OS keyring instead of a key file")
+
+	return cmd
+}
+
+func encryptionDisableCommand(appManager *AppManager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "disable",
+		Short: "Store provider credentials in plaintext again",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := appManager.AppConfig().GetGlobalConfig().DisableProviderEncryption(); err != nil {
+				return fmt.Errorf("failed to disable encryption: %w", err)
+			}
+
+			fmt.Println("Provider credentials are now stored in plaintext")
+			return nil
+		},
+	}
+}
+
+func encryptionRotateKeyCommand(appManager *AppManager) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate-key",
+		Short: "Generate a new provider key and re-encrypt all credentials",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldKeyID, newKeyID, err := appManager.AppConfig().GetGlobalConfig().RotateProviderKey()
+			if err != nil {
+				return fmt.Errorf("failed to rotate provider key: %w", err)
+			}
+
+			fmt.Printf("Provider key rotated: %s -> %s\n", oldKeyID, newKeyID)
+			return nil
+		},
+	}
+}
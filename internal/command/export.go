@@ -0,0 +1,88 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ExportRuleCommand represents the export rule command
+func ExportRuleCommand(appManager *AppManager) *cobra.Command {
+	var output string
+	var redact bool
+
+	cmd := &cobra.Command{
+		Use:   "export-rule <uuid>",
+		Short: "Export a rule with its providers to JSONL",
+		Long: `Export a routing rule with the providers its services use as JSONL.
+The output can be imported on another machine with the import command:
+  tingly-box export-rule <uuid> | tingly-box import
+
+Use --redact-secrets to leave out API keys and OAuth tokens. Secret
+references (env:, file:, exec:) are always kept.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := appManager.ExportRuleToJSONL(strings.TrimSpace(args[0]), ExportOptions{RedactSecrets: redact})
+			if err != nil {
+				return err
+			}
+			return writeExport(data, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write to file instead of stdout")
+	cmd.Flags().BoolVar(&redact, "redact-secrets", false, "Leave out API keys and OAuth tokens")
+
+	return cmd
+}
+
+// ExportCommand represents the export command
+func ExportCommand(appManager *AppManager) *cobra.Command {
+	var output string
+	var redact bool
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "export --all",
+		Short: "Export the full configuration bundle to JSONL",
+		Long: `Export all providers, rules, scenario settings and tool interceptor
+settings as a JSONL bundle, for backup or to move to another machine:
+  tingly-box export --all -o backup.jsonl
+  tingly-box import --all backup.jsonl
+
+Use --redact-secrets to leave out API keys, OAuth tokens and the search
+API key. Secret references (env:, file:, exec:) are always kept.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !all {
+				return fmt.Errorf("use --all to export the full configuration, or export-rule <uuid> for a single rule")
+			}
+			data, err := appManager.ExportAllToJSONL(ExportOptions{RedactSecrets: redact})
+			if err != nil {
+				return err
+			}
+			return writeExport(data, output)
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Export providers, rules, scenarios and tool interceptor settings")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write to file instead of stdout")
+	cmd.Flags().BoolVar(&redact, "redact-secrets", false, "Leave out API keys, OAuth tokens and the search API key")
+
+	return cmd
+}
+
+func writeExport(data, output string) error {
+	if output == "" {
+		fmt.Print(data)
+		return nil
+	}
+	// The export may carry credentials, keep it private
+	if err := os.WriteFile(output, []byte(data), 0600); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported to %s\n", output)
+	return nil
+}
@@ -1,8 +1,8 @@
 package command
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -11,6 +11,9 @@ import (
 
 // ImportCommand represents the import rule command
 func ImportCommand(appManager *AppManager) *cobra.Command {
+	var all bool
+	var onConflict string
+
 	cmd := &cobra.Command{
 		Use:   "import [file.jsonl]",
 		Short: "Import a rule with providers from a JSONL file",
@@ -20,48 +23,79 @@ The file should contain line-delimited JSON with:
   - Line 2: rule data (type="rule")
   - Subsequent lines: provider data (type="provider")
 
+With --all, imports a full configuration bundle written by export --all:
+providers, rules, scenario settings and tool interceptor settings.
+
+--on-conflict decides what happens to providers, rules and settings that
+already exist:
+  use       - keep the existing ones and reference them (default)
+  rename    - import next to them under a new name
+  overwrite - replace them with the imported data (credentials left out
+              of a redacted export are kept)
+
+Providers created without credentials, as from a redacted export, are
+reported so that their keys can be set. The import is applied
+all-or-nothing: if any part fails, the configuration is left unchanged.
+
 If no file is specified, reads from stdin for pipe-friendly operation:
   cat export.jsonl | tingly-box import
   tingly-box export-rule <uuid> | tingly-box import`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runImport(appManager, args)
+			return runImport(appManager, args, all, onConflict)
 		},
 	}
 
+	cmd.Flags().BoolVar(&all, "all", false, "Import a full configuration bundle")
+	cmd.Flags().StringVar(&onConflict, "on-conflict", ConflictUse, "Conflict strategy: use, rename or overwrite")
+
 	return cmd
 }
 
-func runImport(appManager *AppManager, args []string) error {
-	var data string
+func runImport(appManager *AppManager, args []string, all bool, onConflict string) error {
+	opts, err := ImportOptionsForConflict(onConflict)
+	if err != nil {
+		return err
+	}
 
+	var content []byte
 	if len(args) > 0 {
 		// Read from file
-		content, err := os.ReadFile(args[0])
+		content, err = os.ReadFile(args[0])
 		if err != nil {
 			return fmt.Errorf("failed to open file: %w", err)
 		}
-		data = string(content)
 	} else {
 		// Read from stdin
-		scanner := bufio.NewScanner(os.Stdin)
-		var builder strings.Builder
-		for scanner.Scan() {
-			builder.WriteString(scanner.Text())
-			builder.WriteString("\n")
-		}
-		if err := scanner.Err(); err != nil {
+		content, err = io.ReadAll(os.Stdin)
+		if err != nil {
 			return fmt.Errorf("error reading input: %w", err)
 		}
-		data = builder.String()
 	}
+	data := string(content)
 
-	// Import using AppManager with defaults for conflicts
-	result, err := appManager.ImportRuleFromJSONL(data, ImportOptions{
-		OnProviderConflict: "use",  // Use existing provider by default
-		OnRuleConflict:     "skip", // Skip existing rules by default
-		Quiet:              false,
-	})
+	if all {
+		result, err := appManager.ImportBundleFromJSONL(data, opts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\nImport completed!\n")
+		fmt.Printf("✓ Providers created: %d, updated: %d, reused: %d\n",
+			result.ProvidersCreated, result.ProvidersUpdated, result.ProvidersUsed)
+		fmt.Printf("✓ Rules created: %d, updated: %d, skipped: %d\n",
+			result.RulesCreated, result.RulesUpdated, result.RulesSkipped)
+		if result.ScenariosImported > 0 {
+			fmt.Printf("✓ Scenarios imported: %d\n", result.ScenariosImported)
+		}
+		if result.ToolInterceptorImported {
+			fmt.Println("✓ Tool interceptor settings imported")
+		}
+		printMissingCredentials(result.ProvidersWithoutCredentials)
+		return nil
+	}
 
+	result, err := appManager.ImportRuleFromJSONL(data, opts)
 	if err != nil {
 		return err
 	}
@@ -77,9 +111,22 @@ func runImport(appManager *AppManager, args []string) error {
 	if result.ProvidersCreated > 0 {
 		fmt.Printf("✓ Providers created: %d\n", result.ProvidersCreated)
 	}
+	if result.ProvidersUpdated > 0 {
+		fmt.Printf("✓ Providers updated: %d\n", result.ProvidersUpdated)
+	}
 	if result.ProvidersUsed > 0 {
 		fmt.Printf("ℹ Providers reused: %d\n", result.ProvidersUsed)
 	}
+	printMissingCredentials(result.ProvidersWithoutCredentials)
 
 	return nil
 }
+
+// printMissingCredentials warns about imported providers that cannot authenticate yet
+func printMissingCredentials(providers []string) {
+	if len(providers) == 0 {
+		return
+	}
+	fmt.Printf("⚠ Providers imported without credentials (redacted export): %s\n", strings.Join(providers, ", "))
+	fmt.Println("  Set their API keys or log in again before routing requests to them")
+}
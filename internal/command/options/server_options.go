@@ -1,10 +1,13 @@
 package options
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 
 	"github.com/tingly-dev/tingly-box/internal/config"
 	"github.com/tingly-dev/tingly-box/internal/feature"
+	"github.com/tingly-dev/tingly-box/internal/obs"
 )
 
 // StartFlags holds flags for starting the server
@@ -23,6 +26,10 @@ type StartFlags struct {
 	HTTPSRegen           bool
 	RecordMode           string
 	RecordDir            string
+	RecordMaxFileSize    int64 // MB
+	RecordMaxAge         int   // days
+	RecordMaxTotalSize   int64 // MB
+	AllowSecretExec      bool
 	Expr                 string
 }
 
@@ -43,6 +50,8 @@ type StartServerOptions struct {
 	}
 	RecordMode           string
 	RecordDir            string
+	RecordRetention      obs.RetentionPolicy
+	AllowSecretExec      bool
 	ExperimentalFeatures map[string]bool
 }
 
@@ -61,8 +70,12 @@ func AddStartFlags(cmd *cobra.Command, flags *StartFlags) {
 	cmd.Flags().BoolVar(&flags.HTTPS, "https", false, "Enable HTTPS mode with self-signed certificate (default: false)")
 	cmd.Flags().StringVar(&flags.HTTPSCertDir, "https-cert-dir", "", "Certificate directory for HTTPS (default: ~/.tingly-box/certs/)")
 	cmd.Flags().BoolVar(&flags.HTTPSRegen, "https-regen", false, "Regenerate HTTPS certificate (default: false)")
-	cmd.Flags().StringVar(&flags.RecordMode, "record-mode", "", "Record mode: empty=disabled, 'all'=record request+response, 'scenario'=all but for scenario only, 'response'=response only (default: disabled)")
+	cmd.Flags().StringVar(&flags.RecordMode, "record-mode", "", "Record mode: empty=disabled, 'all'=record request+response, 'scenario'=all but for scenario only, 'response'=response only, 'slim'=metadata, previews, usage and errors only (default: disabled)")
 	cmd.Flags().StringVar(&flags.RecordDir, "record-dir", "", "Record directory (default: ~/.tingly-box/record/)")
+	cmd.Flags().Int64Var(&flags.RecordMaxFileSize, "record-max-file-size", obs.DefaultRecordMaxFileBytes>>20, "Rotate record files once they reach this size in MB, 0=rotate daily only")
+	cmd.Flags().IntVar(&flags.RecordMaxAge, "record-max-age", int(obs.DefaultRecordMaxAge/(24*time.Hour)), "Purge rotated record files older than this many days, 0=keep")
+	cmd.Flags().Int64Var(&flags.RecordMaxTotalSize, "record-max-total-size", obs.DefaultRecordMaxTotalBytes>>20, "Purge the oldest rotated record files above this total size in MB, 0=unlimited")
+	cmd.Flags().BoolVar(&flags.AllowSecretExec, "allow-secret-exec", false, "Allow exec: secret references in the config file, which run a command to get a credential (default: false)")
 	cmd.Flags().StringVar(&flags.Expr, "expr", "", "Enable experimental features (comma-separated, e.g., compact,other)")
 }
 
@@ -88,6 +101,13 @@ func ResolveStartOptions(cmd *cobra.Command, flags StartFlags, appConfig *config
 		resolvedRecordDir = appConfig.ConfigDir() + "/record"
 	}
 
+	// Resolve record retention, sizes are given in MB and ages in days
+	recordRetention := obs.RetentionPolicy{
+		MaxFileBytes:  flags.RecordMaxFileSize << 20,
+		MaxAge:        time.Duration(flags.RecordMaxAge) * 24 * time.Hour,
+		MaxTotalBytes: flags.RecordMaxTotalSize << 20,
+	}
+
 	// Parse experimental features
 	experimentalFeatures := feature.ParseFeatures(flags.Expr)
 
@@ -111,6 +131,8 @@ func ResolveStartOptions(cmd *cobra.Command, flags StartFlags, appConfig *config
 		},
 		RecordMode:           flags.RecordMode,
 		RecordDir:            resolvedRecordDir,
+		RecordRetention:      recordRetention,
+		AllowSecretExec:      flags.AllowSecretExec,
 		ExperimentalFeatures: experimentalFeatures,
 	}
 }
@@ -228,6 +228,8 @@ func startServer(appManager *AppManager, opts options.StartServerOptions) error
 		server.WithHTTPSRegenerate(opts.HTTPS.Regenerate),
 		server.WithRecordMode(obs.RecordMode(opts.RecordMode)),
 		server.WithRecordDir(opts.RecordDir),
+		server.WithRecordRetention(opts.RecordRetention),
+		server.WithSecretExec(opts.AllowSecretExec),
 		server.WithExperimentalFeatures(opts.ExperimentalFeatures),
 	)
 
@@ -124,7 +124,8 @@ func (ac *AppConfig) Save() error {
 	encoder.SetEscapeHTML(false)
 	encoder.SetIndent("", "  ")
 
-	if err := encoder.Encode(ac.config); err != nil {
+	// Provider credentials are encrypted in the file when enabled
+	if err := ac.config.EncodeForFile(encoder.Encode); err != nil {
 		return fmt.Errorf("failed to marshal config with indentation: %w", err)
 	}
 
@@ -33,6 +33,9 @@ const (
 	// DefaultMaxTokens is the default max_tokens value for API requests
 	DefaultMaxTokens = 8192
 
+	// DefaultResponseStoreTTLHours is how long stored Responses API responses are kept (30 days)
+	DefaultResponseStoreTTLHours = 720
+
 	// Template cache constants
 
 )
@@ -40,8 +43,9 @@ const (
 const DBFileName = "tingly.db" // Unified SQLite database file
 
 // Load balancing threshold defaults
-const DefaultRequestThreshold = int64(10)  // Default request threshold for round-robin and hybrid tactics
-const DefaultTokenThreshold = int64(10000) // Default token threshold for token-based and hybrid tactics
+const DefaultRequestThreshold = int64(10)   // Default request threshold for round-robin and hybrid tactics
+const DefaultTokenThreshold = int64(10000)  // Default token threshold for token-based and hybrid tactics
+const DefaultLatencyToleranceMs = int64(50) // Default margin within which least-latency treats services as equally fast
 
 const ConfigDirName = ".tingly-box"
 
@@ -0,0 +1,160 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/tingly-dev/tingly-box/internal/constant"
+)
+
+// ResponseRecord is a stored Responses API response.
+// Input only holds the items of the request that created it; the earlier history is
+// rebuilt by following PreviousResponseID through the stored responses.
+type ResponseRecord struct {
+	ID                 string    `gorm:"primaryKey;column:id"`
+	Model              string    `gorm:"column:model"`
+	PreviousResponseID string    `gorm:"column:previous_response_id"`
+	APIKeyID           string    `gorm:"column:api_key_id;index"` // Named API key that created the response, empty for the model token
+	Input              string    `gorm:"column:input"`            // JSON array of input items
+	Output             string    `gorm:"column:output"`           // JSON array of output items
+	Response           string    `gorm:"column:response"`         // JSON of the response object
+	CreatedAt          time.Time `gorm:"column:created_at"`
+	ExpiresAt          time.Time `gorm:"column:expires_at;index"`
+}
+
+// TableName specifies the table name for ResponseRecord
+func (ResponseRecord) TableName() string {
+	return "responses"
+}
+
+// ResponseStore persists Responses API responses in SQLite using GORM.
+type ResponseStore struct {
+	db     *gorm.DB
+	dbPath string
+	mu     sync.Mutex
+}
+
+// NewResponseStore creates or loads a response store using SQLite database.
+func NewResponseStore(baseDir string) (*ResponseStore, error) {
+	if err := os.MkdirAll(baseDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create response store directory: %w", err)
+	}
+
+	dbPath := constant.GetDBFile(baseDir)
+	// Configure SQLite with busy timeout and other settings
+	dsn := dbPath + "?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=1"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open response database: %w", err)
+	}
+
+	store := &ResponseStore{
+		db:     db,
+		dbPath: dbPath,
+	}
+
+	// Auto-migrate schema
+	if err := db.AutoMigrate(&ResponseRecord{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate response database: %w", err)
+	}
+
+	return store, nil
+}
+
+// Save creates or replaces a stored response
+func (rs *ResponseStore) Save(record *ResponseRecord) error {
+	if record == nil || record.ID == "" {
+		return errors.New("response record must have an ID")
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+	if err := rs.db.Save(record).Error; err != nil {
+		return fmt.Errorf("failed to save response: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a stored response by ID. Expired responses are reported as not found.
+func (rs *ResponseStore) Get(id string) (*ResponseRecord, bool, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	var record ResponseRecord
+	err := rs.db.Where("id = ? AND expires_at > ?", id, time.Now()).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query response: %w", err)
+	}
+	return &record, true, nil
+}
+
+// ExtendExpiry postpones the expiry of the given responses to expiresAt, so that the
+// responses a stored response continues live at least as long as it does
+func (rs *ResponseStore) ExtendExpiry(ids []string, expiresAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	err := rs.db.Model(&ResponseRecord{}).
+		Where("id IN ? AND expires_at < ?", ids, expiresAt).
+		Update("expires_at", expiresAt).Error
+	if err != nil {
+		return fmt.Errorf("failed to extend response expiry: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a stored response and reports whether it existed
+func (rs *ResponseStore) Delete(id string) (bool, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	result := rs.db.Where("id = ?", id).Delete(&ResponseRecord{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete response: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteExpired removes responses that expired before the given time
+func (rs *ResponseStore) DeleteExpired(now time.Time) (int64, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	result := rs.db.Where("expires_at <= ?", now).Delete(&ResponseRecord{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired responses: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// StartCleanupTask periodically removes expired responses
+func (rs *ResponseStore) StartCleanupTask(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			_, _ = rs.DeleteExpired(time.Now())
+		}
+	}()
+}
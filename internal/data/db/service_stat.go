@@ -34,6 +34,16 @@ type ServiceStatsRecord struct {
 	WindowInputTokens    int64     `gorm:"column:window_input_tokens"`
 	WindowOutputTokens   int64     `gorm:"column:window_output_tokens"`
 	TimeWindow           int       `gorm:"column:time_window"`
+	ErrorCount           int64     `gorm:"column:error_count"`
+	WindowErrorCount     int64     `gorm:"column:window_error_count"`
+	ConsecutiveFailures  int       `gorm:"column:consecutive_failures"`
+	AvgLatencyMs         float64   `gorm:"column:avg_latency_ms"`
+	AvgTTFTMs            float64   `gorm:"column:avg_ttft_ms"`
+	TTFTSamples          int64     `gorm:"column:ttft_samples"`
+	LastError            string    `gorm:"column:last_error"`
+	LastErrorAt          time.Time `gorm:"column:last_error_at"`
+	CircuitState         string    `gorm:"column:circuit_state"`
+	CircuitOpenedAt      time.Time `gorm:"column:circuit_opened_at"`
 }
 
 // TableName specifies the table name for GORM
@@ -155,6 +165,16 @@ func (ss *StatsStore) UpdateFromService(service *loadbalance.Service) error {
 		WindowInputTokens:    stat.WindowInputTokens,
 		WindowOutputTokens:   stat.WindowOutputTokens,
 		TimeWindow:           stat.TimeWindow,
+		ErrorCount:           stat.ErrorCount,
+		WindowErrorCount:     stat.WindowErrorCount,
+		ConsecutiveFailures:  stat.ConsecutiveFailures,
+		AvgLatencyMs:         stat.AvgLatencyMs,
+		AvgTTFTMs:            stat.AvgTTFTMs,
+		TTFTSamples:          stat.TTFTSamples,
+		LastError:            stat.LastError,
+		LastErrorAt:          stat.LastErrorAt,
+		CircuitState:         stat.CircuitState.String(),
+		CircuitOpenedAt:      stat.CircuitOpenedAt,
 	}
 
 	// Normalize time window if needed
@@ -215,6 +235,7 @@ func (ss *StatsStore) RecordUsage(service *loadbalance.Service, inputTokens, out
 		record.WindowTokensConsumed = 0
 		record.WindowInputTokens = 0
 		record.WindowOutputTokens = 0
+		record.WindowErrorCount = 0
 	}
 
 	record.RequestCount++
@@ -272,6 +293,16 @@ func (ss *StatsStore) HydrateRules(rules []typ.Rule) error {
 					WindowInputTokens:    statCopy.WindowInputTokens,
 					WindowOutputTokens:   statCopy.WindowOutputTokens,
 					TimeWindow:           statCopy.TimeWindow,
+					ErrorCount:           statCopy.ErrorCount,
+					WindowErrorCount:     statCopy.WindowErrorCount,
+					ConsecutiveFailures:  statCopy.ConsecutiveFailures,
+					AvgLatencyMs:         statCopy.AvgLatencyMs,
+					AvgTTFTMs:            statCopy.AvgTTFTMs,
+					TTFTSamples:          statCopy.TTFTSamples,
+					LastError:            statCopy.LastError,
+					LastErrorAt:          statCopy.LastErrorAt,
+					CircuitState:         statCopy.CircuitState.String(),
+					CircuitOpenedAt:      statCopy.CircuitOpenedAt,
 				}
 				if record.TimeWindow == 0 {
 					if service.TimeWindow > 0 {
@@ -318,5 +349,15 @@ func (r *ServiceStatsRecord) toServiceStats() loadbalance.ServiceStats {
 		WindowInputTokens:    r.WindowInputTokens,
 		WindowOutputTokens:   r.WindowOutputTokens,
 		TimeWindow:           r.TimeWindow,
+		ErrorCount:           r.ErrorCount,
+		WindowErrorCount:     r.WindowErrorCount,
+		ConsecutiveFailures:  r.ConsecutiveFailures,
+		AvgLatencyMs:         r.AvgLatencyMs,
+		AvgTTFTMs:            r.AvgTTFTMs,
+		TTFTSamples:          r.TTFTSamples,
+		LastError:            r.LastError,
+		LastErrorAt:          r.LastErrorAt,
+		CircuitState:         loadbalance.ParseCircuitState(r.CircuitState),
+		CircuitOpenedAt:      r.CircuitOpenedAt,
 	}
 }
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// budgetDateFormat is the format of the local dates of the budget aggregates
+const budgetDateFormat = "2006-01-02"
+
+// UsageBudgetRecord is the GORM model for the daily usage of a client API key or a
+// scenario, which usage budgets are enforced against. Unlike usage records it is kept
+// when old usage is deleted, so a budget period is always counted in full.
+type UsageBudgetRecord struct {
+	ID           uint    `gorm:"primaryKey;autoIncrement;column:id"`
+	Date         string  `gorm:"column:date;uniqueIndex:idx_budget_date_dimension;not null"`      // Local date (YYYY-MM-DD)
+	Dimension    string  `gorm:"column:dimension;uniqueIndex:idx_budget_date_dimension;not null"` // api_key:<id> or scenario:<name>
+	RequestCount int64   `gorm:"column:request_count;not null"`
+	TotalTokens  int64   `gorm:"column:total_tokens;not null"`
+	CostUSD      float64 `gorm:"column:cost_usd;default:0"`
+}
+
+// TableName specifies the table name for GORM
+func (UsageBudgetRecord) TableName() string {
+	return "usage_budget_daily"
+}
+
+// budgetDimensions returns the dimensions a usage record counts against
+func budgetDimensions(record *UsageRecord) []string {
+	dimensions := []string{"scenario:" + record.Scenario}
+	if record.APIKeyID != "" {
+		dimensions = append(dimensions, "api_key:"+record.APIKeyID)
+	}
+	return dimensions
+}
+
+// budgetUsage returns the date and the usage a record adds to the budget aggregates.
+// Only the first attempt of a failover chain counts as a request, the tokens and cost
+// of every attempt count.
+func budgetUsage(record *UsageRecord) (string, UsageTotals) {
+	delta := UsageTotals{
+		TotalTokens: int64(record.TotalTokens),
+		CostUSD:     record.CostUSD,
+	}
+	if record.Attempt <= 1 {
+		delta.RequestCount = 1
+	}
+	return record.Timestamp.In(time.Local).Format(budgetDateFormat), delta
+}
+
+// writeBudgetUsage adds a usage record to the budget aggregates
+func writeBudgetUsage(tx *gorm.DB, record *UsageRecord) error {
+	date, delta := budgetUsage(record)
+	for _, dimension := range budgetDimensions(record) {
+		if err := tx.Exec(`
+			INSERT INTO usage_budget_daily (date, dimension, request_count, total_tokens, cost_usd)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(date, dimension) DO UPDATE SET
+				request_count = request_count + excluded.request_count,
+				total_tokens = total_tokens + excluded.total_tokens,
+				cost_usd = cost_usd + excluded.cost_usd
+		`, date, dimension, delta.RequestCount, delta.TotalTokens, delta.CostUSD).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countBudgetUsage adds a usage record written to the budget aggregates to the loaded
+// counters. Counters of months not loaded yet are seeded from the aggregates on first
+// use. The caller holds us.mu.
+func (us *UsageStore) countBudgetUsage(record *UsageRecord) {
+	date, delta := budgetUsage(record)
+	us.pruneBudgetCounters(date)
+
+	for _, dimension := range budgetDimensions(record) {
+		if !us.budgetLoaded[date[:7]+"|"+dimension] {
+			continue
+		}
+		totals := us.budgetTotals[date+"|"+dimension]
+		totals.RequestCount += delta.RequestCount
+		totals.TotalTokens += delta.TotalTokens
+		totals.CostUSD += delta.CostUSD
+		us.budgetTotals[date+"|"+dimension] = totals
+	}
+}
+
+// GetBudgetTotals returns the usage of an API key, or of a scenario when apiKeyID is
+// empty, on the local days from start to end. The counters of a month are loaded from
+// the database once and then kept up to date in memory.
+func (us *UsageStore) GetBudgetTotals(start, end time.Time, apiKeyID, scenario string) (UsageTotals, error) {
+	dimension := "scenario:" + scenario
+	if apiKeyID != "" {
+		dimension = "api_key:" + apiKeyID
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	var totals UsageTotals
+	for day := start.In(time.Local); day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(budgetDateFormat)
+		if err := us.loadBudgetMonth(date[:7], dimension); err != nil {
+			return UsageTotals{}, err
+		}
+		dayTotals := us.budgetTotals[date+"|"+dimension]
+		totals.RequestCount += dayTotals.RequestCount
+		totals.TotalTokens += dayTotals.TotalTokens
+		totals.CostUSD += dayTotals.CostUSD
+	}
+	return totals, nil
+}
+
+// loadBudgetMonth seeds the counters of a dimension for a month (YYYY-MM) from the
+// database, once. The caller holds us.mu.
+func (us *UsageStore) loadBudgetMonth(month, dimension string) error {
+	key := month + "|" + dimension
+	if us.budgetLoaded[key] {
+		return nil
+	}
+
+	var records []UsageBudgetRecord
+	if err := us.db.Where("dimension = ? AND date LIKE ?", dimension, month+"-%").Find(&records).Error; err != nil {
+		return err
+	}
+	for _, r := range records {
+		us.budgetTotals[r.Date+"|"+dimension] = UsageTotals{
+			RequestCount: r.RequestCount,
+			TotalTokens:  r.TotalTokens,
+			CostUSD:      r.CostUSD,
+		}
+	}
+	us.budgetLoaded[key] = true
+	return nil
+}
+
+// pruneBudgetCounters drops the counters of the months before the previous one when
+// the date changes. Budget periods never reach that far back. The caller holds us.mu.
+func (us *UsageStore) pruneBudgetCounters(date string) {
+	if date == us.budgetDate {
+		return
+	}
+	us.budgetDate = date
+
+	day, err := time.ParseInLocation(budgetDateFormat, date, time.Local)
+	if err != nil {
+		return
+	}
+	cutoff := time.Date(day.Year(), day.Month()-1, 1, 0, 0, 0, 0, time.Local).Format("2006-01")
+	for key := range us.budgetTotals {
+		if key[:7] < cutoff {
+			delete(us.budgetTotals, key)
+		}
+	}
+	for key := range us.budgetLoaded {
+		if key[:7] < cutoff {
+			delete(us.budgetLoaded, key)
+		}
+	}
+}
+
+// deleteBudgetUsageBefore deletes the budget aggregates of the months before the one
+// containing cutoff, keeping every day of a period that may still be current.
+// The caller holds us.mu.
+func (us *UsageStore) deleteBudgetUsageBefore(cutoff time.Time) error {
+	local := cutoff.In(time.Local)
+	monthStart := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, time.Local).Format(budgetDateFormat)
+	return us.db.Where("date < ?", monthStart).Delete(&UsageBudgetRecord{}).Error
+}
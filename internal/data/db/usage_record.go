@@ -24,6 +24,7 @@ type UsageRecord struct {
 	Scenario     string    `gorm:"column:scenario;index:idx_scenario;not null"`
 	RuleUUID     string    `gorm:"column:rule_uuid;index:idx_rule"`
 	RequestModel string    `gorm:"column:request_model"`
+	APIKeyID     string    `gorm:"column:api_key_id;index:idx_api_key"` // Named client API key (empty for the global model token)
 	Timestamp    time.Time `gorm:"column:timestamp;index:idx_timestamp;index:idx_timestamp_scenario;not null"`
 	InputTokens  int       `gorm:"column:input_tokens;not null"`
 	OutputTokens int       `gorm:"column:output_tokens;not null"`
@@ -32,6 +33,18 @@ type UsageRecord struct {
 	ErrorCode    string    `gorm:"column:error_code"`
 	LatencyMs    int       `gorm:"column:latency_ms"`
 	Streamed     bool      `gorm:"column:streamed;type:integer"`
+	Attempt      int       `gorm:"column:attempt;default:1"`  // 1-based attempt number within a failover chain
+	CostUSD      float64   `gorm:"column:cost_usd;default:0"` // Cost computed from the pricing catalog when recorded
+
+	// Breakdown of the token counts: cache tokens are part of InputTokens,
+	// reasoning tokens are part of OutputTokens
+	CacheReadTokens     int `gorm:"column:cache_read_tokens;default:0"`
+	CacheCreationTokens int `gorm:"column:cache_creation_tokens;default:0"`
+	ReasoningTokens     int `gorm:"column:reasoning_tokens;default:0"`
+
+	// Savings of tool result compaction on the request sent upstream
+	CompactSavedBytes  int `gorm:"column:compact_saved_bytes;default:0"`
+	CompactSavedTokens int `gorm:"column:compact_saved_tokens;default:0"`
 }
 
 // TableName specifies the table name for GORM
@@ -51,6 +64,11 @@ type UsageDailyRecord struct {
 	InputTokens  int64     `gorm:"column:input_tokens;not null"`
 	OutputTokens int64     `gorm:"column:output_tokens;not null"`
 	ErrorCount   int64     `gorm:"column:error_count;default:0"`
+	CostUSD      float64   `gorm:"column:cost_usd;default:0"`
+
+	CacheReadTokens     int64 `gorm:"column:cache_read_tokens;default:0"`
+	CacheCreationTokens int64 `gorm:"column:cache_creation_tokens;default:0"`
+	ReasoningTokens     int64 `gorm:"column:reasoning_tokens;default:0"`
 }
 
 // TableName specifies the table name for GORM
@@ -60,17 +78,22 @@ func (UsageDailyRecord) TableName() string {
 
 // UsageMonthlyRecord is the GORM model for monthly aggregated usage statistics
 type UsageMonthlyRecord struct {
-	ID           uint   `gorm:"primaryKey;autoIncrement;column:id"`
-	Year         int    `gorm:"column:year;not null"`
-	Month        int    `gorm:"column:month;not null"`
-	ProviderUUID string `gorm:"column:provider_uuid;not null"`
-	ProviderName string `gorm:"column:provider_name;not null"`
-	Model        string `gorm:"column:model;not null"`
-	RequestCount int64  `gorm:"column:request_count;not null"`
-	TotalTokens  int64  `gorm:"column:total_tokens;not null"`
-	InputTokens  int64  `gorm:"column:input_tokens;not null"`
-	OutputTokens int64  `gorm:"column:output_tokens;not null"`
-	ErrorCount   int64  `gorm:"column:error_count;default:0"`
+	ID           uint    `gorm:"primaryKey;autoIncrement;column:id"`
+	Year         int     `gorm:"column:year;not null"`
+	Month        int     `gorm:"column:month;not null"`
+	ProviderUUID string  `gorm:"column:provider_uuid;not null"`
+	ProviderName string  `gorm:"column:provider_name;not null"`
+	Model        string  `gorm:"column:model;not null"`
+	RequestCount int64   `gorm:"column:request_count;not null"`
+	TotalTokens  int64   `gorm:"column:total_tokens;not null"`
+	InputTokens  int64   `gorm:"column:input_tokens;not null"`
+	OutputTokens int64   `gorm:"column:output_tokens;not null"`
+	ErrorCount   int64   `gorm:"column:error_count;default:0"`
+	CostUSD      float64 `gorm:"column:cost_usd;default:0"`
+
+	CacheReadTokens     int64 `gorm:"column:cache_read_tokens;default:0"`
+	CacheCreationTokens int64 `gorm:"column:cache_creation_tokens;default:0"`
+	ReasoningTokens     int64 `gorm:"column:reasoning_tokens;default:0"`
 }
 
 // TableName specifies the table name for GORM
@@ -78,11 +101,21 @@ func (UsageMonthlyRecord) TableName() string {
 	return "usage_monthly"
 }
 
+// CostCalculator computes the cost in USD of a usage record
+type CostCalculator func(record *UsageRecord) float64
+
 // UsageStore persists usage records in SQLite using GORM.
 type UsageStore struct {
 	db     *gorm.DB
 	dbPath string
 	mu     sync.Mutex
+	costFn CostCalculator
+
+	// In-memory budget counters, per local date and dimension, seeded per month
+	// from the budget aggregates (see usage_budget.go)
+	budgetTotals map[string]UsageTotals
+	budgetLoaded map[string]bool
+	budgetDate   string
 }
 
 // NewUsageStore creates or loads a usage store using SQLite database.
@@ -104,12 +137,14 @@ func NewUsageStore(baseDir string) (*UsageStore, error) {
 	logrus.Debugf("SQLite database opened successfully for usage store")
 
 	store := &UsageStore{
-		db:     db,
-		dbPath: dbPath,
+		db:           db,
+		dbPath:       dbPath,
+		budgetTotals: make(map[string]UsageTotals),
+		budgetLoaded: make(map[string]bool),
 	}
 
 	// Auto-migrate schema for all usage-related tables
-	if err := db.AutoMigrate(&UsageRecord{}, &UsageDailyRecord{}, &UsageMonthlyRecord{}); err != nil {
+	if err := db.AutoMigrate(&UsageRecord{}, &UsageDailyRecord{}, &UsageMonthlyRecord{}, &UsageBudgetRecord{}); err != nil {
 		return nil, fmt.Errorf("failed to migrate usage database: %w", err)
 	}
 	logrus.Debugf("Usage store initialization completed")
@@ -117,6 +152,13 @@ func NewUsageStore(baseDir string) (*UsageStore, error) {
 	return store, nil
 }
 
+// SetCostCalculator sets the function used to fill CostUSD on recorded usage
+func (us *UsageStore) SetCostCalculator(fn CostCalculator) {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	us.costFn = fn
+}
+
 // RecordUsage records a single usage event
 func (us *UsageStore) RecordUsage(record *UsageRecord) error {
 	if record == nil {
@@ -133,22 +175,35 @@ func (us *UsageStore) RecordUsage(record *UsageRecord) error {
 	if record.Status == "" {
 		record.Status = "success"
 	}
+	if record.CostUSD == 0 && us.costFn != nil {
+		record.CostUSD = us.costFn(record)
+	}
 
-	return us.db.Create(record).Error
+	if err := us.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+		return writeBudgetUsage(tx, record)
+	}); err != nil {
+		return err
+	}
+	us.countBudgetUsage(record)
+	return nil
 }
 
 // GetAggregatedStats returns aggregated usage statistics based on query parameters
 type UsageStatsQuery struct {
-	GroupBy   string // model, provider, scenario, rule, daily, hourly
+	GroupBy   string // model, provider, scenario, rule, api_key, daily, hourly
 	StartTime time.Time
 	EndTime   time.Time
 	Provider  string
 	Model     string
 	Scenario  string
 	RuleUUID  string
+	APIKeyID  string
 	Status    string
 	Limit     int
-	SortBy    string // total_tokens, request_count, avg_latency
+	SortBy    string // total_tokens, request_count, avg_latency, total_cost
 	SortOrder string // asc, desc
 }
 
@@ -170,6 +225,13 @@ type AggregatedStat struct {
 	ErrorRate       float64 `json:"error_rate"`
 	StreamedCount   int64   `json:"streamed_count"`
 	StreamedRate    float64 `json:"streamed_rate"`
+	TotalCostUSD    float64 `json:"total_cost_usd"`
+	AvgCostUSD      float64 `json:"avg_cost_usd"`
+
+	CacheReadTokens     int64   `json:"total_cache_read_tokens"`
+	CacheCreationTokens int64   `json:"total_cache_creation_tokens"`
+	ReasoningTokens     int64   `json:"total_reasoning_tokens"`
+	CacheHitRate        float64 `json:"cache_hit_rate"` // Share of input tokens read from the prompt cache
 }
 
 // GetAggregatedStats returns aggregated statistics
@@ -201,6 +263,9 @@ func (us *UsageStore) GetAggregatedStats(query UsageStatsQuery) ([]AggregatedSta
 	if query.RuleUUID != "" {
 		db = db.Where("rule_uuid = ?", query.RuleUUID)
 	}
+	if query.APIKeyID != "" {
+		db = db.Where("api_key_id = ?", query.APIKeyID)
+	}
 	if query.Status != "" {
 		db = db.Where("status = ?", query.Status)
 	}
@@ -218,6 +283,9 @@ func (us *UsageStore) GetAggregatedStats(query UsageStatsQuery) ([]AggregatedSta
 	case "rule":
 		groupBy = "rule_uuid"
 		keyField = "rule_uuid"
+	case "api_key":
+		groupBy = "api_key_id"
+		keyField = "api_key_id"
 	case "daily":
 		groupBy = "date(timestamp)"
 		keyField = "date(timestamp)"
@@ -242,6 +310,11 @@ func (us *UsageStore) GetAggregatedStats(query UsageStatsQuery) ([]AggregatedSta
 		ErrorCount    int64
 		StreamedCount int64
 		AvgLatency    float64
+		TotalCost     float64
+
+		CacheReadTokens     int64
+		CacheCreationTokens int64
+		ReasoningTokens     int64
 	}
 
 	var results []result
@@ -257,7 +330,11 @@ func (us *UsageStore) GetAggregatedStats(query UsageStatsQuery) ([]AggregatedSta
 		COALESCE(SUM(output_tokens), 0) as output_tokens,
 		COALESCE(SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END), 0) as error_count,
 		COALESCE(SUM(CASE WHEN streamed = true THEN 1 ELSE 0 END), 0) as streamed_count,
-		COALESCE(AVG(latency_ms), 0) as avg_latency
+		COALESCE(AVG(latency_ms), 0) as avg_latency,
+		COALESCE(SUM(cost_usd), 0) as total_cost,
+		COALESCE(SUM(cache_read_tokens), 0) as cache_read_tokens,
+		COALESCE(SUM(cache_creation_tokens), 0) as cache_creation_tokens,
+		COALESCE(SUM(reasoning_tokens), 0) as reasoning_tokens
 	`, keyField)
 
 	if err := db.
@@ -289,12 +366,26 @@ func (us *UsageStore) GetAggregatedStats(query UsageStatsQuery) ([]AggregatedSta
 			ErrorRate:       rateFloat(r.ErrorCount, r.RequestCount),
 			StreamedCount:   r.StreamedCount,
 			StreamedRate:    rateFloat(r.StreamedCount, r.RequestCount),
+			TotalCostUSD:    r.TotalCost,
+			AvgCostUSD:      avgFloat(r.TotalCost, r.RequestCount),
+
+			CacheReadTokens:     r.CacheReadTokens,
+			CacheCreationTokens: r.CacheCreationTokens,
+			ReasoningTokens:     r.ReasoningTokens,
+			CacheHitRate:        rateFloat(r.CacheReadTokens, r.InputTokens),
 		}
 	}
 
 	return stats, nil
 }
 
+// UsageTotals holds the usage accumulated by a client key or scenario over a budget period
+type UsageTotals struct {
+	RequestCount int64   `gorm:"column:request_count" json:"request_count"`
+	TotalTokens  int64   `gorm:"column:total_tokens" json:"total_tokens"`
+	CostUSD      float64 `gorm:"column:cost_usd" json:"cost_usd"`
+}
+
 // TimeSeriesData represents a single time bucket in time series data
 type TimeSeriesData struct {
 	Timestamp    string  `json:"timestamp"`
@@ -304,6 +395,11 @@ type TimeSeriesData struct {
 	OutputTokens int64   `json:"output_tokens"`
 	ErrorCount   int64   `json:"error_count"`
 	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	CostUSD      float64 `json:"cost_usd"`
+
+	CacheReadTokens     int64 `json:"cache_read_tokens"`
+	CacheCreationTokens int64 `json:"cache_creation_tokens"`
+	ReasoningTokens     int64 `json:"reasoning_tokens"`
 }
 
 // GetTimeSeries returns time-series data for usage
@@ -347,6 +443,11 @@ func (us *UsageStore) GetTimeSeries(interval string, startTime, endTime time.Tim
 		OutputTokens int64
 		ErrorCount   int64
 		AvgLatency   float64
+		Cost         float64
+
+		CacheReadTokens     int64
+		CacheCreationTokens int64
+		ReasoningTokens     int64
 	}
 
 	var results []result
@@ -358,7 +459,11 @@ func (us *UsageStore) GetTimeSeries(interval string, startTime, endTime time.Tim
 		COALESCE(SUM(input_tokens), 0) as input_tokens,
 		COALESCE(SUM(output_tokens), 0) as output_tokens,
 		COALESCE(SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END), 0) as error_count,
-		COALESCE(AVG(latency_ms), 0) as avg_latency
+		COALESCE(AVG(latency_ms), 0) as avg_latency,
+		COALESCE(SUM(cost_usd), 0) as cost,
+		COALESCE(SUM(cache_read_tokens), 0) as cache_read_tokens,
+		COALESCE(SUM(cache_creation_tokens), 0) as cache_creation_tokens,
+		COALESCE(SUM(reasoning_tokens), 0) as reasoning_tokens
 	`, timeFormat)
 
 	if err := db.
@@ -380,6 +485,11 @@ func (us *UsageStore) GetTimeSeries(interval string, startTime, endTime time.Tim
 			OutputTokens: r.OutputTokens,
 			ErrorCount:   r.ErrorCount,
 			AvgLatencyMs: r.AvgLatency,
+			CostUSD:      r.Cost,
+
+			CacheReadTokens:     r.CacheReadTokens,
+			CacheCreationTokens: r.CacheCreationTokens,
+			ReasoningTokens:     r.ReasoningTokens,
 		}
 	}
 
@@ -423,13 +533,20 @@ func (us *UsageStore) GetRecords(startTime, endTime time.Time, filters map[strin
 	return records, total, nil
 }
 
-// DeleteOlderThan deletes records older than the specified date
+// DeleteOlderThan deletes records older than the specified date. The budget aggregates
+// of the current budget periods are kept.
 func (us *UsageStore) DeleteOlderThan(cutoffDate time.Time) (int64, error) {
 	us.mu.Lock()
 	defer us.mu.Unlock()
 
 	result := us.db.Where("timestamp < ?", cutoffDate).Delete(&UsageRecord{})
-	return result.RowsAffected, result.Error
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if err := us.deleteBudgetUsageBefore(cutoffDate); err != nil {
+		return result.RowsAffected, err
+	}
+	return result.RowsAffected, nil
 }
 
 // AggregateToDaily aggregates records to daily summaries
@@ -439,7 +556,7 @@ func (us *UsageStore) AggregateToDaily(date time.Time) (int64, error) {
 
 	// Aggregate usage records to daily summaries
 	result := us.db.Exec(`
-		INSERT OR REPLACE INTO usage_daily (date, provider_uuid, provider_name, model, request_count, total_tokens, input_tokens, output_tokens, error_count)
+		INSERT OR REPLACE INTO usage_daily (date, provider_uuid, provider_name, model, request_count, total_tokens, input_tokens, output_tokens, error_count, cost_usd, cache_read_tokens, cache_creation_tokens, reasoning_tokens)
 		SELECT
 			date(?) as date,
 			provider_uuid,
@@ -449,7 +566,11 @@ func (us *UsageStore) AggregateToDaily(date time.Time) (int64, error) {
 			SUM(total_tokens) as total_tokens,
 			SUM(input_tokens) as input_tokens,
 			SUM(output_tokens) as output_tokens,
-			SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END) as error_count
+			SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END) as error_count,
+			COALESCE(SUM(cost_usd), 0) as cost_usd,
+			COALESCE(SUM(cache_read_tokens), 0) as cache_read_tokens,
+			COALESCE(SUM(cache_creation_tokens), 0) as cache_creation_tokens,
+			COALESCE(SUM(reasoning_tokens), 0) as reasoning_tokens
 		FROM usage_records
 		WHERE date(timestamp) = date(?)
 		GROUP BY provider_uuid, provider_name, model
@@ -473,6 +594,8 @@ func buildOrderBy(sortBy, sortOrder string) string {
 		return fmt.Sprintf("request_count %s", sortOrder)
 	case "avg_latency":
 		return fmt.Sprintf("avg_latency %s", sortOrder)
+	case "total_cost":
+		return fmt.Sprintf("total_cost %s", sortOrder)
 	default: // total_tokens
 		return fmt.Sprintf("total_tokens %s", sortOrder)
 	}
@@ -47,27 +47,29 @@ type ResultFormat struct {
 
 // ProviderTemplate represents a predefined provider configuration template
 type ProviderTemplate struct {
-	ID                     string            `json:"id"`
-	Name                   string            `json:"name"`
-	Alias                  string            `json:"alias,omitempty"` // Display name with locale information
-	Status                 string            `json:"status"` // "active", "deprecated", etc.
-	Valid                  bool              `json:"valid"`
-	Website                string            `json:"website"`
-	Description            string            `json:"description"`
-	Type                   string            `json:"type"` // "official", "reseller", etc.
-	APIDoc                 string            `json:"api_doc"`
-	ModelDoc               string            `json:"model_doc"`
-	PricingDoc             string            `json:"pricing_doc"`
-	BaseURLOpenAI          string            `json:"base_url_openai,omitempty"`
-	BaseURLAnthropic       string            `json:"base_url_anthropic,omitempty"`
-	Models                 []string          `json:"models"`                 // List of model IDs
-	ModelLimits            map[string]int    `json:"model_limits,omitempty"` // Model name -> max_tokens mapping
-	SupportsModelsEndpoint bool              `json:"supports_models_endpoint"`
-	Tags                   []string          `json:"tags,omitempty"`
-	Metadata               map[string]string `json:"metadata,omitempty"`
-	OAuthProvider          string            `json:"oauth_provider,omitempty"`    // OAuth provider type for oauth type providers
-	AuthType               string            `json:"auth_type,omitempty"`         // "oauth", "key"
-	WebSearchSchema        string            `json:"web_search_schema,omitempty"` // Reference to capability schema for web_search
+	ID                     string                       `json:"id"`
+	Name                   string                       `json:"name"`
+	Alias                  string                       `json:"alias,omitempty"` // Display name with locale information
+	Status                 string                       `json:"status"`          // "active", "deprecated", etc.
+	Valid                  bool                         `json:"valid"`
+	Website                string                       `json:"website"`
+	Description            string                       `json:"description"`
+	Type                   string                       `json:"type"` // "official", "reseller", etc.
+	APIDoc                 string                       `json:"api_doc"`
+	ModelDoc               string                       `json:"model_doc"`
+	PricingDoc             string                       `json:"pricing_doc"`
+	BaseURLOpenAI          string                       `json:"base_url_openai,omitempty"`
+	BaseURLAnthropic       string                       `json:"base_url_anthropic,omitempty"`
+	Models                 []string                     `json:"models"`                          // List of model IDs
+	ModelLimits            map[string]int               `json:"model_limits,omitempty"`          // Model name -> max_tokens mapping
+	ModelContextWindows    map[string]int               `json:"model_context_windows,omitempty"` // Model name -> context window (input + output tokens)
+	ModelPricing           map[string]*typ.ModelPricing `json:"model_pricing,omitempty"`         // Model name -> price per 1M tokens (USD)
+	SupportsModelsEndpoint bool                         `json:"supports_models_endpoint"`
+	Tags                   []string                     `json:"tags,omitempty"`
+	Metadata               map[string]string            `json:"metadata,omitempty"`
+	OAuthProvider          string                       `json:"oauth_provider,omitempty"`    // OAuth provider type for oauth type providers
+	AuthType               string                       `json:"auth_type,omitempty"`         // "oauth", "key"
+	WebSearchSchema        string                       `json:"web_search_schema,omitempty"` // Reference to capability schema for web_search
 }
 
 // ProviderTemplateRegistry represents the provider template registry structure from GitHub
@@ -523,6 +525,25 @@ func deepCopyTemplate(tmpl *ProviderTemplate) *ProviderTemplate {
 		}
 	}
 
+	// Copy model context windows map
+	if tmpl.ModelContextWindows != nil {
+		result.ModelContextWindows = make(map[string]int, len(tmpl.ModelContextWindows))
+		for k, v := range tmpl.ModelContextWindows {
+			result.ModelContextWindows[k] = v
+		}
+	}
+
+	// Copy model pricing map
+	if tmpl.ModelPricing != nil {
+		result.ModelPricing = make(map[string]*typ.ModelPricing, len(tmpl.ModelPricing))
+		for k, v := range tmpl.ModelPricing {
+			if v != nil {
+				pricing := *v
+				result.ModelPricing[k] = &pricing
+			}
+		}
+	}
+
 	// Copy metadata map
 	if tmpl.Metadata != nil {
 		result.Metadata = make(map[string]string, len(tmpl.Metadata))
@@ -628,6 +649,40 @@ func (tm *TemplateManager) GetMaxTokensForModelByProvider(provider *typ.Provider
 	return constant.DefaultMaxTokens
 }
 
+// GetContextWindowForModelByProvider returns the context window of a model from the
+// template matched by APIBase or OAuthProvider, or 0 when the template does not know it.
+func (tm *TemplateManager) GetContextWindowForModelByProvider(provider *typ.Provider, model string) int {
+	if tm == nil || provider == nil {
+		return 0
+	}
+
+	tmpl := tm.findTemplateByProvider(provider)
+	if tmpl == nil || tmpl.ModelContextWindows == nil {
+		return 0
+	}
+	return tmpl.ModelContextWindows[model]
+}
+
+// GetModelPricingByProvider returns the pricing of a model from the template matched
+// by APIBase or OAuthProvider, or nil when the template has no price for it.
+func (tm *TemplateManager) GetModelPricingByProvider(provider *typ.Provider, model string) *typ.ModelPricing {
+	if tm == nil || provider == nil {
+		return nil
+	}
+
+	tmpl := tm.findTemplateByProvider(provider)
+	if tmpl == nil {
+		return nil
+	}
+	pricing := typ.LookupModelPricing(tmpl.ModelPricing, model)
+	if pricing == nil {
+		return nil
+	}
+	// Return a copy so callers cannot mutate the shared template
+	result := *pricing
+	return &result
+}
+
 // GetWebSearchSchemaForProvider returns the web search capability schema for a provider
 // Returns nil if the provider doesn't have web_search_schema defined or the schema doesn't exist
 func (tm *TemplateManager) GetWebSearchSchemaForProvider(provider *typ.Provider) *CapabilitySchema {
@@ -283,6 +283,72 @@ func TestTemplateManagerGetModelsForProvider(t *testing.T) {
 	}
 }
 
+// TestTemplateManagerGetModelPricingByProvider tests pricing lookup from embedded templates
+func TestTemplateManagerGetModelPricingByProvider(t *testing.T) {
+	tm := NewTemplateManager("")
+	if err := tm.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	anthropicProvider := &typ.Provider{
+		Name:     "my-anthropic",
+		APIBase:  "https://api.anthropic.com",
+		APIStyle: protocol.APIStyleAnthropic,
+	}
+
+	tests := []struct {
+		name          string
+		provider      *typ.Provider
+		model         string
+		expectPricing bool
+		expectedInput float64
+	}{
+		{"Exact match", anthropicProvider, "claude-sonnet-4", true, 3},
+		{"Dated variant matches family", anthropicProvider, "claude-sonnet-4-20250514", true, 3},
+		{"Longest prefix wins", anthropicProvider, "claude-opus-4-5-20251101", true, 5},
+		{"Unknown model", anthropicProvider, "claude-unknown", false, 0},
+		{"Unknown provider", &typ.Provider{Name: "x", APIBase: "https://nonexistent.example.com/v1"}, "gpt-4o", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pricing := tm.GetModelPricingByProvider(tt.provider, tt.model)
+			if !tt.expectPricing {
+				if pricing != nil {
+					t.Errorf("expected no pricing, got %+v", pricing)
+				}
+				return
+			}
+			if pricing == nil {
+				t.Fatal("expected pricing, got nil")
+			}
+			if pricing.Input != tt.expectedInput {
+				t.Errorf("expected input price %v, got %v", tt.expectedInput, pricing.Input)
+			}
+		})
+	}
+}
+
+// TestModelPricingCost tests cost calculation from per-million-token prices
+func TestModelPricingCost(t *testing.T) {
+	pricing := &typ.ModelPricing{Input: 3, Output: 15, CacheRead: 0.3}
+
+	got := pricing.Cost(1_000_000, 100_000, 0, 0)
+	if want := 4.5; got < want-1e-9 || got > want+1e-9 {
+		t.Errorf("expected cost %v, got %v", want, got)
+	}
+
+	// Cache writes without a price are billed at the input price
+	got = pricing.Cost(0, 0, 1_000_000, 1_000_000)
+	if want := 3.3; got < want-1e-9 || got > want+1e-9 {
+		t.Errorf("expected cost %v, got %v", want, got)
+	}
+
+	if got := (*typ.ModelPricing)(nil).Cost(1000, 1000, 0, 0); got != 0 {
+		t.Errorf("expected zero cost for nil pricing, got %v", got)
+	}
+}
+
 // TestValidateTemplate tests template validation
 func TestValidateTemplate(t *testing.T) {
 	tests := []struct {
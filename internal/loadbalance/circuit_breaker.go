@@ -0,0 +1,217 @@
+package loadbalance
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CircuitState represents the health state of a service's circuit breaker
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // Healthy, requests flow normally
+	CircuitOpen                         // Tripped, the service is skipped until the cool-down expires
+	CircuitHalfOpen                     // Cool-down expired, a single request probes the service
+)
+
+// Circuit breaker defaults
+const (
+	DefaultCircuitFailureThreshold = 5                // Consecutive failures before the circuit opens
+	DefaultCircuitOpenDuration     = 30 * time.Second // How long an open circuit stays open before probing
+	DefaultCircuitProbeTimeout     = 2 * time.Minute  // How long a probe whose result is never recorded blocks other requests
+	latencyEWMAAlpha               = 0.2              // Weight of the newest sample in the latency moving average
+)
+
+// String returns string representation of CircuitState
+func (cs CircuitState) String() string {
+	switch cs {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseCircuitState parses string to CircuitState
+func ParseCircuitState(s string) CircuitState {
+	switch s {
+	case "open":
+		return CircuitOpen
+	case "half_open":
+		return CircuitHalfOpen
+	default:
+		return CircuitClosed
+	}
+}
+
+// MarshalJSON implements json.Marshaler for CircuitState
+func (cs CircuitState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cs.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler for CircuitState
+func (cs *CircuitState) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*cs = ParseCircuitState(s)
+	return nil
+}
+
+// IsAvailable reports whether the service may receive traffic, i.e. its circuit is not open,
+// no probe of its half-open circuit is in flight and it is not inside an upstream retry-after window
+func (s *Service) IsAvailable() bool {
+	s.InitializeStats()
+	return s.Stats.IsAvailable()
+}
+
+// AcquireProbe claims the probe of a half-open circuit for a request about to be sent
+func (s *Service) AcquireProbe() bool {
+	s.InitializeStats()
+	return s.Stats.AcquireProbe()
+}
+
+// ReleaseProbe gives up the probe claimed for a request that produced no verdict on the service
+func (s *Service) ReleaseProbe() {
+	s.InitializeStats()
+	s.Stats.ReleaseProbe()
+}
+
+// RecordResult records the outcome of a request to this service for health tracking
+func (s *Service) RecordResult(failed bool, latencyMs int, errMsg string) CircuitState {
+	s.InitializeStats()
+	return s.Stats.RecordResult(failed, latencyMs, errMsg)
+}
+
+// RecordResult records the outcome of a request and advances the circuit breaker.
+// Failures are upstream-side errors (rate limits, server errors, connection failures);
+// client errors should not be reported here. Returns the resulting circuit state.
+func (ss *ServiceStats) RecordResult(failed bool, latencyMs int, errMsg string) CircuitState {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	now := time.Now()
+
+	if latencyMs > 0 {
+		if ss.AvgLatencyMs == 0 {
+			ss.AvgLatencyMs = float64(latencyMs)
+		} else {
+			ss.AvgLatencyMs = latencyEWMAAlpha*float64(latencyMs) + (1-latencyEWMAAlpha)*ss.AvgLatencyMs
+		}
+	}
+
+	// Any outcome resolves the probe in flight
+	ss.ProbeStartedAt = time.Time{}
+
+	if !failed {
+		ss.ConsecutiveFailures = 0
+		ss.CircuitState = CircuitClosed
+		ss.CircuitOpenedAt = time.Time{}
+		return ss.CircuitState
+	}
+
+	// Start a new window if needed, mirroring RecordUsage
+	if now.Sub(ss.WindowStart) >= time.Duration(ss.TimeWindow)*time.Second {
+		ss.WindowStart = now
+		ss.WindowRequestCount = 0
+		ss.WindowTokensConsumed = 0
+		ss.WindowInputTokens = 0
+		ss.WindowOutputTokens = 0
+		ss.WindowErrorCount = 0
+	}
+
+	ss.ErrorCount++
+	ss.WindowErrorCount++
+	ss.ConsecutiveFailures++
+	ss.LastError = errMsg
+	ss.LastErrorAt = now
+
+	// A failed probe re-opens the circuit immediately; otherwise open once the threshold is reached
+	if ss.effectiveState(now) == CircuitHalfOpen || ss.ConsecutiveFailures >= DefaultCircuitFailureThreshold {
+		ss.CircuitState = CircuitOpen
+		ss.CircuitOpenedAt = now
+	}
+
+	return ss.CircuitState
+}
+
+// IsAvailable reports whether the circuit allows traffic (closed, or half-open with no probe
+// in flight) and the upstream has not asked to retry later
+func (ss *ServiceStats) IsAvailable() bool {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	now := time.Now()
+	state := ss.effectiveState(now)
+	if state == CircuitOpen || (state == CircuitHalfOpen && ss.probeInFlight(now)) {
+		return false
+	}
+	return !now.Before(ss.ThrottledUntil)
+}
+
+// AcquireProbe claims the probe of a half-open circuit, so that the service stays unavailable
+// to other requests until the result of the probe is recorded. It returns false when another
+// request's probe is still in flight. Closed and open circuits have no probe to claim and
+// always return true.
+func (ss *ServiceStats) AcquireProbe() bool {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	now := time.Now()
+	if ss.effectiveState(now) != CircuitHalfOpen {
+		return true
+	}
+	if ss.probeInFlight(now) {
+		return false
+	}
+	ss.ProbeStartedAt = now
+	return true
+}
+
+// ReleaseProbe gives up the probe in flight without a result, e.g. when the request failed
+// on the client side, so that the next request probes the service
+func (ss *ServiceStats) ReleaseProbe() {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	ss.ProbeStartedAt = time.Time{}
+}
+
+// GetCircuitState returns the current circuit state, reporting half-open once the cool-down has expired
+func (ss *ServiceStats) GetCircuitState() CircuitState {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	return ss.effectiveState(time.Now())
+}
+
+// ResetHealth closes the circuit, clears failure counters and forgets upstream throttling
+func (ss *ServiceStats) ResetHealth() {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	ss.ConsecutiveFailures = 0
+	ss.CircuitState = CircuitClosed
+	ss.CircuitOpenedAt = time.Time{}
+	ss.ProbeStartedAt = time.Time{}
+	ss.ThrottledUntil = time.Time{}
+	ss.RateLimitResetAt = time.Time{}
+}
+
+// effectiveState must be called with the mutex held
+func (ss *ServiceStats) effectiveState(now time.Time) CircuitState {
+	if ss.CircuitState == CircuitOpen && now.Sub(ss.CircuitOpenedAt) >= DefaultCircuitOpenDuration {
+		return CircuitHalfOpen
+	}
+	return ss.CircuitState
+}
+
+// probeInFlight must be called with the mutex held
+func (ss *ServiceStats) probeInFlight(now time.Time) bool {
+	return !ss.ProbeStartedAt.IsZero() && now.Sub(ss.ProbeStartedAt) < DefaultCircuitProbeTimeout
+}
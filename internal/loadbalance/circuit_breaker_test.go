@@ -0,0 +1,172 @@
+package loadbalance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServiceStats_CircuitOpensAfterConsecutiveFailures(t *testing.T) {
+	stats := &ServiceStats{
+		ServiceID:   "test:model",
+		TimeWindow:  60,
+		WindowStart: time.Now(),
+	}
+
+	for i := 0; i < DefaultCircuitFailureThreshold-1; i++ {
+		if state := stats.RecordResult(true, 100, "upstream error"); state != CircuitClosed {
+			t.Fatalf("Expected circuit to stay closed after %d failures, got %s", i+1, state)
+		}
+	}
+	if !stats.IsAvailable() {
+		t.Error("Expected service to be available before reaching the failure threshold")
+	}
+
+	if state := stats.RecordResult(true, 100, "upstream error"); state != CircuitOpen {
+		t.Fatalf("Expected circuit to open at threshold, got %s", state)
+	}
+	if stats.IsAvailable() {
+		t.Error("Expected service to be unavailable while circuit is open")
+	}
+	if stats.ErrorCount != DefaultCircuitFailureThreshold {
+		t.Errorf("Expected ErrorCount = %d, got %d", DefaultCircuitFailureThreshold, stats.ErrorCount)
+	}
+}
+
+func TestServiceStats_SuccessResetsFailures(t *testing.T) {
+	stats := &ServiceStats{TimeWindow: 60, WindowStart: time.Now()}
+
+	stats.RecordResult(true, 0, "boom")
+	stats.RecordResult(true, 0, "boom")
+	stats.RecordResult(false, 0, "")
+
+	if stats.ConsecutiveFailures != 0 {
+		t.Errorf("Expected ConsecutiveFailures = 0 after success, got %d", stats.ConsecutiveFailures)
+	}
+	if stats.ErrorCount != 2 {
+		t.Errorf("Expected ErrorCount = 2, got %d", stats.ErrorCount)
+	}
+}
+
+func TestServiceStats_HalfOpenAfterCooldown(t *testing.T) {
+	stats := &ServiceStats{
+		TimeWindow:      60,
+		WindowStart:     time.Now(),
+		CircuitState:    CircuitOpen,
+		CircuitOpenedAt: time.Now().Add(-DefaultCircuitOpenDuration - time.Second),
+	}
+
+	if got := stats.GetCircuitState(); got != CircuitHalfOpen {
+		t.Fatalf("Expected half_open after cool-down, got %s", got)
+	}
+	if !stats.IsAvailable() {
+		t.Error("Expected half-open service to accept a probe request")
+	}
+
+	// A failed probe re-opens the circuit immediately
+	if state := stats.RecordResult(true, 0, "still down"); state != CircuitOpen {
+		t.Fatalf("Expected failed probe to re-open circuit, got %s", state)
+	}
+
+	// A successful probe closes it
+	stats.CircuitOpenedAt = time.Now().Add(-DefaultCircuitOpenDuration - time.Second)
+	if state := stats.RecordResult(false, 0, ""); state != CircuitClosed {
+		t.Fatalf("Expected successful probe to close circuit, got %s", state)
+	}
+}
+
+func TestServiceStats_LatencyMovingAverage(t *testing.T) {
+	stats := &ServiceStats{TimeWindow: 60, WindowStart: time.Now()}
+
+	stats.RecordResult(false, 100, "")
+	if stats.AvgLatencyMs != 100 {
+		t.Errorf("Expected first sample to seed average, got %v", stats.AvgLatencyMs)
+	}
+
+	stats.RecordResult(false, 200, "")
+	if stats.AvgLatencyMs <= 100 || stats.AvgLatencyMs >= 200 {
+		t.Errorf("Expected average between samples, got %v", stats.AvgLatencyMs)
+	}
+}
+
+func TestCircuitState_String(t *testing.T) {
+	tests := []struct {
+		state    CircuitState
+		expected string
+	}{
+		{CircuitClosed, "closed"},
+		{CircuitOpen, "open"},
+		{CircuitHalfOpen, "half_open"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.state.String(); got != tt.expected {
+			t.Errorf("CircuitState.String() = %v, want %v", got, tt.expected)
+		}
+		if got := ParseCircuitState(tt.expected); got != tt.state {
+			t.Errorf("ParseCircuitState(%q) = %v, want %v", tt.expected, got, tt.state)
+		}
+	}
+}
+
+func TestServiceStats_RecordTTFT(t *testing.T) {
+	stats := &ServiceStats{TimeWindow: 60, WindowStart: time.Now()}
+
+	if _, ok := stats.GetAvgTTFT(); ok {
+		t.Error("Expected no TTFT data before any sample")
+	}
+
+	stats.RecordTTFT(0)
+	if _, ok := stats.GetAvgTTFT(); ok {
+		t.Error("Expected non-positive samples to be ignored")
+	}
+
+	stats.RecordTTFT(300)
+	stats.RecordTTFT(100)
+	avg, ok := stats.GetAvgTTFT()
+	if !ok {
+		t.Fatal("Expected TTFT data after samples")
+	}
+	if avg <= 100 || avg >= 300 {
+		t.Errorf("Expected average between samples, got %v", avg)
+	}
+}
+
+func TestServiceStats_HalfOpenAllowsSingleProbe(t *testing.T) {
+	stats := &ServiceStats{
+		TimeWindow:      60,
+		WindowStart:     time.Now(),
+		CircuitState:    CircuitOpen,
+		CircuitOpenedAt: time.Now().Add(-DefaultCircuitOpenDuration - time.Second),
+	}
+
+	if !stats.AcquireProbe() {
+		t.Fatal("Expected the first request to claim the probe")
+	}
+	if stats.IsAvailable() {
+		t.Error("Expected service to be unavailable while the probe is in flight")
+	}
+	if stats.AcquireProbe() {
+		t.Error("Expected a concurrent request not to claim the probe")
+	}
+
+	// A probe without a verdict lets the next request probe
+	stats.ReleaseProbe()
+	if !stats.IsAvailable() || !stats.AcquireProbe() {
+		t.Fatal("Expected the next request to claim the released probe")
+	}
+
+	// A probe whose result is never recorded stops blocking after the timeout
+	stats.ProbeStartedAt = time.Now().Add(-DefaultCircuitProbeTimeout - time.Second)
+	if !stats.IsAvailable() {
+		t.Error("Expected an expired probe to stop blocking the service")
+	}
+
+	// A successful probe closes the circuit for everyone
+	stats.AcquireProbe()
+	if state := stats.RecordResult(false, 0, ""); state != CircuitClosed {
+		t.Fatalf("Expected successful probe to close circuit, got %s", state)
+	}
+	if !stats.IsAvailable() || !stats.AcquireProbe() {
+		t.Error("Expected a closed circuit to accept every request")
+	}
+}
@@ -48,6 +48,12 @@ func (s *Service) RecordUsage(inputTokens, outputTokens int) {
 	s.Stats.RecordUsage(inputTokens, outputTokens)
 }
 
+// RecordTTFT records a time-to-first-token sample for this service
+func (s *Service) RecordTTFT(ttftMs int) {
+	s.InitializeStats()
+	s.Stats.RecordTTFT(ttftMs)
+}
+
 // GetWindowStats returns current window statistics for this service
 func (s *Service) GetWindowStats() (requestCount int64, tokensConsumed int64) {
 	s.InitializeStats()
@@ -65,6 +71,20 @@ type ServiceStats struct {
 	WindowInputTokens    int64        `json:"window_input_tokens"`    // Input tokens in current window
 	WindowOutputTokens   int64        `json:"window_output_tokens"`   // Output tokens in current window
 	TimeWindow           int          `json:"time_window"`            // Copy of service's time window
+	ErrorCount           int64        `json:"error_count"`            // Total upstream failures
+	WindowErrorCount     int64        `json:"window_error_count"`     // Upstream failures in current window
+	ConsecutiveFailures  int          `json:"consecutive_failures"`   // Failures since the last success
+	AvgLatencyMs         float64      `json:"avg_latency_ms"`         // Moving average of request latency
+	AvgTTFTMs            float64      `json:"avg_ttft_ms"`            // Moving average of time-to-first-token
+	TTFTSamples          int64        `json:"ttft_samples"`           // Number of time-to-first-token samples
+	LastError            string       `json:"last_error,omitempty"`   // Last upstream error message
+	LastErrorAt          time.Time    `json:"last_error_at"`          // Last upstream error timestamp
+	CircuitState         CircuitState `json:"circuit_state"`          // Circuit breaker state
+	CircuitOpenedAt      time.Time    `json:"circuit_opened_at"`      // When the circuit was last opened
+	ProbeStartedAt       time.Time    `json:"probe_started_at"`       // When the probe in flight of the half-open circuit was sent
+	ThrottledUntil       time.Time    `json:"throttled_until"`        // End of the upstream retry-after window
+	RateLimitHeadroom    float64      `json:"rate_limit_headroom"`    // Fraction of the upstream rate limit left (0-1)
+	RateLimitResetAt     time.Time    `json:"rate_limit_reset_at"`    // When the upstream rate limit headroom resets
 	mutex                sync.RWMutex `json:"-"`                      // Thread safety
 }
 
@@ -82,6 +102,7 @@ func (ss *ServiceStats) RecordUsage(inputTokens, outputTokens int) {
 		ss.WindowTokensConsumed = 0
 		ss.WindowInputTokens = 0
 		ss.WindowOutputTokens = 0
+		ss.WindowErrorCount = 0
 	}
 
 	ss.RequestCount++
@@ -92,6 +113,31 @@ func (ss *ServiceStats) RecordUsage(inputTokens, outputTokens int) {
 	ss.LastUsed = now
 }
 
+// RecordTTFT records a time-to-first-token sample into the moving average
+func (ss *ServiceStats) RecordTTFT(ttftMs int) {
+	if ttftMs <= 0 {
+		return
+	}
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	if ss.TTFTSamples == 0 {
+		ss.AvgTTFTMs = float64(ttftMs)
+	} else {
+		ss.AvgTTFTMs = latencyEWMAAlpha*float64(ttftMs) + (1-latencyEWMAAlpha)*ss.AvgTTFTMs
+	}
+	ss.TTFTSamples++
+}
+
+// GetAvgTTFT returns the time-to-first-token moving average and whether any sample exists
+func (ss *ServiceStats) GetAvgTTFT() (float64, bool) {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	return ss.AvgTTFTMs, ss.TTFTSamples > 0
+}
+
 // GetWindowStats returns current window statistics
 func (ss *ServiceStats) GetWindowStats() (requestCount int64, tokensConsumed int64) {
 	// Check if window has expired without locking first
@@ -142,6 +188,7 @@ func (ss *ServiceStats) ResetWindow() {
 	ss.WindowTokensConsumed = 0
 	ss.WindowInputTokens = 0
 	ss.WindowOutputTokens = 0
+	ss.WindowErrorCount = 0
 }
 
 // GetStats returns a copy of current statistics
@@ -159,6 +206,20 @@ func (ss *ServiceStats) GetStats() ServiceStats {
 		WindowInputTokens:    ss.WindowInputTokens,
 		WindowOutputTokens:   ss.WindowOutputTokens,
 		TimeWindow:           ss.TimeWindow,
+		ErrorCount:           ss.ErrorCount,
+		WindowErrorCount:     ss.WindowErrorCount,
+		ConsecutiveFailures:  ss.ConsecutiveFailures,
+		AvgLatencyMs:         ss.AvgLatencyMs,
+		AvgTTFTMs:            ss.AvgTTFTMs,
+		TTFTSamples:          ss.TTFTSamples,
+		LastError:            ss.LastError,
+		LastErrorAt:          ss.LastErrorAt,
+		CircuitState:         ss.effectiveState(time.Now()),
+		CircuitOpenedAt:      ss.CircuitOpenedAt,
+		ProbeStartedAt:       ss.ProbeStartedAt,
+		ThrottledUntil:       ss.ThrottledUntil,
+		RateLimitHeadroom:    ss.RateLimitHeadroom,
+		RateLimitResetAt:     ss.RateLimitResetAt,
 	}
 }
 
@@ -166,10 +227,12 @@ func (ss *ServiceStats) GetStats() ServiceStats {
 type TacticType int
 
 const (
-	TacticRoundRobin TacticType = iota // Rotate by request count
-	TacticTokenBased                   // Rotate by token consumption
-	TacticHybrid                       // Hybrid: request count or tokens, whichever comes first
-	TacticRandom                       // Random selection with weighted probability
+	TacticRoundRobin   TacticType = iota // Rotate by request count
+	TacticTokenBased                     // Rotate by token consumption
+	TacticHybrid                         // Hybrid: request count or tokens, whichever comes first
+	TacticRandom                         // Random selection with weighted probability
+	TacticLeastLatency                   // Lowest moving average of time-to-first-token
+	TacticLowestCost                     // Cheapest service by per-model pricing
 )
 
 // MarshalJSON implements json.Marshaler for TacticType
@@ -204,6 +267,10 @@ func (tt TacticType) String() string {
 		return "hybrid"
 	case TacticRandom:
 		return "random"
+	case TacticLeastLatency:
+		return "least_latency"
+	case TacticLowestCost:
+		return "lowest_cost"
 	default:
 		return "unknown"
 	}
@@ -220,6 +287,10 @@ func ParseTacticType(s string) TacticType {
 		return TacticHybrid
 	case "random":
 		return TacticRandom
+	case "least_latency":
+		return TacticLeastLatency
+	case "lowest_cost":
+		return TacticLowestCost
 	default:
 		return TacticRoundRobin // default
 	}
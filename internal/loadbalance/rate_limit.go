@@ -0,0 +1,72 @@
+package loadbalance
+
+import "time"
+
+// Upstream rate limit defaults
+const (
+	// DefaultRateLimitHeadroomThreshold is the fraction of the upstream rate limit below
+	// which a service is considered near its limit and deprioritized
+	DefaultRateLimitHeadroomThreshold = 0.1
+	// defaultRateLimitTTL is how long a headroom observation is trusted when the
+	// upstream did not say when its limit resets
+	defaultRateLimitTTL = time.Minute
+)
+
+// UpstreamRateLimit is the rate limit state reported by an upstream provider
+// through its response headers
+type UpstreamRateLimit struct {
+	Headroom   float64   // Fraction of the most constrained limit left (0-1), negative if unknown
+	ResetAt    time.Time // When the headroom resets (zero if unknown)
+	RetryUntil time.Time // End of the retry-after window (zero if none)
+}
+
+// RecordUpstreamRateLimit records the rate limit state reported by the upstream for this service
+func (s *Service) RecordUpstreamRateLimit(rl UpstreamRateLimit) {
+	s.InitializeStats()
+	s.Stats.RecordUpstreamRateLimit(rl)
+}
+
+// IsNearRateLimit reports whether the service is close to its upstream rate limit
+func (s *Service) IsNearRateLimit() bool {
+	s.InitializeStats()
+	return s.Stats.IsNearRateLimit()
+}
+
+// RecordUpstreamRateLimit records the rate limit state reported by the upstream.
+// A retry-after window makes the service unavailable until it expires; the
+// headroom is used to deprioritize the service until its limit resets.
+func (ss *ServiceStats) RecordUpstreamRateLimit(rl UpstreamRateLimit) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	now := time.Now()
+
+	if rl.RetryUntil.After(ss.ThrottledUntil) {
+		ss.ThrottledUntil = rl.RetryUntil
+	}
+
+	if rl.Headroom >= 0 {
+		ss.RateLimitHeadroom = rl.Headroom
+		ss.RateLimitResetAt = rl.ResetAt
+		if ss.RateLimitResetAt.IsZero() {
+			ss.RateLimitResetAt = now.Add(defaultRateLimitTTL)
+		}
+	}
+}
+
+// IsThrottled reports whether the service is inside an upstream retry-after window
+func (ss *ServiceStats) IsThrottled() bool {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	return time.Now().Before(ss.ThrottledUntil)
+}
+
+// IsNearRateLimit reports whether the last reported headroom is below
+// DefaultRateLimitHeadroomThreshold and the limit has not reset since
+func (ss *ServiceStats) IsNearRateLimit() bool {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	return time.Now().Before(ss.RateLimitResetAt) && ss.RateLimitHeadroom < DefaultRateLimitHeadroomThreshold
+}
@@ -0,0 +1,73 @@
+package loadbalance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServiceStats_RetryAfterMakesServiceUnavailable(t *testing.T) {
+	stats := &ServiceStats{TimeWindow: 60, WindowStart: time.Now()}
+
+	stats.RecordUpstreamRateLimit(UpstreamRateLimit{Headroom: -1, RetryUntil: time.Now().Add(time.Minute)})
+	if !stats.IsThrottled() {
+		t.Error("Expected service to be throttled inside the retry-after window")
+	}
+	if stats.IsAvailable() {
+		t.Error("Expected service to be unavailable inside the retry-after window")
+	}
+
+	// The window expired
+	stats.ThrottledUntil = time.Now().Add(-time.Second)
+	if !stats.IsAvailable() {
+		t.Error("Expected service to be available once the retry-after window expired")
+	}
+}
+
+func TestServiceStats_RetryAfterKeepsLongestWindow(t *testing.T) {
+	stats := &ServiceStats{}
+	until := time.Now().Add(time.Minute)
+
+	stats.RecordUpstreamRateLimit(UpstreamRateLimit{Headroom: -1, RetryUntil: until})
+	stats.RecordUpstreamRateLimit(UpstreamRateLimit{Headroom: -1, RetryUntil: time.Now().Add(time.Second)})
+
+	if !stats.ThrottledUntil.Equal(until) {
+		t.Errorf("Expected ThrottledUntil = %v, got %v", until, stats.ThrottledUntil)
+	}
+}
+
+func TestServiceStats_NearRateLimit(t *testing.T) {
+	stats := &ServiceStats{}
+	if stats.IsNearRateLimit() {
+		t.Error("Expected service without rate limit info not to be near its limit")
+	}
+
+	stats.RecordUpstreamRateLimit(UpstreamRateLimit{Headroom: 0.05, ResetAt: time.Now().Add(time.Minute)})
+	if !stats.IsNearRateLimit() {
+		t.Error("Expected service with 5% headroom to be near its limit")
+	}
+	if !stats.IsAvailable() {
+		t.Error("Expected service near its limit to stay available")
+	}
+
+	stats.RecordUpstreamRateLimit(UpstreamRateLimit{Headroom: 0.5, ResetAt: time.Now().Add(time.Minute)})
+	if stats.IsNearRateLimit() {
+		t.Error("Expected service with 50% headroom not to be near its limit")
+	}
+
+	// Headroom is forgotten once the limit resets
+	stats.RecordUpstreamRateLimit(UpstreamRateLimit{Headroom: 0, ResetAt: time.Now().Add(-time.Second)})
+	if stats.IsNearRateLimit() {
+		t.Error("Expected headroom to be ignored after the limit reset")
+	}
+}
+
+func TestServiceStats_ResetHealthClearsThrottling(t *testing.T) {
+	stats := &ServiceStats{}
+	stats.RecordUpstreamRateLimit(UpstreamRateLimit{Headroom: 0, RetryUntil: time.Now().Add(time.Minute)})
+
+	stats.ResetHealth()
+
+	if stats.IsThrottled() || stats.IsNearRateLimit() {
+		t.Error("Expected ResetHealth to clear upstream throttling")
+	}
+}
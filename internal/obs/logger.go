@@ -24,6 +24,9 @@ const (
 	ActionGenerateToken  ActionType = "generate_token"
 	ActionUpdateDefaults ActionType = "update_defaults"
 	ActionFetchModels    ActionType = "fetch_models"
+	ActionCreateAPIKey   ActionType = "create_api_key"
+	ActionRevokeAPIKey   ActionType = "revoke_api_key"
+	ActionRotateAPIKey   ActionType = "rotate_api_key"
 )
 
 // HistoryEntry represents a single history entry
@@ -15,7 +15,7 @@ var (
 	// AttrLLMRequestModel identifies the model requested by the user
 	AttrLLMRequestModel = attribute.Key("llm.request.model")
 
-	// AttrLLMTokenType identifies the type of token (input/output)
+	// AttrLLMTokenType identifies the type of token (input/output/cache_read/cache_creation/reasoning)
 	AttrLLMTokenType = attribute.Key("llm.token_type")
 
 	// AttrLLMScenario identifies the API scenario (e.g., "openai", "anthropic", "claude_code")
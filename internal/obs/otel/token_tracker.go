@@ -33,6 +33,15 @@ type UsageOptions struct {
 	// OutputTokens is the number of output/completion tokens consumed
 	OutputTokens int
 
+	// CacheReadTokens is the part of InputTokens served from the prompt cache
+	CacheReadTokens int
+
+	// CacheCreationTokens is the part of InputTokens written to the prompt cache
+	CacheCreationTokens int
+
+	// ReasoningTokens is the part of OutputTokens spent on reasoning
+	ReasoningTokens int
+
 	// Streamed indicates whether this was a streaming request
 	Streamed bool
 
@@ -66,7 +75,7 @@ func NewTokenTracker(meter metric.Meter) (*TokenTracker, error) {
 	// Token usage counters
 	tt.inputTokens, err = meter.Int64Counter(
 		"llm.token.usage",
-		metric.WithDescription("LLM token usage by type (input/output)"),
+		metric.WithDescription("LLM token usage by type (input/output/cache_read/cache_creation/reasoning)"),
 		metric.WithUnit("{token}"),
 	)
 	if err != nil {
@@ -75,7 +84,7 @@ func NewTokenTracker(meter metric.Meter) (*TokenTracker, error) {
 
 	tt.outputTokens, err = meter.Int64Counter(
 		"llm.token.usage",
-		metric.WithDescription("LLM token usage by type (input/output)"),
+		metric.WithDescription("LLM token usage by type (input/output/cache_read/cache_creation/reasoning)"),
 		metric.WithUnit("{token}"),
 	)
 	if err != nil {
@@ -157,6 +166,20 @@ func (tt *TokenTracker) RecordUsage(ctx context.Context, opts UsageOptions) {
 		tt.outputTokens.Add(ctx, int64(opts.OutputTokens), metric.WithAttributes(outputAttrs...))
 	}
 
+	// Record the cache and reasoning breakdown (subsets of input/output)
+	if opts.CacheReadTokens > 0 {
+		cacheReadAttrs := append(commonAttrs, AttrLLMTokenType.String("cache_read"))
+		tt.inputTokens.Add(ctx, int64(opts.CacheReadTokens), metric.WithAttributes(cacheReadAttrs...))
+	}
+	if opts.CacheCreationTokens > 0 {
+		cacheCreationAttrs := append(commonAttrs, AttrLLMTokenType.String("cache_creation"))
+		tt.inputTokens.Add(ctx, int64(opts.CacheCreationTokens), metric.WithAttributes(cacheCreationAttrs...))
+	}
+	if opts.ReasoningTokens > 0 {
+		reasoningAttrs := append(commonAttrs, AttrLLMTokenType.String("reasoning"))
+		tt.outputTokens.Add(ctx, int64(opts.ReasoningTokens), metric.WithAttributes(reasoningAttrs...))
+	}
+
 	// Record total tokens
 	totalTokens := opts.InputTokens + opts.OutputTokens
 	if totalTokens > 0 {
@@ -0,0 +1,205 @@
+package obs
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Record retention defaults
+const (
+	DefaultRecordMaxFileBytes  = 100 << 20           // Rotate a record file once it reaches 100MB
+	DefaultRecordMaxAge        = 30 * 24 * time.Hour // Purge rotated files after 30 days
+	DefaultRecordMaxTotalBytes = 2 << 30             // Purge the oldest rotated files above 2GB in total
+)
+
+const (
+	recordFileExt        = ".jsonl"
+	rotatedRecordFileExt = ".jsonl.gz"
+)
+
+// RetentionPolicy controls the rotation and purging of the record files of a Sink.
+//
+// Record files rotate daily and once they reach MaxFileBytes. Rotated files are gzipped,
+// and purged once older than MaxAge or, oldest first, while the record directory holds
+// more than MaxTotalBytes. Zero disables the corresponding limit.
+type RetentionPolicy struct {
+	MaxFileBytes  int64         // Size at which a record file is rotated
+	MaxAge        time.Duration // Age after which rotated files are purged
+	MaxTotalBytes int64         // Total size of the record directory above which rotated files are purged
+}
+
+// DefaultRetentionPolicy returns the retention policy of sinks created without one
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		MaxFileBytes:  DefaultRecordMaxFileBytes,
+		MaxAge:        DefaultRecordMaxAge,
+		MaxTotalBytes: DefaultRecordMaxTotalBytes,
+	}
+}
+
+// rotateFile moves a record file that reached its size limit aside, to be compressed
+// by the next maintenance
+func rotateFile(path string) error {
+	base := strings.TrimSuffix(path, recordFileExt)
+	for i := 1; ; i++ {
+		rotated := fmt.Sprintf("%s.%d%s", base, i, recordFileExt)
+		if fileExists(rotated) || fileExists(rotated+".gz") {
+			continue
+		}
+		return os.Rename(path, rotated)
+	}
+}
+
+// maintain compresses the record files that are no longer written and purges rotated
+// files according to the retention policy. active lists the files open for writing.
+func maintain(dir string, policy RetentionPolicy, active map[string]bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		logrus.Errorf("Failed to list record directory %s: %v", dir, err)
+		return
+	}
+
+	// Files last written before today are not written anymore, files are opened per day
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+		if entry.IsDir() || !strings.HasSuffix(name, recordFileExt) || active[path] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if isRotatedName(name) || info.ModTime().UTC().Before(today) {
+			if err := compressFile(path); err != nil {
+				logrus.Errorf("Failed to compress record file %s: %v", path, err)
+			}
+		}
+	}
+
+	purge(dir, policy)
+}
+
+// purge removes rotated files older than MaxAge, then the oldest rotated files while the
+// record files take more than MaxTotalBytes
+func purge(dir string, policy RetentionPolicy) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	type recordFileInfo struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var rotated []recordFileInfo
+	var total int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, recordFileExt) || strings.HasSuffix(name, rotatedRecordFileExt)) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		total += info.Size()
+		if strings.HasSuffix(name, rotatedRecordFileExt) {
+			rotated = append(rotated, recordFileInfo{path: filepath.Join(dir, name), size: info.Size(), modTime: info.ModTime()})
+		}
+	}
+	sort.Slice(rotated, func(i, j int) bool {
+		return rotated[i].modTime.Before(rotated[j].modTime)
+	})
+
+	for _, f := range rotated {
+		expired := policy.MaxAge > 0 && time.Since(f.modTime) > policy.MaxAge
+		overLimit := policy.MaxTotalBytes > 0 && total > policy.MaxTotalBytes
+		if !expired && !overLimit {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil {
+			logrus.Errorf("Failed to purge record file %s: %v", f.path, err)
+			continue
+		}
+		total -= f.size
+		logrus.Debugf("Purged record file %s", f.path)
+	}
+}
+
+// compressFile gzips a record file next to it and removes the original
+func compressFile(path string) error {
+	dst := path + ".gz"
+	base := strings.TrimSuffix(path, recordFileExt)
+	for i := 1; fileExists(dst); i++ {
+		dst = fmt.Sprintf("%s.%d%s", base, i, rotatedRecordFileExt)
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	zw.Name = filepath.Base(path)
+	zw.ModTime = info.ModTime()
+	if _, err := io.Copy(zw, src); err != nil {
+		zw.Close()
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	// Keep the time of the last record, purging goes by it
+	_ = os.Chtimes(dst, info.ModTime(), info.ModTime())
+	src.Close()
+	return os.Remove(path)
+}
+
+// isRotatedName reports whether a record file name carries a rotation index (name.N.jsonl)
+func isRotatedName(name string) bool {
+	base := strings.TrimSuffix(name, recordFileExt)
+	dot := strings.LastIndexByte(base, '.')
+	if dot < 0 || dot == len(base)-1 {
+		return false
+	}
+	for _, r := range base[dot+1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -19,7 +19,7 @@ const (
 	RecordModeAll      RecordMode = "all"      // Record both request and response
 	RecordModeResponse RecordMode = "response" // Record only response
 	RecordModeScenario RecordMode = "scenario"
-	RecordModeSlim     RecordMode = "slim" // TODO: Not implemented yet
+	RecordModeSlim     RecordMode = "slim" // Record metadata, message previews, usage and errors only
 )
 
 // RecordEntry represents a single recorded request/response pair
@@ -56,33 +56,54 @@ type RecordResponse struct {
 
 // Sink manages recording of HTTP requests/responses to JSONL files
 type Sink struct {
-	mode    RecordMode
-	baseDir string
-	fileMap map[string]*recordFile // provider -> file
-	mutex   sync.RWMutex
+	mode      RecordMode
+	baseDir   string
+	retention RetentionPolicy
+	fileMap   map[string]*recordFile // provider -> file
+	mutex     sync.RWMutex
+
+	// background maintenance (compression and purging of record files)
+	maintaining     bool
+	maintainPending bool
+	wg              sync.WaitGroup
+}
+
+// SinkOption configures a Sink
+type SinkOption func(*Sink)
+
+// WithRetention sets the rotation and purging policy of the record files
+func WithRetention(policy RetentionPolicy) SinkOption {
+	return func(s *Sink) {
+		s.retention = policy
+	}
 }
 
 // recordFile holds a file handle and its writer
 type recordFile struct {
-	file        *os.File
-	writer      *json.Encoder
-	currentHour string // time in YYYY-MM-DD-HH format (hourly rotation)
+	file       *os.File
+	writer     *json.Encoder
+	path       string
+	currentDay string // date in YYYY-MM-DD format (daily rotation)
+	size       int64  // bytes in the file, for size rotation
+}
+
+// Write writes to the file and counts the bytes written
+func (rf *recordFile) Write(p []byte) (int, error) {
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
 }
 
 // NewSink creates a new record sink
-// mode: empty string = disabled, "all" = record all, "response" = response only
-func NewSink(baseDir string, mode RecordMode) *Sink {
+// mode: empty string = disabled, "all" = record all, "response" = response only, "slim" = metadata only
+// Record files follow DefaultRetentionPolicy unless WithRetention is given.
+func NewSink(baseDir string, mode RecordMode, opts ...SinkOption) *Sink {
 	switch mode {
 	case "":
 		// Empty mode means recording is disabled
 		return nil
 
-	case RecordModeSlim:
-		// Check for slim mode (not implemented)
-		logrus.Warnf("Record mode 'slim' is not implemented yet, please use 'all' or 'response'")
-		return nil
-
-	case RecordModeAll, RecordModeResponse, RecordModeScenario:
+	case RecordModeAll, RecordModeResponse, RecordModeScenario, RecordModeSlim:
 
 		// Ensure base directory exists
 		if err := os.MkdirAll(baseDir, 0755); err != nil {
@@ -90,11 +111,21 @@ func NewSink(baseDir string, mode RecordMode) *Sink {
 			return nil
 		}
 
-		return &Sink{
-			mode:    mode,
-			baseDir: baseDir,
-			fileMap: make(map[string]*recordFile),
+		sink := &Sink{
+			mode:      mode,
+			baseDir:   baseDir,
+			retention: DefaultRetentionPolicy(),
+			fileMap:   make(map[string]*recordFile),
+		}
+		for _, opt := range opts {
+			opt(sink)
 		}
+
+		// Compress and purge the files left by previous runs
+		sink.mutex.Lock()
+		sink.maintainAsync()
+		sink.mutex.Unlock()
+		return sink
 	default:
 		// Invalid mode
 		logrus.Warnf("Invalid record mode '%s', recording disabled", mode)
@@ -117,9 +148,13 @@ func (r *Sink) Record(provider, model string, req *RecordRequest, resp *RecordRe
 		DurationMs: duration.Milliseconds(),
 	}
 
-	// Only include request if mode is "all"
-	if r.mode == RecordModeAll {
+	// Only include request if mode is "all", slim mode keeps previews of both
+	switch r.mode {
+	case RecordModeAll:
 		entry.Request = req
+	case RecordModeSlim:
+		entry.Request = slimRequest(req)
+		entry.Response = slimResponse(resp)
 	}
 
 	if err != nil {
@@ -145,9 +180,13 @@ func (r *Sink) RecordWithMetadata(provider, model string, req *RecordRequest, re
 		Metadata:   metadata,
 	}
 
-	// Only include request if mode is "all"
-	if r.mode == RecordModeAll {
+	// Only include request if mode is "all", slim mode keeps previews of both
+	switch r.mode {
+	case RecordModeAll:
 		entry.Request = req
+	case RecordModeSlim:
+		entry.Request = slimRequest(req)
+		entry.Response = slimResponse(resp)
 	}
 
 	if err != nil {
@@ -179,6 +218,9 @@ func (r *Sink) RecordWithScenario(provider, model, scenario string, req *RecordR
 		entry.Request = req
 	case RecordModeScenario:
 		entry.Request = req
+	case RecordModeSlim:
+		entry.Request = slimRequest(req)
+		entry.Response = slimResponse(resp)
 	}
 
 	if err != nil {
@@ -191,81 +233,103 @@ func (r *Sink) RecordWithScenario(provider, model, scenario string, req *RecordR
 
 // writeEntry writes an entry to the appropriate file
 func (r *Sink) writeEntry(provider string, entry *RecordEntry) {
+	r.write(provider, func(day string) string {
+		return fmt.Sprintf("%s-%s.jsonl", provider, day)
+	}, entry)
+}
+
+// writeEntryWithScenario writes an entry to a scenario-based file
+func (r *Sink) writeEntryWithScenario(scenario string, entry *RecordEntry) {
+	// Use scenario as the file key
+	fileKey := fmt.Sprintf("scenario:%s:%s", scenario, entry.Provider)
+	r.write(fileKey, func(day string) string {
+		return fmt.Sprintf("%s.%s.%s.jsonl", scenario, entry.Provider, day)
+	}, entry)
+}
+
+// write writes an entry to the file of the given key, opening the file of the day
+// and rotating it once it reaches the size limit
+func (r *Sink) write(fileKey string, filename func(day string) string, entry *RecordEntry) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	// Get current hour for file rotation (YYYY-MM-DD-HH)
-	currentHour := time.Now().UTC().Format("2006-01-02-15")
+	// Get current day for file rotation (YYYY-MM-DD)
+	currentDay := time.Now().UTC().Format("2006-01-02")
 
-	// Get or create file for this provider
-	rf, exists := r.fileMap[provider]
-	if !exists || rf.currentHour != currentHour {
-		// Close old file if hour changed
-		if exists && rf.currentHour != currentHour {
+	// Get or create file for this key
+	rf, exists := r.fileMap[fileKey]
+	if !exists || rf.currentDay != currentDay {
+		// Close old file if day changed, it is compressed by the maintenance
+		if exists {
 			r.closeFile(rf)
+			delete(r.fileMap, fileKey)
+			r.maintainAsync()
 		}
 
 		// Create new file
-		filename := filepath.Join(r.baseDir, fmt.Sprintf("%s-%s.jsonl", provider, currentHour))
-		file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		path := filepath.Join(r.baseDir, filename(currentDay))
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 		if err != nil {
-			logrus.Errorf("Failed to open record file %s: %v", filename, err)
+			logrus.Errorf("Failed to open record file %s: %v", path, err)
 			return
 		}
+		var size int64
+		if info, err := file.Stat(); err == nil {
+			size = info.Size()
+		}
 
 		rf = &recordFile{
-			file:        file,
-			writer:      json.NewEncoder(file),
-			currentHour: currentHour,
+			file:       file,
+			path:       path,
+			currentDay: currentDay,
+			size:       size,
 		}
-		r.fileMap[provider] = rf
+		rf.writer = json.NewEncoder(rf)
+		r.fileMap[fileKey] = rf
 	}
 
 	// Write entry as JSONL (one JSON object per line)
 	if err := rf.writer.Encode(entry); err != nil {
 		logrus.Errorf("Failed to write record entry: %v", err)
 	}
-}
 
-// writeEntryWithScenario writes an entry to a scenario-based file
-func (r *Sink) writeEntryWithScenario(scenario string, entry *RecordEntry) {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
-	// Get current hour for file rotation (YYYY-MM-DD-HH)
-	currentHour := time.Now().UTC().Format("2006-01-02-15")
-
-	// Use scenario as the file key
-	fileKey := fmt.Sprintf("scenario:%s:%s", scenario, entry.Provider)
-
-	// Get or create file for this scenario
-	rf, exists := r.fileMap[fileKey]
-	if !exists || rf.currentHour != currentHour {
-		// Close old file if hour changed
-		if exists && rf.currentHour != currentHour {
-			r.closeFile(rf)
-		}
-
-		// Create new file with scenario-based naming
-		filename := filepath.Join(r.baseDir, fmt.Sprintf("%s.%s.%s.jsonl", scenario, entry.Provider, currentHour))
-		file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-		if err != nil {
-			logrus.Errorf("Failed to open record file %s: %v", filename, err)
-			return
+	// Rotate the file once it reaches the size limit
+	if r.retention.MaxFileBytes > 0 && rf.size >= r.retention.MaxFileBytes {
+		r.closeFile(rf)
+		delete(r.fileMap, fileKey)
+		if err := rotateFile(rf.path); err != nil {
+			logrus.Errorf("Failed to rotate record file %s: %v", rf.path, err)
 		}
+		r.maintainAsync()
+	}
+}
 
-		rf = &recordFile{
-			file:        file,
-			writer:      json.NewEncoder(file),
-			currentHour: currentHour,
-		}
-		r.fileMap[fileKey] = rf
+// maintainAsync compresses and purges record files in the background, at most one
+// maintenance runs at a time. The caller holds the mutex.
+func (r *Sink) maintainAsync() {
+	if r.maintaining {
+		r.maintainPending = true
+		return
 	}
+	r.maintaining = true
 
-	// Write entry as JSONL (one JSON object per line)
-	if err := rf.writer.Encode(entry); err != nil {
-		logrus.Errorf("Failed to write record entry: %v", err)
+	active := make(map[string]bool, len(r.fileMap))
+	for _, rf := range r.fileMap {
+		active[rf.path] = true
 	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		maintain(r.baseDir, r.retention, active)
+
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		r.maintaining = false
+		if r.maintainPending {
+			r.maintainPending = false
+			r.maintainAsync()
+		}
+	}()
 }
 
 // closeFile closes a record file
@@ -280,12 +344,14 @@ func (r *Sink) closeFile(rf *recordFile) {
 // Close closes all open record files
 func (r *Sink) Close() {
 	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
 	for _, rf := range r.fileMap {
 		r.closeFile(rf)
 	}
 	r.fileMap = make(map[string]*recordFile)
+	r.mutex.Unlock()
+
+	// Let a running maintenance finish
+	r.wg.Wait()
 
 	if r.mode != "" {
 		logrus.Info("Record sink closed")
@@ -0,0 +1,147 @@
+package obs
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readEntries(t *testing.T, path string) []RecordEntry {
+	t.Helper()
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var entries []RecordEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1<<20), 1<<20)
+	for scanner.Scan() {
+		var entry RecordEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestSink_SlimMode(t *testing.T) {
+	dir := t.TempDir()
+	sink := NewSink(dir, RecordModeSlim)
+	require.NotNil(t, sink)
+
+	req := &RecordRequest{
+		Method:  "POST",
+		URL:     "https://api.example.com/v1/chat/completions",
+		Headers: map[string]string{"Authorization": "Bearer sk-secret"},
+		Body: map[string]interface{}{
+			"model":      "gpt-4o",
+			"max_tokens": float64(1024),
+			"tools":      []interface{}{map[string]interface{}{"type": "function"}},
+			"messages": []interface{}{
+				map[string]interface{}{"role": "system", "content": "You are helpful"},
+				map[string]interface{}{"role": "user", "content": strings.Repeat("long question ", 100)},
+			},
+		},
+	}
+	resp := &RecordResponse{
+		StatusCode: 200,
+		Headers:    map[string]string{"X-Request-Id": "abc"},
+		Body: map[string]interface{}{
+			"id":    "chatcmpl-1",
+			"usage": map[string]interface{}{"prompt_tokens": float64(400), "completion_tokens": float64(5)},
+			"choices": []interface{}{map[string]interface{}{
+				"finish_reason": "stop",
+				"message":       map[string]interface{}{"role": "assistant", "content": "Hello"},
+			}},
+		},
+	}
+	sink.RecordWithScenario("openai-provider", "gpt-4o", "openai", req, resp, time.Second, nil)
+	sink.Close()
+
+	files, err := filepath.Glob(filepath.Join(dir, "openai.openai-provider.*.jsonl"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	entries := readEntries(t, files[0])
+	require.Len(t, entries, 1)
+
+	entry := entries[0]
+	require.NotNil(t, entry.Request)
+	assert.Empty(t, entry.Request.Headers, "headers are left out")
+	assert.Equal(t, "gpt-4o", entry.Request.Body["model"])
+	assert.Equal(t, float64(1), entry.Request.Body["tool_count"])
+	assert.Equal(t, float64(2), entry.Request.Body["message_count"])
+	messages := entry.Request.Body["messages"].([]interface{})
+	preview := messages[1].(map[string]interface{})["preview"].(string)
+	assert.Less(t, len(preview), DefaultSlimPreviewBytes+32)
+	assert.True(t, strings.HasSuffix(preview, "(1400 bytes)"))
+
+	require.NotNil(t, entry.Response)
+	assert.Equal(t, 200, entry.Response.StatusCode)
+	assert.Empty(t, entry.Response.Headers)
+	assert.Equal(t, "stop", entry.Response.Body["finish_reason"])
+	assert.Equal(t, "Hello", entry.Response.Body["output_preview"])
+	assert.Contains(t, entry.Response.Body, "usage")
+	assert.NotContains(t, entry.Response.Body, "choices")
+}
+
+func TestSink_RotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	sink := NewSink(dir, RecordModeResponse, WithRetention(RetentionPolicy{MaxFileBytes: 512}))
+	require.NotNil(t, sink)
+
+	resp := &RecordResponse{StatusCode: 200, Body: map[string]interface{}{"text": strings.Repeat("x", 200)}}
+	for i := 0; i < 4; i++ {
+		sink.Record("provider", "model", nil, resp, time.Millisecond, nil)
+	}
+	sink.Close()
+
+	rotated, err := filepath.Glob(filepath.Join(dir, "provider-*.jsonl.gz"))
+	require.NoError(t, err)
+	assert.Len(t, rotated, 2, "every second entry crosses the size limit")
+
+	zf, err := os.Open(rotated[0])
+	require.NoError(t, err)
+	defer zf.Close()
+	zr, err := gzip.NewReader(zf)
+	require.NoError(t, err)
+	var entry RecordEntry
+	require.NoError(t, json.NewDecoder(zr).Decode(&entry))
+	assert.Equal(t, "provider", entry.Provider)
+}
+
+func TestMaintain_CompressesAndPurges(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, size int, age time.Duration) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", size)), 0644))
+		mtime := time.Now().Add(-age)
+		require.NoError(t, os.Chtimes(path, mtime, mtime))
+		return path
+	}
+
+	expired := write("p-2024-01-01.jsonl.gz", 10, 60*24*time.Hour)
+	stale := write("p-2024-02-01.jsonl", 100, 48*time.Hour)
+	old := write("p-2024-01-15.jsonl.gz", 1000, 72*time.Hour)
+	active := write("p-today.jsonl", 1000, 0)
+
+	maintain(dir, RetentionPolicy{MaxAge: 30 * 24 * time.Hour, MaxTotalBytes: 1500}, map[string]bool{active: true})
+
+	assert.NoFileExists(t, expired, "older than the max age")
+	assert.NoFileExists(t, stale, "files not written today are compressed")
+	assert.FileExists(t, stale+".gz")
+	assert.NoFileExists(t, old, "oldest rotated file purged above the total size")
+	assert.FileExists(t, active)
+}
+
+func TestIsRotatedName(t *testing.T) {
+	assert.True(t, isRotatedName("provider-2026-01-02.3.jsonl"))
+	assert.False(t, isRotatedName("provider-2026-01-02.jsonl"))
+	assert.False(t, isRotatedName("claude_code.provider.2026-01-02.jsonl"))
+}
@@ -0,0 +1,212 @@
+package obs
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultSlimPreviewBytes is the length of the message previews kept in slim mode
+const DefaultSlimPreviewBytes = 256
+
+// slimRequestFields are the request body fields kept as is in slim mode
+var slimRequestFields = []string{
+	"model", "stream", "max_tokens", "max_completion_tokens", "max_output_tokens",
+	"temperature", "top_p", "tool_choice", "reasoning_effort", "thinking", "metadata", "user",
+}
+
+// slimResponseFields are the response body fields kept as is in slim mode
+var slimResponseFields = []string{
+	"id", "model", "usage", "usageMetadata", "stop_reason", "status", "error", "incomplete_details",
+}
+
+// slimRequest keeps the method, URL and parameters of a request, and replaces its
+// messages with truncated previews. Headers are left out.
+func slimRequest(req *RecordRequest) *RecordRequest {
+	if req == nil {
+		return nil
+	}
+	slim := &RecordRequest{
+		Method: req.Method,
+		URL:    req.URL,
+	}
+	if req.Body == nil {
+		return slim
+	}
+
+	body := make(map[string]interface{})
+	for _, field := range slimRequestFields {
+		if v, ok := req.Body[field]; ok {
+			body[field] = v
+		}
+	}
+	if system, ok := req.Body["system"]; ok {
+		body["system"] = previewText(contentText(system))
+	}
+	if instructions, ok := req.Body["instructions"].(string); ok {
+		body["instructions"] = previewText(instructions)
+	}
+	if tools, ok := req.Body["tools"].([]interface{}); ok {
+		body["tool_count"] = len(tools)
+	}
+	for _, field := range []string{"messages", "input", "contents"} {
+		switch v := req.Body[field].(type) {
+		case []interface{}:
+			body[field] = previewMessages(v)
+			body["message_count"] = len(v)
+		case string:
+			body[field] = previewText(v)
+		}
+	}
+	slim.Body = body
+	return slim
+}
+
+// slimResponse keeps the status, usage, stop reason and errors of a response, and
+// replaces its output with a truncated preview. Headers and stream chunks are left out.
+func slimResponse(resp *RecordResponse) *RecordResponse {
+	if resp == nil {
+		return nil
+	}
+	slim := &RecordResponse{
+		StatusCode:  resp.StatusCode,
+		IsStreaming: resp.IsStreaming,
+	}
+	if resp.Body == nil {
+		return slim
+	}
+
+	body := make(map[string]interface{})
+	for _, field := range slimResponseFields {
+		if v, ok := resp.Body[field]; ok {
+			body[field] = v
+		}
+	}
+
+	var output []string
+	// Anthropic messages
+	if text := contentText(resp.Body["content"]); text != "" {
+		output = append(output, text)
+	}
+	// OpenAI chat completions
+	if choices, ok := resp.Body["choices"].([]interface{}); ok {
+		for _, choice := range choices {
+			c, ok := choice.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if reason, ok := c["finish_reason"]; ok && reason != nil {
+				body["finish_reason"] = reason
+			}
+			if msg, ok := c["message"].(map[string]interface{}); ok {
+				output = append(output, messageText(msg))
+			}
+		}
+	}
+	// OpenAI Responses API
+	if items, ok := resp.Body["output"].([]interface{}); ok {
+		for _, item := range items {
+			if msg, ok := item.(map[string]interface{}); ok {
+				output = append(output, messageText(msg))
+			}
+		}
+	}
+	if preview := previewText(strings.TrimSpace(strings.Join(output, "\n"))); preview != "" {
+		body["output_preview"] = preview
+	}
+
+	slim.Body = body
+	return slim
+}
+
+// previewMessages returns the role and a truncated text preview of each message
+func previewMessages(messages []interface{}) []interface{} {
+	previews := make([]interface{}, 0, len(messages))
+	for _, m := range messages {
+		msg, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		preview := map[string]interface{}{}
+		if role, ok := msg["role"]; ok {
+			preview["role"] = role
+		}
+		if kind, ok := msg["type"]; ok {
+			preview["type"] = kind
+		}
+		preview["preview"] = previewText(messageText(msg))
+		previews = append(previews, preview)
+	}
+	return previews
+}
+
+// messageText returns the text of a message in the OpenAI, Anthropic, Responses or
+// Google format, with tool calls and results named but not included
+func messageText(msg map[string]interface{}) string {
+	var parts []string
+	if text := contentText(msg["content"]); text != "" {
+		parts = append(parts, text)
+	}
+	if text := contentText(msg["parts"]); text != "" {
+		parts = append(parts, text)
+	}
+	if calls, ok := msg["tool_calls"].([]interface{}); ok {
+		for _, call := range calls {
+			c, _ := call.(map[string]interface{})
+			if fn, ok := c["function"].(map[string]interface{}); ok {
+				parts = append(parts, fmt.Sprintf("[tool_call %v]", fn["name"]))
+			}
+		}
+	}
+	switch msg["type"] {
+	case "function_call":
+		parts = append(parts, fmt.Sprintf("[tool_call %v]", msg["name"]))
+	case "function_call_output":
+		parts = append(parts, "[tool_result]")
+	}
+	return strings.Join(parts, "\n")
+}
+
+// contentText returns the text of string content or of a list of content blocks
+func contentText(content interface{}) string {
+	switch v := content.(type) {
+	case string:
+		return v
+	case []interface{}:
+		var parts []string
+		for _, b := range v {
+			block, ok := b.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch block["type"] {
+			case "tool_use", "server_tool_use":
+				parts = append(parts, fmt.Sprintf("[tool_use %v]", block["name"]))
+			case "tool_result":
+				parts = append(parts, "[tool_result]")
+			case "image", "image_url", "input_image":
+				parts = append(parts, "[image]")
+			case "thinking", "redacted_thinking", "reasoning":
+				// Thinking is left out of previews
+			default:
+				if text, ok := block["text"].(string); ok {
+					parts = append(parts, text)
+				}
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// previewText truncates text to DefaultSlimPreviewBytes on a UTF-8 boundary
+func previewText(text string) string {
+	if len(text) <= DefaultSlimPreviewBytes {
+		return text
+	}
+	cut := DefaultSlimPreviewBytes
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s... (%d bytes)", text[:cut], len(text))
+}
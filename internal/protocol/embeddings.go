@@ -0,0 +1,119 @@
+package protocol
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+)
+
+// Embedding encoding formats of an OpenAI embeddings request
+const (
+	EmbeddingEncodingFloat  = "float"
+	EmbeddingEncodingBase64 = "base64"
+)
+
+// EmbeddingRequest is the body of an OpenAI /v1/embeddings request.
+// The input is either a string, a list of strings, a list of token IDs or a list of token ID lists.
+type EmbeddingRequest struct {
+	Model          string          `json:"model"`
+	Input          json.RawMessage `json:"input"`
+	Dimensions     int64           `json:"dimensions,omitempty"`
+	EncodingFormat string          `json:"encoding_format,omitempty"`
+	User           string          `json:"user,omitempty"`
+}
+
+// EmbeddingInput is the parsed input of an embeddings request.
+// Exactly one of Texts and Tokens is set.
+type EmbeddingInput struct {
+	Texts  []string
+	Tokens [][]int64
+}
+
+// Len returns the number of inputs to embed
+func (in EmbeddingInput) Len() int {
+	if in.Tokens != nil {
+		return len(in.Tokens)
+	}
+	return len(in.Texts)
+}
+
+// ParseInput parses the input union of the request
+func (r *EmbeddingRequest) ParseInput() (EmbeddingInput, error) {
+	if len(r.Input) == 0 || string(r.Input) == "null" {
+		return EmbeddingInput{}, errors.New("input is required")
+	}
+
+	var text string
+	if err := json.Unmarshal(r.Input, &text); err == nil {
+		return EmbeddingInput{Texts: []string{text}}, nil
+	}
+	var texts []string
+	if err := json.Unmarshal(r.Input, &texts); err == nil {
+		if len(texts) == 0 {
+			return EmbeddingInput{}, errors.New("input must not be empty")
+		}
+		return EmbeddingInput{Texts: texts}, nil
+	}
+	var tokens []int64
+	if err := json.Unmarshal(r.Input, &tokens); err == nil {
+		return EmbeddingInput{Tokens: [][]int64{tokens}}, nil
+	}
+	var tokenLists [][]int64
+	if err := json.Unmarshal(r.Input, &tokenLists); err == nil {
+		return EmbeddingInput{Tokens: tokenLists}, nil
+	}
+	return EmbeddingInput{}, errors.New("input must be a string, an array of strings or an array of token arrays")
+}
+
+// EmbeddingResponse is an OpenAI /v1/embeddings response
+type EmbeddingResponse struct {
+	Object string          `json:"object"`
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  EmbeddingUsage  `json:"usage"`
+}
+
+// EmbeddingData is a single embedding of an embeddings response.
+// Embedding is a list of floats, or a base64 string of little-endian float32 values.
+type EmbeddingData struct {
+	Object    string      `json:"object"`
+	Index     int         `json:"index"`
+	Embedding interface{} `json:"embedding"`
+}
+
+// EmbeddingUsage is the token usage of an embeddings response
+type EmbeddingUsage struct {
+	PromptTokens int64 `json:"prompt_tokens"`
+	TotalTokens  int64 `json:"total_tokens"`
+}
+
+// NewEmbeddingResponse builds an OpenAI embeddings response from the embedding vectors,
+// encoding them as requested by the client
+func NewEmbeddingResponse(model string, vectors [][]float64, promptTokens int64, encodingFormat string) *EmbeddingResponse {
+	resp := &EmbeddingResponse{
+		Object: "list",
+		Data:   make([]EmbeddingData, 0, len(vectors)),
+		Model:  model,
+		Usage:  EmbeddingUsage{PromptTokens: promptTokens, TotalTokens: promptTokens},
+	}
+	for i, vector := range vectors {
+		var embedding interface{} = vector
+		if encodingFormat == EmbeddingEncodingBase64 {
+			embedding = EncodeEmbeddingBase64(vector)
+		}
+		resp.Data = append(resp.Data, EmbeddingData{Object: "embedding", Index: i, Embedding: embedding})
+	}
+	return resp
+}
+
+// EncodeEmbeddingBase64 encodes an embedding the way OpenAI does for encoding_format=base64:
+// little-endian float32 values, base64 encoded
+func EncodeEmbeddingBase64(vector []float64) string {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(v)))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
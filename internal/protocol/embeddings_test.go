@@ -0,0 +1,66 @@
+package protocol
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddingRequestParseInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantTexts  []string
+		wantTokens [][]int64
+		wantErr    bool
+	}{
+		{"string", `"hello"`, []string{"hello"}, nil, false},
+		{"strings", `["a","b"]`, []string{"a", "b"}, nil, false},
+		{"tokens", `[1,2,3]`, nil, [][]int64{{1, 2, 3}}, false},
+		{"token arrays", `[[1,2],[3]]`, nil, [][]int64{{1, 2}, {3}}, false},
+		{"empty array", `[]`, nil, nil, true},
+		{"object", `{"text":"hello"}`, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := EmbeddingRequest{Model: "text-embedding-3-small", Input: json.RawMessage(tt.input)}
+			input, err := req.ParseInput()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTexts, input.Texts)
+			assert.Equal(t, tt.wantTokens, input.Tokens)
+		})
+	}
+
+	_, err := (&EmbeddingRequest{Model: "m"}).ParseInput()
+	assert.Error(t, err)
+}
+
+func TestNewEmbeddingResponse(t *testing.T) {
+	vectors := [][]float64{{0.5, -1}, {2}}
+
+	resp := NewEmbeddingResponse("embed", vectors, 7, EmbeddingEncodingFloat)
+	assert.Equal(t, "list", resp.Object)
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, 1, resp.Data[1].Index)
+	assert.Equal(t, []float64{2}, resp.Data[1].Embedding)
+	assert.Equal(t, int64(7), resp.Usage.TotalTokens)
+
+	resp = NewEmbeddingResponse("embed", vectors, 7, EmbeddingEncodingBase64)
+	encoded, ok := resp.Data[0].Embedding.(string)
+	require.True(t, ok)
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	require.Len(t, raw, 8)
+	assert.Equal(t, float32(0.5), math.Float32frombits(binary.LittleEndian.Uint32(raw[0:])))
+	assert.Equal(t, float32(-1), math.Float32frombits(binary.LittleEndian.Uint32(raw[4:])))
+}
@@ -0,0 +1,71 @@
+package protocol
+
+import "google.golang.org/genai"
+
+// Google generateContent methods, as used in ".../models/{model}:{method}"
+const (
+	GoogleMethodGenerateContent       = "generateContent"
+	GoogleMethodStreamGenerateContent = "streamGenerateContent"
+	GoogleMethodCountTokens           = "countTokens"
+)
+
+// GoogleGenerateContentRequest is the body of a Gemini REST generateContent or
+// streamGenerateContent request. The model is taken from the URL path.
+type GoogleGenerateContentRequest struct {
+	Contents          []*genai.Content        `json:"contents"`
+	SystemInstruction *genai.Content          `json:"systemInstruction,omitempty"`
+	Tools             []*genai.Tool           `json:"tools,omitempty"`
+	ToolConfig        *genai.ToolConfig       `json:"toolConfig,omitempty"`
+	SafetySettings    []*genai.SafetySetting  `json:"safetySettings,omitempty"`
+	GenerationConfig  *genai.GenerationConfig `json:"generationConfig,omitempty"`
+	CachedContent     string                  `json:"cachedContent,omitempty"`
+}
+
+// Config flattens the request into the SDK's GenerateContentConfig
+func (r *GoogleGenerateContentRequest) Config() *genai.GenerateContentConfig {
+	config := &genai.GenerateContentConfig{
+		SystemInstruction: r.SystemInstruction,
+		Tools:             r.Tools,
+		ToolConfig:        r.ToolConfig,
+		SafetySettings:    r.SafetySettings,
+		CachedContent:     r.CachedContent,
+	}
+
+	if gc := r.GenerationConfig; gc != nil {
+		config.Temperature = gc.Temperature
+		config.TopP = gc.TopP
+		config.TopK = gc.TopK
+		config.CandidateCount = gc.CandidateCount
+		config.MaxOutputTokens = gc.MaxOutputTokens
+		config.StopSequences = gc.StopSequences
+		config.PresencePenalty = gc.PresencePenalty
+		config.FrequencyPenalty = gc.FrequencyPenalty
+		config.Seed = gc.Seed
+		config.ResponseMIMEType = gc.ResponseMIMEType
+		config.ResponseSchema = gc.ResponseSchema
+		config.ThinkingConfig = gc.ThinkingConfig
+	}
+
+	return config
+}
+
+// GoogleCountTokensRequest is the body of a Gemini REST countTokens request.
+// Clients send either the contents directly or a full generateContent request.
+type GoogleCountTokensRequest struct {
+	Contents               []*genai.Content              `json:"contents,omitempty"`
+	GenerateContentRequest *GoogleGenerateContentRequest `json:"generateContentRequest,omitempty"`
+}
+
+// GenerateContent returns the request to count tokens for
+func (r *GoogleCountTokensRequest) GenerateContent() *GoogleGenerateContentRequest {
+	if r.GenerateContentRequest != nil {
+		return r.GenerateContentRequest
+	}
+	return &GoogleGenerateContentRequest{Contents: r.Contents}
+}
+
+// GoogleCountTokensResponse is the response of a Gemini REST countTokens request
+type GoogleCountTokensResponse struct {
+	TotalTokens             int32 `json:"totalTokens"`
+	CachedContentTokenCount int32 `json:"cachedContentTokenCount,omitempty"`
+}
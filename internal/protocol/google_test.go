@@ -0,0 +1,41 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoogleGenerateContentRequest_Config(t *testing.T) {
+	body := `{
+		"contents": [{"role": "user", "parts": [{"text": "hello"}]}],
+		"systemInstruction": {"parts": [{"text": "be brief"}]},
+		"generationConfig": {"temperature": 0.5, "maxOutputTokens": 256, "stopSequences": ["END"]}
+	}`
+
+	var req GoogleGenerateContentRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+	require.Len(t, req.Contents, 1)
+
+	config := req.Config()
+	require.NotNil(t, config.SystemInstruction)
+	assert.Equal(t, "be brief", config.SystemInstruction.Parts[0].Text)
+	require.NotNil(t, config.Temperature)
+	assert.Equal(t, float32(0.5), *config.Temperature)
+	assert.Equal(t, int32(256), config.MaxOutputTokens)
+	assert.Equal(t, []string{"END"}, config.StopSequences)
+}
+
+func TestGoogleCountTokensRequest_GenerateContent(t *testing.T) {
+	var direct GoogleCountTokensRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"contents": [{"role": "user", "parts": [{"text": "hi"}]}]}`), &direct))
+	assert.Len(t, direct.GenerateContent().Contents, 1)
+
+	var wrapped GoogleCountTokensRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"generateContentRequest": {"contents": [{"role": "user", "parts": [{"text": "hi"}]}], "systemInstruction": {"parts": [{"text": "sys"}]}}}`), &wrapped))
+	gen := wrapped.GenerateContent()
+	assert.Len(t, gen.Contents, 1)
+	assert.NotNil(t, gen.SystemInstruction)
+}
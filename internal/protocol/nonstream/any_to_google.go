@@ -6,6 +6,8 @@ import (
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/openai/openai-go/v3"
 	"google.golang.org/genai"
+
+	"github.com/tingly-dev/tingly-box/internal/protocol"
 )
 
 // ConvertOpenAIToGoogleResponse converts OpenAI ChatCompletion to Google format
@@ -58,9 +60,8 @@ func ConvertOpenAIToGoogleResponse(openaiResp *openai.ChatCompletion) *genai.Gen
 	}
 
 	// Add usage metadata
-	googleResp.UsageMetadata.PromptTokenCount = int32(openaiResp.Usage.PromptTokens)
-	googleResp.UsageMetadata.CandidatesTokenCount = int32(openaiResp.Usage.CompletionTokens)
-	googleResp.UsageMetadata.TotalTokenCount = int32(openaiResp.Usage.TotalTokens)
+	usage := protocol.NewUsageStatFromOpenAIChat(openaiResp.Usage)
+	googleResp.UsageMetadata = usage.ToGoogleUsageMetadata()
 
 	return googleResp
 }
@@ -121,9 +122,8 @@ func ConvertAnthropicToGoogleResponse(anthropicResp *anthropic.Message) *genai.G
 	googleResp.Candidates = append(googleResp.Candidates, candidate)
 
 	// Add usage metadata
-	googleResp.UsageMetadata.PromptTokenCount = int32(anthropicResp.Usage.InputTokens)
-	googleResp.UsageMetadata.CandidatesTokenCount = int32(anthropicResp.Usage.OutputTokens)
-	googleResp.UsageMetadata.TotalTokenCount = int32(anthropicResp.Usage.InputTokens + anthropicResp.Usage.OutputTokens)
+	usage := protocol.NewUsageStatFromAnthropic(anthropicResp.Usage)
+	googleResp.UsageMetadata = usage.ToGoogleUsageMetadata()
 
 	return googleResp
 }
@@ -0,0 +1,130 @@
+package nonstream
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"google.golang.org/genai"
+
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+)
+
+// ConvertAnthropicToResponsesResponse converts an Anthropic message to an OpenAI Responses API result.
+// Thinking blocks become reasoning items carrying the signature in encrypted_content,
+// redacted thinking blocks reasoning items carrying their data.
+func ConvertAnthropicToResponsesResponse(anthropicResp *anthropic.Message, responseModel string) *protocol.ResponsesResult {
+	result := protocol.NewResponsesResult(responseModel)
+	if anthropicResp == nil {
+		return result
+	}
+
+	var text strings.Builder
+	flushText := func() {
+		if text.Len() > 0 {
+			result.Output = append(result.Output, protocol.NewResponsesMessageItem(protocol.NewResponsesID("msg"), text.String()))
+			text.Reset()
+		}
+	}
+
+	for _, block := range anthropicResp.Content {
+		switch block.Type {
+		case "thinking":
+			flushText()
+			result.Output = append(result.Output, protocol.NewResponsesReasoningItem(protocol.NewResponsesID("rs"), block.Thinking,
+				protocol.EncodeResponsesReasoning(protocol.ResponsesReasoningAnthropic, block.Signature)))
+		case "redacted_thinking":
+			flushText()
+			result.Output = append(result.Output, protocol.NewResponsesReasoningItem(protocol.NewResponsesID("rs"), "",
+				protocol.EncodeResponsesReasoning(protocol.ResponsesReasoningAnthropicRedacted, block.Data)))
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			flushText()
+			result.Output = append(result.Output, protocol.NewResponsesFunctionCallItem(protocol.NewResponsesID("fc"), block.ID, block.Name, string(block.Input)))
+		}
+	}
+	flushText()
+
+	if string(anthropicResp.StopReason) == "max_tokens" {
+		result.IncompleteReason = protocol.ResponsesIncompleteMaxOutputTokens
+	}
+	result.Usage = protocol.NewUsageStatFromAnthropic(anthropicResp.Usage)
+
+	return result
+}
+
+// ConvertGoogleToResponsesResponse converts a Google response to an OpenAI Responses API result.
+// Thought parts become a reasoning item; the thought signature is carried base64 encoded in encrypted_content.
+func ConvertGoogleToResponsesResponse(googleResp *genai.GenerateContentResponse, responseModel string) *protocol.ResponsesResult {
+	result := protocol.NewResponsesResult(responseModel)
+	if googleResp == nil {
+		return result
+	}
+
+	if len(googleResp.Candidates) > 0 {
+		candidate := googleResp.Candidates[0]
+
+		var (
+			thoughts  strings.Builder
+			text      strings.Builder
+			signature []byte
+			calls     []map[string]interface{}
+		)
+		if candidate.Content != nil {
+			for _, part := range candidate.Content.Parts {
+				if signature == nil && len(part.ThoughtSignature) > 0 {
+					signature = part.ThoughtSignature
+				}
+				switch {
+				case part.Thought:
+					thoughts.WriteString(part.Text)
+				case part.FunctionCall != nil:
+					calls = append(calls, NewResponsesFunctionCallFromGoogle(part.FunctionCall))
+				case part.Text != "":
+					text.WriteString(part.Text)
+				}
+			}
+		}
+
+		if thoughts.Len() > 0 || signature != nil {
+			result.Output = append(result.Output, protocol.NewResponsesReasoningItem(protocol.NewResponsesID("rs"), thoughts.String(), EncodeGoogleThoughtSignature(signature)))
+		}
+		if text.Len() > 0 {
+			result.Output = append(result.Output, protocol.NewResponsesMessageItem(protocol.NewResponsesID("msg"), text.String()))
+		}
+		result.Output = append(result.Output, calls...)
+
+		if candidate.FinishReason == genai.FinishReasonMaxTokens {
+			result.IncompleteReason = protocol.ResponsesIncompleteMaxOutputTokens
+		}
+	}
+	result.Usage = protocol.NewUsageStatFromGoogle(googleResp.UsageMetadata)
+
+	return result
+}
+
+// NewResponsesFunctionCallFromGoogle converts a Google function call to a Responses API function_call item.
+// Gemini does not always assign call IDs, so one is generated when missing.
+func NewResponsesFunctionCallFromGoogle(call *genai.FunctionCall) map[string]interface{} {
+	callID := call.ID
+	if callID == "" {
+		callID = protocol.NewResponsesID("call")
+	}
+	arguments := "{}"
+	if len(call.Args) > 0 {
+		if argsJSON, err := json.Marshal(call.Args); err == nil {
+			arguments = string(argsJSON)
+		}
+	}
+	return protocol.NewResponsesFunctionCallItem(protocol.NewResponsesID("fc"), callID, call.Name, arguments)
+}
+
+// EncodeGoogleThoughtSignature encodes a thought signature for the encrypted_content of a reasoning item
+func EncodeGoogleThoughtSignature(signature []byte) string {
+	if len(signature) == 0 {
+		return ""
+	}
+	return protocol.EncodeResponsesReasoning(protocol.ResponsesReasoningGoogle, base64.StdEncoding.EncodeToString(signature))
+}
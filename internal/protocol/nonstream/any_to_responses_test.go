@@ -0,0 +1,87 @@
+package nonstream
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+)
+
+func TestConvertAnthropicToResponsesResponse(t *testing.T) {
+	var msg anthropic.Message
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": "msg_1",
+		"type": "message",
+		"role": "assistant",
+		"model": "claude-sonnet",
+		"content": [
+			{"type": "thinking", "thinking": "Let me check", "signature": "sig"},
+			{"type": "redacted_thinking", "data": "opaque"},
+			{"type": "text", "text": "Checking."},
+			{"type": "tool_use", "id": "toolu_1", "name": "shell", "input": {"cmd": "ls"}}
+		],
+		"stop_reason": "tool_use",
+		"usage": {"input_tokens": 10, "output_tokens": 20, "cache_read_input_tokens": 5}
+	}`), &msg))
+
+	result := ConvertAnthropicToResponsesResponse(&msg, "my-model")
+
+	require.Len(t, result.Output, 4)
+	assert.Equal(t, protocol.ResponsesItemTypeReasoning, result.Output[0]["type"])
+	assert.Equal(t, "anthropic:sig", result.Output[0]["encrypted_content"])
+	assert.Equal(t, protocol.ResponsesItemTypeReasoning, result.Output[1]["type"])
+	assert.Equal(t, "anthropic_redacted:opaque", result.Output[1]["encrypted_content"])
+	assert.Equal(t, protocol.ResponsesItemTypeMessage, result.Output[2]["type"])
+	assert.Equal(t, protocol.ResponsesItemTypeFunctionCall, result.Output[3]["type"])
+	assert.Equal(t, "toolu_1", result.Output[3]["call_id"])
+	assert.JSONEq(t, `{"cmd":"ls"}`, result.Output[3]["arguments"].(string))
+
+	assert.Equal(t, protocol.ResponsesStatusCompleted, result.Status())
+	assert.Equal(t, 15, result.Usage.InputTokens)
+	assert.Equal(t, 5, result.Usage.CacheReadTokens)
+
+	resp := result.Response(result.Status())
+	assert.Equal(t, "my-model", resp["model"])
+	assert.Equal(t, "response", resp["object"])
+}
+
+func TestConvertGoogleToResponsesResponse(t *testing.T) {
+	googleResp := &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{
+			{
+				Content: &genai.Content{
+					Role: "model",
+					Parts: []*genai.Part{
+						{Text: "Thinking about it", Thought: true},
+						{Text: "Partial answer", ThoughtSignature: []byte("sig")},
+						{FunctionCall: &genai.FunctionCall{Name: "shell", Args: map[string]any{"cmd": "ls"}}},
+					},
+				},
+				FinishReason: genai.FinishReasonMaxTokens,
+			},
+		},
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:     10,
+			CandidatesTokenCount: 5,
+			ThoughtsTokenCount:   3,
+			TotalTokenCount:      18,
+		},
+	}
+
+	result := ConvertGoogleToResponsesResponse(googleResp, "my-model")
+
+	require.Len(t, result.Output, 3)
+	assert.Equal(t, protocol.ResponsesItemTypeReasoning, result.Output[0]["type"])
+	assert.Equal(t, EncodeGoogleThoughtSignature([]byte("sig")), result.Output[0]["encrypted_content"])
+	assert.Equal(t, protocol.ResponsesItemTypeMessage, result.Output[1]["type"])
+	assert.Equal(t, "shell", result.Output[2]["name"])
+	assert.NotEmpty(t, result.Output[2]["call_id"])
+
+	assert.Equal(t, protocol.ResponsesStatusIncomplete, result.Status())
+	assert.Equal(t, 3, result.Usage.ReasoningTokens)
+}
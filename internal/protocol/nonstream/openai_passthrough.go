@@ -12,8 +12,7 @@ import (
 // HandleOpenAIChatNonStream handles OpenAI chat non-streaming response.
 // Returns (UsageStat, error)
 func HandleOpenAIChatNonStream(hc *protocol.HandleContext, resp *openai.ChatCompletion) (protocol.UsageStat, error) {
-	inputTokens := int(resp.Usage.PromptTokens)
-	outputTokens := int(resp.Usage.CompletionTokens)
+	usage := protocol.NewUsageStatFromOpenAIChat(resp.Usage)
 
 	// Convert response to JSON map for modification
 	responseJSON, err := json.Marshal(resp)
@@ -32,15 +31,14 @@ func HandleOpenAIChatNonStream(hc *protocol.HandleContext, resp *openai.ChatComp
 	responseMap["model"] = hc.ResponseModel
 
 	hc.GinContext.JSON(http.StatusOK, responseMap)
-	return protocol.NewUsageStat(inputTokens, outputTokens), nil
+	return usage, nil
 }
 
 // HandleOpenAIResponsesNonStream handles OpenAI Responses API non-streaming response.
 // Returns (UsageStat, error)
 func HandleOpenAIResponsesNonStream(hc *protocol.HandleContext, resp *responses.Response) (protocol.UsageStat, error) {
-	inputTokens := int(resp.Usage.InputTokens)
-	outputTokens := int(resp.Usage.OutputTokens)
+	usage := protocol.NewUsageStatFromOpenAIResponses(resp.Usage)
 
 	hc.GinContext.JSON(http.StatusOK, resp)
-	return protocol.NewUsageStat(inputTokens, outputTokens), nil
+	return usage, nil
 }
@@ -2,6 +2,7 @@ package request
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/anthropics/anthropic-sdk-go"
@@ -32,8 +33,16 @@ func ConvertGoogleToOpenAIRequest(model string, contents []*genai.Content, confi
 		openaiReq.TopP = openai.Opt(float64(*config.TopP))
 	}
 
+	// System instruction from config
+	if config != nil && config.SystemInstruction != nil {
+		if systemText := ConvertGooglePartsToString(config.SystemInstruction.Parts); systemText != "" {
+			openaiReq.Messages = append(openaiReq.Messages, openai.SystemMessage(systemText))
+		}
+	}
+
 	// Convert contents to messages
-	for _, content := range contents {
+	ids := newGoogleCallIDs()
+	for turn, content := range contents {
 		if content.Role == "system" {
 			// System message
 			systemText := ConvertGooglePartsToString(content.Parts)
@@ -43,7 +52,7 @@ func ConvertGoogleToOpenAIRequest(model string, contents []*genai.Content, confi
 				openaiReq.Messages = append([]openai.ChatCompletionMessageParamUnion{sysMsg}, openaiReq.Messages...)
 			}
 		} else {
-			openaiMsg := convertGoogleContentToOpenAI(content)
+			openaiMsg := convertGoogleContentToOpenAI(content, turn, ids)
 			// Check if conversion succeeded by trying to use the result
 			msgBytes, _ := json.Marshal(openaiMsg)
 			if len(msgBytes) > 0 && string(msgBytes) != "null" {
@@ -70,7 +79,7 @@ func ConvertGoogleToOpenAIRequest(model string, contents []*genai.Content, confi
 }
 
 // convertGoogleContentToOpenAI converts a Google Content to OpenAI message format
-func convertGoogleContentToOpenAI(content *genai.Content) openai.ChatCompletionMessageParamUnion {
+func convertGoogleContentToOpenAI(content *genai.Content, turn int, ids *googleCallIDs) openai.ChatCompletionMessageParamUnion {
 	var textContent string
 	var toolCalls []map[string]interface{}
 
@@ -83,7 +92,7 @@ func convertGoogleContentToOpenAI(content *genai.Content) openai.ChatCompletionM
 		// Handle function calls
 		if part.FunctionCall != nil {
 			toolCall := map[string]interface{}{
-				"id":   part.FunctionCall.ID,
+				"id":   ids.callID(turn, len(toolCalls), part.FunctionCall),
 				"type": "function",
 				"function": map[string]interface{}{
 					"name": part.FunctionCall.Name,
@@ -123,7 +132,7 @@ func convertGoogleContentToOpenAI(content *genai.Content) openai.ChatCompletionM
 
 			toolMsg := map[string]interface{}{
 				"role":         "tool",
-				"tool_call_id": part.FunctionResponse.Name,
+				"tool_call_id": ids.responseID(part.FunctionResponse),
 				"content":      resultText,
 			}
 			msgBytes, _ := json.Marshal(toolMsg)
@@ -278,10 +287,29 @@ func ConvertGoogleToAnthropicRequest(model string, contents []*genai.Content, co
 		params.MaxTokens = int64(config.MaxOutputTokens)
 	}
 
+	// Set sampling parameters - Google uses *float32, Anthropic uses float64
+	if config != nil && config.Temperature != nil {
+		params.Temperature = anthropic.Opt(float64(*config.Temperature))
+	}
+	if config != nil && config.TopP != nil {
+		params.TopP = anthropic.Opt(float64(*config.TopP))
+	}
+	if config != nil && len(config.StopSequences) > 0 {
+		params.StopSequences = config.StopSequences
+	}
+
 	// Convert contents
 	var systemParts []string
 
-	for _, content := range contents {
+	// System instruction from config
+	if config != nil && config.SystemInstruction != nil {
+		if systemText := ConvertGooglePartsToString(config.SystemInstruction.Parts); systemText != "" {
+			systemParts = append(systemParts, systemText)
+		}
+	}
+
+	ids := newGoogleCallIDs()
+	for turn, content := range contents {
 		if content.Role == "system" {
 			// System message → system instruction
 			systemText := ConvertGooglePartsToString(content.Parts)
@@ -289,7 +317,7 @@ func ConvertGoogleToAnthropicRequest(model string, contents []*genai.Content, co
 				systemParts = append(systemParts, systemText)
 			}
 		} else {
-			anthropicMsg := convertGoogleContentToAnthropic(content)
+			anthropicMsg := convertGoogleContentToAnthropic(content, turn, ids)
 			// Check if conversion succeeded
 			msgBytes, _ := json.Marshal(anthropicMsg)
 			if len(msgBytes) > 0 && string(msgBytes) != "null" {
@@ -325,8 +353,9 @@ func ConvertGoogleToAnthropicRequest(model string, contents []*genai.Content, co
 }
 
 // convertGoogleContentToAnthropic converts a Google Content to Anthropic message format
-func convertGoogleContentToAnthropic(content *genai.Content) anthropic.MessageParam {
+func convertGoogleContentToAnthropic(content *genai.Content, turn int, ids *googleCallIDs) anthropic.MessageParam {
 	var blocks []anthropic.ContentBlockParamUnion
+	calls := 0
 
 	for _, part := range content.Parts {
 		// Handle text parts
@@ -337,8 +366,9 @@ func convertGoogleContentToAnthropic(content *genai.Content) anthropic.MessagePa
 		// Handle function calls
 		if part.FunctionCall != nil {
 			blocks = append(blocks,
-				anthropic.NewToolUseBlock(part.FunctionCall.ID, part.FunctionCall.Args, part.FunctionCall.Name),
+				anthropic.NewToolUseBlock(ids.callID(turn, calls, part.FunctionCall), part.FunctionCall.Args, part.FunctionCall.Name),
 			)
+			calls++
 		}
 
 		// Handle function responses (tool results)
@@ -368,7 +398,7 @@ func convertGoogleContentToAnthropic(content *genai.Content) anthropic.MessagePa
 
 			// Return as user message with tool_result
 			return anthropic.NewUserMessage(
-				anthropic.NewToolResultBlock(part.FunctionResponse.Name, resultText, false),
+				anthropic.NewToolResultBlock(ids.responseID(part.FunctionResponse), resultText, false),
 			)
 		}
 	}
@@ -448,3 +478,44 @@ func ConvertGooglePartsToString(parts []*genai.Part) string {
 	}
 	return result.String()
 }
+
+// googleCallIDs pairs function calls with their responses when Gemini clients omit call IDs.
+// A call without an ID gets one derived from its turn and its index within the turn, so that
+// parallel calls of the same function stay distinct. The responses of a turn answer the calls
+// of the previous model turn in order, so the n-th response of a function without an ID is
+// paired with the n-th call of that function.
+type googleCallIDs struct {
+	turn    int                 // Turn of the last function call
+	pending map[string][]string // Unanswered call IDs of that turn by function name
+}
+
+func newGoogleCallIDs() *googleCallIDs {
+	return &googleCallIDs{turn: -1, pending: map[string][]string{}}
+}
+
+// callID returns the ID of the index-th function call of a model turn
+func (g *googleCallIDs) callID(turn, index int, call *genai.FunctionCall) string {
+	if turn != g.turn {
+		g.turn = turn
+		g.pending = map[string][]string{}
+	}
+	id := call.ID
+	if id == "" {
+		id = fmt.Sprintf("call_%d_%d", turn, index)
+	}
+	g.pending[call.Name] = append(g.pending[call.Name], id)
+	return id
+}
+
+// responseID returns the ID of the function call a response answers, falling back to the
+// function name when no call is pending
+func (g *googleCallIDs) responseID(response *genai.FunctionResponse) string {
+	if response.ID != "" {
+		return response.ID
+	}
+	if ids := g.pending[response.Name]; len(ids) > 0 {
+		g.pending[response.Name] = ids[1:]
+		return ids[0]
+	}
+	return response.Name
+}
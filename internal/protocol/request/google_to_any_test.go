@@ -703,3 +703,39 @@ func TestConvertGoogleToAnthropicRequest(t *testing.T) {
 		}
 	})
 }
+
+// TestConvertGoogleFunctionCallIDs verifies that parallel calls of the same function without
+// IDs get distinct IDs, and that their responses are paired with them in order
+func TestConvertGoogleFunctionCallIDs(t *testing.T) {
+	weather := func(location string) *genai.Part {
+		return &genai.Part{FunctionCall: &genai.FunctionCall{Name: "get_weather", Args: map[string]interface{}{"location": location}}}
+	}
+	response := func(output string) *genai.Content {
+		return &genai.Content{Role: "user", Parts: []*genai.Part{{
+			FunctionResponse: &genai.FunctionResponse{Name: "get_weather", Response: map[string]interface{}{"output": output}},
+		}}}
+	}
+	contents := []*genai.Content{
+		{Role: "user", Parts: []*genai.Part{genai.NewPartFromText("Weather in NYC and Tokyo?")}},
+		{Role: "model", Parts: []*genai.Part{weather("NYC"), weather("Tokyo")}},
+		response("Sunny"),
+		response("Rainy"),
+	}
+
+	params := ConvertGoogleToAnthropicRequest("claude", contents, &genai.GenerateContentConfig{})
+	require.Len(t, params.Messages, 4)
+	assistant := params.Messages[1].Content
+	require.Len(t, assistant, 2)
+	assert.Equal(t, "call_1_0", assistant[0].OfToolUse.ID)
+	assert.Equal(t, "call_1_1", assistant[1].OfToolUse.ID)
+	assert.Equal(t, "call_1_0", params.Messages[2].Content[0].OfToolResult.ToolUseID)
+	assert.Equal(t, "call_1_1", params.Messages[3].Content[0].OfToolResult.ToolUseID)
+
+	openaiReq := ConvertGoogleToOpenAIRequest("gpt-4o", contents, &genai.GenerateContentConfig{})
+	require.Len(t, openaiReq.Messages, 4)
+	require.Len(t, openaiReq.Messages[1].OfAssistant.ToolCalls, 2)
+	assert.Equal(t, "call_1_0", openaiReq.Messages[1].OfAssistant.ToolCalls[0].OfFunction.ID)
+	assert.Equal(t, "call_1_1", openaiReq.Messages[1].OfAssistant.ToolCalls[1].OfFunction.ID)
+	assert.Equal(t, "call_1_0", openaiReq.Messages[2].OfTool.ToolCallID)
+	assert.Equal(t, "call_1_1", openaiReq.Messages[3].OfTool.ToolCallID)
+}
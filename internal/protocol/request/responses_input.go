@@ -0,0 +1,172 @@
+package request
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/openai/openai-go/v3/packages/param"
+	"github.com/openai/openai-go/v3/responses"
+)
+
+// Thinking budgets used for the Responses API reasoning effort on providers
+// that take a token budget instead of an effort level
+const (
+	responsesThinkingBudgetLow    = 2048
+	responsesThinkingBudgetMedium = 8192
+	responsesThinkingBudgetHigh   = 16384
+	responsesThinkingBudgetXHigh  = 32768
+)
+
+// responsesThinkingBudget maps a Responses API reasoning effort to a thinking budget.
+// Returns 0 when reasoning is not requested.
+func responsesThinkingBudget(params *responses.ResponseNewParams) int64 {
+	switch string(params.Reasoning.Effort) {
+	case "low":
+		return responsesThinkingBudgetLow
+	case "medium":
+		return responsesThinkingBudgetMedium
+	case "high":
+		return responsesThinkingBudgetHigh
+	case "xhigh":
+		return responsesThinkingBudgetXHigh
+	default:
+		return 0
+	}
+}
+
+// responsesInputItems returns the input items of a Responses API request in their JSON form.
+// The input is a union of several item types, so like the other converters we go through
+// JSON instead of the SDK union. A plain string input becomes a single user message.
+func responsesInputItems(params *responses.ResponseNewParams) []map[string]interface{} {
+	if !param.IsOmitted(params.Input.OfString) {
+		return []map[string]interface{}{
+			{"type": "message", "role": "user", "content": params.Input.OfString.Value},
+		}
+	}
+
+	raw, err := json.Marshal(params.Input.OfInputItemList)
+	if err != nil {
+		return nil
+	}
+	var items []map[string]interface{}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil
+	}
+
+	// Items without a type but with a role are messages
+	for _, item := range items {
+		if _, ok := item["type"]; !ok {
+			if _, hasRole := item["role"]; hasRole {
+				item["type"] = "message"
+			}
+		}
+	}
+	return items
+}
+
+// responsesContentParts returns the content parts of a message, whose content is
+// either a plain string or a list of parts (input_text, output_text, input_image, ...)
+func responsesContentParts(content interface{}) []map[string]interface{} {
+	switch v := content.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []map[string]interface{}{{"type": "input_text", "text": v}}
+	case []interface{}:
+		parts := make([]map[string]interface{}, 0, len(v))
+		for _, p := range v {
+			if part, ok := p.(map[string]interface{}); ok {
+				parts = append(parts, part)
+			}
+		}
+		return parts
+	}
+	return nil
+}
+
+// responsesContentText joins the text of a message content or a function call output
+func responsesContentText(content interface{}) string {
+	var sb strings.Builder
+	for _, part := range responsesContentParts(content) {
+		if text, ok := part["text"].(string); ok {
+			sb.WriteString(text)
+		}
+	}
+	return sb.String()
+}
+
+// responsesReasoningText joins the summary and content text of a reasoning item
+func responsesReasoningText(item map[string]interface{}) string {
+	var texts []string
+	for _, key := range []string{"summary", "content"} {
+		if parts, ok := item[key].([]interface{}); ok {
+			for _, p := range parts {
+				if part, ok := p.(map[string]interface{}); ok {
+					if text, ok := part["text"].(string); ok && text != "" {
+						texts = append(texts, text)
+					}
+				}
+			}
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// responsesImageURL returns the URL of an input_image part
+func responsesImageURL(part map[string]interface{}) string {
+	switch v := part["image_url"].(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		url, _ := v["url"].(string)
+		return url
+	}
+	return ""
+}
+
+// parseDataURL splits a "data:<media type>;base64,<data>" URL
+func parseDataURL(url string) (mediaType, data string, ok bool) {
+	rest, found := strings.CutPrefix(url, "data:")
+	if !found {
+		return "", "", false
+	}
+	meta, data, found := strings.Cut(rest, ",")
+	if !found {
+		return "", "", false
+	}
+	mediaType, isBase64 := strings.CutSuffix(meta, ";base64")
+	if !isBase64 {
+		return "", "", false
+	}
+	return mediaType, data, true
+}
+
+// responsesFunctionArguments parses the JSON arguments of a function call,
+// falling back to an empty object
+func responsesFunctionArguments(arguments string) map[string]interface{} {
+	args := map[string]interface{}{}
+	if arguments != "" {
+		_ = json.Unmarshal([]byte(arguments), &args)
+	}
+	return args
+}
+
+// responsesInstructions collects the instructions and the system/developer messages of a request
+func responsesInstructions(params *responses.ResponseNewParams, items []map[string]interface{}) []string {
+	var parts []string
+	if !param.IsOmitted(params.Instructions) && params.Instructions.Value != "" {
+		parts = append(parts, params.Instructions.Value)
+	}
+	for _, item := range items {
+		if item["type"] != "message" {
+			continue
+		}
+		if role, _ := item["role"].(string); role == "system" || role == "developer" {
+			if text := responsesContentText(item["content"]); text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+	return parts
+}
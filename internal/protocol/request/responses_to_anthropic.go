@@ -0,0 +1,202 @@
+package request
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go/v3/packages/param"
+	"github.com/openai/openai-go/v3/responses"
+
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+)
+
+// anthropicMinThinkingBudget is the smallest thinking budget accepted by Anthropic
+const anthropicMinThinkingBudget = 1024
+
+// ConvertResponsesToAnthropicRequest converts an OpenAI Responses API request to Anthropic SDK format.
+// Reasoning items that carry an Anthropic thinking signature or redacted thinking data in
+// encrypted_content are replayed as thinking blocks; reasoning of other providers is dropped.
+func ConvertResponsesToAnthropicRequest(params *responses.ResponseNewParams, defaultMaxTokens int64) anthropic.MessageNewParams {
+	items := responsesInputItems(params)
+	messages := make([]anthropic.MessageParam, 0, len(items))
+
+	// Consecutive items of the same role are merged, so that tool_use blocks
+	// and their tool_result blocks end up in alternating messages
+	appendBlocks := func(role anthropic.MessageParamRole, blocks ...anthropic.ContentBlockParamUnion) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			return
+		}
+		messages = append(messages, anthropic.MessageParam{Role: role, Content: blocks})
+	}
+
+	for _, item := range items {
+		itemType, _ := item["type"].(string)
+
+		switch itemType {
+		case "message":
+			role, _ := item["role"].(string)
+			switch role {
+			case "user":
+				appendBlocks(anthropic.MessageParamRoleUser, convertResponsesPartsToAnthropic(item["content"])...)
+			case "assistant":
+				if text := responsesContentText(item["content"]); text != "" {
+					appendBlocks(anthropic.MessageParamRoleAssistant, anthropic.NewTextBlock(text))
+				}
+			}
+			// system and developer messages are collected by responsesInstructions
+
+		case "function_call":
+			callID, _ := item["call_id"].(string)
+			name, _ := item["name"].(string)
+			arguments, _ := item["arguments"].(string)
+			appendBlocks(anthropic.MessageParamRoleAssistant,
+				anthropic.NewToolUseBlock(callID, responsesFunctionArguments(arguments), name))
+
+		case "function_call_output":
+			callID, _ := item["call_id"].(string)
+			appendBlocks(anthropic.MessageParamRoleUser,
+				anthropic.NewToolResultBlock(callID, responsesContentText(item["output"]), false))
+
+		case "reasoning":
+			// Only reasoning produced by Anthropic carries a signature we can replay
+			encrypted, _ := item["encrypted_content"].(string)
+			if signature, ok := protocol.DecodeResponsesReasoning(encrypted, protocol.ResponsesReasoningAnthropic); ok {
+				appendBlocks(anthropic.MessageParamRoleAssistant,
+					anthropic.NewThinkingBlock(signature, responsesReasoningText(item)))
+			} else if data, ok := protocol.DecodeResponsesReasoning(encrypted, protocol.ResponsesReasoningAnthropicRedacted); ok {
+				appendBlocks(anthropic.MessageParamRoleAssistant, anthropic.NewRedactedThinkingBlock(data))
+			}
+		}
+	}
+
+	// Determine max_tokens - use default if not set
+	maxTokens := params.MaxOutputTokens.Value
+	if maxTokens == 0 {
+		maxTokens = defaultMaxTokens
+	}
+
+	req := anthropic.MessageNewParams{
+		Model:     anthropic.Model(params.Model),
+		Messages:  messages,
+		MaxTokens: maxTokens,
+	}
+
+	if instructions := responsesInstructions(params, items); len(instructions) > 0 {
+		req.System = []anthropic.TextBlockParam{{Text: strings.Join(instructions, "\n\n")}}
+	}
+
+	if budget := responsesThinkingBudget(params); budget > 0 {
+		req.Thinking = anthropic.ThinkingConfigParamOfEnabled(budget)
+		ClampAnthropicThinkingBudget(&req)
+	}
+
+	// Anthropic only accepts the default sampling parameters with thinking enabled
+	if req.Thinking.OfEnabled == nil {
+		if !param.IsOmitted(params.Temperature) {
+			req.Temperature = anthropic.Opt(params.Temperature.Value)
+		}
+		if !param.IsOmitted(params.TopP) {
+			req.TopP = anthropic.Opt(params.TopP.Value)
+		}
+	}
+
+	if len(params.Tools) > 0 {
+		req.Tools = ConvertResponsesToolsToAnthropic(params.Tools)
+		if len(req.Tools) > 0 {
+			req.ToolChoice = ConvertResponsesToolChoiceToAnthropic(params.ToolChoice)
+		}
+	}
+
+	return req
+}
+
+// ClampAnthropicThinkingBudget keeps the thinking budget below max_tokens as required by
+// Anthropic, and disables thinking when there is no room for the minimum budget
+func ClampAnthropicThinkingBudget(req *anthropic.MessageNewParams) {
+	budget := req.Thinking.GetBudgetTokens()
+	if budget == nil || *budget < req.MaxTokens {
+		return
+	}
+	if half := req.MaxTokens / 2; half >= anthropicMinThinkingBudget {
+		req.Thinking = anthropic.ThinkingConfigParamOfEnabled(half)
+		return
+	}
+	req.Thinking = anthropic.ThinkingConfigParamUnion{}
+}
+
+// convertResponsesPartsToAnthropic converts user message content parts to Anthropic content blocks
+func convertResponsesPartsToAnthropic(content interface{}) []anthropic.ContentBlockParamUnion {
+	var blocks []anthropic.ContentBlockParamUnion
+	for _, part := range responsesContentParts(content) {
+		partType, _ := part["type"].(string)
+		switch partType {
+		case "input_text", "output_text", "text":
+			if text, _ := part["text"].(string); text != "" {
+				blocks = append(blocks, anthropic.NewTextBlock(text))
+			}
+		case "input_image":
+			url := responsesImageURL(part)
+			if mediaType, data, ok := parseDataURL(url); ok {
+				blocks = append(blocks, anthropic.NewImageBlockBase64(mediaType, data))
+			} else if url != "" {
+				blocks = append(blocks, anthropic.NewTextBlock("[Image: "+url+"]"))
+			}
+		}
+	}
+	return blocks
+}
+
+// ConvertResponsesToolsToAnthropic converts Responses API function tools to Anthropic tools.
+// Built-in tools (web_search, file_search, ...) have no Anthropic equivalent and are skipped.
+func ConvertResponsesToolsToAnthropic(tools []responses.ToolUnionParam) []anthropic.ToolUnionParam {
+	out := make([]anthropic.ToolUnionParam, 0, len(tools))
+
+	for _, t := range tools {
+		fn := t.OfFunction
+		if fn == nil {
+			continue
+		}
+
+		var schema anthropic.ToolInputSchemaParam
+		if fn.Parameters != nil {
+			if schemaBytes, err := json.Marshal(fn.Parameters); err == nil {
+				_ = json.Unmarshal(schemaBytes, &schema)
+			}
+		}
+
+		tool := &anthropic.ToolParam{
+			Name:        fn.Name,
+			InputSchema: schema,
+		}
+		if !param.IsOmitted(fn.Description) && fn.Description.Value != "" {
+			tool.Description = anthropic.Opt(fn.Description.Value)
+		}
+		out = append(out, anthropic.ToolUnionParam{OfTool: tool})
+	}
+
+	return out
+}
+
+// ConvertResponsesToolChoiceToAnthropic converts a Responses API tool_choice to Anthropic format
+func ConvertResponsesToolChoiceToAnthropic(tc responses.ResponseNewParamsToolChoiceUnion) anthropic.ToolChoiceUnionParam {
+	if !param.IsOmitted(tc.OfToolChoiceMode) {
+		switch tc.OfToolChoiceMode.Value {
+		case "required":
+			return anthropic.ToolChoiceUnionParam{OfAny: &anthropic.ToolChoiceAnyParam{}}
+		case "none":
+			return anthropic.ToolChoiceUnionParam{OfNone: &anthropic.ToolChoiceNoneParam{}}
+		}
+	}
+
+	if tc.OfFunctionTool != nil && tc.OfFunctionTool.Name != "" {
+		return anthropic.ToolChoiceParamOfTool(tc.OfFunctionTool.Name)
+	}
+
+	// Default to auto
+	return anthropic.ToolChoiceUnionParam{OfAuto: &anthropic.ToolChoiceAutoParam{}}
+}
@@ -0,0 +1,168 @@
+package request
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+)
+
+// responsesToolLoopRequest is a Codex-style request: instructions, a tool call and its output
+const responsesToolLoopRequest = `{
+	"model": "claude-sonnet",
+	"instructions": "You are a coding agent.",
+	"reasoning": {"effort": "medium"},
+	"input": [
+		{"role": "developer", "content": "Be concise."},
+		{"type": "message", "role": "user", "content": [{"type": "input_text", "text": "List the files"}]},
+		{"type": "reasoning", "id": "rs_1", "summary": [{"type": "summary_text", "text": "Use the shell"}], "encrypted_content": "anthropic:c2lnbmF0dXJl"},
+		{"type": "function_call", "call_id": "call_1", "name": "shell", "arguments": "{\"cmd\":\"ls\"}"},
+		{"type": "function_call_output", "call_id": "call_1", "output": "main.go"},
+		{"type": "message", "role": "assistant", "content": [{"type": "output_text", "text": "There is main.go"}]},
+		{"role": "user", "content": "Thanks"}
+	],
+	"tools": [{"type": "function", "name": "shell", "description": "Run a command", "parameters": {"type": "object", "properties": {"cmd": {"type": "string"}}, "required": ["cmd"]}}],
+	"tool_choice": "required"
+}`
+
+func parseResponsesRequest(t *testing.T, body string) *protocol.ResponseCreateRequest {
+	var req protocol.ResponseCreateRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+	return &req
+}
+
+func TestConvertResponsesToAnthropicRequest(t *testing.T) {
+	req := parseResponsesRequest(t, responsesToolLoopRequest)
+
+	anthropicReq := ConvertResponsesToAnthropicRequest(&req.ResponseNewParams, 32000)
+
+	assert.Equal(t, "claude-sonnet", string(anthropicReq.Model))
+	assert.Equal(t, int64(32000), anthropicReq.MaxTokens)
+	require.Len(t, anthropicReq.System, 1)
+	assert.Equal(t, "You are a coding agent.\n\nBe concise.", anthropicReq.System[0].Text)
+
+	// user, assistant (thinking + tool_use), user (tool_result), assistant (text), user
+	require.Len(t, anthropicReq.Messages, 5)
+	assert.Equal(t, "user", string(anthropicReq.Messages[0].Role))
+
+	assistant := anthropicReq.Messages[1]
+	assert.Equal(t, "assistant", string(assistant.Role))
+	require.Len(t, assistant.Content, 2)
+	require.NotNil(t, assistant.Content[0].OfThinking)
+	assert.Equal(t, "c2lnbmF0dXJl", assistant.Content[0].OfThinking.Signature)
+	assert.Equal(t, "Use the shell", assistant.Content[0].OfThinking.Thinking)
+	require.NotNil(t, assistant.Content[1].OfToolUse)
+	assert.Equal(t, "call_1", assistant.Content[1].OfToolUse.ID)
+	assert.Equal(t, "shell", assistant.Content[1].OfToolUse.Name)
+
+	toolResult := anthropicReq.Messages[2]
+	require.Len(t, toolResult.Content, 1)
+	require.NotNil(t, toolResult.Content[0].OfToolResult)
+	assert.Equal(t, "call_1", toolResult.Content[0].OfToolResult.ToolUseID)
+
+	require.NotNil(t, anthropicReq.Thinking.OfEnabled)
+	assert.Equal(t, int64(responsesThinkingBudgetMedium), anthropicReq.Thinking.OfEnabled.BudgetTokens)
+
+	require.Len(t, anthropicReq.Tools, 1)
+	assert.Equal(t, "shell", anthropicReq.Tools[0].OfTool.Name)
+	assert.NotNil(t, anthropicReq.ToolChoice.OfAny)
+}
+
+func TestConvertResponsesToAnthropicRequest_StringInput(t *testing.T) {
+	req := parseResponsesRequest(t, `{"model": "claude", "input": "Hello", "max_output_tokens": 100, "temperature": 0.2}`)
+
+	anthropicReq := ConvertResponsesToAnthropicRequest(&req.ResponseNewParams, 4096)
+
+	assert.Equal(t, int64(100), anthropicReq.MaxTokens)
+	require.Len(t, anthropicReq.Messages, 1)
+	require.NotNil(t, anthropicReq.Messages[0].Content[0].OfText)
+	assert.Equal(t, "Hello", anthropicReq.Messages[0].Content[0].OfText.Text)
+	assert.Equal(t, 0.2, anthropicReq.Temperature.Value)
+	assert.Nil(t, anthropicReq.Thinking.OfEnabled)
+}
+
+func TestClampAnthropicThinkingBudget(t *testing.T) {
+	req := parseResponsesRequest(t, `{"model": "claude", "input": "Hello", "max_output_tokens": 4000, "reasoning": {"effort": "high"}}`)
+	anthropicReq := ConvertResponsesToAnthropicRequest(&req.ResponseNewParams, 4096)
+	require.NotNil(t, anthropicReq.Thinking.OfEnabled)
+	assert.Equal(t, int64(2000), anthropicReq.Thinking.OfEnabled.BudgetTokens)
+
+	req = parseResponsesRequest(t, `{"model": "claude", "input": "Hello", "max_output_tokens": 1500, "reasoning": {"effort": "high"}}`)
+	anthropicReq = ConvertResponsesToAnthropicRequest(&req.ResponseNewParams, 4096)
+	assert.Nil(t, anthropicReq.Thinking.OfEnabled)
+}
+
+func TestConvertResponsesToGoogleRequest(t *testing.T) {
+	req := parseResponsesRequest(t, responsesToolLoopRequest)
+
+	model, contents, config := ConvertResponsesToGoogleRequest(&req.ResponseNewParams, 8192)
+
+	assert.Equal(t, "claude-sonnet", model)
+	assert.Equal(t, int32(8192), config.MaxOutputTokens)
+	require.NotNil(t, config.SystemInstruction)
+	assert.Equal(t, "You are a coding agent.\n\nBe concise.", config.SystemInstruction.Parts[0].Text)
+
+	// user, model (function call), user (function response), model (text), user
+	require.Len(t, contents, 5)
+	call := contents[1]
+	assert.Equal(t, "model", call.Role)
+	require.NotNil(t, call.Parts[0].FunctionCall)
+	assert.Equal(t, "shell", call.Parts[0].FunctionCall.Name)
+	assert.Equal(t, map[string]interface{}{"cmd": "ls"}, call.Parts[0].FunctionCall.Args)
+	assert.Nil(t, call.Parts[0].ThoughtSignature, "reasoning of another provider is dropped")
+
+	response := contents[2]
+	assert.Equal(t, "user", response.Role)
+	require.NotNil(t, response.Parts[0].FunctionResponse)
+	assert.Equal(t, "shell", response.Parts[0].FunctionResponse.Name)
+	assert.Equal(t, "call_1", response.Parts[0].FunctionResponse.ID)
+	assert.Equal(t, map[string]any{"output": "main.go"}, response.Parts[0].FunctionResponse.Response)
+
+	require.NotNil(t, config.ThinkingConfig)
+	assert.True(t, config.ThinkingConfig.IncludeThoughts)
+	require.Len(t, config.Tools, 1)
+	assert.Equal(t, "shell", config.Tools[0].FunctionDeclarations[0].Name)
+	assert.Equal(t, "ANY", string(config.ToolConfig.FunctionCallingConfig.Mode))
+}
+
+// TestConvertResponsesReasoning_Providers verifies that reasoning is only replayed to the
+// provider that produced it, and that redacted thinking is replayed as is
+func TestConvertResponsesReasoning_Providers(t *testing.T) {
+	req := parseResponsesRequest(t, `{
+		"model": "any",
+		"input": [
+			{"role": "user", "content": "List the files"},
+			{"type": "reasoning", "id": "rs_1", "summary": [], "encrypted_content": "anthropic_redacted:b3BhcXVl"},
+			{"type": "reasoning", "id": "rs_2", "summary": [], "encrypted_content": "google:c2lnbmF0dXJl"},
+			{"type": "function_call", "call_id": "call_1", "name": "shell", "arguments": "{}"},
+			{"type": "function_call_output", "call_id": "call_1", "output": "main.go"}
+		]
+	}`)
+
+	anthropicReq := ConvertResponsesToAnthropicRequest(&req.ResponseNewParams, 4096)
+	require.Len(t, anthropicReq.Messages, 3)
+	assistant := anthropicReq.Messages[1]
+	require.Len(t, assistant.Content, 2)
+	require.NotNil(t, assistant.Content[0].OfRedactedThinking)
+	assert.Equal(t, "b3BhcXVl", assistant.Content[0].OfRedactedThinking.Data)
+	assert.NotNil(t, assistant.Content[1].OfToolUse)
+
+	_, contents, _ := ConvertResponsesToGoogleRequest(&req.ResponseNewParams, 4096)
+	require.Len(t, contents, 3)
+	signature, _ := base64.StdEncoding.DecodeString("c2lnbmF0dXJl")
+	assert.Equal(t, signature, contents[1].Parts[0].ThoughtSignature)
+}
+
+func TestParseDataURL(t *testing.T) {
+	mediaType, data, ok := parseDataURL("data:image/png;base64,aGVsbG8=")
+	assert.True(t, ok)
+	assert.Equal(t, "image/png", mediaType)
+	assert.Equal(t, "aGVsbG8=", data)
+
+	_, _, ok = parseDataURL("https://example.com/a.png")
+	assert.False(t, ok)
+}
@@ -0,0 +1,230 @@
+package request
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/openai/openai-go/v3/packages/param"
+	"github.com/openai/openai-go/v3/responses"
+	"google.golang.org/genai"
+
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+)
+
+// ConvertResponsesToGoogleRequest converts an OpenAI Responses API request to Google SDK format.
+// Reasoning items that carry a Gemini thought signature in encrypted_content have it attached
+// to the next model part, as Gemini expects for function calls; reasoning of other providers
+// is dropped.
+func ConvertResponsesToGoogleRequest(params *responses.ResponseNewParams, defaultMaxTokens int64) (string, []*genai.Content, *genai.GenerateContentConfig) {
+	model := string(params.Model)
+	items := responsesInputItems(params)
+	contents := make([]*genai.Content, 0, len(items))
+	config := &genai.GenerateContentConfig{}
+
+	// Function responses need the function name, which only the function call carries
+	callNames := make(map[string]string)
+	for _, item := range items {
+		if item["type"] == "function_call" {
+			callID, _ := item["call_id"].(string)
+			name, _ := item["name"].(string)
+			callNames[callID] = name
+		}
+	}
+
+	var pendingSignature []byte
+	appendParts := func(role string, parts ...*genai.Part) {
+		if len(parts) == 0 {
+			return
+		}
+		if role == "model" && pendingSignature != nil {
+			parts[0].ThoughtSignature = pendingSignature
+			pendingSignature = nil
+		}
+		if n := len(contents); n > 0 && contents[n-1].Role == role {
+			contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+			return
+		}
+		contents = append(contents, &genai.Content{Role: role, Parts: parts})
+	}
+
+	for _, item := range items {
+		itemType, _ := item["type"].(string)
+
+		switch itemType {
+		case "message":
+			role, _ := item["role"].(string)
+			switch role {
+			case "user":
+				appendParts("user", convertResponsesPartsToGoogle(item["content"])...)
+			case "assistant":
+				if text := responsesContentText(item["content"]); text != "" {
+					appendParts("model", genai.NewPartFromText(text))
+				}
+			}
+			// system and developer messages are collected by responsesInstructions
+
+		case "function_call":
+			callID, _ := item["call_id"].(string)
+			name, _ := item["name"].(string)
+			arguments, _ := item["arguments"].(string)
+			appendParts("model", &genai.Part{
+				FunctionCall: &genai.FunctionCall{
+					ID:   callID,
+					Name: name,
+					Args: responsesFunctionArguments(arguments),
+				},
+			})
+
+		case "function_call_output":
+			callID, _ := item["call_id"].(string)
+			output := responsesContentText(item["output"])
+
+			// Try to parse as JSON first, if it fails, treat as plain text output
+			var response map[string]any
+			if err := json.Unmarshal([]byte(output), &response); err != nil {
+				response = map[string]any{"output": output}
+			}
+
+			name := callNames[callID]
+			if name == "" {
+				name = callID
+			}
+			appendParts("user", &genai.Part{
+				FunctionResponse: &genai.FunctionResponse{
+					ID:       callID,
+					Name:     name,
+					Response: response,
+				},
+			})
+
+		case "reasoning":
+			encrypted, _ := item["encrypted_content"].(string)
+			if payload, ok := protocol.DecodeResponsesReasoning(encrypted, protocol.ResponsesReasoningGoogle); ok {
+				if signature, err := base64.StdEncoding.DecodeString(payload); err == nil {
+					pendingSignature = signature
+				}
+			}
+		}
+	}
+
+	if instructions := responsesInstructions(params, items); len(instructions) > 0 {
+		config.SystemInstruction = &genai.Content{
+			Parts: []*genai.Part{genai.NewPartFromText(strings.Join(instructions, "\n\n"))},
+		}
+	}
+
+	// Determine max_tokens - use default if not set
+	maxTokens := params.MaxOutputTokens.Value
+	if maxTokens == 0 {
+		maxTokens = defaultMaxTokens
+	}
+	config.MaxOutputTokens = int32(maxTokens)
+
+	if !param.IsOmitted(params.Temperature) {
+		temperature := float32(params.Temperature.Value)
+		config.Temperature = &temperature
+	}
+	if !param.IsOmitted(params.TopP) {
+		topP := float32(params.TopP.Value)
+		config.TopP = &topP
+	}
+
+	if budget := responsesThinkingBudget(params); budget > 0 {
+		thinkingBudget := int32(budget)
+		config.ThinkingConfig = &genai.ThinkingConfig{
+			IncludeThoughts: true,
+			ThinkingBudget:  &thinkingBudget,
+		}
+	}
+
+	if len(params.Tools) > 0 {
+		if decls := ConvertResponsesToolsToGoogle(params.Tools); len(decls) > 0 {
+			config.Tools = []*genai.Tool{{FunctionDeclarations: decls}}
+			config.ToolConfig = ConvertResponsesToolChoiceToGoogle(params.ToolChoice)
+		}
+	}
+
+	return model, contents, config
+}
+
+// convertResponsesPartsToGoogle converts user message content parts to Google parts
+func convertResponsesPartsToGoogle(content interface{}) []*genai.Part {
+	var parts []*genai.Part
+	for _, part := range responsesContentParts(content) {
+		partType, _ := part["type"].(string)
+		switch partType {
+		case "input_text", "output_text", "text":
+			if text, _ := part["text"].(string); text != "" {
+				parts = append(parts, genai.NewPartFromText(text))
+			}
+		case "input_image":
+			url := responsesImageURL(part)
+			if mediaType, data, ok := parseDataURL(url); ok {
+				if decoded, err := base64.StdEncoding.DecodeString(data); err == nil {
+					parts = append(parts, &genai.Part{
+						InlineData: &genai.Blob{MIMEType: mediaType, Data: decoded},
+					})
+				}
+			} else if url != "" {
+				parts = append(parts, genai.NewPartFromText("[Image: "+url+"]"))
+			}
+		}
+	}
+	return parts
+}
+
+// ConvertResponsesToolsToGoogle converts Responses API function tools to Google function declarations.
+// Built-in tools (web_search, file_search, ...) are skipped.
+func ConvertResponsesToolsToGoogle(tools []responses.ToolUnionParam) []*genai.FunctionDeclaration {
+	out := make([]*genai.FunctionDeclaration, 0, len(tools))
+
+	for _, t := range tools {
+		fn := t.OfFunction
+		if fn == nil {
+			continue
+		}
+
+		var parameters *genai.Schema
+		if len(fn.Parameters) > 0 {
+			if schemaBytes, err := json.Marshal(fn.Parameters); err == nil {
+				_ = json.Unmarshal(schemaBytes, &parameters)
+				// Normalize schema types from lowercase (JSON Schema) to uppercase (Google format)
+				normalizeSchemaTypes(parameters)
+			}
+		}
+
+		out = append(out, &genai.FunctionDeclaration{
+			Name:        fn.Name,
+			Description: fn.Description.Value,
+			Parameters:  parameters,
+		})
+	}
+
+	return out
+}
+
+// ConvertResponsesToolChoiceToGoogle converts a Responses API tool_choice to a Google tool config
+func ConvertResponsesToolChoiceToGoogle(tc responses.ResponseNewParamsToolChoiceUnion) *genai.ToolConfig {
+	config := &genai.ToolConfig{
+		FunctionCallingConfig: &genai.FunctionCallingConfig{
+			Mode: genai.FunctionCallingConfigModeAuto,
+		},
+	}
+
+	if !param.IsOmitted(tc.OfToolChoiceMode) {
+		switch tc.OfToolChoiceMode.Value {
+		case "required":
+			config.FunctionCallingConfig.Mode = genai.FunctionCallingConfigModeAny
+		case "none":
+			config.FunctionCallingConfig.Mode = genai.FunctionCallingConfigModeNone
+		}
+	}
+
+	if tc.OfFunctionTool != nil && tc.OfFunctionTool.Name != "" {
+		config.FunctionCallingConfig.Mode = genai.FunctionCallingConfigModeAny
+		config.FunctionCallingConfig.AllowedFunctionNames = []string{tc.OfFunctionTool.Name}
+	}
+
+	return config
+}
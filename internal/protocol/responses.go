@@ -0,0 +1,172 @@
+package protocol
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Responses API output item types
+const (
+	ResponsesItemTypeMessage      = "message"
+	ResponsesItemTypeFunctionCall = "function_call"
+	ResponsesItemTypeReasoning    = "reasoning"
+)
+
+// Responses API response statuses
+const (
+	ResponsesStatusInProgress = "in_progress"
+	ResponsesStatusCompleted  = "completed"
+	ResponsesStatusIncomplete = "incomplete"
+	ResponsesStatusFailed     = "failed"
+)
+
+// ResponsesIncompleteMaxOutputTokens is the incomplete reason when the output was cut off
+const ResponsesIncompleteMaxOutputTokens = "max_output_tokens"
+
+// ResponsesResult accumulates a Responses API response that is built from the
+// response of another provider style (Anthropic, Google). The output items are
+// kept in their JSON form so they can be sent as-is in stream events.
+type ResponsesResult struct {
+	ID        string
+	Model     string
+	CreatedAt int64
+	Output    []map[string]interface{}
+	Usage     UsageStat
+
+	// IncompleteReason is set when the output was cut off, e.g. "max_output_tokens"
+	IncompleteReason string
+}
+
+// NewResponsesResult creates an empty result for the given response model
+func NewResponsesResult(model string) *ResponsesResult {
+	return &ResponsesResult{
+		ID:        NewResponsesID("resp"),
+		Model:     model,
+		CreatedAt: time.Now().Unix(),
+		Output:    []map[string]interface{}{},
+	}
+}
+
+// NewResponsesID generates an ID in the Responses API style, e.g. "resp_..." or "msg_..."
+func NewResponsesID(prefix string) string {
+	return prefix + "_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// Status returns the final status of the response
+func (r *ResponsesResult) Status() string {
+	if r.IncompleteReason != "" {
+		return ResponsesStatusIncomplete
+	}
+	return ResponsesStatusCompleted
+}
+
+// Response returns the Responses API response object with the given status
+func (r *ResponsesResult) Response(status string) map[string]interface{} {
+	resp := map[string]interface{}{
+		"id":                  r.ID,
+		"object":              "response",
+		"created_at":          r.CreatedAt,
+		"status":              status,
+		"model":               r.Model,
+		"output":              r.Output,
+		"parallel_tool_calls": true,
+		"error":               nil,
+		"incomplete_details":  nil,
+	}
+	if status == ResponsesStatusIncomplete {
+		resp["incomplete_details"] = map[string]interface{}{"reason": r.IncompleteReason}
+	}
+	if status != ResponsesStatusInProgress {
+		resp["usage"] = r.Usage.ToResponsesUsage()
+	}
+	return resp
+}
+
+// NewResponsesMessageItem creates a completed assistant message output item
+func NewResponsesMessageItem(id, text string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":     id,
+		"type":   ResponsesItemTypeMessage,
+		"status": ResponsesStatusCompleted,
+		"role":   "assistant",
+		"content": []map[string]interface{}{
+			NewResponsesOutputText(text),
+		},
+	}
+}
+
+// NewResponsesOutputText creates an output_text content part
+func NewResponsesOutputText(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "output_text",
+		"text":        text,
+		"annotations": []interface{}{},
+	}
+}
+
+// NewResponsesFunctionCallItem creates a completed function_call output item
+func NewResponsesFunctionCallItem(id, callID, name, arguments string) map[string]interface{} {
+	if arguments == "" {
+		arguments = "{}"
+	}
+	return map[string]interface{}{
+		"id":        id,
+		"type":      ResponsesItemTypeFunctionCall,
+		"status":    ResponsesStatusCompleted,
+		"call_id":   callID,
+		"name":      name,
+		"arguments": arguments,
+	}
+}
+
+// Providers of the reasoning carried in the encrypted_content of a reasoning item. The
+// payload is tagged with its provider, as a conversation may move between providers and
+// a signature is only valid for the provider that produced it.
+const (
+	ResponsesReasoningAnthropic         = "anthropic"          // Anthropic thinking signature
+	ResponsesReasoningAnthropicRedacted = "anthropic_redacted" // Anthropic redacted thinking data
+	ResponsesReasoningGoogle            = "google"             // Gemini thought signature, base64 encoded
+)
+
+// EncodeResponsesReasoning tags a provider's reasoning payload for encrypted_content
+func EncodeResponsesReasoning(provider, payload string) string {
+	if payload == "" {
+		return ""
+	}
+	return provider + ":" + payload
+}
+
+// DecodeResponsesReasoning returns the payload of an encrypted_content produced by the
+// given provider. ok is false for reasoning of any other provider.
+func DecodeResponsesReasoning(encryptedContent, provider string) (payload string, ok bool) {
+	payload, ok = strings.CutPrefix(encryptedContent, provider+":")
+	return payload, ok && payload != ""
+}
+
+// NewResponsesReasoningItem creates a reasoning output item. The provider's
+// thinking signature is carried in encrypted_content, tagged by EncodeResponsesReasoning,
+// so that the reasoning can be replayed when the client sends the item back.
+func NewResponsesReasoningItem(id, summary, encryptedContent string) map[string]interface{} {
+	item := map[string]interface{}{
+		"id":      id,
+		"type":    ResponsesItemTypeReasoning,
+		"summary": []map[string]interface{}{},
+	}
+	if summary != "" {
+		item["summary"] = []map[string]interface{}{NewResponsesSummaryText(summary)}
+	}
+	if encryptedContent != "" {
+		item["encrypted_content"] = encryptedContent
+	}
+	return item
+}
+
+// NewResponsesSummaryText creates a summary_text part of a reasoning item
+func NewResponsesSummaryText(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "summary_text",
+		"text": text,
+	}
+}
@@ -2,9 +2,12 @@ package protocol
 
 import (
 	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/param"
+	"github.com/openai/openai-go/v3/responses"
 )
 
-// File provides message round grouping for Anthropic requests.
+// File provides message round grouping for Anthropic and OpenAI requests.
 //
 // A conversation round is defined as starting from a pure user instruction
 // (not a tool result), followed by assistant messages (which may include tool use),
@@ -24,6 +27,25 @@ type BetaRound struct {
 	Stats          *RoundStats // Optional metadata about the round structure
 }
 
+// OpenAIRound represents a conversation round for the OpenAI chat completions API.
+type OpenAIRound struct {
+	Messages       []openai.ChatCompletionMessageParamUnion
+	IsCurrentRound bool
+	Stats          *RoundStats // Optional metadata about the round structure
+}
+
+// ResponsesRound represents a conversation round for the OpenAI Responses API.
+type ResponsesRound struct {
+	Items          []responses.ResponseInputItemUnionParam
+	IsCurrentRound bool
+	Stats          *RoundStats // Optional metadata about the round structure
+}
+
+// OpenAIReasoningFields are the assistant message fields OpenAI-compatible providers
+// use to carry reasoning content (DeepSeek reasoning_content, OpenRouter reasoning
+// and reasoning_details, and x_thinking kept by the Anthropic conversion).
+var OpenAIReasoningFields = []string{"reasoning_content", "reasoning", "reasoning_details", "x_thinking"}
+
 // RoundStats contains metadata about a round's message composition.
 type RoundStats struct {
 	UserMessageCount int  // Number of pure user messages in this round (should be 1)
@@ -113,6 +135,76 @@ func (g *Grouper) GroupBeta(messages []anthropic.BetaMessageParam) []BetaRound {
 	return rounds
 }
 
+// GroupOpenAI groups OpenAI chat messages into conversation rounds.
+// System and developer messages before the first user message belong to the first round.
+func (g *Grouper) GroupOpenAI(messages []openai.ChatCompletionMessageParamUnion) []OpenAIRound {
+	var rounds []OpenAIRound
+	var currentRound []openai.ChatCompletionMessageParamUnion
+	hasUser := false
+
+	for _, msg := range messages {
+		if g.IsPureOpenAIUserMessage(msg) {
+			// Save previous round if it has started
+			if hasUser {
+				rounds = append(rounds, OpenAIRound{
+					Messages:       currentRound,
+					IsCurrentRound: false,
+					Stats:          g.analyzeOpenAIRound(currentRound),
+				})
+				currentRound = nil
+			}
+			hasUser = true
+		}
+		currentRound = append(currentRound, msg)
+	}
+
+	// Add the last round (current round)
+	if len(currentRound) > 0 {
+		rounds = append(rounds, OpenAIRound{
+			Messages:       currentRound,
+			IsCurrentRound: true,
+			Stats:          g.analyzeOpenAIRound(currentRound),
+		})
+	}
+
+	return rounds
+}
+
+// GroupResponses groups Responses API input items into conversation rounds.
+// Instruction items before the first user message belong to the first round.
+func (g *Grouper) GroupResponses(items []responses.ResponseInputItemUnionParam) []ResponsesRound {
+	var rounds []ResponsesRound
+	var currentRound []responses.ResponseInputItemUnionParam
+	hasUser := false
+
+	for _, item := range items {
+		if g.IsPureResponsesUserItem(item) {
+			// Save previous round if it has started
+			if hasUser {
+				rounds = append(rounds, ResponsesRound{
+					Items:          currentRound,
+					IsCurrentRound: false,
+					Stats:          g.analyzeResponsesRound(currentRound),
+				})
+				currentRound = nil
+			}
+			hasUser = true
+		}
+		currentRound = append(currentRound, item)
+	}
+
+	// Add the last round (current round)
+	if len(currentRound) > 0 {
+		rounds = append(rounds, ResponsesRound{
+			Items:          currentRound,
+			IsCurrentRound: true,
+			Stats:          g.analyzeResponsesRound(currentRound),
+		})
+	}
+
+	return rounds
+}
+
 // IsPureUserMessage checks if a v1 message is a pure user instruction (not a tool result).
 func (g *Grouper) IsPureUserMessage(msg anthropic.MessageParam) bool {
 	if string(msg.Role) != "user" {
@@ -141,6 +233,37 @@ func (g *Grouper) IsPureBetaUserMessage(msg anthropic.BetaMessageParam) bool {
 	return true
 }
 
+// IsPureOpenAIUserMessage checks if an OpenAI chat message is a user instruction.
+// Tool results have their own role in OpenAI chat, so every user message is pure.
+func (g *Grouper) IsPureOpenAIUserMessage(msg openai.ChatCompletionMessageParamUnion) bool {
+	return msg.OfUser != nil
+}
+
+// IsPureResponsesUserItem checks if a Responses API input item is a user instruction.
+func (g *Grouper) IsPureResponsesUserItem(item responses.ResponseInputItemUnionParam) bool {
+	if !param.IsOmitted(item.OfMessage) {
+		return string(item.OfMessage.Role) == "user"
+	}
+	if !param.IsOmitted(item.OfInputMessage) {
+		return item.OfInputMessage.Role == "user"
+	}
+	return false
+}
+
+// HasOpenAIReasoning checks if an OpenAI assistant message carries reasoning content.
+func HasOpenAIReasoning(msg openai.ChatCompletionMessageParamUnion) bool {
+	if msg.OfAssistant == nil {
+		return false
+	}
+	extra := msg.ExtraFields()
+	for _, field := range OpenAIReasoningFields {
+		if _, ok := extra[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 // analyzeV1Round analyzes a v1 round and returns its stats.
 func (g *Grouper) analyzeV1Round(messages []anthropic.MessageParam) *RoundStats {
 	stats := &RoundStats{
@@ -198,3 +321,51 @@ func (g *Grouper) analyzeBetaRound(messages []anthropic.BetaMessageParam) *Round
 
 	return stats
 }
+
+// analyzeOpenAIRound analyzes an OpenAI chat round and returns its stats.
+func (g *Grouper) analyzeOpenAIRound(messages []openai.ChatCompletionMessageParamUnion) *RoundStats {
+	stats := &RoundStats{
+		TotalMessages: len(messages),
+	}
+
+	for _, msg := range messages {
+		switch {
+		case msg.OfUser != nil:
+			stats.UserMessageCount++
+		case msg.OfTool != nil, msg.OfFunction != nil:
+			stats.ToolResultCount++
+		case msg.OfAssistant != nil:
+			stats.AssistantCount++
+			if HasOpenAIReasoning(msg) {
+				stats.HasThinking = true
+			}
+		}
+	}
+
+	return stats
+}
+
+// analyzeResponsesRound analyzes a Responses API round and returns its stats.
+// Function calls count as assistant output, reasoning items as thinking.
+func (g *Grouper) analyzeResponsesRound(items []responses.ResponseInputItemUnionParam) *RoundStats {
+	stats := &RoundStats{
+		TotalMessages: len(items),
+	}
+
+	for _, item := range items {
+		switch {
+		case g.IsPureResponsesUserItem(item):
+			stats.UserMessageCount++
+		case !param.IsOmitted(item.OfFunctionCallOutput):
+			stats.ToolResultCount++
+		case !param.IsOmitted(item.OfFunctionCall), !param.IsOmitted(item.OfOutputMessage):
+			stats.AssistantCount++
+		case !param.IsOmitted(item.OfMessage) && string(item.OfMessage.Role) == "assistant":
+			stats.AssistantCount++
+		case !param.IsOmitted(item.OfReasoning):
+			stats.HasThinking = true
+		}
+	}
+
+	return stats
+}
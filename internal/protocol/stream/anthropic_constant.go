@@ -19,9 +19,10 @@ const (
 	eventTypeError             = "error"
 
 	// Anthropic block types
-	blockTypeText     = "text"
-	blockTypeThinking = "thinking"
-	blockTypeToolUse  = "tool_use"
+	blockTypeText             = "text"
+	blockTypeThinking         = "thinking"
+	blockTypeRedactedThinking = "redacted_thinking"
+	blockTypeToolUse          = "tool_use"
 
 	// Anthropic delta types
 	deltaTypeTextDelta      = "text_delta"
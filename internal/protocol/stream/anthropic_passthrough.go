@@ -22,7 +22,7 @@ func HandleAnthropicV1Stream(hc *protocol.HandleContext, req anthropic.MessageNe
 
 	hc.SetupSSEHeaders()
 
-	var inputTokens, outputTokens int
+	var usage protocol.UsageStat
 	var hasUsage bool
 
 	err := hc.ProcessStream(
@@ -38,12 +38,13 @@ func HandleAnthropicV1Stream(hc *protocol.HandleContext, req anthropic.MessageNe
 			evt := event.(*anthropic.MessageStreamEventUnion)
 			evt.Message.Model = anthropic.Model(hc.ResponseModel)
 
-			if evt.Usage.InputTokens > 0 {
-				inputTokens = int(evt.Usage.InputTokens)
-				hasUsage = true
+			switch evt.Type {
+			case "message_start":
+				usage.Merge(protocol.NewUsageStatFromAnthropic(evt.Message.Usage))
+			case "message_delta":
+				usage.Merge(protocol.NewUsageStatFromAnthropicDelta(evt.Usage))
 			}
-			if evt.Usage.OutputTokens > 0 {
-				outputTokens = int(evt.Usage.OutputTokens)
+			if usage.HasUsage() {
 				hasUsage = true
 			}
 
@@ -65,16 +66,16 @@ func HandleAnthropicV1Stream(hc *protocol.HandleContext, req anthropic.MessageNe
 			if !hasUsage {
 				return protocol.ZeroUsageStat(), nil
 			}
-			return protocol.NewUsageStat(inputTokens, outputTokens), nil
+			return usage, nil
 		}
 
 		MarshalAndSendErrorEvent(hc.GinContext, err.Error(), "stream_error", "stream_failed")
-		return protocol.NewUsageStat(inputTokens, outputTokens), err
+		return usage, err
 	}
 
 	SendFinishEvent(hc.GinContext)
 
-	return protocol.NewUsageStat(inputTokens, outputTokens), nil
+	return usage, nil
 }
 
 // HandleAnthropicV1BetaStream handles Anthropic v1 beta streaming response.
@@ -84,7 +85,7 @@ func HandleAnthropicV1BetaStream(hc *protocol.HandleContext, req anthropic.BetaM
 
 	hc.SetupSSEHeaders()
 
-	var inputTokens, outputTokens int
+	var usage protocol.UsageStat
 	var hasUsage bool
 
 	err := hc.ProcessStream(
@@ -100,12 +101,13 @@ func HandleAnthropicV1BetaStream(hc *protocol.HandleContext, req anthropic.BetaM
 			evt := event.(*anthropic.BetaRawMessageStreamEventUnion)
 			evt.Message.Model = anthropic.Model(hc.ResponseModel)
 
-			if evt.Usage.InputTokens > 0 {
-				inputTokens = int(evt.Usage.InputTokens)
-				hasUsage = true
+			switch evt.Type {
+			case "message_start":
+				usage.Merge(protocol.NewUsageStatFromAnthropicBeta(evt.Message.Usage))
+			case "message_delta":
+				usage.Merge(protocol.NewUsageStatFromAnthropicBetaDelta(evt.Usage))
 			}
-			if evt.Usage.OutputTokens > 0 {
-				outputTokens = int(evt.Usage.OutputTokens)
+			if usage.HasUsage() {
 				hasUsage = true
 			}
 
@@ -127,16 +129,16 @@ func HandleAnthropicV1BetaStream(hc *protocol.HandleContext, req anthropic.BetaM
 			if !hasUsage {
 				return protocol.ZeroUsageStat(), nil
 			}
-			return protocol.NewUsageStat(inputTokens, outputTokens), nil
+			return usage, nil
 		}
 
 		MarshalAndSendErrorEvent(hc.GinContext, err.Error(), "stream_error", "stream_failed")
-		return protocol.NewUsageStat(inputTokens, outputTokens), err
+		return usage, err
 	}
 
 	SendFinishEvent(hc.GinContext)
 
-	return protocol.NewUsageStat(inputTokens, outputTokens), nil
+	return usage, nil
 }
 
 // ===================================================================
@@ -14,11 +14,13 @@ import (
 	anthropicstream "github.com/anthropics/anthropic-sdk-go/packages/ssestream"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+
+	"github.com/tingly-dev/tingly-box/internal/protocol"
 )
 
 // HandleAnthropicToOpenAIStreamResponse processes Anthropic streaming events and converts them to OpenAI format
-// Returns inputTokens, outputTokens, and error for usage tracking
-func HandleAnthropicToOpenAIStreamResponse(c *gin.Context, req *anthropic.MessageNewParams, stream *anthropicstream.Stream[anthropic.MessageStreamEventUnion], responseModel string) (int, int, error) {
+// Returns UsageStat containing token usage information for tracking.
+func HandleAnthropicToOpenAIStreamResponse(c *gin.Context, req *anthropic.MessageNewParams, stream *anthropicstream.Stream[anthropic.MessageStreamEventUnion], responseModel string) (protocol.UsageStat, error) {
 	logrus.Info("Starting Anthropic to OpenAI streaming response handler")
 	defer func() {
 		if r := recover(); r != nil {
@@ -52,13 +54,12 @@ func HandleAnthropicToOpenAIStreamResponse(c *gin.Context, req *anthropic.Messag
 
 	// Track streaming state
 	var (
-		chatID       = fmt.Sprintf("chatcmpl-%d", time.Now().Unix())
-		created      = time.Now().Unix()
-		contentText  = strings.Builder{}
-		usage        *anthropic.MessageDeltaUsage
-		inputTokens  int
-		outputTokens int
-		finished     bool
+		chatID      = fmt.Sprintf("chatcmpl-%d", time.Now().Unix())
+		created     = time.Now().Unix()
+		contentText = strings.Builder{}
+		usage       *anthropic.MessageDeltaUsage
+		usageStat   protocol.UsageStat
+		finished    bool
 	)
 
 	// Process the stream with context cancellation checking
@@ -81,6 +82,8 @@ func HandleAnthropicToOpenAIStreamResponse(c *gin.Context, req *anthropic.Messag
 		// Handle different event types
 		switch event.Type {
 		case "message_start":
+			usageStat.Merge(protocol.NewUsageStatFromAnthropic(event.Message.Usage))
+
 			// Send initial chat completion chunk
 			chunk := map[string]interface{}{
 				"id":      chatID,
@@ -133,8 +136,7 @@ func HandleAnthropicToOpenAIStreamResponse(c *gin.Context, req *anthropic.Messag
 			// Message delta (includes usage info)
 			if event.Usage.InputTokens != 0 || event.Usage.OutputTokens != 0 {
 				usage = &event.Usage
-				inputTokens = int(event.Usage.InputTokens)
-				outputTokens = int(event.Usage.OutputTokens)
+				usageStat.Merge(protocol.NewUsageStatFromAnthropicDelta(event.Usage))
 			}
 
 		case "message_stop":
@@ -174,7 +176,7 @@ func HandleAnthropicToOpenAIStreamResponse(c *gin.Context, req *anthropic.Messag
 	})
 
 	if finished {
-		return inputTokens, outputTokens, nil
+		return usageStat, nil
 	}
 
 	// Check for stream errors
@@ -182,12 +184,12 @@ func HandleAnthropicToOpenAIStreamResponse(c *gin.Context, req *anthropic.Messag
 		// Check if it was a client cancellation
 		if errors.Is(err, context.Canceled) {
 			logrus.Debug("Anthropic to OpenAI stream canceled by client")
-			return inputTokens, outputTokens, nil
+			return usageStat, nil
 		}
 		// EOF is expected when stream ends normally
 		if errors.Is(err, io.EOF) {
 			logrus.Info("Anthropic stream ended normally (EOF)")
-			return inputTokens, outputTokens, nil
+			return usageStat, nil
 		}
 		logrus.Errorf("Anthropic stream error: %v", err)
 		// Send error event in OpenAI format
@@ -205,10 +207,10 @@ func HandleAnthropicToOpenAIStreamResponse(c *gin.Context, req *anthropic.Messag
 		if flusher, ok := c.Writer.(http.Flusher); ok {
 			flusher.Flush()
 		}
-		return inputTokens, outputTokens, nil
+		return usageStat, nil
 	}
 
-	return inputTokens, outputTokens, nil
+	return usageStat, nil
 }
 
 // sendOpenAIStreamChunk helper function to send a chunk in OpenAI format
@@ -15,12 +15,14 @@ import (
 	"github.com/sirupsen/logrus"
 	"google.golang.org/genai"
 
+	"github.com/tingly-dev/tingly-box/internal/protocol"
 	"github.com/tingly-dev/tingly-box/internal/protocol/nonstream"
 )
 
 // HandleOpenAIToGoogleStreamResponse processes OpenAI streaming events and converts them to Google format
-// This handler writes Google-format streaming responses to the gin.Context
-func HandleOpenAIToGoogleStreamResponse(c *gin.Context, stream *openaistream.Stream[openai.ChatCompletionChunk], responseModel string) error {
+// This handler writes Google-format streaming responses (alt=sse) to the gin.Context.
+// Text is sent as incremental chunks; function calls are sent once complete, with the final chunk.
+func HandleOpenAIToGoogleStreamResponse(c *gin.Context, stream *openaistream.Stream[openai.ChatCompletionChunk], responseModel string) (protocol.UsageStat, error) {
 	logrus.Info("Starting OpenAI to Google streaming response handler")
 	defer func() {
 		if r := recover(); r != nil {
@@ -41,176 +43,82 @@ func HandleOpenAIToGoogleStreamResponse(c *gin.Context, stream *openaistream.Str
 		logrus.Info("Finished OpenAI to Google streaming response handler")
 	}()
 
-	// Set SSE headers
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("Access-Control-Allow-Origin", "*")
+	var usage protocol.UsageStat
 
-	flusher, ok := c.Writer.(http.Flusher)
+	flusher, ok := setGoogleStreamHeaders(c)
 	if !ok {
-		return errors.New("Streaming not supported by this connection")
+		return usage, errors.New("Streaming not supported by this connection")
 	}
 
-	// Google format sends GenerateContentResponse objects as JSON
-	// Each chunk contains incremental content
-
-	// Track streaming state
+	// Tool call arguments arrive in fragments, keyed by tool call index
 	var (
-		currentContent   strings.Builder
-		currentToolCalls []map[string]interface{}
-		outputTokens     int64
+		toolCalls    []*googleStreamToolCall
+		toolCallsIdx = make(map[int64]*googleStreamToolCall)
+		finishReason string
 	)
 
 	// Process the stream
 	for stream.Next() {
 		chunk := stream.Current()
 
-		// Check if we have choices
+		// Usage arrives with the last chunk when stream_options.include_usage is set
+		if chunk.Usage.PromptTokens > 0 || chunk.Usage.CompletionTokens > 0 {
+			usage.Merge(protocol.NewUsageStatFromOpenAIChat(chunk.Usage))
+		}
+
 		if len(chunk.Choices) == 0 {
-			if chunk.Usage.CompletionTokens > 0 {
-				outputTokens = chunk.Usage.CompletionTokens
-			}
 			continue
 		}
-
 		choice := chunk.Choices[0]
 		delta := choice.Delta
 
 		// Handle content delta
 		if delta.Content != "" {
-			currentContent.WriteString(delta.Content)
-
-			// Send incremental response
-			googleResp := &genai.GenerateContentResponse{
-				Candidates: []*genai.Candidate{
-					{
-						Content: &genai.Content{
-							Role: "model",
-							Parts: []*genai.Part{
-								genai.NewPartFromText(currentContent.String()),
-							},
-						},
-						FinishReason: "",
-						Index:        0,
-					},
-				},
-			}
-			sendGoogleStreamChunk(c, googleResp, flusher)
+			sendGoogleStreamChunk(c, newGoogleStreamChunk(responseModel, []*genai.Part{genai.NewPartFromText(delta.Content)}, ""), flusher)
 		}
 
-		// Handle tool_calls delta
-		if len(delta.ToolCalls) > 0 {
-			for _, toolCall := range delta.ToolCalls {
-				// For simplicity, send the current state of tool calls
-				// In a more complex implementation, you'd track incremental updates
-				var argsInput map[string]interface{}
-				if toolCall.Function.Arguments != "" {
-					_ = json.Unmarshal([]byte(toolCall.Function.Arguments), &argsInput)
-				}
-
-				currentToolCalls = append(currentToolCalls, map[string]interface{}{
-					"id":   toolCall.ID,
-					"name": toolCall.Function.Name,
-					"args": argsInput,
-				})
-
-				// Send incremental response with tool calls
-				parts := []*genai.Part{}
-				if currentContent.Len() > 0 {
-					parts = append(parts, genai.NewPartFromText(currentContent.String()))
-				}
-				for _, tc := range currentToolCalls {
-					if name, ok := tc["name"].(string); ok {
-						if args, ok := tc["args"].(map[string]interface{}); ok {
-							if id, ok := tc["id"].(string); ok {
-								parts = append(parts, &genai.Part{
-									FunctionCall: &genai.FunctionCall{
-										ID:   id,
-										Name: name,
-										Args: args,
-									},
-								})
-							}
-						}
-					}
-				}
-
-				googleResp := &genai.GenerateContentResponse{
-					Candidates: []*genai.Candidate{
-						{
-							Content: &genai.Content{
-								Role:  "model",
-								Parts: parts,
-							},
-							FinishReason: "",
-							Index:        0,
-						},
-					},
-				}
-				sendGoogleStreamChunk(c, googleResp, flusher)
+		// Accumulate tool call fragments
+		for _, toolCall := range delta.ToolCalls {
+			tc, exists := toolCallsIdx[toolCall.Index]
+			if !exists {
+				tc = &googleStreamToolCall{}
+				toolCallsIdx[toolCall.Index] = tc
+				toolCalls = append(toolCalls, tc)
 			}
-		}
-
-		// Track usage
-		if chunk.Usage.CompletionTokens > 0 {
-			outputTokens = chunk.Usage.CompletionTokens
-		}
-
-		// Handle finish_reason (last chunk)
-		if choice.FinishReason != "" {
-			// Send final response with finish reason
-			parts := []*genai.Part{}
-			if currentContent.Len() > 0 {
-				parts = append(parts, genai.NewPartFromText(currentContent.String()))
+			if toolCall.ID != "" {
+				tc.id = toolCall.ID
 			}
-			for _, tc := range currentToolCalls {
-				if name, ok := tc["name"].(string); ok {
-					if args, ok := tc["args"].(map[string]interface{}); ok {
-						if id, ok := tc["id"].(string); ok {
-							parts = append(parts, &genai.Part{
-								FunctionCall: &genai.FunctionCall{
-									ID:   id,
-									Name: name,
-									Args: args,
-								},
-							})
-						}
-					}
-				}
+			if toolCall.Function.Name != "" {
+				tc.name = toolCall.Function.Name
 			}
+			tc.args.WriteString(toolCall.Function.Arguments)
+		}
 
-			googleResp := &genai.GenerateContentResponse{
-				Candidates: []*genai.Candidate{
-					{
-						Content: &genai.Content{
-							Role:  "model",
-							Parts: parts,
-						},
-						FinishReason: nonstream.MapOpenAIFinishReasonToGoogle(choice.FinishReason),
-						Index:        0,
-					},
-				},
-				UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
-					CandidatesTokenCount: int32(outputTokens),
-				},
-			}
-			sendGoogleStreamChunk(c, googleResp, flusher)
-			return nil
+		if choice.FinishReason != "" {
+			finishReason = choice.FinishReason
 		}
 	}
 
 	// Check for stream errors
 	if err := stream.Err(); err != nil {
 		logrus.Errorf("OpenAI stream error: %v", err)
-		return nil
+		return usage, err
+	}
+
+	if finishReason == "" {
+		finishReason = "stop"
 	}
+	final := newGoogleStreamChunk(responseModel, googleFunctionCallParts(toolCalls), nonstream.MapOpenAIFinishReasonToGoogle(finishReason))
+	final.UsageMetadata = usage.ToGoogleUsageMetadata()
+	sendGoogleStreamChunk(c, final, flusher)
 
-	return nil
+	return usage, nil
 }
 
 // HandleAnthropicToGoogleStreamResponse processes Anthropic streaming events and converts them to Google format
-func HandleAnthropicToGoogleStreamResponse(c *gin.Context, stream *anthropicstream.Stream[anthropic.MessageStreamEventUnion], responseModel string) error {
+// This handler writes Google-format streaming responses (alt=sse) to the gin.Context.
+// Text is sent as incremental chunks; function calls are sent once complete, with the final chunk.
+func HandleAnthropicToGoogleStreamResponse(c *gin.Context, stream *anthropicstream.Stream[anthropic.MessageStreamEventUnion], responseModel string) (protocol.UsageStat, error) {
 	logrus.Info("Starting Anthropic to Google streaming response handler")
 	defer func() {
 		if r := recover(); r != nil {
@@ -231,22 +139,18 @@ func HandleAnthropicToGoogleStreamResponse(c *gin.Context, stream *anthropicstre
 		logrus.Info("Finished Anthropic to Google streaming response handler")
 	}()
 
-	// Set SSE headers
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("Access-Control-Allow-Origin", "*")
+	var usage protocol.UsageStat
 
-	flusher, ok := c.Writer.(http.Flusher)
+	flusher, ok := setGoogleStreamHeaders(c)
 	if !ok {
-		return errors.New("Streaming not supported by this connection")
+		return usage, errors.New("Streaming not supported by this connection")
 	}
 
-	// Google format sends GenerateContentResponse objects as JSON
+	// Tool use input arrives as partial JSON, keyed by content block index
 	var (
-		currentContent   strings.Builder
-		currentToolCalls []map[string]interface{}
-		outputTokens     int64
+		toolCalls    []*googleStreamToolCall
+		toolCallsIdx = make(map[int64]*googleStreamToolCall)
+		stopReason   string
 	)
 
 	// Process the stream
@@ -254,128 +158,107 @@ func HandleAnthropicToGoogleStreamResponse(c *gin.Context, stream *anthropicstre
 		event := stream.Current()
 
 		switch event.Type {
-		case "content_block_delta":
-			// Text delta - send as Google chunk
-			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
-				currentContent.WriteString(event.Delta.Text)
-
-				googleResp := &genai.GenerateContentResponse{
-					Candidates: []*genai.Candidate{
-						{
-							Content: &genai.Content{
-								Role: "model",
-								Parts: []*genai.Part{
-									genai.NewPartFromText(currentContent.String()),
-								},
-							},
-							FinishReason: "",
-							Index:        0,
-						},
-					},
-				}
-				sendGoogleStreamChunk(c, googleResp, flusher)
-			}
+		case "message_start":
+			usage.Merge(protocol.NewUsageStatFromAnthropic(event.Message.Usage))
 
 		case "content_block_start":
 			if event.ContentBlock.Type == "tool_use" {
-				// New tool use block
-				currentToolCalls = append(currentToolCalls, map[string]interface{}{
-					"id":   event.ContentBlock.ID,
-					"name": event.ContentBlock.Name,
-					"args": event.ContentBlock.Input,
-				})
-
-				// Send with tool call
-				parts := []*genai.Part{}
-				if currentContent.Len() > 0 {
-					parts = append(parts, genai.NewPartFromText(currentContent.String()))
-				}
-				for _, tc := range currentToolCalls {
-					if name, ok := tc["name"].(string); ok {
-						if args, ok := tc["args"].(map[string]interface{}); ok {
-							if id, ok := tc["id"].(string); ok {
-								parts = append(parts, &genai.Part{
-									FunctionCall: &genai.FunctionCall{
-										ID:   id,
-										Name: name,
-										Args: args,
-									},
-								})
-							}
-						}
-					}
-				}
+				tc := &googleStreamToolCall{id: event.ContentBlock.ID, name: event.ContentBlock.Name}
+				toolCallsIdx[event.Index] = tc
+				toolCalls = append(toolCalls, tc)
+			}
 
-				googleResp := &genai.GenerateContentResponse{
-					Candidates: []*genai.Candidate{
-						{
-							Content: &genai.Content{
-								Role:  "model",
-								Parts: parts,
-							},
-							FinishReason: "",
-							Index:        0,
-						},
-					},
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "text_delta":
+				if event.Delta.Text != "" {
+					sendGoogleStreamChunk(c, newGoogleStreamChunk(responseModel, []*genai.Part{genai.NewPartFromText(event.Delta.Text)}, ""), flusher)
+				}
+			case "input_json_delta":
+				if tc, exists := toolCallsIdx[event.Index]; exists {
+					tc.args.WriteString(event.Delta.PartialJSON)
 				}
-				sendGoogleStreamChunk(c, googleResp, flusher)
 			}
 
 		case "message_delta":
-			// Message delta (includes usage info)
-			if event.Usage.OutputTokens != 0 {
-				outputTokens = event.Usage.OutputTokens
+			// Message delta carries the stop reason and cumulative usage
+			if event.Delta.StopReason != "" {
+				stopReason = string(event.Delta.StopReason)
 			}
+			usage.Merge(protocol.NewUsageStatFromAnthropicDelta(event.Usage))
 
 		case "message_stop":
-			// Send final response with finish reason
-			parts := []*genai.Part{}
-			if currentContent.Len() > 0 {
-				parts = append(parts, genai.NewPartFromText(currentContent.String()))
-			}
-			for _, tc := range currentToolCalls {
-				if name, ok := tc["name"].(string); ok {
-					if args, ok := tc["args"].(map[string]interface{}); ok {
-						if id, ok := tc["id"].(string); ok {
-							parts = append(parts, &genai.Part{
-								FunctionCall: &genai.FunctionCall{
-									ID:   id,
-									Name: name,
-									Args: args,
-								},
-							})
-						}
-					}
-				}
-			}
-
-			googleResp := &genai.GenerateContentResponse{
-				Candidates: []*genai.Candidate{
-					{
-						Content: &genai.Content{
-							Role:  "model",
-							Parts: parts,
-						},
-						FinishReason: nonstream.MapAnthropicFinishReasonToGoogle("end_turn"),
-						Index:        0,
-					},
-				},
-				UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
-					CandidatesTokenCount: int32(outputTokens),
-				},
+			if stopReason == "" {
+				stopReason = "end_turn"
 			}
-			sendGoogleStreamChunk(c, googleResp, flusher)
-			return nil
+			final := newGoogleStreamChunk(responseModel, googleFunctionCallParts(toolCalls), nonstream.MapAnthropicFinishReasonToGoogle(stopReason))
+			final.UsageMetadata = usage.ToGoogleUsageMetadata()
+			sendGoogleStreamChunk(c, final, flusher)
+			return usage, nil
 		}
 	}
 
 	// Check for stream errors
 	if err := stream.Err(); err != nil {
 		logrus.Errorf("Anthropic stream error: %v", err)
-		return nil
+		return usage, err
+	}
+
+	return usage, nil
+}
+
+// googleStreamToolCall accumulates a function call while it is being streamed
+type googleStreamToolCall struct {
+	id   string
+	name string
+	args strings.Builder
+}
+
+// googleFunctionCallParts converts the accumulated function calls to Google parts
+func googleFunctionCallParts(toolCalls []*googleStreamToolCall) []*genai.Part {
+	parts := make([]*genai.Part, 0, len(toolCalls))
+	for _, tc := range toolCalls {
+		var args map[string]interface{}
+		if tc.args.Len() > 0 {
+			_ = json.Unmarshal([]byte(tc.args.String()), &args)
+		}
+		parts = append(parts, &genai.Part{
+			FunctionCall: &genai.FunctionCall{
+				ID:   tc.id,
+				Name: tc.name,
+				Args: args,
+			},
+		})
 	}
+	return parts
+}
 
-	return nil
+// newGoogleStreamChunk builds a single-candidate streaming chunk
+func newGoogleStreamChunk(responseModel string, parts []*genai.Part, finishReason genai.FinishReason) *genai.GenerateContentResponse {
+	return &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{
+			{
+				Content: &genai.Content{
+					Role:  "model",
+					Parts: parts,
+				},
+				FinishReason: finishReason,
+				Index:        0,
+			},
+		},
+		ModelVersion: responseModel,
+	}
+}
+
+// setGoogleStreamHeaders sets the SSE headers and returns the flusher of the connection
+func setGoogleStreamHeaders(c *gin.Context) (http.Flusher, bool) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("Access-Control-Allow-Origin", "*")
+
+	flusher, ok := c.Writer.(http.Flusher)
+	return flusher, ok
 }
 
 // sendGoogleStreamChunk sends a GenerateContentResponse as a JSON chunk
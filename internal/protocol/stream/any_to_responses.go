@@ -0,0 +1,434 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	anthropicstream "github.com/anthropics/anthropic-sdk-go/packages/ssestream"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genai"
+
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/protocol/nonstream"
+)
+
+// HandleAnthropicToResponsesStream processes Anthropic streaming events and converts them to
+// OpenAI Responses API events. Text, thinking and tool_use blocks become message, reasoning
+// and function_call output items; the returned result holds the completed response.
+func HandleAnthropicToResponsesStream(c *gin.Context, stream *anthropicstream.Stream[anthropic.MessageStreamEventUnion], responseModel string) (*protocol.ResponsesResult, error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.Errorf("Panic in Anthropic to Responses streaming handler: %v", r)
+		}
+		if stream != nil {
+			if err := stream.Close(); err != nil {
+				logrus.Errorf("Error closing Anthropic stream: %v", err)
+			}
+		}
+	}()
+
+	w, err := newResponsesStreamWriter(c, responseModel)
+	if err != nil {
+		return w.result, err
+	}
+	w.start()
+
+	for stream.Next() {
+		event := stream.Current()
+
+		switch event.Type {
+		case eventTypeMessageStart:
+			w.result.Usage.Merge(protocol.NewUsageStatFromAnthropic(event.Message.Usage))
+
+		case eventTypeContentBlockStart:
+			switch event.ContentBlock.Type {
+			case blockTypeThinking:
+				w.openReasoning()
+			case blockTypeRedactedThinking:
+				// Redacted thinking has no deltas, its data is replayed as is
+				w.reasoningSignature(protocol.EncodeResponsesReasoning(protocol.ResponsesReasoningAnthropicRedacted, event.ContentBlock.Data))
+			case blockTypeToolUse:
+				w.openFunctionCall(event.ContentBlock.ID, event.ContentBlock.Name)
+			}
+
+		case eventTypeContentBlockDelta:
+			switch event.Delta.Type {
+			case deltaTypeTextDelta:
+				w.textDelta(event.Delta.Text)
+			case deltaTypeThinkingDelta:
+				w.reasoningDelta(event.Delta.Thinking)
+			case "signature_delta":
+				w.reasoningSignature(protocol.EncodeResponsesReasoning(protocol.ResponsesReasoningAnthropic, event.Delta.Signature))
+			case deltaTypeInputJSONDelta:
+				w.argumentsDelta(event.Delta.PartialJSON)
+			}
+
+		case eventTypeContentBlockStop:
+			w.closeItem()
+
+		case eventTypeMessageDelta:
+			if string(event.Delta.StopReason) == anthropicStopReasonMaxTokens {
+				w.result.IncompleteReason = protocol.ResponsesIncompleteMaxOutputTokens
+			}
+			w.result.Usage.Merge(protocol.NewUsageStatFromAnthropicDelta(event.Usage))
+
+		case eventTypeMessageStop:
+			w.complete()
+			return w.result, nil
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		if errors.Is(err, context.Canceled) || protocol.IsContextCanceled(err) {
+			logrus.Debug("Anthropic to Responses stream canceled by client")
+			return w.result, nil
+		}
+		logrus.Errorf("Anthropic stream error: %v", err)
+		w.fail(err)
+		return w.result, err
+	}
+
+	w.complete()
+	return w.result, nil
+}
+
+// HandleGoogleToResponsesStream processes Google streaming responses and converts them to
+// OpenAI Responses API events. Thought parts become a reasoning item; function calls are
+// sent as complete function_call items since Gemini does not stream their arguments.
+func HandleGoogleToResponsesStream(c *gin.Context, stream iter.Seq2[*genai.GenerateContentResponse, error], responseModel string) (*protocol.ResponsesResult, error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.Errorf("Panic in Google to Responses streaming handler: %v", r)
+		}
+	}()
+
+	w, err := newResponsesStreamWriter(c, responseModel)
+	if err != nil {
+		return w.result, err
+	}
+	w.start()
+
+	for googleResp, err := range stream {
+		if err != nil {
+			if errors.Is(err, context.Canceled) || protocol.IsContextCanceled(err) {
+				logrus.Debug("Google to Responses stream canceled by client")
+				return w.result, nil
+			}
+			logrus.Errorf("Google stream error: %v", err)
+			w.fail(err)
+			return w.result, err
+		}
+		if googleResp == nil {
+			continue
+		}
+
+		// Usage metadata is cumulative, the last chunk carries the totals
+		w.result.Usage.Merge(protocol.NewUsageStatFromGoogle(googleResp.UsageMetadata))
+
+		if len(googleResp.Candidates) == 0 {
+			continue
+		}
+		candidate := googleResp.Candidates[0]
+
+		if candidate.Content != nil {
+			for _, part := range candidate.Content.Parts {
+				if part.Thought {
+					w.reasoningDelta(part.Text)
+				}
+				// The signature belongs to the reasoning that precedes this part
+				if len(part.ThoughtSignature) > 0 {
+					w.reasoningSignature(nonstream.EncodeGoogleThoughtSignature(part.ThoughtSignature))
+				}
+				if part.Thought {
+					continue
+				}
+
+				switch {
+				case part.FunctionCall != nil:
+					item := nonstream.NewResponsesFunctionCallFromGoogle(part.FunctionCall)
+					w.openFunctionCall(item["call_id"].(string), item["name"].(string))
+					w.argumentsDelta(item["arguments"].(string))
+					w.closeItem()
+				case part.Text != "":
+					w.textDelta(part.Text)
+				}
+			}
+		}
+
+		if candidate.FinishReason == genai.FinishReasonMaxTokens {
+			w.result.IncompleteReason = protocol.ResponsesIncompleteMaxOutputTokens
+		}
+	}
+
+	w.complete()
+	return w.result, nil
+}
+
+// responsesStreamWriter emits the Responses API event sequence for a response converted
+// from another provider style. Only one output item is open at a time; it is appended to
+// the result once done.
+type responsesStreamWriter struct {
+	c       *gin.Context
+	flusher http.Flusher
+	result  *protocol.ResponsesResult
+	seq     int
+
+	// The open output item
+	item           map[string]interface{}
+	itemType       string
+	itemText       strings.Builder // message text, reasoning summary or function arguments
+	itemSignature  string
+	summaryStarted bool
+}
+
+func newResponsesStreamWriter(c *gin.Context, responseModel string) (*responsesStreamWriter, error) {
+	w := &responsesStreamWriter{c: c, result: protocol.NewResponsesResult(responseModel)}
+
+	// Set SSE headers for Responses API
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("Access-Control-Allow-Origin", "*")
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		return w, errors.New("streaming not supported by this connection")
+	}
+	w.flusher = flusher
+	return w, nil
+}
+
+// send writes an event with its type and sequence number
+func (w *responsesStreamWriter) send(eventType string, event map[string]interface{}) {
+	event["type"] = eventType
+	event["sequence_number"] = w.seq
+	w.seq++
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		logrus.Errorf("Failed to marshal Responses stream event: %v", err)
+		return
+	}
+	w.c.Writer.WriteString(fmt.Sprintf("event: %s\ndata: %s\n\n", eventType, eventJSON))
+	w.flusher.Flush()
+}
+
+func (w *responsesStreamWriter) start() {
+	w.send("response.created", map[string]interface{}{"response": w.result.Response(protocol.ResponsesStatusInProgress)})
+	w.send("response.in_progress", map[string]interface{}{"response": w.result.Response(protocol.ResponsesStatusInProgress)})
+}
+
+// outputIndex is the index of the open item, which is appended to the output when done
+func (w *responsesStreamWriter) outputIndex() int {
+	return len(w.result.Output)
+}
+
+func (w *responsesStreamWriter) itemRef() map[string]interface{} {
+	return map[string]interface{}{
+		"item_id":      w.item["id"],
+		"output_index": w.outputIndex(),
+	}
+}
+
+func (w *responsesStreamWriter) openItem(itemType string, item map[string]interface{}) {
+	w.closeItem()
+
+	w.item = item
+	w.itemType = itemType
+	w.itemText.Reset()
+	w.itemSignature = ""
+	w.summaryStarted = false
+
+	w.send("response.output_item.added", map[string]interface{}{
+		"output_index": w.outputIndex(),
+		"item":         item,
+	})
+
+	if itemType == protocol.ResponsesItemTypeMessage {
+		event := w.itemRef()
+		event["content_index"] = 0
+		event["part"] = protocol.NewResponsesOutputText("")
+		w.send("response.content_part.added", event)
+	}
+}
+
+func (w *responsesStreamWriter) openMessage() {
+	w.openItem(protocol.ResponsesItemTypeMessage, map[string]interface{}{
+		"id":      protocol.NewResponsesID("msg"),
+		"type":    protocol.ResponsesItemTypeMessage,
+		"status":  protocol.ResponsesStatusInProgress,
+		"role":    "assistant",
+		"content": []interface{}{},
+	})
+}
+
+func (w *responsesStreamWriter) openReasoning() {
+	w.openItem(protocol.ResponsesItemTypeReasoning, map[string]interface{}{
+		"id":      protocol.NewResponsesID("rs"),
+		"type":    protocol.ResponsesItemTypeReasoning,
+		"summary": []interface{}{},
+	})
+}
+
+func (w *responsesStreamWriter) openFunctionCall(callID, name string) {
+	w.openItem(protocol.ResponsesItemTypeFunctionCall, map[string]interface{}{
+		"id":        protocol.NewResponsesID("fc"),
+		"type":      protocol.ResponsesItemTypeFunctionCall,
+		"status":    protocol.ResponsesStatusInProgress,
+		"call_id":   callID,
+		"name":      name,
+		"arguments": "",
+	})
+}
+
+func (w *responsesStreamWriter) textDelta(text string) {
+	if text == "" {
+		return
+	}
+	if w.itemType != protocol.ResponsesItemTypeMessage {
+		w.openMessage()
+	}
+	w.itemText.WriteString(text)
+
+	event := w.itemRef()
+	event["content_index"] = 0
+	event["delta"] = text
+	event["logprobs"] = []interface{}{}
+	w.send("response.output_text.delta", event)
+}
+
+func (w *responsesStreamWriter) reasoningDelta(text string) {
+	if text == "" {
+		return
+	}
+	if w.itemType != protocol.ResponsesItemTypeReasoning {
+		w.openReasoning()
+	}
+	if !w.summaryStarted {
+		w.summaryStarted = true
+		event := w.itemRef()
+		event["summary_index"] = 0
+		event["part"] = protocol.NewResponsesSummaryText("")
+		w.send("response.reasoning_summary_part.added", event)
+	}
+	w.itemText.WriteString(text)
+
+	event := w.itemRef()
+	event["summary_index"] = 0
+	event["delta"] = text
+	w.send("response.reasoning_summary_text.delta", event)
+}
+
+// reasoningSignature records the thinking signature on the open reasoning item,
+// or emits a reasoning item of its own when none is open
+func (w *responsesStreamWriter) reasoningSignature(signature string) {
+	if signature == "" {
+		return
+	}
+	if w.itemType == protocol.ResponsesItemTypeReasoning {
+		w.itemSignature = signature
+		return
+	}
+	w.openReasoning()
+	w.itemSignature = signature
+	w.closeItem()
+}
+
+func (w *responsesStreamWriter) argumentsDelta(delta string) {
+	if delta == "" || w.itemType != protocol.ResponsesItemTypeFunctionCall {
+		return
+	}
+	w.itemText.WriteString(delta)
+
+	event := w.itemRef()
+	event["delta"] = delta
+	w.send("response.function_call_arguments.delta", event)
+}
+
+// closeItem sends the done events of the open item and appends it to the output
+func (w *responsesStreamWriter) closeItem() {
+	if w.item == nil {
+		return
+	}
+
+	id, _ := w.item["id"].(string)
+	text := w.itemText.String()
+
+	var done map[string]interface{}
+	switch w.itemType {
+	case protocol.ResponsesItemTypeMessage:
+		event := w.itemRef()
+		event["content_index"] = 0
+		event["text"] = text
+		event["logprobs"] = []interface{}{}
+		w.send("response.output_text.done", event)
+
+		event = w.itemRef()
+		event["content_index"] = 0
+		event["part"] = protocol.NewResponsesOutputText(text)
+		w.send("response.content_part.done", event)
+
+		done = protocol.NewResponsesMessageItem(id, text)
+
+	case protocol.ResponsesItemTypeReasoning:
+		if w.summaryStarted {
+			event := w.itemRef()
+			event["summary_index"] = 0
+			event["text"] = text
+			w.send("response.reasoning_summary_text.done", event)
+
+			event = w.itemRef()
+			event["summary_index"] = 0
+			event["part"] = protocol.NewResponsesSummaryText(text)
+			w.send("response.reasoning_summary_part.done", event)
+		}
+
+		done = protocol.NewResponsesReasoningItem(id, text, w.itemSignature)
+
+	case protocol.ResponsesItemTypeFunctionCall:
+		callID, _ := w.item["call_id"].(string)
+		name, _ := w.item["name"].(string)
+		done = protocol.NewResponsesFunctionCallItem(id, callID, name, text)
+
+		event := w.itemRef()
+		event["arguments"] = done["arguments"]
+		w.send("response.function_call_arguments.done", event)
+	}
+
+	w.send("response.output_item.done", map[string]interface{}{
+		"output_index": w.outputIndex(),
+		"item":         done,
+	})
+	w.result.Output = append(w.result.Output, done)
+	w.item = nil
+	w.itemType = ""
+}
+
+// complete closes the open item and sends response.completed (or response.incomplete)
+func (w *responsesStreamWriter) complete() {
+	w.closeItem()
+
+	status := w.result.Status()
+	eventType := "response.completed"
+	if status == protocol.ResponsesStatusIncomplete {
+		eventType = "response.incomplete"
+	}
+	w.send(eventType, map[string]interface{}{"response": w.result.Response(status)})
+}
+
+// fail sends response.failed with the upstream error
+func (w *responsesStreamWriter) fail(err error) {
+	resp := w.result.Response(protocol.ResponsesStatusFailed)
+	resp["error"] = map[string]interface{}{
+		"code":    "server_error",
+		"message": err.Error(),
+	}
+	w.send("response.failed", map[string]interface{}{"response": resp})
+}
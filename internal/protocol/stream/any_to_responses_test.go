@@ -0,0 +1,94 @@
+package stream
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+)
+
+// responsesEventTypes returns the type of each event of a Responses API stream
+func responsesEventTypes(t *testing.T, body string) []string {
+	var types []string
+	for _, line := range strings.Split(body, "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(data), &event))
+		types = append(types, event["type"].(string))
+	}
+	return types
+}
+
+func TestHandleGoogleToResponsesStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	stream := func(yield func(*genai.GenerateContentResponse, error) bool) {
+		chunks := [][]*genai.Part{
+			{{Text: "Thinking", Thought: true}},
+			{{Text: "Hello", ThoughtSignature: []byte("sig")}},
+			{genai.NewPartFromText(" world")},
+			{{FunctionCall: &genai.FunctionCall{Name: "shell", Args: map[string]any{"cmd": "ls"}}}},
+		}
+		for _, parts := range chunks {
+			if !yield(&genai.GenerateContentResponse{
+				Candidates: []*genai.Candidate{{Content: &genai.Content{Role: "model", Parts: parts}}},
+			}, nil) {
+				return
+			}
+		}
+		yield(&genai.GenerateContentResponse{
+			Candidates: []*genai.Candidate{{FinishReason: genai.FinishReasonStop}},
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+				PromptTokenCount:     10,
+				CandidatesTokenCount: 5,
+				TotalTokenCount:      15,
+			},
+		}, nil)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/v1/responses", nil)
+
+	result, err := HandleGoogleToResponsesStream(c, stream, "my-model")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"response.created",
+		"response.in_progress",
+		"response.output_item.added",
+		"response.reasoning_summary_part.added",
+		"response.reasoning_summary_text.delta",
+		"response.reasoning_summary_text.done",
+		"response.reasoning_summary_part.done",
+		"response.output_item.done",
+		"response.output_item.added",
+		"response.content_part.added",
+		"response.output_text.delta",
+		"response.output_text.delta",
+		"response.output_text.done",
+		"response.content_part.done",
+		"response.output_item.done",
+		"response.output_item.added",
+		"response.function_call_arguments.delta",
+		"response.function_call_arguments.done",
+		"response.output_item.done",
+		"response.completed",
+	}, responsesEventTypes(t, w.Body.String()))
+
+	require.Len(t, result.Output, 3)
+	assert.Equal(t, "google:c2ln", result.Output[0]["encrypted_content"])
+	assert.Equal(t, "Hello world", result.Output[1]["content"].([]map[string]interface{})[0]["text"])
+	assert.Equal(t, protocol.ResponsesItemTypeFunctionCall, result.Output[2]["type"])
+	assert.Equal(t, 10, result.Usage.InputTokens)
+}
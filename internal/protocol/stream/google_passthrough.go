@@ -0,0 +1,41 @@
+package stream
+
+import (
+	"errors"
+	"iter"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genai"
+
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+)
+
+// HandleGooglePassthroughStreamResponse relays a Google stream to the client (alt=sse),
+// only replacing the model version with the response model
+func HandleGooglePassthroughStreamResponse(c *gin.Context, stream iter.Seq2[*genai.GenerateContentResponse, error], responseModel string) (protocol.UsageStat, error) {
+	var usage protocol.UsageStat
+
+	flusher, ok := setGoogleStreamHeaders(c)
+	if !ok {
+		return usage, errors.New("Streaming not supported by this connection")
+	}
+
+	for resp, err := range stream {
+		if err != nil {
+			logrus.Errorf("Google stream error: %v", err)
+			return usage, err
+		}
+		if resp == nil {
+			continue
+		}
+
+		// Usage metadata is cumulative, the last chunk carries the totals
+		usage.Merge(protocol.NewUsageStatFromGoogle(resp.UsageMetadata))
+
+		resp.ModelVersion = responseModel
+		sendGoogleStreamChunk(c, resp, flusher)
+	}
+
+	return usage, nil
+}
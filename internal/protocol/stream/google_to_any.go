@@ -237,8 +237,8 @@ func HandleGoogleToAnthropicStreamResponse(c *gin.Context, stream iter.Seq2[*gen
 	var (
 		textBlockIndex = -1
 		toolBlockIndex = -1
-		inputTokens    int64
 		outputTokens   int64
+		usage          protocol.UsageStat // Usage reported for tracking (includes thoughts)
 	)
 
 	// Send message_start event first
@@ -266,7 +266,7 @@ func HandleGoogleToAnthropicStreamResponse(c *gin.Context, stream iter.Seq2[*gen
 		select {
 		case <-c.Request.Context().Done():
 			logrus.Debug("Client disconnected, stopping Google to Anthropic stream")
-			return protocol.NewUsageStat(int(inputTokens), int(outputTokens)), nil
+			return usage, nil
 		default:
 		}
 
@@ -274,7 +274,7 @@ func HandleGoogleToAnthropicStreamResponse(c *gin.Context, stream iter.Seq2[*gen
 			// Check if it was a client cancellation
 			if errors.Is(err, context.Canceled) {
 				logrus.Debug("Google stream canceled by client")
-				return protocol.NewUsageStat(int(inputTokens), int(outputTokens)), nil
+				return usage, nil
 			}
 			logrus.Errorf("Google stream error: %v", err)
 			errorEvent := map[string]interface{}{
@@ -286,7 +286,7 @@ func HandleGoogleToAnthropicStreamResponse(c *gin.Context, stream iter.Seq2[*gen
 				},
 			}
 			sendAnthropicStreamEventFromG(c, "error", errorEvent, flusher)
-			return protocol.NewUsageStat(int(inputTokens), int(outputTokens)), err
+			return usage, err
 		}
 
 		// Process candidates
@@ -365,8 +365,8 @@ func HandleGoogleToAnthropicStreamResponse(c *gin.Context, stream iter.Seq2[*gen
 
 				// Collect usage info
 				if googleResp.UsageMetadata != nil {
-					inputTokens = int64(googleResp.UsageMetadata.PromptTokenCount)
 					outputTokens = int64(googleResp.UsageMetadata.CandidatesTokenCount)
+					usage = protocol.NewUsageStatFromGoogle(googleResp.UsageMetadata)
 				}
 
 				// Send message_delta with stop reason and usage
@@ -387,18 +387,18 @@ func HandleGoogleToAnthropicStreamResponse(c *gin.Context, stream iter.Seq2[*gen
 					"type": "message_stop",
 				}
 				sendAnthropicStreamEventFromG(c, "message_stop", messageStopEvent, flusher)
-				return protocol.NewUsageStat(int(inputTokens), int(outputTokens)), nil
+				return usage, nil
 			}
 		}
 
 		// Track usage
 		if googleResp.UsageMetadata != nil {
-			inputTokens = int64(googleResp.UsageMetadata.PromptTokenCount)
 			outputTokens = int64(googleResp.UsageMetadata.CandidatesTokenCount)
+			usage = protocol.NewUsageStatFromGoogle(googleResp.UsageMetadata)
 		}
 	}
 
-	return protocol.NewUsageStat(int(inputTokens), int(outputTokens)), nil
+	return usage, nil
 }
 
 // sendAnthropicStreamEventFromG helper function (rename to avoid duplicate)
@@ -450,8 +450,8 @@ func HandleGoogleToAnthropicBetaStreamResponse(c *gin.Context, stream iter.Seq2[
 	var (
 		textBlockIndex = -1
 		toolBlockIndex = -1
-		inputTokens    int64
 		outputTokens   int64
+		usage          protocol.UsageStat // Usage reported for tracking (includes thoughts)
 	)
 
 	// Send message_start event first
@@ -479,7 +479,7 @@ func HandleGoogleToAnthropicBetaStreamResponse(c *gin.Context, stream iter.Seq2[
 		select {
 		case <-c.Request.Context().Done():
 			logrus.Debug("Client disconnected, stopping Google to Anthropic beta stream")
-			return protocol.NewUsageStat(int(inputTokens), int(outputTokens)), nil
+			return usage, nil
 		default:
 		}
 
@@ -487,7 +487,7 @@ func HandleGoogleToAnthropicBetaStreamResponse(c *gin.Context, stream iter.Seq2[
 			// Check if it was a client cancellation
 			if errors.Is(err, context.Canceled) {
 				logrus.Debug("Google stream canceled by client")
-				return protocol.NewUsageStat(int(inputTokens), int(outputTokens)), nil
+				return usage, nil
 			}
 			logrus.Errorf("Google stream error: %v", err)
 			errorEvent := map[string]interface{}{
@@ -499,7 +499,7 @@ func HandleGoogleToAnthropicBetaStreamResponse(c *gin.Context, stream iter.Seq2[
 				},
 			}
 			sendAnthropicBetaStreamEventFromG(c, "error", errorEvent, flusher)
-			return protocol.NewUsageStat(int(inputTokens), int(outputTokens)), err
+			return usage, err
 		}
 
 		// Process candidates
@@ -578,8 +578,8 @@ func HandleGoogleToAnthropicBetaStreamResponse(c *gin.Context, stream iter.Seq2[
 
 				// Collect usage info
 				if googleResp.UsageMetadata != nil {
-					inputTokens = int64(googleResp.UsageMetadata.PromptTokenCount)
 					outputTokens = int64(googleResp.UsageMetadata.CandidatesTokenCount)
+					usage = protocol.NewUsageStatFromGoogle(googleResp.UsageMetadata)
 				}
 
 				// Send message_delta with stop reason and usage
@@ -616,18 +616,18 @@ func HandleGoogleToAnthropicBetaStreamResponse(c *gin.Context, stream iter.Seq2[
 				// Send final simple data with type (without event, aka empty)
 				c.SSEvent("", map[string]interface{}{"type": eventTypeMessageStop})
 				flusher.Flush()
-				return protocol.NewUsageStat(int(inputTokens), int(outputTokens)), nil
+				return usage, nil
 			}
 		}
 
 		// Track usage
 		if googleResp.UsageMetadata != nil {
-			inputTokens = int64(googleResp.UsageMetadata.PromptTokenCount)
 			outputTokens = int64(googleResp.UsageMetadata.CandidatesTokenCount)
+			usage = protocol.NewUsageStatFromGoogle(googleResp.UsageMetadata)
 		}
 	}
 
-	return protocol.NewUsageStat(int(inputTokens), int(outputTokens)), nil
+	return usage, nil
 }
 
 // sendAnthropicBetaStreamEventFromG helper function for beta streaming
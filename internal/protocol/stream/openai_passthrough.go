@@ -37,6 +37,7 @@ func HandleOpenAIChatStream(hc *protocol.HandleContext, stream *openaistream.Str
 	c.Header("Connection", "keep-alive")
 
 	var inputTokens, outputTokens int
+	var cacheReadTokens, reasoningTokens int
 	var hasUsage bool
 	var contentBuilder strings.Builder
 	var firstChunkID string
@@ -78,6 +79,12 @@ func HandleOpenAIChatStream(hc *protocol.HandleContext, stream *openaistream.Str
 				outputTokens = int(chunk.Usage.CompletionTokens)
 				hasUsage = true
 			}
+			if chunk.Usage.PromptTokensDetails.CachedTokens != 0 {
+				cacheReadTokens = int(chunk.Usage.PromptTokensDetails.CachedTokens)
+			}
+			if chunk.Usage.CompletionTokensDetails.ReasoningTokens != 0 {
+				reasoningTokens = int(chunk.Usage.CompletionTokensDetails.ReasoningTokens)
+			}
 
 			// Check if we have choices and they're not empty
 			if len(chunk.Choices) == 0 {
@@ -195,7 +202,7 @@ func HandleOpenAIChatStream(hc *protocol.HandleContext, stream *openaistream.Str
 		errorJSON, _ := json.Marshal(errorChunk)
 		c.SSEvent("", string(errorJSON))
 		flusher.Flush()
-		return protocol.NewUsageStat(inputTokens, outputTokens), err
+		return chatStreamUsage(inputTokens, outputTokens, cacheReadTokens, reasoningTokens), err
 	}
 
 	if !hasUsage {
@@ -240,7 +247,7 @@ func HandleOpenAIChatStream(hc *protocol.HandleContext, stream *openaistream.Str
 	c.SSEvent("", " [DONE]")
 	flusher.Flush()
 
-	return protocol.NewUsageStat(inputTokens, outputTokens), nil
+	return chatStreamUsage(inputTokens, outputTokens, cacheReadTokens, reasoningTokens), nil
 }
 
 // HandleOpenAIResponsesStream handles OpenAI Responses API streaming response.
@@ -256,7 +263,7 @@ func HandleOpenAIResponsesStream(hc *protocol.HandleContext, stream *openaistrea
 	c.Header("Access-Control-Allow-Origin", "*")
 	c.Header("Access-Control-Allow-Headers", "Cache-Control")
 
-	var inputTokens, outputTokens int64
+	var usage protocol.UsageStat
 	var hasUsage bool
 
 	// Panic recovery
@@ -308,13 +315,19 @@ func HandleOpenAIResponsesStream(hc *protocol.HandleContext, stream *openaistrea
 
 		evt := stream.Current()
 
-		// Accumulate usage from completed events
-		if evt.Response.Usage.InputTokens > 0 {
-			inputTokens = evt.Response.Usage.InputTokens
-			hasUsage = true
+		// Call OnStreamEvent hooks first
+		for _, hook := range hc.OnStreamEventHooks {
+			if err := hook(evt); err != nil {
+				logrus.Debugf("Responses stream event hook failed: %v", err)
+			}
 		}
-		if evt.Response.Usage.OutputTokens > 0 {
-			outputTokens = evt.Response.Usage.OutputTokens
+
+		// Accumulate usage from completed events
+		if evt.Response.Usage.InputTokens > 0 || evt.Response.Usage.OutputTokens > 0 {
+			usage.Merge(protocol.NewUsageStatFromOpenAIResponses(evt.Response.Usage))
+			if evt.Response.Usage.InputTokens > 0 {
+				hasUsage = true
+			}
 		}
 
 		// Marshal event using RawJSON() to avoid serializing empty union fields
@@ -349,14 +362,14 @@ func HandleOpenAIResponsesStream(hc *protocol.HandleContext, stream *openaistrea
 		if errors.Is(err, context.Canceled) || protocol.IsContextCanceled(err) {
 			logrus.Debug("Responses stream canceled by client")
 			if hasUsage {
-				return protocol.NewUsageStat(int(inputTokens), int(outputTokens)), nil
+				return usage, nil
 			}
 			return protocol.ZeroUsageStat(), nil
 		}
 
 		logrus.Errorf("Responses stream error: %v", err)
 		if hasUsage {
-			return protocol.NewUsageStat(int(inputTokens), int(outputTokens)), err
+			return usage, err
 		}
 
 		// Send error chunk
@@ -371,7 +384,7 @@ func HandleOpenAIResponsesStream(hc *protocol.HandleContext, stream *openaistrea
 		errorJSON, _ := json.Marshal(errorChunk)
 		c.Writer.WriteString(fmt.Sprintf("data: %s\n\n", string(errorJSON)))
 		flusher.Flush()
-		return protocol.NewUsageStat(int(inputTokens), int(outputTokens)), err
+		return usage, err
 	}
 
 	// Send final [DONE] message
@@ -380,7 +393,7 @@ func HandleOpenAIResponsesStream(hc *protocol.HandleContext, stream *openaistrea
 
 	// Track successful streaming completion
 	if hasUsage {
-		return protocol.NewUsageStat(int(inputTokens), int(outputTokens)), nil
+		return usage, nil
 	}
 
 	return protocol.ZeroUsageStat(), nil
@@ -390,6 +403,15 @@ func HandleOpenAIResponsesStream(hc *protocol.HandleContext, stream *openaistrea
 // Helper Functions
 // ===================================================================
 
+// chatStreamUsage builds the usage of a chat completion stream from the counts
+// reported by the upstream (or estimated when it reported none)
+func chatStreamUsage(inputTokens, outputTokens, cacheReadTokens, reasoningTokens int) protocol.UsageStat {
+	usage := protocol.NewUsageStat(inputTokens, outputTokens)
+	usage.CacheReadTokens = cacheReadTokens
+	usage.ReasoningTokens = reasoningTokens
+	return usage
+}
+
 // Note: The following functions are already defined in other files:
 // - IsContextCanceled is in streaming.go
 // - MarshalAndSendErrorEvent is in anthropic_error.go
@@ -119,6 +119,7 @@ func HandleOpenAIToAnthropicStreamResponse(c *gin.Context, req *openai.ChatCompl
 			// Token counter will handle usage tracking if present in chunk
 			if tokenCounter != nil {
 				_, _, _ = tokenCounter.ConsumeOpenAIChunk(&chunk)
+				state.recordOpenAIUsageDetails(&chunk)
 				inputTokens, outputTokens := tokenCounter.GetCounts()
 				if inputTokens > 0 {
 					state.inputTokens = int64(inputTokens)
@@ -284,6 +285,7 @@ func HandleOpenAIToAnthropicStreamResponse(c *gin.Context, req *openai.ChatCompl
 		// Track usage from chunk using token counter
 		if tokenCounter != nil {
 			_, _, _ = tokenCounter.ConsumeOpenAIChunk(&chunk)
+			state.recordOpenAIUsageDetails(&chunk)
 			inputTokens, outputTokens := tokenCounter.GetCounts()
 			if inputTokens > 0 {
 				state.inputTokens = int64(inputTokens)
@@ -316,7 +318,7 @@ func HandleOpenAIToAnthropicStreamResponse(c *gin.Context, req *openai.ChatCompl
 		// Check if it was a client cancellation
 		if errors.Is(err, context.Canceled) {
 			logrus.Debug("OpenAI to Anthropic stream canceled by client")
-			return protocol.NewUsageStat(int(state.inputTokens), int(state.outputTokens)), nil
+			return state.usageStat(), nil
 		}
 		logrus.Errorf("OpenAI stream error: %v", err)
 		errorEvent := map[string]interface{}{
@@ -328,9 +330,9 @@ func HandleOpenAIToAnthropicStreamResponse(c *gin.Context, req *openai.ChatCompl
 			},
 		}
 		sendAnthropicStreamEvent(c, "error", errorEvent, flusher)
-		return protocol.NewUsageStat(int(state.inputTokens), int(state.outputTokens)), err
+		return state.usageStat(), err
 	}
-	return protocol.NewUsageStat(int(state.inputTokens), int(state.outputTokens)), nil
+	return state.usageStat(), nil
 }
 
 // HandleResponsesToAnthropicV1Stream processes OpenAI Responses API streaming events and converts them to Anthropic v1 format.
@@ -640,6 +642,8 @@ func handleResponsesToAnthropicV1Stream(c *gin.Context, stream *openaistream.Str
 			completed := currentEvent.AsResponseCompleted()
 			state.inputTokens = int64(completed.Response.Usage.InputTokens)
 			state.outputTokens = int64(completed.Response.Usage.OutputTokens)
+			state.cacheReadTokens = int64(completed.Response.Usage.InputTokensDetails.CachedTokens)
+			state.reasoningTokens = int64(completed.Response.Usage.OutputTokensDetails.ReasoningTokens)
 
 			logrus.Debugf("[ResponsesAPI] Response completed: input_tokens=%d, output_tokens=%d", state.inputTokens, state.outputTokens)
 
@@ -712,7 +716,7 @@ func handleResponsesToAnthropicV1Stream(c *gin.Context, stream *openaistream.Str
 			senders.SendMessageStop(messageID, responseModel, state, stopReason, flusher)
 
 			logrus.Debugf("[ResponsesAPI] Sent message_stop event with stop_reason=%s, finishing stream", stopReason)
-			return protocol.NewUsageStat(int(state.inputTokens), int(state.outputTokens)), nil
+			return state.usageStat(), nil
 
 		case "error", "response.failed", "response.incomplete":
 			logrus.Errorf("Responses API error event: %v", currentEvent)
@@ -724,7 +728,7 @@ func handleResponsesToAnthropicV1Stream(c *gin.Context, stream *openaistream.Str
 				},
 			}
 			senders.SendErrorEvent(errorEvent, flusher)
-			return protocol.NewUsageStat(int(state.inputTokens), int(state.outputTokens)), fmt.Errorf("Responses API error: %v", currentEvent)
+			return state.usageStat(), fmt.Errorf("Responses API error: %v", currentEvent)
 
 		default:
 			logrus.Debugf("Unhandled Responses API event type: %s", currentEvent.Type)
@@ -742,10 +746,10 @@ func handleResponsesToAnthropicV1Stream(c *gin.Context, stream *openaistream.Str
 			},
 		}
 		senders.SendErrorEvent(errorEvent, flusher)
-		return protocol.NewUsageStat(int(state.inputTokens), int(state.outputTokens)), err
+		return state.usageStat(), err
 	}
 
-	return protocol.NewUsageStat(int(state.inputTokens), int(state.outputTokens)), nil
+	return state.usageStat(), nil
 }
 
 // mapOpenAIFinishReasonToAnthropic converts OpenAI finish_reason to Anthropic stop_reason
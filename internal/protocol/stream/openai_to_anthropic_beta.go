@@ -118,6 +118,7 @@ func HandleOpenAIToAnthropicBetaStream(c *gin.Context, req *openai.ChatCompletio
 			// Token counter will handle usage tracking if present in chunk
 			if tokenCounter != nil {
 				_, _, _ = tokenCounter.ConsumeOpenAIChunk(&chunk)
+				state.recordOpenAIUsageDetails(&chunk)
 				inputTokens, outputTokens := tokenCounter.GetCounts()
 				if inputTokens > 0 {
 					state.inputTokens = int64(inputTokens)
@@ -281,6 +282,7 @@ func HandleOpenAIToAnthropicBetaStream(c *gin.Context, req *openai.ChatCompletio
 		// Track usage from chunk using token counter
 		if tokenCounter != nil {
 			_, _, _ = tokenCounter.ConsumeOpenAIChunk(&chunk)
+			state.recordOpenAIUsageDetails(&chunk)
 			inputTokens, outputTokens := tokenCounter.GetCounts()
 			if inputTokens > 0 {
 				state.inputTokens = int64(inputTokens)
@@ -313,7 +315,7 @@ func HandleOpenAIToAnthropicBetaStream(c *gin.Context, req *openai.ChatCompletio
 		// Check if it was a client cancellation
 		if errors.Is(err, context.Canceled) {
 			logrus.Debug("OpenAI to Anthropic beta stream canceled by client")
-			return protocol.NewUsageStat(int(state.inputTokens), int(state.outputTokens)), nil
+			return state.usageStat(), nil
 		}
 		logrus.Errorf("OpenAI stream error: %v", err)
 		errorEvent := map[string]interface{}{
@@ -325,9 +327,9 @@ func HandleOpenAIToAnthropicBetaStream(c *gin.Context, req *openai.ChatCompletio
 			},
 		}
 		sendAnthropicBetaStreamEvent(c, "error", errorEvent, flusher)
-		return protocol.NewUsageStat(int(state.inputTokens), int(state.outputTokens)), err
+		return state.usageStat(), err
 	}
-	return protocol.NewUsageStat(int(state.inputTokens), int(state.outputTokens)), nil
+	return state.usageStat(), nil
 }
 
 // HandleResponsesToAnthropicBetaStream processes OpenAI Responses API streaming events and converts them to Anthropic beta format.
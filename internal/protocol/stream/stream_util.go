@@ -1,5 +1,11 @@
 package stream
 
+import (
+	"github.com/openai/openai-go/v3"
+
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+)
+
 // StreamEventRecorder is an interface for recording stream events during protocol conversion
 type StreamEventRecorder interface {
 	RecordRawMapEvent(eventType string, event map[string]interface{})
@@ -18,6 +24,8 @@ type streamState struct {
 	deltaExtras                map[string]interface{}
 	outputTokens               int64
 	inputTokens                int64
+	cacheReadTokens            int64        // Part of inputTokens served from the prompt cache
+	reasoningTokens            int64        // Part of outputTokens spent on reasoning
 	stoppedBlocks              map[int]bool // Tracks blocks that have already sent content_block_stop
 }
 
@@ -35,3 +43,24 @@ func newStreamState() *streamState {
 		stoppedBlocks:              make(map[int]bool),
 	}
 }
+
+// recordOpenAIUsageDetails captures the cache and reasoning token counts of a chunk carrying usage
+func (s *streamState) recordOpenAIUsageDetails(chunk *openai.ChatCompletionChunk) {
+	if !chunk.JSON.Usage.Valid() {
+		return
+	}
+	if cached := chunk.Usage.PromptTokensDetails.CachedTokens; cached > 0 {
+		s.cacheReadTokens = cached
+	}
+	if reasoning := chunk.Usage.CompletionTokensDetails.ReasoningTokens; reasoning > 0 {
+		s.reasoningTokens = reasoning
+	}
+}
+
+// usageStat returns the usage accumulated by the stream
+func (s *streamState) usageStat() protocol.UsageStat {
+	usage := protocol.NewUsageStat(int(s.inputTokens), int(s.outputTokens))
+	usage.CacheReadTokens = int(s.cacheReadTokens)
+	usage.ReasoningTokens = int(s.reasoningTokens)
+	return usage
+}
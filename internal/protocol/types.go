@@ -1,6 +1,10 @@
 package protocol
 
-import "github.com/anthropics/anthropic-sdk-go"
+import (
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/responses"
+)
 
 // APIStyle represents the API style/version for a provider
 type APIStyle string
@@ -31,17 +35,34 @@ type Transformer interface {
 
 	// HandleV1Beta handles compacting for Anthropic v1beta requests.
 	HandleV1Beta(req *anthropic.BetaMessageNewParams) error
+
+	// HandleOpenAIChat handles compacting for OpenAI chat completion requests.
+	HandleOpenAIChat(req *openai.ChatCompletionNewParams) error
+
+	// HandleOpenAIResponses handles compacting for OpenAI Responses API requests.
+	HandleOpenAIResponses(req *responses.ResponseNewParams) error
 }
 
 // UsageStat represents token usage statistics returned by stream handlers.
 // This is used to propagate usage information from protocol conversion
 // handlers back to the server layer for tracking.
 type UsageStat struct {
-	// InputTokens is the number of input/prompt tokens consumed
+	// InputTokens is the number of input/prompt tokens consumed,
+	// including tokens read from or written to the prompt cache
 	InputTokens int
 
-	// OutputTokens is the number of output/completion tokens consumed
+	// OutputTokens is the number of output/completion tokens consumed,
+	// including reasoning tokens
 	OutputTokens int
+
+	// CacheReadTokens is the part of InputTokens served from the prompt cache
+	CacheReadTokens int
+
+	// CacheCreationTokens is the part of InputTokens written to the prompt cache
+	CacheCreationTokens int
+
+	// ReasoningTokens is the part of OutputTokens spent on reasoning/thinking
+	ReasoningTokens int
 }
 
 // TotalTokens returns the sum of input and output tokens.
@@ -49,6 +70,15 @@ func (u *UsageStat) TotalTokens() int {
 	return u.InputTokens + u.OutputTokens
 }
 
+// UncachedInputTokens returns the input tokens that were neither read from nor written to the prompt cache.
+func (u *UsageStat) UncachedInputTokens() int {
+	uncached := u.InputTokens - u.CacheReadTokens - u.CacheCreationTokens
+	if uncached < 0 {
+		return 0
+	}
+	return uncached
+}
+
 // HasUsage returns true if either input or output tokens are non-zero.
 func (u *UsageStat) HasUsage() bool {
 	return u.InputTokens > 0 || u.OutputTokens > 0
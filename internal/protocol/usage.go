@@ -0,0 +1,124 @@
+package protocol
+
+import (
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/responses"
+	"google.golang.org/genai"
+)
+
+// Providers report prompt caching and reasoning differently. The constructors
+// below normalize them so that InputTokens always includes cached tokens and
+// OutputTokens always includes reasoning tokens:
+//   - Anthropic reports input_tokens without cache reads/creation
+//   - OpenAI includes cached tokens in prompt_tokens and reasoning in completion_tokens
+//   - Google includes cached content in the prompt count but reports thoughts separately
+
+// NewUsageStatFromAnthropic creates a UsageStat from Anthropic message usage.
+func NewUsageStatFromAnthropic(usage anthropic.Usage) UsageStat {
+	return newAnthropicUsageStat(usage.InputTokens, usage.OutputTokens, usage.CacheReadInputTokens, usage.CacheCreationInputTokens)
+}
+
+// NewUsageStatFromAnthropicBeta creates a UsageStat from Anthropic beta message usage.
+func NewUsageStatFromAnthropicBeta(usage anthropic.BetaUsage) UsageStat {
+	return newAnthropicUsageStat(usage.InputTokens, usage.OutputTokens, usage.CacheReadInputTokens, usage.CacheCreationInputTokens)
+}
+
+// NewUsageStatFromAnthropicDelta creates a UsageStat from the cumulative usage of an Anthropic message_delta event.
+func NewUsageStatFromAnthropicDelta(usage anthropic.MessageDeltaUsage) UsageStat {
+	return newAnthropicUsageStat(usage.InputTokens, usage.OutputTokens, usage.CacheReadInputTokens, usage.CacheCreationInputTokens)
+}
+
+// NewUsageStatFromAnthropicBetaDelta creates a UsageStat from the cumulative usage of an Anthropic beta message_delta event.
+func NewUsageStatFromAnthropicBetaDelta(usage anthropic.BetaMessageDeltaUsage) UsageStat {
+	return newAnthropicUsageStat(usage.InputTokens, usage.OutputTokens, usage.CacheReadInputTokens, usage.CacheCreationInputTokens)
+}
+
+func newAnthropicUsageStat(inputTokens, outputTokens, cacheReadTokens, cacheCreationTokens int64) UsageStat {
+	return UsageStat{
+		InputTokens:         int(inputTokens + cacheReadTokens + cacheCreationTokens),
+		OutputTokens:        int(outputTokens),
+		CacheReadTokens:     int(cacheReadTokens),
+		CacheCreationTokens: int(cacheCreationTokens),
+	}
+}
+
+// NewUsageStatFromOpenAIChat creates a UsageStat from OpenAI chat completion usage.
+func NewUsageStatFromOpenAIChat(usage openai.CompletionUsage) UsageStat {
+	return UsageStat{
+		InputTokens:     int(usage.PromptTokens),
+		OutputTokens:    int(usage.CompletionTokens),
+		CacheReadTokens: int(usage.PromptTokensDetails.CachedTokens),
+		ReasoningTokens: int(usage.CompletionTokensDetails.ReasoningTokens),
+	}
+}
+
+// NewUsageStatFromOpenAIResponses creates a UsageStat from OpenAI Responses API usage.
+func NewUsageStatFromOpenAIResponses(usage responses.ResponseUsage) UsageStat {
+	return UsageStat{
+		InputTokens:     int(usage.InputTokens),
+		OutputTokens:    int(usage.OutputTokens),
+		CacheReadTokens: int(usage.InputTokensDetails.CachedTokens),
+		ReasoningTokens: int(usage.OutputTokensDetails.ReasoningTokens),
+	}
+}
+
+// NewUsageStatFromGoogle creates a UsageStat from Google usage metadata.
+func NewUsageStatFromGoogle(usage *genai.GenerateContentResponseUsageMetadata) UsageStat {
+	if usage == nil {
+		return ZeroUsageStat()
+	}
+	return UsageStat{
+		InputTokens:     int(usage.PromptTokenCount),
+		OutputTokens:    int(usage.CandidatesTokenCount + usage.ThoughtsTokenCount),
+		CacheReadTokens: int(usage.CachedContentTokenCount),
+		ReasoningTokens: int(usage.ThoughtsTokenCount),
+	}
+}
+
+// ToGoogleUsageMetadata converts the usage back to Google usage metadata,
+// reporting reasoning tokens as thoughts.
+func (u *UsageStat) ToGoogleUsageMetadata() *genai.GenerateContentResponseUsageMetadata {
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:        int32(u.InputTokens),
+		CandidatesTokenCount:    int32(u.OutputTokens - u.ReasoningTokens),
+		ThoughtsTokenCount:      int32(u.ReasoningTokens),
+		CachedContentTokenCount: int32(u.CacheReadTokens),
+		TotalTokenCount:         int32(u.TotalTokens()),
+	}
+}
+
+// ToResponsesUsage converts the usage to the OpenAI Responses API usage object.
+func (u *UsageStat) ToResponsesUsage() map[string]interface{} {
+	return map[string]interface{}{
+		"input_tokens": u.InputTokens,
+		"input_tokens_details": map[string]interface{}{
+			"cached_tokens": u.CacheReadTokens,
+		},
+		"output_tokens": u.OutputTokens,
+		"output_tokens_details": map[string]interface{}{
+			"reasoning_tokens": u.ReasoningTokens,
+		},
+		"total_tokens": u.TotalTokens(),
+	}
+}
+
+// Merge overwrites the counts of u with the non-zero counts of other.
+// Streams report cumulative usage, so the latest non-zero value wins.
+func (u *UsageStat) Merge(other UsageStat) {
+	if other.InputTokens > 0 {
+		u.InputTokens = other.InputTokens
+	}
+	if other.OutputTokens > 0 {
+		u.OutputTokens = other.OutputTokens
+	}
+	if other.CacheReadTokens > 0 {
+		u.CacheReadTokens = other.CacheReadTokens
+	}
+	if other.CacheCreationTokens > 0 {
+		u.CacheCreationTokens = other.CacheCreationTokens
+	}
+	if other.ReasoningTokens > 0 {
+		u.ReasoningTokens = other.ReasoningTokens
+	}
+}
@@ -0,0 +1,53 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genai"
+)
+
+func TestNewUsageStatFromAnthropic_IncludesCacheTokens(t *testing.T) {
+	usage := NewUsageStatFromAnthropic(anthropic.Usage{
+		InputTokens:              10,
+		OutputTokens:             20,
+		CacheReadInputTokens:     100,
+		CacheCreationInputTokens: 30,
+	})
+
+	assert.Equal(t, 140, usage.InputTokens)
+	assert.Equal(t, 20, usage.OutputTokens)
+	assert.Equal(t, 100, usage.CacheReadTokens)
+	assert.Equal(t, 30, usage.CacheCreationTokens)
+	assert.Equal(t, 10, usage.UncachedInputTokens())
+	assert.Equal(t, 160, usage.TotalTokens())
+}
+
+func TestNewUsageStatFromGoogle_IncludesThoughts(t *testing.T) {
+	usage := NewUsageStatFromGoogle(&genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:        50,
+		CandidatesTokenCount:    15,
+		CachedContentTokenCount: 40,
+		ThoughtsTokenCount:      5,
+	})
+
+	assert.Equal(t, 50, usage.InputTokens)
+	assert.Equal(t, 20, usage.OutputTokens)
+	assert.Equal(t, 40, usage.CacheReadTokens)
+	assert.Equal(t, 5, usage.ReasoningTokens)
+	assert.Equal(t, 10, usage.UncachedInputTokens())
+
+	empty := NewUsageStatFromGoogle(nil)
+	assert.False(t, empty.HasUsage())
+}
+
+func TestUsageStat_Merge(t *testing.T) {
+	usage := UsageStat{}
+	usage.Merge(UsageStat{InputTokens: 100, CacheReadTokens: 80})
+	usage.Merge(UsageStat{OutputTokens: 12})
+
+	assert.Equal(t, 100, usage.InputTokens)
+	assert.Equal(t, 12, usage.OutputTokens)
+	assert.Equal(t, 80, usage.CacheReadTokens)
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of a rate limit check
+type Result struct {
+	Allowed    bool          // Whether the request may proceed
+	Limit      int           // Bucket capacity (per minute)
+	Remaining  int           // Tokens left after this check
+	RetryAfter time.Duration // Time until enough tokens are available (0 when allowed)
+	Reset      time.Duration // Time until the bucket is full again
+}
+
+// bucket is a token bucket refilled continuously so that it regains its
+// full capacity once per minute
+type bucket struct {
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.capacity/60)
+		b.last = now
+	}
+}
+
+func (b *bucket) result(allowed bool, cost float64) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     int(b.capacity),
+		Remaining: int(math.Max(0, math.Floor(b.tokens))),
+		Reset:     tokensDuration(b.capacity-b.tokens, b.capacity),
+	}
+	if !allowed {
+		res.RetryAfter = tokensDuration(cost-b.tokens, b.capacity)
+	}
+	return res
+}
+
+// tokensDuration returns how long a bucket of the given capacity needs to regain n tokens
+func tokensDuration(n, capacity float64) time.Duration {
+	if n <= 0 || capacity <= 0 {
+		return 0
+	}
+	return time.Duration(n / capacity * float64(time.Minute))
+}
+
+// Limiter holds per-key token buckets. Each key is limited to perMinute tokens
+// per minute with bursts of up to perMinute tokens.
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewLimiter creates a new limiter
+func NewLimiter() *Limiter {
+	return &Limiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes cost tokens from the bucket of key if available.
+// A perMinute of zero or less disables the limit.
+func (l *Limiter) Allow(key string, perMinute, cost int) Result {
+	if perMinute <= 0 {
+		return Result{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.getBucket(key, perMinute)
+	if b.tokens < float64(cost) {
+		return b.result(false, float64(cost))
+	}
+	b.tokens -= float64(cost)
+	return b.result(true, float64(cost))
+}
+
+// Check reports whether cost tokens are available in the bucket of key without taking them
+func (l *Limiter) Check(key string, perMinute, cost int) Result {
+	if perMinute <= 0 {
+		return Result{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.getBucket(key, perMinute)
+	return b.result(b.tokens >= float64(cost), float64(cost))
+}
+
+// Consume takes cost tokens from the bucket of key unconditionally. The bucket may
+// go into debt, which blocks the key until it has refilled. Used for costs only known
+// after the fact, such as the tokens of a response.
+func (l *Limiter) Consume(key string, perMinute, cost int) Result {
+	if perMinute <= 0 {
+		return Result{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.getBucket(key, perMinute)
+	b.tokens -= float64(cost)
+	return b.result(true, float64(cost))
+}
+
+// getBucket returns the refilled bucket of key, resizing it when the limit changed
+func (l *Limiter) getBucket(key string, perMinute int) *bucket {
+	now := l.now()
+	capacity := float64(perMinute)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{capacity: capacity, tokens: capacity, last: now}
+		l.buckets[key] = b
+		return b
+	}
+
+	if b.capacity != capacity {
+		b.capacity = capacity
+		b.tokens = math.Min(b.tokens, capacity)
+	}
+	b.refill(now)
+	return b
+}
+
+// CleanupIdle removes the buckets that have refilled to their full capacity. A full
+// bucket is equivalent to a new one, so removing it does not change any later result.
+func (l *Limiter) CleanupIdle() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= b.capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// StartCleanupTask starts a background task to periodically remove idle buckets
+func (l *Limiter) StartCleanupTask(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			l.CleanupIdle()
+		}
+	}()
+}
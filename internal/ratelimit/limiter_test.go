@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter_AllowsBurstThenRejects(t *testing.T) {
+	now := time.Now()
+	l := NewLimiter()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if res := l.Allow("key", 3, 1); !res.Allowed {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+	}
+
+	res := l.Allow("key", 3, 1)
+	if res.Allowed {
+		t.Fatal("Expected request over the limit to be rejected")
+	}
+	if res.Remaining != 0 {
+		t.Errorf("Expected Remaining = 0, got %d", res.Remaining)
+	}
+	if res.RetryAfter != 20*time.Second {
+		t.Errorf("Expected RetryAfter = 20s, got %v", res.RetryAfter)
+	}
+
+	// Other keys have their own bucket
+	if res := l.Allow("other", 3, 1); !res.Allowed {
+		t.Error("Expected a different key to be allowed")
+	}
+}
+
+func TestLimiter_Refill(t *testing.T) {
+	now := time.Now()
+	l := NewLimiter()
+	l.now = func() time.Time { return now }
+
+	l.Allow("key", 60, 60)
+	if res := l.Allow("key", 60, 1); res.Allowed {
+		t.Fatal("Expected empty bucket to reject")
+	}
+
+	now = now.Add(2 * time.Second)
+	res := l.Allow("key", 60, 1)
+	if !res.Allowed {
+		t.Fatal("Expected bucket to refill one token per second")
+	}
+	if res.Remaining != 1 {
+		t.Errorf("Expected Remaining = 1, got %d", res.Remaining)
+	}
+}
+
+func TestLimiter_Unlimited(t *testing.T) {
+	l := NewLimiter()
+	for i := 0; i < 100; i++ {
+		if res := l.Allow("key", 0, 1); !res.Allowed {
+			t.Fatal("Expected zero limit to allow everything")
+		}
+	}
+}
+
+func TestLimiter_ConsumeDebt(t *testing.T) {
+	now := time.Now()
+	l := NewLimiter()
+	l.now = func() time.Time { return now }
+
+	if res := l.Check("key", 60, 1); !res.Allowed || res.Remaining != 60 {
+		t.Fatalf("Expected full bucket, got %+v", res)
+	}
+
+	// A response larger than the bucket puts it into debt
+	l.Consume("key", 60, 90)
+	if res := l.Check("key", 60, 1); res.Allowed {
+		t.Fatal("Expected bucket in debt to reject")
+	}
+
+	// 30 tokens of debt + 1 token needed = 31 seconds at one token per second
+	now = now.Add(31 * time.Second)
+	if res := l.Check("key", 60, 1); !res.Allowed {
+		t.Error("Expected bucket to recover from debt")
+	}
+}
+
+func TestLimiter_CleanupIdle(t *testing.T) {
+	now := time.Now()
+	l := NewLimiter()
+	l.now = func() time.Time { return now }
+
+	l.Allow("idle", 60, 1)
+	l.Consume("debt", 60, 120)
+
+	now = now.Add(time.Minute)
+	l.CleanupIdle()
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("Expected a refilled bucket to be removed")
+	}
+	if _, ok := l.buckets["debt"]; !ok {
+		t.Fatal("Expected a bucket still in debt to be kept")
+	}
+
+	now = now.Add(time.Minute)
+	l.CleanupIdle()
+	if len(l.buckets) != 0 {
+		t.Errorf("Expected every bucket to be removed, got %d", len(l.buckets))
+	}
+}
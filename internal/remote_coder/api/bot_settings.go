@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/tingly-dev/tingly-box/internal/remote_coder/bot"
+	"github.com/tingly-dev/tingly-box/internal/secretref"
 )
 
 type BotSettingsHandler struct {
@@ -124,6 +125,16 @@ func (h *BotSettingsHandler) GetSettingsByUUID(c *gin.Context) {
 	})
 }
 
+// checkBotTokens rejects exec: secret references in the credentials of a bot sent through
+// the API: they would let API clients run commands on the server
+func checkBotTokens(payload BotSettingsPayload) error {
+	values := []string{strings.TrimSpace(payload.Token)}
+	for _, value := range payload.Auth {
+		values = append(values, strings.TrimSpace(value))
+	}
+	return secretref.CheckNoExec(values...)
+}
+
 // CreateSettings creates a new bot configuration
 func (h *BotSettingsHandler) CreateSettings(c *gin.Context) {
 	if h == nil || h.store == nil {
@@ -136,6 +147,10 @@ func (h *BotSettingsHandler) CreateSettings(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid request body"})
 		return
 	}
+	if err := checkBotTokens(payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
 
 	platform := strings.TrimSpace(payload.Platform)
 	if platform == "" {
@@ -200,6 +215,10 @@ func (h *BotSettingsHandler) UpdateSettings(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid request body"})
 		return
 	}
+	if err := checkBotTokens(payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
 
 	platform := strings.TrimSpace(payload.Platform)
 	if platform == "" {
@@ -314,6 +333,10 @@ func (h *BotSettingsHandler) UpdateSettingsLegacy(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid request body"})
 		return
 	}
+	if err := checkBotTokens(payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
 
 	platform := strings.TrimSpace(payload.Platform)
 	if platform == "" {
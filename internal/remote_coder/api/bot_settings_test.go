@@ -0,0 +1,45 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tingly-dev/tingly-box/internal/remote_coder/bot"
+)
+
+// TestBotSettings_RejectsExecTokens verifies that bot tokens sent through the API cannot be
+// exec: references, in any of the endpoints that save them
+func TestBotSettings_RejectsExecTokens(t *testing.T) {
+	store, err := bot.NewStore(filepath.Join(t.TempDir(), "tingly.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	handler := NewBotSettingsHandler(store, nil)
+
+	router := gin.New()
+	router.POST("/bots", handler.CreateSettings)
+	router.PUT("/bots/:uuid", handler.UpdateSettings)
+	router.POST("/bot/settings", handler.UpdateSettingsLegacy)
+
+	for _, tc := range []struct {
+		method, path, body string
+	}{
+		{http.MethodPost, "/bots", `{"platform":"telegram","auth":{"token":"exec:pass show bot"}}`},
+		{http.MethodPut, "/bots/some-uuid", `{"platform":"telegram","auth":{"token":"exec:pass show bot"}}`},
+		{http.MethodPost, "/bot/settings", `{"platform":"telegram","token":"exec:pass show bot"}`},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body)))
+		require.Equal(t, http.StatusBadRequest, w.Code, "%s %s", tc.method, tc.path)
+		require.Contains(t, w.Body.String(), "exec:")
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/bots", bytes.NewBufferString(`{"platform":"telegram","auth":{"token":"env:BOT_TOKEN"}}`)))
+	require.Equal(t, http.StatusOK, w.Code)
+}
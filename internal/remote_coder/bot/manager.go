@@ -61,9 +61,11 @@ func (m *Manager) Start(parentCtx context.Context, uuid string) error {
 
 		switch platform {
 		case "telegram":
-			token := s.Auth["token"]
-			if token == "" {
-				token = s.Token // Legacy field
+			token, err := s.ResolvedToken()
+			if err != nil {
+				logrus.WithError(err).WithField("uuid", uuid).Warn("Failed to resolve bot token, not starting")
+				m.removeRunning(uuid)
+				return
 			}
 			if token == "" {
 				logrus.WithField("uuid", uuid).Warn("Bot has no token, not starting")
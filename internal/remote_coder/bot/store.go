@@ -11,6 +11,8 @@ import (
 
 	"github.com/google/uuid"
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/tingly-dev/tingly-box/internal/secretref"
 )
 
 // Settings represents bot configuration with platform-specific auth
@@ -29,6 +31,16 @@ type Settings struct {
 	UpdatedAt     string            `json:"updated_at,omitempty"`
 }
 
+// ResolvedToken returns the bot token with a secret reference (env:, file:, exec:) resolved.
+// The stored settings keep the reference.
+func (s Settings) ResolvedToken() (string, error) {
+	token := s.Auth["token"]
+	if token == "" {
+		token = s.Token // Legacy field
+	}
+	return secretref.Resolve(strings.TrimSpace(token))
+}
+
 type Store struct {
 	db *sql.DB
 }
@@ -82,7 +82,11 @@ func runTelegramBotOnce(ctx context.Context, store *Store, sessionMgr *session.M
 	if err != nil {
 		return fmt.Errorf("failed to load bot settings: %w", err)
 	}
-	if strings.TrimSpace(settings.Token) == "" {
+	token, err := settings.ResolvedToken()
+	if err != nil {
+		return fmt.Errorf("failed to resolve telegram bot token: %w", err)
+	}
+	if token == "" {
 		return fmt.Errorf("telegram bot token is not configured")
 	}
 	platform := strings.TrimSpace(settings.Platform)
@@ -118,7 +122,7 @@ func runTelegramBotOnce(ctx context.Context, store *Store, sessionMgr *session.M
 		Enabled:  true,
 		Auth: imbot.AuthConfig{
 			Type:  "token",
-			Token: settings.Token,
+			Token: token,
 		},
 		Options: options,
 	})
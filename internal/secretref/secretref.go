@@ -0,0 +1,223 @@
+// Package secretref resolves secret references used in place of literal credentials.
+//
+// A credential field may hold one of the following references instead of the secret itself:
+//
+//	env:OPENAI_API_KEY          value of an environment variable
+//	file:/run/secrets/anthropic content of a file, trailing whitespace trimmed
+//	exec:pass show llm/openai   standard output of a command, trailing whitespace trimmed
+//
+// Any other value is a literal and is returned unchanged. Resolved values are cached
+// until Reset is called, so commands run once per configuration load rather than per request.
+//
+// exec: references run a command, so they are refused unless the server opts in with
+// EnableExec. The command is split into arguments like a shell would split it, with
+// quotes and backslash escapes, and run directly: pipes, redirections and variables
+// are not interpreted.
+package secretref
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Reference prefixes
+const (
+	PrefixEnv  = "env:"
+	PrefixFile = "file:"
+	PrefixExec = "exec:"
+)
+
+// ExecTimeout bounds how long an exec: reference may run
+const ExecTimeout = 10 * time.Second
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]string)
+
+	execEnabled atomic.Bool
+)
+
+// EnableExec allows or refuses the resolution of exec: references. They are refused by default.
+func EnableExec(enabled bool) {
+	execEnabled.Store(enabled)
+}
+
+// ExecEnabled reports whether exec: references are resolved
+func ExecEnabled() bool {
+	return execEnabled.Load()
+}
+
+// IsRef reports whether value is a secret reference rather than a literal secret
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, PrefixEnv) ||
+		strings.HasPrefix(value, PrefixFile) ||
+		strings.HasPrefix(value, PrefixExec)
+}
+
+// IsExecRef reports whether value is an exec: reference, which runs a command when resolved
+func IsExecRef(value string) bool {
+	return strings.HasPrefix(value, PrefixExec)
+}
+
+// ErrExecNotAllowed is returned for exec: references set where commands must not come from,
+// such as the HTTP API or an imported bundle
+var ErrExecNotAllowed = errors.New("exec: secret references can only be set in the config file")
+
+// CheckNoExec returns ErrExecNotAllowed when one of the values is an exec: reference
+func CheckNoExec(values ...string) error {
+	for _, value := range values {
+		if IsExecRef(value) {
+			return ErrExecNotAllowed
+		}
+	}
+	return nil
+}
+
+// Resolve returns the secret a value refers to. Literal values are returned unchanged.
+func Resolve(value string) (string, error) {
+	if !IsRef(value) {
+		return value, nil
+	}
+
+	cacheMu.Lock()
+	resolved, ok := cache[value]
+	cacheMu.Unlock()
+	if ok {
+		return resolved, nil
+	}
+
+	resolved, err := resolve(value)
+	if err != nil {
+		return "", err
+	}
+
+	cacheMu.Lock()
+	cache[value] = resolved
+	cacheMu.Unlock()
+	return resolved, nil
+}
+
+// Reset drops all cached values so that references are resolved again on next use.
+// It is called when the configuration is reloaded.
+func Reset() {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	cache = make(map[string]string)
+}
+
+func resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, PrefixEnv):
+		name := strings.TrimSpace(strings.TrimPrefix(value, PrefixEnv))
+		secret, ok := os.LookupEnv(name)
+		if !ok || secret == "" {
+			return "", fmt.Errorf("secret reference %s: environment variable %s is not set", value, name)
+		}
+		return secret, nil
+
+	case strings.HasPrefix(value, PrefixFile):
+		path := strings.TrimSpace(strings.TrimPrefix(value, PrefixFile))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("secret reference %s: %w", value, err)
+		}
+		secret := strings.TrimRight(string(data), " \t\r\n")
+		if secret == "" {
+			return "", fmt.Errorf("secret reference %s: file is empty", value)
+		}
+		return secret, nil
+
+	case strings.HasPrefix(value, PrefixExec):
+		if !ExecEnabled() {
+			return "", fmt.Errorf("secret reference %s: exec: references are disabled, start the server with --allow-secret-exec to enable them", value)
+		}
+		command := strings.TrimSpace(strings.TrimPrefix(value, PrefixExec))
+		args, err := splitCommand(command)
+		if err != nil {
+			return "", fmt.Errorf("secret reference %s: %w", value, err)
+		}
+		if len(args) == 0 {
+			return "", fmt.Errorf("secret reference %s: command is empty", value)
+		}
+		return runCommand(command, args)
+	}
+	return value, nil
+}
+
+func runCommand(command string, args []string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ExecTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		// Only stderr is included: stdout may hold a partial secret
+		return "", fmt.Errorf("secret reference exec:%s failed: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	secret := strings.TrimRight(stdout.String(), " \t\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("secret reference exec:%s produced no output", command)
+	}
+	return secret, nil
+}
+
+// splitCommand splits a command line into arguments on unquoted whitespace. Single quotes
+// keep their content as is, a backslash escapes the next character, also in double quotes.
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range command {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inArg = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if escaped || quote != 0 {
+		return nil, fmt.Errorf("unterminated quote or escape in command")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
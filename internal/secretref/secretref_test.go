@@ -0,0 +1,96 @@
+package secretref
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveLiteral(t *testing.T) {
+	value, err := Resolve("sk-literal")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-literal", value)
+	assert.False(t, IsRef("sk-literal"))
+}
+
+func TestResolveEnv(t *testing.T) {
+	Reset()
+	t.Setenv("SECRETREF_TEST_KEY", "sk-from-env")
+
+	value, err := Resolve("env:SECRETREF_TEST_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-from-env", value)
+
+	_, err = Resolve("env:SECRETREF_TEST_MISSING")
+	assert.ErrorContains(t, err, "SECRETREF_TEST_MISSING")
+}
+
+func TestResolveFileCachedUntilReset(t *testing.T) {
+	Reset()
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte("sk-first\n"), 0600))
+
+	value, err := Resolve("file:" + path)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-first", value)
+
+	require.NoError(t, os.WriteFile(path, []byte("sk-second\n"), 0600))
+	value, _ = Resolve("file:" + path)
+	assert.Equal(t, "sk-first", value, "value should be cached")
+
+	Reset()
+	value, err = Resolve("file:" + path)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-second", value)
+
+	_, err = Resolve("file:" + filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestResolveExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX commands")
+	}
+	Reset()
+	EnableExec(true)
+	defer EnableExec(false)
+
+	value, err := Resolve("exec:echo sk-from-exec")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-from-exec", value)
+
+	// The command is not run by a shell: the separator is passed to echo
+	value, err = Resolve("exec:echo sk-one; echo sk-two")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-one; echo sk-two", value)
+
+	_, err = Resolve(`exec:sh -c "echo oops >&2; exit 3"`)
+	assert.ErrorContains(t, err, "oops")
+}
+
+func TestResolveExecDisabled(t *testing.T) {
+	Reset()
+	EnableExec(false)
+
+	_, err := Resolve("exec:echo sk-from-exec")
+	assert.ErrorContains(t, err, "disabled")
+	assert.True(t, IsExecRef("exec:echo sk-from-exec"))
+	assert.False(t, IsExecRef("env:OPENAI_API_KEY"))
+}
+
+func TestSplitCommand(t *testing.T) {
+	args, err := splitCommand(`pass show 'llm/open ai' "a \"b\"" c\ d`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pass", "show", "llm/open ai", `a "b"`, "c d"}, args)
+
+	args, err = splitCommand(`echo ''`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"echo", ""}, args)
+
+	_, err = splitCommand(`echo "unterminated`)
+	assert.Error(t, err)
+}
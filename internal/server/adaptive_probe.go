@@ -15,6 +15,7 @@ import (
 	"github.com/openai/openai-go/v3/responses"
 	"github.com/sirupsen/logrus"
 
+	"github.com/tingly-dev/tingly-box/internal/client"
 	"github.com/tingly-dev/tingly-box/internal/data/db"
 	"github.com/tingly-dev/tingly-box/internal/protocol"
 	"github.com/tingly-dev/tingly-box/internal/typ"
@@ -116,6 +117,15 @@ func (ap *AdaptiveProbe) ProbeModelEndpoints(ctx context.Context, req ModelProbe
 func (ap *AdaptiveProbe) probeChatEndpoint(ctx context.Context, provider *typ.Provider, modelID string) EndpointStatus {
 	startTime := time.Now()
 
+	provider, err := client.ResolveProvider(provider)
+	if err != nil {
+		return EndpointStatus{
+			Available:    false,
+			ErrorMessage: fmt.Sprintf("Failed to resolve provider token: %v", err),
+			LastChecked:  time.Now(),
+		}
+	}
+
 	switch provider.APIStyle {
 	case protocol.APIStyleOpenAI:
 		return ap.probeOpenAIChatEndpoint(ctx, provider, modelID, startTime)
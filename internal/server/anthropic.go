@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -133,7 +134,10 @@ func (s *Server) AnthropicMessages(c *gin.Context) {
 		})
 		return
 	}
-	provider, selectedService, err = s.DetermineProviderAndModelWithScenario(scenarioType, rule, reqParams)
+	if !s.authorizeAPIKeyForRule(c, rule) || !s.rateLimitMW.AllowModel(c, model) {
+		return
+	}
+	provider, selectedService, err = s.DetermineProviderAndModelWithScenario(c, scenarioType, rule, reqParams)
 	if err != nil {
 		// Record error if recording is enabled
 		if recorder != nil {
@@ -153,27 +157,58 @@ func (s *Server) AnthropicMessages(c *gin.Context) {
 		c.Set("rule", rule)
 	}
 
-	// Delegate to the appropriate implementation based on beta parameter
-	if beta {
+	s.serveWithFailover(c, rule, provider, selectedService, func(attempt int, provider *typ.Provider, selectedService *loadbalance.Service) {
+		// Delegate to the appropriate implementation based on beta parameter
+		if beta {
+			if attempt > 1 {
+				// Earlier attempts may have mutated the request, start from the original body
+				betaMessages = protocol.AnthropicBetaMessagesRequest{}
+				if err := json.Unmarshal(bodyBytes, &betaMessages); err != nil {
+					return
+				}
+			}
 
-		// Apply compact transformation only if the compact feature is enabled for this scenario
-		if s.ApplySmartCompact(scenarioType) {
-			tf := smart_compact.NewCompactTransformer(2)
-			tf.HandleV1Beta(&betaMessages.BetaMessageNewParams)
-			logrus.Infoln("smart compact triggered")
-		}
-		s.anthropicMessagesV1Beta(c, betaMessages, model, provider, selectedService.Model, rule)
+			// Summarize before compacting, the summary cache is keyed by the original rounds
+			s.summarizeForContextWindow(c, rule, provider, selectedService.Model, &betaMessages.BetaMessageNewParams, func(ctx context.Context, summarizer *smart_compact.OverflowSummarizer, overflow int) error {
+				return summarizer.HandleV1Beta(ctx, &betaMessages.BetaMessageNewParams, overflow)
+			})
 
-	} else {
+			// Apply compact transformation only if the compact feature is enabled for this scenario
+			if s.ApplySmartCompact(scenarioType) {
+				tf := smart_compact.NewCompactTransformer(2)
+				tf.HandleV1Beta(&betaMessages.BetaMessageNewParams)
+				logrus.Infoln("smart compact triggered")
+			}
+			s.compactToolResults(c, scenarioType, func(tc *smart_compact.ToolResultCompactor) error {
+				return tc.HandleV1Beta(&betaMessages.BetaMessageNewParams)
+			})
+			s.anthropicMessagesV1Beta(c, betaMessages, model, provider, selectedService.Model, rule)
 
-		// Apply compact transformation only if the compact feature is enabled for this scenario
-		if s.ApplySmartCompact(scenarioType) {
-			tf := smart_compact.NewCompactTransformer(2)
-			tf.HandleV1(&messages.MessageNewParams)
-			logrus.Infoln("smart compact triggered")
+		} else {
+			if attempt > 1 {
+				messages = protocol.AnthropicMessagesRequest{}
+				if err := json.Unmarshal(bodyBytes, &messages); err != nil {
+					return
+				}
+			}
+
+			// Summarize before compacting, the summary cache is keyed by the original rounds
+			s.summarizeForContextWindow(c, rule, provider, selectedService.Model, &messages.MessageNewParams, func(ctx context.Context, summarizer *smart_compact.OverflowSummarizer, overflow int) error {
+				return summarizer.HandleV1(ctx, &messages.MessageNewParams, overflow)
+			})
+
+			// Apply compact transformation only if the compact feature is enabled for this scenario
+			if s.ApplySmartCompact(scenarioType) {
+				tf := smart_compact.NewCompactTransformer(2)
+				tf.HandleV1(&messages.MessageNewParams)
+				logrus.Infoln("smart compact triggered")
+			}
+			s.compactToolResults(c, scenarioType, func(tc *smart_compact.ToolResultCompactor) error {
+				return tc.HandleV1(&messages.MessageNewParams)
+			})
+			s.anthropicMessagesV1(c, messages, model, provider, selectedService.Model, rule)
 		}
-		s.anthropicMessagesV1(c, messages, model, provider, selectedService.Model, rule)
-	}
+	})
 }
 
 // AnthropicListModels handles Anthropic v1 models endpoint
@@ -105,9 +105,7 @@ func (s *Server) anthropicMessagesV1Beta(c *gin.Context, req protocol.AnthropicB
 			defer cancel()
 
 			// Track usage from response
-			inputTokens := int(anthropicResp.Usage.InputTokens)
-			outputTokens := int(anthropicResp.Usage.OutputTokens)
-			s.trackUsageFromContext(c, inputTokens, outputTokens, nil)
+			s.trackUsageStatFromContext(c, protocol.NewUsageStatFromAnthropicBeta(anthropicResp.Usage), nil)
 
 			// FIXME: now we use req model as resp model
 			anthropicResp.Model = anthropic.Model(proxyModel)
@@ -131,6 +129,7 @@ func (s *Server) anthropicMessagesV1Beta(c *gin.Context, req protocol.AnthropicB
 			fc := NewForwardContext(c.Request.Context(), provider)
 			streamResp, cancel, err := ForwardGoogleStream(fc, wrapper, model, googleReq, cfg)
 			if err != nil {
+				s.trackUsageFromContext(c, 0, 0, err)
 				stream.SendStreamingError(c, err)
 				if recorder != nil {
 					recorder.RecordError(err)
@@ -142,7 +141,7 @@ func (s *Server) anthropicMessagesV1Beta(c *gin.Context, req protocol.AnthropicB
 			// Handle the streaming response
 			usage, err := stream.HandleGoogleToAnthropicBetaStreamResponse(c, streamResp, proxyModel)
 			if err != nil {
-				s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, err)
+				s.trackUsageStatFromContext(c, usage, err)
 				stream.SendInternalError(c, err.Error())
 				if recorder != nil {
 					recorder.RecordError(err)
@@ -151,7 +150,7 @@ func (s *Server) anthropicMessagesV1Beta(c *gin.Context, req protocol.AnthropicB
 			}
 
 			// Track usage from stream handler
-			s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, nil)
+			s.trackUsageStatFromContext(c, usage, nil)
 
 		} else {
 			// Handle non-streaming request
@@ -159,6 +158,7 @@ func (s *Server) anthropicMessagesV1Beta(c *gin.Context, req protocol.AnthropicB
 			fc := NewForwardContext(nil, provider)
 			resp, err := ForwardGoogle(fc, wrapper, model, googleReq, cfg)
 			if err != nil {
+				s.trackUsageFromContext(c, 0, 0, err)
 				stream.SendForwardingError(c, err)
 				if recorder != nil {
 					recorder.RecordError(err)
@@ -170,13 +170,7 @@ func (s *Server) anthropicMessagesV1Beta(c *gin.Context, req protocol.AnthropicB
 			anthropicResp := nonstream.ConvertGoogleToAnthropicBetaResponse(resp, proxyModel)
 
 			// Track usage from response
-			inputTokens := 0
-			outputTokens := 0
-			if resp.UsageMetadata != nil {
-				inputTokens = int(resp.UsageMetadata.PromptTokenCount)
-				outputTokens = int(resp.UsageMetadata.CandidatesTokenCount)
-			}
-			s.trackUsageFromContext(c, inputTokens, outputTokens, nil)
+			s.trackUsageStatFromContext(c, protocol.NewUsageStatFromGoogle(resp.UsageMetadata), nil)
 
 			// Record response if scenario recording is enabled
 			if recorder != nil {
@@ -236,6 +230,7 @@ func (s *Server) anthropicMessagesV1Beta(c *gin.Context, req protocol.AnthropicB
 				fc := NewForwardContext(c.Request.Context(), provider)
 				streamResp, _, err := ForwardOpenAIChatStream(fc, wrapper, openaiReq)
 				if err != nil {
+					s.trackUsageFromContext(c, 0, 0, err)
 					stream.SendStreamingError(c, err)
 					if streamRec != nil {
 						streamRec.RecordError(err)
@@ -246,7 +241,7 @@ func (s *Server) anthropicMessagesV1Beta(c *gin.Context, req protocol.AnthropicB
 				// Handle the streaming response
 				usage, err := stream.HandleOpenAIToAnthropicBetaStream(c, openaiReq, streamResp, proxyModel)
 				if err != nil {
-					s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, err)
+					s.trackUsageStatFromContext(c, usage, err)
 					stream.SendInternalError(c, err.Error())
 					if streamRec != nil {
 						streamRec.RecordError(err)
@@ -255,7 +250,7 @@ func (s *Server) anthropicMessagesV1Beta(c *gin.Context, req protocol.AnthropicB
 				}
 
 				// Track usage from stream handler
-				s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, nil)
+				s.trackUsageStatFromContext(c, usage, nil)
 
 				// Finish recording and assemble response
 				if streamRec != nil {
@@ -268,6 +263,7 @@ func (s *Server) anthropicMessagesV1Beta(c *gin.Context, req protocol.AnthropicB
 				fc := NewForwardContext(nil, provider)
 				resp, err := ForwardOpenAIChat(fc, wrapper, openaiReq)
 				if err != nil {
+					s.trackUsageFromContext(c, 0, 0, err)
 					stream.SendForwardingError(c, err)
 					if recorder != nil {
 						recorder.RecordError(err)
@@ -278,9 +274,7 @@ func (s *Server) anthropicMessagesV1Beta(c *gin.Context, req protocol.AnthropicB
 				anthropicResp := nonstream.ConvertOpenAIToAnthropicBetaResponse(resp, proxyModel)
 
 				// Track usage from response
-				inputTokens := int(resp.Usage.PromptTokens)
-				outputTokens := int(resp.Usage.CompletionTokens)
-				s.trackUsageFromContext(c, inputTokens, outputTokens, nil)
+				s.trackUsageStatFromContext(c, protocol.NewUsageStatFromOpenAIChat(resp.Usage), nil)
 
 				// Record response if scenario recording is enabled
 				if recorder != nil {
@@ -317,7 +311,7 @@ func (s *Server) handleAnthropicStreamResponseV1Beta(c *gin.Context, req anthrop
 	}
 
 	usageStat, err := stream.HandleAnthropicV1BetaStream(hc, req, streamResp)
-	s.trackUsageFromContext(c, usageStat.InputTokens, usageStat.OutputTokens, err)
+	s.trackUsageStatFromContext(c, usageStat, err)
 }
 
 // handleAnthropicV1BetaViaResponsesAPINonStreaming handles non-streaming Responses API request
@@ -351,12 +345,8 @@ func (s *Server) handleAnthropicV1BetaViaResponsesAPINonStreaming(c *gin.Context
 		return
 	}
 
-	// Extract usage from response
-	inputTokens := int(response.Usage.InputTokens)
-	outputTokens := int(response.Usage.OutputTokens)
-
-	// Track usage
-	s.trackUsageFromContext(c, inputTokens, outputTokens, nil)
+	// Track usage from response
+	s.trackUsageStatFromContext(c, protocol.NewUsageStatFromOpenAIResponses(response.Usage), nil)
 
 	// Convert Responses API response back to Anthropic beta format
 	anthropicResp := nonstream.ConvertResponsesToAnthropicBetaResponse(response, proxyModel)
@@ -409,14 +399,14 @@ func (s *Server) handleAnthropicV1BetaViaResponsesAPIStreaming(c *gin.Context, r
 
 	// Track usage from stream handler
 	if err != nil {
-		s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, err)
+		s.trackUsageStatFromContext(c, usage, err)
 		if streamRec != nil {
 			streamRec.RecordError(err)
 		}
 		return
 	}
 
-	s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, nil)
+	s.trackUsageStatFromContext(c, usage, nil)
 
 	// Finish recording and assemble response
 	if streamRec != nil {
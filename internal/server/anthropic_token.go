@@ -86,6 +86,9 @@ func (s *Server) AnthropicCountTokens(c *gin.Context) {
 		})
 		return
 	}
+	if !s.authorizeAPIKeyForRule(c, rule) || !s.rateLimitMW.AllowModel(c, model) {
+		return
+	}
 	provider, service, err := s.DetermineProviderAndModel(rule)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
@@ -112,6 +115,7 @@ func (s *Server) anthropicCountTokens(c *gin.Context, provider *typ.Provider, mo
 	c.Set("model", model)
 
 	apiStyle := provider.APIStyle
+	provider = s.clientPool.SelectKey(provider)
 	wrapper := s.clientPool.GetAnthropicClient(provider, model)
 	timeout := time.Duration(provider.Timeout) * time.Second
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
@@ -127,26 +131,29 @@ func (s *Server) anthropicCountTokens(c *gin.Context, provider *typ.Provider, mo
 		})
 		return
 	case protocol.APIStyleAnthropic:
-		s.anthropicCountTokensViaAPI(c, ctx, wrapper, model, version)
+		err := s.anthropicCountTokensViaAPI(c, ctx, wrapper, model, version)
+		s.recordProviderKeyResult(provider, model, protocol.UsageStat{}, err)
 	case protocol.APIStyleOpenAI:
 		s.anthropicCountTokensViaTiktoken(c, version)
 	}
 }
 
-func (s *Server) anthropicCountTokensViaAPI(c *gin.Context, ctx context.Context, wrapper interface{}, model string, version anthropicCountTokensVersion) {
+// anthropicCountTokensViaAPI counts the tokens of the request with the upstream API and
+// returns the upstream error, if any
+func (s *Server) anthropicCountTokensViaAPI(c *gin.Context, ctx context.Context, wrapper interface{}, model string, version anthropicCountTokensVersion) error {
 	switch version {
 	case anthropicCountTokensBeta:
 		var req anthropic.BetaMessageCountTokensParams
 		if err := c.ShouldBindJSON(&req); err != nil {
 			logrus.Debugf("Invalid JSON request received: %v", err)
 			stream.SendInvalidRequestBodyError(c, err)
-			return
+			return nil
 		}
 		req.Model = anthropic.Model(model)
 		message, err := wrapper.(*client.AnthropicClient).BetaMessagesCountTokens(ctx, req)
 		if err != nil {
 			stream.SendInvalidRequestBodyError(c, err)
-			return
+			return err
 		}
 		c.JSON(http.StatusOK, message)
 	case anthropicCountTokensV1:
@@ -154,16 +161,17 @@ func (s *Server) anthropicCountTokensViaAPI(c *gin.Context, ctx context.Context,
 		if err := c.ShouldBindJSON(&req); err != nil {
 			logrus.Debugf("Invalid JSON request received: %v", err)
 			stream.SendInvalidRequestBodyError(c, err)
-			return
+			return nil
 		}
 		req.Model = anthropic.Model(model)
 		message, err := wrapper.(*client.AnthropicClient).MessagesCountTokens(ctx, req)
 		if err != nil {
 			stream.SendInvalidRequestBodyError(c, err)
-			return
+			return err
 		}
 		c.JSON(http.StatusOK, message)
 	}
+	return nil
 }
 
 func (s *Server) anthropicCountTokensViaTiktoken(c *gin.Context, version anthropicCountTokensVersion) {
@@ -106,9 +106,7 @@ func (s *Server) anthropicMessagesV1(c *gin.Context, req protocol.AnthropicMessa
 			defer cancel()
 
 			// Track usage from response
-			inputTokens := int(anthropicResp.Usage.InputTokens)
-			outputTokens := int(anthropicResp.Usage.OutputTokens)
-			s.trackUsageFromContext(c, inputTokens, outputTokens, nil)
+			s.trackUsageStatFromContext(c, protocol.NewUsageStatFromAnthropic(anthropicResp.Usage), nil)
 
 			// FIXME: now we use req model as resp model
 			anthropicResp.Model = anthropic.Model(proxyModel)
@@ -139,19 +137,21 @@ func (s *Server) anthropicMessagesV1(c *gin.Context, req protocol.AnthropicMessa
 			// Create streaming request with request context for proper cancellation
 			wrapper := s.clientPool.GetGoogleClient(provider, model)
 			fc := NewForwardContext(c.Request.Context(), provider)
-			streamResp, _, err := ForwardGoogleStream(fc, wrapper, model, googleReq, cfg)
+			streamResp, cancel, err := ForwardGoogleStream(fc, wrapper, model, googleReq, cfg)
 			if err != nil {
+				s.trackUsageFromContext(c, 0, 0, err)
 				stream.SendStreamingError(c, err)
 				if recorder != nil {
 					recorder.RecordError(err)
 				}
 				return
 			}
+			defer cancel()
 
 			// Handle the streaming response
 			usage, err := stream.HandleGoogleToAnthropicStreamResponse(c, streamResp, proxyModel)
 			if err != nil {
-				s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, err)
+				s.trackUsageStatFromContext(c, usage, err)
 				stream.SendInternalError(c, err.Error())
 				if recorder != nil {
 					recorder.RecordError(err)
@@ -160,7 +160,7 @@ func (s *Server) anthropicMessagesV1(c *gin.Context, req protocol.AnthropicMessa
 			}
 
 			// Track usage from stream handler
-			s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, nil)
+			s.trackUsageStatFromContext(c, usage, nil)
 
 		} else {
 			// Handle non-streaming request
@@ -168,6 +168,7 @@ func (s *Server) anthropicMessagesV1(c *gin.Context, req protocol.AnthropicMessa
 			fc := NewForwardContext(nil, provider)
 			response, err := ForwardGoogle(fc, wrapper, model, googleReq, cfg)
 			if err != nil {
+				s.trackUsageFromContext(c, 0, 0, err)
 				stream.SendForwardingError(c, err)
 				if recorder != nil {
 					recorder.RecordError(err)
@@ -187,13 +188,7 @@ func (s *Server) anthropicMessagesV1(c *gin.Context, req protocol.AnthropicMessa
 			}
 
 			// Track usage from response
-			inputTokens := 0
-			outputTokens := 0
-			if response.UsageMetadata != nil {
-				inputTokens = int(response.UsageMetadata.PromptTokenCount)
-				outputTokens = int(response.UsageMetadata.CandidatesTokenCount)
-			}
-			s.trackUsageFromContext(c, inputTokens, outputTokens, nil)
+			s.trackUsageStatFromContext(c, protocol.NewUsageStatFromGoogle(response.UsageMetadata), nil)
 
 			// Record response if scenario recording is enabled
 			if recorder != nil {
@@ -249,6 +244,7 @@ func (s *Server) anthropicMessagesV1(c *gin.Context, req protocol.AnthropicMessa
 			fc := NewForwardContext(c.Request.Context(), provider)
 			streamResp, _, err := ForwardOpenAIChatStream(fc, wrapper, openaiReq)
 			if err != nil {
+				s.trackUsageFromContext(c, 0, 0, err)
 				stream.SendStreamingError(c, err)
 				if recorder != nil {
 					recorder.RecordError(err)
@@ -259,7 +255,7 @@ func (s *Server) anthropicMessagesV1(c *gin.Context, req protocol.AnthropicMessa
 			// Handle the streaming response
 			usage, err := stream.HandleOpenAIToAnthropicStreamResponse(c, openaiReq, streamResp, proxyModel)
 			if err != nil {
-				s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, err)
+				s.trackUsageStatFromContext(c, usage, err)
 				stream.SendInternalError(c, err.Error())
 				if recorder != nil {
 					recorder.RecordError(err)
@@ -268,7 +264,7 @@ func (s *Server) anthropicMessagesV1(c *gin.Context, req protocol.AnthropicMessa
 			}
 
 			// Track usage from stream handler
-			s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, nil)
+			s.trackUsageStatFromContext(c, usage, nil)
 
 		} else {
 			// Handle non-streaming request
@@ -278,6 +274,7 @@ func (s *Server) anthropicMessagesV1(c *gin.Context, req protocol.AnthropicMessa
 			fc := NewForwardContext(nil, provider)
 			response, err := ForwardOpenAIChat(fc, wrapper, openaiReq)
 			if err != nil {
+				s.trackUsageFromContext(c, 0, 0, err)
 				stream.SendForwardingError(c, err)
 				if recorder != nil {
 					recorder.RecordError(err)
@@ -296,9 +293,7 @@ func (s *Server) anthropicMessagesV1(c *gin.Context, req protocol.AnthropicMessa
 			}
 
 			// Track usage from response
-			inputTokens := int(response.Usage.PromptTokens)
-			outputTokens := int(response.Usage.CompletionTokens)
-			s.trackUsageFromContext(c, inputTokens, outputTokens, nil)
+			s.trackUsageStatFromContext(c, protocol.NewUsageStatFromOpenAIChat(response.Usage), nil)
 
 			// Record response if scenario recording is enabled
 			if recorder != nil {
@@ -334,7 +329,7 @@ func (s *Server) handleAnthropicStreamResponseV1(c *gin.Context, req anthropic.M
 	}
 
 	usageStat, err := stream.HandleAnthropicV1Stream(hc, req, streamResp)
-	s.trackUsageFromContext(c, usageStat.InputTokens, usageStat.OutputTokens, err)
+	s.trackUsageStatFromContext(c, usageStat, err)
 }
 
 // handleAnthropicV1ViaResponsesAPINonStreaming handles non-streaming Responses API request for v1
@@ -369,12 +364,8 @@ func (s *Server) handleAnthropicV1ViaResponsesAPINonStreaming(c *gin.Context, re
 		return
 	}
 
-	// Extract usage from response
-	inputTokens := int(response.Usage.InputTokens)
-	outputTokens := int(response.Usage.OutputTokens)
-
-	// Track usage
-	s.trackUsageFromContext(c, inputTokens, outputTokens, nil)
+	// Track usage from response
+	s.trackUsageStatFromContext(c, protocol.NewUsageStatFromOpenAIResponses(response.Usage), nil)
 
 	// Convert Responses API response back to Anthropic v1 format
 	anthropicResp := nonstream.ConvertResponsesToAnthropicV1Response(response, proxyModel)
@@ -434,14 +425,14 @@ func (s *Server) handleAnthropicV1ViaResponsesAPIStreaming(c *gin.Context, req p
 
 	// Track usage from stream handler
 	if err != nil {
-		s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, err)
+		s.trackUsageStatFromContext(c, usage, err)
 		if streamRec != nil {
 			streamRec.RecordError(err)
 		}
 		return
 	}
 
-	s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, nil)
+	s.trackUsageStatFromContext(c, usage, nil)
 
 	// Finish recording and assemble response
 	if streamRec != nil {
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tingly-dev/tingly-box/internal/obs"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// GetAPIKeys returns all client API keys without their hashes
+func (s *Server) GetAPIKeys(c *gin.Context) {
+	cfg := s.config
+	if cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Global config not available",
+		})
+		return
+	}
+
+	keys := cfg.ListAPIKeys()
+	for i := range keys {
+		keys[i].Hash = ""
+	}
+
+	c.JSON(http.StatusOK, APIKeysResponse{
+		Success: true,
+		Data:    keys,
+	})
+}
+
+// CreateAPIKey creates a client API key and returns its secret once
+func (s *Server) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	cfg := s.config
+	if cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Global config not available",
+		})
+		return
+	}
+
+	expiresAt := req.ExpiresAt
+	if expiresAt == nil && req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+	for _, scenario := range req.Scenarios {
+		if !isValidRuleScenario(scenario) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("invalid scenario: %s", scenario),
+			})
+			return
+		}
+	}
+
+	key, secret, err := cfg.CreateAPIKey(typ.APIKey{
+		Name:      req.Name,
+		ExpiresAt: expiresAt,
+		Scenarios: req.Scenarios,
+		Models:    req.Models,
+		Rules:     req.Rules,
+		RateLimit: req.RateLimit,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Failed to create API key: " + err.Error(),
+		})
+		return
+	}
+
+	if s.logger != nil {
+		s.logger.LogAction(obs.ActionCreateAPIKey, map[string]interface{}{
+			"id":   key.ID,
+			"name": key.Name,
+		}, true, fmt.Sprintf("API key %s created successfully", key.Name))
+	}
+
+	key.Hash = ""
+	c.JSON(http.StatusOK, APIKeySecretResponse{
+		Success: true,
+		Data:    key,
+		Key:     secret,
+	})
+}
+
+// RevokeAPIKey revokes a client API key
+func (s *Server) RevokeAPIKey(c *gin.Context) {
+	id := c.Param("id")
+
+	cfg := s.config
+	if cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Global config not available",
+		})
+		return
+	}
+
+	if err := cfg.RevokeAPIKey(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if s.logger != nil {
+		s.logger.LogAction(obs.ActionRevokeAPIKey, map[string]interface{}{
+			"id": id,
+		}, true, fmt.Sprintf("API key %s revoked successfully", id))
+	}
+
+	c.JSON(http.StatusOK, APIKeyActionResponse{
+		Success: true,
+		Message: "API key revoked successfully",
+	})
+}
+
+// RotateAPIKey replaces the secret of a client API key and returns the new one once
+func (s *Server) RotateAPIKey(c *gin.Context) {
+	id := c.Param("id")
+
+	cfg := s.config
+	if cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Global config not available",
+		})
+		return
+	}
+
+	secret, err := cfg.RotateAPIKey(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if s.logger != nil {
+		s.logger.LogAction(obs.ActionRotateAPIKey, map[string]interface{}{
+			"id": id,
+		}, true, fmt.Sprintf("API key %s rotated successfully", id))
+	}
+
+	key, err := cfg.GetAPIKey(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	key.Hash = ""
+	c.JSON(http.StatusOK, APIKeySecretResponse{
+		Success: true,
+		Data:    key,
+		Key:     secret,
+	})
+}
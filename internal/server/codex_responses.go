@@ -164,7 +164,7 @@ func (s *Server) handleChatGPTBackendStreamingRequest(c *gin.Context, provider *
 	}
 
 	if streamErr != nil {
-		s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, streamErr)
+		s.trackUsageStatFromContext(c, usage, streamErr)
 		logrus.Errorf("[ChatGPT] Stream handler error: %v", streamErr)
 		if streamRec != nil {
 			streamRec.RecordError(streamErr)
@@ -173,7 +173,7 @@ func (s *Server) handleChatGPTBackendStreamingRequest(c *gin.Context, provider *
 	}
 
 	// Track usage from stream handler
-	s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, nil)
+	s.trackUsageStatFromContext(c, usage, nil)
 
 	// Finish recording and assemble response
 	if streamRec != nil {
@@ -199,7 +199,7 @@ func (s *Server) makeChatGPTBackendRequest(wrapper *client.OpenAIClient, provide
 	timeout := time.Duration(provider.Timeout) * time.Second
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewReader(bodyBytes))
+	req, err := http.NewRequestWithContext(client.WithUpstreamModel(ctx, string(params.Model)), "POST", reqURL, bytes.NewReader(bodyBytes))
 	if err != nil {
 		cancel()
 		return nil, nil, fmt.Errorf("failed to create request: %w", err)
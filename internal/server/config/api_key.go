@@ -0,0 +1,178 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// apiKeySecretBytes is the number of random bytes in a generated key secret
+const apiKeySecretBytes = 24
+
+// ListAPIKeys returns a copy of all client API keys, including revoked ones
+func (c *Config) ListAPIKeys() []typ.APIKey {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]typ.APIKey, 0, len(c.APIKeys))
+	for _, key := range c.APIKeys {
+		if key != nil {
+			result = append(result, *key)
+		}
+	}
+	return result
+}
+
+// GetAPIKey returns a copy of the client API key with the given ID
+func (c *Config) GetAPIKey(id string) (*typ.APIKey, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key := c.findAPIKey(id)
+	if key == nil {
+		return nil, fmt.Errorf("api key '%s' not found", id)
+	}
+	result := *key
+	return &result, nil
+}
+
+// CreateAPIKey stores a new client API key built from the given name, expiry and scopes.
+// It returns the stored key and its secret, which is not recoverable afterwards.
+func (c *Config) CreateAPIKey(spec typ.APIKey) (*typ.APIKey, string, error) {
+	if spec.Name == "" {
+		return nil, "", errors.New("api key name cannot be empty")
+	}
+
+	secret, err := generateAPIKeySecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &typ.APIKey{
+		ID:        GenerateUUID(),
+		Name:      spec.Name,
+		Hash:      typ.HashAPIKey(secret),
+		Hint:      apiKeyHint(secret),
+		CreatedAt: time.Now(),
+		ExpiresAt: spec.ExpiresAt,
+		Scenarios: spec.Scenarios,
+		Models:    spec.Models,
+		Rules:     spec.Rules,
+		RateLimit: spec.RateLimit,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, existing := range c.APIKeys {
+		if existing != nil && existing.Name == key.Name && !existing.IsRevoked() {
+			return nil, "", fmt.Errorf("api key '%s' already exists", key.Name)
+		}
+	}
+
+	c.APIKeys = append(c.APIKeys, key)
+	if err := c.Save(); err != nil {
+		return nil, "", err
+	}
+
+	result := *key
+	return &result, secret, nil
+}
+
+// RevokeAPIKey revokes a client API key. Revoked keys are kept so usage stays attributable.
+func (c *Config) RevokeAPIKey(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := c.findAPIKey(id)
+	if key == nil {
+		return fmt.Errorf("api key '%s' not found", id)
+	}
+	if key.IsRevoked() {
+		return nil
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	return c.Save()
+}
+
+// RotateAPIKey replaces the secret of a client API key, keeping its ID and scopes.
+// It returns the new secret; the old one stops working immediately.
+func (c *Config) RotateAPIKey(id string) (string, error) {
+	secret, err := generateAPIKeySecret()
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := c.findAPIKey(id)
+	if key == nil {
+		return "", fmt.Errorf("api key '%s' not found", id)
+	}
+	if key.IsRevoked() {
+		return "", fmt.Errorf("api key '%s' is revoked", id)
+	}
+
+	now := time.Now()
+	key.Hash = typ.HashAPIKey(secret)
+	key.Hint = apiKeyHint(secret)
+	key.RotatedAt = &now
+	if err := c.Save(); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// AuthenticateAPIKey returns a copy of the active client API key matching the secret, or nil
+func (c *Config) AuthenticateAPIKey(secret string) *typ.APIKey {
+	if secret == "" {
+		return nil
+	}
+	hash := typ.HashAPIKey(secret)
+	now := time.Now()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, key := range c.APIKeys {
+		if key != nil && key.Hash == hash && key.IsActive(now) {
+			result := *key
+			return &result
+		}
+	}
+	return nil
+}
+
+// findAPIKey returns the stored key with the given ID; the caller must hold the lock
+func (c *Config) findAPIKey(id string) *typ.APIKey {
+	for _, key := range c.APIKeys {
+		if key != nil && key.ID == id {
+			return key
+		}
+	}
+	return nil
+}
+
+// generateAPIKeySecret generates a random client API key secret
+func generateAPIKeySecret() (string, error) {
+	buf := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return typ.APIKeyPrefix + hex.EncodeToString(buf), nil
+}
+
+// apiKeyHint returns the part of a secret that is safe to display
+func apiKeyHint(secret string) string {
+	if len(secret) <= 4 {
+		return secret
+	}
+	return "..." + secret[len(secret)-4:]
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	cfg, err := NewConfigWithDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	key, secret, err := cfg.CreateAPIKey(typ.APIKey{
+		Name:      "ci",
+		Scenarios: []typ.RuleScenario{typ.ScenarioOpenAI},
+		Models:    []string{"gpt-4o"},
+	})
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	if !strings.HasPrefix(secret, typ.APIKeyPrefix) {
+		t.Errorf("Expected secret to start with %q, got %q", typ.APIKeyPrefix, secret)
+	}
+	if key.Hash == secret || key.Hash != typ.HashAPIKey(secret) {
+		t.Error("Expected only the hash of the secret to be stored")
+	}
+
+	if _, _, err := cfg.CreateAPIKey(typ.APIKey{Name: "ci"}); err == nil {
+		t.Error("Expected duplicate active key name to be rejected")
+	}
+
+	authenticated := cfg.AuthenticateAPIKey(secret)
+	if authenticated == nil || authenticated.ID != key.ID {
+		t.Fatal("Expected secret to authenticate the key")
+	}
+	if cfg.AuthenticateAPIKey("tb-wrong") != nil {
+		t.Error("Expected unknown secret to be rejected")
+	}
+
+	// Rotation invalidates the old secret
+	rotated, err := cfg.RotateAPIKey(key.ID)
+	if err != nil {
+		t.Fatalf("RotateAPIKey failed: %v", err)
+	}
+	if cfg.AuthenticateAPIKey(secret) != nil {
+		t.Error("Expected old secret to stop working after rotation")
+	}
+	if got := cfg.AuthenticateAPIKey(rotated); got == nil || got.ID != key.ID {
+		t.Error("Expected rotated secret to authenticate the same key")
+	}
+
+	// Revoked keys are kept but no longer authenticate
+	if err := cfg.RevokeAPIKey(key.ID); err != nil {
+		t.Fatalf("RevokeAPIKey failed: %v", err)
+	}
+	if cfg.AuthenticateAPIKey(rotated) != nil {
+		t.Error("Expected revoked key to be rejected")
+	}
+	if keys := cfg.ListAPIKeys(); len(keys) != 1 || !keys[0].IsRevoked() {
+		t.Errorf("Expected revoked key to stay listed, got %+v", keys)
+	}
+}
+
+func TestAPIKeyExpiry(t *testing.T) {
+	cfg, err := NewConfigWithDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	_, secret, err := cfg.CreateAPIKey(typ.APIKey{Name: "old", ExpiresAt: &expired})
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	if cfg.AuthenticateAPIKey(secret) != nil {
+		t.Error("Expected expired key to be rejected")
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	key := &typ.APIKey{
+		Scenarios: []typ.RuleScenario{typ.ScenarioClaudeCode},
+		Rules:     []string{"rule-1"},
+	}
+
+	if !key.AllowsScenario(typ.ScenarioClaudeCode) || key.AllowsScenario(typ.ScenarioOpenAI) {
+		t.Error("Expected scenario scope to be enforced")
+	}
+	if !key.AllowsRule(&typ.Rule{UUID: "rule-1", RequestModel: "any"}) {
+		t.Error("Expected allowed rule to pass")
+	}
+	if key.AllowsRule(&typ.Rule{UUID: "rule-2", RequestModel: "any"}) {
+		t.Error("Expected other rule to be rejected")
+	}
+
+	unscoped := &typ.APIKey{}
+	if !unscoped.AllowsScenario(typ.ScenarioOpenAI) || !unscoped.AllowsRule(&typ.Rule{UUID: "x"}) {
+		t.Error("Expected key without scopes to allow everything")
+	}
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tingly-dev/tingly-box/internal/data/db"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// ListBudgets returns a copy of all usage budgets
+func (c *Config) ListBudgets() []typ.Budget {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]typ.Budget, 0, len(c.Budgets))
+	for _, b := range c.Budgets {
+		if b != nil {
+			result = append(result, *b)
+		}
+	}
+	return result
+}
+
+// MatchingBudgets returns a copy of the enabled budgets that apply to a request
+func (c *Config) MatchingBudgets(apiKeyID, scenario string) []typ.Budget {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var result []typ.Budget
+	for _, b := range c.Budgets {
+		if b != nil && b.Matches(apiKeyID, scenario) {
+			result = append(result, *b)
+		}
+	}
+	return result
+}
+
+// SetBudget creates or updates a usage budget. A budget without ID is created.
+func (c *Config) SetBudget(budget typ.Budget) (*typ.Budget, error) {
+	if err := budget.Validate(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if budget.ID == "" {
+		budget.ID = GenerateUUID()
+		c.Budgets = append(c.Budgets, &budget)
+	} else {
+		found := false
+		for i, b := range c.Budgets {
+			if b != nil && b.ID == budget.ID {
+				c.Budgets[i] = &budget
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("budget '%s' not found", budget.ID)
+		}
+	}
+
+	if err := c.Save(); err != nil {
+		return nil, err
+	}
+	result := budget
+	return &result, nil
+}
+
+// DeleteBudget removes a usage budget
+func (c *Config) DeleteBudget(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, b := range c.Budgets {
+		if b != nil && b.ID == id {
+			c.Budgets = append(c.Budgets[:i], c.Budgets[i+1:]...)
+			return c.Save()
+		}
+	}
+	return fmt.Errorf("budget '%s' not found", id)
+}
+
+// GetBudgetUsage returns the usage counted against a budget in the period containing now
+func (c *Config) GetBudgetUsage(budget *typ.Budget, now time.Time) (db.UsageTotals, error) {
+	usageStore := c.GetUsageStore()
+	if usageStore == nil {
+		return db.UsageTotals{}, errors.New("usage store not available")
+	}
+
+	start, end := budget.PeriodStart(now), budget.PeriodEnd(now)
+	if budget.Scope == typ.BudgetScopeAPIKey {
+		return usageStore.GetBudgetTotals(start, end, budget.Target, "")
+	}
+	return usageStore.GetBudgetTotals(start, end, "", budget.Target)
+}
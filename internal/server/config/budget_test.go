@@ -0,0 +1,122 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tingly-dev/tingly-box/internal/data/db"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+func TestBudgetUsage(t *testing.T) {
+	cfg, err := NewConfigWithDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	budget, err := cfg.SetBudget(typ.Budget{
+		Scope:  typ.BudgetScopeAPIKey,
+		Target: "key-1",
+		Period: typ.BudgetPeriodDaily,
+		Hard:   typ.BudgetLimits{Requests: 2},
+		Soft:   typ.BudgetLimits{Tokens: 100},
+	})
+	if err != nil {
+		t.Fatalf("SetBudget failed: %v", err)
+	}
+	if budget.ID == "" {
+		t.Fatal("Expected new budget to get an ID")
+	}
+
+	if got := cfg.MatchingBudgets("key-1", "openai"); len(got) != 1 {
+		t.Fatalf("Expected budget to match its key, got %d", len(got))
+	}
+	if got := cfg.MatchingBudgets("key-2", "openai"); len(got) != 0 {
+		t.Errorf("Expected budget not to match other keys, got %d", len(got))
+	}
+
+	store := cfg.GetUsageStore()
+	for _, keyID := range []string{"key-1", "key-1", "key-2"} {
+		if err := store.RecordUsage(&db.UsageRecord{
+			ProviderUUID: "p",
+			ProviderName: "p",
+			Model:        "m",
+			Scenario:     "openai",
+			APIKeyID:     keyID,
+			InputTokens:  40,
+			OutputTokens: 20,
+		}); err != nil {
+			t.Fatalf("RecordUsage failed: %v", err)
+		}
+	}
+
+	totals, err := cfg.GetBudgetUsage(budget, time.Now())
+	if err != nil {
+		t.Fatalf("GetBudgetUsage failed: %v", err)
+	}
+	if totals.RequestCount != 2 || totals.TotalTokens != 120 {
+		t.Errorf("Expected 2 requests and 120 tokens, got %+v", totals)
+	}
+
+	// A failover attempt adds its tokens to the same client request
+	if err := store.RecordUsage(&db.UsageRecord{
+		ProviderUUID: "p",
+		ProviderName: "p",
+		Model:        "m",
+		Scenario:     "openai",
+		APIKeyID:     "key-1",
+		InputTokens:  10,
+		Attempt:      2,
+	}); err != nil {
+		t.Fatalf("RecordUsage failed: %v", err)
+	}
+	// Deleting old usage records does not reset the current period
+	if _, err := store.DeleteOlderThan(time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("DeleteOlderThan failed: %v", err)
+	}
+
+	totals, err = cfg.GetBudgetUsage(budget, time.Now())
+	if err != nil {
+		t.Fatalf("GetBudgetUsage failed: %v", err)
+	}
+	if totals.RequestCount != 2 || totals.TotalTokens != 130 {
+		t.Errorf("Expected 2 requests and 130 tokens, got %+v", totals)
+	}
+	if budget.Hard.Exceeded(totals.RequestCount, totals.TotalTokens, totals.CostUSD) == "" {
+		t.Error("Expected hard request limit to be reached")
+	}
+	if budget.Soft.Exceeded(totals.RequestCount, totals.TotalTokens, totals.CostUSD) == "" {
+		t.Error("Expected soft token limit to be crossed")
+	}
+
+	if err := cfg.DeleteBudget(budget.ID); err != nil {
+		t.Fatalf("DeleteBudget failed: %v", err)
+	}
+	if len(cfg.ListBudgets()) != 0 {
+		t.Error("Expected budget to be deleted")
+	}
+}
+
+func TestBudgetPeriod(t *testing.T) {
+	now := time.Date(2025, 3, 15, 13, 30, 0, 0, time.UTC)
+
+	daily := &typ.Budget{Period: typ.BudgetPeriodDaily}
+	if got := daily.PeriodStart(now); !got.Equal(time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected daily period start: %v", got)
+	}
+	if got := daily.PeriodEnd(now); !got.Equal(time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected daily period end: %v", got)
+	}
+
+	monthly := &typ.Budget{Period: typ.BudgetPeriodMonthly}
+	if got := monthly.PeriodStart(now); !got.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected monthly period start: %v", got)
+	}
+	if got := monthly.PeriodEnd(now); !got.Equal(time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected monthly period end: %v", got)
+	}
+
+	if err := (&typ.Budget{Scope: "team", Target: "x", Period: typ.BudgetPeriodDaily}).Validate(); err == nil {
+		t.Error("Expected invalid scope to be rejected")
+	}
+}
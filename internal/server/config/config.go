@@ -20,21 +20,24 @@ import (
 	"github.com/tingly-dev/tingly-box/internal/data/db"
 	"github.com/tingly-dev/tingly-box/internal/loadbalance"
 	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/secretref"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 	"github.com/tingly-dev/tingly-box/pkg/auth"
 )
 
 // Config represents the global configuration
 type Config struct {
-	Rules             []typ.Rule           `yaml:"rules" json:"rules"`                             // List of request configurations
-	DefaultRequestID  int                  `yaml:"default_request_id" json:"default_request_id"`   // Index of the default Rule
-	UserToken         string               `yaml:"user_token" json:"user_token"`                   // User token for UI and control API authentication
-	ModelToken        string               `yaml:"model_token" json:"model_token"`                 // Model token for OpenAI and Anthropic API authentication
-	VirtualModelToken string               `yaml:"virtual_model_token" json:"virtual_model_token"` // Virtual model token for testing (independent from ModelToken)
-	EncryptProviders  bool                 `yaml:"encrypt_providers" json:"encrypt_providers"`     // Whether to encrypt provider info (default false)
-	Scenarios         []typ.ScenarioConfig `yaml:"scenarios" json:"scenarios"`                     // Scenario-specific configurations
-	GUI               GUIConfig            `json:"gui"`                                            // GUI-specific settings
-	RemoteCoder       RemoteCoderConfig    `json:"remote_coder"`                                   // Remote-coder service settings
+	Rules             []typ.Rule           `yaml:"rules" json:"rules"`                                       // List of request configurations
+	DefaultRequestID  int                  `yaml:"default_request_id" json:"default_request_id"`             // Index of the default Rule
+	UserToken         string               `yaml:"user_token" json:"user_token"`                             // User token for UI and control API authentication
+	ModelToken        string               `yaml:"model_token" json:"model_token"`                           // Model token for OpenAI and Anthropic API authentication
+	VirtualModelToken string               `yaml:"virtual_model_token" json:"virtual_model_token"`           // Virtual model token for testing (independent from ModelToken)
+	EncryptProviders  bool                 `yaml:"encrypt_providers" json:"encrypt_providers"`               // Whether to encrypt provider credentials at rest (default false)
+	ProviderKeySource ProviderKeySource    `yaml:"provider_key_source" json:"provider_key_source,omitempty"` // Where the provider encryption key is held: file (default) or keyring
+	ProviderKeyFile   string               `yaml:"provider_key_file" json:"provider_key_file,omitempty"`     // Provider key file, defaults to provider.key in the config directory
+	Scenarios         []typ.ScenarioConfig `yaml:"scenarios" json:"scenarios"`                               // Scenario-specific configurations
+	GUI               GUIConfig            `json:"gui"`                                                      // GUI-specific settings
+	RemoteCoder       RemoteCoderConfig    `json:"remote_coder"`                                             // Remote-coder service settings
 
 	// Merged fields from Config struct
 	ProvidersV1 map[string]*typ.Provider `json:"providers"`
@@ -49,6 +52,18 @@ type Config struct {
 	OpenBrowser      bool `yaml:"-" json:"-"`         // Auto-open browser in web UI mode (default: true)
 	// Tool interceptor (local web_search/web_fetch)
 	ToolInterceptor *typ.ToolInterceptorConfig `json:"tool_interceptor,omitempty"`
+	// Pricing overrides in USD per 1M tokens, keyed by "<provider>:<model>" or "<model>" (take precedence over templates)
+	PricingOverrides map[string]*typ.ModelPricing `json:"pricing_overrides,omitempty"`
+	// Named client API keys for the model endpoints (in addition to ModelToken)
+	APIKeys []*typ.APIKey `json:"api_keys,omitempty"`
+	// Daily/monthly usage budgets per API key or scenario
+	Budgets []*typ.Budget `json:"budgets,omitempty"`
+	// Token-bucket RPM/TPM limits on the model endpoints
+	RateLimits []typ.RateLimitRule `json:"rate_limits,omitempty"`
+	// How long stored Responses API responses are kept, in hours (negative disables storage)
+	ResponseStoreTTLHours int `json:"response_store_ttl_hours"`
+	// Whether proxied responses carry x-tingly-rule / x-tingly-service headers naming the routing decision
+	RouteDecisionHeaders bool `json:"route_decision_headers"`
 
 	// Error log settings
 	ErrorLogFilterExpression string `json:"error_log_filter_expression"` // Expression for filtering error log entries (default: "StatusCode >= 400 && Path matches '^/api/'")
@@ -61,6 +76,8 @@ type Config struct {
 	usageStore      *db.UsageStore
 	ruleStateStore  *db.RuleStateStore // Persists current_service_index to SQLite
 	templateManager *data.TemplateManager
+	providerKeys    [][]byte         // Provider encryption keys, the current one first, loaded on first use
+	keyStore        providerKeyStore // Overrides the provider key store selected in the config
 
 	mu sync.RWMutex
 }
@@ -119,6 +136,7 @@ func NewConfigWithDir(configDir string) (*Config, error) {
 		return nil, fmt.Errorf("failed to initialize usage store: %w", err)
 	}
 	cfg.usageStore = usageStore
+	usageStore.SetCostCalculator(cfg.calculateUsageCost)
 
 	// Initialize rule state store (for persisting current_service_index)
 	ruleStateStore, err := db.NewRuleStateStore(configDir)
@@ -179,6 +197,10 @@ func NewConfigWithDir(configDir string) (*Config, error) {
 		cfg.DefaultMaxTokens = constant.DefaultMaxTokens
 		updated = true
 	}
+	if cfg.ResponseStoreTTLHours == 0 {
+		cfg.ResponseStoreTTLHours = constant.DefaultResponseStoreTTLHours
+		updated = true
+	}
 	if cfg.ErrorLogFilterExpression == "" {
 		cfg.ErrorLogFilterExpression = "StatusCode >= 400 && Path matches '^/api/'"
 		updated = true
@@ -227,9 +249,26 @@ func (c *Config) load() error {
 	// Restore the config file path after unmarshaling
 	c.ConfigFile = configFile
 
+	// Decrypt provider credentials (also migrates them when encryption was just turned off)
+	if err := c.decryptProviderSecrets(); err != nil {
+		return err
+	}
+
+	for _, p := range c.allProviders() {
+		p.NormalizeKeys()
+	}
+
+	// Secret references (env:, file:, exec:) stay in the config as-is; drop their cached
+	// values so that clients created from now on see the current secrets
+	secretref.Reset()
+
 	// Migration: Ensure all rules have a tactic set
 	Migrate(c)
 
+	for i := range c.Rules {
+		c.Rules[i].CompileSmartRouting()
+	}
+
 	return c.RefreshStatsFromStore()
 }
 
@@ -238,7 +277,12 @@ func (c *Config) Save() error {
 	if c.ConfigFile == "" {
 		return fmt.Errorf("ConfigFile is empty")
 	}
-	data, err := json.MarshalIndent(c, "", "    ")
+	// Provider credentials are only encrypted in the file, the in-memory config keeps them in plaintext
+	view, err := c.fileView()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(view, "", "    ")
 	if err != nil {
 		return err
 	}
@@ -296,6 +340,7 @@ func (c *Config) AddRule(rule typ.Rule) error {
 	}
 
 	// If not found, append new config
+	rule.CompileSmartRouting()
 	c.Rules = append(c.Rules, rule)
 	c.DefaultRequestID = len(c.Rules) - 1
 	return c.Save()
@@ -317,6 +362,7 @@ func (c *Config) UpdateRule(uid string, rule typ.Rule) error {
 	// Find existing config with same request model
 	for i, rc := range c.Rules {
 		if rc.UUID == uid {
+			rule.CompileSmartRouting()
 			c.Rules[i] = rule
 			return c.Save()
 		}
@@ -330,6 +376,7 @@ func (c *Config) AddRequestConfig(reqConfig typ.Rule) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	reqConfig.CompileSmartRouting()
 	c.Rules = append(c.Rules, reqConfig)
 	return c.Save()
 }
@@ -453,6 +500,9 @@ func (c *Config) SetRequestConfigs(requestConfigs []typ.Rule) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	for i := range requestConfigs {
+		requestConfigs[i].CompileSmartRouting()
+	}
 	c.Rules = requestConfigs
 
 	return c.Save()
@@ -467,6 +517,7 @@ func (c *Config) UpdateRequestConfigAt(index int, reqConfig typ.Rule) error {
 		return fmt.Errorf("index %d is out of bounds for Rules (length %d)", index, len(c.Rules))
 	}
 
+	reqConfig.CompileSmartRouting()
 	c.Rules[index] = reqConfig
 	return c.Save()
 }
@@ -478,6 +529,7 @@ func (c *Config) UpdateRequestConfigByRequestModel(requestModel string, reqConfi
 
 	for i, rule := range c.Rules {
 		if rule.RequestModel == requestModel {
+			reqConfig.CompileSmartRouting()
 			c.Rules[i] = reqConfig
 			return c.Save()
 		}
@@ -493,6 +545,7 @@ func (c *Config) UpdateRequestConfigByUUID(uuid string, reqConfig typ.Rule) erro
 
 	for i, rule := range c.Rules {
 		if rule.UUID == uuid {
+			reqConfig.CompileSmartRouting()
 			c.Rules[i] = reqConfig
 			return c.Save()
 		}
@@ -506,6 +559,8 @@ func (c *Config) AddOrUpdateRequestConfigByRequestModel(reqConfig typ.Rule) erro
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	reqConfig.CompileSmartRouting()
+
 	for i, rule := range c.Rules {
 		if rule.RequestModel == reqConfig.RequestModel {
 			c.Rules[i] = reqConfig
@@ -770,6 +825,7 @@ func (c *Config) AddProvider(provider *typ.Provider) error {
 	if provider.APIBase == "" {
 		return errors.New("API base URL cannot be empty")
 	}
+	provider.NormalizeKeys()
 
 	c.Providers = append(c.Providers, provider)
 
@@ -785,6 +841,7 @@ func (c *Config) UpdateProvider(uuid string, provider *typ.Provider) error {
 		if p.UUID == uuid {
 			// Preserve the UUID
 			provider.UUID = uuid
+			provider.NormalizeKeys()
 			c.Providers[i] = provider
 			return c.Save()
 		}
@@ -814,6 +871,43 @@ func (c *Config) DeleteProvider(uuid string) error {
 	return fmt.Errorf("provider with UUID '%s' not found", uuid)
 }
 
+// RoutingSnapshot is a copy of the providers, rules, scenarios and tool interceptor settings
+type RoutingSnapshot struct {
+	providers        []*typ.Provider
+	rules            []typ.Rule
+	defaultRequestID int
+	scenarios        []typ.ScenarioConfig
+	toolInterceptor  *typ.ToolInterceptorConfig
+}
+
+// SnapshotRouting copies the routing configuration, so that a change made in several
+// steps can be undone with RestoreRouting
+func (c *Config) SnapshotRouting() *RoutingSnapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return &RoutingSnapshot{
+		providers:        append([]*typ.Provider(nil), c.Providers...),
+		rules:            append([]typ.Rule(nil), c.Rules...),
+		defaultRequestID: c.DefaultRequestID,
+		scenarios:        append([]typ.ScenarioConfig(nil), c.Scenarios...),
+		toolInterceptor:  c.ToolInterceptor,
+	}
+}
+
+// RestoreRouting puts back the routing configuration of a snapshot and saves it
+func (c *Config) RestoreRouting(snapshot *RoutingSnapshot) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Providers = snapshot.providers
+	c.Rules = snapshot.rules
+	c.DefaultRequestID = snapshot.defaultRequestID
+	c.Scenarios = snapshot.scenarios
+	c.ToolInterceptor = snapshot.toolInterceptor
+	return c.Save()
+}
+
 // Server configuration methods (merged from AppConfig)
 
 // GetServerPort returns the configured server port
@@ -849,6 +943,51 @@ func (c *Config) GetDefaultMaxTokens() int {
 	return c.DefaultMaxTokens
 }
 
+// GetResponseStoreTTL returns how long stored Responses API responses are kept.
+// Returns 0 when response storage is disabled.
+func (c *Config) GetResponseStoreTTL() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.ResponseStoreTTLHours <= 0 {
+		return 0
+	}
+	return time.Duration(c.ResponseStoreTTLHours) * time.Hour
+}
+
+// GetResponseStoreTTLHours returns the configured retention of stored Responses API responses, in hours
+func (c *Config) GetResponseStoreTTLHours() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ResponseStoreTTLHours
+}
+
+// SetResponseStoreTTLHours updates the retention of stored Responses API responses
+func (c *Config) SetResponseStoreTTLHours(hours int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ResponseStoreTTLHours = hours
+	return c.Save()
+}
+
+// GetRouteDecisionHeaders returns whether proxied responses carry the routing decision headers
+func (c *Config) GetRouteDecisionHeaders() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.RouteDecisionHeaders
+}
+
+// SetRouteDecisionHeaders updates whether proxied responses carry the routing decision headers
+func (c *Config) SetRouteDecisionHeaders(enabled bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.RouteDecisionHeaders = enabled
+	return c.Save()
+}
+
 // GetToolInterceptorConfig returns the global tool interceptor config
 func (c *Config) GetToolInterceptorConfig() *typ.ToolInterceptorConfig {
 	c.mu.RLock()
@@ -857,6 +996,15 @@ func (c *Config) GetToolInterceptorConfig() *typ.ToolInterceptorConfig {
 	return c.ToolInterceptor
 }
 
+// SetToolInterceptorConfig replaces the global tool interceptor configuration
+func (c *Config) SetToolInterceptorConfig(config *typ.ToolInterceptorConfig) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ToolInterceptor = config
+	return c.Save()
+}
+
 // SetDefaultMaxTokens updates the default max_tokens
 func (c *Config) SetDefaultMaxTokens(maxTokens int) error {
 	c.mu.Lock()
@@ -1037,6 +1185,8 @@ func (c *Config) GetScenarioFlag(scenario typ.RuleScenario, flagName string) boo
 		return flags.Smart
 	case "smart_compact":
 		return flags.SmartCompact
+	case "compact_tool_results":
+		return flags.CompactToolResults
 	case "recording":
 		return flags.Recording
 	case "skill_user":
@@ -1098,6 +1248,8 @@ func (c *Config) SetScenarioFlag(scenario typ.RuleScenario, flagName string, val
 		config.Flags.Smart = value
 	case "smart_compact":
 		config.Flags.SmartCompact = value
+	case "compact_tool_results":
+		config.Flags.CompactToolResults = value
 	case "recording":
 		config.Flags.Recording = value
 	case "skill_user":
@@ -1148,18 +1300,24 @@ func (c *Config) FetchAndSaveProviderModels(uid string) error {
 	var models []string
 	var apiErr error
 
+	// Secret references in the token are resolved for the client only, the config keeps them
+	clientProvider, err := client.ResolveProvider(provider)
+	if err != nil {
+		return fmt.Errorf("failed to resolve token for provider %s: %w", provider.Name, err)
+	}
+
 	// Create appropriate client based on provider API style
 	var lister client.ModelLister
 	switch provider.APIStyle {
 	case protocol.APIStyleAnthropic:
-		aClient, err := client.NewAnthropicClient(provider)
+		aClient, err := client.NewAnthropicClient(clientProvider)
 		if err == nil {
 			defer aClient.Close()
 			lister = aClient
 		}
 		apiErr = err
 	case protocol.APIStyleGoogle:
-		gClient, err := client.NewGoogleClient(provider)
+		gClient, err := client.NewGoogleClient(clientProvider)
 		if err == nil {
 			defer gClient.Close()
 			lister = gClient
@@ -1168,7 +1326,7 @@ func (c *Config) FetchAndSaveProviderModels(uid string) error {
 	case protocol.APIStyleOpenAI:
 		fallthrough
 	default:
-		oClient, err := client.NewOpenAIClient(provider)
+		oClient, err := client.NewOpenAIClient(clientProvider)
 		if err == nil {
 			defer oClient.Close()
 			lister = oClient
@@ -1245,6 +1403,11 @@ func IsTacticValid(tactic *typ.Tactic) bool {
 	case *typ.RandomParams:
 		// Random params has no fields, always valid if not nil
 		return true
+	case *typ.LeastLatencyParams:
+		return p.ToleranceMs >= 0
+	case *typ.LowestCostParams:
+		// Prices are optional overrides, always valid if not nil
+		return true
 	default:
 		// Unknown params type, treat as invalid
 		return false
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"github.com/tingly-dev/tingly-box/internal/data/db"
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// GetModelPricing returns the pricing of a model served by a provider.
+// It checks in order:
+// 1. Config override keyed by "<provider uuid>:<model>"
+// 2. Config override keyed by "<provider name>:<model>"
+// 3. Config override keyed by "<model>"
+// 4. Provider template pricing catalog
+// Returns nil when the model has no known price.
+func (c *Config) GetModelPricing(providerUUID, model string) *typ.ModelPricing {
+	c.mu.RLock()
+	var provider *typ.Provider
+	for _, p := range c.Providers {
+		if p.UUID == providerUUID {
+			provider = p
+			break
+		}
+	}
+
+	if len(c.PricingOverrides) > 0 {
+		keys := []string{providerUUID + ":" + model}
+		if provider != nil {
+			keys = append(keys, provider.Name+":"+model)
+		}
+		keys = append(keys, model)
+		for _, key := range keys {
+			if pricing, ok := c.PricingOverrides[key]; ok && pricing != nil {
+				result := *pricing
+				c.mu.RUnlock()
+				return &result
+			}
+		}
+	}
+	tm := c.templateManager
+	c.mu.RUnlock()
+
+	if provider == nil || tm == nil {
+		return nil
+	}
+	return tm.GetModelPricingByProvider(provider, model)
+}
+
+// GetPricingOverrides returns a copy of the configured pricing overrides
+func (c *Config) GetPricingOverrides() map[string]*typ.ModelPricing {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make(map[string]*typ.ModelPricing, len(c.PricingOverrides))
+	for k, v := range c.PricingOverrides {
+		if v != nil {
+			pricing := *v
+			result[k] = &pricing
+		}
+	}
+	return result
+}
+
+// SetPricingOverride sets the price override for a key ("<provider>:<model>" or "<model>").
+// A nil pricing removes the override.
+func (c *Config) SetPricingOverride(key string, pricing *typ.ModelPricing) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pricing == nil {
+		delete(c.PricingOverrides, key)
+	} else {
+		if c.PricingOverrides == nil {
+			c.PricingOverrides = make(map[string]*typ.ModelPricing)
+		}
+		c.PricingOverrides[key] = pricing
+	}
+	return c.Save()
+}
+
+// ServicePrice returns the blended price of a rule service in USD per 1M tokens,
+// used by the lowest_cost tactic to rank services
+func (c *Config) ServicePrice(service *loadbalance.Service) (float64, bool) {
+	pricing := c.GetModelPricing(service.Provider, service.Model)
+	if pricing == nil {
+		return 0, false
+	}
+	return pricing.BlendedPrice(), true
+}
+
+// calculateUsageCost computes the cost of a usage record from the pricing catalog
+func (c *Config) calculateUsageCost(record *db.UsageRecord) float64 {
+	pricing := c.GetModelPricing(record.ProviderUUID, record.Model)
+	if pricing == nil {
+		return 0
+	}
+	uncachedInput := record.InputTokens - record.CacheReadTokens - record.CacheCreationTokens
+	if uncachedInput < 0 {
+		uncachedInput = 0
+	}
+	return pricing.Cost(uncachedInput, record.OutputTokens, record.CacheReadTokens, record.CacheCreationTokens)
+}
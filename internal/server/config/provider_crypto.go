@@ -0,0 +1,457 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/tingly-dev/tingly-box/internal/secretref"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// Provider credentials are protected with envelope encryption: every value is sealed with
+// its own random data key (AES-256-GCM), and the data key is sealed with the provider key
+// held outside config.json (key file or OS keyring). Encrypted values look like
+//
+//	enc:v1:<key id>:<sealed data key>:<sealed value>
+//
+// The key id is a fingerprint of the provider key, so a wrong key is reported as such.
+const (
+	encryptedValuePrefix = "enc:v1:"
+	providerKeySize      = 32
+)
+
+// ErrProviderKeyNotFound is returned when encrypted credentials are found but the provider key is not available
+var ErrProviderKeyNotFound = errors.New("provider encryption key not found")
+
+// IsEncryptedValue reports whether a config value is an encrypted credential
+func IsEncryptedValue(value string) bool {
+	return strings.HasPrefix(value, encryptedValuePrefix)
+}
+
+// providerKeyID returns the fingerprint of a provider key
+func providerKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// newProviderKey generates a random provider key
+func newProviderKey() ([]byte, error) {
+	key := make([]byte, providerKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate provider key: %w", err)
+	}
+	return key, nil
+}
+
+// gcmSeal encrypts plaintext with AES-256-GCM, returning nonce || ciphertext
+func gcmSeal(key, plaintext, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// gcmOpen decrypts a value produced by gcmSeal
+func gcmOpen(key, sealed, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+// EncryptValue encrypts a credential with the provider key. Empty values and secret
+// references (env:, file:, exec:) are kept as-is, since they hold no secret.
+func EncryptValue(key []byte, plaintext string) (string, error) {
+	if plaintext == "" || IsEncryptedValue(plaintext) || secretref.IsRef(plaintext) {
+		return plaintext, nil
+	}
+
+	keyID := providerKeyID(key)
+	dataKey, err := newProviderKey()
+	if err != nil {
+		return "", err
+	}
+	sealedKey, err := gcmSeal(key, dataKey, []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("failed to seal data key: %w", err)
+	}
+	sealedValue, err := gcmSeal(dataKey, []byte(plaintext), []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("failed to seal value: %w", err)
+	}
+
+	return encryptedValuePrefix + keyID + ":" +
+		base64.RawStdEncoding.EncodeToString(sealedKey) + ":" +
+		base64.RawStdEncoding.EncodeToString(sealedValue), nil
+}
+
+// DecryptValue decrypts a credential produced by EncryptValue. Plaintext values are returned unchanged.
+func DecryptValue(key []byte, value string) (string, error) {
+	if !IsEncryptedValue(value) {
+		return value, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, encryptedValuePrefix), ":")
+	if len(parts) != 3 {
+		return "", errors.New("malformed encrypted value")
+	}
+	keyID := parts[0]
+	if current := providerKeyID(key); keyID != current {
+		return "", fmt.Errorf("value was encrypted with provider key %s, but the available key is %s", keyID, current)
+	}
+
+	sealedKey, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	sealedValue, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+
+	dataKey, err := gcmOpen(key, sealedKey, []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("failed to open data key: %w", err)
+	}
+	plaintext, err := gcmOpen(dataKey, sealedValue, []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// decryptWithKeys decrypts a credential with the key whose id the value names, or reports
+// the mismatch against the current key when none of the keys matches
+func decryptWithKeys(keys [][]byte, value string) (string, error) {
+	if !IsEncryptedValue(value) {
+		return value, nil
+	}
+	keyID, _, _ := strings.Cut(strings.TrimPrefix(value, encryptedValuePrefix), ":")
+	for _, key := range keys {
+		if providerKeyID(key) == keyID {
+			return DecryptValue(key, value)
+		}
+	}
+	return DecryptValue(keys[0], value)
+}
+
+// providerSecrets returns pointers to the credential fields of a provider
+func providerSecrets(p *typ.Provider) []*string {
+	secrets := []*string{&p.Token}
+	for _, k := range p.Keys {
+		if k != nil {
+			secrets = append(secrets, &k.Token)
+		}
+	}
+	if p.OAuthDetail != nil {
+		secrets = append(secrets, &p.OAuthDetail.AccessToken, &p.OAuthDetail.RefreshToken)
+	}
+	return secrets
+}
+
+// hasEncryptedProviderSecrets reports whether any provider credential is encrypted
+func (c *Config) hasEncryptedProviderSecrets() bool {
+	for _, p := range c.allProviders() {
+		for _, secret := range providerSecrets(p) {
+			if IsEncryptedValue(*secret) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allProviders returns the providers of both the current and the legacy provider lists
+func (c *Config) allProviders() []*typ.Provider {
+	providers := make([]*typ.Provider, 0, len(c.Providers)+len(c.ProvidersV1))
+	for _, p := range c.Providers {
+		if p != nil {
+			providers = append(providers, p)
+		}
+	}
+	for _, p := range c.ProvidersV1 {
+		if p != nil {
+			providers = append(providers, p)
+		}
+	}
+	return providers
+}
+
+// decryptProviderSecrets decrypts the provider credentials read from config.json in place.
+// It fails with a descriptive error when the provider key is missing or does not match.
+func (c *Config) decryptProviderSecrets() error {
+	if !c.hasEncryptedProviderSecrets() {
+		return nil
+	}
+
+	keys, err := c.loadProviderKeys()
+	if err != nil {
+		if errors.Is(err, ErrProviderKeyNotFound) {
+			return fmt.Errorf("provider credentials in %s are encrypted but the %w (looked in %s); restore the key, or remove the encrypted values and enter the credentials again",
+				c.ConfigFile, ErrProviderKeyNotFound, c.providerKeyStore().Describe())
+		}
+		return err
+	}
+
+	for _, p := range c.allProviders() {
+		for _, secret := range providerSecrets(p) {
+			plaintext, err := decryptWithKeys(keys, *secret)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt credentials of provider '%s' (key from %s): %w", p.Name, c.providerKeyStore().Describe(), err)
+			}
+			*secret = plaintext
+		}
+	}
+	return nil
+}
+
+// encryptedProviders returns copies of the providers with their credentials encrypted
+func encryptedProviders(key []byte, providers []*typ.Provider) ([]*typ.Provider, error) {
+	out := make([]*typ.Provider, 0, len(providers))
+	for _, p := range providers {
+		if p == nil {
+			out = append(out, nil)
+			continue
+		}
+		clone := *p
+		if p.OAuthDetail != nil {
+			detail := *p.OAuthDetail
+			clone.OAuthDetail = &detail
+		}
+		if p.Keys != nil {
+			clone.Keys = make([]*typ.ProviderKey, len(p.Keys))
+			for i, k := range p.Keys {
+				if k != nil {
+					key := *k
+					clone.Keys[i] = &key
+				}
+			}
+		}
+		for _, secret := range providerSecrets(&clone) {
+			encrypted, err := EncryptValue(key, *secret)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt credentials of provider '%s': %w", p.Name, err)
+			}
+			*secret = encrypted
+		}
+		out = append(out, &clone)
+	}
+	return out, nil
+}
+
+// configFields has the fields of Config without its methods
+type configFields Config
+
+// configFileView is the config as written to config.json when encryption is enabled: the
+// provider lists are replaced by copies with encrypted credentials. Its own provider lists
+// shadow the ones of the embedded config.
+type configFileView struct {
+	*configFields
+	ProvidersV1 map[string]*typ.Provider `json:"providers"`
+	Providers   []*typ.Provider          `json:"providers_v2,omitempty"`
+}
+
+// fileView returns the value to serialize to config.json: the config itself, or a view whose
+// provider lists are encrypted copies when encryption is enabled. The config is left
+// untouched and keeps the plaintext credentials. The caller must hold c.mu.
+func (c *Config) fileView() (interface{}, error) {
+	if !c.EncryptProviders {
+		return c, nil
+	}
+
+	key, err := c.ensureProviderKey()
+	if err != nil {
+		return nil, err
+	}
+
+	providers, err := encryptedProviders(key, c.Providers)
+	if err != nil {
+		return nil, err
+	}
+	var legacy map[string]*typ.Provider
+	if c.ProvidersV1 != nil {
+		legacy = make(map[string]*typ.Provider, len(c.ProvidersV1))
+		for name, p := range c.ProvidersV1 {
+			encrypted, err := encryptedProviders(key, []*typ.Provider{p})
+			if err != nil {
+				return nil, err
+			}
+			legacy[name] = encrypted[0]
+		}
+	}
+
+	return &configFileView{
+		configFields: (*configFields)(c),
+		ProvidersV1:  legacy,
+		Providers:    providers,
+	}, nil
+}
+
+// EncodeForFile serializes the config as it is written to config.json, with the provider
+// credentials encrypted when enabled
+func (c *Config) EncodeForFile(encode func(v interface{}) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	view, err := c.fileView()
+	if err != nil {
+		return err
+	}
+	return encode(view)
+}
+
+// loadProviderKeys returns the provider keys, the current one first, reading them from
+// their store on first use
+func (c *Config) loadProviderKeys() ([][]byte, error) {
+	if c.providerKeys != nil {
+		return c.providerKeys, nil
+	}
+	keys, err := c.providerKeyStore().Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if len(key) != providerKeySize {
+			return nil, fmt.Errorf("invalid provider key in %s: expected %d bytes, got %d", c.providerKeyStore().Describe(), providerKeySize, len(key))
+		}
+	}
+	c.providerKeys = keys
+	return keys, nil
+}
+
+// loadProviderKey returns the current provider key, which credentials are encrypted with
+func (c *Config) loadProviderKey() ([]byte, error) {
+	keys, err := c.loadProviderKeys()
+	if err != nil {
+		return nil, err
+	}
+	return keys[0], nil
+}
+
+// ensureProviderKey returns the provider key, creating it when encryption is used for the first time
+func (c *Config) ensureProviderKey() ([]byte, error) {
+	key, err := c.loadProviderKey()
+	if err == nil || !errors.Is(err, ErrProviderKeyNotFound) {
+		return key, err
+	}
+
+	key, err = newProviderKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.providerKeyStore().Store([][]byte{key}); err != nil {
+		return nil, fmt.Errorf("failed to store provider key in %s: %w", c.providerKeyStore().Describe(), err)
+	}
+	c.providerKeys = [][]byte{key}
+	return key, nil
+}
+
+// IsProviderEncryptionEnabled reports whether provider credentials are encrypted at rest
+func (c *Config) IsProviderEncryptionEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.EncryptProviders
+}
+
+// GetProviderKeyLocation describes where the provider key is held
+func (c *Config) GetProviderKeyLocation() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.providerKeyStore().Describe()
+}
+
+// EnableProviderEncryption encrypts provider credentials at rest, using a key held in the given store.
+// Existing plaintext credentials are migrated on save; a key is generated if the store holds none.
+func (c *Config) EnableProviderEncryption(source ProviderKeySource) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if source == "" {
+		source = ProviderKeySourceFile
+	}
+	if source != ProviderKeySourceFile && source != ProviderKeySourceKeyring {
+		return fmt.Errorf("unknown provider key source: %s", source)
+	}
+	c.ProviderKeySource = source
+	c.providerKeys = nil
+	c.EncryptProviders = true
+	return c.Save()
+}
+
+// DisableProviderEncryption writes provider credentials in plaintext again.
+// The provider key is left in place.
+func (c *Config) DisableProviderEncryption() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.EncryptProviders = false
+	return c.Save()
+}
+
+// RotateProviderKey replaces the provider key and re-encrypts all credentials with the new key.
+// The new key is stored next to the old one before config.json is rewritten, and the old key
+// is dropped after, so that the store always holds the key of the credentials on disk.
+func (c *Config) RotateProviderKey() (oldKeyID, newKeyID string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.EncryptProviders {
+		return "", "", errors.New("provider encryption is not enabled")
+	}
+
+	oldKey, err := c.loadProviderKey()
+	if err != nil {
+		return "", "", err
+	}
+	newKey, err := newProviderKey()
+	if err != nil {
+		return "", "", err
+	}
+
+	store := c.providerKeyStore()
+	if err := store.Store([][]byte{newKey, oldKey}); err != nil {
+		return "", "", fmt.Errorf("failed to store new provider key in %s, the old provider key is kept: %w", store.Describe(), err)
+	}
+	c.providerKeys = [][]byte{newKey, oldKey}
+
+	if err := c.Save(); err != nil {
+		return "", "", fmt.Errorf("failed to save config, both provider keys are kept in %s: %w", store.Describe(), err)
+	}
+
+	if err := store.Store([][]byte{newKey}); err != nil {
+		logrus.Warnf("Failed to remove the old provider key %s from %s, it is removed on the next rotation: %v", providerKeyID(oldKey), store.Describe(), err)
+	} else {
+		c.providerKeys = [][]byte{newKey}
+	}
+
+	return providerKeyID(oldKey), providerKeyID(newKey), nil
+}
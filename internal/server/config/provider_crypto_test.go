@@ -0,0 +1,225 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+func TestEncryptValueRoundTrip(t *testing.T) {
+	key, err := newProviderKey()
+	if err != nil {
+		t.Fatalf("newProviderKey failed: %v", err)
+	}
+
+	encrypted, err := EncryptValue(key, "sk-secret")
+	if err != nil {
+		t.Fatalf("EncryptValue failed: %v", err)
+	}
+	if !IsEncryptedValue(encrypted) || strings.Contains(encrypted, "sk-secret") {
+		t.Fatalf("Expected an encrypted value, got %q", encrypted)
+	}
+
+	again, _ := EncryptValue(key, "sk-secret")
+	if again == encrypted {
+		t.Error("Expected a fresh data key and nonce for every encryption")
+	}
+
+	decrypted, err := DecryptValue(key, encrypted)
+	if err != nil {
+		t.Fatalf("DecryptValue failed: %v", err)
+	}
+	if decrypted != "sk-secret" {
+		t.Errorf("Expected sk-secret, got %q", decrypted)
+	}
+
+	if empty, _ := EncryptValue(key, ""); empty != "" {
+		t.Errorf("Expected empty values to stay empty, got %q", empty)
+	}
+	if plain, _ := DecryptValue(key, "plain"); plain != "plain" {
+		t.Errorf("Expected plaintext values to pass through, got %q", plain)
+	}
+
+	otherKey, _ := newProviderKey()
+	if _, err := DecryptValue(otherKey, encrypted); err == nil || !strings.Contains(err.Error(), providerKeyID(key)) {
+		t.Errorf("Expected a wrong key error naming the key, got %v", err)
+	}
+}
+
+func TestProviderEncryptionLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := NewConfigWithDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	provider := &typ.Provider{
+		UUID:     "p1",
+		Name:     "oauth-provider",
+		APIBase:  "https://api.example.com",
+		Token:    "sk-plain-token",
+		Enabled:  true,
+		AuthType: typ.AuthTypeOAuth,
+		OAuthDetail: &typ.OAuthDetail{
+			AccessToken:  "access-secret",
+			RefreshToken: "refresh-secret",
+		},
+	}
+	if err := cfg.AddProvider(provider); err != nil {
+		t.Fatalf("AddProvider failed: %v", err)
+	}
+
+	// Existing plaintext credentials are migrated when encryption is enabled
+	if err := cfg.EnableProviderEncryption(ProviderKeySourceFile); err != nil {
+		t.Fatalf("EnableProviderEncryption failed: %v", err)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	for _, secret := range []string{"sk-plain-token", "access-secret", "refresh-secret"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("Expected %q to be encrypted in config.json", secret)
+		}
+	}
+	if got, _ := cfg.GetProviderByUUID("p1"); got.GetAccessToken() != "access-secret" {
+		t.Errorf("Expected the in-memory config to keep plaintext credentials, got %q", got.GetAccessToken())
+	}
+
+	// Credentials are decrypted on load, also after a key rotation
+	oldKeyID, newKeyID, err := cfg.RotateProviderKey()
+	if err != nil {
+		t.Fatalf("RotateProviderKey failed: %v", err)
+	}
+	if oldKeyID == newKeyID {
+		t.Error("Expected a new provider key")
+	}
+	reloaded, err := NewConfigWithDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	got, err := reloaded.GetProviderByUUID("p1")
+	if err != nil {
+		t.Fatalf("GetProviderByUUID failed: %v", err)
+	}
+	if got.Token != "sk-plain-token" || got.OAuthDetail.RefreshToken != "refresh-secret" {
+		t.Errorf("Expected decrypted credentials, got %q / %q", got.Token, got.OAuthDetail.RefreshToken)
+	}
+
+	// A key that cannot be stored leaves the config encrypted with the old key
+	keyFile := filepath.Join(dir, providerKeyFileName)
+	if err := os.Mkdir(keyFile+".tmp", 0700); err != nil {
+		t.Fatalf("Failed to block the key file: %v", err)
+	}
+	if _, _, err := reloaded.RotateProviderKey(); err == nil {
+		t.Fatal("Expected RotateProviderKey to fail when the key cannot be stored")
+	}
+	if err := os.Remove(keyFile + ".tmp"); err != nil {
+		t.Fatalf("Failed to unblock the key file: %v", err)
+	}
+	if _, err := NewConfigWithDir(dir); err != nil {
+		t.Fatalf("Expected the config to stay readable with the old key: %v", err)
+	}
+
+	// A missing key is reported clearly
+	if err := os.Remove(filepath.Join(dir, providerKeyFileName)); err != nil {
+		t.Fatalf("Failed to remove key file: %v", err)
+	}
+	if _, err := NewConfigWithDir(dir); err == nil || !errors.Is(err, ErrProviderKeyNotFound) {
+		t.Errorf("Expected ErrProviderKeyNotFound, got %v", err)
+	}
+}
+
+// failingKeyStore wraps a key store and fails the given call to Store
+type failingKeyStore struct {
+	providerKeyStore
+	calls  int
+	failAt int
+}
+
+func (s *failingKeyStore) Store(keys [][]byte) error {
+	s.calls++
+	if s.calls == s.failAt {
+		return errors.New("store unavailable")
+	}
+	return s.providerKeyStore.Store(keys)
+}
+
+func TestRotateProviderKeyStoreFailures(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := NewConfigWithDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	if err := cfg.AddProvider(&typ.Provider{UUID: "p1", Name: "p1", APIBase: "https://api.example.com", Token: "sk-plain-token", Enabled: true}); err != nil {
+		t.Fatalf("AddProvider failed: %v", err)
+	}
+	if err := cfg.EnableProviderEncryption(ProviderKeySourceFile); err != nil {
+		t.Fatalf("EnableProviderEncryption failed: %v", err)
+	}
+	fileStore := cfg.providerKeyStore()
+
+	reloadToken := func() string {
+		t.Helper()
+		reloaded, err := NewConfigWithDir(dir)
+		if err != nil {
+			t.Fatalf("Expected the config to stay readable: %v", err)
+		}
+		p, err := reloaded.GetProviderByUUID("p1")
+		if err != nil {
+			t.Fatalf("GetProviderByUUID failed: %v", err)
+		}
+		return p.Token
+	}
+
+	// The new key cannot be stored: nothing changes
+	cfg.keyStore = &failingKeyStore{providerKeyStore: fileStore, failAt: 1}
+	if _, _, err := cfg.RotateProviderKey(); err == nil {
+		t.Fatal("Expected RotateProviderKey to fail when the new key cannot be stored")
+	}
+	if got := reloadToken(); got != "sk-plain-token" {
+		t.Errorf("Expected the old key to decrypt the config, got %q", got)
+	}
+
+	// The old key cannot be dropped: the rotation succeeds and both keys stay usable
+	cfg.keyStore = &failingKeyStore{providerKeyStore: fileStore, failAt: 2}
+	if _, _, err := cfg.RotateProviderKey(); err != nil {
+		t.Fatalf("RotateProviderKey failed: %v", err)
+	}
+	if keys, err := fileStore.Load(); err != nil || len(keys) != 2 {
+		t.Fatalf("Expected the store to keep both keys, got %d keys (%v)", len(keys), err)
+	}
+	if got := reloadToken(); got != "sk-plain-token" {
+		t.Errorf("Expected the new key to decrypt the config, got %q", got)
+	}
+
+	// A config still encrypted with the previous key, as after a crash before it was
+	// rewritten, is decrypted with the key named in its values
+	raw, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	keys, _ := fileStore.Load()
+	if err := fileStore.Store([][]byte{mustProviderKey(t), keys[0]}); err != nil {
+		t.Fatalf("Failed to store keys: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), raw, 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if got := reloadToken(); got != "sk-plain-token" {
+		t.Errorf("Expected the previous key to decrypt the config, got %q", got)
+	}
+}
+
+func mustProviderKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := newProviderKey()
+	if err != nil {
+		t.Fatalf("newProviderKey failed: %v", err)
+	}
+	return key
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ProviderKeySource is where the provider encryption key is held
+type ProviderKeySource string
+
+const (
+	ProviderKeySourceFile    ProviderKeySource = "file"    // Key file next to config.json (or provider_key_file)
+	ProviderKeySourceKeyring ProviderKeySource = "keyring" // OS keyring (macOS Keychain, Secret Service on Linux)
+)
+
+// providerKeyFileName is the default name of the provider key file in the config directory
+const providerKeyFileName = "provider.key"
+
+// keyringService is the service name of the provider key in the OS keyring
+const keyringService = "tingly-box"
+
+// providerKeyStore loads and stores the provider encryption keys. A store holds the current
+// key first, followed by the previous key while a key rotation is in progress.
+type providerKeyStore interface {
+	// Load returns the keys, or ErrProviderKeyNotFound when the store holds none
+	Load() ([][]byte, error)
+	Store(keys [][]byte) error
+	Describe() string
+}
+
+// encodeProviderKeys serializes provider keys, base64 encoded and separated by commas
+func encodeProviderKeys(keys [][]byte) string {
+	encoded := make([]string, len(keys))
+	for i, key := range keys {
+		encoded[i] = base64.StdEncoding.EncodeToString(key)
+	}
+	return strings.Join(encoded, ",")
+}
+
+// decodeProviderKeys parses keys serialized by encodeProviderKeys, or a single key as
+// written before key rotation kept the previous key
+func decodeProviderKeys(value string) ([][]byte, error) {
+	var keys [][]byte
+	for _, encoded := range strings.Split(strings.TrimSpace(value), ",") {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// providerKeyStore returns the key store selected in the config
+func (c *Config) providerKeyStore() providerKeyStore {
+	if c.keyStore != nil {
+		return c.keyStore
+	}
+	if c.ProviderKeySource == ProviderKeySourceKeyring {
+		return &keyringKeyStore{account: "provider-key:" + c.ConfigDir}
+	}
+	path := c.ProviderKeyFile
+	if path == "" {
+		path = filepath.Join(c.ConfigDir, providerKeyFileName)
+	}
+	return &fileKeyStore{path: path}
+}
+
+// fileKeyStore keeps the key base64 encoded in a file only readable by the owner
+type fileKeyStore struct {
+	path string
+}
+
+func (s *fileKeyStore) Load() ([][]byte, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrProviderKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to read provider key file: %w", err)
+	}
+	keys, err := decodeProviderKeys(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid provider key file %s: %w", s.path, err)
+	}
+	return keys, nil
+}
+
+func (s *fileKeyStore) Store(keys [][]byte) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that an interrupted write never leaves a truncated key
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(encodeProviderKeys(keys)+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *fileKeyStore) Describe() string {
+	return "key file " + s.path
+}
+
+// keyringKeyStore keeps the key in the OS keyring through the platform command line tools,
+// security(1) on macOS and secret-tool(1) from libsecret on Linux
+type keyringKeyStore struct {
+	account string
+}
+
+func (s *keyringKeyStore) Load() ([][]byte, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", s.account, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", s.account)
+	default:
+		return nil, fmt.Errorf("OS keyring is not supported on %s, use the key file instead", runtime.GOOS)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// Both tools exit with a non-zero status when the item does not exist
+			return nil, ErrProviderKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to read provider key from OS keyring: %w", err)
+	}
+
+	value := strings.TrimSpace(stdout.String())
+	if value == "" {
+		return nil, ErrProviderKeyNotFound
+	}
+	keys, err := decodeProviderKeys(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid provider key in OS keyring: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *keyringKeyStore) Store(keys [][]byte) error {
+	encoded := encodeProviderKeys(keys)
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// The command is read from stdin so that the key does not show in the process list
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+			securityQuote(keyringService), securityQuote(s.account), securityQuote(encoded)))
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label=Tingly Box provider key", "service", keyringService, "account", s.account)
+		cmd.Stdin = strings.NewReader(encoded)
+	default:
+		return fmt.Errorf("OS keyring is not supported on %s, use the key file instead", runtime.GOOS)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to write provider key to OS keyring: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	// security -i does not always report a failed command in its exit status, read the key back
+	if stored, err := s.Load(); err != nil || encodeProviderKeys(stored) != encoded {
+		return fmt.Errorf("failed to write provider key to OS keyring: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (s *keyringKeyStore) Describe() string {
+	return fmt.Sprintf("OS keyring (service %s, account %s)", keyringService, s.account)
+}
+
+// securityQuote quotes an argument of a security(1) interactive mode command
+func securityQuote(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// GetRateLimitRules returns a copy of the configured rate limit rules
+func (c *Config) GetRateLimitRules() []typ.RateLimitRule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]typ.RateLimitRule, len(c.RateLimits))
+	copy(result, c.RateLimits)
+	return result
+}
+
+// SetRateLimitRules replaces the rate limit rules
+func (c *Config) SetRateLimitRules(rules []typ.RateLimitRule) error {
+	for _, rule := range rules {
+		switch rule.Scope {
+		case typ.RateLimitScopeAPIKey, typ.RateLimitScopeScenario, typ.RateLimitScopeModel:
+		default:
+			return fmt.Errorf("invalid rate limit scope: %s", rule.Scope)
+		}
+		if rule.RequestsPerMinute < 0 || rule.TokensPerMinute < 0 {
+			return fmt.Errorf("rate limits cannot be negative")
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.RateLimits = rules
+	return c.Save()
+}
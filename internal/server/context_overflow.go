@@ -0,0 +1,358 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/gin-gonic/gin"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/responses"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genai"
+
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/server/middleware"
+	"github.com/tingly-dev/tingly-box/internal/smart_compact"
+	smartrouting "github.com/tingly-dev/tingly-box/internal/smart_routing"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+const (
+	// summaryTTL is how long a conversation summary stays cached after its last use
+	summaryTTL = 2 * time.Hour
+
+	// summaryMaxTokens caps the length of the summaries written by the summarizer rule
+	summaryMaxTokens = 4096
+)
+
+// SummaryStore caches the summaries of conversation prefixes written on context overflow
+type SummaryStore struct {
+	mu        sync.Mutex
+	summaries map[string]*cachedSummary
+}
+
+type cachedSummary struct {
+	summary   string
+	expiresAt time.Time
+}
+
+// NewSummaryStore creates an empty summary store
+func NewSummaryStore() *SummaryStore {
+	return &SummaryStore{
+		summaries: make(map[string]*cachedSummary),
+	}
+}
+
+// Get returns the summary of a conversation prefix and extends its lifetime
+func (st *SummaryStore) Get(key string) (string, bool) {
+	if st == nil {
+		return "", false
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	entry, found := st.summaries[key]
+	if !found {
+		return "", false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(st.summaries, key)
+		return "", false
+	}
+	entry.expiresAt = time.Now().Add(summaryTTL)
+	return entry.summary, true
+}
+
+// Set stores the summary of a conversation prefix
+func (st *SummaryStore) Set(key, summary string) {
+	if st == nil {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.summaries[key] = &cachedSummary{
+		summary:   summary,
+		expiresAt: time.Now().Add(summaryTTL),
+	}
+}
+
+// CleanupExpired removes expired summaries
+func (st *SummaryStore) CleanupExpired() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range st.summaries {
+		if now.After(entry.expiresAt) {
+			delete(st.summaries, key)
+		}
+	}
+}
+
+// StartCleanupTask starts a background task to periodically clean up expired summaries
+func (st *SummaryStore) StartCleanupTask(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			st.CleanupExpired()
+		}
+	}()
+}
+
+// contextWindow returns the context window of a service: the one the provider templates
+// know for the model, else the one configured on the rule, 0 when unknown
+func (s *Server) contextWindow(cfg *typ.ContextOverflowConfig, provider *typ.Provider, model string) int {
+	if window := s.templateManager.GetContextWindowForModelByProvider(provider, model); window > 0 {
+		return window
+	}
+	if cfg != nil {
+		return cfg.ContextWindow
+	}
+	return 0
+}
+
+// estimateRequestTokens estimates the tokens a request needs from the context window:
+// its input, estimated like smart routing does, plus the output tokens it reserves
+func estimateRequestTokens(req interface{}) int {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return 0
+	}
+	tokens := smartrouting.EstimateTokens(string(data))
+
+	switch r := req.(type) {
+	case *anthropic.MessageNewParams:
+		tokens += int(r.MaxTokens)
+	case *anthropic.BetaMessageNewParams:
+		tokens += int(r.MaxTokens)
+	case *openai.ChatCompletionNewParams:
+		if r.MaxCompletionTokens.Valid() {
+			tokens += int(r.MaxCompletionTokens.Value)
+		} else if r.MaxTokens.Valid() {
+			tokens += int(r.MaxTokens.Value)
+		}
+	case *responses.ResponseNewParams:
+		if r.MaxOutputTokens.Valid() {
+			tokens += int(r.MaxOutputTokens.Value)
+		}
+	}
+	return tokens
+}
+
+// routeForContextWindow returns the service to use for a request that does not fit the
+// context window of the selected service: the available service of the rule with the
+// smallest window that fits it. The selected service is returned when the request fits,
+// its window is unknown or no service fits.
+func (s *Server) routeForContextWindow(rule *typ.Rule, req interface{}, provider *typ.Provider, service *loadbalance.Service) (*typ.Provider, *loadbalance.Service) {
+	if rule == nil || !rule.ContextOverflow.IsEnabled() || !rule.ContextOverflow.CanRoute() || provider == nil || service == nil {
+		return provider, service
+	}
+	cfg := rule.ContextOverflow
+
+	window := s.contextWindow(cfg, provider, service.Model)
+	if window == 0 {
+		return provider, service
+	}
+	needed := estimateRequestTokens(req)
+	if needed <= window {
+		return provider, service
+	}
+
+	var bestProvider *typ.Provider
+	var bestService *loadbalance.Service
+	bestWindow := 0
+	for _, svc := range rule.GetAvailableServices() {
+		p, err := s.config.GetProviderByUUID(svc.Provider)
+		if err != nil || !p.Enabled {
+			continue
+		}
+		w := s.contextWindow(cfg, p, svc.Model)
+		if w >= needed && (bestService == nil || w < bestWindow) {
+			bestProvider, bestService, bestWindow = p, svc, w
+		}
+	}
+	if bestService == nil {
+		logrus.Infof("[context_overflow] rule %s: ~%d tokens exceed the %d token window of %s and no service fits", rule.UUID, needed, window, service.Model)
+		return provider, service
+	}
+
+	logrus.Infof("[context_overflow] rule %s: ~%d tokens exceed the %d token window of %s, routing to %s (%d tokens)",
+		rule.UUID, needed, window, service.Model, bestService.Model, bestWindow)
+	return bestProvider, bestService
+}
+
+// summarizeForContextWindow summarizes the oldest rounds of a request that does not fit
+// the context window of the service, with the summarizer rule of the rule. handle applies
+// the summarizer to the request. On failure the request is forwarded as is.
+func (s *Server) summarizeForContextWindow(c *gin.Context, rule *typ.Rule, provider *typ.Provider, model string, req interface{}, handle func(ctx context.Context, summarizer *smart_compact.OverflowSummarizer, overflow int) error) {
+	if rule == nil || !rule.ContextOverflow.IsEnabled() || !rule.ContextOverflow.CanSummarize() {
+		return
+	}
+	cfg := rule.ContextOverflow
+
+	window := s.contextWindow(cfg, provider, model)
+	if window == 0 {
+		return
+	}
+	overflow := estimateRequestTokens(req) - window
+	if overflow <= 0 {
+		return
+	}
+
+	summarizer := smart_compact.NewOverflowSummarizer(cfg.GetKeepLastNRounds(), s.summarizeWithRule(c, cfg.SummarizerRule), s.summaryStore)
+	summarizer.MaxTranscriptTokens = s.summaryTranscriptTokens(cfg)
+	if err := handle(c.Request.Context(), summarizer, overflow); err != nil {
+		logrus.Warnf("[context_overflow] rule %s: %v, forwarding the request as is", rule.UUID, err)
+	}
+}
+
+// summaryTranscriptTokens returns how many transcript tokens a summary request may carry:
+// the smallest context window among the services of the summarizer rule, less the room
+// taken by the prompt, the previous summary and the new one. 0 when no window is known.
+func (s *Server) summaryTranscriptTokens(cfg *typ.ContextOverflowConfig) int {
+	rule := s.config.GetRuleByUUID(cfg.SummarizerRule)
+	if rule == nil {
+		return 0
+	}
+
+	window := 0
+	for _, svc := range rule.GetAvailableServices() {
+		p, err := s.config.GetProviderByUUID(svc.Provider)
+		if err != nil || !p.Enabled {
+			continue
+		}
+		if w := s.templateManager.GetContextWindowForModelByProvider(p, svc.Model); w > 0 && (window == 0 || w < window) {
+			window = w
+		}
+	}
+	if window == 0 {
+		return 0
+	}
+
+	// The previous summary and the new one take up to summaryMaxTokens each
+	reserved := 2*summaryMaxTokens + smartrouting.EstimateTokens(smart_compact.SummarySystemPrompt) + 1024
+	if window <= reserved {
+		return 1
+	}
+	return window - reserved
+}
+
+// summarizeWithRule returns a summarizer sending the summary requests to a service of
+// the rule with the given UUID. The requests are checked against the budgets and rate
+// limits of the client and the summarizer rule, and their usage is recorded against the
+// summarizer rule.
+func (s *Server) summarizeWithRule(c *gin.Context, ruleUUID string) smart_compact.Summarizer {
+	return func(ctx context.Context, previous, transcript string) (string, error) {
+		rule := s.config.GetRuleByUUID(ruleUUID)
+		if rule == nil || !rule.Active {
+			return "", fmt.Errorf("summarizer rule '%s' not found or not active", ruleUUID)
+		}
+		provider, service, err := s.DetermineProviderAndModel(rule)
+		if err != nil {
+			return "", err
+		}
+		// Bound to a key so that the usage is recorded against it
+		provider = s.clientPool.SelectKey(provider)
+
+		scenario := string(rule.GetScenario())
+		if err := s.quotaMW.CheckInternal(c.GetString(middleware.ContextKeyAPIKeyID), scenario); err != nil {
+			return "", err
+		}
+		charge, err := s.rateLimitMW.AllowInternal(c, scenario, rule.RequestModel)
+		if err != nil {
+			return "", err
+		}
+
+		// Track the summary request on its own context so it is recorded against the
+		// summarizer rule and not the client request
+		tc := c.Copy()
+		tc.Set(ContextKeyScenario, scenario)
+		tc.Set(ContextKeyAttempt, 1)
+		tc.Set(ContextKeyCompactSavedBytes, 0)
+		tc.Set(ContextKeyCompactSavedTokens, 0)
+		SetTrackingContext(tc, rule, provider, service.Model, rule.RequestModel, false)
+
+		summary, usage, err := s.sendSummaryRequest(ctx, provider, service.Model, smart_compact.SummaryRequest(previous, transcript))
+		s.trackUsageStatFromContext(tc, usage, err)
+		charge(usage.TotalTokens())
+		return summary, err
+	}
+}
+
+// sendSummaryRequest sends a summary request to a model of the provider, in the API style
+// of the provider, and returns the summary with the usage of the request
+func (s *Server) sendSummaryRequest(ctx context.Context, provider *typ.Provider, model, prompt string) (string, protocol.UsageStat, error) {
+	fc := NewForwardContext(ctx, provider)
+	switch provider.APIStyle {
+	case protocol.APIStyleAnthropic:
+		wrapper := s.clientPool.GetAnthropicClient(provider, model)
+		resp, cancel, err := ForwardAnthropicV1(fc, wrapper, anthropic.MessageNewParams{
+			Model:     anthropic.Model(model),
+			MaxTokens: summaryMaxTokens,
+			System:    []anthropic.TextBlockParam{{Text: smart_compact.SummarySystemPrompt}},
+			Messages:  []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(prompt))},
+		})
+		if err != nil {
+			return "", protocol.ZeroUsageStat(), err
+		}
+		defer cancel()
+
+		var texts []string
+		for _, block := range resp.Content {
+			if block.Type == "text" {
+				texts = append(texts, block.Text)
+			}
+		}
+		return strings.Join(texts, "\n"), protocol.NewUsageStatFromAnthropic(resp.Usage), nil
+
+	case protocol.APIStyleGoogle:
+		wrapper := s.clientPool.GetGoogleClient(provider, model)
+		resp, err := ForwardGoogle(fc, wrapper, model,
+			[]*genai.Content{{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(prompt)}}},
+			&genai.GenerateContentConfig{
+				SystemInstruction: &genai.Content{Parts: []*genai.Part{genai.NewPartFromText(smart_compact.SummarySystemPrompt)}},
+				MaxOutputTokens:   summaryMaxTokens,
+			})
+		if err != nil {
+			return "", protocol.ZeroUsageStat(), err
+		}
+		usage := protocol.NewUsageStatFromGoogle(resp.UsageMetadata)
+		if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+			return "", usage, fmt.Errorf("summarizer returned no candidates")
+		}
+
+		var texts []string
+		for _, part := range resp.Candidates[0].Content.Parts {
+			if part != nil && part.Text != "" && !part.Thought {
+				texts = append(texts, part.Text)
+			}
+		}
+		return strings.Join(texts, ""), usage, nil
+
+	default:
+		wrapper := s.clientPool.GetOpenAIClient(provider, model)
+		resp, err := ForwardOpenAIChat(fc, wrapper, &openai.ChatCompletionNewParams{
+			Model: model,
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				openai.SystemMessage(smart_compact.SummarySystemPrompt),
+				openai.UserMessage(prompt),
+			},
+			MaxTokens: openai.Int(summaryMaxTokens),
+		})
+		if err != nil {
+			return "", protocol.ZeroUsageStat(), err
+		}
+		usage := protocol.NewUsageStatFromOpenAIChat(resp.Usage)
+		if len(resp.Choices) == 0 {
+			return "", usage, fmt.Errorf("summarizer returned no choices")
+		}
+		return resp.Choices[0].Message.Content, usage, nil
+	}
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/param"
+	"google.golang.org/genai"
+
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/protocol/token"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// embeddingsScenario returns the rule scenario of an embeddings request, defaulting
+// to the embeddings scenario on the non-scenario routes
+func embeddingsScenario(c *gin.Context) typ.RuleScenario {
+	if scenario := c.Param("scenario"); scenario != "" {
+		return typ.RuleScenario(scenario)
+	}
+	return typ.ScenarioEmbeddings
+}
+
+// OpenAIEmbeddings handles OpenAI /v1/embeddings requests.
+// The model is resolved through the rules of the scenario like chat requests, Google-style
+// providers are served through Gemini embedContent, and usage is always recorded under the
+// embeddings scenario so that it is not mixed with chat traffic.
+func (s *Server) OpenAIEmbeddings(c *gin.Context) {
+	scenarioType := embeddingsScenario(c)
+	if !isValidRuleScenario(scenarioType) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: ErrorDetail{
+				Message: fmt.Sprintf("invalid scenario: %s", scenarioType),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	var req protocol.EmbeddingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: ErrorDetail{
+				Message: "Invalid request body: " + err.Error(),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	if req.Model == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: ErrorDetail{
+				Message: "Model is required",
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	input, err := req.ParseInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: ErrorDetail{
+				Message: "Invalid input: " + err.Error(),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	switch req.EncodingFormat {
+	case "", protocol.EmbeddingEncodingFloat, protocol.EmbeddingEncodingBase64:
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: ErrorDetail{
+				Message: fmt.Sprintf("Invalid encoding_format: %s", req.EncodingFormat),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	rule, err := s.determineRuleWithScenario(scenarioType, req.Model)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: ErrorDetail{
+				Message: err.Error(),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	if !s.authorizeAPIKeyForRule(c, rule) || !s.rateLimitMW.AllowModel(c, string(req.Model)) {
+		return
+	}
+	provider, selectedService, err := s.DetermineProviderAndModelWithScenario(c, scenarioType, rule, nil)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: ErrorDetail{
+				Message: err.Error(),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	// Record usage under the embeddings scenario whatever route was used
+	c.Set(ContextKeyScenario, string(typ.ScenarioEmbeddings))
+
+	s.serveWithFailover(c, rule, provider, selectedService, func(attempt int, provider *typ.Provider, selectedService *loadbalance.Service) {
+		s.embeddingsWithService(c, &req, input, rule, provider, selectedService)
+	})
+}
+
+// embeddingsWithService forwards an embeddings request to the given service,
+// converting it to the provider's API style
+func (s *Server) embeddingsWithService(c *gin.Context, req *protocol.EmbeddingRequest, input protocol.EmbeddingInput, rule *typ.Rule, provider *typ.Provider, selectedService *loadbalance.Service) {
+	actualModel := selectedService.Model
+	SetTrackingContext(c, rule, provider, actualModel, req.Model, false)
+
+	var (
+		vectors      [][]float64
+		promptTokens int64
+		err          error
+	)
+
+	switch provider.APIStyle {
+	case protocol.APIStyleOpenAI:
+		vectors, promptTokens, err = s.embedViaOpenAI(provider, actualModel, req, input)
+	case protocol.APIStyleGoogle:
+		if input.Tokens != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: ErrorDetail{
+					Message: fmt.Sprintf("Token inputs are not supported by provider '%s', send text inputs instead", provider.Name),
+					Type:    "invalid_request_error",
+				},
+			})
+			return
+		}
+		vectors, promptTokens, err = s.embedViaGoogle(provider, actualModel, req, input)
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: ErrorDetail{
+				Message: fmt.Sprintf("Embeddings are not supported by provider '%s' with API style: %s", provider.Name, provider.APIStyle),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	if err != nil {
+		s.trackUsageFromContext(c, 0, 0, err)
+		sendOpenAIForwardError(c, err)
+		return
+	}
+
+	s.trackUsageFromContext(c, int(promptTokens), 0, nil)
+	c.JSON(http.StatusOK, protocol.NewEmbeddingResponse(req.Model, vectors, promptTokens, req.EncodingFormat))
+}
+
+// embedViaOpenAI creates embeddings with an OpenAI-style provider. Embeddings are always
+// requested as floats; base64 output is encoded by the gateway.
+func (s *Server) embedViaOpenAI(provider *typ.Provider, actualModel string, req *protocol.EmbeddingRequest, input protocol.EmbeddingInput) ([][]float64, int64, error) {
+	params := openai.EmbeddingNewParams{
+		Model:          openai.EmbeddingModel(actualModel),
+		EncodingFormat: openai.EmbeddingNewParamsEncodingFormatFloat,
+	}
+	if input.Tokens != nil {
+		params.Input = openai.EmbeddingNewParamsInputUnion{OfArrayOfTokenArrays: input.Tokens}
+	} else {
+		params.Input = openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: input.Texts}
+	}
+	if req.Dimensions > 0 {
+		params.Dimensions = param.NewOpt(req.Dimensions)
+	}
+	if req.User != "" {
+		params.User = param.NewOpt(req.User)
+	}
+
+	wrapper := s.clientPool.GetOpenAIClient(provider, actualModel)
+	fc := NewForwardContext(nil, provider)
+	resp, err := ForwardOpenAIEmbeddings(fc, wrapper, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	vectors := make([][]float64, input.Len())
+	for _, data := range resp.Data {
+		if int(data.Index) < len(vectors) {
+			vectors[data.Index] = data.Embedding
+		}
+	}
+	return vectors, resp.Usage.PromptTokens, nil
+}
+
+// embedViaGoogle creates embeddings with a Google-style provider, one content per input text.
+// The Gemini API does not report token usage for embeddings, so it is estimated when missing.
+func (s *Server) embedViaGoogle(provider *typ.Provider, actualModel string, req *protocol.EmbeddingRequest, input protocol.EmbeddingInput) ([][]float64, int64, error) {
+	contents := make([]*genai.Content, 0, len(input.Texts))
+	for _, text := range input.Texts {
+		contents = append(contents, &genai.Content{Parts: []*genai.Part{genai.NewPartFromText(text)}})
+	}
+
+	config := &genai.EmbedContentConfig{}
+	if req.Dimensions > 0 {
+		dimensions := int32(req.Dimensions)
+		config.OutputDimensionality = &dimensions
+	}
+
+	wrapper := s.clientPool.GetGoogleClient(provider, actualModel)
+	fc := NewForwardContext(nil, provider)
+	resp, err := ForwardGoogleEmbedContent(fc, wrapper, actualModel, contents, config)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	vectors := make([][]float64, 0, len(resp.Embeddings))
+	var promptTokens int64
+	counted := len(resp.Embeddings) > 0
+	for _, embedding := range resp.Embeddings {
+		vector := make([]float64, len(embedding.Values))
+		for i, v := range embedding.Values {
+			vector[i] = float64(v)
+		}
+		vectors = append(vectors, vector)
+
+		if embedding.Statistics != nil {
+			promptTokens += int64(embedding.Statistics.TokenCount)
+		} else {
+			counted = false
+		}
+	}
+
+	if !counted {
+		promptTokens = 0
+		for _, text := range input.Texts {
+			promptTokens += int64(token.EstimateOutputTokens(text))
+		}
+	}
+	return vectors, promptTokens, nil
+}
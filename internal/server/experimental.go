@@ -1,13 +1,18 @@
 package server
 
 import (
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tingly-dev/tingly-box/internal/smart_compact"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 )
 
 // Experimental feature flag names
 const (
-	ExperimentalFeatureSmartCompact = "smart_compact"
-	ExperimentalFeatureRecording    = "recording"
+	ExperimentalFeatureSmartCompact       = "smart_compact"
+	ExperimentalFeatureCompactToolResults = "compact_tool_results"
+	ExperimentalFeatureRecording          = "recording"
 )
 
 // IsExperimentalFeatureEnabled checks if an experimental feature is enabled for a scenario
@@ -23,7 +28,27 @@ func (s *Server) ApplySmartCompact(scenario typ.RuleScenario) bool {
 	return s.IsExperimentalFeatureEnabled(scenario, ExperimentalFeatureSmartCompact)
 }
 
+// ApplyCompactToolResults checks if tool result compaction should be applied for a scenario
+func (s *Server) ApplyCompactToolResults(scenario typ.RuleScenario) bool {
+	return s.IsExperimentalFeatureEnabled(scenario, ExperimentalFeatureCompactToolResults)
+}
+
 // ApplyRecording checks if recording should be applied for a scenario
 func (s *Server) ApplyRecording(scenario typ.RuleScenario) bool {
 	return s.IsExperimentalFeatureEnabled(scenario, ExperimentalFeatureRecording)
 }
+
+// compactToolResults applies tool result compaction to a request when it is enabled for the
+// scenario, and records the savings in the gin context for the usage record
+func (s *Server) compactToolResults(c *gin.Context, scenario typ.RuleScenario, handle func(tc *smart_compact.ToolResultCompactor) error) {
+	if !s.ApplyCompactToolResults(scenario) {
+		return
+	}
+	tc := smart_compact.NewToolResultCompactor(smart_compact.DefaultToolResultKeepRounds)
+	if err := handle(tc); err != nil {
+		logrus.Debugf("[smart_compact] tool result compaction failed: %v", err)
+		return
+	}
+	c.Set(ContextKeyCompactSavedBytes, tc.Stats.SavedBytes)
+	c.Set(ContextKeyCompactSavedTokens, tc.Stats.SavedTokens)
+}
@@ -0,0 +1,275 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/gin-gonic/gin"
+	"github.com/openai/openai-go/v3"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genai"
+
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// failoverDispatch serves one attempt of a request against the given provider and service.
+type failoverDispatch func(attempt int, provider *typ.Provider, service *loadbalance.Service)
+
+// serveWithFailover dispatches the request to the selected service, bound to the next
+// key of its provider when the provider has several keys. When the rule
+// has failover enabled, a retryable upstream failure that happened before anything
+// was sent to the client causes the request to be dispatched again to the next
+// active service of the rule, with the protocol conversion redone by the handler.
+func (s *Server) serveWithFailover(c *gin.Context, rule *typ.Rule, provider *typ.Provider, service *loadbalance.Service, dispatch failoverDispatch) {
+	if rule == nil || !rule.Failover.IsEnabled() {
+		s.setRouteDecisionHeaders(c, rule, provider, service)
+		dispatch(1, s.clientPool.SelectKey(provider), service)
+		return
+	}
+
+	candidates := rule.FailoverCandidates(service)
+	maxAttempts := rule.Failover.GetMaxAttempts(len(candidates) + 1)
+
+	original := c.Writer
+	defer func() { c.Writer = original }()
+
+	for attempt := 1; ; attempt++ {
+		fw := newFailoverWriter(original)
+		c.Writer = fw
+		c.Set(ContextKeyAttempt, attempt)
+		c.Set(ContextKeyUpstreamError, nil)
+		s.setRouteDecisionHeaders(c, rule, provider, service)
+
+		dispatch(attempt, s.clientPool.SelectKey(provider), service)
+
+		upstreamErr := upstreamErrorFromContext(c)
+		if !fw.held || upstreamErr == nil || !isRetryableUpstreamError(upstreamErr) || attempt >= maxAttempts {
+			fw.commit()
+			return
+		}
+
+		// Pick the next usable service from the rule
+		var nextProvider *typ.Provider
+		var nextService *loadbalance.Service
+		for len(candidates) > 0 {
+			candidate := candidates[0]
+			candidates = candidates[1:]
+			p, err := s.config.GetProviderByUUID(candidate.Provider)
+			if err != nil || !p.Enabled || !candidate.AcquireProbe() {
+				continue
+			}
+			nextProvider, nextService = p, candidate
+			break
+		}
+		if nextService == nil {
+			fw.commit()
+			return
+		}
+
+		logrus.Warnf("[failover] rule %s: service %s failed on attempt %d (%v), retrying with %s",
+			rule.UUID, service.ServiceID(), attempt, upstreamErr, nextService.ServiceID())
+		fw.discard()
+		provider, service = nextProvider, nextService
+	}
+}
+
+// recordUpstreamError stores the upstream error of the current attempt so the
+// failover loop can decide whether the request should be retried elsewhere.
+func recordUpstreamError(c *gin.Context, err error) {
+	if c == nil || err == nil {
+		return
+	}
+	c.Set(ContextKeyUpstreamError, err)
+}
+
+// upstreamErrorFromContext returns the upstream error of the current attempt, if any
+func upstreamErrorFromContext(c *gin.Context) error {
+	if v, exists := c.Get(ContextKeyUpstreamError); exists {
+		if err, ok := v.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// attemptFromContext returns the current failover attempt number (1 when failover is not in use)
+func attemptFromContext(c *gin.Context) int {
+	if v, exists := c.Get(ContextKeyAttempt); exists {
+		if attempt, ok := v.(int); ok && attempt > 0 {
+			return attempt
+		}
+	}
+	return 1
+}
+
+// isRetryableUpstreamError reports whether an upstream error is worth retrying
+// on another service: rate limits, server errors and connection failures.
+func isRetryableUpstreamError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		// The client went away, nobody is waiting for another attempt
+		return false
+	}
+
+	if status := upstreamStatusCode(err); status != 0 {
+		return isRetryableStatus(status)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "connection refused") ||
+		strings.Contains(msg, "connection reset") ||
+		strings.Contains(msg, "no such host")
+}
+
+// isRetryableStatus reports whether an upstream HTTP status should trigger failover
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// upstreamStatusCode extracts the HTTP status code from SDK errors, or 0 if unknown
+func upstreamStatusCode(err error) int {
+	var openaiErr *openai.Error
+	if errors.As(err, &openaiErr) {
+		return openaiErr.StatusCode
+	}
+	var anthropicErr *anthropic.Error
+	if errors.As(err, &anthropicErr) {
+		return anthropicErr.StatusCode
+	}
+	var googleErr genai.APIError
+	if errors.As(err, &googleErr) {
+		return googleErr.Code
+	}
+	var googleErrPtr *genai.APIError
+	if errors.As(err, &googleErrPtr) && googleErrPtr != nil {
+		return googleErrPtr.Code
+	}
+	return 0
+}
+
+// failoverWriter holds back error responses written by a handler so that a
+// failed attempt can be discarded and retried on another service. Successful
+// responses (status < 400) are passed through untouched, including streams.
+type failoverWriter struct {
+	gin.ResponseWriter
+	held   bool
+	status int
+	buf    bytes.Buffer
+	// header is the response header as it was before the attempt started
+	header http.Header
+}
+
+func newFailoverWriter(w gin.ResponseWriter) *failoverWriter {
+	return &failoverWriter{ResponseWriter: w, header: w.Header().Clone()}
+}
+
+func (w *failoverWriter) WriteHeader(code int) {
+	if code >= http.StatusBadRequest && !w.ResponseWriter.Written() {
+		w.held = true
+		w.status = code
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *failoverWriter) WriteHeaderNow() {
+	if w.held {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *failoverWriter) Write(data []byte) (int, error) {
+	if w.held {
+		return w.buf.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *failoverWriter) WriteString(s string) (int, error) {
+	if w.held {
+		return w.buf.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *failoverWriter) Status() int {
+	if w.held {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *failoverWriter) Size() int {
+	if w.held {
+		return w.buf.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+func (w *failoverWriter) Written() bool {
+	return w.held || w.ResponseWriter.Written()
+}
+
+func (w *failoverWriter) Flush() {
+	if w.held {
+		return
+	}
+	w.ResponseWriter.Flush()
+}
+
+// commit sends any held error response to the client
+func (w *failoverWriter) commit() {
+	if !w.held {
+		return
+	}
+	w.held = false
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+}
+
+// discard drops the held error response of a failed attempt and the headers it set
+func (w *failoverWriter) discard() {
+	w.held = false
+	w.status = 0
+	w.buf.Reset()
+
+	header := w.ResponseWriter.Header()
+	for key := range header {
+		if _, ok := w.header[key]; !ok {
+			delete(header, key)
+		}
+	}
+	for key, values := range w.header {
+		header[key] = values
+	}
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/gin-gonic/gin"
+	"github.com/openai/openai-go/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tingly-dev/tingly-box/internal/client"
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+func TestIsRetryableUpstreamError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil error", nil, false},
+		{"client canceled", context.Canceled, false},
+		{"openai rate limited", &openai.Error{StatusCode: http.StatusTooManyRequests}, true},
+		{"openai server error", &openai.Error{StatusCode: http.StatusBadGateway}, true},
+		{"openai bad request", &openai.Error{StatusCode: http.StatusBadRequest}, false},
+		{"anthropic overloaded", &anthropic.Error{StatusCode: 529}, true},
+		{"anthropic unauthorized", &anthropic.Error{StatusCode: http.StatusUnauthorized}, false},
+		{"wrapped server error", fmt.Errorf("forward: %w", &openai.Error{StatusCode: http.StatusServiceUnavailable}), true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"connection refused", errors.New("dial tcp 127.0.0.1:1: connect: connection refused"), true},
+		{"plain error", errors.New("invalid request"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isRetryableUpstreamError(tt.err))
+		})
+	}
+}
+
+func TestFailoverWriter_HoldsErrorResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	fw := newFailoverWriter(c.Writer)
+	c.Writer = fw
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "upstream failed"})
+
+	assert.True(t, fw.held)
+	assert.Equal(t, http.StatusInternalServerError, fw.Status())
+	assert.Empty(t, w.Body.String())
+
+	fw.discard()
+	assert.False(t, fw.held)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestFailoverWriter_DiscardRestoresHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Header("X-Route", "first")
+
+	fw := newFailoverWriter(c.Writer)
+	c.Writer = fw
+	c.Header("X-Route", "second")
+	c.Header("X-Upstream-Request-Id", "abc")
+	c.JSON(http.StatusBadGateway, gin.H{"error": "upstream failed"})
+
+	fw.discard()
+	assert.Equal(t, "first", w.Header().Get("X-Route"))
+	assert.Empty(t, w.Header().Get("X-Upstream-Request-Id"))
+	assert.Empty(t, w.Header().Get("Content-Type"))
+}
+
+func TestFailoverWriter_CommitWritesHeldResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	fw := newFailoverWriter(c.Writer)
+	c.Writer = fw
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limited"})
+	fw.commit()
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "rate limited")
+}
+
+func TestFailoverWriter_PassesThroughSuccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	fw := newFailoverWriter(c.Writer)
+	c.Writer = fw
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+
+	assert.False(t, fw.held)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "ok")
+}
+
+func TestServeWithFailover_Disabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	s := &Server{}
+	rule := &typ.Rule{UUID: "rule-1"}
+	service := &loadbalance.Service{Provider: "p1", Model: "m1", Active: true}
+
+	attempts := 0
+	s.serveWithFailover(c, rule, &typ.Provider{UUID: "p1"}, service, func(attempt int, provider *typ.Provider, service *loadbalance.Service) {
+		attempts++
+		recordUpstreamError(c, &openai.Error{StatusCode: http.StatusServiceUnavailable})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unavailable"})
+	})
+
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestRuleFailoverCandidates(t *testing.T) {
+	rule := &typ.Rule{
+		Services: []*loadbalance.Service{
+			{Provider: "p1", Model: "m1", Active: true},
+			{Provider: "p2", Model: "m2", Active: false},
+			{Provider: "p3", Model: "m3", Active: true},
+			{Provider: "p4", Model: "m4", Active: true},
+		},
+	}
+
+	candidates := rule.FailoverCandidates(rule.Services[2])
+	if assert.Len(t, candidates, 2) {
+		assert.Equal(t, "p4", candidates[0].Provider)
+		assert.Equal(t, "p1", candidates[1].Provider)
+	}
+
+	cfg := &typ.FailoverConfig{Enabled: true, MaxAttempts: 5}
+	assert.Equal(t, 3, cfg.GetMaxAttempts(3))
+	assert.Equal(t, 3, (*typ.FailoverConfig)(nil).GetMaxAttempts(3))
+}
+
+// TestForwardGoogleStream_FirstChunkError verifies that a Google stream whose request fails
+// returns the error before anything is streamed, so that the request can fail over
+func TestForwardGoogleStream_FirstChunkError(t *testing.T) {
+	failing := true
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error": {"code": 429, "message": "quota exceeded", "status": "RESOURCE_EXHAUSTED"}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"candidates\": [{\"content\": {\"role\": \"model\", \"parts\": [{\"text\": \"Hello\"}]}}]}\n\n")
+	}))
+	defer upstream.Close()
+
+	provider := &typ.Provider{UUID: "p1", Name: "gemini", APIBase: upstream.URL, Token: "key"}
+	wrapper, err := client.NewGoogleClient(provider)
+	require.NoError(t, err)
+
+	_, _, err = ForwardGoogleStream(NewForwardContext(context.Background(), provider), wrapper, "gemini-2.5-flash", nil, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, upstreamStatusCode(err))
+	assert.True(t, isRetryableUpstreamError(err))
+
+	failing = false
+	streamResp, cancel, err := ForwardGoogleStream(NewForwardContext(context.Background(), provider), wrapper, "gemini-2.5-flash", nil, nil)
+	require.NoError(t, err)
+	defer cancel()
+
+	var texts []string
+	for resp, err := range streamResp {
+		require.NoError(t, err)
+		texts = append(texts, resp.Candidates[0].Content.Parts[0].Text)
+	}
+	assert.Equal(t, []string{"Hello"}, texts)
+}
@@ -0,0 +1,395 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openai/openai-go/v3/packages/param"
+	"google.golang.org/genai"
+
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/protocol/nonstream"
+	"github.com/tingly-dev/tingly-box/internal/protocol/request"
+	"github.com/tingly-dev/tingly-box/internal/protocol/stream"
+	"github.com/tingly-dev/tingly-box/internal/protocol/token"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+type (
+	// GoogleModel Model types - based on the Gemini models API format
+	GoogleModel struct {
+		Name                       string   `json:"name"`
+		DisplayName                string   `json:"displayName"`
+		SupportedGenerationMethods []string `json:"supportedGenerationMethods"`
+	}
+	GoogleModelsResponse struct {
+		Models []GoogleModel `json:"models"`
+	}
+)
+
+// GoogleModelAction handles Gemini REST calls of the form "models/{model}:{method}".
+// generateContent, streamGenerateContent and countTokens are supported; they go
+// through the same rule and load balancing path as the other endpoints, to any
+// provider style.
+func (s *Server) GoogleModelAction(c *gin.Context) {
+	model, method, ok := parseGoogleModelAction(c.Param("action"))
+	if !ok {
+		sendGoogleError(c, http.StatusNotFound, fmt.Sprintf("unsupported path: %s", c.Request.URL.Path))
+		return
+	}
+
+	switch method {
+	case protocol.GoogleMethodGenerateContent:
+		s.googleGenerateContent(c, model, false)
+	case protocol.GoogleMethodStreamGenerateContent:
+		s.googleGenerateContent(c, model, true)
+	case protocol.GoogleMethodCountTokens:
+		s.googleCountTokens(c, model)
+	default:
+		sendGoogleError(c, http.StatusNotFound, fmt.Sprintf("unsupported method: %s", method))
+	}
+}
+
+// parseGoogleModelAction splits "{model}:{method}" (optionally prefixed by "models/")
+func parseGoogleModelAction(action string) (model, method string, ok bool) {
+	action = strings.TrimPrefix(action, "/")
+	action = strings.TrimPrefix(action, "models/")
+	idx := strings.LastIndex(action, ":")
+	if idx <= 0 || idx == len(action)-1 {
+		return "", "", false
+	}
+	return action[:idx], action[idx+1:], true
+}
+
+// googleScenario returns the scenario of a Google request, defaulting to the
+// google scenario on the non-scenario routes
+func googleScenario(c *gin.Context) typ.RuleScenario {
+	if scenario := c.Param("scenario"); scenario != "" {
+		return typ.RuleScenario(scenario)
+	}
+	return typ.ScenarioGoogle
+}
+
+// googleGenerateContent handles generateContent and streamGenerateContent requests
+func (s *Server) googleGenerateContent(c *gin.Context, model string, isStreaming bool) {
+	scenarioType := googleScenario(c)
+	if !isValidRuleScenario(scenarioType) {
+		sendGoogleError(c, http.StatusBadRequest, fmt.Sprintf("invalid scenario: %s", scenarioType))
+		return
+	}
+
+	bodyBytes, err := c.GetRawData()
+	if err != nil {
+		sendGoogleError(c, http.StatusBadRequest, "Failed to read request body: "+err.Error())
+		return
+	}
+
+	var req protocol.GoogleGenerateContentRequest
+	if err := json.Unmarshal(bodyBytes, &req); err != nil {
+		sendGoogleError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if len(req.Contents) == 0 {
+		sendGoogleError(c, http.StatusBadRequest, "contents is required")
+		return
+	}
+
+	rule, err := s.determineRuleWithScenario(scenarioType, model)
+	if err != nil {
+		sendGoogleError(c, http.StatusNotFound, err.Error())
+		return
+	}
+	if !s.authorizeAPIKeyForRule(c, rule) || !s.rateLimitMW.AllowModel(c, model) {
+		return
+	}
+
+	// Smart routing inspects the request in OpenAI form
+	reqParams := request.ConvertGoogleToOpenAIRequest(model, req.Contents, req.Config())
+	provider, selectedService, err := s.DetermineProviderAndModelWithScenario(c, scenarioType, rule, reqParams)
+	if err != nil {
+		sendGoogleError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.Set("rule", rule)
+
+	s.serveWithFailover(c, rule, provider, selectedService, func(attempt int, provider *typ.Provider, selectedService *loadbalance.Service) {
+		if attempt > 1 {
+			// Earlier attempts may have mutated the request, start from the original body
+			req = protocol.GoogleGenerateContentRequest{}
+			if err := json.Unmarshal(bodyBytes, &req); err != nil {
+				return
+			}
+		}
+		s.googleGenerateContentWithService(c, req, model, provider, selectedService, rule, isStreaming)
+	})
+}
+
+// googleGenerateContentWithService forwards a generateContent request to the given service,
+// converting it to the provider's API style
+func (s *Server) googleGenerateContentWithService(c *gin.Context, req protocol.GoogleGenerateContentRequest, proxyModel string, provider *typ.Provider, selectedService *loadbalance.Service, rule *typ.Rule, isStreaming bool) {
+	actualModel := selectedService.Model
+
+	// Set tracking context with all metadata (eliminates need for explicit parameter passing)
+	SetTrackingContext(c, rule, provider, actualModel, proxyModel, isStreaming)
+	c.Set("provider", provider.UUID)
+	c.Set("model", actualModel)
+
+	config := req.Config()
+
+	switch provider.APIStyle {
+	default:
+		sendGoogleError(c, http.StatusBadRequest, fmt.Sprintf("Unsupported API style: %s %s", provider.Name, provider.APIStyle))
+		return
+
+	case protocol.APIStyleGoogle:
+		wrapper := s.clientPool.GetGoogleClient(provider, actualModel)
+		if isStreaming {
+			fc := NewForwardContext(c.Request.Context(), provider)
+			streamResp, cancel, err := ForwardGoogleStream(fc, wrapper, actualModel, req.Contents, config)
+			if err != nil {
+				s.trackUsageFromContext(c, 0, 0, err)
+				sendGoogleUpstreamError(c, err)
+				return
+			}
+			defer cancel()
+
+			usage, err := stream.HandleGooglePassthroughStreamResponse(c, streamResp, proxyModel)
+			s.trackUsageStatFromContext(c, usage, err)
+			return
+		}
+
+		fc := NewForwardContext(nil, provider)
+		googleResp, err := ForwardGoogle(fc, wrapper, actualModel, req.Contents, config)
+		if err != nil {
+			s.trackUsageFromContext(c, 0, 0, err)
+			sendGoogleUpstreamError(c, err)
+			return
+		}
+		s.trackUsageStatFromContext(c, protocol.NewUsageStatFromGoogle(googleResp.UsageMetadata), nil)
+
+		googleResp.ModelVersion = proxyModel
+		c.JSON(http.StatusOK, googleResp)
+
+	case protocol.APIStyleAnthropic:
+		anthropicReq := request.ConvertGoogleToAnthropicRequest(actualModel, req.Contents, config)
+		// Cap max_tokens at the model's maximum to prevent API errors
+		if maxAllowed := int64(s.templateManager.GetMaxTokensForModelByProvider(provider, actualModel)); maxAllowed > 0 && anthropicReq.MaxTokens > maxAllowed {
+			anthropicReq.MaxTokens = maxAllowed
+		}
+
+		wrapper := s.clientPool.GetAnthropicClient(provider, actualModel)
+		if isStreaming {
+			fc := NewForwardContext(c.Request.Context(), provider)
+			streamResp, cancel, err := ForwardAnthropicV1Stream(fc, wrapper, anthropicReq)
+			if err != nil {
+				s.trackUsageFromContext(c, 0, 0, err)
+				sendGoogleUpstreamError(c, err)
+				return
+			}
+			defer cancel()
+
+			usage, err := stream.HandleAnthropicToGoogleStreamResponse(c, streamResp, proxyModel)
+			s.trackUsageStatFromContext(c, usage, err)
+			return
+		}
+
+		fc := NewForwardContext(nil, provider)
+		anthropicResp, cancel, err := ForwardAnthropicV1(fc, wrapper, anthropicReq)
+		if err != nil {
+			s.trackUsageFromContext(c, 0, 0, err)
+			sendGoogleUpstreamError(c, err)
+			return
+		}
+		defer cancel()
+		s.trackUsageStatFromContext(c, protocol.NewUsageStatFromAnthropic(anthropicResp.Usage), nil)
+
+		googleResp := nonstream.ConvertAnthropicToGoogleResponse(anthropicResp)
+		googleResp.ModelVersion = proxyModel
+		c.JSON(http.StatusOK, googleResp)
+
+	case protocol.APIStyleOpenAI:
+		openaiReq := request.ConvertGoogleToOpenAIRequest(actualModel, req.Contents, config)
+
+		wrapper := s.clientPool.GetOpenAIClient(provider, actualModel)
+		if isStreaming {
+			// force to return usage
+			openaiReq.StreamOptions.IncludeUsage = param.Opt[bool]{Value: true}
+
+			fc := NewForwardContext(c.Request.Context(), provider)
+			streamResp, cancel, err := ForwardOpenAIChatStream(fc, wrapper, openaiReq)
+			if err != nil {
+				s.trackUsageFromContext(c, 0, 0, err)
+				sendGoogleUpstreamError(c, err)
+				return
+			}
+			defer cancel()
+
+			usage, err := stream.HandleOpenAIToGoogleStreamResponse(c, streamResp, proxyModel)
+			s.trackUsageStatFromContext(c, usage, err)
+			return
+		}
+
+		fc := NewForwardContext(nil, provider)
+		openaiResp, err := ForwardOpenAIChat(fc, wrapper, openaiReq)
+		if err != nil {
+			s.trackUsageFromContext(c, 0, 0, err)
+			sendGoogleUpstreamError(c, err)
+			return
+		}
+		s.trackUsageStatFromContext(c, protocol.NewUsageStatFromOpenAIChat(openaiResp.Usage), nil)
+
+		googleResp := nonstream.ConvertOpenAIToGoogleResponse(openaiResp)
+		googleResp.ModelVersion = proxyModel
+		c.JSON(http.StatusOK, googleResp)
+	}
+}
+
+// googleCountTokens handles countTokens requests. Google providers count through
+// their API; other providers are estimated with tiktoken.
+func (s *Server) googleCountTokens(c *gin.Context, model string) {
+	scenarioType := googleScenario(c)
+	if !isValidRuleScenario(scenarioType) {
+		sendGoogleError(c, http.StatusBadRequest, fmt.Sprintf("invalid scenario: %s", scenarioType))
+		return
+	}
+
+	var countReq protocol.GoogleCountTokensRequest
+	if err := c.ShouldBindJSON(&countReq); err != nil {
+		sendGoogleError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	req := countReq.GenerateContent()
+
+	rule, err := s.determineRuleWithScenario(scenarioType, model)
+	if err != nil {
+		sendGoogleError(c, http.StatusNotFound, err.Error())
+		return
+	}
+	if !s.authorizeAPIKeyForRule(c, rule) || !s.rateLimitMW.AllowModel(c, model) {
+		return
+	}
+	provider, service, err := s.DetermineProviderAndModel(rule)
+	if err != nil {
+		sendGoogleError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.Set("provider", provider.UUID)
+	c.Set("model", service.Model)
+
+	if provider.APIStyle == protocol.APIStyleGoogle {
+		provider = s.clientPool.SelectKey(provider)
+		wrapper := s.clientPool.GetGoogleClient(provider, service.Model)
+		if wrapper == nil {
+			sendGoogleError(c, http.StatusInternalServerError, fmt.Sprintf("failed to get Google client for provider: %s", provider.Name))
+			return
+		}
+
+		timeout := time.Duration(provider.Timeout) * time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		resp, err := wrapper.Client().Models.CountTokens(ctx, service.Model, req.Contents, &genai.CountTokensConfig{
+			SystemInstruction: req.SystemInstruction,
+			Tools:             req.Tools,
+		})
+		s.recordProviderKeyResult(provider, service.Model, protocol.UsageStat{}, err)
+		if err != nil {
+			sendGoogleUpstreamError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, protocol.GoogleCountTokensResponse{
+			TotalTokens:             resp.TotalTokens,
+			CachedContentTokenCount: resp.CachedContentTokenCount,
+		})
+		return
+	}
+
+	count, err := token.EstimateInputTokens(request.ConvertGoogleToOpenAIRequest(service.Model, req.Contents, req.Config()))
+	if err != nil {
+		sendGoogleError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, protocol.GoogleCountTokensResponse{TotalTokens: int32(count)})
+}
+
+// GoogleListModels handles the Gemini models endpoint
+func (s *Server) GoogleListModels(c *gin.Context) {
+	s.googleListModelsWithScenario(c, googleScenario(c))
+}
+
+func (s *Server) googleListModelsWithScenario(c *gin.Context, scenario typ.RuleScenario) {
+	cfg := s.config
+	if cfg == nil {
+		sendGoogleError(c, http.StatusInternalServerError, "Config not available")
+		return
+	}
+
+	models := []GoogleModel{}
+	for _, rule := range cfg.GetRequestConfigs() {
+		if !rule.Active || rule.GetScenario() != scenario {
+			continue
+		}
+		models = append(models, GoogleModel{
+			Name:        "models/" + rule.RequestModel,
+			DisplayName: rule.RequestModel,
+			SupportedGenerationMethods: []string{
+				protocol.GoogleMethodGenerateContent,
+				protocol.GoogleMethodStreamGenerateContent,
+				protocol.GoogleMethodCountTokens,
+			},
+		})
+	}
+
+	c.JSON(http.StatusOK, GoogleModelsResponse{Models: models})
+}
+
+// sendGoogleUpstreamError writes an upstream failure in the Google error format,
+// keeping the upstream status code when known
+func sendGoogleUpstreamError(c *gin.Context, err error) {
+	status := upstreamStatusCode(err)
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	sendGoogleError(c, status, err.Error())
+}
+
+// sendGoogleError writes an error in the Google API error format
+func sendGoogleError(c *gin.Context, status int, message string) {
+	c.JSON(status, gin.H{
+		"error": gin.H{
+			"code":    status,
+			"message": message,
+			"status":  googleErrorStatus(status),
+		},
+	})
+}
+
+// googleErrorStatus maps an HTTP status to the canonical Google error status
+func googleErrorStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
+	default:
+		return "INTERNAL"
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGoogleModelAction(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     string
+		wantModel  string
+		wantMethod string
+		wantOK     bool
+	}{
+		{"generate content", "gemini-2.5-pro:generateContent", "gemini-2.5-pro", "generateContent", true},
+		{"leading slash", "/gemini-2.5-flash:streamGenerateContent", "gemini-2.5-flash", "streamGenerateContent", true},
+		{"models prefix", "models/gemini-2.5-flash:countTokens", "gemini-2.5-flash", "countTokens", true},
+		{"model with colon", "tuned:v1:generateContent", "tuned:v1", "generateContent", true},
+		{"missing method", "gemini-2.5-pro", "", "", false},
+		{"empty method", "gemini-2.5-pro:", "", "", false},
+		{"empty model", ":generateContent", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, method, ok := parseGoogleModelAction(tt.action)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantModel, model)
+			assert.Equal(t, tt.wantMethod, method)
+		})
+	}
+}
+
+func TestGoogleErrorStatus(t *testing.T) {
+	assert.Equal(t, "INVALID_ARGUMENT", googleErrorStatus(400))
+	assert.Equal(t, "UNAUTHENTICATED", googleErrorStatus(401))
+	assert.Equal(t, "NOT_FOUND", googleErrorStatus(404))
+	assert.Equal(t, "RESOURCE_EXHAUSTED", googleErrorStatus(429))
+	assert.Equal(t, "INTERNAL", googleErrorStatus(500))
+}
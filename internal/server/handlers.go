@@ -8,7 +8,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/tingly-dev/tingly-box/internal/loadbalance"
-	smartrouting "github.com/tingly-dev/tingly-box/internal/smart_routing"
+	"github.com/tingly-dev/tingly-box/internal/server/middleware"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 )
 
@@ -114,10 +114,78 @@ func (s *Server) GetToken(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// DetermineProviderAndModelWithScenario
-func (s *Server) DetermineProviderAndModelWithScenario(scenario typ.RuleScenario, rule *typ.Rule, req interface{}) (*typ.Provider, *loadbalance.Service, error) {
+// DetermineProviderAndModelWithScenario selects the provider and service serving a request
+func (s *Server) DetermineProviderAndModelWithScenario(c *gin.Context, scenario typ.RuleScenario, rule *typ.Rule, req interface{}) (*typ.Provider, *loadbalance.Service, error) {
+	provider, service, _, err := s.routeRequest(c, rule, req, false)
+	return provider, service, err
+}
+
+// routeDecision describes how the service of a request was selected
+type routeDecision struct {
+	source          string                 // session_affinity, smart_routing or load_balancer
+	candidates      []*loadbalance.Service // Services the service was selected from
+	contextOverflow bool                   // Moved to a service whose context window fits the request
+}
+
+// routeRequest selects the service of a request: the service its conversation is pinned
+// to, else one selected through smart routing and load balancing, moved to a service
+// with a larger context window when the request does not fit. The probe of a half-open
+// service is claimed for the request. A dry run makes the same decision without pinning
+// the conversation, advancing the load balancer or claiming a probe.
+func (s *Server) routeRequest(c *gin.Context, rule *typ.Rule, req interface{}, dryRun bool) (*typ.Provider, *loadbalance.Service, *routeDecision, error) {
+	decision := &routeDecision{}
+	var provider *typ.Provider
+	var service *loadbalance.Service
+
+	// Session affinity: keep a conversation on the service it started on
+	affinity := rule.SessionAffinity
+	var sessionKey string
+	if affinity.IsEnabled() {
+		sessionKey = s.sessionKey(c, affinity, req)
+		if sessionKey != "" {
+			if provider, service = s.pinnedService(rule, sessionKey, dryRun); service != nil {
+				logrus.Debugf("[session_affinity] rule %s: using pinned service %s -> %s", rule.UUID, provider.Name, service.Model)
+				decision.source = RouteSourceSessionAffinity
+				decision.candidates = []*loadbalance.Service{service}
+			}
+		}
+	}
+
+	if service == nil {
+		var err error
+		provider, service, err = s.selectProviderAndService(c, rule, req, dryRun, decision)
+		if err != nil {
+			return nil, nil, decision, err
+		}
+		if sessionKey != "" && !dryRun {
+			s.sessionAffinity.Pin(rule.UUID, sessionKey, service.ServiceID(), affinity.GetTTL())
+		}
+	}
+
+	routedProvider, routedService := s.routeForContextWindow(rule, req, provider, service)
+	if !dryRun && !routedService.AcquireProbe() {
+		// A concurrent request claimed the probe of the half-open service since it was
+		// selected: select again, which skips the service until the probe resolves
+		var err error
+		provider, service, err = s.selectProviderAndService(c, rule, req, dryRun, decision)
+		if err != nil {
+			return nil, nil, decision, err
+		}
+		routedProvider, routedService = s.routeForContextWindow(rule, req, provider, service)
+		if !routedService.AcquireProbe() {
+			// Only half-open services were left, each with its probe in flight
+			return nil, nil, decision, fmt.Errorf("no available service for request model '%s': the probe of service %s is in flight", rule.RequestModel, routedService.ServiceID())
+		}
+	}
+	decision.contextOverflow = routedService != service
+	return routedProvider, routedService, decision, nil
+}
+
+// selectProviderAndService selects the service of a rule through smart routing and load
+// balancing. A dry run previews the selection without advancing the load balancer.
+func (s *Server) selectProviderAndService(c *gin.Context, rule *typ.Rule, req interface{}, dryRun bool, decision *routeDecision) (*typ.Provider, *loadbalance.Service, error) {
 	modelName := rule.RequestModel
-	c := s.config
+	cfg := s.config
 	var selectedService *loadbalance.Service
 	var err error
 
@@ -126,20 +194,26 @@ func (s *Server) DetermineProviderAndModelWithScenario(scenario typ.RuleScenario
 		logrus.Debugf("[smart_routing] smart routing enabled for model %s", modelName)
 
 		// Extract context from request (type switch handles different request types)
-		ctx, err := s.ExtractRequestContext(req)
+		ctx, err := s.ExtractRequestContext(c, req)
 		if err == nil && ctx != nil {
 			// Create router and evaluate
-			router, err := smartrouting.NewRouter(rule.SmartRouting)
+			router, err := rule.SmartRouter()
 			if err == nil {
 				if matchedServices, matched := router.EvaluateRequest(ctx); matched && len(matchedServices) > 0 {
 					logrus.Debugf("[smart_routing] rule matched for model %s, selecting from %d services", modelName, len(matchedServices))
 					// Select service from matched services using load balancing
-					selectedService, err = s.SelectServiceFromSmartRouting(matchedServices, rule)
+					if dryRun {
+						selectedService = s.previewSmartRoutingService(matchedServices, rule)
+					} else {
+						selectedService, err = s.SelectServiceFromSmartRouting(matchedServices, rule)
+					}
 					if err == nil && selectedService != nil {
 						// Verify the provider exists and is enabled
-						provider, err := c.GetProviderByUUID(selectedService.Provider)
+						provider, err := cfg.GetProviderByUUID(selectedService.Provider)
 						if err == nil && provider.Enabled {
 							logrus.Infof("[smart_routing] using smart routed service: %s -> %s", provider.Name, selectedService.Model)
+							decision.source = RouteSourceSmartRouting
+							decision.candidates = matchedServices
 							return provider, selectedService, nil
 						}
 					}
@@ -156,7 +230,13 @@ func (s *Server) DetermineProviderAndModelWithScenario(scenario typ.RuleScenario
 	}
 
 	// Normal load balancing path
-	selectedService, err = s.loadBalancer.SelectService(rule)
+	decision.source = RouteSourceLoadBalancer
+	decision.candidates = rule.GetServices()
+	if dryRun {
+		selectedService, err = s.loadBalancer.PreviewService(rule)
+	} else {
+		selectedService, err = s.loadBalancer.SelectService(rule)
+	}
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to select service: %w", err)
 	}
@@ -166,7 +246,7 @@ func (s *Server) DetermineProviderAndModelWithScenario(scenario typ.RuleScenario
 	}
 
 	// Verify the provider exists and is enabled
-	provider, err := c.GetProviderByUUID(selectedService.Provider)
+	provider, err := cfg.GetProviderByUUID(selectedService.Provider)
 	if err != nil {
 		return nil, nil, fmt.Errorf("provider '%s' not found: %w", selectedService.Provider, err)
 	}
@@ -174,13 +254,16 @@ func (s *Server) DetermineProviderAndModelWithScenario(scenario typ.RuleScenario
 	if !provider.Enabled {
 		return nil, nil, fmt.Errorf("provider '%s' is not enabled", selectedService.Provider)
 	}
+	if dryRun {
+		return provider, selectedService, nil
+	}
 
 	// Update the current service index for the rule
 	s.loadBalancer.UpdateServiceIndex(rule, selectedService)
 
 	// Persist the updated CurrentServiceID to SQLite (not config.json)
 	// This is critical for round-robin to work correctly across requests
-	if err := c.SaveCurrentServiceID(rule.UUID, rule.CurrentServiceID); err != nil {
+	if err := cfg.SaveCurrentServiceID(rule.UUID, rule.CurrentServiceID); err != nil {
 		// Log error but don't fail the request
 		fmt.Printf("Warning: failed to persist CurrentServiceID: %v\n", err)
 	}
@@ -232,6 +315,25 @@ func (s *Server) DetermineProviderAndModel(rule *typ.Rule) (*typ.Provider, *load
 	return provider, selectedService, nil
 }
 
+// authorizeAPIKeyForRule checks the scopes of the API key the request was authenticated with
+// against the resolved rule, writing a permission error when the key may not use it.
+// Requests authenticated with the global model token are always allowed.
+func (s *Server) authorizeAPIKeyForRule(c *gin.Context, rule *typ.Rule) bool {
+	key := middleware.APIKeyFromContext(c)
+	if key == nil || rule == nil {
+		return true
+	}
+
+	if key.AllowsScenario(rule.GetScenario()) && key.AllowsRule(rule) {
+		return true
+	}
+
+	middleware.WriteProtocolError(c, http.StatusForbidden,
+		fmt.Sprintf("API key '%s' is not allowed to use model '%s'", key.Name, rule.RequestModel),
+		"permission_error", "")
+	return false
+}
+
 func (s *Server) determineRule(modelName string) (*typ.Rule, error) {
 	c := s.config
 	if c != nil && c.IsRequestModel(modelName) {
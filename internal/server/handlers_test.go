@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/server/middleware"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// TestAuthorizeAPIKeyForRule_ProtocolErrors verifies that a key which may not use a rule
+// gets a 403 in the error format of the API the request targets
+func TestAuthorizeAPIKeyForRule_ProtocolErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rule := &typ.Rule{UUID: "rule-1", RequestModel: "tingly-claude"}
+	key := &typ.APIKey{ID: "key-1", Name: "ci-bot", Models: []string{"tingly-gpt"}}
+
+	tests := []struct {
+		name  string
+		path  string
+		check func(t *testing.T, body map[string]interface{})
+	}{
+		{
+			name: "anthropic",
+			path: "/tingly/claude_code/v1/messages",
+			check: func(t *testing.T, body map[string]interface{}) {
+				assert.Equal(t, "error", body["type"])
+				assert.Equal(t, "permission_error", body["error"].(map[string]interface{})["type"])
+			},
+		},
+		{
+			name: "google",
+			path: "/tingly/google/v1beta/models/tingly-claude:generateContent",
+			check: func(t *testing.T, body map[string]interface{}) {
+				assert.Equal(t, "PERMISSION_DENIED", body["error"].(map[string]interface{})["status"])
+			},
+		},
+		{
+			name: "openai",
+			path: "/tingly/openai/v1/chat/completions",
+			check: func(t *testing.T, body map[string]interface{}) {
+				assert.Equal(t, "permission_error", body["error"].(map[string]interface{})["type"])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, tt.path, nil)
+			c.Set(middleware.ContextKeyAPIKey, key)
+
+			assert.False(t, (&Server{}).authorizeAPIKeyForRule(c, rule))
+			assert.Equal(t, http.StatusForbidden, w.Code)
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Contains(t, body["error"].(map[string]interface{})["message"], "ci-bot")
+			tt.check(t, body)
+		})
+	}
+}
+
+// TestRouteRequest_ProbeInFlight verifies that a request is not sent to a half-open service
+// whose probe another request claimed, even when no other service is available
+func TestRouteRequest_ProbeInFlight(t *testing.T) {
+	s, rule := newSessionAffinityServer(t)
+	for _, service := range rule.Services {
+		service.InitializeStats()
+		service.Stats.CircuitState = loadbalance.CircuitOpen
+		service.Stats.CircuitOpenedAt = time.Now().Add(-loadbalance.DefaultCircuitOpenDuration - time.Second)
+		require.True(t, service.AcquireProbe())
+	}
+
+	_, _, err := s.DetermineProviderAndModelWithScenario(sessionContext(""), typ.ScenarioOpenAI, rule, nil)
+	assert.ErrorContains(t, err, "in flight")
+}
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/tingly-dev/tingly-box/internal/constant"
 	"github.com/tingly-dev/tingly-box/internal/loadbalance"
 	"github.com/tingly-dev/tingly-box/internal/server/config"
 	typ "github.com/tingly-dev/tingly-box/internal/typ"
@@ -37,6 +38,8 @@ func (lb *LoadBalancer) initializeDefaultTactics() {
 	lb.tactics[loadbalance.TacticRoundRobin] = typ.NewRoundRobinTactic()
 	lb.tactics[loadbalance.TacticTokenBased] = typ.NewTokenBasedTactic(10000)
 	lb.tactics[loadbalance.TacticHybrid] = typ.NewHybridTactic(100, 10000)
+	lb.tactics[loadbalance.TacticLeastLatency] = typ.NewLeastLatencyTactic(constant.DefaultLatencyToleranceMs)
+	lb.tactics[loadbalance.TacticLowestCost] = typ.NewLowestCostTactic(nil)
 }
 
 // RegisterTactic registers a custom tactic
@@ -89,6 +92,27 @@ func (lb *LoadBalancer) SelectService(rule *typ.Rule) (*loadbalance.Service, err
 	return selectedService, nil
 }
 
+// PreviewService returns the service SelectService would select for the rule, without
+// advancing the rule's round-robin state
+func (lb *LoadBalancer) PreviewService(rule *typ.Rule) (*loadbalance.Service, error) {
+	if rule == nil {
+		return nil, fmt.Errorf("rule is nil")
+	}
+
+	activeServices := rule.GetActiveServices()
+	if len(activeServices) == 0 {
+		return nil, fmt.Errorf("no active services for rule %s", rule.RequestModel)
+	}
+	if len(activeServices) == 1 {
+		return activeServices[0], nil
+	}
+
+	if selected := typ.PreviewService(rule.LBTactic.Instantiate(), rule); selected != nil {
+		return selected, nil
+	}
+	return activeServices[0], nil
+}
+
 // getTactic retrieves a tactic by type
 func (lb *LoadBalancer) getTactic(tacticType loadbalance.TacticType) (typ.LoadBalancingTactic, bool) {
 	lb.mutex.RLock()
@@ -184,6 +208,21 @@ func (lb *LoadBalancer) ClearServiceStats(provider, model string) {
 	if stats, exists := lb.stats[serviceID]; exists {
 		stats.ResetWindow()
 	}
+
+	// Close the circuit breaker so a tripped service gets traffic again
+	if lb.config != nil {
+		for _, rule := range lb.config.GetRequestConfigs() {
+			for i := range rule.Services {
+				service := rule.Services[i]
+				if service.Provider == provider && service.Model == model {
+					service.Stats.ResetHealth()
+					if store := lb.config.GetStatsStore(); store != nil {
+						_ = store.UpdateFromService(service)
+					}
+				}
+			}
+		}
+	}
 }
 
 // ClearAllStats clears all statistics (both in-memory and persisted in config)
@@ -296,6 +335,11 @@ func (lb *LoadBalancer) GetRuleSummary(rule *typ.Rule) map[string]interface{} {
 				"window_input_tokens":  stats.WindowInputTokens,
 				"window_output_tokens": stats.WindowOutputTokens,
 				"last_used":            stats.LastUsed,
+				"error_count":          stats.ErrorCount,
+				"window_error_count":   stats.WindowErrorCount,
+				"consecutive_failures": stats.ConsecutiveFailures,
+				"avg_latency_ms":       stats.AvgLatencyMs,
+				"circuit_state":        stats.CircuitState.String(),
 			}
 		}
 
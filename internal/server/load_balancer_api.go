@@ -124,15 +124,27 @@ func (api *LoadBalancerAPI) GetRuleStats(c *gin.Context) {
 
 	services := rule.GetServices()
 	stats := make(map[string]interface{})
+	health := make(map[string]string)
+	tripped := make([]string, 0)
 
 	for _, service := range services {
 		serviceStats := api.loadBalancer.GetServiceStats(service.Provider, service.Model)
 		if serviceStats != nil {
 			stats[service.ServiceID()] = serviceStats
+			health[service.ServiceID()] = serviceStats.CircuitState.String()
+			if serviceStats.CircuitState == loadbalance.CircuitOpen {
+				tripped = append(tripped, service.ServiceID())
+			}
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"rule_id": ruleId, "rule_name": rule.RequestModel, "stats": stats})
+	c.JSON(http.StatusOK, gin.H{
+		"rule_id":   ruleId,
+		"rule_name": rule.RequestModel,
+		"stats":     stats,
+		"health":    health,
+		"tripped":   tripped,
+	})
 }
 
 // ClearRuleStats clears statistics for all services in a rule
@@ -1,15 +1,23 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/tingly-dev/tingly-box/internal/server/config"
+	"github.com/tingly-dev/tingly-box/internal/typ"
 	"github.com/tingly-dev/tingly-box/pkg/auth"
 )
 
+// Context keys set by ModelAuthMiddleware when a request is authenticated with a named API key
+const (
+	ContextKeyAPIKey   = "api_key"
+	ContextKeyAPIKeyID = "api_key_id"
+)
+
 // AuthMiddleware provides authentication middleware for different types of authentication
 type AuthMiddleware struct {
 	config     *config.Config
@@ -96,12 +104,19 @@ func (am *AuthMiddleware) UserAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
-// ModelAuthMiddleware middleware for OpenAI and Anthropic API authentication
-// The auth will support both `Authorization` and `X-Api-Key`
+// ModelAuthMiddleware middleware for OpenAI, Anthropic and Google API authentication
+// The auth will support `Authorization`, `X-Api-Key`, `X-Goog-Api-Key` and the `key` query parameter
 func (am *AuthMiddleware) ModelAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		xApiKey := c.GetHeader("X-Api-Key")
+		if xApiKey == "" {
+			// Gemini clients send the key in x-goog-api-key or the key query parameter
+			xApiKey = c.GetHeader("X-Goog-Api-Key")
+			if xApiKey == "" {
+				xApiKey = c.Query("key")
+			}
+		}
 		if authHeader == "" && xApiKey == "" {
 			c.JSON(http.StatusUnauthorized, ErrorResponse{
 				Error: ErrorDetail{
@@ -141,6 +156,23 @@ func (am *AuthMiddleware) ModelAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Named API keys
+		key := cfg.AuthenticateAPIKey(token)
+		if key == nil && xApiKey != "" {
+			key = cfg.AuthenticateAPIKey(xApiKey)
+		}
+		if key != nil {
+			if !am.authorizeAPIKey(c, key) {
+				c.Abort()
+				return
+			}
+			c.Set("client_id", "api_key_authenticated")
+			c.Set(ContextKeyAPIKey, key)
+			c.Set(ContextKeyAPIKeyID, key.ID)
+			c.Next()
+			return
+		}
+
 		c.JSON(http.StatusUnauthorized, ErrorResponse{
 			Error: ErrorDetail{
 				Message: "Invalid authorization header format. Expected: 'Bearer <token>'",
@@ -152,6 +184,32 @@ func (am *AuthMiddleware) ModelAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// authorizeAPIKey checks the scenario scope of an API key, writing the error
+// response when the request is not allowed. Model and rule scopes are checked
+// by the handlers once the rule is known; rate limits by RateLimitMiddleware.
+func (am *AuthMiddleware) authorizeAPIKey(c *gin.Context, key *typ.APIKey) bool {
+	if scenario := c.Param("scenario"); scenario != "" && !key.AllowsScenario(typ.RuleScenario(scenario)) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: ErrorDetail{
+				Message: fmt.Sprintf("API key '%s' is not allowed to access scenario '%s'", key.Name, scenario),
+				Type:    "permission_error",
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// APIKeyFromContext returns the named API key the request was authenticated with, or nil
+func APIKeyFromContext(c *gin.Context) *typ.APIKey {
+	if value, exists := c.Get(ContextKeyAPIKey); exists {
+		if key, ok := value.(*typ.APIKey); ok {
+			return key
+		}
+	}
+	return nil
+}
+
 // VirtualModelAuthMiddleware middleware for virtual model API authentication
 // Uses an independent token separate from the main model token
 func (am *AuthMiddleware) VirtualModelAuthMiddleware() gin.HandlerFunc {
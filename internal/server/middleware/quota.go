@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tingly-dev/tingly-box/internal/data/db"
+	"github.com/tingly-dev/tingly-box/internal/server/config"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// Budget event types sent to webhooks
+const (
+	BudgetEventSoftLimit = "budget.soft_limit"
+	BudgetEventHardLimit = "budget.hard_limit"
+)
+
+// BudgetEvent is emitted when a budget limit is crossed
+type BudgetEvent struct {
+	Type      string           `json:"type"`
+	BudgetID  string           `json:"budget_id"`
+	Name      string           `json:"name,omitempty"`
+	Scope     typ.BudgetScope  `json:"scope"`
+	Target    string           `json:"target"`
+	Period    typ.BudgetPeriod `json:"period"`
+	Limit     string           `json:"limit"`
+	Usage     db.UsageTotals   `json:"usage"`
+	Limits    typ.BudgetLimits `json:"limits"`
+	Timestamp time.Time        `json:"timestamp"`
+}
+
+// QuotaMiddleware enforces the usage budgets of API keys and scenarios on the model endpoints.
+// It must run after ModelAuthMiddleware so the API key is known.
+type QuotaMiddleware struct {
+	config     *config.Config
+	scenarioFn func(c *gin.Context) string
+	httpClient *http.Client
+
+	// notified remembers the period in which each budget event was last sent,
+	// so that every limit is reported once per period
+	notified map[string]time.Time
+	mu       sync.Mutex
+}
+
+// NewQuotaMiddleware creates a new quota middleware. scenarioFn returns the scenario
+// of a request as recorded in the usage store.
+func NewQuotaMiddleware(cfg *config.Config, scenarioFn func(c *gin.Context) string) *QuotaMiddleware {
+	return &QuotaMiddleware{
+		config:     cfg,
+		scenarioFn: scenarioFn,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		notified:   make(map[string]time.Time),
+	}
+}
+
+// Middleware returns the gin handler checking the budgets that apply to the request
+func (qm *QuotaMiddleware) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		budget, limit := qm.exceededBudget(c.GetString(ContextKeyAPIKeyID), qm.scenarioFn(c), now)
+		if budget != nil {
+			retryAfter := budget.PeriodEnd(now).Sub(now)
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			writeTooManyRequests(c, budgetExceededMessage(budget, limit), "insufficient_quota", "insufficient_quota")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CheckInternal checks the budgets of a request the server sends on behalf of a client
+// request, such as a conversation summary, and returns an error when one is exceeded
+func (qm *QuotaMiddleware) CheckInternal(apiKeyID, scenario string) error {
+	if qm == nil {
+		return nil
+	}
+	if budget, limit := qm.exceededBudget(apiKeyID, scenario, time.Now()); budget != nil {
+		return errors.New(budgetExceededMessage(budget, limit))
+	}
+	return nil
+}
+
+// exceededBudget returns the first budget of the API key or scenario whose hard limit
+// is reached, with the limit reached. Soft limits crossed on the way are notified.
+func (qm *QuotaMiddleware) exceededBudget(apiKeyID, scenario string, now time.Time) (*typ.Budget, string) {
+	cfg := qm.config
+	if cfg == nil {
+		return nil, ""
+	}
+
+	budgets := cfg.MatchingBudgets(apiKeyID, scenario)
+	for i := range budgets {
+		budget := &budgets[i]
+
+		totals, err := cfg.GetBudgetUsage(budget, now)
+		if err != nil {
+			logrus.Warnf("Failed to load usage for budget %s: %v", budget.ID, err)
+			continue
+		}
+
+		if limit := budget.Hard.Exceeded(totals.RequestCount, totals.TotalTokens, totals.CostUSD); limit != "" {
+			qm.notify(budget, BudgetEventHardLimit, limit, budget.Hard, totals, now)
+			return budget, limit
+		}
+
+		if limit := budget.Soft.Exceeded(totals.RequestCount, totals.TotalTokens, totals.CostUSD); limit != "" {
+			qm.notify(budget, BudgetEventSoftLimit, limit, budget.Soft, totals, now)
+		}
+	}
+	return nil, ""
+}
+
+// budgetExceededMessage describes the hard limit of a budget being reached
+func budgetExceededMessage(budget *typ.Budget, limit string) string {
+	return fmt.Sprintf("Budget exceeded: %s %s reached for %s '%s'", budget.Period, limit, budget.Scope, budget.Target)
+}
+
+// notify logs a budget event and sends it to the budget webhook, once per period
+func (qm *QuotaMiddleware) notify(budget *typ.Budget, eventType, limit string, limits typ.BudgetLimits, totals db.UsageTotals, now time.Time) {
+	key := budget.ID + ":" + eventType
+	periodStart := budget.PeriodStart(now)
+
+	qm.mu.Lock()
+	if last, ok := qm.notified[key]; ok && last.Equal(periodStart) {
+		qm.mu.Unlock()
+		return
+	}
+	qm.notified[key] = periodStart
+	qm.mu.Unlock()
+
+	logrus.Warnf("Budget %s (%s '%s') crossed %s %s: %d requests, %d tokens, $%.4f",
+		budget.ID, budget.Scope, budget.Target, budget.Period, limit,
+		totals.RequestCount, totals.TotalTokens, totals.CostUSD)
+
+	if budget.WebhookURL == "" {
+		return
+	}
+
+	event := BudgetEvent{
+		Type:      eventType,
+		BudgetID:  budget.ID,
+		Name:      budget.Name,
+		Scope:     budget.Scope,
+		Target:    budget.Target,
+		Period:    budget.Period,
+		Limit:     limit,
+		Usage:     totals,
+		Limits:    limits,
+		Timestamp: now,
+	}
+	go qm.sendWebhook(budget.WebhookURL, event)
+}
+
+// sendWebhook posts a budget event to a webhook URL
+func (qm *QuotaMiddleware) sendWebhook(url string, event BudgetEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		logrus.Warnf("Failed to encode budget event: %v", err)
+		return
+	}
+
+	resp, err := qm.httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logrus.Warnf("Failed to send budget event to %s: %v", url, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		logrus.Warnf("Budget webhook %s returned status %d", url, resp.StatusCode)
+	}
+}
@@ -0,0 +1,289 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tingly-dev/tingly-box/internal/ratelimit"
+	"github.com/tingly-dev/tingly-box/internal/server/config"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// ContextKeyUsedTokens holds the tokens used by the request, set by usage tracking
+// and charged against TPM limits once the response is done
+const ContextKeyUsedTokens = "used_tokens"
+
+// contextKeyRateLimit holds the rateLimitState of the request
+const contextKeyRateLimit = "rate_limit_state"
+
+// RateLimitMiddleware applies token-bucket RPM and TPM limits to the model endpoints,
+// keyed by client API key, scenario or request model. It must run after
+// ModelAuthMiddleware so the API key is known. The limits of the request model are
+// applied by the handlers once they have parsed it, with AllowModel.
+type RateLimitMiddleware struct {
+	config     *config.Config
+	scenarioFn func(c *gin.Context) string
+	limiter    *ratelimit.Limiter
+}
+
+// rateLimitBucket is a limit that applies to the current request
+type rateLimitBucket struct {
+	key   string
+	name  string
+	limit typ.RateLimit
+}
+
+// rateLimitState is the buckets a request was admitted to, charged with the tokens of
+// the response, and the most restrictive results reported in its headers
+type rateLimitState struct {
+	buckets          []rateLimitBucket
+	requests, tokens *ratelimit.Result
+}
+
+// NewRateLimitMiddleware creates a new rate limit middleware. scenarioFn returns the
+// scenario of a request as recorded in the usage store.
+func NewRateLimitMiddleware(cfg *config.Config, scenarioFn func(c *gin.Context) string) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		config:     cfg,
+		scenarioFn: scenarioFn,
+		limiter:    ratelimit.NewLimiter(),
+	}
+}
+
+// StartCleanupTask starts a background task to periodically drop the buckets of idle keys
+func (rm *RateLimitMiddleware) StartCleanupTask(interval time.Duration) {
+	rm.limiter.StartCleanupTask(interval)
+}
+
+// Middleware returns the gin handler enforcing the API key and scenario limits that
+// apply to the request
+func (rm *RateLimitMiddleware) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := &rateLimitState{}
+		c.Set(contextKeyRateLimit, state)
+
+		if buckets := rm.clientBuckets(APIKeyFromContext(c), rm.scenarioFn(c)); len(buckets) > 0 {
+			if !rm.admitRequest(c, state, buckets) {
+				return
+			}
+		}
+
+		c.Next()
+
+		// Charge the tokens of the response, which are only known now
+		rm.charge(state.buckets, c.GetInt(ContextKeyUsedTokens))
+	}
+}
+
+// AllowModel enforces the limits of the request model, once the handler has parsed it.
+// It writes a 429 and returns false when a limit is reached. Requests that did not go
+// through the middleware are not limited.
+func (rm *RateLimitMiddleware) AllowModel(c *gin.Context, model string) bool {
+	if rm == nil {
+		return true
+	}
+	value, exists := c.Get(contextKeyRateLimit)
+	if !exists {
+		return true
+	}
+	state := value.(*rateLimitState)
+
+	buckets := rm.modelBuckets(model)
+	if len(buckets) == 0 {
+		return true
+	}
+	return rm.admitRequest(c, state, buckets)
+}
+
+// admitRequest admits the request to the buckets and records them in its state, or
+// rejects it with a 429
+func (rm *RateLimitMiddleware) admitRequest(c *gin.Context, state *rateLimitState, buckets []rateLimitBucket) bool {
+	adm := rm.admit(buckets)
+	if adm.rejected != nil {
+		rm.reject(c, *adm.rejected, adm.kind, adm.result)
+		return false
+	}
+
+	state.buckets = append(state.buckets, buckets...)
+	if adm.requests != nil {
+		state.requests = mostRestrictive(state.requests, *adm.requests)
+	}
+	if adm.tokens != nil {
+		state.tokens = mostRestrictive(state.tokens, *adm.tokens)
+	}
+	setRateLimitHeaders(c, "requests", state.requests)
+	setRateLimitHeaders(c, "tokens", state.tokens)
+	return true
+}
+
+// AllowInternal applies the limits of the client API key, scenario and model to a request
+// the server sends on behalf of a client request, such as a conversation summary. It
+// returns an error when a limit is reached, else a function charging the tokens the
+// request used once they are known.
+func (rm *RateLimitMiddleware) AllowInternal(c *gin.Context, scenario, model string) (func(tokens int), error) {
+	if rm == nil {
+		return func(int) {}, nil
+	}
+	buckets := append(rm.clientBuckets(APIKeyFromContext(c), scenario), rm.modelBuckets(model)...)
+	adm := rm.admit(buckets)
+	if adm.rejected != nil {
+		return nil, errors.New(rateLimitMessage(*adm.rejected, adm.kind))
+	}
+	return func(tokens int) { rm.charge(buckets, tokens) }, nil
+}
+
+// admission is the outcome of admitting a request against its buckets
+type admission struct {
+	rejected *rateLimitBucket // The bucket rejecting the request, nil when admitted
+	kind     string           // The kind of limit reached: requests or tokens
+	result   ratelimit.Result // The result of the rejecting bucket
+
+	// The most restrictive results of an admitted request, for the headers
+	requests, tokens *ratelimit.Result
+}
+
+// admit takes a request from every bucket. Every bucket is checked first so a rejected
+// request is not charged anywhere.
+func (rm *RateLimitMiddleware) admit(buckets []rateLimitBucket) admission {
+	for i, b := range buckets {
+		if res := rm.limiter.Check(b.key+":requests", b.limit.RequestsPerMinute, 1); !res.Allowed {
+			return admission{rejected: &buckets[i], kind: "requests", result: res}
+		}
+		if res := rm.limiter.Check(b.key+":tokens", b.limit.TokensPerMinute, 1); !res.Allowed {
+			return admission{rejected: &buckets[i], kind: "tokens", result: res}
+		}
+	}
+
+	var adm admission
+	for i, b := range buckets {
+		if b.limit.RequestsPerMinute > 0 {
+			res := rm.limiter.Allow(b.key+":requests", b.limit.RequestsPerMinute, 1)
+			if !res.Allowed {
+				return admission{rejected: &buckets[i], kind: "requests", result: res}
+			}
+			adm.requests = mostRestrictive(adm.requests, res)
+		}
+		if b.limit.TokensPerMinute > 0 {
+			adm.tokens = mostRestrictive(adm.tokens, rm.limiter.Check(b.key+":tokens", b.limit.TokensPerMinute, 0))
+		}
+	}
+	return adm
+}
+
+// charge takes the tokens a request used from the TPM limits of its buckets
+func (rm *RateLimitMiddleware) charge(buckets []rateLimitBucket, used int) {
+	if used <= 0 {
+		return
+	}
+	for _, b := range buckets {
+		rm.limiter.Consume(b.key+":tokens", b.limit.TokensPerMinute, used)
+	}
+}
+
+// clientBuckets returns the limits of the API key and of the API key and scenario
+// rules that apply to a request
+func (rm *RateLimitMiddleware) clientBuckets(key *typ.APIKey, scenario string) []rateLimitBucket {
+	var buckets []rateLimitBucket
+
+	if key != nil && !key.RateLimit.IsZero() {
+		buckets = append(buckets, rateLimitBucket{
+			key:   "api_key:" + key.ID,
+			name:  fmt.Sprintf("API key '%s'", key.Name),
+			limit: *key.RateLimit,
+		})
+	}
+
+	for _, rule := range rm.rules() {
+		var value string
+		switch rule.Scope {
+		case typ.RateLimitScopeAPIKey:
+			if key != nil {
+				value = key.ID
+			}
+		case typ.RateLimitScopeScenario:
+			value = scenario
+		default:
+			continue
+		}
+		if rule.Matches(value) {
+			buckets = append(buckets, ruleBucket(rule, value))
+		}
+	}
+	return buckets
+}
+
+// modelBuckets returns the limits of the model rules that apply to a request model
+func (rm *RateLimitMiddleware) modelBuckets(model string) []rateLimitBucket {
+	var buckets []rateLimitBucket
+	for _, rule := range rm.rules() {
+		if rule.Scope == typ.RateLimitScopeModel && rule.Matches(model) {
+			buckets = append(buckets, ruleBucket(rule, model))
+		}
+	}
+	return buckets
+}
+
+// rules returns the configured rate limit rules with a limit set
+func (rm *RateLimitMiddleware) rules() []typ.RateLimitRule {
+	if rm.config == nil {
+		return nil
+	}
+	var rules []typ.RateLimitRule
+	for _, rule := range rm.config.GetRateLimitRules() {
+		if !rule.RateLimit.IsZero() {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// ruleBucket returns the bucket of a rule for the value it matched
+func ruleBucket(rule typ.RateLimitRule, value string) rateLimitBucket {
+	return rateLimitBucket{
+		key:   fmt.Sprintf("%s:%s:%s", rule.Scope, rule.Target, value),
+		name:  fmt.Sprintf("%s '%s'", strings.ReplaceAll(string(rule.Scope), "_", " "), value),
+		limit: rule.RateLimit,
+	}
+}
+
+// reject aborts the request with a 429 for the given bucket
+func (rm *RateLimitMiddleware) reject(c *gin.Context, b rateLimitBucket, kind string, res ratelimit.Result) {
+	setRateLimitHeaders(c, kind, &res)
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+
+	writeTooManyRequests(c, rateLimitMessage(b, kind), kind, "rate_limit_exceeded")
+	c.Abort()
+}
+
+// rateLimitMessage describes the limit of a kind (requests or tokens) of a bucket being reached
+func rateLimitMessage(b rateLimitBucket, kind string) string {
+	limit := b.limit.RequestsPerMinute
+	if kind == "tokens" {
+		limit = b.limit.TokensPerMinute
+	}
+	return fmt.Sprintf("Rate limit reached for %s: %d %s per minute", b.name, limit, kind)
+}
+
+// setRateLimitHeaders sets the x-ratelimit-* headers of a limit kind (requests or tokens)
+func setRateLimitHeaders(c *gin.Context, kind string, res *ratelimit.Result) {
+	if res == nil {
+		return
+	}
+	c.Header("x-ratelimit-limit-"+kind, strconv.Itoa(res.Limit))
+	c.Header("x-ratelimit-remaining-"+kind, strconv.Itoa(res.Remaining))
+	c.Header("x-ratelimit-reset-"+kind, res.Reset.Round(time.Millisecond).String())
+}
+
+// mostRestrictive returns the result with the fewest remaining tokens
+func mostRestrictive(current *ratelimit.Result, res ratelimit.Result) *ratelimit.Result {
+	if current == nil || res.Remaining < current.Remaining {
+		return &res
+	}
+	return current
+}
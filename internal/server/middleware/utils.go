@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"bytes"
+	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,3 +18,50 @@ func (r *responseBodyWriter) Write(b []byte) (int, error) {
 	r.body.Write(b)
 	return r.ResponseWriter.Write(b)
 }
+
+// protocolErrorStatuses maps HTTP status codes to the error status of the Google API
+// and the error type of the Anthropic API
+var protocolErrorStatuses = map[int]struct{ google, anthropic string }{
+	http.StatusForbidden:       {google: "PERMISSION_DENIED", anthropic: "permission_error"},
+	http.StatusTooManyRequests: {google: "RESOURCE_EXHAUSTED", anthropic: "rate_limit_error"},
+}
+
+// writeTooManyRequests writes a 429 error in the error format of the API the request
+// targets. openAIType and openAICode are only used for the OpenAI format.
+func writeTooManyRequests(c *gin.Context, message, openAIType, openAICode string) {
+	WriteProtocolError(c, http.StatusTooManyRequests, message, openAIType, openAICode)
+}
+
+// WriteProtocolError writes an error in the error format of the API the request
+// targets, Google, Anthropic or OpenAI by default. openAIType and openAICode are only
+// used for the OpenAI format.
+func WriteProtocolError(c *gin.Context, status int, message, openAIType, openAICode string) {
+	statuses := protocolErrorStatuses[status]
+	path := c.Request.URL.Path
+	switch {
+	case strings.Contains(path, "/google/") || strings.Contains(path, "/v1beta/models/"):
+		c.JSON(status, gin.H{
+			"error": gin.H{
+				"code":    status,
+				"message": message,
+				"status":  statuses.google,
+			},
+		})
+	case strings.Contains(path, "/anthropic/") || strings.HasSuffix(path, "/messages") || strings.HasSuffix(path, "/messages/count_tokens"):
+		c.JSON(status, gin.H{
+			"type": "error",
+			"error": gin.H{
+				"type":    statuses.anthropic,
+				"message": message,
+			},
+		})
+	default:
+		c.JSON(status, ErrorResponse{
+			Error: ErrorDetail{
+				Message: message,
+				Type:    openAIType,
+				Code:    openAICode,
+			},
+		})
+	}
+}
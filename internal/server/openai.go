@@ -1,16 +1,19 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"github.com/tingly-dev/tingly-box/internal/loadbalance"
 	"github.com/tingly-dev/tingly-box/internal/protocol"
 	"github.com/tingly-dev/tingly-box/internal/protocol/nonstream"
 	"github.com/tingly-dev/tingly-box/internal/protocol/request"
 	"github.com/tingly-dev/tingly-box/internal/protocol/stream"
+	"github.com/tingly-dev/tingly-box/internal/smart_compact"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 )
 
@@ -110,8 +113,6 @@ func (s *Server) OpenAIChatCompletions(c *gin.Context) {
 		return
 	}
 
-	isStreaming := req.Stream
-
 	// Validate
 	proxyModel := req.Model
 	if req.Model == "" {
@@ -164,7 +165,10 @@ func (s *Server) OpenAIChatCompletions(c *gin.Context) {
 		})
 		return
 	}
-	provider, selectedService, err = s.DetermineProviderAndModelWithScenario(scenarioType, rule, &req.ChatCompletionNewParams)
+	if !s.authorizeAPIKeyForRule(c, rule) || !s.rateLimitMW.AllowModel(c, string(req.Model)) {
+		return
+	}
+	provider, selectedService, err = s.DetermineProviderAndModelWithScenario(c, scenarioType, rule, &req.ChatCompletionNewParams)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error: ErrorDetail{
@@ -175,6 +179,38 @@ func (s *Server) OpenAIChatCompletions(c *gin.Context) {
 		return
 	}
 
+	s.serveWithFailover(c, rule, provider, selectedService, func(attempt int, provider *typ.Provider, selectedService *loadbalance.Service) {
+		if attempt > 1 {
+			// Earlier attempts may have mutated the request, start from the original body
+			req = protocol.OpenAIChatCompletionRequest{}
+			if err := json.Unmarshal(bodyBytes, &req); err != nil {
+				return
+			}
+		}
+
+		// Summarize before compacting, the summary cache is keyed by the original rounds
+		s.summarizeForContextWindow(c, rule, provider, selectedService.Model, &req.ChatCompletionNewParams, func(ctx context.Context, summarizer *smart_compact.OverflowSummarizer, overflow int) error {
+			return summarizer.HandleOpenAIChat(ctx, &req.ChatCompletionNewParams, overflow)
+		})
+
+		// Apply compact transformation only if the compact feature is enabled for this scenario
+		if s.ApplySmartCompact(scenarioType) {
+			tf := smart_compact.NewCompactTransformer(2)
+			tf.HandleOpenAIChat(&req.ChatCompletionNewParams)
+			logrus.Infoln("smart compact triggered")
+		}
+		s.compactToolResults(c, scenarioType, func(tc *smart_compact.ToolResultCompactor) error {
+			return tc.HandleOpenAIChat(&req.ChatCompletionNewParams)
+		})
+		s.openAIChatCompletionsWithService(c, req, proxyModel, provider, selectedService, rule)
+	})
+}
+
+// openAIChatCompletionsWithService forwards a chat completion request to the given service,
+// converting it to the provider's API style
+func (s *Server) openAIChatCompletionsWithService(c *gin.Context, req protocol.OpenAIChatCompletionRequest, proxyModel string, provider *typ.Provider, selectedService *loadbalance.Service, rule *typ.Rule) {
+	isStreaming := req.Stream
+
 	actualModel := selectedService.Model
 	req.Model = actualModel
 	maxAllowed := s.templateManager.GetMaxTokensForModelByProvider(provider, actualModel)
@@ -218,11 +254,11 @@ func (s *Server) OpenAIChatCompletions(c *gin.Context) {
 			}
 			defer cancel()
 
-			inputTokens, outputTokens, err := stream.HandleAnthropicToOpenAIStreamResponse(c, &anthropicReq, streamResp, responseModel)
+			usage, err := stream.HandleAnthropicToOpenAIStreamResponse(c, &anthropicReq, streamResp, responseModel)
 			if err != nil {
 				// Track usage with error status
-				if inputTokens > 0 || outputTokens > 0 {
-					s.trackUsageFromContext(c, inputTokens, outputTokens, err)
+				if usage.HasUsage() {
+					s.trackUsageStatFromContext(c, usage, err)
 				}
 				c.JSON(http.StatusInternalServerError, ErrorResponse{
 					Error: ErrorDetail{
@@ -234,8 +270,8 @@ func (s *Server) OpenAIChatCompletions(c *gin.Context) {
 			}
 
 			// Track successful streaming completion
-			if inputTokens > 0 || outputTokens > 0 {
-				s.trackUsageFromContext(c, inputTokens, outputTokens, nil)
+			if usage.HasUsage() {
+				s.trackUsageStatFromContext(c, usage, nil)
 			}
 			return
 		} else {
@@ -256,9 +292,7 @@ func (s *Server) OpenAIChatCompletions(c *gin.Context) {
 			defer cancel()
 
 			// Track usage from response
-			inputTokens := int(anthropicResp.Usage.InputTokens)
-			outputTokens := int(anthropicResp.Usage.OutputTokens)
-			s.trackUsageFromContext(c, inputTokens, outputTokens, nil)
+			s.trackUsageStatFromContext(c, protocol.NewUsageStatFromAnthropic(anthropicResp.Usage), nil)
 
 			// Use provider-aware conversion for provider-specific handling
 			openaiResp := nonstream.ConvertAnthropicToOpenAIResponseWithProvider(anthropicResp, responseModel, provider, actualModel)
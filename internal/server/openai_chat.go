@@ -83,10 +83,8 @@ func (s *Server) handleNonStreamingRequest(c *gin.Context, provider *typ.Provide
 					return
 				}
 
-				// Extract usage from final response
-				inputTokens := int(finalResponse.Usage.PromptTokens)
-				outputTokens := int(finalResponse.Usage.CompletionTokens)
-				s.trackUsageFromContext(c, inputTokens, outputTokens, nil)
+				// Track usage from final response
+				s.trackUsageStatFromContext(c, protocol.NewUsageStatFromOpenAIChat(finalResponse.Usage), nil)
 
 				// Convert to JSON and return
 				responseJSON, _ := json.Marshal(finalResponse)
@@ -99,12 +97,8 @@ func (s *Server) handleNonStreamingRequest(c *gin.Context, provider *typ.Provide
 		}
 	}
 
-	// Extract usage from response
-	inputTokens := int(response.Usage.PromptTokens)
-	outputTokens := int(response.Usage.CompletionTokens)
-
-	// Track usage
-	s.trackUsageFromContext(c, inputTokens, outputTokens, nil)
+	// Track usage from response
+	s.trackUsageStatFromContext(c, protocol.NewUsageStatFromOpenAIChat(response.Usage), nil)
 
 	// Convert response to JSON map for modification
 	responseJSON, err := json.Marshal(response)
@@ -237,7 +231,7 @@ func (s *Server) handleOpenAIChatStreamingRequest(c *gin.Context, provider *typ.
 	usage, err := stream.HandleOpenAIChatStream(hc, streamResp, req)
 
 	// Track usage from stream handler
-	s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, err)
+	s.trackUsageStatFromContext(c, usage, err)
 }
 
 // handleOpenAIStreamResponse processes the streaming response and sends it to the client
@@ -547,6 +541,8 @@ func (s *Server) ListModelsByScenario(c *gin.Context) {
 	switch scenarioType {
 	case typ.ScenarioAnthropic, typ.ScenarioClaudeCode:
 		s.AnthropicListModelsForScenario(c, scenarioType)
+	case typ.ScenarioGoogle:
+		s.googleListModelsWithScenario(c, scenarioType)
 	default:
 		// OpenAI is the default
 		s.OpenAIListModelsForScenario(c, scenarioType)
@@ -560,9 +556,9 @@ func (s *Server) handleResponsesForChatRequest(c *gin.Context, provider *typ.Pro
 	params := s.convertChatCompletionToResponsesParams(req, actualModel)
 
 	if isStreaming {
-		s.handleResponsesStreamingRequest(c, provider, params, responseModel, actualModel)
+		s.handleResponsesStreamingRequest(c, provider, params, responseModel, actualModel, nil)
 	} else {
-		s.handleResponsesNonStreamingRequest(c, provider, params, responseModel, actualModel)
+		s.handleResponsesNonStreamingRequest(c, provider, params, responseModel, actualModel, nil)
 	}
 }
 
@@ -650,7 +646,7 @@ func (s *Server) convertMessagesToResponseInputItems(messages []openai.ChatCompl
 // isValidRuleScenario checks if the given scenario is a valid RuleScenario
 func isValidRuleScenario(scenario typ.RuleScenario) bool {
 	switch scenario {
-	case typ.ScenarioOpenAI, typ.ScenarioAnthropic, typ.ScenarioClaudeCode, typ.ScenarioOpenCode, typ.ScenarioXcode:
+	case typ.ScenarioOpenAI, typ.ScenarioAnthropic, typ.ScenarioClaudeCode, typ.ScenarioOpenCode, typ.ScenarioXcode, typ.ScenarioGoogle, typ.ScenarioEmbeddings:
 		return true
 	default:
 		return false
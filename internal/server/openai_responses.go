@@ -17,6 +17,7 @@ import (
 
 	"github.com/tingly-dev/tingly-box/internal/loadbalance"
 	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/smart_compact"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 )
 
@@ -36,6 +37,12 @@ func (s *Server) ResponsesCreate(c *gin.Context) {
 		return
 	}
 
+	// Resolve previous_response_id from the response store before anything reads the input
+	bodyBytes, conv, ok := s.expandPreviousResponse(c, bodyBytes)
+	if !ok {
+		return
+	}
+
 	// Parse request (minimal parsing for validation)
 	var req protocol.ResponseCreateRequest
 	if err := json.Unmarshal(bodyBytes, &req); err != nil {
@@ -100,7 +107,10 @@ func (s *Server) ResponsesCreate(c *gin.Context) {
 		})
 		return
 	}
-	provider, selectedService, err = s.DetermineProviderAndModelWithScenario(scenarioType, rule, req)
+	if !s.authorizeAPIKeyForRule(c, rule) || !s.rateLimitMW.AllowModel(c, string(req.Model)) {
+		return
+	}
+	provider, selectedService, err = s.DetermineProviderAndModelWithScenario(c, scenarioType, rule, &req.ResponseNewParams)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error: ErrorDetail{
@@ -111,16 +121,55 @@ func (s *Server) ResponsesCreate(c *gin.Context) {
 		return
 	}
 
+	s.serveWithFailover(c, rule, provider, selectedService, func(attempt int, provider *typ.Provider, selectedService *loadbalance.Service) {
+		if attempt > 1 {
+			// Earlier attempts may have mutated the request, start from the original body
+			req = protocol.ResponseCreateRequest{}
+			if err := json.Unmarshal(bodyBytes, &req); err != nil {
+				return
+			}
+		}
+		s.responsesCreateWithService(c, req, bodyBytes, conv, scenarioType, rule, provider, selectedService)
+	})
+}
+
+// responsesCreateWithService forwards a Responses API request to the given service,
+// converting it when the provider is not OpenAI-style
+func (s *Server) responsesCreateWithService(c *gin.Context, req protocol.ResponseCreateRequest, bodyBytes []byte, conv *responsesConversation, scenarioType typ.RuleScenario, rule *typ.Rule, provider *typ.Provider, selectedService *loadbalance.Service) {
+	if !requireResolvedPreviousResponse(c, conv, provider) {
+		return
+	}
+
 	actualModel := selectedService.Model
 
 	// Set tracking context with all metadata (eliminates need for explicit parameter passing)
 	SetTrackingContext(c, rule, provider, actualModel, req.Model, req.Stream)
 
-	// Check provider API style - only OpenAI-style providers support Responses API
-	if provider.APIStyle != protocol.APIStyleOpenAI {
+	// Apply compact transformation only if the compact feature is enabled for this scenario
+	var tf *smart_compact.CompactTransformer
+	if s.ApplySmartCompact(scenarioType) {
+		tf = smart_compact.NewCompactTransformer(2)
+		tf.HandleOpenAIResponses(&req.ResponseNewParams)
+		logrus.Infoln("smart compact triggered")
+	}
+	s.compactToolResults(c, scenarioType, func(tc *smart_compact.ToolResultCompactor) error {
+		return tc.HandleOpenAIResponses(&req.ResponseNewParams)
+	})
+
+	// Anthropic and Google providers are served by converting the request and the response
+	switch provider.APIStyle {
+	case protocol.APIStyleOpenAI:
+		// Native Responses API, forwarded below
+	case protocol.APIStyleAnthropic:
+		s.handleResponsesViaAnthropic(c, provider, &req.ResponseNewParams, req.Model, actualModel, req.Stream, conv)
+		return
+	case protocol.APIStyleGoogle:
+		s.handleResponsesViaGoogle(c, provider, &req.ResponseNewParams, req.Model, actualModel, req.Stream, conv)
+		return
+	default:
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error: ErrorDetail{
-				Message: fmt.Sprintf("Responses API is only supported by OpenAI-style providers. Provider '%s' has API style: %s", provider.Name, provider.APIStyle),
+				Message: fmt.Sprintf("Responses API is not supported by provider '%s' with API style: %s", provider.Name, provider.APIStyle),
 				Type:    "invalid_request_error",
 			},
 		})
@@ -138,17 +187,25 @@ func (s *Server) ResponsesCreate(c *gin.Context) {
 		})
 		return
 	}
+	// The native request is parsed again from the body, compact it as well
+	if tf != nil {
+		tf.HandleOpenAIResponses(&params)
+	}
+	s.compactToolResults(c, scenarioType, func(tc *smart_compact.ToolResultCompactor) error {
+		return tc.HandleOpenAIResponses(&params)
+	})
 
 	// Handle streaming or non-streaming
 	if req.Stream {
-		s.handleResponsesStreamingRequest(c, provider, params, req.Model, actualModel)
+		s.handleResponsesStreamingRequest(c, provider, params, req.Model, actualModel, conv)
 	} else {
-		s.handleResponsesNonStreamingRequest(c, provider, params, req.Model, actualModel)
+		s.handleResponsesNonStreamingRequest(c, provider, params, req.Model, actualModel, conv)
 	}
 }
 
-// handleResponsesNonStreamingRequest handles non-streaming Responses API requests
-func (s *Server) handleResponsesNonStreamingRequest(c *gin.Context, provider *typ.Provider, params responses.ResponseNewParams, responseModel, actualModel string) {
+// handleResponsesNonStreamingRequest handles non-streaming Responses API requests.
+// conv is nil when the response should not be stored.
+func (s *Server) handleResponsesNonStreamingRequest(c *gin.Context, provider *typ.Provider, params responses.ResponseNewParams, responseModel, actualModel string, conv *responsesConversation) {
 	// Forward request to provider
 	var response *responses.Response
 	var err error
@@ -174,22 +231,20 @@ func (s *Server) handleResponsesNonStreamingRequest(c *gin.Context, provider *ty
 		return
 	}
 
-	// Extract usage from response
-	inputTokens := int64(response.Usage.InputTokens)
-	outputTokens := int64(response.Usage.OutputTokens)
-
-	// Track usage
-	s.trackUsageFromContext(c, int(inputTokens), int(outputTokens), nil)
+	// Track usage from response
+	s.trackUsageStatFromContext(c, protocol.NewUsageStatFromOpenAIResponses(response.Usage), nil)
 
 	// Check if this is a ChatGPT backend API provider (Codex OAuth)
 	// These providers return responses in a different format that needs conversion
 	if provider.APIBase == protocol.ChatGPTBackendAPIBase && response.ID != "" {
 		// Convert ChatGPT backend API response to OpenAI chat completion format
 		// The response was accumulated from streaming chunks in forwardChatGPTBackendRequest
-		s.convertChatGPTResponseToOpenAIChatCompletion(c, *response, responseModel, inputTokens, outputTokens)
+		s.convertChatGPTResponseToOpenAIChatCompletion(c, *response, responseModel, response.Usage.InputTokens, response.Usage.OutputTokens)
 		return
 	}
 
+	s.storeResponse(c, conv, responsesJSONMap(response.RawJSON(), responseModel))
+
 	// Override model in response if needed
 	if responseModel != actualModel {
 		// Create a copy of the response with updated model
@@ -206,8 +261,9 @@ func (s *Server) handleResponsesNonStreamingRequest(c *gin.Context, provider *ty
 	c.JSON(http.StatusOK, response)
 }
 
-// handleResponsesStreamingRequest handles streaming Responses API requests
-func (s *Server) handleResponsesStreamingRequest(c *gin.Context, provider *typ.Provider, params responses.ResponseNewParams, responseModel, actualModel string) {
+// handleResponsesStreamingRequest handles streaming Responses API requests.
+// conv is nil when the response should not be stored.
+func (s *Server) handleResponsesStreamingRequest(c *gin.Context, provider *typ.Provider, params responses.ResponseNewParams, responseModel, actualModel string, conv *responsesConversation) {
 	// Check if this is a ChatGPT backend API provider (Codex OAuth)
 	// These providers use a custom streaming handler
 	if provider.APIBase == protocol.ChatGPTBackendAPIBase {
@@ -233,11 +289,27 @@ func (s *Server) handleResponsesStreamingRequest(c *gin.Context, provider *typ.P
 	defer cancel()
 
 	// Handle the streaming response
-	hc := protocol.NewHandleContext(c, responseModel)
+	// Keep the final response object so that it can be stored
+	var completed string
+	hc := protocol.NewHandleContext(c, responseModel).WithOnStreamEvent(func(event interface{}) error {
+		if evt, ok := event.(responses.ResponseStreamEventUnion); ok && evt.Type == "response.completed" {
+			completed = evt.RawJSON()
+		}
+		return nil
+	})
 	usage, err := HandleOpenAIResponsesStream(hc, stream, responseModel)
 
 	// Track usage from stream handler
-	s.trackUsageFromContext(c, usage.InputTokens, usage.OutputTokens, err)
+	s.trackUsageStatFromContext(c, usage, err)
+
+	if err == nil && completed != "" {
+		var event struct {
+			Response json.RawMessage `json:"response"`
+		}
+		if json.Unmarshal([]byte(completed), &event) == nil {
+			s.storeResponse(c, conv, responsesJSONMap(string(event.Response), responseModel))
+		}
+	}
 }
 
 // handleResponsesStreamResponse processes the streaming response and sends it to the client
@@ -443,28 +515,3 @@ func (s *Server) convertToResponsesParams(bodyBytes []byte, actualModel string)
 
 	return params, nil
 }
-
-// ResponsesGet handles GET /v1/responses/{id}
-func (s *Server) ResponsesGet(c *gin.Context) {
-	responseID := c.Param("id")
-
-	if responseID == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: ErrorDetail{
-				Message: "Response ID is required",
-				Type:    "invalid_request_error",
-			},
-		})
-		return
-	}
-
-	// Phase 1: We don't store responses, so return not found
-	// In future phases, we would retrieve from storage
-	c.JSON(http.StatusNotFound, ErrorResponse{
-		Error: ErrorDetail{
-			Message: "Response retrieval is not supported in this version. Responses are not stored server-side.",
-			Type:    "invalid_request_error",
-			Code:    "response_not_found",
-		},
-	})
-}
@@ -13,7 +13,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 
+	"github.com/tingly-dev/tingly-box/internal/client"
 	"github.com/tingly-dev/tingly-box/internal/constant"
+	"github.com/tingly-dev/tingly-box/internal/protocol"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 )
 
@@ -78,12 +80,17 @@ func (s *Server) handlePassthroughRequest(c *gin.Context, apiStyle string) {
 		})
 		return
 	}
+	if !s.authorizeAPIKeyForRule(c, rule) || !s.rateLimitMW.AllowModel(c, requestModel) {
+		return
+	}
 	provider, selectedService, err := s.DetermineProviderAndModel(rule)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	s.setRouteDecisionHeaders(c, rule, provider, selectedService)
+
 	// Set the rule and provider in context
 	if rule != nil {
 		c.Set("rule", rule)
@@ -113,8 +120,8 @@ func (s *Server) handlePassthroughRequest(c *gin.Context, apiStyle string) {
 		"pass_through": true,
 	}).Debug("Pass-through handler proxying request")
 
-	// Proxy the request
-	if err := s.proxyPassthroughRequest(c, provider, modifiedRequestBody, apiStyle); err != nil {
+	// Proxy the request, bound to the next key of the provider
+	if err := s.proxyPassthroughRequest(c, s.clientPool.SelectKey(provider), modifiedRequestBody, apiStyle); err != nil {
 		logrus.WithError(err).Error("Failed to proxy request in pass-through handler")
 		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to proxy request"})
 	}
@@ -164,16 +171,28 @@ func (s *Server) proxyPassthroughRequest(c *gin.Context, provider *typ.Provider,
 		return fmt.Errorf("failed to create proxy request: %w", err)
 	}
 
-	// Copy headers
-	s.copyPassthroughHeaders(c.Request, proxyReq, provider)
+	// Copy headers, with a secret reference in the provider token resolved
+	resolved, err := client.ResolveProvider(provider)
+	if err != nil {
+		return fmt.Errorf("failed to resolve token for provider %s: %w", provider.Name, err)
+	}
+	s.copyPassthroughHeaders(c.Request, proxyReq, resolved)
 
 	// Use the HTTP client from pool for the request
 	resp, err := httpClient.Do(proxyReq)
 	if err != nil {
+		s.clientPool.RecordKeyResult(provider, 0, time.Time{}, 0, 0, true)
 		return fmt.Errorf("failed to execute proxy request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// Account the response to the key, so that a key rejected by the upstream is rotated out
+	var retryUntil time.Time
+	if rl, ok := client.ParseRateLimitHeaders(protocol.APIStyle(apiStyle), resp.Header, time.Now()); ok {
+		retryUntil = rl.RetryUntil
+	}
+	s.clientPool.RecordKeyResult(provider, resp.StatusCode, retryUntil, 0, 0, resp.StatusCode >= http.StatusBadRequest)
+
 	// Copy response headers
 	for key, values := range resp.Header {
 		for _, value := range values {
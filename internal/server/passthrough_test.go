@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tingly-dev/tingly-box/internal/client"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// TestProxyPassthroughRequest_RotatesRejectedKey verifies that a key the upstream rejects
+// on the pass-through path is taken out of rotation
+func TestProxyPassthroughRequest_RotatesRejectedKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var authorizations []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "Bearer token-revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	s := &Server{clientPool: client.NewClientPool()}
+	provider := &typ.Provider{
+		UUID:    "passthrough-keys",
+		Name:    "passthrough-keys",
+		APIBase: upstream.URL + "/v1",
+		Keys:    []*typ.ProviderKey{{ID: "revoked", Token: "token-revoked"}, {ID: "valid", Token: "token-valid"}},
+	}
+
+	for i := 0; i < 4; i++ {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/passthrough/openai/chat/completions", nil)
+		require.NoError(t, s.proxyPassthroughRequest(c, s.clientPool.SelectKey(provider), []byte(`{"model":"gpt-4o"}`), "openai"))
+	}
+
+	assert.Equal(t, []string{"Bearer token-revoked", "Bearer token-valid", "Bearer token-valid", "Bearer token-valid"}, authorizations)
+	for _, stats := range s.clientPool.KeyStats(provider) {
+		assert.Equal(t, stats.KeyID == "valid", stats.Available, "key %s", stats.KeyID)
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPricingOverrides returns the model price overrides
+func (s *Server) GetPricingOverrides(c *gin.Context) {
+	cfg := s.config
+	if cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Global config not available",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PricingOverridesResponse{
+		Success: true,
+		Data:    cfg.GetPricingOverrides(),
+	})
+}
+
+// SetPricingOverride sets the price override of a model, or removes it when no
+// pricing is given
+func (s *Server) SetPricingOverride(c *gin.Context) {
+	var req PricingOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if p := req.Pricing; p != nil && (p.Input < 0 || p.Output < 0 || p.CacheRead < 0 || p.CacheWrite < 0) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "prices cannot be negative",
+		})
+		return
+	}
+
+	cfg := s.config
+	if cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Global config not available",
+		})
+		return
+	}
+
+	if err := cfg.SetPricingOverride(req.Key, req.Pricing); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PricingOverridesResponse{
+		Success: true,
+		Data:    cfg.GetPricingOverrides(),
+	})
+}
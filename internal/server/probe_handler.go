@@ -11,6 +11,7 @@ import (
 	"github.com/tingly-dev/tingly-box/internal/client"
 	"github.com/tingly-dev/tingly-box/internal/obs"
 	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/secretref"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 )
 
@@ -40,6 +41,17 @@ func (s *Server) HandleProbeProvider(c *gin.Context) {
 		return
 	}
 
+	if err := secretref.CheckNoExec(req.Token); err != nil {
+		c.JSON(http.StatusBadRequest, ProbeProviderResponse{
+			Success: false,
+			Error: &ErrorDetail{
+				Message: err.Error(),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
 	// Start timing
 	startTime := time.Now()
 
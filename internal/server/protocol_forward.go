@@ -59,6 +59,12 @@ func ForwardAnthropicV1Stream(fc *ForwardContext, wrapper *client.AnthropicClien
 
 	logrus.Debugln("Creating Anthropic v1 streaming request")
 	stream := wrapper.MessagesNewStreaming(ctx, req)
+	// The request is sent when the stream is created, so connection and HTTP
+	// errors surface here before anything has been written to the client
+	if err := stream.Err(); err != nil {
+		cancel()
+		return nil, nil, err
+	}
 	return stream, cancel, nil
 }
 
@@ -100,6 +106,12 @@ func ForwardAnthropicV1BetaStream(fc *ForwardContext, wrapper *client.AnthropicC
 
 	logrus.Debugln("Creating Anthropic v1 beta streaming request")
 	stream := wrapper.BetaMessagesNewStreaming(ctx, req)
+	// The request is sent when the stream is created, so connection and HTTP
+	// errors surface here before anything has been written to the client
+	if err := stream.Err(); err != nil {
+		cancel()
+		return nil, nil, err
+	}
 	return stream, cancel, nil
 }
 
@@ -163,6 +175,12 @@ func ForwardOpenAIChatStream(fc *ForwardContext, wrapper *client.OpenAIClient, r
 	}
 
 	stream := wrapper.ChatCompletionsNewStreaming(ctx, *req)
+	// The request is sent when the stream is created, so connection and HTTP
+	// errors surface here before anything has been written to the client
+	if err := stream.Err(); err != nil {
+		cancel()
+		return nil, nil, err
+	}
 	return stream, cancel, nil
 }
 
@@ -199,9 +217,33 @@ func ForwardOpenAIResponsesStream(fc *ForwardContext, wrapper *client.OpenAIClie
 	}
 
 	stream := wrapper.ResponsesNewStreaming(ctx, params)
+	// The request is sent when the stream is created, so connection and HTTP
+	// errors surface here before anything has been written to the client
+	if err := stream.Err(); err != nil {
+		cancel()
+		return nil, nil, err
+	}
 	return stream, cancel, nil
 }
 
+// ForwardOpenAIEmbeddings sends an OpenAI embeddings request.
+func ForwardOpenAIEmbeddings(fc *ForwardContext, wrapper *client.OpenAIClient, params openai.EmbeddingNewParams) (*openai.CreateEmbeddingResponse, error) {
+	ctx, cancel, err := fc.PrepareContext(params)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	if wrapper == nil {
+		return nil, fmt.Errorf("failed to get OpenAI client for provider: %s", fc.Provider.Name)
+	}
+
+	resp, err := wrapper.EmbeddingsNew(ctx, params)
+	fc.Complete(ctx, resp, err)
+
+	return resp, err
+}
+
 // ===================================================================
 // Google Forward Functions
 // ===================================================================
@@ -239,7 +281,51 @@ func ForwardGoogleStream(fc *ForwardContext, wrapper *client.GoogleClient, model
 
 	logrus.Debugln("Creating Google streaming request")
 	stream := wrapper.GenerateContentStream(ctx, model, contents, config)
-	return stream, cancel, nil
+
+	// The request is only sent when the stream is iterated: wait for the first chunk, so that
+	// a failed request is returned here before anything reaches the client and can fail over
+	next, stop := iter.Pull2(stream)
+	first, err, ok := next()
+	if err != nil {
+		stop()
+		cancel()
+		return nil, nil, err
+	}
+
+	pulled := func(yield func(*genai.GenerateContentResponse, error) bool) {
+		defer stop()
+		if !ok || !yield(first, nil) {
+			return
+		}
+		for {
+			resp, err, ok := next()
+			if !ok || !yield(resp, err) {
+				return
+			}
+		}
+	}
+	return pulled, func() {
+		stop()
+		cancel()
+	}, nil
+}
+
+// ForwardGoogleEmbedContent sends a Google embedContent request, one content per input.
+func ForwardGoogleEmbedContent(fc *ForwardContext, wrapper *client.GoogleClient, model string, contents []*genai.Content, config *genai.EmbedContentConfig) (*genai.EmbedContentResponse, error) {
+	ctx, cancel, err := fc.PrepareContext(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	if wrapper == nil {
+		return nil, fmt.Errorf("failed to get Google client for provider: %s", fc.Provider.Name)
+	}
+
+	resp, err := wrapper.EmbedContent(ctx, model, contents, config)
+	fc.Complete(ctx, resp, err)
+
+	return resp, err
 }
 
 // ===================================================================
@@ -9,6 +9,7 @@ import (
 
 	"github.com/tingly-dev/tingly-box/internal/obs"
 	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/secretref"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 )
 
@@ -43,6 +44,7 @@ func maskProviderForResponse(provider *typ.Provider) ProviderResponse {
 		// For api_key (or empty for backward compatibility), return masked Token
 		//resp.Token = maskToken(provider.Token)
 		resp.Token = provider.Token
+		resp.Keys = provider.Keys
 	}
 
 	return resp
@@ -81,8 +83,8 @@ func (s *Server) CreateProvider(c *gin.Context) {
 		return
 	}
 
-	// Custom validation: token is required unless NoKeyRequired is true
-	if !req.NoKeyRequired && req.Token == "" {
+	// Custom validation: token (or additional keys) is required unless NoKeyRequired is true
+	if !req.NoKeyRequired && req.Token == "" && len(req.Keys) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Token is required when No Key Required is false",
@@ -90,6 +92,14 @@ func (s *Server) CreateProvider(c *gin.Context) {
 		return
 	}
 
+	if err := checkProviderTokens(req.Token, req.Keys); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	// Backend verification: Verify provider connection before saving (skip if no key required)
 	// This is a safety measure in addition to frontend verification
 	if !req.NoKeyRequired && req.Token != "" {
@@ -136,6 +146,7 @@ func (s *Server) CreateProvider(c *gin.Context) {
 		APIBase:       req.APIBase,
 		APIStyle:      protocol.APIStyle(req.APIStyle),
 		Token:         req.Token,
+		Keys:          req.Keys,
 		NoKeyRequired: req.NoKeyRequired,
 		Enabled:       req.Enabled,
 		ProxyURL:      req.ProxyURL,
@@ -237,6 +248,22 @@ func (s *Server) UpdateProvider(c *gin.Context) {
 		return
 	}
 
+	var token string
+	var keys []*typ.ProviderKey
+	if req.Token != nil {
+		token = *req.Token
+	}
+	if req.Keys != nil {
+		keys = *req.Keys
+	}
+	if err := checkProviderTokens(token, keys); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	// check existing
 	if req.Name != nil {
 		name := *req.Name
@@ -283,6 +310,9 @@ func (s *Server) UpdateProvider(c *gin.Context) {
 	if req.ProxyURL != nil {
 		provider.ProxyURL = *req.ProxyURL
 	}
+	if req.Keys != nil {
+		provider.Keys = mergeProviderKeys(provider.Keys, *req.Keys)
+	}
 
 	err = s.config.UpdateProvider(uid, provider)
 	if err != nil {
@@ -352,6 +382,60 @@ func (s *Server) GetProvider(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetProviderKeyStats returns the usage of each key of a provider
+func (s *Server) GetProviderKeyStats(c *gin.Context) {
+	provider, err := s.config.GetProviderByUUID(c.Param("uuid"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Provider not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ProviderKeyStatsResponse{
+		Success: true,
+		Data:    s.clientPool.KeyStats(provider),
+	})
+}
+
+// checkProviderTokens rejects exec: secret references in the token or keys of a provider
+// sent through the API: they would let API clients run commands on the server
+func checkProviderTokens(token string, keys []*typ.ProviderKey) error {
+	values := []string{token}
+	for _, k := range keys {
+		if k != nil {
+			values = append(values, k.Token)
+		}
+	}
+	return secretref.CheckNoExec(values...)
+}
+
+// mergeProviderKeys returns the updated key list. Like the provider token, a key
+// sent without a token keeps the token it already has.
+func mergeProviderKeys(current, updated []*typ.ProviderKey) []*typ.ProviderKey {
+	existing := make(map[string]*typ.ProviderKey, len(current))
+	for _, k := range current {
+		if k != nil && k.ID != "" {
+			existing[k.ID] = k
+		}
+	}
+
+	merged := make([]*typ.ProviderKey, 0, len(updated))
+	for _, k := range updated {
+		if k == nil {
+			continue
+		}
+		if k.Token == "" {
+			if old, ok := existing[k.ID]; ok {
+				k.Token = old.Token
+			}
+		}
+		merged = append(merged, k)
+	}
+	return merged
+}
+
 // ToggleProvider enables/disables a provider
 func (s *Server) ToggleProvider(c *gin.Context) {
 	uid := c.Param("uuid")
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRateLimits returns the rate limit rules of the model endpoints
+func (s *Server) GetRateLimits(c *gin.Context) {
+	cfg := s.config
+	if cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Global config not available",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, RateLimitsResponse{
+		Success: true,
+		Data:    cfg.GetRateLimitRules(),
+	})
+}
+
+// SetRateLimits replaces the rate limit rules of the model endpoints
+func (s *Server) SetRateLimits(c *gin.Context) {
+	var req RateLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	cfg := s.config
+	if cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Global config not available",
+		})
+		return
+	}
+
+	if err := cfg.SetRateLimitRules(req.Rules); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, RateLimitsResponse{
+		Success: true,
+		Data:    cfg.GetRateLimitRules(),
+	})
+}
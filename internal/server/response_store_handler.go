@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetResponseStoreSettings returns the retention of stored Responses API responses
+func (s *Server) GetResponseStoreSettings(c *gin.Context) {
+	cfg := s.config
+	if cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Global config not available",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseStoreSettingsResponse{
+		Success: true,
+		Data:    ResponseStoreSettings{TTLHours: cfg.GetResponseStoreTTLHours()},
+	})
+}
+
+// SetResponseStoreSettings updates the retention of stored Responses API responses
+func (s *Server) SetResponseStoreSettings(c *gin.Context) {
+	var req ResponseStoreSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if req.TTLHours == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ttl_hours must be positive, or negative to disable response storage",
+		})
+		return
+	}
+
+	cfg := s.config
+	if cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Global config not available",
+		})
+		return
+	}
+
+	if err := cfg.SetResponseStoreTTLHours(req.TTLHours); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ResponseStoreSettingsResponse{
+		Success: true,
+		Data:    ResponseStoreSettings{TTLHours: cfg.GetResponseStoreTTLHours()},
+	})
+}
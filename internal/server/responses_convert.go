@@ -0,0 +1,114 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/gin-gonic/gin"
+	"github.com/openai/openai-go/v3/responses"
+
+	"github.com/tingly-dev/tingly-box/internal/protocol/nonstream"
+	"github.com/tingly-dev/tingly-box/internal/protocol/request"
+	"github.com/tingly-dev/tingly-box/internal/protocol/stream"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// handleResponsesViaAnthropic serves a Responses API request with an Anthropic-style provider
+func (s *Server) handleResponsesViaAnthropic(c *gin.Context, provider *typ.Provider, params *responses.ResponseNewParams, responseModel, actualModel string, isStreaming bool, conv *responsesConversation) {
+	anthropicReq := request.ConvertResponsesToAnthropicRequest(params, int64(s.config.GetDefaultMaxTokens()))
+	anthropicReq.Model = anthropic.Model(actualModel)
+
+	// Cap max_tokens at the model's maximum to prevent API errors
+	if maxAllowed := int64(s.templateManager.GetMaxTokensForModelByProvider(provider, actualModel)); maxAllowed > 0 && anthropicReq.MaxTokens > maxAllowed {
+		anthropicReq.MaxTokens = maxAllowed
+		request.ClampAnthropicThinkingBudget(&anthropicReq)
+	}
+
+	wrapper := s.clientPool.GetAnthropicClient(provider, actualModel)
+
+	if isStreaming {
+		fc := NewForwardContext(c.Request.Context(), provider)
+		streamResp, cancel, err := ForwardAnthropicV1Stream(fc, wrapper, anthropicReq)
+		if err != nil {
+			s.trackUsageFromContext(c, 0, 0, err)
+			sendOpenAIForwardError(c, err)
+			return
+		}
+		defer cancel()
+
+		result, err := stream.HandleAnthropicToResponsesStream(c, streamResp, responseModel)
+		s.trackUsageStatFromContext(c, result.Usage, err)
+		if err == nil {
+			s.storeResponse(c, conv, result.Response(result.Status()))
+		}
+		return
+	}
+
+	fc := NewForwardContext(nil, provider)
+	anthropicResp, cancel, err := ForwardAnthropicV1(fc, wrapper, anthropicReq)
+	if err != nil {
+		s.trackUsageFromContext(c, 0, 0, err)
+		sendOpenAIForwardError(c, err)
+		return
+	}
+	defer cancel()
+
+	result := nonstream.ConvertAnthropicToResponsesResponse(anthropicResp, responseModel)
+	s.trackUsageStatFromContext(c, result.Usage, nil)
+	response := result.Response(result.Status())
+	s.storeResponse(c, conv, response)
+	c.JSON(http.StatusOK, response)
+}
+
+// handleResponsesViaGoogle serves a Responses API request with a Google-style provider
+func (s *Server) handleResponsesViaGoogle(c *gin.Context, provider *typ.Provider, params *responses.ResponseNewParams, responseModel, actualModel string, isStreaming bool, conv *responsesConversation) {
+	_, contents, config := request.ConvertResponsesToGoogleRequest(params, int64(s.config.GetDefaultMaxTokens()))
+
+	wrapper := s.clientPool.GetGoogleClient(provider, actualModel)
+
+	if isStreaming {
+		fc := NewForwardContext(c.Request.Context(), provider)
+		streamResp, cancel, err := ForwardGoogleStream(fc, wrapper, actualModel, contents, config)
+		if err != nil {
+			s.trackUsageFromContext(c, 0, 0, err)
+			sendOpenAIForwardError(c, err)
+			return
+		}
+		defer cancel()
+
+		result, err := stream.HandleGoogleToResponsesStream(c, streamResp, responseModel)
+		s.trackUsageStatFromContext(c, result.Usage, err)
+		if err == nil {
+			s.storeResponse(c, conv, result.Response(result.Status()))
+		}
+		return
+	}
+
+	fc := NewForwardContext(nil, provider)
+	googleResp, err := ForwardGoogle(fc, wrapper, actualModel, contents, config)
+	if err != nil {
+		s.trackUsageFromContext(c, 0, 0, err)
+		sendOpenAIForwardError(c, err)
+		return
+	}
+
+	result := nonstream.ConvertGoogleToResponsesResponse(googleResp, responseModel)
+	s.trackUsageStatFromContext(c, result.Usage, nil)
+	response := result.Response(result.Status())
+	s.storeResponse(c, conv, response)
+	c.JSON(http.StatusOK, response)
+}
+
+// sendOpenAIForwardError reports a failed upstream request in OpenAI error format
+func sendOpenAIForwardError(c *gin.Context, err error) {
+	status := upstreamStatusCode(err)
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	c.JSON(status, ErrorResponse{
+		Error: ErrorDetail{
+			Message: "Failed to forward request: " + err.Error(),
+			Type:    "api_error",
+		},
+	})
+}
@@ -0,0 +1,450 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tingly-dev/tingly-box/internal/data/db"
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/server/middleware"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// Limits of the input_items listing
+const (
+	responsesInputItemsDefaultLimit = 20
+	responsesInputItemsMaxLimit     = 100
+)
+
+// maxResponseChainLength bounds how many stored responses a previous_response_id chain may span
+const maxResponseChainLength = 1000
+
+// responsesConversation tracks the previous_response_id of a Responses API request and
+// its new input items, kept to store the response afterwards
+type responsesConversation struct {
+	input              []interface{}
+	previousResponseID string
+	// chain holds the IDs of the stored responses the request continues
+	chain []string
+	// unresolved is set when previous_response_id is not a stored response: it can then
+	// only be served by an upstream that stores responses itself
+	unresolved bool
+	store      bool
+}
+
+// expandPreviousResponse resolves previous_response_id inside the gateway: the stored history
+// of the previous response is prepended to the request input and the field is removed, which
+// is what lets stateless providers (Anthropic, Google) continue a stored conversation.
+// An ID the gateway does not know is left in the request, for OpenAI-style upstreams that
+// store responses themselves; see requireResolvedPreviousResponse.
+// Returns false when an error response has been sent.
+func (s *Server) expandPreviousResponse(c *gin.Context, bodyBytes []byte) ([]byte, *responsesConversation, bool) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(bodyBytes, &raw); err != nil {
+		// Invalid bodies are reported by the request parsing
+		return bodyBytes, nil, true
+	}
+
+	conv := &responsesConversation{
+		input: responsesInputList(raw["input"]),
+		store: raw["store"] != false,
+	}
+
+	previousID, _ := raw["previous_response_id"].(string)
+	if previousID == "" {
+		return bodyBytes, conv, true
+	}
+	conv.previousResponseID = previousID
+
+	chain, found := s.responseChain(c, previousID)
+	if !found {
+		conv.unresolved = true
+		return bodyBytes, conv, true
+	}
+
+	history, err := responseChainHistory(chain)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: ErrorDetail{
+				Message: "Failed to load previous response: " + err.Error(),
+				Type:    "api_error",
+			},
+		})
+		return nil, nil, false
+	}
+	for _, record := range chain {
+		conv.chain = append(conv.chain, record.ID)
+	}
+
+	raw["input"] = append(history, conv.input...)
+	delete(raw, "previous_response_id")
+
+	expanded, err := json.Marshal(raw)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: ErrorDetail{
+				Message: "Failed to expand previous response: " + err.Error(),
+				Type:    "api_error",
+			},
+		})
+		return nil, nil, false
+	}
+	return expanded, conv, true
+}
+
+// requireResolvedPreviousResponse rejects a previous_response_id the gateway could not resolve
+// unless the provider is OpenAI-style, which receives it unchanged.
+// Returns false when an error response has been sent.
+func requireResolvedPreviousResponse(c *gin.Context, conv *responsesConversation, provider *typ.Provider) bool {
+	if conv == nil || !conv.unresolved || provider.APIStyle == protocol.APIStyleOpenAI {
+		return true
+	}
+	c.JSON(http.StatusBadRequest, ErrorResponse{
+		Error: ErrorDetail{
+			Message: fmt.Sprintf("Previous response with id '%s' not found.", conv.previousResponseID),
+			Type:    "invalid_request_error",
+			Code:    "previous_response_not_found",
+		},
+	})
+	return false
+}
+
+// responseChain returns the stored responses from the start of a conversation up to the
+// response with the given ID, oldest first. found is false when one of them is not stored.
+func (s *Server) responseChain(c *gin.Context, id string) ([]*db.ResponseRecord, bool) {
+	var chain []*db.ResponseRecord
+	seen := make(map[string]bool)
+	for id != "" {
+		if seen[id] || len(chain) >= maxResponseChainLength {
+			return nil, false
+		}
+		seen[id] = true
+
+		record, found := s.lookupStoredResponse(c, id)
+		if !found {
+			return nil, false
+		}
+		chain = append(chain, record)
+		id = record.PreviousResponseID
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, true
+}
+
+// responseChainHistory returns the input and output items of a chain of stored responses
+func responseChainHistory(chain []*db.ResponseRecord) ([]interface{}, error) {
+	var history []interface{}
+	for _, record := range chain {
+		items, err := storedResponseHistory(record)
+		if err != nil {
+			return nil, fmt.Errorf("response %s: %w", record.ID, err)
+		}
+		history = append(history, items...)
+	}
+	return history, nil
+}
+
+// responsesInputList returns the input of a Responses API request as a list of items.
+// A plain string input becomes a single user message.
+func responsesInputList(input interface{}) []interface{} {
+	switch v := input.(type) {
+	case string:
+		return []interface{}{
+			map[string]interface{}{"type": "message", "role": "user", "content": v},
+		}
+	case []interface{}:
+		return v
+	}
+	return nil
+}
+
+// storedResponseHistory returns the input items followed by the output items of a stored response
+func storedResponseHistory(record *db.ResponseRecord) ([]interface{}, error) {
+	var input, output []interface{}
+	if err := json.Unmarshal([]byte(record.Input), &input); err != nil {
+		return nil, fmt.Errorf("invalid stored input: %w", err)
+	}
+	if err := json.Unmarshal([]byte(record.Output), &output); err != nil {
+		return nil, fmt.Errorf("invalid stored output: %w", err)
+	}
+	return append(input, output...), nil
+}
+
+// responsesJSONMap parses a raw response object and applies the response model
+func responsesJSONMap(rawJSON string, responseModel string) map[string]interface{} {
+	if rawJSON == "" {
+		return nil
+	}
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(rawJSON), &response); err != nil {
+		return nil
+	}
+	if responseModel != "" {
+		response["model"] = responseModel
+	}
+	return response
+}
+
+// storeResponse saves a response so that it can be retrieved and continued with previous_response_id.
+// Nothing is stored when the request opted out with store=false or storage is disabled.
+func (s *Server) storeResponse(c *gin.Context, conv *responsesConversation, response map[string]interface{}) {
+	if s.responseStore == nil || conv == nil || !conv.store || response == nil {
+		return
+	}
+	ttl := s.config.GetResponseStoreTTL()
+	if ttl <= 0 {
+		return
+	}
+
+	id, _ := response["id"].(string)
+	if id == "" {
+		return
+	}
+	model, _ := response["model"].(string)
+
+	output, _ := response["output"].([]interface{})
+	if output == nil {
+		// Results built by the converters carry typed item maps
+		if items, ok := response["output"].([]map[string]interface{}); ok {
+			for _, item := range items {
+				output = append(output, item)
+			}
+		}
+	}
+
+	inputJSON, err := json.Marshal(conv.input)
+	if err != nil {
+		logrus.Warnf("Failed to store response %s: %v", id, err)
+		return
+	}
+	outputJSON, err := json.Marshal(output)
+	if err != nil {
+		logrus.Warnf("Failed to store response %s: %v", id, err)
+		return
+	}
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		logrus.Warnf("Failed to store response %s: %v", id, err)
+		return
+	}
+
+	now := time.Now()
+	record := &db.ResponseRecord{
+		ID:                 id,
+		Model:              model,
+		PreviousResponseID: conv.previousResponseID,
+		APIKeyID:           c.GetString(middleware.ContextKeyAPIKeyID),
+		Input:              string(inputJSON),
+		Output:             string(outputJSON),
+		Response:           string(responseJSON),
+		CreatedAt:          now,
+		ExpiresAt:          now.Add(ttl),
+	}
+	if err := s.responseStore.Save(record); err != nil {
+		logrus.Warnf("Failed to store response %s: %v", id, err)
+		return
+	}
+	// The response needs the ones it continues to rebuild its history
+	if err := s.responseStore.ExtendExpiry(conv.chain, record.ExpiresAt); err != nil {
+		logrus.Warnf("Failed to store response %s: %v", id, err)
+	}
+}
+
+// lookupStoredResponse returns a stored response visible to the caller.
+// Responses created with a named API key are only visible to that key.
+func (s *Server) lookupStoredResponse(c *gin.Context, id string) (*db.ResponseRecord, bool) {
+	if s.responseStore == nil {
+		return nil, false
+	}
+	record, found, err := s.responseStore.Get(id)
+	if err != nil {
+		logrus.Warnf("Failed to load response %s: %v", id, err)
+		return nil, false
+	}
+	if !found {
+		return nil, false
+	}
+	if keyID := c.GetString(middleware.ContextKeyAPIKeyID); keyID != "" && record.APIKeyID != keyID {
+		return nil, false
+	}
+	return record, true
+}
+
+// requireStoredResponse loads the response named by the id path parameter,
+// sending an error response when it does not exist
+func (s *Server) requireStoredResponse(c *gin.Context) (*db.ResponseRecord, bool) {
+	responseID := c.Param("id")
+	if responseID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: ErrorDetail{
+				Message: "Response ID is required",
+				Type:    "invalid_request_error",
+			},
+		})
+		return nil, false
+	}
+
+	record, found := s.lookupStoredResponse(c, responseID)
+	if !found {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: ErrorDetail{
+				Message: fmt.Sprintf("Response with id '%s' not found.", responseID),
+				Type:    "invalid_request_error",
+				Code:    "response_not_found",
+			},
+		})
+		return nil, false
+	}
+	return record, true
+}
+
+// ResponsesGet handles GET /v1/responses/{id}
+func (s *Server) ResponsesGet(c *gin.Context) {
+	record, ok := s.requireStoredResponse(c)
+	if !ok {
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(record.Response))
+}
+
+// ResponsesDelete handles DELETE /v1/responses/{id}
+func (s *Server) ResponsesDelete(c *gin.Context) {
+	record, ok := s.requireStoredResponse(c)
+	if !ok {
+		return
+	}
+
+	if _, err := s.responseStore.Delete(record.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: ErrorDetail{
+				Message: "Failed to delete response: " + err.Error(),
+				Type:    "api_error",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      record.ID,
+		"object":  "response",
+		"deleted": true,
+	})
+}
+
+// ResponsesInputItems handles GET /v1/responses/{id}/input_items.
+// The listing covers the full conversation the response was generated from,
+// including the history expanded from previous_response_id.
+func (s *Server) ResponsesInputItems(c *gin.Context) {
+	record, ok := s.requireStoredResponse(c)
+	if !ok {
+		return
+	}
+
+	limit := responsesInputItemsDefaultLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > responsesInputItemsMaxLimit {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: ErrorDetail{
+					Message: fmt.Sprintf("limit must be between 1 and %d", responsesInputItemsMaxLimit),
+					Type:    "invalid_request_error",
+				},
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	items, err := s.responseInputItems(c, record)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: ErrorDetail{
+				Message: "Failed to load input items: " + err.Error(),
+				Type:    "api_error",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, paginateResponsesInputItems(items, c.DefaultQuery("order", "desc"), c.Query("after"), limit))
+}
+
+// responseInputItems returns the conversation a stored response was generated from: the
+// history of the responses it continues followed by its own input. A history that is no
+// longer stored is left out.
+func (s *Server) responseInputItems(c *gin.Context, record *db.ResponseRecord) ([]interface{}, error) {
+	var items []interface{}
+	if record.PreviousResponseID != "" {
+		if chain, found := s.responseChain(c, record.PreviousResponseID); found {
+			history, err := responseChainHistory(chain)
+			if err != nil {
+				return nil, err
+			}
+			items = history
+		}
+	}
+
+	var input []interface{}
+	if err := json.Unmarshal([]byte(record.Input), &input); err != nil {
+		return nil, fmt.Errorf("invalid stored input: %w", err)
+	}
+	return append(items, input...), nil
+}
+
+// paginateResponsesInputItems builds an input_items list page. Items are returned newest first
+// unless order is "asc"; after is the ID of the item preceding the page.
+func paginateResponsesInputItems(items []interface{}, order, after string, limit int) gin.H {
+	ordered := make([]interface{}, 0, len(items))
+	if order == "asc" {
+		ordered = append(ordered, items...)
+	} else {
+		for i := len(items) - 1; i >= 0; i-- {
+			ordered = append(ordered, items[i])
+		}
+	}
+
+	if after != "" {
+		for i, item := range ordered {
+			if responsesItemID(item) == after {
+				ordered = ordered[i+1:]
+				break
+			}
+		}
+	}
+
+	hasMore := len(ordered) > limit
+	if hasMore {
+		ordered = ordered[:limit]
+	}
+
+	page := gin.H{
+		"object":   "list",
+		"data":     ordered,
+		"first_id": nil,
+		"last_id":  nil,
+		"has_more": hasMore,
+	}
+	if len(ordered) > 0 {
+		page["first_id"] = responsesItemID(ordered[0])
+		page["last_id"] = responsesItemID(ordered[len(ordered)-1])
+	}
+	return page
+}
+
+// responsesItemID returns the id of an input or output item, if it has one
+func responsesItemID(item interface{}) string {
+	if m, ok := item.(map[string]interface{}); ok {
+		id, _ := m["id"].(string)
+		return id
+	}
+	return ""
+}
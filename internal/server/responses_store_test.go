@@ -0,0 +1,115 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tingly-dev/tingly-box/internal/data/db"
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+func TestResponsesInputList(t *testing.T) {
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"type": "message", "role": "user", "content": "hello"},
+	}, responsesInputList("hello"))
+
+	items := []interface{}{map[string]interface{}{"type": "function_call_output", "call_id": "call_1", "output": "ok"}}
+	assert.Equal(t, items, responsesInputList(items))
+
+	assert.Nil(t, responsesInputList(nil))
+}
+
+func TestStoredResponseHistory(t *testing.T) {
+	record := &db.ResponseRecord{
+		Input:  `[{"type":"message","role":"user","content":"What is the weather?"}]`,
+		Output: `[{"type":"function_call","id":"fc_1","call_id":"call_1","name":"get_weather","arguments":"{}"}]`,
+	}
+
+	history, err := storedResponseHistory(record)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "message", history[0].(map[string]interface{})["type"])
+	assert.Equal(t, "function_call", history[1].(map[string]interface{})["type"])
+
+	_, err = storedResponseHistory(&db.ResponseRecord{Input: "not json", Output: "[]"})
+	assert.Error(t, err)
+}
+
+func TestExpandPreviousResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{}
+
+	t.Run("without previous response", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		body := []byte(`{"model":"gpt-4o","input":"hi","store":false}`)
+
+		expanded, conv, ok := s.expandPreviousResponse(c, body)
+		require.True(t, ok)
+		assert.Equal(t, body, expanded)
+		assert.False(t, conv.store)
+		assert.Len(t, conv.input, 1)
+	})
+
+	t.Run("unknown previous response", func(t *testing.T) {
+		body := []byte(`{"model":"gpt-4o","input":"hi","previous_response_id":"resp_missing"}`)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+		expanded, conv, ok := s.expandPreviousResponse(c, body)
+		require.True(t, ok)
+		assert.Equal(t, body, expanded, "unknown IDs are left for the upstream")
+		assert.True(t, conv.unresolved)
+		assert.Equal(t, "resp_missing", conv.previousResponseID)
+
+		// OpenAI-style upstreams receive the ID unchanged
+		c, _ = gin.CreateTestContext(httptest.NewRecorder())
+		assert.True(t, requireResolvedPreviousResponse(c, conv, &typ.Provider{APIStyle: protocol.APIStyleOpenAI}))
+
+		// Stateless upstreams cannot continue it
+		w := httptest.NewRecorder()
+		c, _ = gin.CreateTestContext(w)
+		assert.False(t, requireResolvedPreviousResponse(c, conv, &typ.Provider{APIStyle: protocol.APIStyleAnthropic}))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "previous_response_not_found")
+	})
+}
+
+func TestResponseChainHistory(t *testing.T) {
+	chain := []*db.ResponseRecord{
+		{ID: "resp_1", Input: `[{"type":"message","role":"user","content":"first"}]`, Output: `[{"type":"message","role":"assistant","content":"one"}]`},
+		{ID: "resp_2", PreviousResponseID: "resp_1", Input: `[{"type":"message","role":"user","content":"second"}]`, Output: `[{"type":"message","role":"assistant","content":"two"}]`},
+	}
+
+	history, err := responseChainHistory(chain)
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	assert.Equal(t, "first", history[0].(map[string]interface{})["content"])
+	assert.Equal(t, "two", history[3].(map[string]interface{})["content"])
+}
+
+func TestPaginateResponsesInputItems(t *testing.T) {
+	items := []interface{}{
+		map[string]interface{}{"id": "msg_1"},
+		map[string]interface{}{"id": "msg_2"},
+		map[string]interface{}{"id": "msg_3"},
+	}
+
+	page := paginateResponsesInputItems(items, "desc", "", 2)
+	assert.Equal(t, "msg_3", page["first_id"])
+	assert.Equal(t, "msg_2", page["last_id"])
+	assert.Equal(t, true, page["has_more"])
+
+	page = paginateResponsesInputItems(items, "asc", "msg_1", 20)
+	assert.Equal(t, "msg_2", page["first_id"])
+	assert.Equal(t, "msg_3", page["last_id"])
+	assert.Equal(t, false, page["has_more"])
+
+	page = paginateResponsesInputItems(nil, "desc", "", 20)
+	assert.Nil(t, page["first_id"])
+	assert.Empty(t, page["data"])
+}
@@ -0,0 +1,328 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/protocol/request"
+	"github.com/tingly-dev/tingly-box/internal/server/middleware"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// Response headers naming the routing decision, sent when Config.RouteDecisionHeaders is enabled
+const (
+	HeaderTinglyRule    = "x-tingly-rule"    // UUID of the rule the request matched
+	HeaderTinglyService = "x-tingly-service" // Provider name and model of the service that served it
+)
+
+// Request formats accepted by the routing dry-run
+const (
+	RouteFormatOpenAI        = "openai"
+	RouteFormatAnthropic     = "anthropic"
+	RouteFormatAnthropicBeta = "anthropic_beta"
+	RouteFormatGoogle        = "google"
+)
+
+// Sources of the service chosen for a request
+const (
+	RouteSourceSessionAffinity = "session_affinity"
+	RouteSourceSmartRouting    = "smart_routing"
+	RouteSourceLoadBalancer    = "load_balancer"
+)
+
+// setRouteDecisionHeaders names the rule and service serving the request in the response
+// headers, when enabled in the config. Failover attempts overwrite them with the service
+// that is tried next.
+func (s *Server) setRouteDecisionHeaders(c *gin.Context, rule *typ.Rule, provider *typ.Provider, service *loadbalance.Service) {
+	if s.config == nil || !s.config.GetRouteDecisionHeaders() || rule == nil || provider == nil || service == nil {
+		return
+	}
+	c.Header(HeaderTinglyRule, rule.UUID)
+	c.Header(HeaderTinglyService, provider.Name+"/"+service.Model)
+}
+
+// GetRouteHeadersSettings returns whether proxied responses carry the routing decision headers
+func (s *Server) GetRouteHeadersSettings(c *gin.Context) {
+	cfg := s.config
+	if cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Global config not available",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, RouteHeadersSettingsResponse{
+		Success: true,
+		Data:    RouteHeadersSettings{Enabled: cfg.GetRouteDecisionHeaders()},
+	})
+}
+
+// SetRouteHeadersSettings turns the routing decision headers on or off
+func (s *Server) SetRouteHeadersSettings(c *gin.Context) {
+	var req RouteHeadersSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	cfg := s.config
+	if cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Global config not available",
+		})
+		return
+	}
+
+	if err := cfg.SetRouteDecisionHeaders(req.Enabled); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, RouteHeadersSettingsResponse{
+		Success: true,
+		Data:    RouteHeadersSettings{Enabled: cfg.GetRouteDecisionHeaders()},
+	})
+}
+
+// ExplainRoute runs the routing of a request without sending it upstream and reports
+// the matched rule, the result of every smart routing operation, the candidates of
+// the load balancing tactic and the service that would be chosen
+func (s *Server) ExplainRoute(c *gin.Context) {
+	var req RouteExplainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	cfg := s.config
+	if cfg == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Global config not available",
+		})
+		return
+	}
+
+	scenario := typ.RuleScenario(req.Scenario)
+	if !isValidRuleScenario(scenario) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("invalid scenario: %s", req.Scenario),
+		})
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = detectRouteFormat(scenario, req.Request)
+	}
+	model, reqParams, err := parseRouteRequest(format, req.Request, req.Model)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	rule, err := s.determineRuleWithScenario(scenario, model)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	explanation := &RouteExplanation{
+		Scenario:     string(scenario),
+		Format:       format,
+		RequestModel: model,
+		Rule:         rule,
+		Tactic:       rule.GetTacticType().String(),
+	}
+
+	// Route the request as its handler would, with the explained headers and API key
+	rc := s.explainContext(c, req)
+	if rule.SmartEnabled && len(rule.SmartRouting) > 0 {
+		if ctx, _ := s.ExtractRequestContext(rc, reqParams); ctx != nil {
+			router, err := rule.SmartRouter()
+			if err != nil {
+				explanation.SmartError = err.Error()
+			} else {
+				explanation.SmartRouting = router.Explain(ctx)
+			}
+		}
+	}
+
+	provider, chosen, decision, err := s.routeRequest(rc, rule, reqParams, true)
+	explanation.Source = decision.source
+	explanation.ContextOverflow = decision.contextOverflow
+	candidates := decision.candidates
+	if err != nil {
+		explanation.NoService = err.Error()
+	} else {
+		explanation.Service = chosen
+		explanation.ProviderName = provider.Name
+	}
+
+	explanation.Candidates = make([]RouteCandidate, 0, len(candidates))
+	for _, svc := range candidates {
+		candidate := RouteCandidate{
+			Provider:  svc.Provider,
+			Model:     svc.Model,
+			Weight:    svc.Weight,
+			Active:    svc.Active,
+			Available: svc.IsAvailable(),
+		}
+		if provider, err := cfg.GetProviderByUUID(svc.Provider); err == nil {
+			candidate.ProviderName = provider.Name
+			candidate.ProviderEnabled = provider.Enabled
+		}
+		explanation.Candidates = append(explanation.Candidates, candidate)
+	}
+
+	c.JSON(http.StatusOK, RouteExplainResponse{
+		Success: true,
+		Data:    explanation,
+	})
+}
+
+// explainContext returns a copy of the context of the dry-run request carrying the headers
+// and the API key of the explained request, for routing to read them as it would
+func (s *Server) explainContext(c *gin.Context, req RouteExplainRequest) *gin.Context {
+	rc := c.Copy()
+	rc.Request = c.Request.Clone(c.Request.Context())
+	rc.Request.Header = make(http.Header, len(req.Headers))
+	for name, value := range req.Headers {
+		rc.Request.Header.Set(name, value)
+	}
+
+	delete(rc.Keys, middleware.ContextKeyAPIKey)
+	if key := s.lookupAPIKey(req.APIKey); key != nil {
+		rc.Set(middleware.ContextKeyAPIKey, key)
+	}
+	return rc
+}
+
+// previewSmartRoutingService returns the service SelectServiceFromSmartRouting would select
+// from the matched services, without advancing the round-robin state
+func (s *Server) previewSmartRoutingService(matchedServices []*loadbalance.Service, rule *typ.Rule) *loadbalance.Service {
+	var activeServices []*loadbalance.Service
+	for _, service := range matchedServices {
+		if service.Active {
+			activeServices = append(activeServices, service)
+		}
+	}
+	if len(activeServices) == 0 {
+		return nil
+	}
+
+	tempRule := *rule
+	tempRule.Services = activeServices
+	tempRule.CurrentServiceID = ""
+	selected, err := s.loadBalancer.PreviewService(&tempRule)
+	if err != nil {
+		return nil
+	}
+	return selected
+}
+
+// lookupAPIKey returns the API key with the given name or ID, or nil
+func (s *Server) lookupAPIKey(nameOrID string) *typ.APIKey {
+	if nameOrID == "" {
+		return nil
+	}
+	for _, key := range s.config.ListAPIKeys() {
+		if key.ID == nameOrID || key.Name == nameOrID {
+			return &key
+		}
+	}
+	return nil
+}
+
+// detectRouteFormat guesses the format of a request body: Google bodies carry contents,
+// otherwise the scenario decides between the Anthropic and OpenAI formats
+func detectRouteFormat(scenario typ.RuleScenario, body json.RawMessage) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err == nil {
+		if _, ok := fields["contents"]; ok {
+			return RouteFormatGoogle
+		}
+	}
+	switch scenario {
+	case typ.ScenarioAnthropic, typ.ScenarioClaudeCode:
+		return RouteFormatAnthropic
+	default:
+		return RouteFormatOpenAI
+	}
+}
+
+// parseRouteRequest parses a request body in the given format and returns its model and
+// the request the handlers pass to smart routing. model overrides the model of the body.
+func parseRouteRequest(format string, body json.RawMessage, model string) (string, interface{}, error) {
+	switch format {
+	case RouteFormatOpenAI:
+		var req protocol.OpenAIChatCompletionRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return "", nil, fmt.Errorf("invalid OpenAI request: %w", err)
+		}
+		if model == "" {
+			model = req.Model
+		}
+		return model, &req.ChatCompletionNewParams, requireRouteModel(model)
+	case RouteFormatAnthropic:
+		var req protocol.AnthropicMessagesRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return "", nil, fmt.Errorf("invalid Anthropic request: %w", err)
+		}
+		if model == "" {
+			model = string(req.Model)
+		}
+		return model, &req.MessageNewParams, requireRouteModel(model)
+	case RouteFormatAnthropicBeta:
+		var req protocol.AnthropicBetaMessagesRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return "", nil, fmt.Errorf("invalid Anthropic request: %w", err)
+		}
+		if model == "" {
+			model = string(req.Model)
+		}
+		return model, &req.BetaMessageNewParams, requireRouteModel(model)
+	case RouteFormatGoogle:
+		var req protocol.GoogleGenerateContentRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return "", nil, fmt.Errorf("invalid Google request: %w", err)
+		}
+		if err := requireRouteModel(model); err != nil {
+			return "", nil, fmt.Errorf("model is required for google requests")
+		}
+		// Smart routing inspects Google requests in OpenAI form
+		return model, request.ConvertGoogleToOpenAIRequest(model, req.Contents, req.Config()), nil
+	default:
+		return "", nil, fmt.Errorf("unknown format '%s' (openai, anthropic, anthropic_beta or google)", format)
+	}
+}
+
+func requireRouteModel(model string) error {
+	if model == "" {
+		return fmt.Errorf("model is required")
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/server/config"
+	smartrouting "github.com/tingly-dev/tingly-box/internal/smart_routing"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+func newRouteExplainServer(t *testing.T) (*Server, *typ.Rule) {
+	t.Helper()
+	cfg, err := config.NewConfigWithDir(t.TempDir())
+	require.NoError(t, err)
+
+	for _, p := range []*typ.Provider{
+		{UUID: "p-default", Name: "default-provider", APIBase: "https://a.example.com/v1", Token: "sk-a", Enabled: true},
+		{UUID: "p-thinking", Name: "thinking-provider", APIBase: "https://b.example.com/v1", Token: "sk-b", Enabled: true},
+	} {
+		require.NoError(t, cfg.AddProvider(p))
+	}
+
+	rule := typ.Rule{
+		UUID:         "explain-rule",
+		Scenario:     typ.ScenarioOpenAI,
+		RequestModel: "explain-model",
+		Services:     []*loadbalance.Service{{Provider: "p-default", Model: "gpt-4o-mini", Weight: 1, Active: true}},
+		Active:       true,
+		SmartEnabled: true,
+		SmartRouting: []smartrouting.SmartRouting{{
+			Description: "cli clients",
+			Ops: []smartrouting.SmartOp{{
+				Position:  smartrouting.PositionHeader,
+				Operation: smartrouting.OpHeaderEquals,
+				Value:     "x-app: cli",
+			}},
+			Services: []*loadbalance.Service{{Provider: "p-thinking", Model: "o3", Weight: 1, Active: true}},
+		}},
+	}
+	require.NoError(t, cfg.AddRule(rule))
+
+	return &Server{config: cfg, loadBalancer: NewLoadBalancer(cfg)}, &rule
+}
+
+func explainRoute(t *testing.T, s *Server, body RouteExplainRequest) (int, RouteExplainResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/route/explain", bytes.NewReader(data))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	s.ExplainRoute(c)
+
+	var resp RouteExplainResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+func TestExplainRoute(t *testing.T) {
+	s, _ := newRouteExplainServer(t)
+	request := json.RawMessage(`{"model":"explain-model","messages":[{"role":"user","content":"hi"}]}`)
+
+	code, resp := explainRoute(t, s, RouteExplainRequest{Scenario: "openai", Request: request})
+	require.Equal(t, http.StatusOK, code)
+	require.NotNil(t, resp.Data)
+	assert.Equal(t, RouteFormatOpenAI, resp.Data.Format)
+	assert.Equal(t, "explain-rule", resp.Data.Rule.UUID)
+	require.Len(t, resp.Data.SmartRouting, 1)
+	assert.False(t, resp.Data.SmartRouting[0].Matched)
+	assert.Equal(t, RouteSourceLoadBalancer, resp.Data.Source)
+	require.NotNil(t, resp.Data.Service)
+	assert.Equal(t, "gpt-4o-mini", resp.Data.Service.Model)
+	assert.Equal(t, "default-provider", resp.Data.ProviderName)
+
+	code, resp = explainRoute(t, s, RouteExplainRequest{
+		Scenario: "openai",
+		Request:  request,
+		Headers:  map[string]string{"X-App": "cli"},
+	})
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, resp.Data.SmartRouting[0].Selected)
+	assert.True(t, resp.Data.SmartRouting[0].Ops[0].Matched)
+	assert.Equal(t, RouteSourceSmartRouting, resp.Data.Source)
+	require.Len(t, resp.Data.Candidates, 1)
+	assert.Equal(t, "thinking-provider", resp.Data.Candidates[0].ProviderName)
+	assert.Equal(t, "o3", resp.Data.Service.Model)
+
+	code, _ = explainRoute(t, s, RouteExplainRequest{Scenario: "openai", Request: json.RawMessage(`{"model":"unknown","messages":[]}`)})
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = explainRoute(t, s, RouteExplainRequest{Scenario: "google", Request: json.RawMessage(`{"contents":[]}`)})
+	assert.Equal(t, http.StatusBadRequest, code, "google bodies need the model")
+}
+
+func TestSetRouteDecisionHeaders(t *testing.T) {
+	s, rule := newRouteExplainServer(t)
+	provider := &typ.Provider{UUID: "p-default", Name: "default-provider"}
+	service := rule.Services[0]
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	s.setRouteDecisionHeaders(c, rule, provider, service)
+	assert.Empty(t, w.Header().Get(HeaderTinglyRule), "headers are off by default")
+
+	require.NoError(t, s.config.SetRouteDecisionHeaders(true))
+	s.setRouteDecisionHeaders(c, rule, provider, service)
+	assert.Equal(t, "explain-rule", w.Header().Get(HeaderTinglyRule))
+	assert.Equal(t, "default-provider/gpt-4o-mini", w.Header().Get(HeaderTinglyService))
+}
+
+// TestRouteHeadersSettings verifies that the routing decision headers can be turned on
+// and off through the API
+func TestRouteHeadersSettings(t *testing.T) {
+	s, _ := newRouteExplainServer(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/route/headers", s.GetRouteHeadersSettings)
+	router.PUT("/route/headers", s.SetRouteHeadersSettings)
+
+	request := func(method, body string) RouteHeadersSettingsResponse {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/route/headers", bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp RouteHeadersSettingsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	assert.False(t, request(http.MethodGet, "").Data.Enabled)
+	assert.True(t, request(http.MethodPut, `{"enabled":true}`).Data.Enabled)
+	assert.True(t, s.config.GetRouteDecisionHeaders())
+	assert.False(t, request(http.MethodPut, `{"enabled":false}`).Data.Enabled)
+}
+
+// TestExplainRoute_SessionAffinity verifies that the dry-run reports the service a
+// conversation is pinned to, without pinning conversations itself
+func TestExplainRoute_SessionAffinity(t *testing.T) {
+	s, rule := newSessionAffinityServer(t)
+	request := json.RawMessage(`{"model":"sticky-model","messages":[{"role":"user","content":"hi"}]}`)
+	headers := map[string]string{typ.DefaultSessionHeader: "conv-1"}
+
+	code, resp := explainRoute(t, s, RouteExplainRequest{Scenario: "openai", Request: request, Headers: headers})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, RouteSourceLoadBalancer, resp.Data.Source)
+	_, pinned := s.sessionAffinity.Get(rule.UUID, "header:conv-1")
+	assert.False(t, pinned, "the dry-run does not pin the conversation")
+
+	_, service, err := s.DetermineProviderAndModelWithScenario(sessionContext("conv-1"), typ.ScenarioOpenAI, rule, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		code, resp = explainRoute(t, s, RouteExplainRequest{Scenario: "openai", Request: request, Headers: headers})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, RouteSourceSessionAffinity, resp.Data.Source)
+		require.NotNil(t, resp.Data.Service)
+		assert.Equal(t, service.Model, resp.Data.Service.Model)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/tingly-dev/tingly-box/internal/obs"
+	smartrouting "github.com/tingly-dev/tingly-box/internal/smart_routing"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 )
 
@@ -104,6 +105,9 @@ func (s *Server) CreateRule(c *gin.Context) {
 		return
 	}
 	rule.UUID = uuid.NewString()
+	if !validateRuleSmartRouting(c, &rule) {
+		return
+	}
 
 	cfg := s.config
 	if cfg == nil {
@@ -177,6 +181,9 @@ func (s *Server) UpdateRule(c *gin.Context) {
 	}
 
 	rule.UUID = uid
+	if !validateRuleSmartRouting(c, &rule) {
+		return
+	}
 	if err := cfg.UpdateRule(uid, rule); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -209,6 +216,30 @@ func (s *Server) UpdateRule(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// validateRuleSmartRouting validates the smart routing operations of a rule sent to the
+// API, writing a 400 when one is invalid
+func validateRuleSmartRouting(c *gin.Context, rule *typ.Rule) bool {
+	for i := range rule.SmartRouting {
+		if err := smartrouting.ValidateSmartRouting(&rule.SmartRouting[i]); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("Invalid smart routing rule %d: %v", i+1, err),
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// GetSmartRoutingOperations returns the operations smart routing rules can use, for
+// the rule editor to build its position and operation choices from
+func (s *Server) GetSmartRoutingOperations(c *gin.Context) {
+	c.JSON(http.StatusOK, SmartRoutingOperationsResponse{
+		Success: true,
+		Data:    smartrouting.Operations,
+	})
+}
+
 func (s *Server) DeleteRule(c *gin.Context) {
 	ruleUUID := c.Param("uuid")
 	if ruleUUID == "" {
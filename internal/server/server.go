@@ -23,6 +23,7 @@ import (
 	"github.com/tingly-dev/tingly-box/internal/obs/otel"
 	remote_coder "github.com/tingly-dev/tingly-box/internal/remote_coder"
 	remoteconfig "github.com/tingly-dev/tingly-box/internal/remote_coder/config"
+	"github.com/tingly-dev/tingly-box/internal/secretref"
 	"github.com/tingly-dev/tingly-box/internal/server/background"
 	"github.com/tingly-dev/tingly-box/internal/server/config"
 	"github.com/tingly-dev/tingly-box/internal/server/middleware"
@@ -47,6 +48,8 @@ type Server struct {
 	// middleware
 	errorMW         *middleware.ErrorLogMiddleware
 	authMW          *middleware.AuthMiddleware
+	rateLimitMW     *middleware.RateLimitMiddleware
+	quotaMW         *middleware.QuotaMiddleware
 	memoryLogMW     *middleware.MemoryLogMiddleware
 	loadBalancer    *LoadBalancer
 	loadBalancerAPI *LoadBalancerAPI
@@ -77,9 +80,18 @@ type Server struct {
 	// probe cache for model endpoint capabilities
 	probeCache *ProbeCache
 
+	// session affinity pins conversations to a service
+	sessionAffinity *SessionAffinityStore
+
+	// summary store caches the summaries written on context window overflow
+	summaryStore *SummaryStore
+
 	// capability store for persistent model capabilities
 	capabilityStore *db.ModelCapabilityStore
 
+	// response store for Responses API retrieval and previous_response_id chaining
+	responseStore *db.ResponseStore
+
 	// tool interceptor for local tool execution
 	toolInterceptor *toolinterceptor.Interceptor
 
@@ -103,6 +115,7 @@ type Server struct {
 	openBrowser   bool
 	host          string
 	debug         bool
+	secretExec    bool
 
 	// https options
 	httpsEnabled    bool
@@ -110,8 +123,9 @@ type Server struct {
 	httpsRegenerate bool
 
 	// record options
-	recordMode obs.RecordMode
-	recordDir  string
+	recordMode      obs.RecordMode
+	recordDir       string
+	recordRetention obs.RetentionPolicy
 
 	// experimental features
 	experimentalFeatures map[string]bool
@@ -129,6 +143,8 @@ func WithDefault() ServerOption {
 		s.enableAdaptor = true // Default: adapter enabled
 		s.openBrowser = true   // Default: open browser enabled
 		s.host = ""            // Default: empty host (resolves to localhost)
+
+		s.recordRetention = obs.DefaultRetentionPolicy() // Default: rotate, compress and purge record files
 	}
 }
 
@@ -187,7 +203,7 @@ func WithHTTPSRegenerate(regenerate bool) ServerOption {
 }
 
 // WithRecordMode sets the record mode for request/response recording
-// mode: empty string = disabled, "all" = record all, "response" = response only, "scenario" = record scenario only
+// mode: empty string = disabled, "all" = record all, "response" = response only, "scenario" = record scenario only, "slim" = metadata only
 func WithRecordMode(mode obs.RecordMode) ServerOption {
 	return func(s *Server) {
 		s.recordMode = mode
@@ -201,6 +217,13 @@ func WithRecordDir(dir string) ServerOption {
 	}
 }
 
+// WithRecordRetention sets the rotation and purging policy of the record files
+func WithRecordRetention(policy obs.RetentionPolicy) ServerOption {
+	return func(s *Server) {
+		s.recordRetention = policy
+	}
+}
+
 // WithExperimentalFeatures sets the experimental features for the server
 func WithExperimentalFeatures(features map[string]bool) ServerOption {
 	return func(s *Server) {
@@ -208,6 +231,13 @@ func WithExperimentalFeatures(features map[string]bool) ServerOption {
 	}
 }
 
+// WithSecretExec allows exec: secret references, which run a command to get a credential
+func WithSecretExec(enabled bool) ServerOption {
+	return func(s *Server) {
+		s.secretExec = enabled
+	}
+}
+
 // WithDebug enables or disables debug mode for the server
 func WithDebug(enabled bool) ServerOption {
 	return func(s *Server) {
@@ -232,7 +262,7 @@ func (s *Server) GetOrCreateScenarioSink(scenario typ.RuleScenario) *obs.Sink {
 	}
 
 	// Create new sink for this scenario
-	sink := obs.NewSink(s.recordDir, obs.RecordModeScenario)
+	sink := obs.NewSink(s.recordDir, obs.RecordModeScenario, obs.WithRetention(s.recordRetention))
 	if sink == nil {
 		logrus.Warnf("Failed to create scenario recording sink for %s", scenario)
 		return nil
@@ -258,6 +288,8 @@ func NewServer(cfg *config.Config, opts ...ServerOption) *Server {
 		opt(server)
 	}
 
+	secretref.EnableExec(server.secretExec)
+
 	// Set gin mode based on debug flag
 	if server.debug {
 		gin.SetMode(gin.DebugMode)
@@ -337,8 +369,8 @@ func NewServer(cfg *config.Config, opts ...ServerOption) *Server {
 	switch server.recordMode {
 	case "":
 		// Recording disabled
-	case obs.RecordModeResponse, obs.RecordModeAll:
-		recordSink := obs.NewSink(server.recordDir, server.recordMode)
+	case obs.RecordModeResponse, obs.RecordModeAll, obs.RecordModeSlim:
+		recordSink := obs.NewSink(server.recordDir, server.recordMode, obs.WithRetention(server.recordRetention))
 		server.clientPool.SetRecordSink(recordSink)
 		logrus.Debugf("Request recording enabled, mode: %s, directory: %s", server.recordMode, server.recordDir)
 	case obs.RecordModeScenario:
@@ -354,6 +386,14 @@ func NewServer(cfg *config.Config, opts ...ServerOption) *Server {
 	// Initialize auth middleware
 	authMW := middleware.NewAuthMiddleware(cfg, jwtManager)
 
+	// Initialize rate limit and quota middlewares, which bucket usage by the scenario recorded in the usage store
+	requestScenario := func(c *gin.Context) string {
+		return extractScenarioFromPath(c.Request.URL.Path)
+	}
+	rateLimitMW := middleware.NewRateLimitMiddleware(cfg, requestScenario)
+	rateLimitMW.StartCleanupTask(10 * time.Minute)
+	quotaMW := middleware.NewQuotaMiddleware(cfg, requestScenario)
+
 	// Initialize load balancer
 	loadBalancer := NewLoadBalancer(cfg)
 
@@ -386,6 +426,8 @@ func NewServer(cfg *config.Config, opts ...ServerOption) *Server {
 
 	// Update server with dependencies
 	server.authMW = authMW
+	server.rateLimitMW = rateLimitMW
+	server.quotaMW = quotaMW
 	server.memoryLogMW = memoryLogMW
 	server.loadBalancer = loadBalancer
 	server.loadBalancerAPI = loadBalancerAPI
@@ -405,6 +447,9 @@ func NewServer(cfg *config.Config, opts ...ServerOption) *Server {
 	// Set template manager in config for model fetching fallback
 	server.config.SetTemplateManager(templateManager)
 
+	// Price rule services from the pricing catalog for the lowest_cost tactic
+	typ.SetServicePricer(server.config.ServicePrice)
+
 	// Initialize tool interceptor (local web_search/web_fetch)
 	server.toolInterceptor = toolinterceptor.NewInterceptor(cfg.GetToolInterceptorConfig())
 
@@ -424,6 +469,14 @@ func NewServer(cfg *config.Config, opts ...ServerOption) *Server {
 	server.probeCache.StartCleanupTask(1 * time.Hour)
 	logrus.Debugf("Probe cache initialized with TTL: 24h")
 
+	// Initialize session affinity store, pins expire after their rule's TTL
+	server.sessionAffinity = NewSessionAffinityStore()
+	server.sessionAffinity.StartCleanupTask(10 * time.Minute)
+
+	// Initialize summary store for context window overflow summaries
+	server.summaryStore = NewSummaryStore()
+	server.summaryStore.StartCleanupTask(10 * time.Minute)
+
 	// Initialize model capability store
 	capabilityStore, err := db.NewModelCapabilityStore(cfg.ConfigDir)
 	if err != nil {
@@ -434,6 +487,17 @@ func NewServer(cfg *config.Config, opts ...ServerOption) *Server {
 		logrus.Debugf("Model capability store initialized")
 	}
 
+	// Initialize response store
+	responseStore, err := db.NewResponseStore(cfg.ConfigDir)
+	if err != nil {
+		logrus.Debugf("Failed to initialize response store: %v", err)
+		// Continue without response store - responses will not be stored
+	} else {
+		server.responseStore = responseStore
+		server.responseStore.StartCleanupTask(1 * time.Hour)
+		logrus.Debugf("Response store initialized")
+	}
+
 	// Initialize OTel meter setup for token tracking
 	meterSetup, err := otel.NewMeterSetup(context.Background(), otel.DefaultConfig(), &otel.StoreRefs{
 		StatsStore: cfg.GetStatsStore(),
@@ -672,6 +736,10 @@ func (s *Server) UseAIEndpoints() {
 	anthropicV1 := s.engine.Group("/anthropic/v1")
 	s.SetupAnthropicEndpoints(anthropicV1)
 
+	// Google (Gemini) v1beta API group
+	googleV1Beta := s.engine.Group("/google/v1beta")
+	s.SetupGoogleEndpoints(googleV1Beta)
+
 	// Passthrough endpoints (no request/response transformation, just model replacement)
 	// Non-versioned passthrough routes
 	passthroughOpenai := s.engine.Group("/passthrough/openai")
@@ -693,51 +761,76 @@ func (s *Server) UseAIEndpoints() {
 	scenarioV1 := s.engine.Group("/tingly/:scenario/v1")
 	scenarioV1.Use(contextMiddleware)
 	s.SetupMixinEndpoints(scenarioV1)
+
+	// scenario v1beta routes for Gemini clients
+	scenarioV1Beta := s.engine.Group("/tingly/:scenario/v1beta")
+	scenarioV1Beta.Use(contextMiddleware)
+	s.SetupGoogleEndpoints(scenarioV1Beta)
 }
 
 func (s *Server) SetupMixinEndpoints(group *gin.RouterGroup) {
 	// Chat completions endpoint (OpenAI compatible)
-	group.POST("/chat/completions", s.authMW.ModelAuthMiddleware(), s.OpenAIChatCompletions)
+	group.POST("/chat/completions", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.OpenAIChatCompletions)
 
 	// Responses API endpoints (OpenAI compatible)
-	group.POST("/responses", s.authMW.ModelAuthMiddleware(), s.ResponsesCreate)
+	group.POST("/responses", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.ResponsesCreate)
 	group.GET("/responses/:id", s.authMW.ModelAuthMiddleware(), s.ResponsesGet)
+	group.DELETE("/responses/:id", s.authMW.ModelAuthMiddleware(), s.ResponsesDelete)
+	group.GET("/responses/:id/input_items", s.authMW.ModelAuthMiddleware(), s.ResponsesInputItems)
+
+	// Embeddings endpoint (OpenAI compatible)
+	group.POST("/embeddings", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.OpenAIEmbeddings)
 
 	// Chat completions endpoint (Anthropic compatible)
-	group.POST("/messages", s.authMW.ModelAuthMiddleware(), s.AnthropicMessages)
+	group.POST("/messages", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.AnthropicMessages)
 	// Count tokens endpoint (Anthropic compatible)
-	group.POST("/messages/count_tokens", s.authMW.ModelAuthMiddleware(), s.AnthropicCountTokens)
+	group.POST("/messages/count_tokens", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.AnthropicCountTokens)
+
+	// Generate content endpoints (Google compatible): models/{model}:generateContent, :streamGenerateContent, :countTokens
+	group.POST("/models/:action", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.GoogleModelAction)
 
-	// Models endpoint (routed by scenario: openai -> OpenAIListModels, anthropic/claude_code -> AnthropicListModels)
+	// Models endpoint (routed by scenario: openai -> OpenAIListModels, anthropic/claude_code -> AnthropicListModels, google -> GoogleListModels)
 	group.GET("/models", s.authMW.ModelAuthMiddleware(), s.ListModelsByScenario)
 }
 
 func (s *Server) SetupOpenAIEndpoints(group *gin.RouterGroup) {
 	// Chat completions endpoint (OpenAI compatible)
-	group.POST("/chat/completions", s.authMW.ModelAuthMiddleware(), s.OpenAIChatCompletions)
+	group.POST("/chat/completions", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.OpenAIChatCompletions)
 	// Models endpoint (OpenAI compatible)
 	group.GET("/models", s.authMW.ModelAuthMiddleware(), s.OpenAIListModels)
 
 	// Responses API endpoints (OpenAI compatible)
-	group.POST("/responses", s.authMW.ModelAuthMiddleware(), s.ResponsesCreate)
+	group.POST("/responses", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.ResponsesCreate)
 	group.GET("/responses/:id", s.authMW.ModelAuthMiddleware(), s.ResponsesGet)
+	group.DELETE("/responses/:id", s.authMW.ModelAuthMiddleware(), s.ResponsesDelete)
+	group.GET("/responses/:id/input_items", s.authMW.ModelAuthMiddleware(), s.ResponsesInputItems)
+
+	// Embeddings endpoint (OpenAI compatible)
+	group.POST("/embeddings", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.OpenAIEmbeddings)
 }
 
 func (s *Server) SetupAnthropicEndpoints(group *gin.RouterGroup) {
 	// Chat completions endpoint (Anthropic compatible)
-	group.POST("/messages", s.authMW.ModelAuthMiddleware(), s.AnthropicMessages)
+	group.POST("/messages", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.AnthropicMessages)
 	// Count tokens endpoint (Anthropic compatible)
-	group.POST("/messages/count_tokens", s.authMW.ModelAuthMiddleware(), s.AnthropicCountTokens)
+	group.POST("/messages/count_tokens", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.AnthropicCountTokens)
 	// Models endpoint (Anthropic compatible)
 	group.GET("/models", s.authMW.ModelAuthMiddleware(), s.AnthropicListModels)
 }
 
+func (s *Server) SetupGoogleEndpoints(group *gin.RouterGroup) {
+	// Generate content endpoints (Google compatible): models/{model}:generateContent, :streamGenerateContent, :countTokens
+	group.POST("/models/:action", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.GoogleModelAction)
+	// Models endpoint (Google compatible)
+	group.GET("/models", s.authMW.ModelAuthMiddleware(), s.GoogleListModels)
+}
+
 // SetupPassthroughOpenAIEndpoints sets up pass-through endpoints for OpenAI-style requests
 // These endpoints bypass request/response transformations and only replace the model name
 func (s *Server) SetupPassthroughOpenAIEndpoints(group *gin.RouterGroup) {
 	// POST endpoints that use passthrough (proxy with model replacement)
-	group.POST("/chat/completions", s.authMW.ModelAuthMiddleware(), s.PassthroughOpenAI)
-	group.POST("/responses", s.authMW.ModelAuthMiddleware(), s.PassthroughOpenAI)
+	group.POST("/chat/completions", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.PassthroughOpenAI)
+	group.POST("/responses", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.PassthroughOpenAI)
 	// GET responses/:id also uses passthrough
 	group.GET("/responses/*path", s.authMW.ModelAuthMiddleware(), s.PassthroughOpenAI)
 	// Models endpoint returns tingly-box's model list (not passthrough)
@@ -757,8 +850,8 @@ func contextMiddleware(c *gin.Context) {
 // These endpoints bypass request/response transformations and only replace the model name
 func (s *Server) SetupPassthroughAnthropicEndpoints(group *gin.RouterGroup) {
 	// POST endpoints that use passthrough (proxy with model replacement)
-	group.POST("/messages", s.authMW.ModelAuthMiddleware(), s.PassthroughAnthropic)
-	group.POST("/messages/count_tokens", s.authMW.ModelAuthMiddleware(), s.PassthroughAnthropic)
+	group.POST("/messages", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.PassthroughAnthropic)
+	group.POST("/messages/count_tokens", s.authMW.ModelAuthMiddleware(), s.rateLimitMW.Middleware(), s.quotaMW.Middleware(), s.PassthroughAnthropic)
 	// Models endpoint returns tingly-box's model list (not passthrough)
 	group.GET("/models", s.authMW.ModelAuthMiddleware(), s.AnthropicListModels)
 }
@@ -1,9 +1,13 @@
 package server
 
 import (
+	"encoding/json"
 	"strings"
 	"time"
 
+	"github.com/tingly-dev/tingly-box/internal/client"
+	"github.com/tingly-dev/tingly-box/internal/data/db"
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
 	smartrouting "github.com/tingly-dev/tingly-box/internal/smart_routing"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 )
@@ -137,6 +141,68 @@ type RuleResponse struct {
 	Data    *typ.Rule `json:"data"`
 }
 
+// SmartRoutingOperationsResponse represents the operations available to smart routing rules
+type SmartRoutingOperationsResponse struct {
+	Success bool                   `json:"success" example:"true"`
+	Data    []smartrouting.SmartOp `json:"data"`
+}
+
+// RouteExplainRequest represents a routing dry-run request
+type RouteExplainRequest struct {
+	Scenario string            `json:"scenario" binding:"required" description:"Scenario the request is sent to" example:"claude_code"`
+	Format   string            `json:"format,omitempty" description:"Request format: openai, anthropic, anthropic_beta or google (detected from the body when empty)" example:"anthropic"`
+	Model    string            `json:"model,omitempty" description:"Request model, required for google bodies which do not carry it" example:"tingly-claude"`
+	Request  json.RawMessage   `json:"request" binding:"required" description:"Raw request body"`
+	Headers  map[string]string `json:"headers,omitempty" description:"Request headers to route on, e.g. User-Agent or x-app"`
+	APIKey   string            `json:"api_key,omitempty" description:"Name or ID of the API key to route the request as"`
+}
+
+// RouteCandidate is a service the load balancing tactic chooses from
+type RouteCandidate struct {
+	Provider        string `json:"provider"`
+	ProviderName    string `json:"provider_name,omitempty"`
+	ProviderEnabled bool   `json:"provider_enabled"`
+	Model           string `json:"model"`
+	Weight          int    `json:"weight"`
+	Active          bool   `json:"active"`
+	Available       bool   `json:"available"` // Circuit closed and rate limits not exhausted
+}
+
+// RouteExplanation describes how a request would be routed
+type RouteExplanation struct {
+	Scenario     string                         `json:"scenario"`
+	Format       string                         `json:"format"`
+	RequestModel string                         `json:"request_model"`
+	Rule         *typ.Rule                      `json:"rule"`
+	SmartRouting []smartrouting.RuleExplanation `json:"smart_routing,omitempty"`
+	SmartError   string                         `json:"smart_routing_error,omitempty"`
+	Source       string                         `json:"source"` // session_affinity, smart_routing or load_balancer
+	Tactic       string                         `json:"tactic"`
+	Candidates   []RouteCandidate               `json:"candidates"`
+	Service      *loadbalance.Service           `json:"service,omitempty"`
+	ProviderName string                         `json:"provider_name,omitempty"`
+	NoService    string                         `json:"no_service,omitempty"` // Why no service would be used
+
+	ContextOverflow bool `json:"context_overflow,omitempty"` // Moved to a service whose context window fits the request
+}
+
+// RouteExplainResponse represents the response of a routing dry-run
+type RouteExplainResponse struct {
+	Success bool              `json:"success" example:"true"`
+	Data    *RouteExplanation `json:"data"`
+}
+
+// RouteHeadersSettings represents whether proxied responses name the routing decision
+type RouteHeadersSettings struct {
+	Enabled bool `json:"enabled" example:"false"` // Send the x-tingly-rule and x-tingly-service headers
+}
+
+// RouteHeadersSettingsResponse represents the response for the routing decision headers setting
+type RouteHeadersSettingsResponse struct {
+	Success bool                 `json:"success" example:"true"`
+	Data    RouteHeadersSettings `json:"data"`
+}
+
 // RuleSummaryResponse represents a rule summary response
 type RuleSummaryResponse struct {
 	Summary interface{} `json:"summary"`
@@ -181,16 +247,23 @@ type ProbeProviderResponseData struct {
 
 // ProviderResponse represents a provider configuration with masked token
 type ProviderResponse struct {
-	UUID          string           `json:"uuid" example:"0123456789ABCDEF"`
-	Name          string           `json:"name" example:"openai"`
-	APIBase       string           `json:"api_base" example:"https://api.openai.com/v1"`
-	APIStyle      string           `json:"api_style" example:"openai"`
-	Token         string           `json:"token" example:"sk-***...***"` // Only populated for api_key auth type
-	NoKeyRequired bool             `json:"no_key_required" example:"false"`
-	Enabled       bool             `json:"enabled" example:"true"`
-	ProxyURL      string           `json:"proxy_url,omitempty" example:"http://127.0.0.1:7890"`
-	AuthType      string           `json:"auth_type,omitempty" example:"api_key"` // api_key or oauth
-	OAuthDetail   *typ.OAuthDetail `json:"oauth_detail,omitempty"`                // OAuth credentials (only for oauth auth type)
+	UUID          string             `json:"uuid" example:"0123456789ABCDEF"`
+	Name          string             `json:"name" example:"openai"`
+	APIBase       string             `json:"api_base" example:"https://api.openai.com/v1"`
+	APIStyle      string             `json:"api_style" example:"openai"`
+	Token         string             `json:"token" example:"sk-***...***"` // Only populated for api_key auth type
+	NoKeyRequired bool               `json:"no_key_required" example:"false"`
+	Enabled       bool               `json:"enabled" example:"true"`
+	ProxyURL      string             `json:"proxy_url,omitempty" example:"http://127.0.0.1:7890"`
+	AuthType      string             `json:"auth_type,omitempty" example:"api_key"` // api_key or oauth
+	OAuthDetail   *typ.OAuthDetail   `json:"oauth_detail,omitempty"`                // OAuth credentials (only for oauth auth type)
+	Keys          []*typ.ProviderKey `json:"keys,omitempty"`                        // Additional API keys rotated with the token
+}
+
+// ProviderKeyStatsResponse represents the per-key usage of a provider
+type ProviderKeyStatsResponse struct {
+	Success bool              `json:"success" example:"true"`
+	Data    []client.KeyStats `json:"data"`
 }
 
 // ProvidersResponse represents the response for listing providers
@@ -259,15 +332,81 @@ type DeleteRuleResponse struct {
 	Message string `json:"message" example:"Rule deleted successfully"`
 }
 
+// APIKeysResponse represents the response for listing client API keys
+type APIKeysResponse struct {
+	Success bool         `json:"success" example:"true"`
+	Data    []typ.APIKey `json:"data"`
+}
+
+// CreateAPIKeyRequest represents the request to create a client API key
+type CreateAPIKeyRequest struct {
+	Name          string             `json:"name" binding:"required" description:"Display name of the key" example:"ci-pipeline"`
+	ExpiresAt     *time.Time         `json:"expires_at,omitempty" description:"Expiry time (RFC 3339)"`
+	ExpiresInDays int                `json:"expires_in_days,omitempty" description:"Expiry relative to now, used when expires_at is empty" example:"90"`
+	Scenarios     []typ.RuleScenario `json:"scenarios,omitempty" description:"Allowed scenarios (empty = all)"`
+	Models        []string           `json:"models,omitempty" description:"Allowed request models (empty = all)"`
+	Rules         []string           `json:"rules,omitempty" description:"Allowed rule UUIDs (empty = all)"`
+	RateLimit     *typ.RateLimit     `json:"rate_limit,omitempty" description:"Optional per-key rate limit"`
+}
+
+// APIKeySecretResponse represents the response for creating or rotating a client API key.
+// The secret is only returned once.
+type APIKeySecretResponse struct {
+	Success bool        `json:"success" example:"true"`
+	Data    *typ.APIKey `json:"data"`
+	Key     string      `json:"key" example:"tb-0123456789abcdef"`
+}
+
+// APIKeyActionResponse represents the response for an API key action
+type APIKeyActionResponse struct {
+	Success bool   `json:"success" example:"true"`
+	Message string `json:"message" example:"API key revoked successfully"`
+}
+
+// RateLimitsRequest represents the request to replace the rate limit rules
+type RateLimitsRequest struct {
+	Rules []typ.RateLimitRule `json:"rules"`
+}
+
+// RateLimitsResponse represents the response for the rate limit rules
+type RateLimitsResponse struct {
+	Success bool                `json:"success" example:"true"`
+	Data    []typ.RateLimitRule `json:"data"`
+}
+
+// PricingOverrideRequest represents the request to set or remove a pricing override
+type PricingOverrideRequest struct {
+	Key     string            `json:"key" binding:"required" description:"<provider>:<model> or <model>" example:"openai:gpt-4o"`
+	Pricing *typ.ModelPricing `json:"pricing" description:"Price in USD per 1M tokens, null removes the override"`
+}
+
+// PricingOverridesResponse represents the response for the pricing overrides
+type PricingOverridesResponse struct {
+	Success bool                         `json:"success" example:"true"`
+	Data    map[string]*typ.ModelPricing `json:"data"`
+}
+
+// ResponseStoreSettings represents the retention of stored Responses API responses
+type ResponseStoreSettings struct {
+	TTLHours int `json:"ttl_hours" example:"720"` // Negative disables response storage
+}
+
+// ResponseStoreSettingsResponse represents the response for the response store settings
+type ResponseStoreSettingsResponse struct {
+	Success bool                  `json:"success" example:"true"`
+	Data    ResponseStoreSettings `json:"data"`
+}
+
 // CreateProviderRequest represents the request to add a new provider
 type CreateProviderRequest struct {
-	Name          string `json:"name" binding:"required" description:"Provider name" example:"openai"`
-	APIBase       string `json:"api_base" binding:"required" description:"API base URL" example:"https://api.openai.com/v1"`
-	APIStyle      string `json:"api_style" description:"API style" example:"openai"`
-	Token         string `json:"token" description:"API token" example:"sk-..."`
-	NoKeyRequired bool   `json:"no_key_required" description:"Whether provider requires no API key" example:"false"`
-	Enabled       bool   `json:"enabled" description:"Whether provider is enabled" example:"true"`
-	ProxyURL      string `json:"proxy_url,omitempty" description:"HTTP or SOCKS proxy URL (e.g., http://127.0.0.1:7890 or socks5://127.0.0.1:1080)" example:"http://127.0.0.1:7890"`
+	Name          string             `json:"name" binding:"required" description:"Provider name" example:"openai"`
+	APIBase       string             `json:"api_base" binding:"required" description:"API base URL" example:"https://api.openai.com/v1"`
+	APIStyle      string             `json:"api_style" description:"API style" example:"openai"`
+	Token         string             `json:"token" description:"API token" example:"sk-..."`
+	NoKeyRequired bool               `json:"no_key_required" description:"Whether provider requires no API key" example:"false"`
+	Enabled       bool               `json:"enabled" description:"Whether provider is enabled" example:"true"`
+	ProxyURL      string             `json:"proxy_url,omitempty" description:"HTTP or SOCKS proxy URL (e.g., http://127.0.0.1:7890 or socks5://127.0.0.1:1080)" example:"http://127.0.0.1:7890"`
+	Keys          []*typ.ProviderKey `json:"keys,omitempty" description:"Additional API keys rotated with the token, with optional weights"`
 }
 
 // CreateProviderResponse represents the response for adding a provider
@@ -279,13 +418,14 @@ type CreateProviderResponse struct {
 
 // UpdateProviderRequest represents the request to update a provider
 type UpdateProviderRequest struct {
-	Name          *string `json:"name,omitempty" description:"New provider name"`
-	APIBase       *string `json:"api_base,omitempty" description:"New API base URL"`
-	APIStyle      *string `json:"api_style,omitempty" description:"New API style"`
-	Token         *string `json:"token,omitempty" description:"New API token"`
-	NoKeyRequired *bool   `json:"no_key_required,omitempty" description:"Whether provider requires no API key"`
-	Enabled       *bool   `json:"enabled,omitempty" description:"New enabled status"`
-	ProxyURL      *string `json:"proxy_url,omitempty" description:"HTTP or SOCKS proxy URL"`
+	Name          *string             `json:"name,omitempty" description:"New provider name"`
+	APIBase       *string             `json:"api_base,omitempty" description:"New API base URL"`
+	APIStyle      *string             `json:"api_style,omitempty" description:"New API style"`
+	Token         *string             `json:"token,omitempty" description:"New API token"`
+	NoKeyRequired *bool               `json:"no_key_required,omitempty" description:"Whether provider requires no API key"`
+	Enabled       *bool               `json:"enabled,omitempty" description:"New enabled status"`
+	ProxyURL      *string             `json:"proxy_url,omitempty" description:"HTTP or SOCKS proxy URL"`
+	Keys          *[]*typ.ProviderKey `json:"keys,omitempty" description:"Replacement list of additional API keys; a key sent without token keeps its current token"`
 }
 
 // UpdateProviderResponse represents the response for updating a provider
@@ -464,16 +604,17 @@ type ProbeResponse struct {
 
 // UsageStatsQuery represents query parameters for usage statistics
 type UsageStatsQuery struct {
-	GroupBy   string `json:"group_by" form:"group_by" description:"Aggregation level: model, provider, scenario, rule, daily, hourly" example:"model"`
+	GroupBy   string `json:"group_by" form:"group_by" description:"Aggregation level: model, provider, scenario, rule, api_key, daily, hourly" example:"model"`
 	StartTime string `json:"start_time" form:"start_time" description:"ISO 8601 start time" example:"2025-01-10T00:00:00Z"`
 	EndTime   string `json:"end_time" form:"end_time" description:"ISO 8601 end time" example:"2025-01-11T00:00:00Z"`
 	Provider  string `json:"provider" form:"provider" description:"Filter by provider UUID"`
 	Model     string `json:"model" form:"model" description:"Filter by model name"`
 	Scenario  string `json:"scenario" form:"scenario" description:"Filter by scenario"`
 	RuleUUID  string `json:"rule_uuid" form:"rule_uuid" description:"Filter by rule UUID"`
+	APIKeyID  string `json:"api_key_id" form:"api_key_id" description:"Filter by API key ID"`
 	Status    string `json:"status" form:"status" description:"Filter by status: success, error, partial" example:"success"`
 	Limit     int    `json:"limit" form:"limit" description:"Max results to return" example:"100"`
-	SortBy    string `json:"sort_by" form:"sort_by" description:"Sort field: total_tokens, request_count, avg_latency" example:"total_tokens"`
+	SortBy    string `json:"sort_by" form:"sort_by" description:"Sort field: total_tokens, request_count, avg_latency, total_cost" example:"total_tokens"`
 	SortOrder string `json:"sort_order" form:"sort_order" description:"asc or desc" example:"desc"`
 }
 
@@ -495,6 +636,13 @@ type AggregatedStat struct {
 	ErrorRate       float64 `json:"error_rate" example:"0.0022"`
 	StreamedCount   int64   `json:"streamed_count" example:"4800"`
 	StreamedRate    float64 `json:"streamed_rate" example:"0.885"`
+	TotalCostUSD    float64 `json:"total_cost_usd" example:"18.42"`
+	AvgCostUSD      float64 `json:"avg_cost_usd" example:"0.0034"`
+
+	CacheReadTokens     int64   `json:"total_cache_read_tokens" example:"980000"`
+	CacheCreationTokens int64   `json:"total_cache_creation_tokens" example:"120000"`
+	ReasoningTokens     int64   `json:"total_reasoning_tokens" example:"45000"`
+	CacheHitRate        float64 `json:"cache_hit_rate" example:"0.784"`
 }
 
 // UsageStatsResponse represents the response for usage statistics
@@ -530,6 +678,11 @@ type TimeSeriesData struct {
 	OutputTokens int64   `json:"output_tokens" example:"20000"`
 	ErrorCount   int64   `json:"error_count" example:"0"`
 	AvgLatencyMs float64 `json:"avg_latency_ms" example:"1100"`
+	CostUSD      float64 `json:"cost_usd" example:"0.84"`
+
+	CacheReadTokens     int64 `json:"cache_read_tokens" example:"24000"`
+	CacheCreationTokens int64 `json:"cache_creation_tokens" example:"3000"`
+	ReasoningTokens     int64 `json:"reasoning_tokens" example:"1500"`
 }
 
 // TimeSeriesResponse represents the response for time-series data
@@ -559,21 +712,52 @@ type UsageRecordsQuery struct {
 
 // UsageRecordResponse represents a single usage record
 type UsageRecordResponse struct {
-	ID           uint   `json:"id" example:"1"`
-	ProviderUUID string `json:"provider_uuid" example:"uuid-123"`
-	ProviderName string `json:"provider_name" example:"openai"`
-	Model        string `json:"model" example:"gpt-4"`
-	Scenario     string `json:"scenario" example:"openai"`
-	RuleUUID     string `json:"rule_uuid,omitempty" example:"rule-uuid"`
-	RequestModel string `json:"request_model,omitempty" example:"gpt-4"`
-	Timestamp    string `json:"timestamp" example:"2025-01-10T12:00:00Z"`
-	InputTokens  int    `json:"input_tokens" example:"1000"`
-	OutputTokens int    `json:"output_tokens" example:"500"`
-	TotalTokens  int    `json:"total_tokens" example:"1500"`
-	Status       string `json:"status" example:"success"`
-	ErrorCode    string `json:"error_code,omitempty"`
-	LatencyMs    int    `json:"latency_ms" example:"1200"`
-	Streamed     bool   `json:"streamed" example:"true"`
+	ID           uint    `json:"id" example:"1"`
+	ProviderUUID string  `json:"provider_uuid" example:"uuid-123"`
+	ProviderName string  `json:"provider_name" example:"openai"`
+	Model        string  `json:"model" example:"gpt-4"`
+	Scenario     string  `json:"scenario" example:"openai"`
+	RuleUUID     string  `json:"rule_uuid,omitempty" example:"rule-uuid"`
+	RequestModel string  `json:"request_model,omitempty" example:"gpt-4"`
+	APIKeyID     string  `json:"api_key_id,omitempty" example:"key-uuid"`
+	Timestamp    string  `json:"timestamp" example:"2025-01-10T12:00:00Z"`
+	InputTokens  int     `json:"input_tokens" example:"1000"`
+	OutputTokens int     `json:"output_tokens" example:"500"`
+	TotalTokens  int     `json:"total_tokens" example:"1500"`
+	Status       string  `json:"status" example:"success"`
+	ErrorCode    string  `json:"error_code,omitempty"`
+	LatencyMs    int     `json:"latency_ms" example:"1200"`
+	Streamed     bool    `json:"streamed" example:"true"`
+	CostUSD      float64 `json:"cost_usd" example:"0.0105"`
+
+	CacheReadTokens     int `json:"cache_read_tokens" example:"800"`
+	CacheCreationTokens int `json:"cache_creation_tokens" example:"0"`
+	ReasoningTokens     int `json:"reasoning_tokens" example:"120"`
+
+	CompactSavedBytes  int `json:"compact_saved_bytes,omitempty" example:"48000"`
+	CompactSavedTokens int `json:"compact_saved_tokens,omitempty" example:"12000"`
+}
+
+// BudgetStatus represents a budget with the usage of its current period
+type BudgetStatus struct {
+	typ.Budget
+	PeriodStart  string         `json:"period_start" example:"2025-01-10T00:00:00Z"`
+	PeriodEnd    string         `json:"period_end" example:"2025-01-11T00:00:00Z"`
+	Usage        db.UsageTotals `json:"usage"`
+	SoftExceeded string         `json:"soft_exceeded,omitempty" example:"token limit of 1000000"`
+	HardExceeded string         `json:"hard_exceeded,omitempty"`
+}
+
+// BudgetsResponse represents the response for listing budgets
+type BudgetsResponse struct {
+	Success bool           `json:"success" example:"true"`
+	Data    []BudgetStatus `json:"data"`
+}
+
+// BudgetResponse represents the response for creating, updating or deleting a budget
+type BudgetResponse struct {
+	Success bool        `json:"success" example:"true"`
+	Data    *typ.Budget `json:"data,omitempty"`
 }
 
 // UsageRecordsResponse represents the response for usage records
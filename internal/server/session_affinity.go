@@ -0,0 +1,227 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/gin-gonic/gin"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/responses"
+	"github.com/sirupsen/logrus"
+
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+// SessionAffinityStore keeps the service each conversation is pinned to, per rule
+type SessionAffinityStore struct {
+	mu   sync.Mutex
+	pins map[string]*SessionPin
+}
+
+// SessionPin is the service a conversation is pinned to
+type SessionPin struct {
+	ServiceID string
+	ExpiresAt time.Time
+}
+
+// NewSessionAffinityStore creates an empty session affinity store
+func NewSessionAffinityStore() *SessionAffinityStore {
+	return &SessionAffinityStore{
+		pins: make(map[string]*SessionPin),
+	}
+}
+
+// Get returns the service ID the conversation is pinned to, if the pin has not expired
+func (st *SessionAffinityStore) Get(ruleUUID, sessionKey string) (string, bool) {
+	if st == nil {
+		return "", false
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	key := st.makeKey(ruleUUID, sessionKey)
+	pin, found := st.pins[key]
+	if !found {
+		return "", false
+	}
+	if time.Now().After(pin.ExpiresAt) {
+		delete(st.pins, key)
+		return "", false
+	}
+	return pin.ServiceID, true
+}
+
+// Pin pins the conversation to a service, or extends the pin, for the given TTL
+func (st *SessionAffinityStore) Pin(ruleUUID, sessionKey, serviceID string, ttl time.Duration) {
+	if st == nil {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.pins[st.makeKey(ruleUUID, sessionKey)] = &SessionPin{
+		ServiceID: serviceID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+}
+
+// Release removes the pin of a conversation
+func (st *SessionAffinityStore) Release(ruleUUID, sessionKey string) {
+	if st == nil {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	delete(st.pins, st.makeKey(ruleUUID, sessionKey))
+}
+
+// CleanupExpired removes expired pins
+func (st *SessionAffinityStore) CleanupExpired() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+	for key, pin := range st.pins {
+		if now.After(pin.ExpiresAt) {
+			delete(st.pins, key)
+		}
+	}
+}
+
+// StartCleanupTask starts a background task to periodically clean up expired pins
+func (st *SessionAffinityStore) StartCleanupTask(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			st.CleanupExpired()
+		}
+	}()
+}
+
+func (st *SessionAffinityStore) makeKey(ruleUUID, sessionKey string) string {
+	return ruleUUID + "/" + sessionKey
+}
+
+// pinnedService returns the service the conversation is pinned to. The pin is released
+// when the service was removed from the rule, was deactivated, has an open circuit or
+// its provider is no longer enabled, so that the caller selects and pins a new one.
+// A dry run leaves the pin as is.
+func (s *Server) pinnedService(rule *typ.Rule, sessionKey string, dryRun bool) (*typ.Provider, *loadbalance.Service) {
+	serviceID, ok := s.sessionAffinity.Get(rule.UUID, sessionKey)
+	if !ok {
+		return nil, nil
+	}
+
+	if service := rule.FindService(serviceID); service != nil && service.Active && service.IsAvailable() {
+		provider, err := s.config.GetProviderByUUID(service.Provider)
+		if err == nil && provider.Enabled {
+			// Sliding TTL: every request of the conversation extends the pin
+			if !dryRun {
+				s.sessionAffinity.Pin(rule.UUID, sessionKey, serviceID, rule.SessionAffinity.GetTTL())
+			}
+			return provider, service
+		}
+	}
+	if dryRun {
+		return nil, nil
+	}
+
+	logrus.Infof("[session_affinity] rule %s: releasing pin to unavailable service %s", rule.UUID, serviceID)
+	s.sessionAffinity.Release(rule.UUID, sessionKey)
+	return nil, nil
+}
+
+// sessionKey derives the conversation key of a request from the configured source,
+// or "" when the request carries none. Keys are prefixed with their source so that
+// a header value never collides with a message hash.
+func (s *Server) sessionKey(c *gin.Context, cfg *typ.SessionAffinityConfig, req interface{}) string {
+	source := cfg.GetKey()
+
+	if source == typ.SessionKeyAuto || source == typ.SessionKeyHeader {
+		if c != nil && c.Request != nil {
+			if value := strings.TrimSpace(c.GetHeader(cfg.GetHeader())); value != "" {
+				return "header:" + value
+			}
+		}
+	}
+	if source == typ.SessionKeyAuto || source == typ.SessionKeyMetadata {
+		if value := sessionMetadata(req); value != "" {
+			return "metadata:" + value
+		}
+	}
+	if source == typ.SessionKeyAuto || source == typ.SessionKeyFirstMessage {
+		if value := s.firstMessageHash(req); value != "" {
+			return "first_message:" + value
+		}
+	}
+	return ""
+}
+
+// sessionMetadata returns the session metadata a client sends with the request:
+// metadata.user_id for Anthropic requests (Claude Code includes its session ID),
+// user for OpenAI requests and prompt_cache_key, else user, for Responses requests
+// (Codex sends its conversation ID as the prompt cache key)
+func sessionMetadata(req interface{}) string {
+	switch r := req.(type) {
+	case *anthropic.MessageNewParams:
+		return r.Metadata.UserID.Value
+	case *anthropic.BetaMessageNewParams:
+		return r.Metadata.UserID.Value
+	case *openai.ChatCompletionNewParams:
+		return r.User.Value
+	case *responses.ResponseNewParams:
+		if r.PromptCacheKey.Value != "" {
+			return r.PromptCacheKey.Value
+		}
+		return r.User.Value
+	}
+	return ""
+}
+
+// firstMessageHash hashes the system prompt and the first user message, which stay
+// the same for every request of a conversation
+func (s *Server) firstMessageHash(req interface{}) string {
+	if r, ok := req.(*responses.ResponseNewParams); ok {
+		return responsesFirstItemHash(r)
+	}
+
+	ctx, _ := s.ExtractRequestContext(nil, req)
+	if ctx == nil || len(ctx.UserMessages) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, msg := range ctx.SystemMessages {
+		h.Write([]byte(msg))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(ctx.UserMessages[0]))
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// responsesFirstItemHash hashes the instructions and the first input item of a Responses
+// request. Requests continuing a stored response only carry the new turn, so they are
+// better keyed by prompt_cache_key.
+func responsesFirstItemHash(req *responses.ResponseNewParams) string {
+	var first []byte
+	if req.Input.OfString.Valid() {
+		first = []byte(req.Input.OfString.Value)
+	} else if len(req.Input.OfInputItemList) > 0 {
+		first, _ = json.Marshal(req.Input.OfInputItemList[0])
+	}
+	if len(first) == 0 {
+		return ""
+	}
+
+	h := sha256.New()
+	h.Write([]byte(req.Instructions.Value))
+	h.Write([]byte{0})
+	h.Write(first)
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/gin-gonic/gin"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/server/config"
+	"github.com/tingly-dev/tingly-box/internal/typ"
+)
+
+func newSessionAffinityServer(t *testing.T) (*Server, *typ.Rule) {
+	t.Helper()
+	cfg, err := config.NewConfigWithDir(t.TempDir())
+	require.NoError(t, err)
+
+	for _, p := range []*typ.Provider{
+		{UUID: "p-a", Name: "provider-a", APIBase: "https://a.example.com/v1", Token: "sk-a", Enabled: true},
+		{UUID: "p-b", Name: "provider-b", APIBase: "https://b.example.com/v1", Token: "sk-b", Enabled: true},
+	} {
+		require.NoError(t, cfg.AddProvider(p))
+	}
+
+	require.NoError(t, cfg.AddRule(typ.Rule{
+		UUID:         "sticky-rule",
+		Scenario:     typ.ScenarioOpenAI,
+		RequestModel: "sticky-model",
+		Services: []*loadbalance.Service{
+			{Provider: "p-a", Model: "model-a", Weight: 1, Active: true},
+			{Provider: "p-b", Model: "model-b", Weight: 1, Active: true},
+		},
+		LBTactic:        typ.Tactic{Type: loadbalance.TacticRoundRobin, Params: typ.DefaultRoundRobinParams()},
+		Active:          true,
+		SessionAffinity: &typ.SessionAffinityConfig{Enabled: true},
+	}))
+
+	return &Server{
+		config:          cfg,
+		loadBalancer:    NewLoadBalancer(cfg),
+		sessionAffinity: NewSessionAffinityStore(),
+	}, cfg.GetRuleByUUID("sticky-rule")
+}
+
+func sessionContext(sessionID string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/openai/v1/chat/completions", nil)
+	if sessionID != "" {
+		c.Request.Header.Set(typ.DefaultSessionHeader, sessionID)
+	}
+	return c
+}
+
+func TestSessionAffinity_PinsConversation(t *testing.T) {
+	s, rule := newSessionAffinityServer(t)
+	require.NotNil(t, rule)
+
+	_, first, err := s.DetermineProviderAndModelWithScenario(sessionContext("conv-1"), typ.ScenarioOpenAI, rule, nil)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, service, err := s.DetermineProviderAndModelWithScenario(sessionContext("conv-1"), typ.ScenarioOpenAI, rule, nil)
+		require.NoError(t, err)
+		assert.Equal(t, first.ServiceID(), service.ServiceID(), "request %d left the pinned service", i)
+	}
+
+	// A deactivated service releases the pin and the conversation moves on
+	first.Active = false
+	provider, moved, err := s.DetermineProviderAndModelWithScenario(sessionContext("conv-1"), typ.ScenarioOpenAI, rule, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ServiceID(), moved.ServiceID())
+	assert.Equal(t, moved.Provider, provider.UUID)
+
+	first.Active = true
+	_, service, err := s.DetermineProviderAndModelWithScenario(sessionContext("conv-1"), typ.ScenarioOpenAI, rule, nil)
+	require.NoError(t, err)
+	assert.Equal(t, moved.ServiceID(), service.ServiceID(), "the conversation stays on its new service")
+}
+
+func TestSessionAffinity_SessionKey(t *testing.T) {
+	s, _ := newSessionAffinityServer(t)
+	auto := &typ.SessionAffinityConfig{Enabled: true}
+
+	turn1 := &openai.ChatCompletionNewParams{
+		Model: "sticky-model",
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage("You are helpful"),
+			openai.UserMessage("Refactor main.go"),
+		},
+	}
+	turn2 := &openai.ChatCompletionNewParams{
+		Model: "sticky-model",
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage("You are helpful"),
+			openai.UserMessage("Refactor main.go"),
+			openai.AssistantMessage("Done"),
+			openai.UserMessage("Now add tests"),
+		},
+	}
+	other := &openai.ChatCompletionNewParams{
+		Model:    "sticky-model",
+		Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("Something else")},
+	}
+
+	key := s.sessionKey(sessionContext(""), auto, turn1)
+	assert.Contains(t, key, "first_message:")
+	assert.Equal(t, key, s.sessionKey(sessionContext(""), auto, turn2), "later turns share the key")
+	assert.NotEqual(t, key, s.sessionKey(sessionContext(""), auto, other))
+
+	assert.Equal(t, "header:conv-1", s.sessionKey(sessionContext("conv-1"), auto, turn1), "the header wins in auto mode")
+
+	claude := &anthropic.MessageNewParams{
+		Model:    "sticky-model",
+		Metadata: anthropic.MetadataParam{UserID: anthropic.String("user_abc_session_123")},
+		Messages: []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock("hi"))},
+	}
+	assert.Equal(t, "metadata:user_abc_session_123", s.sessionKey(sessionContext(""), auto, claude))
+
+	headerOnly := &typ.SessionAffinityConfig{Enabled: true, Key: typ.SessionKeyHeader}
+	assert.Empty(t, s.sessionKey(sessionContext(""), headerOnly, claude), "no header, no key")
+}
+
+func TestSessionAffinity_SessionKeyResponses(t *testing.T) {
+	s, _ := newSessionAffinityServer(t)
+	auto := &typ.SessionAffinityConfig{Enabled: true}
+
+	message := func(role responses.EasyInputMessageRole, text string) responses.ResponseInputItemUnionParam {
+		return responses.ResponseInputItemUnionParam{
+			OfMessage: &responses.EasyInputMessageParam{
+				Role:    role,
+				Content: responses.EasyInputMessageContentUnionParam{OfString: openai.String(text)},
+			},
+		}
+	}
+	turn1 := &responses.ResponseNewParams{
+		Model:        "sticky-model",
+		Instructions: openai.String("You are a coding agent"),
+		Input: responses.ResponseNewParamsInputUnion{OfInputItemList: responses.ResponseInputParam{
+			message(responses.EasyInputMessageRoleUser, "Refactor main.go"),
+		}},
+	}
+	turn2 := &responses.ResponseNewParams{
+		Model:        "sticky-model",
+		Instructions: openai.String("You are a coding agent"),
+		Input: responses.ResponseNewParamsInputUnion{OfInputItemList: responses.ResponseInputParam{
+			message(responses.EasyInputMessageRoleUser, "Refactor main.go"),
+			message(responses.EasyInputMessageRoleAssistant, "Done"),
+			message(responses.EasyInputMessageRoleUser, "Now add tests"),
+		}},
+	}
+
+	key := s.sessionKey(sessionContext(""), auto, turn1)
+	assert.Contains(t, key, "first_message:")
+	assert.Equal(t, key, s.sessionKey(sessionContext(""), auto, turn2), "later turns share the key")
+
+	turn2.PromptCacheKey = openai.String("codex-conv-1")
+	assert.Equal(t, "metadata:codex-conv-1", s.sessionKey(sessionContext(""), auto, turn2))
+}
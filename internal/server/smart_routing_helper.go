@@ -1,28 +1,46 @@
 package server
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/gin-gonic/gin"
 	"github.com/openai/openai-go/v3"
 	"github.com/sirupsen/logrus"
 
 	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+	"github.com/tingly-dev/tingly-box/internal/server/middleware"
 	smartrouting "github.com/tingly-dev/tingly-box/internal/smart_routing"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 )
 
-// ExtractRequestContext extracts RequestContext from request based on type
-func (s *Server) ExtractRequestContext(req interface{}) (*smartrouting.RequestContext, error) {
+// ExtractRequestContext extracts RequestContext from request based on type,
+// along with the headers and API key of the HTTP request when c is set
+func (s *Server) ExtractRequestContext(c *gin.Context, req interface{}) (*smartrouting.RequestContext, error) {
+	var ctx *smartrouting.RequestContext
 	switch r := req.(type) {
 	case *openai.ChatCompletionNewParams:
-		return smartrouting.ExtractContextFromOpenAIRequest(r), nil
+		ctx = smartrouting.ExtractContextFromOpenAIRequest(r)
 	case *anthropic.MessageNewParams:
-		return smartrouting.ExtractContextFromAnthropicRequest(r), nil
+		ctx = smartrouting.ExtractContextFromAnthropicRequest(r)
 	case *anthropic.BetaMessageNewParams:
-		return smartrouting.ExtractContextFromBetaRequest(r), nil
+		ctx = smartrouting.ExtractContextFromBetaRequest(r)
 	default:
 		logrus.Debugf("[smart_routing] unknown request type %T, cannot extract context", req)
 		return nil, nil
 	}
+
+	var header http.Header
+	var apiKeyID, apiKeyName string
+	if c != nil {
+		header = c.Request.Header
+		if key := middleware.APIKeyFromContext(c); key != nil {
+			apiKeyID, apiKeyName = key.ID, key.Name
+		}
+	}
+	ctx.SetRequestInfo(header, apiKeyID, apiKeyName, time.Now())
+	return ctx, nil
 }
 
 // SelectServiceFromSmartRouting selects a service from matched smart routing services
@@ -1063,3 +1063,106 @@ func TestLoadBalancer_RoundRobinThreshold2(t *testing.T) {
 		providerCounts["provider-A"], providerCounts["provider-B"], providerCounts["provider-C"])
 	t.Logf("Final CurrentServiceID: %s", rule.CurrentServiceID)
 }
+
+func TestLoadBalancer_LeastLatency(t *testing.T) {
+	appConfig, err := config.NewAppConfig(config.WithConfigDir(t.TempDir()))
+	require.NoError(t, err)
+
+	lb := server.NewLoadBalancer(appConfig.GetGlobalConfig())
+	defer lb.Stop()
+
+	rule := &typ.Rule{
+		UUID:         "least-latency-rule",
+		Scenario:     typ.ScenarioOpenAI,
+		RequestModel: "test",
+		Services: []*loadbalance.Service{
+			{Provider: "slow", Model: "model1", Active: true, TimeWindow: 300},
+			{Provider: "fast", Model: "model2", Active: true, TimeWindow: 300},
+		},
+		LBTactic: typ.Tactic{
+			Type:   loadbalance.TacticLeastLatency,
+			Params: &typ.LeastLatencyParams{ToleranceMs: 10},
+		},
+		Active: true,
+	}
+
+	// Without samples it falls back to round-robin and still returns a service
+	service, err := lb.SelectService(rule)
+	require.NoError(t, err)
+	assert.NotNil(t, service)
+
+	rule.Services[0].RecordUsage(10, 10)
+	rule.Services[0].RecordTTFT(900)
+	rule.Services[1].RecordUsage(10, 10)
+	rule.Services[1].RecordTTFT(200)
+
+	for i := 0; i < 5; i++ {
+		service, err := lb.SelectService(rule)
+		require.NoError(t, err)
+		assert.Equal(t, "fast", service.Provider)
+	}
+}
+
+func TestLoadBalancer_LowestCost(t *testing.T) {
+	appConfig, err := config.NewAppConfig(config.WithConfigDir(t.TempDir()))
+	require.NoError(t, err)
+
+	lb := server.NewLoadBalancer(appConfig.GetGlobalConfig())
+	defer lb.Stop()
+
+	rule := &typ.Rule{
+		UUID:         "lowest-cost-rule",
+		Scenario:     typ.ScenarioOpenAI,
+		RequestModel: "test",
+		Services: []*loadbalance.Service{
+			{Provider: "expensive", Model: "model1", Active: true, TimeWindow: 300},
+			{Provider: "cheap", Model: "model2", Active: true, TimeWindow: 300},
+			{Provider: "unknown", Model: "model3", Active: true, TimeWindow: 300},
+		},
+		LBTactic: typ.Tactic{
+			Type: loadbalance.TacticLowestCost,
+			Params: &typ.LowestCostParams{Prices: map[string]float64{
+				"expensive:model1": 15,
+				"model2":           0.5,
+			}},
+		},
+		Active: true,
+	}
+
+	service, err := lb.SelectService(rule)
+	require.NoError(t, err)
+	assert.Equal(t, "cheap", service.Provider)
+
+	// Tripped services are skipped even if they are the cheapest
+	for i := 0; i < loadbalance.DefaultCircuitFailureThreshold; i++ {
+		rule.Services[1].RecordResult(true, 0, "upstream error")
+	}
+	service, err = lb.SelectService(rule)
+	require.NoError(t, err)
+	assert.Equal(t, "expensive", service.Provider)
+}
+
+func TestTactic_UnmarshalJSON_NewTactics(t *testing.T) {
+	var latency typ.Tactic
+	require.NoError(t, json.Unmarshal([]byte(`{"type":"least_latency","params":{"tolerance_ms":25}}`), &latency))
+	assert.Equal(t, loadbalance.TacticLeastLatency, latency.Type)
+	if params, ok := latency.Params.(*typ.LeastLatencyParams); assert.True(t, ok) {
+		assert.Equal(t, int64(25), params.ToleranceMs)
+	}
+
+	var cost typ.Tactic
+	require.NoError(t, json.Unmarshal([]byte(`{"type":"lowest_cost","params":{"prices":{"gpt-4o":5}}}`), &cost))
+	assert.Equal(t, loadbalance.TacticLowestCost, cost.Type)
+	if params, ok := cost.Params.(*typ.LowestCostParams); assert.True(t, ok) {
+		assert.Equal(t, 5.0, params.Prices["gpt-4o"])
+	}
+
+	parsed := typ.ParseTacticFromMap(loadbalance.TacticLowestCost, map[string]interface{}{
+		"prices": map[string]interface{}{"gpt-4o": 2.5},
+	})
+	if params, ok := parsed.Params.(*typ.LowestCostParams); assert.True(t, ok) {
+		assert.Equal(t, 2.5, params.Prices["gpt-4o"])
+	}
+	assert.True(t, typ.IsValidTactic("least_latency"))
+	assert.True(t, typ.IsValidTactic("lowest_cost"))
+}
@@ -146,6 +146,9 @@ func extractScenarioFromPath(path string) string {
 	if strings.Contains(path, "/anthropic/") {
 		return "anthropic"
 	}
+	if strings.Contains(path, "/google/") {
+		return "google"
+	}
 	if strings.Contains(path, "/claude_code/") || strings.Contains(path, "/claude-code/") {
 		return "claude_code"
 	}
@@ -12,13 +12,18 @@ import (
 // These keys are used to store tracking information in the gin context
 // to avoid explicit parameter passing throughout the handler chain.
 const (
-	ContextKeyRule         = "tracking_rule"          // *typ.Rule
-	ContextKeyProvider     = "tracking_provider"      // *typ.Provider
-	ContextKeyModel        = "tracking_model"         // string (actual model used)
-	ContextKeyRequestModel = "tracking_request_model" // string (model requested by user)
-	ContextKeyScenario     = "tracking_scenario"      // string (extracted from request path)
-	ContextKeyStreamed     = "tracking_streamed"      // bool
-	ContextKeyStartTime    = "tracking_start_time"    // time.Time
+	ContextKeyRule          = "tracking_rule"           // *typ.Rule
+	ContextKeyProvider      = "tracking_provider"       // *typ.Provider
+	ContextKeyModel         = "tracking_model"          // string (actual model used)
+	ContextKeyRequestModel  = "tracking_request_model"  // string (model requested by user)
+	ContextKeyScenario      = "tracking_scenario"       // string (extracted from request path)
+	ContextKeyStreamed      = "tracking_streamed"       // bool
+	ContextKeyStartTime     = "tracking_start_time"     // time.Time
+	ContextKeyAttempt       = "tracking_attempt"        // int (1-based attempt number within the failover chain)
+	ContextKeyUpstreamError = "tracking_upstream_error" // error (upstream error of the current attempt)
+
+	ContextKeyCompactSavedBytes  = "tracking_compact_saved_bytes"  // int (bytes removed by tool result compaction)
+	ContextKeyCompactSavedTokens = "tracking_compact_saved_tokens" // int (estimated tokens removed by tool result compaction)
 )
 
 // SetTrackingContext sets all tracking metadata in the gin context.
@@ -40,6 +45,15 @@ func SetTrackingContext(c *gin.Context, rule *typ.Rule, provider *typ.Provider,
 	c.Set(ContextKeyStreamed, streamed)
 	c.Set(ContextKeyStartTime, time.Now())
 
+	// Watch for the first byte sent to the client to measure time-to-first-token
+	if c.Writer != nil {
+		if fw, ok := c.Writer.(*firstByteWriter); ok {
+			fw.firstByteAt = time.Time{}
+		} else {
+			c.Writer = &firstByteWriter{ResponseWriter: c.Writer}
+		}
+	}
+
 	// Extract scenario from path if not already set
 	if _, exists := c.Get(ContextKeyScenario); !exists {
 		scenario := "unknown"
@@ -85,3 +99,34 @@ func calculateLatencyFromStart(startTime time.Time) int {
 	elapsed := time.Since(startTime)
 	return int(elapsed.Milliseconds())
 }
+
+// firstByteWriter records when the first response byte is written, which for
+// streaming responses approximates the upstream time-to-first-token.
+type firstByteWriter struct {
+	gin.ResponseWriter
+	firstByteAt time.Time
+}
+
+func (w *firstByteWriter) Write(data []byte) (int, error) {
+	if w.firstByteAt.IsZero() && len(data) > 0 {
+		w.firstByteAt = time.Now()
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *firstByteWriter) WriteString(s string) (int, error) {
+	if w.firstByteAt.IsZero() && len(s) > 0 {
+		w.firstByteAt = time.Now()
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// calculateTTFTFromStart returns the milliseconds between the start time and the
+// first byte written to the client, or the full latency if nothing was written yet
+// (non-streaming handlers track usage before writing the response).
+func calculateTTFTFromStart(c *gin.Context, startTime time.Time) int {
+	if fw, ok := c.Writer.(*firstByteWriter); ok && !fw.firstByteAt.IsZero() && !startTime.IsZero() {
+		return int(fw.firstByteAt.Sub(startTime).Milliseconds())
+	}
+	return calculateLatencyFromStart(startTime)
+}
@@ -114,6 +114,7 @@ func TestExtractScenarioFromPath(t *testing.T) {
 	}{
 		{"OpenAI path", "/v1/openai/chat/completions", "openai"},
 		{"Anthropic path", "/v1/anthropic/messages", "anthropic"},
+		{"Google path", "/google/v1beta/models/gemini-pro:generateContent", "google"},
 		{"Claude Code path", "/v1/claude_code/messages", "claude_code"},
 		{"Claude Code with dash path", "/v1/claude-code/messages", "claude_code"},
 		{"Tingly path", "/v1/tingly/custom/messages", "custom"},
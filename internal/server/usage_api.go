@@ -9,6 +9,7 @@ import (
 
 	"github.com/tingly-dev/tingly-box/internal/data/db"
 	"github.com/tingly-dev/tingly-box/internal/server/config"
+	"github.com/tingly-dev/tingly-box/internal/typ"
 	"github.com/tingly-dev/tingly-box/pkg/swagger"
 )
 
@@ -42,9 +43,9 @@ func (s *Server) RegisterUsageRoutes(manager *swagger.RouteManager) {
 			Name:        "group_by",
 			Type:        "string",
 			Required:    false,
-			Description: "Aggregation level: model, provider, scenario, rule, daily, hourly",
+			Description: "Aggregation level: model, provider, scenario, rule, api_key, daily, hourly",
 			Default:     "model",
-			Enum:        []interface{}{"model", "provider", "scenario", "rule", "daily", "hourly"},
+			Enum:        []interface{}{"model", "provider", "scenario", "rule", "api_key", "daily", "hourly"},
 		}),
 		swagger.WithQueryConfig("start_time", swagger.QueryParamConfig{
 			Name:        "start_time",
@@ -82,6 +83,12 @@ func (s *Server) RegisterUsageRoutes(manager *swagger.RouteManager) {
 			Required:    false,
 			Description: "Filter by rule UUID",
 		}),
+		swagger.WithQueryConfig("api_key_id", swagger.QueryParamConfig{
+			Name:        "api_key_id",
+			Type:        "string",
+			Required:    false,
+			Description: "Filter by API key ID",
+		}),
 		swagger.WithQueryConfig("status", swagger.QueryParamConfig{
 			Name:        "status",
 			Type:        "string",
@@ -103,9 +110,9 @@ func (s *Server) RegisterUsageRoutes(manager *swagger.RouteManager) {
 			Name:        "sort_by",
 			Type:        "string",
 			Required:    false,
-			Description: "Sort field: total_tokens, request_count, avg_latency",
+			Description: "Sort field: total_tokens, request_count, avg_latency, total_cost",
 			Default:     "total_tokens",
-			Enum:        []interface{}{"total_tokens", "request_count", "avg_latency"},
+			Enum:        []interface{}{"total_tokens", "request_count", "avg_latency", "total_cost"},
 		}),
 		swagger.WithQueryConfig("sort_order", swagger.QueryParamConfig{
 			Name:        "sort_order",
@@ -243,6 +250,45 @@ func (s *Server) RegisterUsageRoutes(manager *swagger.RouteManager) {
 			swagger.ErrorResponseConfig{Code: 503, Message: "Usage store not available"},
 		),
 	)
+
+	// GET /api/v1/usage/budgets - List budgets with their usage in the current period
+	apiV1.GET("/usage/budgets", usageAPI.GetBudgets,
+		swagger.WithTags("usage"),
+		swagger.WithDescription("Returns usage budgets with the usage of their current period"),
+		swagger.WithResponseModel(BudgetsResponse{}),
+	)
+
+	// POST /api/v1/usage/budgets - Create a budget
+	apiV1.POST("/usage/budgets", usageAPI.SetBudget,
+		swagger.WithTags("usage"),
+		swagger.WithDescription("Creates a daily or monthly budget for an API key or scenario"),
+		swagger.WithRequestModel(typ.Budget{}),
+		swagger.WithResponseModel(BudgetResponse{}),
+		swagger.WithErrorResponses(
+			swagger.ErrorResponseConfig{Code: 400, Message: "Invalid budget"},
+		),
+	)
+
+	// PUT /api/v1/usage/budgets/:id - Update a budget
+	apiV1.PUT("/usage/budgets/:id", usageAPI.SetBudget,
+		swagger.WithTags("usage"),
+		swagger.WithDescription("Updates the limits of a budget"),
+		swagger.WithRequestModel(typ.Budget{}),
+		swagger.WithResponseModel(BudgetResponse{}),
+		swagger.WithErrorResponses(
+			swagger.ErrorResponseConfig{Code: 400, Message: "Invalid budget"},
+		),
+	)
+
+	// DELETE /api/v1/usage/budgets/:id - Delete a budget
+	apiV1.DELETE("/usage/budgets/:id", usageAPI.DeleteBudget,
+		swagger.WithTags("usage"),
+		swagger.WithDescription("Deletes a budget"),
+		swagger.WithResponseModel(BudgetResponse{}),
+		swagger.WithErrorResponses(
+			swagger.ErrorResponseConfig{Code: 404, Message: "Budget not found"},
+		),
+	)
 }
 
 // GetStats returns aggregated usage statistics
@@ -264,6 +310,7 @@ func (api *UsageAPI) GetStats(c *gin.Context) {
 		Model:     c.Query("model"),
 		Scenario:  c.Query("scenario"),
 		RuleUUID:  c.Query("rule_uuid"),
+		APIKeyID:  c.Query("api_key_id"),
 		Status:    c.Query("status"),
 	}
 
@@ -338,6 +385,11 @@ func (api *UsageAPI) GetTimeSeries(c *gin.Context) {
 			OutputTokens: d.OutputTokens,
 			ErrorCount:   d.ErrorCount,
 			AvgLatencyMs: d.AvgLatencyMs,
+			CostUSD:      d.CostUSD,
+
+			CacheReadTokens:     d.CacheReadTokens,
+			CacheCreationTokens: d.CacheCreationTokens,
+			ReasoningTokens:     d.ReasoningTokens,
 		}
 	}
 
@@ -385,6 +437,9 @@ func (api *UsageAPI) GetRecords(c *gin.Context) {
 	if status := c.Query("status"); status != "" {
 		filters["status"] = status
 	}
+	if apiKeyID := c.Query("api_key_id"); apiKeyID != "" {
+		filters["api_key_id"] = apiKeyID
+	}
 
 	records, total, err := api.usageStore.GetRecords(startTime, endTime, filters, limit, offset)
 	if err != nil {
@@ -403,6 +458,7 @@ func (api *UsageAPI) GetRecords(c *gin.Context) {
 			Scenario:     r.Scenario,
 			RuleUUID:     r.RuleUUID,
 			RequestModel: r.RequestModel,
+			APIKeyID:     r.APIKeyID,
 			Timestamp:    r.Timestamp.Format(time.RFC3339),
 			InputTokens:  r.InputTokens,
 			OutputTokens: r.OutputTokens,
@@ -411,6 +467,14 @@ func (api *UsageAPI) GetRecords(c *gin.Context) {
 			ErrorCode:    r.ErrorCode,
 			LatencyMs:    r.LatencyMs,
 			Streamed:     r.Streamed,
+			CostUSD:      r.CostUSD,
+
+			CacheReadTokens:     r.CacheReadTokens,
+			CacheCreationTokens: r.CacheCreationTokens,
+			ReasoningTokens:     r.ReasoningTokens,
+
+			CompactSavedBytes:  r.CompactSavedBytes,
+			CompactSavedTokens: r.CompactSavedTokens,
 		}
 	}
 
@@ -455,6 +519,63 @@ func (api *UsageAPI) DeleteOldRecords(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetBudgets returns all budgets with the usage of their current period
+func (api *UsageAPI) GetBudgets(c *gin.Context) {
+	now := time.Now()
+	budgets := api.config.ListBudgets()
+
+	data := make([]BudgetStatus, len(budgets))
+	for i, b := range budgets {
+		status := BudgetStatus{
+			Budget:      b,
+			PeriodStart: b.PeriodStart(now).Format(time.RFC3339),
+			PeriodEnd:   b.PeriodEnd(now).Format(time.RFC3339),
+		}
+		if totals, err := api.config.GetBudgetUsage(&b, now); err == nil {
+			status.Usage = totals
+			status.HardExceeded = b.Hard.Exceeded(totals.RequestCount, totals.TotalTokens, totals.CostUSD)
+			status.SoftExceeded = b.Soft.Exceeded(totals.RequestCount, totals.TotalTokens, totals.CostUSD)
+		}
+		data[i] = status
+	}
+
+	c.JSON(http.StatusOK, BudgetsResponse{
+		Success: true,
+		Data:    data,
+	})
+}
+
+// SetBudget creates a budget, or updates the one identified by the id path parameter
+func (api *UsageAPI) SetBudget(c *gin.Context) {
+	var budget typ.Budget
+	if err := c.ShouldBindJSON(&budget); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	budget.ID = c.Param("id")
+
+	saved, err := api.config.SetBudget(budget)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, BudgetResponse{
+		Success: true,
+		Data:    saved,
+	})
+}
+
+// DeleteBudget deletes a budget
+func (api *UsageAPI) DeleteBudget(c *gin.Context) {
+	if err := api.config.DeleteBudget(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, BudgetResponse{Success: true})
+}
+
 // Helper functions
 
 func parseIntQuery(c *gin.Context, key string, defaultValue int) int {
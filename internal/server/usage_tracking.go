@@ -3,10 +3,15 @@ package server
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/tingly-dev/tingly-box/internal/client"
 	"github.com/tingly-dev/tingly-box/internal/data/db"
 	"github.com/tingly-dev/tingly-box/internal/obs/otel"
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+	"github.com/tingly-dev/tingly-box/internal/server/middleware"
 	"github.com/tingly-dev/tingly-box/internal/typ"
 )
 
@@ -34,6 +39,15 @@ func (s *Server) trackUsage(c *gin.Context, rule *typ.Rule, provider *typ.Provid
 //   - outputTokens: Number of output/completion tokens consumed
 //   - err: Error if request failed, nil for success (context.Canceled maps to "canceled" status)
 func (s *Server) trackUsageFromContext(c *gin.Context, inputTokens, outputTokens int, err error) {
+	s.trackUsageStatFromContext(c, protocol.NewUsageStat(inputTokens, outputTokens), err)
+}
+
+// trackUsageStatFromContext records token usage like trackUsageFromContext, keeping
+// the prompt-cache and reasoning token breakdown reported by the protocol handlers.
+func (s *Server) trackUsageStatFromContext(c *gin.Context, usage protocol.UsageStat, err error) {
+	// Charge the tokens against TPM limits once the request is done (summed over failover attempts)
+	c.Set(middleware.ContextKeyUsedTokens, c.GetInt(middleware.ContextKeyUsedTokens)+usage.TotalTokens())
+
 	rule, provider, model, requestModel, scenario, streamed, startTime := GetTrackingContext(c)
 
 	if rule == nil || provider == nil || model == "" {
@@ -41,10 +55,13 @@ func (s *Server) trackUsageFromContext(c *gin.Context, inputTokens, outputTokens
 	}
 
 	latencyMs := calculateLatencyFromStart(startTime)
+	ttftMs := calculateTTFTFromStart(c, startTime)
 
 	// Determine status and error code from error
 	status, errorCode := "success", ""
 	if err != nil {
+		// Let the failover loop see what went wrong with this attempt
+		recordUpstreamError(c, err)
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			status = "canceled"
 			errorCode = "client_disconnected"
@@ -54,29 +71,36 @@ func (s *Server) trackUsageFromContext(c *gin.Context, inputTokens, outputTokens
 		}
 	}
 
-	// 1. Update service stats (inline, no UsageTracker allocation)
-	s.updateServiceStats(rule, provider, model, inputTokens, outputTokens)
+	// 1. Update service health and stats (inline, no UsageTracker allocation).
+	// A key rejected by the upstream is rotated out instead of failing the whole service.
+	if !s.recordProviderKeyResult(provider, model, usage, err) {
+		s.updateServiceHealth(rule, provider, model, err, latencyMs, ttftMs)
+	}
+	s.updateServiceStats(rule, provider, model, usage.InputTokens, usage.OutputTokens)
 
 	// 2. Record to OTel (primary path for metrics)
 	if s.tokenTracker != nil {
 		s.tokenTracker.RecordUsage(c.Request.Context(), otel.UsageOptions{
-			Provider:     provider.Name,
-			ProviderUUID: provider.UUID,
-			Model:        model,
-			RequestModel: requestModel,
-			RuleUUID:     rule.UUID,
-			Scenario:     scenario,
-			InputTokens:  inputTokens,
-			OutputTokens: outputTokens,
-			Streamed:     streamed,
-			Status:       status,
-			ErrorCode:    errorCode,
-			LatencyMs:    latencyMs,
+			Provider:            provider.Name,
+			ProviderUUID:        provider.UUID,
+			Model:               model,
+			RequestModel:        requestModel,
+			RuleUUID:            rule.UUID,
+			Scenario:            scenario,
+			InputTokens:         usage.InputTokens,
+			OutputTokens:        usage.OutputTokens,
+			CacheReadTokens:     usage.CacheReadTokens,
+			CacheCreationTokens: usage.CacheCreationTokens,
+			ReasoningTokens:     usage.ReasoningTokens,
+			Streamed:            streamed,
+			Status:              status,
+			ErrorCode:           errorCode,
+			LatencyMs:           latencyMs,
 		})
 	}
 
 	// 3. Record detailed usage (for analytics/dashboard)
-	s.recordDetailedUsage(c, rule, provider, model, requestModel, scenario, inputTokens, outputTokens, streamed, status, errorCode, latencyMs)
+	s.recordDetailedUsage(c, rule, provider, model, requestModel, scenario, usage, streamed, status, errorCode, latencyMs)
 }
 
 // sanitizeErrorCode extracts a safe error code from an error.
@@ -90,7 +114,7 @@ func sanitizeErrorCode(err error) string {
 
 // recordDetailedUsage writes a detailed usage record to the database.
 // This maintains the detailed analytics tracking for the dashboard.
-func (s *Server) recordDetailedUsage(c *gin.Context, rule *typ.Rule, provider *typ.Provider, model, requestModel, scenario string, inputTokens, outputTokens int, streamed bool, status, errorCode string, latencyMs int) {
+func (s *Server) recordDetailedUsage(c *gin.Context, rule *typ.Rule, provider *typ.Provider, model, requestModel, scenario string, usage protocol.UsageStat, streamed bool, status, errorCode string, latencyMs int) {
 	if s.config == nil {
 		return
 	}
@@ -101,18 +125,25 @@ func (s *Server) recordDetailedUsage(c *gin.Context, rule *typ.Rule, provider *t
 	}
 
 	record := &db.UsageRecord{
-		ProviderUUID: provider.UUID,
-		ProviderName: provider.Name,
-		Model:        model,
-		Scenario:     scenario,
-		RequestModel: requestModel,
-		InputTokens:  inputTokens,
-		OutputTokens: outputTokens,
-		TotalTokens:  inputTokens + outputTokens,
-		Status:       status,
-		ErrorCode:    errorCode,
-		LatencyMs:    latencyMs,
-		Streamed:     streamed,
+		ProviderUUID:        provider.UUID,
+		ProviderName:        provider.Name,
+		Model:               model,
+		Scenario:            scenario,
+		RequestModel:        requestModel,
+		InputTokens:         usage.InputTokens,
+		OutputTokens:        usage.OutputTokens,
+		TotalTokens:         usage.TotalTokens(),
+		CacheReadTokens:     usage.CacheReadTokens,
+		CacheCreationTokens: usage.CacheCreationTokens,
+		ReasoningTokens:     usage.ReasoningTokens,
+		Status:              status,
+		ErrorCode:           errorCode,
+		LatencyMs:           latencyMs,
+		Streamed:            streamed,
+		Attempt:             attemptFromContext(c),
+		APIKeyID:            c.GetString(middleware.ContextKeyAPIKeyID),
+		CompactSavedBytes:   c.GetInt(ContextKeyCompactSavedBytes),
+		CompactSavedTokens:  c.GetInt(ContextKeyCompactSavedTokens),
 	}
 
 	if rule != nil {
@@ -144,6 +175,89 @@ func (s *Server) updateServiceStats(rule *typ.Rule, provider *typ.Provider, mode
 	}
 }
 
+// recordProviderKeyResult accounts the request to the provider key it was sent with,
+// for providers with several keys. Returns true when the upstream rejected the key
+// (401, 403, 429) and the provider still has other keys to rotate to.
+func (s *Server) recordProviderKeyResult(provider *typ.Provider, model string, usage protocol.UsageStat, err error) bool {
+	if provider.KeyID == "" {
+		return false
+	}
+
+	var retryUntil time.Time
+	if rl, ok := client.LatestUpstreamRateLimit(provider.UUID, provider.KeyID, model); ok {
+		retryUntil = rl.RetryUntil
+	}
+	failed := err != nil && !errors.Is(err, context.Canceled)
+	return s.clientPool.RecordKeyResult(provider, upstreamStatusCode(err), retryUntil, usage.InputTokens, usage.OutputTokens, failed)
+}
+
+// updateServiceHealth feeds the request outcome into the service's circuit breaker.
+// Only upstream-side failures count against a service; client errors and
+// cancellations leave its health untouched, only releasing the probe of a half-open
+// circuit. The result is persisted together
+// with the usage stats by updateServiceStats.
+func (s *Server) updateServiceHealth(rule *typ.Rule, provider *typ.Provider, model string, err error, latencyMs, ttftMs int) {
+	if rule == nil || provider == nil {
+		return
+	}
+
+	s.updateServiceRateLimit(rule, provider, model)
+
+	failed := err != nil
+	for i := range rule.Services {
+		service := rule.Services[i]
+		if service.Active && service.Provider == provider.UUID && service.Model == model {
+			if failed && !isRetryableUpstreamError(err) {
+				// No verdict on the service, let the next request probe it
+				service.ReleaseProbe()
+				return
+			}
+			errMsg := ""
+			if failed {
+				errMsg = sanitizeErrorCode(err)
+			}
+			before := service.Stats.GetCircuitState()
+			after := service.RecordResult(failed, latencyMs, errMsg)
+			if !failed {
+				service.RecordTTFT(ttftMs)
+			}
+			if before != after {
+				logrus.Warnf("[circuit] rule %s: service %s circuit %s -> %s", rule.UUID, service.ServiceID(), before, after)
+			}
+			return
+		}
+	}
+}
+
+// updateServiceRateLimit feeds the rate limit headers last reported by the upstream
+// into the service, so that load balancing skips it during a retry-after window
+// and deprioritizes it while it is near its limit.
+func (s *Server) updateServiceRateLimit(rule *typ.Rule, provider *typ.Provider, model string) {
+	// The rate limits of a provider with several keys are aggregated over all of its keys
+	if s.config != nil {
+		if configured, err := s.config.GetProviderByUUID(provider.UUID); err == nil {
+			provider = configured
+		}
+	}
+	rl, ok := client.ServiceUpstreamRateLimit(provider, model)
+	if !ok {
+		return
+	}
+
+	for i := range rule.Services {
+		service := rule.Services[i]
+		if service.Active && service.Provider == provider.UUID && service.Model == model {
+			wasThrottled := service.Stats.IsThrottled()
+			service.RecordUpstreamRateLimit(rl)
+			if !wasThrottled && service.Stats.IsThrottled() {
+				logrus.Warnf("[ratelimit] rule %s: service %s throttled by upstream until %s",
+					rule.UUID, service.ServiceID(), rl.RetryUntil.Format(time.RFC3339))
+			}
+			return
+		}
+	}
+}
+
 // TrackUsage implements the UsageTracker interface.
 // It extracts the gin.Context from the provided context and calls trackUsageFromContext.
 // The gin.Context should be stored in the context with the key "gin_context".
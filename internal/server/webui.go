@@ -564,6 +564,100 @@ func (s *Server) useWebAPIEndpoints(manager *swagger.RouteManager) {
 		swagger.WithResponseModel(DeleteRuleResponse{}),
 	)
 
+	apiV1.GET("/smart-routing/operations", s.GetSmartRoutingOperations,
+		swagger.WithDescription("Get the operations available to smart routing rules"),
+		swagger.WithTags("rules"),
+		swagger.WithResponseModel(SmartRoutingOperationsResponse{}),
+	)
+
+	apiV1.POST("/route/explain", s.ExplainRoute,
+		swagger.WithDescription("Dry-run the routing of a request and explain the decision"),
+		swagger.WithTags("rules"),
+		swagger.WithRequestModel(RouteExplainRequest{}),
+		swagger.WithResponseModel(RouteExplainResponse{}),
+	)
+
+	apiV1.GET("/route/headers", s.GetRouteHeadersSettings,
+		swagger.WithDescription("Get whether proxied responses carry the routing decision headers"),
+		swagger.WithTags("rules"),
+		swagger.WithResponseModel(RouteHeadersSettingsResponse{}),
+	)
+
+	apiV1.PUT("/route/headers", s.SetRouteHeadersSettings,
+		swagger.WithDescription("Set whether proxied responses carry the routing decision headers"),
+		swagger.WithTags("rules"),
+		swagger.WithRequestModel(RouteHeadersSettings{}),
+		swagger.WithResponseModel(RouteHeadersSettingsResponse{}),
+	)
+
+	// API Key Management
+	apiV1.GET("/api-keys", s.GetAPIKeys,
+		swagger.WithDescription("Get all client API keys"),
+		swagger.WithTags("api-keys"),
+		swagger.WithResponseModel(APIKeysResponse{}),
+	)
+
+	apiV1.POST("/api-key", s.CreateAPIKey,
+		swagger.WithDescription("Create a client API key; the secret is only returned once"),
+		swagger.WithTags("api-keys"),
+		swagger.WithRequestModel(CreateAPIKeyRequest{}),
+		swagger.WithResponseModel(APIKeySecretResponse{}),
+	)
+
+	apiV1.POST("/api-key/:id/rotate", s.RotateAPIKey,
+		swagger.WithDescription("Replace the secret of a client API key"),
+		swagger.WithTags("api-keys"),
+		swagger.WithResponseModel(APIKeySecretResponse{}),
+	)
+
+	apiV1.DELETE("/api-key/:id", s.RevokeAPIKey,
+		swagger.WithDescription("Revoke a client API key"),
+		swagger.WithTags("api-keys"),
+		swagger.WithResponseModel(APIKeyActionResponse{}),
+	)
+
+	// Rate Limits
+	apiV1.GET("/rate-limits", s.GetRateLimits,
+		swagger.WithDescription("Get the RPM/TPM limits of the model endpoints"),
+		swagger.WithTags("rate-limits"),
+		swagger.WithResponseModel(RateLimitsResponse{}),
+	)
+
+	apiV1.PUT("/rate-limits", s.SetRateLimits,
+		swagger.WithDescription("Replace the RPM/TPM limits of the model endpoints"),
+		swagger.WithTags("rate-limits"),
+		swagger.WithRequestModel(RateLimitsRequest{}),
+		swagger.WithResponseModel(RateLimitsResponse{}),
+	)
+
+	// Pricing
+	apiV1.GET("/pricing/overrides", s.GetPricingOverrides,
+		swagger.WithDescription("Get the model price overrides used to compute usage cost"),
+		swagger.WithTags("pricing"),
+		swagger.WithResponseModel(PricingOverridesResponse{}),
+	)
+
+	apiV1.PUT("/pricing/overrides", s.SetPricingOverride,
+		swagger.WithDescription("Set or remove the price override of a model"),
+		swagger.WithTags("pricing"),
+		swagger.WithRequestModel(PricingOverrideRequest{}),
+		swagger.WithResponseModel(PricingOverridesResponse{}),
+	)
+
+	// Response Store
+	apiV1.GET("/response-store", s.GetResponseStoreSettings,
+		swagger.WithDescription("Get how long stored Responses API responses are kept"),
+		swagger.WithTags("responses"),
+		swagger.WithResponseModel(ResponseStoreSettingsResponse{}),
+	)
+
+	apiV1.PUT("/response-store", s.SetResponseStoreSettings,
+		swagger.WithDescription("Set how long stored Responses API responses are kept"),
+		swagger.WithTags("responses"),
+		swagger.WithRequestModel(ResponseStoreSettings{}),
+		swagger.WithResponseModel(ResponseStoreSettingsResponse{}),
+	)
+
 	// Scenario Management
 	apiV1.GET("/scenarios", s.GetScenarios,
 		swagger.WithDescription("Get all scenario configurations"),
@@ -693,6 +787,12 @@ func useV2Provider(s *Server, api *swagger.RouteGroup) {
 		swagger.WithResponseModel(UpdateProviderResponse{}),
 	)
 
+	api.GET("/providers/:uuid/keys/stats", s.GetProviderKeyStats,
+		swagger.WithDescription("Get the usage and availability of each API key of a provider"),
+		swagger.WithTags("providers"),
+		swagger.WithResponseModel(ProviderKeyStatsResponse{}),
+	)
+
 	api.POST("/providers/:uuid/toggle", s.ToggleProvider,
 		swagger.WithDescription("Toggle provider enabled/disabled status"),
 		swagger.WithTags("providers"),
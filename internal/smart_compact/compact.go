@@ -1,13 +1,17 @@
-// Package smart_compact provides smart context compression for Anthropic requests.
+// Package smart_compact provides smart context compression for Anthropic and OpenAI requests.
 //
-// The transformer removes thinking fields from non-current conversation rounds.
-// MVP focuses on Anthropic v1 and beta APIs.
+// The transformer removes thinking fields from non-current conversation rounds:
+// thinking blocks for Anthropic v1 and beta, reasoning fields of assistant messages
+// for OpenAI chat, and reasoning items for the OpenAI Responses API.
 package smart_compact
 
 import (
 	"log"
 
 	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/param"
+	"github.com/openai/openai-go/v3/responses"
 
 	"github.com/tingly-dev/tingly-box/internal/protocol"
 )
@@ -71,6 +75,38 @@ func (t *CompactTransformer) HandleV1Beta(req *anthropic.BetaMessageNewParams) e
 	return nil
 }
 
+// HandleOpenAIChat compacts an OpenAI chat completion request by removing reasoning
+// fields from assistant messages of non-current rounds.
+func (t *CompactTransformer) HandleOpenAIChat(req *openai.ChatCompletionNewParams) error {
+	if len(req.Messages) == 0 {
+		return nil
+	}
+
+	rounds := t.rounder.GroupOpenAI(req.Messages)
+	log.Printf("[smart_compact] openai: found %d rounds", len(rounds))
+	compacted, removedCount := t.compactOpenAIRounds(rounds)
+	log.Printf("[smart_compact] openai: removed reasoning from %d messages", removedCount)
+	req.Messages = compacted
+
+	return nil
+}
+
+// HandleOpenAIResponses compacts an OpenAI Responses API request by removing reasoning
+// items from non-current rounds. String inputs carry no history and are left as is.
+func (t *CompactTransformer) HandleOpenAIResponses(req *responses.ResponseNewParams) error {
+	if param.IsOmitted(req.Input.OfInputItemList) || len(req.Input.OfInputItemList) == 0 {
+		return nil
+	}
+
+	rounds := t.rounder.GroupResponses(req.Input.OfInputItemList)
+	log.Printf("[smart_compact] responses: found %d rounds", len(rounds))
+	compacted, removedCount := t.compactResponsesRounds(rounds)
+	log.Printf("[smart_compact] responses: removed %d reasoning items", removedCount)
+	req.Input.OfInputItemList = compacted
+
+	return nil
+}
+
 // compactV1Rounds removes thinking blocks from rounds outside the preservation window.
 //
 // Strategy rationale:
@@ -161,6 +197,86 @@ func (t *CompactTransformer) compactBetaRounds(rounds []protocol.BetaRound) ([]a
 	return result, removedCount
 }
 
+// compactOpenAIRounds removes reasoning fields from rounds outside the preservation window.
+//
+// See compactV1Rounds for detailed strategy rationale and guard checks.
+func (t *CompactTransformer) compactOpenAIRounds(rounds []protocol.OpenAIRound) ([]openai.ChatCompletionMessageParamUnion, int) {
+	var result []openai.ChatCompletionMessageParamUnion
+	removedCount := 0
+	preserveStart := t.preserveStart(len(rounds))
+
+	for i, rnd := range rounds {
+		shouldPreserve := i >= preserveStart
+		guardPassed := t.roundGuard("openai", i, rnd.Stats, shouldPreserve)
+
+		for _, msg := range rnd.Messages {
+			// Only remove reasoning from assistant messages in non-preserved rounds that passed guard
+			if !shouldPreserve && guardPassed && protocol.HasOpenAIReasoning(msg) {
+				t.removeOpenAIReasoning(&msg)
+				removedCount++
+			}
+			result = append(result, msg)
+		}
+	}
+
+	return result, removedCount
+}
+
+// compactResponsesRounds removes reasoning items from rounds outside the preservation window.
+//
+// See compactV1Rounds for detailed strategy rationale and guard checks.
+func (t *CompactTransformer) compactResponsesRounds(rounds []protocol.ResponsesRound) (responses.ResponseInputParam, int) {
+	var result responses.ResponseInputParam
+	removedCount := 0
+	preserveStart := t.preserveStart(len(rounds))
+
+	for i, rnd := range rounds {
+		shouldPreserve := i >= preserveStart
+		guardPassed := t.roundGuard("responses", i, rnd.Stats, shouldPreserve)
+
+		for _, item := range rnd.Items {
+			// Only drop reasoning items in non-preserved rounds that passed guard
+			if !shouldPreserve && guardPassed && !param.IsOmitted(item.OfReasoning) {
+				removedCount++
+				continue
+			}
+			result = append(result, item)
+		}
+	}
+
+	return result, removedCount
+}
+
+// preserveStart returns the index of the first round whose thinking is preserved.
+func (t *CompactTransformer) preserveStart(totalRounds int) int {
+	preserveStart := totalRounds - t.KeepLastNRounds
+	if preserveStart < 0 {
+		preserveStart = 0
+	}
+	return preserveStart
+}
+
+// roundGuard logs the round structure and reports whether the round passed the guard checks.
+func (t *CompactTransformer) roundGuard(api string, i int, stats *protocol.RoundStats, shouldPreserve bool) bool {
+	if stats == nil {
+		// No stats available, assume guard passed for backward compatibility
+		return true
+	}
+	guardPassed := t.shouldCompactRound(stats)
+	log.Printf("[smart_compact] %s: round %d: user=%d, assistant=%d, tool_result=%d, has_thinking=%v, preserve=%v, guard_ok=%v",
+		api, i, stats.UserMessageCount, stats.AssistantCount, stats.ToolResultCount, stats.HasThinking, shouldPreserve, guardPassed)
+	return guardPassed
+}
+
+// removeOpenAIReasoning removes the reasoning fields of an OpenAI assistant message.
+func (t *CompactTransformer) removeOpenAIReasoning(msg *openai.ChatCompletionMessageParamUnion) {
+	extra := msg.ExtraFields()
+	for _, field := range protocol.OpenAIReasoningFields {
+		delete(extra, field)
+	}
+	msg.SetExtraFields(extra)
+}
+
 // removeV1ThinkingBlocks removes thinking content blocks from v1 message content.
 func (t *CompactTransformer) removeV1ThinkingBlocks(content []anthropic.ContentBlockParamUnion, count int) ([]anthropic.ContentBlockParamUnion, int) {
 	var filtered []anthropic.ContentBlockParamUnion
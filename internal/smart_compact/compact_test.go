@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/param"
+	"github.com/openai/openai-go/v3/responses"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -467,3 +470,125 @@ func TestHandleV1_KeepLastNRounds(t *testing.T) {
 	assert.Equal(t, "Current thinking", req.Messages[5].Content[0].OfThinking.Thinking)
 	assert.Equal(t, "Current response", req.Messages[5].Content[1].OfText.Text)
 }
+
+// openAIAssistantWithReasoning builds an assistant message carrying DeepSeek-style reasoning_content
+func openAIAssistantWithReasoning(reasoning, content string) openai.ChatCompletionMessageParamUnion {
+	msg := openai.AssistantMessage(content)
+	msg.SetExtraFields(map[string]any{"reasoning_content": reasoning})
+	return msg
+}
+
+// TestHandleOpenAIChat_RemovesReasoningFromPastRounds verifies reasoning_content is stripped
+// from assistant messages of old rounds and kept in the current round
+func TestHandleOpenAIChat_RemovesReasoningFromPastRounds(t *testing.T) {
+	req := &openai.ChatCompletionNewParams{
+		Model: "deepseek-reasoner",
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage("You are helpful"),
+			// Round 1 - should have reasoning removed
+			openai.UserMessage("Search for something"),
+			openAIAssistantWithReasoning("Old reasoning 1", "Searching"),
+			openai.ToolMessage("result", "call-1"),
+			openAIAssistantWithReasoning("Old reasoning 2", "Round 1 complete"),
+			// Round 2 - current, should keep reasoning
+			openai.UserMessage("New question"),
+			openAIAssistantWithReasoning("Current reasoning", "Current response"),
+		},
+	}
+
+	transformer := NewCompactTransformer(1)
+	err := transformer.HandleOpenAIChat(req)
+
+	require.NoError(t, err)
+	require.Len(t, req.Messages, 7)
+
+	// System message stays at the start of the first round
+	assert.NotNil(t, req.Messages[0].OfSystem)
+
+	// Round 1 assistant messages - reasoning removed
+	assert.False(t, protocol.HasOpenAIReasoning(req.Messages[2]))
+	assert.False(t, protocol.HasOpenAIReasoning(req.Messages[4]))
+
+	// Round 2 assistant message - reasoning preserved
+	assert.Equal(t, "Current reasoning", req.Messages[6].ExtraFields()["reasoning_content"])
+}
+
+// TestGroupOpenAI verifies system messages join the first round and tool messages stay in their round
+func TestGroupOpenAI(t *testing.T) {
+	rounder := protocol.NewGrouper()
+
+	rounds := rounder.GroupOpenAI([]openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("You are helpful"),
+		openai.UserMessage("First question"),
+		openAIAssistantWithReasoning("thinking", "calling tool"),
+		openai.ToolMessage("result", "call-1"),
+		openai.UserMessage("Second question"),
+	})
+
+	require.Len(t, rounds, 2)
+	assert.False(t, rounds[0].IsCurrentRound)
+	assert.Len(t, rounds[0].Messages, 4)
+	assert.Equal(t, 1, rounds[0].Stats.UserMessageCount)
+	assert.Equal(t, 1, rounds[0].Stats.ToolResultCount)
+	assert.True(t, rounds[0].Stats.HasThinking)
+	assert.True(t, rounds[1].IsCurrentRound)
+	assert.Len(t, rounds[1].Messages, 1)
+}
+
+func responsesMessage(role, text string) responses.ResponseInputItemUnionParam {
+	return responses.ResponseInputItemUnionParam{
+		OfMessage: &responses.EasyInputMessageParam{
+			Type:    responses.EasyInputMessageTypeMessage,
+			Role:    responses.EasyInputMessageRole(role),
+			Content: responses.EasyInputMessageContentUnionParam{OfString: param.NewOpt(text)},
+		},
+	}
+}
+
+func responsesReasoning(id string) responses.ResponseInputItemUnionParam {
+	return responses.ResponseInputItemUnionParam{
+		OfReasoning: &responses.ResponseReasoningItemParam{ID: id},
+	}
+}
+
+// TestHandleOpenAIResponses_RemovesReasoningItems verifies reasoning items are dropped from
+// old rounds of a Responses API input and kept in the current round
+func TestHandleOpenAIResponses_RemovesReasoningItems(t *testing.T) {
+	req := &responses.ResponseNewParams{
+		Model: "gpt-5",
+		Input: responses.ResponseNewParamsInputUnion{OfInputItemList: responses.ResponseInputParam{
+			// Round 1 - should have reasoning removed
+			responsesMessage("user", "Search for something"),
+			responsesReasoning("rs_1"),
+			responses.ResponseInputItemUnionParam{OfFunctionCall: &responses.ResponseFunctionToolCallParam{CallID: "call-1", Name: "search", Arguments: "{}"}},
+			responses.ResponseInputItemUnionParam{OfFunctionCallOutput: &responses.ResponseInputItemFunctionCallOutputParam{
+				CallID: "call-1",
+				Output: responses.ResponseInputItemFunctionCallOutputOutputUnionParam{OfString: param.NewOpt("result")},
+			}},
+			responsesMessage("assistant", "Round 1 complete"),
+			// Round 2 - current, should keep reasoning
+			responsesMessage("user", "New question"),
+			responsesReasoning("rs_2"),
+		}},
+	}
+
+	transformer := NewCompactTransformer(1)
+	err := transformer.HandleOpenAIResponses(req)
+
+	require.NoError(t, err)
+	items := req.Input.OfInputItemList
+	require.Len(t, items, 6)
+	assert.NotNil(t, items[1].OfFunctionCall, "round 1 reasoning item dropped")
+	assert.Equal(t, "rs_2", items[5].OfReasoning.ID)
+}
+
+func TestHandleOpenAIResponses_StringInput(t *testing.T) {
+	req := &responses.ResponseNewParams{
+		Model: "gpt-5",
+		Input: responses.ResponseNewParamsInputUnion{OfString: param.NewOpt("Hello")},
+	}
+
+	transformer := NewCompactTransformer(1)
+	require.NoError(t, transformer.HandleOpenAIResponses(req))
+	assert.Equal(t, "Hello", req.Input.OfString.Value)
+}
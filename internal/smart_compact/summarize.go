@@ -0,0 +1,397 @@
+package smart_compact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go/v3"
+
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+)
+
+// Summary prompts sent to the summarizer model
+const (
+	SummarySystemPrompt = "You compress the earlier part of a conversation between a user and an AI assistant " +
+		"so that the assistant can continue the conversation without it. Keep the user's goals and " +
+		"instructions, decisions made, facts learned, files and commands involved with their relevant " +
+		"results, errors met and open tasks. Leave out pleasantries and repetition. Answer with the summary only."
+
+	summaryAck = "Understood, I will continue from this summary of our earlier conversation."
+
+	// summaryToolResultPreviewBytes is the head and tail kept of a tool result in the transcript
+	summaryToolResultPreviewBytes = 1024
+)
+
+// Summarizer summarizes the transcript of conversation rounds. previous is the summary of
+// the rounds before the transcript, "" when there is none; the returned summary covers both.
+type Summarizer func(ctx context.Context, previous, transcript string) (string, error)
+
+// SummaryCache stores summaries by the hash of the conversation prefix they cover
+type SummaryCache interface {
+	Get(key string) (string, bool)
+	Set(key, summary string)
+}
+
+// SummaryStats reports what a summarization did
+type SummaryStats struct {
+	SummarizedRounds int  // Leading rounds replaced by the summary
+	CacheHit         bool // Whether the summary was reused from the cache as is
+	SavedTokens      int  // Estimated tokens removed from the request
+}
+
+// OverflowSummarizer replaces the oldest rounds of a conversation that does not fit the
+// context window with a summary written by a (cheap) summarizer model.
+//
+// The summarized rounds become a synthetic user message carrying the summary, acknowledged
+// by a synthetic assistant message; the last KeepLastNRounds rounds are kept verbatim.
+// Summaries are cached by a hash of the conversation prefix they cover, so a growing
+// conversation reuses its summary while it still fits, and only the rounds added since are
+// summarized (together with the cached summary) once it does not anymore. Transcripts
+// larger than the summarizer takes are summarized in several passes, each extending the
+// summary of the previous ones.
+type OverflowSummarizer struct {
+	rounder             *protocol.Grouper
+	KeepLastNRounds     int          // Number of recent rounds never summarized (min: 1)
+	Summarize           Summarizer   // Writes the summaries
+	Cache               SummaryCache // Optional summary cache
+	MaxTranscriptTokens int          // Largest transcript sent in one summarizer call, 0 for no limit
+
+	// Stats reports the summarization of the last handled request
+	Stats SummaryStats
+}
+
+// NewOverflowSummarizer creates a summarizer that keeps the last keepLastNRounds rounds
+func NewOverflowSummarizer(keepLastNRounds int, summarize Summarizer, cache SummaryCache) *OverflowSummarizer {
+	if keepLastNRounds < 1 {
+		keepLastNRounds = 1
+	}
+	return &OverflowSummarizer{
+		rounder:         protocol.NewGrouper(),
+		KeepLastNRounds: keepLastNRounds,
+		Summarize:       summarize,
+		Cache:           cache,
+	}
+}
+
+// HandleV1 summarizes the oldest rounds of an Anthropic v1 request so that it shrinks by
+// about overflow tokens.
+func (s *OverflowSummarizer) HandleV1(ctx context.Context, req *anthropic.MessageNewParams, overflow int) error {
+	rounds := s.rounder.GroupV1(req.Messages)
+	encoded := make([][]json.RawMessage, len(rounds))
+	for i, rnd := range rounds {
+		encoded[i] = encodeMessages(rnd.Messages)
+	}
+
+	summary, cut, err := s.summarize(ctx, "v1", encoded, overflow)
+	if err != nil || cut == 0 {
+		return err
+	}
+
+	messages := []anthropic.MessageParam{
+		anthropic.NewUserMessage(anthropic.NewTextBlock(formatSummary(summary))),
+		anthropic.NewAssistantMessage(anthropic.NewTextBlock(summaryAck)),
+	}
+	for _, rnd := range rounds[cut:] {
+		messages = append(messages, rnd.Messages...)
+	}
+	req.Messages = messages
+	return nil
+}
+
+// HandleV1Beta summarizes the oldest rounds of an Anthropic v1beta request so that it
+// shrinks by about overflow tokens.
+func (s *OverflowSummarizer) HandleV1Beta(ctx context.Context, req *anthropic.BetaMessageNewParams, overflow int) error {
+	rounds := s.rounder.GroupBeta(req.Messages)
+	encoded := make([][]json.RawMessage, len(rounds))
+	for i, rnd := range rounds {
+		encoded[i] = encodeMessages(rnd.Messages)
+	}
+
+	summary, cut, err := s.summarize(ctx, "v1beta", encoded, overflow)
+	if err != nil || cut == 0 {
+		return err
+	}
+
+	messages := []anthropic.BetaMessageParam{
+		{
+			Role:    anthropic.BetaMessageParamRoleUser,
+			Content: []anthropic.BetaContentBlockParamUnion{anthropic.NewBetaTextBlock(formatSummary(summary))},
+		},
+		{
+			Role:    anthropic.BetaMessageParamRoleAssistant,
+			Content: []anthropic.BetaContentBlockParamUnion{anthropic.NewBetaTextBlock(summaryAck)},
+		},
+	}
+	for _, rnd := range rounds[cut:] {
+		messages = append(messages, rnd.Messages...)
+	}
+	req.Messages = messages
+	return nil
+}
+
+// HandleOpenAIChat summarizes the oldest rounds of an OpenAI chat completion request so
+// that it shrinks by about overflow tokens. Leading system and developer messages are kept.
+func (s *OverflowSummarizer) HandleOpenAIChat(ctx context.Context, req *openai.ChatCompletionNewParams, overflow int) error {
+	rounds := s.rounder.GroupOpenAI(req.Messages)
+	encoded := make([][]json.RawMessage, len(rounds))
+	for i, rnd := range rounds {
+		encoded[i] = encodeMessages(rnd.Messages)
+	}
+
+	summary, cut, err := s.summarize(ctx, "openai", encoded, overflow)
+	if err != nil || cut == 0 {
+		return err
+	}
+
+	var messages []openai.ChatCompletionMessageParamUnion
+	for _, msg := range rounds[0].Messages {
+		if msg.OfSystem == nil && msg.OfDeveloper == nil {
+			break
+		}
+		messages = append(messages, msg)
+	}
+	messages = append(messages,
+		openai.UserMessage(formatSummary(summary)),
+		openai.AssistantMessage(summaryAck),
+	)
+	for _, rnd := range rounds[cut:] {
+		messages = append(messages, rnd.Messages...)
+	}
+	req.Messages = messages
+	return nil
+}
+
+// summarize returns the summary replacing the first cut rounds, cut is 0 when nothing is
+// summarized. A cached summary is reused when it shrinks the request enough; otherwise
+// everything but the kept rounds is summarized, starting from the longest cached prefix.
+func (s *OverflowSummarizer) summarize(ctx context.Context, api string, rounds [][]json.RawMessage, overflow int) (string, int, error) {
+	s.Stats = SummaryStats{}
+
+	cut := len(rounds) - s.KeepLastNRounds
+	if cut < 1 || overflow <= 0 {
+		return "", 0, nil
+	}
+
+	// keys[i] identifies the prefix rounds[:i], sizes[i] is its size in bytes
+	keys := make([]string, cut+1)
+	sizes := make([]int, cut+1)
+	h := sha256.New()
+	h.Write([]byte(api))
+	for i := 0; i < cut; i++ {
+		sizes[i+1] = sizes[i]
+		for _, msg := range rounds[i] {
+			h.Write(msg)
+			h.Write([]byte{'\n'})
+			sizes[i+1] += len(msg)
+		}
+		keys[i+1] = hex.EncodeToString(h.Sum(nil))
+	}
+	savedTokens := func(k int, summary string) int {
+		return (sizes[k] - len(formatSummary(summary)) - len(summaryAck)) / estimatedBytesPerToken
+	}
+
+	start, previous := 0, ""
+	if s.Cache != nil {
+		for k := cut; k > 0; k-- {
+			cached, ok := s.Cache.Get(keys[k])
+			if !ok {
+				continue
+			}
+			if k == cut || savedTokens(k, cached) >= overflow {
+				s.Stats = SummaryStats{SummarizedRounds: k, CacheHit: true, SavedTokens: savedTokens(k, cached)}
+				log.Printf("[smart_compact] %s: reused cached summary of %d rounds, saved ~%d tokens", api, k, s.Stats.SavedTokens)
+				return cached, k, nil
+			}
+			start, previous = k, cached
+			break
+		}
+	}
+
+	if s.Summarize == nil {
+		return "", 0, fmt.Errorf("no summarizer configured")
+	}
+	first, summary := start, previous
+	for start < cut {
+		end, transcript := s.transcriptChunk(rounds, start, cut)
+		next, err := s.Summarize(ctx, summary, transcript)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to summarize rounds %d-%d: %w", start+1, end, err)
+		}
+		next = strings.TrimSpace(next)
+		if next == "" {
+			return "", 0, fmt.Errorf("summarizer returned an empty summary")
+		}
+		summary, start = next, end
+		if s.Cache != nil {
+			s.Cache.Set(keys[end], summary)
+		}
+	}
+
+	s.Stats = SummaryStats{SummarizedRounds: cut, SavedTokens: savedTokens(cut, summary)}
+	log.Printf("[smart_compact] %s: summarized %d rounds (%d new), saved ~%d tokens", api, cut, cut-first, s.Stats.SavedTokens)
+	return summary, cut, nil
+}
+
+// transcriptChunk returns the transcript of the rounds from start on that fits
+// MaxTranscriptTokens, and the index of the round after the last one it covers. A round
+// that does not fit on its own is sent alone, truncated.
+func (s *OverflowSummarizer) transcriptChunk(rounds [][]json.RawMessage, start, cut int) (int, string) {
+	limit := s.MaxTranscriptTokens * estimatedBytesPerToken
+	var transcript strings.Builder
+	end := start
+	for end < cut {
+		var round strings.Builder
+		for _, msg := range rounds[end] {
+			writeTranscript(&round, msg)
+		}
+		if limit > 0 && transcript.Len()+round.Len() > limit {
+			if end == start {
+				transcript.WriteString(truncateTranscript(round.String(), limit))
+				end++
+			}
+			break
+		}
+		transcript.WriteString(round.String())
+		end++
+	}
+	return end, transcript.String()
+}
+
+// truncateTranscript keeps the first limit bytes of a transcript, cut on a UTF-8 boundary
+func truncateTranscript(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + fmt.Sprintf("\n[%d bytes of this round truncated]\n\n", len(text)-cut)
+}
+
+// SummaryRequest returns the user message asking the summarizer to summarize a transcript,
+// extending the previous summary if any
+func SummaryRequest(previous, transcript string) string {
+	var b strings.Builder
+	if previous != "" {
+		b.WriteString("Summary of the conversation so far:\n<summary>\n")
+		b.WriteString(previous)
+		b.WriteString("\n</summary>\n\nUpdate the summary with the continuation of the conversation below.\n\n")
+	} else {
+		b.WriteString("Summarize the conversation below.\n\n")
+	}
+	b.WriteString("<transcript>\n")
+	b.WriteString(transcript)
+	b.WriteString("</transcript>")
+	return b.String()
+}
+
+func formatSummary(summary string) string {
+	return "<conversation-summary>\nThe earlier part of this conversation was summarized to fit the context window:\n\n" +
+		summary + "\n</conversation-summary>"
+}
+
+func encodeMessages[T any](messages []T) []json.RawMessage {
+	encoded := make([]json.RawMessage, 0, len(messages))
+	for _, msg := range messages {
+		if data, err := json.Marshal(msg); err == nil {
+			encoded = append(encoded, data)
+		}
+	}
+	return encoded
+}
+
+// transcriptMessage decodes the parts of Anthropic and OpenAI messages rendered in a transcript
+type transcriptMessage struct {
+	Role      string          `json:"role"`
+	Content   json.RawMessage `json:"content"`
+	ToolCalls []struct {
+		Function struct {
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"`
+		} `json:"function"`
+	} `json:"tool_calls"`
+}
+
+// transcriptBlock decodes Anthropic content blocks and OpenAI content parts
+type transcriptBlock struct {
+	Type    string          `json:"type"`
+	Text    string          `json:"text"`
+	Name    string          `json:"name"`
+	Input   json.RawMessage `json:"input"`
+	Content json.RawMessage `json:"content"`
+}
+
+// writeTranscript renders a message as plain text lines. Thinking is left out, system
+// messages are kept in the request and tool results are truncated to a preview.
+func writeTranscript(b *strings.Builder, data json.RawMessage) {
+	var msg transcriptMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+
+	switch msg.Role {
+	case "system", "developer":
+		return
+	case "tool":
+		fmt.Fprintf(b, "[tool result]\n%s\n\n", transcriptPreview(contentText(msg.Content)))
+		return
+	}
+
+	var text string
+	if err := json.Unmarshal(msg.Content, &text); err == nil {
+		if text != "" {
+			fmt.Fprintf(b, "%s: %s\n\n", msg.Role, text)
+		}
+	} else {
+		var blocks []transcriptBlock
+		_ = json.Unmarshal(msg.Content, &blocks)
+		for _, block := range blocks {
+			switch block.Type {
+			case "text":
+				fmt.Fprintf(b, "%s: %s\n\n", msg.Role, block.Text)
+			case "image", "image_url":
+				fmt.Fprintf(b, "%s: [image]\n\n", msg.Role)
+			case "tool_use", "server_tool_use":
+				fmt.Fprintf(b, "[tool call %s] %s\n\n", block.Name, block.Input)
+			case "tool_result":
+				fmt.Fprintf(b, "[tool result]\n%s\n\n", transcriptPreview(contentText(block.Content)))
+			}
+		}
+	}
+	for _, call := range msg.ToolCalls {
+		fmt.Fprintf(b, "[tool call %s] %s\n\n", call.Function.Name, call.Function.Arguments)
+	}
+}
+
+// contentText returns the text of string or block content
+func contentText(content json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text
+	}
+	var blocks []transcriptBlock
+	_ = json.Unmarshal(content, &blocks)
+	texts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		if block.Text != "" {
+			texts = append(texts, block.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func transcriptPreview(text string) string {
+	t := &ToolResultCompactor{PreviewBytes: summaryToolResultPreviewBytes}
+	if preview, ok := t.preview(text); ok {
+		return preview
+	}
+	return text
+}
@@ -0,0 +1,181 @@
+package smart_compact
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapSummaryCache map[string]string
+
+func (m mapSummaryCache) Get(key string) (string, bool) {
+	summary, ok := m[key]
+	return summary, ok
+}
+
+func (m mapSummaryCache) Set(key, summary string) {
+	m[key] = summary
+}
+
+// fakeSummarizer records its calls and returns a short summary
+type fakeSummarizer struct {
+	calls       int
+	previous    string
+	transcripts []string
+}
+
+func (f *fakeSummarizer) summarize(_ context.Context, previous, transcript string) (string, error) {
+	f.calls++
+	f.previous = previous
+	f.transcripts = append(f.transcripts, transcript)
+	return fmt.Sprintf("summary #%d", f.calls), nil
+}
+
+func v1Conversation(rounds int) []anthropic.MessageParam {
+	var messages []anthropic.MessageParam
+	for i := 1; i <= rounds; i++ {
+		messages = append(messages,
+			anthropic.NewUserMessage(anthropic.NewTextBlock(fmt.Sprintf("question %d", i))),
+			anthropic.NewAssistantMessage(anthropic.NewTextBlock(fmt.Sprintf("answer %d ", i)+strings.Repeat("x", 400))),
+		)
+	}
+	return messages
+}
+
+// TestOverflowSummarizer_V1 verifies that the oldest rounds are replaced by a summary
+// and that the kept rounds are left as is
+func TestOverflowSummarizer_V1(t *testing.T) {
+	fake := &fakeSummarizer{}
+	req := &anthropic.MessageNewParams{
+		Model:     anthropic.Model("claude-sonnet-4-5"),
+		MaxTokens: 1024,
+		Messages:  v1Conversation(4),
+	}
+
+	s := NewOverflowSummarizer(2, fake.summarize, nil)
+	require.NoError(t, s.HandleV1(context.Background(), req, 100))
+
+	require.Len(t, req.Messages, 6)
+	assert.Contains(t, req.Messages[0].Content[0].OfText.Text, "<conversation-summary>")
+	assert.Contains(t, req.Messages[0].Content[0].OfText.Text, "summary #1")
+	assert.Equal(t, anthropic.MessageParamRoleAssistant, req.Messages[1].Role)
+	assert.Equal(t, "question 3", req.Messages[2].Content[0].OfText.Text)
+
+	require.Equal(t, 1, fake.calls)
+	assert.Contains(t, fake.transcripts[0], "user: question 1")
+	assert.Contains(t, fake.transcripts[0], "assistant: answer 2")
+	assert.NotContains(t, fake.transcripts[0], "question 3")
+	assert.Equal(t, 2, s.Stats.SummarizedRounds)
+	assert.False(t, s.Stats.CacheHit)
+}
+
+// TestOverflowSummarizer_Cache verifies that the summary of a prefix is reused while it
+// shrinks the request enough, and extended with the new rounds once it does not
+func TestOverflowSummarizer_Cache(t *testing.T) {
+	fake := &fakeSummarizer{}
+	cache := mapSummaryCache{}
+
+	summarize := func(rounds, overflow int) *anthropic.MessageNewParams {
+		req := &anthropic.MessageNewParams{Model: anthropic.Model("claude-sonnet-4-5"), MaxTokens: 1024, Messages: v1Conversation(rounds)}
+		require.NoError(t, NewOverflowSummarizer(2, fake.summarize, cache).HandleV1(context.Background(), req, overflow))
+		return req
+	}
+
+	summarize(4, 100)
+	require.Equal(t, 1, fake.calls)
+
+	// The next turn still fits with the cached summary of rounds 1-2
+	req := summarize(5, 100)
+	assert.Equal(t, 1, fake.calls, "the cached summary is reused")
+	require.Len(t, req.Messages, 8)
+	assert.Contains(t, req.Messages[0].Content[0].OfText.Text, "summary #1")
+
+	// A larger overflow needs rounds 1-3 summarized: only round 3 is sent, with the cached summary
+	req = summarize(5, 300)
+	require.Equal(t, 2, fake.calls)
+	assert.Equal(t, "summary #1", fake.previous)
+	assert.Contains(t, fake.transcripts[1], "question 3")
+	assert.NotContains(t, fake.transcripts[1], "question 2")
+	require.Len(t, req.Messages, 6)
+	assert.Contains(t, req.Messages[0].Content[0].OfText.Text, "summary #2")
+}
+
+// TestOverflowSummarizer_Chunks verifies that a transcript larger than the summarizer takes
+// is summarized in passes, each one extending the summary of the previous ones
+func TestOverflowSummarizer_Chunks(t *testing.T) {
+	fake := &fakeSummarizer{}
+	cache := mapSummaryCache{}
+	req := &anthropic.MessageNewParams{Model: anthropic.Model("claude-sonnet-4-5"), MaxTokens: 1024, Messages: v1Conversation(4)}
+
+	s := NewOverflowSummarizer(1, fake.summarize, cache)
+	s.MaxTranscriptTokens = 150 // about one round
+	require.NoError(t, s.HandleV1(context.Background(), req, 100))
+
+	require.Equal(t, 3, fake.calls)
+	assert.Equal(t, "summary #2", fake.previous)
+	for i, transcript := range fake.transcripts {
+		assert.Contains(t, transcript, fmt.Sprintf("question %d", i+1))
+		assert.NotContains(t, transcript, fmt.Sprintf("question %d", i+2))
+	}
+	assert.Len(t, cache, 3, "every pass is cached")
+	assert.Contains(t, req.Messages[0].Content[0].OfText.Text, "summary #3")
+
+	// A round larger than the limit is sent alone, truncated
+	s.MaxTranscriptTokens = 20
+	end, transcript := s.transcriptChunk([][]json.RawMessage{encodeMessages(v1Conversation(1))}, 0, 1)
+	assert.Equal(t, 1, end)
+	assert.Contains(t, transcript, "bytes of this round truncated")
+}
+
+// TestOverflowSummarizer_OpenAIChat verifies that leading system messages are kept and
+// that tool calls and results appear in the transcript
+func TestOverflowSummarizer_OpenAIChat(t *testing.T) {
+	fake := &fakeSummarizer{}
+	assistant := openai.AssistantMessage("")
+	assistant.OfAssistant.ToolCalls = []openai.ChatCompletionMessageToolCallUnionParam{{
+		OfFunction: &openai.ChatCompletionMessageFunctionToolCallParam{
+			ID:       "call-1",
+			Function: openai.ChatCompletionMessageFunctionToolCallFunctionParam{Name: "read_file", Arguments: `{"path":"a.go"}`},
+		},
+	}}
+	req := &openai.ChatCompletionNewParams{
+		Model: "gpt-4o",
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage("You are a coding agent"),
+			openai.UserMessage("Read a.go"),
+			assistant,
+			openai.ToolMessage(strings.Repeat("package a\n", 500), "call-1"),
+			openai.UserMessage("Now fix it"),
+		},
+	}
+
+	s := NewOverflowSummarizer(1, fake.summarize, nil)
+	require.NoError(t, s.HandleOpenAIChat(context.Background(), req, 100))
+
+	require.Len(t, req.Messages, 4)
+	assert.NotNil(t, req.Messages[0].OfSystem)
+	assert.Contains(t, req.Messages[1].OfUser.Content.OfString.Value, "summary #1")
+	assert.NotNil(t, req.Messages[2].OfAssistant)
+	assert.Equal(t, "Now fix it", req.Messages[3].OfUser.Content.OfString.Value)
+
+	transcript := fake.transcripts[0]
+	assert.NotContains(t, transcript, "You are a coding agent")
+	assert.Contains(t, transcript, `[tool call read_file] {"path":"a.go"}`)
+	assert.Contains(t, transcript, "bytes of tool output truncated")
+}
+
+func TestOverflowSummarizer_NothingToSummarize(t *testing.T) {
+	fake := &fakeSummarizer{}
+	req := &anthropic.MessageNewParams{Model: anthropic.Model("claude-sonnet-4-5"), MaxTokens: 1024, Messages: v1Conversation(2)}
+
+	require.NoError(t, NewOverflowSummarizer(2, fake.summarize, nil).HandleV1(context.Background(), req, 100))
+	assert.Len(t, req.Messages, 4)
+	assert.Equal(t, 0, fake.calls)
+}
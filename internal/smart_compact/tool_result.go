@@ -0,0 +1,405 @@
+package smart_compact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/param"
+	"github.com/openai/openai-go/v3/responses"
+
+	"github.com/tingly-dev/tingly-box/internal/protocol"
+)
+
+// Tool result compaction defaults
+const (
+	DefaultToolResultKeepRounds   = 2        // Rounds whose tool results are never truncated
+	DefaultMaxToolResultBytes     = 8 * 1024 // Older tool results above this size are truncated
+	DefaultToolResultPreviewBytes = 1024     // Bytes kept at the head and at the tail of a truncated result
+)
+
+const (
+	// estimatedBytesPerToken is the rough approximation smart routing uses as well
+	estimatedBytesPerToken = 4
+
+	toolResultMarkerPrefix     = "[tingly-box: "
+	toolResultSupersededMarker = toolResultMarkerPrefix + "output omitted, superseded by a later identical %s call]"
+	toolResultTruncatedMarker  = "\n\n" + toolResultMarkerPrefix + "%d bytes of tool output truncated]\n\n"
+)
+
+// cacheControlKey marks a content block carrying an Anthropic prompt cache breakpoint.
+// Inside a JSON string the quotes are escaped, so text content never matches.
+var cacheControlKey = []byte(`"cache_control":`)
+
+// CompactStats reports what a compaction saved
+type CompactStats struct {
+	Superseded  int // Tool results replaced because a later identical call superseded them
+	Truncated   int // Tool results truncated to a head/tail preview
+	SavedBytes  int // Bytes removed from tool results
+	SavedTokens int // Estimated tokens removed from tool results
+}
+
+// ToolResultCompactor compacts tool results in long agent sessions.
+//
+// Two rules are applied, in order:
+//   - A tool result is superseded when a later call of the same tool with the same input
+//     has a result too (the same file Read again, the same command run again). Its content
+//     is replaced by a short marker.
+//   - A tool result outside the last KeepLastNRounds rounds that is larger than
+//     MaxResultBytes is truncated to its first and last PreviewBytes with a marker in between.
+//
+// Tool use and tool result blocks are never removed, only the result content is rewritten,
+// so every tool call keeps its result. Results with non-text content (images) are left as is.
+//
+// Anthropic caches the request prefix up to the last cache_control breakpoint, and rewriting
+// anything in that prefix invalidates the cache. Results in the messages up to and including
+// the one with the last breakpoint are therefore left as is; they still supersede earlier results.
+type ToolResultCompactor struct {
+	rounder         *protocol.Grouper
+	KeepLastNRounds int // Number of recent rounds whose results are not truncated (min: 1)
+	MaxResultBytes  int // Older results above this size are truncated
+	PreviewBytes    int // Bytes kept at the head and at the tail of a truncated result
+
+	// Stats reports the savings of the last handled request
+	Stats CompactStats
+}
+
+// NewToolResultCompactor creates a tool result compactor that truncates large results
+// outside the last keepLastNRounds rounds
+func NewToolResultCompactor(keepLastNRounds int) *ToolResultCompactor {
+	if keepLastNRounds < 1 {
+		keepLastNRounds = 1
+	}
+	return &ToolResultCompactor{
+		rounder:         protocol.NewGrouper(),
+		KeepLastNRounds: keepLastNRounds,
+		MaxResultBytes:  DefaultMaxToolResultBytes,
+		PreviewBytes:    DefaultToolResultPreviewBytes,
+	}
+}
+
+// toolCall is a tool call of the request being compacted
+type toolCall struct {
+	name string // Name of the tool
+	key  string // Tool name and canonical input, "" when the input cannot be encoded
+}
+
+// toolResultRef points at a tool result of the request being compacted
+type toolResultRef struct {
+	round  int
+	cached bool              // The result is in the prompt cache prefix and is not rewritten
+	call   toolCall          // Call that produced the result, zero when the call is unknown
+	text   string            // Text content of the result
+	set    func(text string) // Rewrites the result content
+}
+
+// HandleV1 compacts the tool results of an Anthropic v1 request.
+func (t *ToolResultCompactor) HandleV1(req *anthropic.MessageNewParams) error {
+	if len(req.Messages) == 0 {
+		return nil
+	}
+
+	rounds := t.rounder.GroupV1(req.Messages)
+	breakpoint := cacheBreakpoint(req.Messages)
+	calls := map[string]toolCall{}
+	var refs []*toolResultRef
+	index := 0
+	for i, rnd := range rounds {
+		for _, msg := range rnd.Messages {
+			for _, block := range msg.Content {
+				if block.OfToolUse != nil {
+					calls[block.OfToolUse.ID] = newToolCall(block.OfToolUse.Name, block.OfToolUse.Input)
+				}
+				if result := block.OfToolResult; result != nil {
+					if ref := newV1ToolResultRef(i, result, calls); ref != nil {
+						ref.cached = index <= breakpoint
+						refs = append(refs, ref)
+					}
+				}
+			}
+			index++
+		}
+	}
+
+	t.compact("v1", refs, len(rounds))
+	return nil
+}
+
+// HandleV1Beta compacts the tool results of an Anthropic v1beta request.
+func (t *ToolResultCompactor) HandleV1Beta(req *anthropic.BetaMessageNewParams) error {
+	if len(req.Messages) == 0 {
+		return nil
+	}
+
+	rounds := t.rounder.GroupBeta(req.Messages)
+	breakpoint := cacheBreakpoint(req.Messages)
+	calls := map[string]toolCall{}
+	var refs []*toolResultRef
+	index := 0
+	for i, rnd := range rounds {
+		for _, msg := range rnd.Messages {
+			for _, block := range msg.Content {
+				if block.OfToolUse != nil {
+					calls[block.OfToolUse.ID] = newToolCall(block.OfToolUse.Name, block.OfToolUse.Input)
+				}
+				if result := block.OfToolResult; result != nil {
+					if ref := newBetaToolResultRef(i, result, calls); ref != nil {
+						ref.cached = index <= breakpoint
+						refs = append(refs, ref)
+					}
+				}
+			}
+			index++
+		}
+	}
+
+	t.compact("v1beta", refs, len(rounds))
+	return nil
+}
+
+// HandleOpenAIChat compacts the tool messages of an OpenAI chat completion request.
+func (t *ToolResultCompactor) HandleOpenAIChat(req *openai.ChatCompletionNewParams) error {
+	if len(req.Messages) == 0 {
+		return nil
+	}
+
+	rounds := t.rounder.GroupOpenAI(req.Messages)
+	calls := map[string]toolCall{}
+	var refs []*toolResultRef
+	for i, rnd := range rounds {
+		for _, msg := range rnd.Messages {
+			if msg.OfAssistant != nil {
+				for _, call := range msg.OfAssistant.ToolCalls {
+					if call.OfFunction != nil {
+						fn := call.OfFunction.Function
+						calls[call.OfFunction.ID] = newToolCall(fn.Name, json.RawMessage(fn.Arguments))
+					}
+				}
+			}
+			if msg.OfTool != nil {
+				if ref := newOpenAIToolResultRef(i, msg.OfTool, calls); ref != nil {
+					refs = append(refs, ref)
+				}
+			}
+		}
+	}
+
+	t.compact("openai", refs, len(rounds))
+	return nil
+}
+
+// HandleOpenAIResponses compacts the function call outputs of an OpenAI Responses API request.
+func (t *ToolResultCompactor) HandleOpenAIResponses(req *responses.ResponseNewParams) error {
+	if param.IsOmitted(req.Input.OfInputItemList) || len(req.Input.OfInputItemList) == 0 {
+		return nil
+	}
+
+	rounds := t.rounder.GroupResponses(req.Input.OfInputItemList)
+	calls := map[string]toolCall{}
+	var refs []*toolResultRef
+	for i, rnd := range rounds {
+		for _, item := range rnd.Items {
+			if call := item.OfFunctionCall; !param.IsOmitted(call) {
+				calls[call.CallID] = newToolCall(call.Name, json.RawMessage(call.Arguments))
+			}
+			if output := item.OfFunctionCallOutput; !param.IsOmitted(output) && !param.IsOmitted(output.Output.OfString) {
+				refs = append(refs, &toolResultRef{
+					round: i,
+					call:  calls[output.CallID],
+					text:  output.Output.OfString.Value,
+					set: func(text string) {
+						output.Output.OfString = param.NewOpt(text)
+					},
+				})
+			}
+		}
+	}
+
+	t.compact("responses", refs, len(rounds))
+	return nil
+}
+
+// compact applies the superseded and truncation rules to the tool results and records the savings.
+func (t *ToolResultCompactor) compact(api string, refs []*toolResultRef, totalRounds int) {
+	t.Stats = CompactStats{}
+
+	// Superseded: walk backwards so the latest result of each call is the one kept
+	seen := map[string]bool{}
+	for i := len(refs) - 1; i >= 0; i-- {
+		ref := refs[i]
+		if ref.call.key == "" {
+			continue
+		}
+		if !seen[ref.call.key] {
+			seen[ref.call.key] = true
+			continue
+		}
+		if ref.cached {
+			continue
+		}
+		marker := fmt.Sprintf(toolResultSupersededMarker, ref.call.name)
+		if len(ref.text) > len(marker) {
+			t.rewrite(ref, marker)
+			t.Stats.Superseded++
+		}
+	}
+
+	// Truncated: large results outside the preservation window
+	preserveStart := totalRounds - t.KeepLastNRounds
+	for _, ref := range refs {
+		if ref.cached || ref.round >= preserveStart || len(ref.text) <= t.MaxResultBytes || strings.HasPrefix(ref.text, toolResultMarkerPrefix) {
+			continue
+		}
+		if preview, ok := t.preview(ref.text); ok {
+			t.rewrite(ref, preview)
+			t.Stats.Truncated++
+		}
+	}
+
+	t.Stats.SavedTokens = t.Stats.SavedBytes / estimatedBytesPerToken
+	if t.Stats.Superseded > 0 || t.Stats.Truncated > 0 {
+		log.Printf("[smart_compact] %s: tool results superseded=%d, truncated=%d, saved %d bytes (~%d tokens)",
+			api, t.Stats.Superseded, t.Stats.Truncated, t.Stats.SavedBytes, t.Stats.SavedTokens)
+	}
+}
+
+func (t *ToolResultCompactor) rewrite(ref *toolResultRef, text string) {
+	t.Stats.SavedBytes += len(ref.text) - len(text)
+	ref.text = text
+	ref.set(text)
+}
+
+// preview returns the head and tail of a tool result with a truncation marker in between,
+// cut on UTF-8 boundaries. ok is false when the preview would not be shorter.
+func (t *ToolResultCompactor) preview(text string) (string, bool) {
+	if 2*t.PreviewBytes >= len(text) {
+		return "", false
+	}
+	head := t.PreviewBytes
+	for head > 0 && !utf8.RuneStart(text[head]) {
+		head--
+	}
+	tail := len(text) - t.PreviewBytes
+	for tail < len(text) && !utf8.RuneStart(text[tail]) {
+		tail++
+	}
+	if head >= tail {
+		return "", false
+	}
+
+	preview := text[:head] + fmt.Sprintf(toolResultTruncatedMarker, tail-head) + text[tail:]
+	return preview, len(preview) < len(text)
+}
+
+// cacheBreakpoint returns the index of the last message carrying a cache_control
+// breakpoint, or -1 when there is none
+func cacheBreakpoint[T any](messages []T) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		data, err := json.Marshal(messages[i])
+		if err == nil && bytes.Contains(data, cacheControlKey) {
+			return i
+		}
+	}
+	return -1
+}
+
+// newToolCall identifies a tool call by its name and its input, encoded canonically
+// so that the same input always yields the same key
+func newToolCall(name string, input any) toolCall {
+	call := toolCall{name: name}
+	if raw, ok := input.(json.RawMessage); ok {
+		var decoded any
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			call.key = name + ":" + string(raw)
+			return call
+		}
+		input = decoded
+	}
+	if data, err := json.Marshal(input); err == nil {
+		call.key = name + ":" + string(data)
+	}
+	return call
+}
+
+// newV1ToolResultRef returns a reference to a v1 tool result with text-only content, or nil
+func newV1ToolResultRef(round int, result *anthropic.ToolResultBlockParam, calls map[string]toolCall) *toolResultRef {
+	if len(result.Content) == 0 {
+		return nil
+	}
+	texts := make([]string, 0, len(result.Content))
+	for _, block := range result.Content {
+		if block.OfText == nil {
+			return nil
+		}
+		texts = append(texts, block.OfText.Text)
+	}
+
+	return &toolResultRef{
+		round: round,
+		call:  calls[result.ToolUseID],
+		text:  strings.Join(texts, "\n"),
+		set: func(text string) {
+			// Keep the last text block, which carries the cache control if any
+			last := *result.Content[len(result.Content)-1].OfText
+			last.Text = text
+			result.Content = []anthropic.ToolResultBlockParamContentUnion{{OfText: &last}}
+		},
+	}
+}
+
+// newBetaToolResultRef returns a reference to a v1beta tool result with text-only content, or nil
+func newBetaToolResultRef(round int, result *anthropic.BetaToolResultBlockParam, calls map[string]toolCall) *toolResultRef {
+	if len(result.Content) == 0 {
+		return nil
+	}
+	texts := make([]string, 0, len(result.Content))
+	for _, block := range result.Content {
+		if block.OfText == nil {
+			return nil
+		}
+		texts = append(texts, block.OfText.Text)
+	}
+
+	return &toolResultRef{
+		round: round,
+		call:  calls[result.ToolUseID],
+		text:  strings.Join(texts, "\n"),
+		set: func(text string) {
+			// Keep the last text block, which carries the cache control if any
+			last := *result.Content[len(result.Content)-1].OfText
+			last.Text = text
+			result.Content = []anthropic.BetaToolResultBlockParamContentUnion{{OfText: &last}}
+		},
+	}
+}
+
+// newOpenAIToolResultRef returns a reference to an OpenAI tool message, or nil when it is empty
+func newOpenAIToolResultRef(round int, msg *openai.ChatCompletionToolMessageParam, calls map[string]toolCall) *toolResultRef {
+	var text string
+	if !param.IsOmitted(msg.Content.OfString) {
+		text = msg.Content.OfString.Value
+	} else {
+		texts := make([]string, 0, len(msg.Content.OfArrayOfContentParts))
+		for _, part := range msg.Content.OfArrayOfContentParts {
+			texts = append(texts, part.Text)
+		}
+		text = strings.Join(texts, "\n")
+	}
+	if text == "" {
+		return nil
+	}
+
+	return &toolResultRef{
+		round: round,
+		call:  calls[msg.ToolCallID],
+		text:  text,
+		set: func(text string) {
+			msg.Content = openai.ChatCompletionToolMessageParamContentUnion{OfString: param.NewOpt(text)}
+		},
+	}
+}
@@ -0,0 +1,156 @@
+package smart_compact
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func v1ToolResultText(msg anthropic.MessageParam) string {
+	return msg.Content[0].OfToolResult.Content[0].OfText.Text
+}
+
+// TestToolResultCompactor_SupersedesRepeatedCalls verifies that an earlier Read of a file is
+// collapsed once the same file is read again, and that the latest result is kept
+func TestToolResultCompactor_SupersedesRepeatedCalls(t *testing.T) {
+	oldContent := strings.Repeat("package main // old\n", 50)
+	newContent := strings.Repeat("package main // new\n", 50)
+	req := &anthropic.MessageNewParams{
+		Model:     anthropic.Model("claude-sonnet-4-5"),
+		MaxTokens: 1024,
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock("Fix main.go")),
+			anthropic.NewAssistantMessage(anthropic.NewToolUseBlock("tool-1", map[string]any{"file_path": "main.go"}, "Read")),
+			anthropic.NewUserMessage(anthropic.NewToolResultBlock("tool-1", oldContent, false)),
+			anthropic.NewAssistantMessage(anthropic.NewToolUseBlock("tool-2", map[string]any{"file_path": "main.go"}, "Read")),
+			anthropic.NewUserMessage(anthropic.NewToolResultBlock("tool-2", newContent, false)),
+			anthropic.NewAssistantMessage(anthropic.NewTextBlock("Done")),
+		},
+	}
+
+	tc := NewToolResultCompactor(DefaultToolResultKeepRounds)
+	require.NoError(t, tc.HandleV1(req))
+
+	require.Len(t, req.Messages, 6)
+	assert.Contains(t, v1ToolResultText(req.Messages[2]), "superseded by a later identical Read call")
+	assert.Equal(t, "tool-1", req.Messages[2].Content[0].OfToolResult.ToolUseID, "the result stays paired with its call")
+	assert.Equal(t, newContent, v1ToolResultText(req.Messages[4]))
+
+	assert.Equal(t, 1, tc.Stats.Superseded)
+	assert.Greater(t, tc.Stats.SavedBytes, 0)
+	assert.Equal(t, tc.Stats.SavedBytes/estimatedBytesPerToken, tc.Stats.SavedTokens)
+}
+
+// TestToolResultCompactor_TruncatesOldResults verifies that large results are truncated to a
+// head/tail preview outside the preservation window only
+func TestToolResultCompactor_TruncatesOldResults(t *testing.T) {
+	bigOutput := "HEAD" + strings.Repeat("x", 20*1024) + "TAIL"
+	req := &anthropic.MessageNewParams{
+		Model:     anthropic.Model("claude-sonnet-4-5"),
+		MaxTokens: 1024,
+		Messages: []anthropic.MessageParam{
+			// Round 1 - old, should be truncated
+			anthropic.NewUserMessage(anthropic.NewTextBlock("Run the tests")),
+			anthropic.NewAssistantMessage(anthropic.NewToolUseBlock("tool-1", map[string]any{"command": "go test ./..."}, "Bash")),
+			anthropic.NewUserMessage(anthropic.NewToolResultBlock("tool-1", bigOutput, false)),
+			// Round 2 - current, should be kept
+			anthropic.NewUserMessage(anthropic.NewTextBlock("Run the build")),
+			anthropic.NewAssistantMessage(anthropic.NewToolUseBlock("tool-2", map[string]any{"command": "go build ./..."}, "Bash")),
+			anthropic.NewUserMessage(anthropic.NewToolResultBlock("tool-2", bigOutput, false)),
+		},
+	}
+
+	tc := NewToolResultCompactor(1)
+	require.NoError(t, tc.HandleV1(req))
+
+	truncated := v1ToolResultText(req.Messages[2])
+	assert.True(t, strings.HasPrefix(truncated, "HEAD"))
+	assert.True(t, strings.HasSuffix(truncated, "TAIL"))
+	assert.Contains(t, truncated, "bytes of tool output truncated")
+	assert.Less(t, len(truncated), 3*DefaultToolResultPreviewBytes)
+	assert.Equal(t, bigOutput, v1ToolResultText(req.Messages[5]))
+	assert.Equal(t, 1, tc.Stats.Truncated)
+	assert.Equal(t, len(bigOutput)-len(truncated), tc.Stats.SavedBytes)
+}
+
+// TestToolResultCompactor_KeepsCachedPrefix verifies that results up to the last cache_control
+// breakpoint are left as is, so that the prompt cache of the prefix stays valid
+func TestToolResultCompactor_KeepsCachedPrefix(t *testing.T) {
+	bigOutput := "HEAD" + strings.Repeat("x", 20*1024) + "TAIL"
+	cachedResult := anthropic.NewToolResultBlock("tool-1", bigOutput, false)
+	cachedResult.OfToolResult.CacheControl = anthropic.NewCacheControlEphemeralParam()
+	req := &anthropic.MessageNewParams{
+		Model:     anthropic.Model("claude-sonnet-4-5"),
+		MaxTokens: 1024,
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock("Run the tests")),
+			anthropic.NewAssistantMessage(anthropic.NewToolUseBlock("tool-1", map[string]any{"command": "go test ./..."}, "Bash")),
+			anthropic.NewUserMessage(cachedResult),
+			anthropic.NewUserMessage(anthropic.NewTextBlock("Run the build")),
+			anthropic.NewAssistantMessage(anthropic.NewToolUseBlock("tool-2", map[string]any{"command": "go build ./..."}, "Bash")),
+			anthropic.NewUserMessage(anthropic.NewToolResultBlock("tool-2", bigOutput, false)),
+			anthropic.NewUserMessage(anthropic.NewTextBlock("Again")),
+			anthropic.NewAssistantMessage(anthropic.NewToolUseBlock("tool-3", map[string]any{"command": "go build ./..."}, "Bash")),
+			anthropic.NewUserMessage(anthropic.NewToolResultBlock("tool-3", bigOutput, false)),
+		},
+	}
+
+	tc := NewToolResultCompactor(1)
+	require.NoError(t, tc.HandleV1(req))
+
+	assert.Equal(t, bigOutput, v1ToolResultText(req.Messages[2]), "the cached prefix is not rewritten")
+	assert.Contains(t, v1ToolResultText(req.Messages[5]), "superseded by a later identical Bash call")
+	assert.Equal(t, bigOutput, v1ToolResultText(req.Messages[8]))
+	assert.Equal(t, 1, tc.Stats.Superseded)
+	assert.Equal(t, 0, tc.Stats.Truncated)
+}
+
+// TestToolResultCompactor_OpenAIChat verifies that repeated OpenAI tool calls are superseded
+// regardless of the argument key order
+func TestToolResultCompactor_OpenAIChat(t *testing.T) {
+	output := strings.Repeat("line\n", 100)
+	toolCall := func(id, args string) openai.ChatCompletionMessageParamUnion {
+		msg := openai.AssistantMessage("")
+		msg.OfAssistant.ToolCalls = []openai.ChatCompletionMessageToolCallUnionParam{{
+			OfFunction: &openai.ChatCompletionMessageFunctionToolCallParam{
+				ID:       id,
+				Function: openai.ChatCompletionMessageFunctionToolCallFunctionParam{Name: "read_file", Arguments: args},
+			},
+		}}
+		return msg
+	}
+	req := &openai.ChatCompletionNewParams{
+		Model: "gpt-4o",
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage("Read the file twice"),
+			toolCall("call-1", `{"path":"a.go","limit":10}`),
+			openai.ToolMessage(output, "call-1"),
+			toolCall("call-2", `{"limit":10,"path":"a.go"}`),
+			openai.ToolMessage(output, "call-2"),
+		},
+	}
+
+	tc := NewToolResultCompactor(DefaultToolResultKeepRounds)
+	require.NoError(t, tc.HandleOpenAIChat(req))
+
+	assert.Contains(t, req.Messages[2].OfTool.Content.OfString.Value, "superseded by a later identical read_file call")
+	assert.Equal(t, output, req.Messages[4].OfTool.Content.OfString.Value)
+	assert.Equal(t, 1, tc.Stats.Superseded)
+}
+
+func TestToolResultCompactor_PreviewKeepsUTF8(t *testing.T) {
+	tc := NewToolResultCompactor(1)
+	tc.PreviewBytes = 5
+
+	preview, ok := tc.preview(strings.Repeat("é", 100))
+	require.True(t, ok)
+	assert.True(t, utf8.ValidString(preview))
+
+	_, ok = tc.preview("short")
+	assert.False(t, ok, "results shorter than both previews are kept")
+}
@@ -1,7 +1,9 @@
 package smartrouting
 
 import (
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/openai/openai-go/v3"
@@ -17,6 +19,31 @@ type RequestContext struct {
 	LatestRole        string // Latest message role (user, assistant, tool, function, etc.)
 	LatestContentType string
 	EstimatedTokens   int
+	ToolCount         int               // Number of tools declared in the request
+	ImageCount        int               // Number of images in user messages
+	APIKeyID          string            // ID of the named API key the request was authenticated with
+	APIKeyName        string            // Name of the named API key the request was authenticated with
+	Client            string            // Client name, from the User-Agent header
+	Headers           map[string]string // Request headers by lowercase name, multiple values joined with ", "
+	Time              time.Time         // Time the request was received
+}
+
+// SetRequestInfo sets the request data that is not part of the request body
+func (rc *RequestContext) SetRequestInfo(header http.Header, apiKeyID, apiKeyName string, now time.Time) {
+	rc.Headers = make(map[string]string, len(header))
+	for name, values := range header {
+		rc.Headers[strings.ToLower(name)] = strings.Join(values, ", ")
+	}
+	rc.Client = header.Get("User-Agent")
+	rc.APIKeyID = apiKeyID
+	rc.APIKeyName = apiKeyName
+	rc.Time = now
+}
+
+// GetHeader returns the value of a request header, matching the name case-insensitively
+func (rc *RequestContext) GetHeader(name string) (string, bool) {
+	value, ok := rc.Headers[strings.ToLower(name)]
+	return value, ok
 }
 
 // GetLatestUserMessage returns the latest user message
@@ -35,7 +62,8 @@ func (rc *RequestContext) CombineMessages(messages []string) string {
 // ExtractContextFromOpenAIRequest extracts RequestContext from an OpenAI chat completion request
 func ExtractContextFromOpenAIRequest(req *openai.ChatCompletionNewParams) *RequestContext {
 	ctx := &RequestContext{
-		Model: string(req.Model),
+		Model:     string(req.Model),
+		ToolCount: len(req.Tools),
 	}
 
 	if req.Messages != nil {
@@ -46,10 +74,11 @@ func ExtractContextFromOpenAIRequest(req *openai.ChatCompletionNewParams) *Reque
 				ctx.SystemMessages = append(ctx.SystemMessages, contentStr)
 				ctx.LatestRole = "system"
 			case msgUnion.OfUser != nil:
-				contentStr, hasImage := extractOpenAIUserContent(msgUnion.OfUser.Content)
+				contentStr, images := extractOpenAIUserContent(msgUnion.OfUser.Content)
 				ctx.UserMessages = append(ctx.UserMessages, contentStr)
 				ctx.LatestRole = "user"
-				if hasImage {
+				ctx.ImageCount += images
+				if images > 0 {
 					ctx.LatestContentType = "image"
 				}
 			case msgUnion.OfAssistant != nil:
@@ -90,14 +119,14 @@ func extractOpenAISystemContent(content openai.ChatCompletionSystemMessageParamC
 	return strings.Join(parts, "\n")
 }
 
-// extractOpenAIUserContent extracts string content and checks for image from user message content
-func extractOpenAIUserContent(content openai.ChatCompletionUserMessageParamContentUnion) (string, bool) {
+// extractOpenAIUserContent extracts string content and counts images in user message content
+func extractOpenAIUserContent(content openai.ChatCompletionUserMessageParamContentUnion) (string, int) {
 	var parts []string
-	hasImage := false
+	images := 0
 
 	// Check if it's a string
 	if content.OfString.Valid() {
-		return content.OfString.Value, false
+		return content.OfString.Value, 0
 	}
 
 	// Check if it's an array of content parts
@@ -106,12 +135,12 @@ func extractOpenAIUserContent(content openai.ChatCompletionUserMessageParamConte
 		case partUnion.OfText != nil:
 			parts = append(parts, partUnion.OfText.Text)
 		case partUnion.OfImageURL != nil:
-			hasImage = true
+			images++
 			parts = append(parts, "[image]")
 		}
 	}
 
-	return strings.Join(parts, "\n"), hasImage
+	return strings.Join(parts, "\n"), images
 }
 
 // ExtractContextFromAnthropicRequest extracts RequestContext from an Anthropic messages request
@@ -119,6 +148,7 @@ func ExtractContextFromAnthropicRequest(req *anthropic.MessageNewParams) *Reques
 	ctx := &RequestContext{
 		Model:           string(req.Model),
 		ThinkingEnabled: req.Thinking.OfEnabled != nil,
+		ToolCount:       len(req.Tools),
 	}
 
 	if req.System != nil {
@@ -139,12 +169,13 @@ func ExtractContextFromAnthropicRequest(req *anthropic.MessageNewParams) *Reques
 			}
 
 			contentStr, toolUses := extractAnthropicContent(msg.Content)
-			hasImage := hasImageInAnthropicContent(msg.Content)
+			images := countImagesInAnthropicContent(msg.Content)
 
 			if contentStr != "" {
 				ctx.UserMessages = append(ctx.UserMessages, contentStr)
 			}
-			if hasImage {
+			ctx.ImageCount += images
+			if images > 0 {
 				ctx.LatestContentType = "image"
 			}
 
@@ -178,14 +209,15 @@ func extractAnthropicContent(content []anthropic.ContentBlockParamUnion) (string
 	return strings.Join(parts, "\n"), tools
 }
 
-// hasImageInAnthropicContent checks if content contains image
-func hasImageInAnthropicContent(content []anthropic.ContentBlockParamUnion) bool {
+// countImagesInAnthropicContent counts the images in content
+func countImagesInAnthropicContent(content []anthropic.ContentBlockParamUnion) int {
+	images := 0
 	for _, blockUnion := range content {
 		if blockUnion.OfImage != nil {
-			return true
+			images++
 		}
 	}
-	return false
+	return images
 }
 
 // ExtractContextFromBetaRequest extracts RequestContext from an Anthropic beta messages request
@@ -193,6 +225,7 @@ func ExtractContextFromBetaRequest(req *anthropic.BetaMessageNewParams) *Request
 	ctx := &RequestContext{
 		Model:           string(req.Model),
 		ThinkingEnabled: req.Thinking.OfEnabled != nil,
+		ToolCount:       len(req.Tools),
 	}
 
 	// Extract system messages
@@ -215,12 +248,13 @@ func ExtractContextFromBetaRequest(req *anthropic.BetaMessageNewParams) *Request
 			}
 
 			contentStr, toolUses := extractBetaContent(msg.Content)
-			hasImage := hasImageInBetaContent(msg.Content)
+			images := countImagesInBetaContent(msg.Content)
 
 			if contentStr != "" {
 				ctx.UserMessages = append(ctx.UserMessages, contentStr)
 			}
-			if hasImage {
+			ctx.ImageCount += images
+			if images > 0 {
 				ctx.LatestContentType = "image"
 			}
 
@@ -254,12 +288,13 @@ func extractBetaContent(content []anthropic.BetaContentBlockParamUnion) (string,
 	return strings.Join(parts, "\n"), tools
 }
 
-// hasImageInBetaContent checks if content contains image
-func hasImageInBetaContent(content []anthropic.BetaContentBlockParamUnion) bool {
+// countImagesInBetaContent counts the images in content
+func countImagesInBetaContent(content []anthropic.BetaContentBlockParamUnion) int {
+	images := 0
 	for _, blockUnion := range content {
 		if blockUnion.OfImage != nil {
-			return true
+			images++
 		}
 	}
-	return false
+	return images
 }
@@ -0,0 +1,96 @@
+package smartrouting
+
+import "github.com/tingly-dev/tingly-box/internal/loadbalance"
+
+// Group kinds reported in an OpExplanation
+const (
+	GroupAny = "any"
+	GroupAll = "all"
+	GroupNot = "not"
+)
+
+// OpExplanation is the result of one operation of a smart routing block
+type OpExplanation struct {
+	Position  SmartOpPosition  `json:"position,omitempty"`
+	Operation SmartOpOperation `json:"operation,omitempty"`
+	Value     string           `json:"value,omitempty"`
+	Group     string           `json:"group,omitempty"` // any, all or not when the operation is a group
+	Matched   bool             `json:"matched"`
+	Ops       []OpExplanation  `json:"ops,omitempty"` // Results of the nested operations of a group
+}
+
+// RuleExplanation is the result of one smart routing block
+type RuleExplanation struct {
+	Index       int                    `json:"index"`
+	Description string                 `json:"description"`
+	Matched     bool                   `json:"matched"`
+	Selected    bool                   `json:"selected"` // First matching block, the one routing uses
+	Ops         []OpExplanation        `json:"ops"`
+	Services    []*loadbalance.Service `json:"services"`
+}
+
+// Explain evaluates every smart routing block against the request and reports the
+// result of each operation. Unlike EvaluateRequest it does not stop at the first
+// match; the block EvaluateRequest would use is marked as selected.
+func (r *Router) Explain(ctx *RequestContext) []RuleExplanation {
+	explanations := make([]RuleExplanation, 0, len(r.rules))
+	selected := false
+	for i := range r.rules {
+		rule := &r.rules[i]
+		ops := r.explainOps(ctx, rule.Ops)
+		matched := true
+		for _, op := range ops {
+			matched = matched && op.Matched
+		}
+		explanation := RuleExplanation{
+			Index:       i,
+			Description: rule.Description,
+			Matched:     matched,
+			Selected:    matched && !selected,
+			Ops:         ops,
+			Services:    rule.Services,
+		}
+		selected = selected || matched
+		explanations = append(explanations, explanation)
+	}
+	return explanations
+}
+
+func (r *Router) explainOps(ctx *RequestContext, ops []SmartOp) []OpExplanation {
+	explanations := make([]OpExplanation, 0, len(ops))
+	for i := range ops {
+		explanations = append(explanations, r.explainOp(ctx, &ops[i]))
+	}
+	return explanations
+}
+
+// explainOp evaluates an operation the way evaluateOp does, recording the result of
+// every nested operation instead of short-circuiting
+func (r *Router) explainOp(ctx *RequestContext, op *SmartOp) OpExplanation {
+	switch {
+	case op.Any != nil:
+		nested := r.explainOps(ctx, op.Any)
+		matched := false
+		for _, n := range nested {
+			matched = matched || n.Matched
+		}
+		return OpExplanation{Group: GroupAny, Matched: matched, Ops: nested}
+	case op.All != nil:
+		nested := r.explainOps(ctx, op.All)
+		matched := true
+		for _, n := range nested {
+			matched = matched && n.Matched
+		}
+		return OpExplanation{Group: GroupAll, Matched: matched, Ops: nested}
+	case op.Not != nil:
+		nested := r.explainOp(ctx, op.Not)
+		return OpExplanation{Group: GroupNot, Matched: !nested.Matched, Ops: []OpExplanation{nested}}
+	}
+
+	return OpExplanation{
+		Position:  op.Position,
+		Operation: op.Operation,
+		Value:     op.Value,
+		Matched:   r.evaluateOp(ctx, op),
+	}
+}
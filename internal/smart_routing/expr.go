@@ -0,0 +1,21 @@
+package smartrouting
+
+import (
+	"fmt"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// compileExpr compiles an expr position value. The expression is evaluated against
+// RequestContext and must return a boolean, e.g. `ThinkingEnabled || EstimatedTokens > 50000`.
+func compileExpr(expression string) (*vm.Program, error) {
+	if expression == "" {
+		return nil, fmt.Errorf("expression cannot be empty")
+	}
+	program, err := expr.Compile(expression, expr.Env(&RequestContext{}), expr.AsBool())
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %w", err)
+	}
+	return program, nil
+}
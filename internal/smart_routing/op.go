@@ -154,6 +154,164 @@ var Operations = []SmartOp{
 			Type:        ValueTypeInt,
 		},
 	},
+
+	// API key operations
+	{
+		Position:  PositionAPIKey,
+		Operation: OpAPIKeyEquals,
+		Meta: SmartOpMeta{
+			Description: "Request was authenticated with the named API key (name or ID)",
+			Type:        ValueTypeString,
+		},
+	},
+	{
+		Position:  PositionAPIKey,
+		Operation: OpAPIKeyGlob,
+		Meta: SmartOpMeta{
+			Description: "Name of the API key the request was authenticated with matches glob pattern",
+			Type:        ValueTypeString,
+		},
+	},
+
+	// Client operations
+	{
+		Position:  PositionClient,
+		Operation: OpClientContains,
+		Meta: SmartOpMeta{
+			Description: "Client name (User-Agent) contains the value",
+			Type:        ValueTypeString,
+		},
+	},
+	{
+		Position:  PositionClient,
+		Operation: OpClientGlob,
+		Meta: SmartOpMeta{
+			Description: "Client name (User-Agent) matches glob pattern",
+			Type:        ValueTypeString,
+		},
+	},
+
+	// Header operations
+	{
+		Position:  PositionHeader,
+		Operation: OpHeaderEquals,
+		Meta: SmartOpMeta{
+			Description: "Request header equals the value, written as `name: value`",
+			Type:        ValueTypeString,
+		},
+	},
+	{
+		Position:  PositionHeader,
+		Operation: OpHeaderContains,
+		Meta: SmartOpMeta{
+			Description: "Request header contains the value, written as `name: value`",
+			Type:        ValueTypeString,
+		},
+	},
+	{
+		Position:  PositionHeader,
+		Operation: OpHeaderExists,
+		Meta: SmartOpMeta{
+			Description: "Request header named by the value is present",
+			Type:        ValueTypeString,
+		},
+	},
+
+	// Tool count operations
+	{
+		Position:  PositionToolCount,
+		Operation: OpToolCountGe,
+		Meta: SmartOpMeta{
+			Description: "Declared tool count greater than or equal to value",
+			Type:        ValueTypeInt,
+		},
+	},
+	{
+		Position:  PositionToolCount,
+		Operation: OpToolCountGt,
+		Meta: SmartOpMeta{
+			Description: "Declared tool count greater than value",
+			Type:        ValueTypeInt,
+		},
+	},
+	{
+		Position:  PositionToolCount,
+		Operation: OpToolCountLe,
+		Meta: SmartOpMeta{
+			Description: "Declared tool count less than or equal to value",
+			Type:        ValueTypeInt,
+		},
+	},
+	{
+		Position:  PositionToolCount,
+		Operation: OpToolCountLt,
+		Meta: SmartOpMeta{
+			Description: "Declared tool count less than value",
+			Type:        ValueTypeInt,
+		},
+	},
+
+	// Image count operations
+	{
+		Position:  PositionImageCount,
+		Operation: OpImageCountGe,
+		Meta: SmartOpMeta{
+			Description: "Image count greater than or equal to value",
+			Type:        ValueTypeInt,
+		},
+	},
+	{
+		Position:  PositionImageCount,
+		Operation: OpImageCountGt,
+		Meta: SmartOpMeta{
+			Description: "Image count greater than value",
+			Type:        ValueTypeInt,
+		},
+	},
+	{
+		Position:  PositionImageCount,
+		Operation: OpImageCountLe,
+		Meta: SmartOpMeta{
+			Description: "Image count less than or equal to value",
+			Type:        ValueTypeInt,
+		},
+	},
+	{
+		Position:  PositionImageCount,
+		Operation: OpImageCountLt,
+		Meta: SmartOpMeta{
+			Description: "Image count less than value",
+			Type:        ValueTypeInt,
+		},
+	},
+
+	// Time operations
+	{
+		Position:  PositionTime,
+		Operation: OpTimeBetween,
+		Meta: SmartOpMeta{
+			Description: "Time of day in the server's local time zone is within the window, e.g. `09:00-18:00` (may wrap past midnight)",
+			Type:        ValueTypeString,
+		},
+	},
+	{
+		Position:  PositionTime,
+		Operation: OpTimeWeekday,
+		Meta: SmartOpMeta{
+			Description: "Weekday in the server's local time zone is one of the values, e.g. `mon-fri` or `sat,sun`",
+			Type:        ValueTypeString,
+		},
+	},
+
+	// Expression operations
+	{
+		Position:  PositionExpr,
+		Operation: OpExprMatch,
+		Meta: SmartOpMeta{
+			Description: "Expression evaluated against the request is true, e.g. `ThinkingEnabled || EstimatedTokens > 50000`",
+			Type:        ValueTypeString,
+		},
+	},
 }
 
 const (
@@ -164,6 +322,13 @@ const (
 	PositionLatestUser    SmartOpPosition = "latest_user"    // Latest user message
 	PositionToolUse       SmartOpPosition = "tool_use"       // Tool use/name
 	PositionToken         SmartOpPosition = "token"          // Token count
+	PositionAPIKey        SmartOpPosition = "api_key"        // Named API key the request was authenticated with
+	PositionClient        SmartOpPosition = "client"         // Client name (User-Agent)
+	PositionHeader        SmartOpPosition = "header"         // Request header
+	PositionToolCount     SmartOpPosition = "tool_count"     // Number of declared tools
+	PositionImageCount    SmartOpPosition = "image_count"    // Number of images in user messages
+	PositionTime          SmartOpPosition = "time"           // Server-local time of day and weekday
+	PositionExpr          SmartOpPosition = "expr"           // expr-lang expression over the request context
 )
 
 const (
@@ -197,4 +362,36 @@ const (
 	OpTokenGt SmartOpOperation = "gt" // Token count greater than value
 	OpTokenLe SmartOpOperation = "le" // Token count less than or equal to value
 	OpTokenLt SmartOpOperation = "lt" // Token count less than value
+
+	// API key operations
+	OpAPIKeyEquals SmartOpOperation = "equals" // API key name or ID equals the value
+	OpAPIKeyGlob   SmartOpOperation = "glob"   // API key name matches glob pattern
+
+	// Client operations
+	OpClientContains SmartOpOperation = "contains" // Client name contains the value
+	OpClientGlob     SmartOpOperation = "glob"     // Client name matches glob pattern
+
+	// Header operations
+	OpHeaderEquals   SmartOpOperation = "equals"   // Header equals the value (`name: value`)
+	OpHeaderContains SmartOpOperation = "contains" // Header contains the value (`name: value`)
+	OpHeaderExists   SmartOpOperation = "exists"   // Header named by the value is present
+
+	// Tool count operations
+	OpToolCountGe SmartOpOperation = "ge" // Declared tool count greater than or equal to value
+	OpToolCountGt SmartOpOperation = "gt" // Declared tool count greater than value
+	OpToolCountLe SmartOpOperation = "le" // Declared tool count less than or equal to value
+	OpToolCountLt SmartOpOperation = "lt" // Declared tool count less than value
+
+	// Image count operations
+	OpImageCountGe SmartOpOperation = "ge" // Image count greater than or equal to value
+	OpImageCountGt SmartOpOperation = "gt" // Image count greater than value
+	OpImageCountLe SmartOpOperation = "le" // Image count less than or equal to value
+	OpImageCountLt SmartOpOperation = "lt" // Image count less than value
+
+	// Time operations
+	OpTimeBetween SmartOpOperation = "between" // Local time of day is within the window
+	OpTimeWeekday SmartOpOperation = "weekday" // Local weekday is one of the values
+
+	// Expression operations
+	OpExprMatch SmartOpOperation = "match" // Expression evaluates to true
 )
@@ -1,7 +1,9 @@
 package smartrouting
 
 import (
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/openai/openai-go/v3"
@@ -618,3 +620,281 @@ func TestValidateOpValueType(t *testing.T) {
 		})
 	}
 }
+
+func TestRouter_EvaluateRequest_Groups(t *testing.T) {
+	router, err := NewRouter([]SmartRouting{
+		{
+			Description: "Route thinking or long requests, except haiku",
+			Ops: []SmartOp{
+				{
+					Any: []SmartOp{
+						{Position: PositionThinking, Operation: OpThinkingEnabled},
+						{Position: PositionToken, Operation: OpTokenGt, Value: "50000"},
+					},
+				},
+				{
+					Not: &SmartOp{Position: PositionModel, Operation: OpModelContains, Value: "haiku"},
+				},
+			},
+			Services: []*loadbalance.Service{
+				{Provider: "big-provider", Model: "claude-opus", Weight: 1, Active: true},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		ctx       *RequestContext
+		wantMatch bool
+	}{
+		{name: "thinking", ctx: &RequestContext{Model: "claude-sonnet", ThinkingEnabled: true}, wantMatch: true},
+		{name: "long request", ctx: &RequestContext{Model: "claude-sonnet", EstimatedTokens: 60000}, wantMatch: true},
+		{name: "short request", ctx: &RequestContext{Model: "claude-sonnet", EstimatedTokens: 100}, wantMatch: false},
+		{name: "negated model", ctx: &RequestContext{Model: "claude-haiku", ThinkingEnabled: true}, wantMatch: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, matched := router.EvaluateRequest(tt.ctx)
+			require.Equal(t, tt.wantMatch, matched)
+		})
+	}
+}
+
+func TestRouter_EvaluateRequest_Expr(t *testing.T) {
+	router, err := NewRouter([]SmartRouting{
+		{
+			Description: "Route by expression",
+			Ops: []SmartOp{
+				{
+					Position:  PositionExpr,
+					Operation: OpExprMatch,
+					Value:     `(ThinkingEnabled || EstimatedTokens > 50000) && !(Model contains "haiku") && len(ToolUses) == 0`,
+				},
+			},
+			Services: []*loadbalance.Service{
+				{Provider: "expr-provider", Model: "claude-opus", Weight: 1, Active: true},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	_, matched := router.EvaluateRequest(&RequestContext{Model: "claude-sonnet", EstimatedTokens: 60000})
+	require.True(t, matched)
+
+	_, matched = router.EvaluateRequest(&RequestContext{Model: "claude-haiku", ThinkingEnabled: true})
+	require.False(t, matched)
+
+	_, matched = router.EvaluateRequest(&RequestContext{Model: "claude-sonnet", ThinkingEnabled: true, ToolUses: []string{"bash"}})
+	require.False(t, matched)
+}
+
+func TestRouter_EvaluateRequest_Globs(t *testing.T) {
+	router, err := NewRouter([]SmartRouting{
+		{
+			Description: "Route by globs",
+			Ops: []SmartOp{
+				{Position: PositionModel, Operation: OpModelGlob, Value: "claude-*"},
+				{Not: &SmartOp{Position: PositionClient, Operation: OpClientGlob, Value: "*curl*"}},
+			},
+			Services: []*loadbalance.Service{
+				{Provider: "glob-provider", Model: "claude-opus", Weight: 1, Active: true},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, router.globs, 2, "glob patterns are compiled once")
+
+	_, matched := router.EvaluateRequest(&RequestContext{Model: "claude-sonnet", Client: "claude-cli/1.0"})
+	require.True(t, matched)
+
+	_, matched = router.EvaluateRequest(&RequestContext{Model: "claude-sonnet", Client: "curl/8.0"})
+	require.False(t, matched)
+
+	_, matched = router.EvaluateRequest(&RequestContext{Model: "gpt-4o", Client: "claude-cli/1.0"})
+	require.False(t, matched)
+}
+
+func TestValidateSmartOp_Groups(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      SmartOp
+		wantErr string
+	}{
+		{
+			name: "valid nested group",
+			op: SmartOp{All: []SmartOp{
+				{Position: PositionModel, Operation: OpModelContains, Value: "gpt"},
+				{Not: &SmartOp{Any: []SmartOp{{Position: PositionThinking, Operation: OpThinkingEnabled}}}},
+			}},
+		},
+		{
+			name:    "empty any",
+			op:      SmartOp{Any: []SmartOp{}},
+			wantErr: "any cannot be empty",
+		},
+		{
+			name: "two group kinds",
+			op: SmartOp{
+				Any: []SmartOp{{Position: PositionModel, Operation: OpModelContains, Value: "gpt"}},
+				Not: &SmartOp{Position: PositionModel, Operation: OpModelContains, Value: "mini"},
+			},
+			wantErr: "exactly one of any, all and not",
+		},
+		{
+			name:    "group with position",
+			op:      SmartOp{Position: PositionModel, Not: &SmartOp{Position: PositionModel, Operation: OpModelContains, Value: "mini"}},
+			wantErr: "cannot have a position",
+		},
+		{
+			name:    "invalid nested op",
+			op:      SmartOp{All: []SmartOp{{Position: PositionToken, Operation: OpModelGlob, Value: "x"}}},
+			wantErr: "all[0]: operation 'glob' is not valid",
+		},
+		{
+			name:    "invalid expression",
+			op:      SmartOp{Position: PositionExpr, Operation: OpExprMatch, Value: "EstimatedTokens >"},
+			wantErr: "invalid expression",
+		},
+		{
+			name:    "non boolean expression",
+			op:      SmartOp{Position: PositionExpr, Operation: OpExprMatch, Value: "EstimatedTokens + 1"},
+			wantErr: "invalid expression",
+		},
+		{
+			name:    "unknown field in expression",
+			op:      SmartOp{Position: PositionExpr, Operation: OpExprMatch, Value: "Unknown > 1"},
+			wantErr: "invalid expression",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSmartOp(&tt.op)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRouter_EvaluateRequest_RequestInfoPositions(t *testing.T) {
+	header := http.Header{}
+	header.Set("User-Agent", "claude-cli/1.0.80 (external, cli)")
+	header.Set("X-App", "cli")
+	header.Set("X-Project", "tingly-box")
+
+	// Wednesday 10:30
+	ctx := &RequestContext{ToolCount: 12, ImageCount: 2}
+	ctx.SetRequestInfo(header, "key-1", "ci-bot", time.Date(2025, 1, 8, 10, 30, 0, 0, time.Local))
+
+	tests := []struct {
+		name      string
+		op        SmartOp
+		wantMatch bool
+	}{
+		{name: "api key name", op: SmartOp{Position: PositionAPIKey, Operation: OpAPIKeyEquals, Value: "ci-bot"}, wantMatch: true},
+		{name: "api key id", op: SmartOp{Position: PositionAPIKey, Operation: OpAPIKeyEquals, Value: "key-1"}, wantMatch: true},
+		{name: "api key glob", op: SmartOp{Position: PositionAPIKey, Operation: OpAPIKeyGlob, Value: "team-*"}, wantMatch: false},
+		{name: "client contains", op: SmartOp{Position: PositionClient, Operation: OpClientContains, Value: "claude-cli"}, wantMatch: true},
+		{name: "header equals", op: SmartOp{Position: PositionHeader, Operation: OpHeaderEquals, Value: "x-app: cli"}, wantMatch: true},
+		{name: "header case insensitive name", op: SmartOp{Position: PositionHeader, Operation: OpHeaderContains, Value: "X-PROJECT: tingly"}, wantMatch: true},
+		{name: "header missing", op: SmartOp{Position: PositionHeader, Operation: OpHeaderExists, Value: "x-team"}, wantMatch: false},
+		{name: "tool count", op: SmartOp{Position: PositionToolCount, Operation: OpToolCountGt, Value: "10"}, wantMatch: true},
+		{name: "image count", op: SmartOp{Position: PositionImageCount, Operation: OpImageCountGe, Value: "3"}, wantMatch: false},
+		{name: "office hours", op: SmartOp{Position: PositionTime, Operation: OpTimeBetween, Value: "09:00-18:00"}, wantMatch: true},
+		{name: "night window wraps", op: SmartOp{Position: PositionTime, Operation: OpTimeBetween, Value: "22:00-06:00"}, wantMatch: false},
+		{name: "weekdays", op: SmartOp{Position: PositionTime, Operation: OpTimeWeekday, Value: "mon-fri"}, wantMatch: true},
+		{name: "weekend", op: SmartOp{Position: PositionTime, Operation: OpTimeWeekday, Value: "sat,sun"}, wantMatch: false},
+		{name: "expr over headers", op: SmartOp{Position: PositionExpr, Operation: OpExprMatch, Value: `Headers["x-app"] == "cli" && ToolCount > 10`}, wantMatch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, ValidateSmartOp(&tt.op))
+			router, err := NewRouter([]SmartRouting{{
+				Description: tt.name,
+				Ops:         []SmartOp{tt.op},
+				Services:    []*loadbalance.Service{{Provider: "p", Model: "m", Weight: 1, Active: true}},
+			}})
+			require.NoError(t, err)
+			_, matched := router.EvaluateRequest(ctx)
+			require.Equal(t, tt.wantMatch, matched)
+		})
+	}
+}
+
+func TestValidateSmartOp_RequestInfoPositions(t *testing.T) {
+	invalid := []SmartOp{
+		{Position: PositionHeader, Operation: OpHeaderEquals, Value: "no-colon"},
+		{Position: PositionHeader, Operation: OpHeaderExists, Value: " "},
+		{Position: PositionTime, Operation: OpTimeBetween, Value: "9-18"},
+		{Position: PositionTime, Operation: OpTimeWeekday, Value: "someday"},
+		{Position: PositionToolCount, Operation: OpToolCountGe, Value: "many", Meta: SmartOpMeta{Type: ValueTypeInt}},
+		{Position: PositionClient, Operation: OpTimeBetween, Value: "09:00-10:00"},
+	}
+	for _, op := range invalid {
+		require.Error(t, ValidateSmartOp(&op), "expected %s/%s %q to be invalid", op.Position, op.Operation, op.Value)
+	}
+}
+
+func TestInTimeWindow_WrapsPastMidnight(t *testing.T) {
+	late := time.Date(2025, 1, 8, 23, 15, 0, 0, time.UTC)
+	early := time.Date(2025, 1, 8, 5, 59, 0, 0, time.UTC)
+	end := time.Date(2025, 1, 8, 6, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		at   time.Time
+		want bool
+	}{{late, true}, {early, true}, {end, false}} {
+		in, err := inTimeWindow(tc.at, "22:00-06:00")
+		require.NoError(t, err)
+		require.Equal(t, tc.want, in, tc.at.Format("15:04"))
+	}
+}
+
+func TestRouter_Explain(t *testing.T) {
+	services := []*loadbalance.Service{{Provider: "p", Model: "m", Weight: 1, Active: true}}
+	router, err := NewRouter([]SmartRouting{
+		{
+			Description: "haiku",
+			Ops:         []SmartOp{{Position: PositionModel, Operation: OpModelContains, Value: "haiku"}},
+			Services:    services,
+		},
+		{
+			Description: "thinking or long",
+			Ops: []SmartOp{{Any: []SmartOp{
+				{Position: PositionThinking, Operation: OpThinkingEnabled},
+				{Position: PositionToken, Operation: OpTokenGt, Value: "100"},
+			}}},
+			Services: services,
+		},
+		{
+			Description: "not gpt",
+			Ops:         []SmartOp{{Not: &SmartOp{Position: PositionModel, Operation: OpModelContains, Value: "gpt"}}},
+			Services:    services,
+		},
+	})
+	require.NoError(t, err)
+
+	explanations := router.Explain(&RequestContext{Model: "claude-sonnet", EstimatedTokens: 500})
+	require.Len(t, explanations, 3)
+
+	require.False(t, explanations[0].Matched)
+	require.False(t, explanations[0].Ops[0].Matched)
+
+	require.True(t, explanations[1].Matched)
+	require.True(t, explanations[1].Selected)
+	group := explanations[1].Ops[0]
+	require.Equal(t, GroupAny, group.Group)
+	require.Len(t, group.Ops, 2, "every nested op is reported")
+	require.False(t, group.Ops[0].Matched)
+	require.True(t, group.Ops[1].Matched)
+
+	require.True(t, explanations[2].Matched)
+	require.False(t, explanations[2].Selected, "only the first matching block is selected")
+	require.Equal(t, GroupNot, explanations[2].Ops[0].Group)
+}
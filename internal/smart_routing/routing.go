@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
 	"github.com/gobwas/glob"
 
 	"github.com/tingly-dev/tingly-box/internal/loadbalance"
@@ -12,23 +15,70 @@ import (
 
 // Router evaluates requests against smart routing rules
 type Router struct {
-	rules []SmartRouting
+	rules    []SmartRouting
+	programs map[string]*vm.Program // compiled expr positions, keyed by expression
+	globs    map[string]glob.Glob   // compiled glob patterns, keyed by pattern
 }
 
-// NewRouter creates a new smart routing router
+// NewRouter creates a new smart routing router. The rules are validated and their
+// expressions and glob patterns compiled once.
 func NewRouter(rules []SmartRouting) (*Router, error) {
+	programs := make(map[string]*vm.Program)
+	globs := make(map[string]glob.Glob)
+
 	// Validate all rules
 	for i, rule := range rules {
-		if err := ValidateSmartRouting(&rule); err != nil {
+		if err := validateSmartRouting(&rule, programs); err != nil {
 			return nil, fmt.Errorf("rule[%d]: %w", i, err)
 		}
+		collectGlobs(rule.Ops, globs)
 	}
 
 	return &Router{
-		rules: rules,
+		rules:    rules,
+		programs: programs,
+		globs:    globs,
 	}, nil
 }
 
+// collectGlobs compiles the glob patterns of the operations into globs. Invalid
+// patterns are left out and reported when evaluated.
+func collectGlobs(ops []SmartOp, globs map[string]glob.Glob) {
+	for i := range ops {
+		op := &ops[i]
+		switch {
+		case op.Any != nil:
+			collectGlobs(op.Any, globs)
+			continue
+		case op.All != nil:
+			collectGlobs(op.All, globs)
+			continue
+		case op.Not != nil:
+			collectGlobs([]SmartOp{*op.Not}, globs)
+			continue
+		}
+
+		// Every glob operation is named "glob", and regex operations are matched as globs
+		if op.Operation != OpModelGlob && op.Operation != OpContextSystemRegex {
+			continue
+		}
+		if _, ok := globs[op.Value]; ok {
+			continue
+		}
+		if g, err := glob.Compile(op.Value); err == nil {
+			globs[op.Value] = g
+		}
+	}
+}
+
+// glob returns the compiled glob pattern, compiling it when it was not cached
+func (r *Router) glob(pattern string) (glob.Glob, error) {
+	if g, ok := r.globs[pattern]; ok {
+		return g, nil
+	}
+	return glob.Compile(pattern)
+}
+
 // EvaluateRequest evaluates a request against smart routing rules
 // Returns the matched services and true if a rule matched, otherwise empty and false
 func (r *Router) EvaluateRequest(ctx *RequestContext) ([]*loadbalance.Service, bool) {
@@ -43,16 +93,40 @@ func (r *Router) EvaluateRequest(ctx *RequestContext) ([]*loadbalance.Service, b
 // evaluateRule evaluates if a context matches a single rule
 func (r *Router) evaluateRule(ctx *RequestContext, rule *SmartRouting) bool {
 	// All operations must match (AND logic)
-	for _, op := range rule.Ops {
-		if !r.evaluateOp(ctx, &op) {
+	return r.evaluateAll(ctx, rule.Ops)
+}
+
+// evaluateAll reports whether every operation matches
+func (r *Router) evaluateAll(ctx *RequestContext, ops []SmartOp) bool {
+	for i := range ops {
+		if !r.evaluateOp(ctx, &ops[i]) {
 			return false
 		}
 	}
 	return true
 }
 
+// evaluateAny reports whether at least one operation matches
+func (r *Router) evaluateAny(ctx *RequestContext, ops []SmartOp) bool {
+	for i := range ops {
+		if r.evaluateOp(ctx, &ops[i]) {
+			return true
+		}
+	}
+	return false
+}
+
 // evaluateOp evaluates if a context matches a single operation
 func (r *Router) evaluateOp(ctx *RequestContext, op *SmartOp) bool {
+	switch {
+	case op.Any != nil:
+		return r.evaluateAny(ctx, op.Any)
+	case op.All != nil:
+		return r.evaluateAll(ctx, op.All)
+	case op.Not != nil:
+		return !r.evaluateOp(ctx, op.Not)
+	}
+
 	switch op.Position {
 	case PositionModel:
 		return r.evaluateModelOp(ctx, op)
@@ -68,6 +142,20 @@ func (r *Router) evaluateOp(ctx *RequestContext, op *SmartOp) bool {
 		return r.evaluateToolUseOp(ctx, op)
 	case PositionToken:
 		return r.evaluateTokenOp(ctx, op)
+	case PositionAPIKey:
+		return r.evaluateAPIKeyOp(ctx, op)
+	case PositionClient:
+		return r.evaluateClientOp(ctx, op)
+	case PositionHeader:
+		return r.evaluateHeaderOp(ctx, op)
+	case PositionToolCount:
+		return evaluateCountOp(ctx.ToolCount, op)
+	case PositionImageCount:
+		return evaluateCountOp(ctx.ImageCount, op)
+	case PositionTime:
+		return r.evaluateTimeOp(ctx, op)
+	case PositionExpr:
+		return r.evaluateExprOp(ctx, op)
 	default:
 		return false
 	}
@@ -75,6 +163,12 @@ func (r *Router) evaluateOp(ctx *RequestContext, op *SmartOp) bool {
 
 // ValidateSmartRouting checks if the smart routing rule is valid
 func ValidateSmartRouting(rule *SmartRouting) error {
+	return validateSmartRouting(rule, make(map[string]*vm.Program))
+}
+
+// validateSmartRouting checks a smart routing rule, adding the programs of its
+// expressions to programs
+func validateSmartRouting(rule *SmartRouting, programs map[string]*vm.Program) error {
 	if rule.Description == "" {
 		return fmt.Errorf("description cannot be empty")
 	}
@@ -84,7 +178,7 @@ func ValidateSmartRouting(rule *SmartRouting) error {
 	}
 
 	for i, op := range rule.Ops {
-		if err := ValidateSmartOp(&op); err != nil {
+		if err := validateSmartOp(&op, programs); err != nil {
 			return fmt.Errorf("op[%d]: %w", i, err)
 		}
 	}
@@ -107,6 +201,15 @@ func ValidateSmartRouting(rule *SmartRouting) error {
 
 // ValidateSmartOp checks if the operation is valid for its position
 func ValidateSmartOp(op *SmartOp) error {
+	return validateSmartOp(op, make(map[string]*vm.Program))
+}
+
+// validateSmartOp checks an operation, adding the program of an expression to programs
+func validateSmartOp(op *SmartOp, programs map[string]*vm.Program) error {
+	if op.IsGroup() {
+		return validateSmartOpGroup(op, programs)
+	}
+
 	if !op.Position.IsValid() {
 		return fmt.Errorf("invalid position: %s", op.Position)
 	}
@@ -125,6 +228,84 @@ func ValidateSmartOp(op *SmartOp) error {
 		return err
 	}
 
+	return validateOpValueFormat(op, programs)
+}
+
+// validateOpValueFormat checks the value of positions that expect a specific format.
+// Expressions are compiled once and their programs added to programs.
+func validateOpValueFormat(op *SmartOp, programs map[string]*vm.Program) error {
+	switch op.Position {
+	case PositionHeader:
+		if op.Operation == OpHeaderExists {
+			if strings.TrimSpace(op.Value) == "" {
+				return fmt.Errorf("header name cannot be empty")
+			}
+			return nil
+		}
+		if _, _, err := parseHeaderValue(op.Value); err != nil {
+			return err
+		}
+	case PositionTime:
+		var err error
+		if op.Operation == OpTimeBetween {
+			_, _, err = parseTimeWindow(op.Value)
+		} else {
+			_, err = parseWeekdays(op.Value)
+		}
+		return err
+	case PositionExpr:
+		if _, ok := programs[op.Value]; ok {
+			return nil
+		}
+		program, err := compileExpr(op.Value)
+		if err != nil {
+			return err
+		}
+		programs[op.Value] = program
+	}
+	return nil
+}
+
+// validateSmartOpGroup checks that a group sets exactly one of any, all and not,
+// and that its nested operations are valid
+func validateSmartOpGroup(op *SmartOp, programs map[string]*vm.Program) error {
+	if op.Position != "" || op.Operation != "" {
+		return fmt.Errorf("group cannot have a position or operation")
+	}
+
+	set := 0
+	for _, isSet := range []bool{op.Any != nil, op.All != nil, op.Not != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("group must set exactly one of any, all and not")
+	}
+
+	switch {
+	case op.Any != nil:
+		return validateSmartOpList("any", op.Any, programs)
+	case op.All != nil:
+		return validateSmartOpList("all", op.All, programs)
+	default:
+		if err := validateSmartOp(op.Not, programs); err != nil {
+			return fmt.Errorf("not: %w", err)
+		}
+		return nil
+	}
+}
+
+// validateSmartOpList checks the nested operations of an any or all group
+func validateSmartOpList(name string, ops []SmartOp, programs map[string]*vm.Program) error {
+	if len(ops) == 0 {
+		return fmt.Errorf("%s cannot be empty", name)
+	}
+	for i := range ops {
+		if err := validateSmartOp(&ops[i], programs); err != nil {
+			return fmt.Errorf("%s[%d]: %w", name, i, err)
+		}
+	}
 	return nil
 }
 
@@ -186,7 +367,7 @@ func (r *Router) evaluateModelOp(ctx *RequestContext, op *SmartOp) bool {
 	case OpModelContains:
 		return strings.Contains(model, value)
 	case OpModelGlob:
-		g, err := glob.Compile(value)
+		g, err := r.glob(value)
 		if err != nil {
 			log.Printf("[smart_routing] invalid glob pattern '%s' in model operation: %v", value, err)
 			return false
@@ -246,7 +427,7 @@ func (r *Router) evaluateContextSystemOp(ctx *RequestContext, op *SmartOp) bool
 		return strings.Contains(combined, value)
 	case OpContextSystemRegex:
 		// Basic regex support - can be extended with regexp package
-		matched, err := stringsMatch(combined, value, true)
+		matched, err := r.stringsMatch(combined, value, true)
 		if err != nil {
 			return false
 		}
@@ -269,7 +450,7 @@ func (r *Router) evaluateContextUserOp(ctx *RequestContext, op *SmartOp) bool {
 	case OpContextUserContains:
 		return strings.Contains(combined, value)
 	case OpContextUserRegex:
-		matched, err := stringsMatch(combined, value, true)
+		matched, err := r.stringsMatch(combined, value, true)
 		if err != nil {
 			return false
 		}
@@ -342,12 +523,158 @@ func (r *Router) evaluateTokenOp(ctx *RequestContext, op *SmartOp) bool {
 	}
 }
 
+// evaluateAPIKeyOp evaluates operations on the named API key of the request
+func (r *Router) evaluateAPIKeyOp(ctx *RequestContext, op *SmartOp) bool {
+	if ctx.APIKeyID == "" {
+		return false // Authenticated with the default token
+	}
+
+	switch op.Operation {
+	case OpAPIKeyEquals:
+		return ctx.APIKeyName == op.Value || ctx.APIKeyID == op.Value
+	case OpAPIKeyGlob:
+		g, err := r.glob(op.Value)
+		if err != nil {
+			log.Printf("[smart_routing] invalid glob pattern '%s' in api_key operation: %v", op.Value, err)
+			return false
+		}
+		return g.Match(ctx.APIKeyName)
+	default:
+		return false
+	}
+}
+
+// evaluateClientOp evaluates operations on the client name
+func (r *Router) evaluateClientOp(ctx *RequestContext, op *SmartOp) bool {
+	switch op.Operation {
+	case OpClientContains:
+		return strings.Contains(ctx.Client, op.Value)
+	case OpClientGlob:
+		g, err := r.glob(op.Value)
+		if err != nil {
+			log.Printf("[smart_routing] invalid glob pattern '%s' in client operation: %v", op.Value, err)
+			return false
+		}
+		return g.Match(ctx.Client)
+	default:
+		return false
+	}
+}
+
+// evaluateHeaderOp evaluates operations on a request header
+func (r *Router) evaluateHeaderOp(ctx *RequestContext, op *SmartOp) bool {
+	if op.Operation == OpHeaderExists {
+		_, ok := ctx.GetHeader(strings.TrimSpace(op.Value))
+		return ok
+	}
+
+	name, want, err := parseHeaderValue(op.Value)
+	if err != nil {
+		log.Printf("[smart_routing] %v", err)
+		return false
+	}
+	got, ok := ctx.GetHeader(name)
+	if !ok {
+		return false
+	}
+
+	switch op.Operation {
+	case OpHeaderEquals:
+		return got == want
+	case OpHeaderContains:
+		return strings.Contains(got, want)
+	default:
+		return false
+	}
+}
+
+// parseHeaderValue splits a header operation value written as "name: value"
+func parseHeaderValue(value string) (name, headerValue string, err error) {
+	name, headerValue, ok := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid header value %q, expected 'name: value'", value)
+	}
+	return name, strings.TrimSpace(headerValue), nil
+}
+
+// evaluateCountOp evaluates comparison operations on a count. The tool and image count
+// positions share the operation names of the token position.
+func evaluateCountOp(count int, op *SmartOp) bool {
+	target, err := op.Int()
+	if err != nil {
+		log.Printf("[smart_routing] invalid %s value '%s': %v", op.Position, op.Value, err)
+		return false
+	}
+
+	switch op.Operation {
+	case OpToolCountGe:
+		return count >= target
+	case OpToolCountGt:
+		return count > target
+	case OpToolCountLe:
+		return count <= target
+	case OpToolCountLt:
+		return count < target
+	default:
+		return false
+	}
+}
+
+// evaluateTimeOp evaluates operations on the time the request was received. Windows and
+// weekdays are in the server's local time zone, not the client's.
+func (r *Router) evaluateTimeOp(ctx *RequestContext, op *SmartOp) bool {
+	now := ctx.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	switch op.Operation {
+	case OpTimeBetween:
+		in, err := inTimeWindow(now, op.Value)
+		if err != nil {
+			log.Printf("[smart_routing] %v", err)
+			return false
+		}
+		return in
+	case OpTimeWeekday:
+		days, err := parseWeekdays(op.Value)
+		if err != nil {
+			log.Printf("[smart_routing] %v", err)
+			return false
+		}
+		return days[now.Weekday()]
+	default:
+		return false
+	}
+}
+
+// evaluateExprOp evaluates an expr-lang expression against the request context
+func (r *Router) evaluateExprOp(ctx *RequestContext, op *SmartOp) bool {
+	program, ok := r.programs[op.Value]
+	if !ok {
+		var err error
+		if program, err = compileExpr(op.Value); err != nil {
+			log.Printf("[smart_routing] %v", err)
+			return false
+		}
+	}
+
+	result, err := expr.Run(program, ctx)
+	if err != nil {
+		log.Printf("[smart_routing] failed to evaluate expression '%s': %v", op.Value, err)
+		return false
+	}
+	matched, _ := result.(bool)
+	return matched
+}
+
 // stringsMatch provides basic regex matching support
 // For now, it provides simple pattern matching with support for:
 // - Wildcards (*)
 // - Character classes ([abc])
 // - Alternatives (a|b)
-func stringsMatch(text, pattern string, useRegex bool) (bool, error) {
+func (r *Router) stringsMatch(text, pattern string, useRegex bool) (bool, error) {
 	if !useRegex {
 		return strings.Contains(text, pattern), nil
 	}
@@ -355,7 +682,7 @@ func stringsMatch(text, pattern string, useRegex bool) (bool, error) {
 	// For simple patterns, use glob
 	// For complex regex, we'd use the regexp package
 	// This is a simplified implementation
-	g, err := glob.Compile(pattern)
+	g, err := r.glob(pattern)
 	if err != nil {
 		log.Printf("[smart_routing] invalid glob/regex pattern '%s', falling back to contains: %v", pattern, err)
 		// Try as simple contains
@@ -0,0 +1,88 @@
+package smartrouting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// weekdays maps the accepted weekday names to time.Weekday
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// parseTimeOfDay parses "HH:MM" into minutes since midnight
+func parseTimeOfDay(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// parseTimeWindow parses "HH:MM-HH:MM" into start and end minutes since midnight
+func parseTimeWindow(s string) (start, end int, err error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid time window %q, expected HH:MM-HH:MM", s)
+	}
+	if start, err = parseTimeOfDay(from); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parseTimeOfDay(to); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// inTimeWindow reports whether t falls in the window. The start is inclusive and the
+// end exclusive; a window whose end is before its start wraps past midnight.
+func inTimeWindow(t time.Time, window string) (bool, error) {
+	start, end, err := parseTimeWindow(window)
+	if err != nil {
+		return false, err
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if start <= end {
+		return minute >= start && minute < end, nil
+	}
+	return minute >= start || minute < end, nil
+}
+
+// parseWeekdays parses a comma-separated list of weekdays and ranges, e.g. "mon-fri,sun"
+func parseWeekdays(s string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(part, "-")
+		first, ok := weekdays[strings.TrimSpace(from)]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q, expected sun, mon, tue, wed, thu, fri or sat", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdays[strings.TrimSpace(to)]; !ok {
+				return nil, fmt.Errorf("invalid weekday %q, expected sun, mon, tue, wed, thu, fri or sat", to)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("weekday list cannot be empty")
+	}
+	return days, nil
+}
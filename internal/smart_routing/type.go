@@ -31,12 +31,24 @@ type SmartOpMeta struct {
 
 // SmartOp represents a single operation for smart routing
 // Each operation has 4 parts: position, operation, value, meta
+//
+// An operation can instead be a group that combines nested operations: Any matches
+// when one of them matches, All when every one matches and Not when its operation
+// does not match. A group sets exactly one of Any, All and Not and no position.
 type SmartOp struct {
 	UUID      string           `json:"uuid"`
 	Position  SmartOpPosition  `json:"position" yaml:"position"`
 	Operation SmartOpOperation `json:"operation" yaml:"operation"`
 	Value     string           `json:"value,omitempty" yaml:"value,omitempty"`
 	Meta      SmartOpMeta      `json:"meta,omitempty" yaml:"meta,omitempty"`
+	Any       []SmartOp        `json:"any,omitempty" yaml:"any,omitempty"`
+	All       []SmartOp        `json:"all,omitempty" yaml:"all,omitempty"`
+	Not       *SmartOp         `json:"not,omitempty" yaml:"not,omitempty"`
+}
+
+// IsGroup reports whether the operation combines nested operations
+func (o *SmartOp) IsGroup() bool {
+	return o.Any != nil || o.All != nil || o.Not != nil
 }
 
 // String returns the value as a string with type checking
@@ -72,7 +84,8 @@ type SmartRouting struct {
 // IsValid checks if the position is valid
 func (p SmartOpPosition) IsValid() bool {
 	switch p {
-	case PositionModel, PositionThinking, PositionContextSystem, PositionContextUser, PositionLatestUser, PositionToolUse, PositionToken:
+	case PositionModel, PositionThinking, PositionContextSystem, PositionContextUser, PositionLatestUser, PositionToolUse, PositionToken,
+		PositionAPIKey, PositionClient, PositionHeader, PositionToolCount, PositionImageCount, PositionTime, PositionExpr:
 		return true
 	default:
 		return false
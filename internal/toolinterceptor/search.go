@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/tingly-dev/tingly-box/internal/secretref"
 )
 
 const (
@@ -122,6 +124,10 @@ func (h *SearchHandler) searchBraveWithConfig(query string, count int, config *C
 	if config.SearchKey == "" {
 		return nil, fmt.Errorf("search API key is required for Brave Search")
 	}
+	searchKey, err := secretref.Resolve(config.SearchKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve search API key: %w", err)
+	}
 
 	// Build request URL
 	apiURL, err := url.Parse(braveSearchAPIURL)
@@ -143,7 +149,7 @@ func (h *SearchHandler) searchBraveWithConfig(query string, count int, config *C
 	// Add API key header
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Accept-Encoding", "gzip")
-	req.Header.Add("X-Subscription-Token", config.SearchKey)
+	req.Header.Add("X-Subscription-Token", searchKey)
 
 	// Execute request
 	resp, err := h.client.Do(req)
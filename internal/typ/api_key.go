@@ -0,0 +1,86 @@
+package typ
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// APIKeyPrefix is prepended to every generated client API key
+const APIKeyPrefix = "tb-"
+
+// APIKey is a named client key for the model endpoints. Only the hash of the
+// secret is stored; the secret itself is shown once on create and rotate.
+// Empty scope lists allow everything.
+type APIKey struct {
+	ID        string         `json:"id" yaml:"id"`
+	Name      string         `json:"name" yaml:"name"`
+	Hash      string         `json:"hash,omitempty" yaml:"hash,omitempty"`             // SHA-256 of the secret
+	Hint      string         `json:"hint" yaml:"hint"`                                 // Last characters of the secret, for display
+	CreatedAt time.Time      `json:"created_at" yaml:"created_at"`                     // Creation time
+	ExpiresAt *time.Time     `json:"expires_at,omitempty" yaml:"expires_at,omitempty"` // Expiry time (nil = never)
+	RevokedAt *time.Time     `json:"revoked_at,omitempty" yaml:"revoked_at,omitempty"` // Revocation time (nil = active)
+	RotatedAt *time.Time     `json:"rotated_at,omitempty" yaml:"rotated_at,omitempty"` // Last secret rotation time
+	Scenarios []RuleScenario `json:"scenarios,omitempty" yaml:"scenarios,omitempty"`   // Allowed scenarios
+	Models    []string       `json:"models,omitempty" yaml:"models,omitempty"`         // Allowed request models
+	Rules     []string       `json:"rules,omitempty" yaml:"rules,omitempty"`           // Allowed rule UUIDs
+	RateLimit *RateLimit     `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"` // Optional per-key rate limit
+}
+
+// HashAPIKey returns the stored representation of an API key secret
+func HashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsRevoked reports whether the key has been revoked
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// IsExpired reports whether the key has expired at the given time
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// IsActive reports whether the key can be used at the given time
+func (k *APIKey) IsActive(now time.Time) bool {
+	return !k.IsRevoked() && !k.IsExpired(now)
+}
+
+// AllowsScenario reports whether the key may be used for a scenario
+func (k *APIKey) AllowsScenario(scenario RuleScenario) bool {
+	if len(k.Scenarios) == 0 {
+		return true
+	}
+	for _, s := range k.Scenarios {
+		if s == scenario {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsRule reports whether the key may be used for a rule, checking both
+// the rule UUID and its request model
+func (k *APIKey) AllowsRule(rule *Rule) bool {
+	if rule == nil {
+		return len(k.Rules) == 0 && len(k.Models) == 0
+	}
+	if len(k.Rules) > 0 && !containsString(k.Rules, rule.UUID) {
+		return false
+	}
+	if len(k.Models) > 0 && !containsString(k.Models, rule.RequestModel) {
+		return false
+	}
+	return true
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package typ
+
+import (
+	"fmt"
+	"time"
+)
+
+// BudgetScope is what a budget applies to
+type BudgetScope string
+
+const (
+	BudgetScopeAPIKey   BudgetScope = "api_key"  // Target is an API key ID
+	BudgetScopeScenario BudgetScope = "scenario" // Target is a scenario name
+)
+
+// BudgetPeriod is the window usage is accumulated over
+type BudgetPeriod string
+
+const (
+	BudgetPeriodDaily   BudgetPeriod = "daily"
+	BudgetPeriodMonthly BudgetPeriod = "monthly"
+)
+
+// BudgetLimits are the usage limits of a budget period. Zero values are unlimited.
+type BudgetLimits struct {
+	Tokens   int64   `json:"tokens,omitempty" yaml:"tokens,omitempty"`     // Total (input + output) tokens
+	Requests int64   `json:"requests,omitempty" yaml:"requests,omitempty"` // Request count
+	CostUSD  float64 `json:"cost_usd,omitempty" yaml:"cost_usd,omitempty"` // Cost from the pricing catalog
+}
+
+// Budget limits the usage of a client API key or a scenario per day or month.
+// Crossing a soft limit emits an event; hitting a hard limit rejects requests with 429.
+type Budget struct {
+	ID         string       `json:"id" yaml:"id"`
+	Name       string       `json:"name,omitempty" yaml:"name,omitempty"`
+	Scope      BudgetScope  `json:"scope" yaml:"scope"`                                 // api_key or scenario
+	Target     string       `json:"target" yaml:"target"`                               // API key ID or scenario name
+	Period     BudgetPeriod `json:"period" yaml:"period"`                               // daily or monthly
+	Hard       BudgetLimits `json:"hard" yaml:"hard"`                                   // Requests are rejected once reached
+	Soft       BudgetLimits `json:"soft" yaml:"soft"`                                   // An event is emitted once crossed
+	WebhookURL string       `json:"webhook_url,omitempty" yaml:"webhook_url,omitempty"` // Receives soft and hard limit events
+	Disabled   bool         `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+}
+
+// Validate checks that the budget is well formed
+func (b *Budget) Validate() error {
+	switch b.Scope {
+	case BudgetScopeAPIKey, BudgetScopeScenario:
+	default:
+		return fmt.Errorf("invalid budget scope: %s", b.Scope)
+	}
+	switch b.Period {
+	case BudgetPeriodDaily, BudgetPeriodMonthly:
+	default:
+		return fmt.Errorf("invalid budget period: %s", b.Period)
+	}
+	if b.Target == "" {
+		return fmt.Errorf("budget target cannot be empty")
+	}
+	return nil
+}
+
+// Matches reports whether the budget applies to a request
+func (b *Budget) Matches(apiKeyID, scenario string) bool {
+	if b.Disabled {
+		return false
+	}
+	switch b.Scope {
+	case BudgetScopeAPIKey:
+		return apiKeyID != "" && b.Target == apiKeyID
+	case BudgetScopeScenario:
+		return b.Target == scenario
+	}
+	return false
+}
+
+// PeriodStart returns the start of the budget period containing now, in now's location
+func (b *Budget) PeriodStart(now time.Time) time.Time {
+	if b.Period == BudgetPeriodMonthly {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// PeriodEnd returns the end of the budget period containing now
+func (b *Budget) PeriodEnd(now time.Time) time.Time {
+	start := b.PeriodStart(now)
+	if b.Period == BudgetPeriodMonthly {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// Exceeded returns a description of the first limit reached by the given usage,
+// or an empty string if none is
+func (l BudgetLimits) Exceeded(requests, tokens int64, costUSD float64) string {
+	if l.Requests > 0 && requests >= l.Requests {
+		return fmt.Sprintf("request limit of %d", l.Requests)
+	}
+	if l.Tokens > 0 && tokens >= l.Tokens {
+		return fmt.Sprintf("token limit of %d", l.Tokens)
+	}
+	if l.CostUSD > 0 && costUSD >= l.CostUSD {
+		return fmt.Sprintf("cost limit of $%.2f", l.CostUSD)
+	}
+	return ""
+}
+
+// IsZero reports whether no limit is set
+func (l BudgetLimits) IsZero() bool {
+	return l.Requests <= 0 && l.Tokens <= 0 && l.CostUSD <= 0
+}
@@ -0,0 +1,62 @@
+package typ
+
+// ContextOverflowStrategy selects what happens to a request that does not fit the
+// context window of the selected service
+type ContextOverflowStrategy string
+
+const (
+	ContextOverflowAuto      ContextOverflowStrategy = "auto"      // Route to a larger window, summarize when none fits
+	ContextOverflowRoute     ContextOverflowStrategy = "route"     // Route to a service of the rule with a larger window
+	ContextOverflowSummarize ContextOverflowStrategy = "summarize" // Summarize the oldest rounds with the summarizer rule
+)
+
+// DefaultContextOverflowKeepRounds is the number of recent rounds never summarized
+const DefaultContextOverflowKeepRounds = 2
+
+// ContextOverflowConfig protects the services of a rule against conversations that
+// outgrow their context window. The window of a service comes from the provider
+// templates, ContextWindow applies to the services the templates do not know.
+//
+// Routing applies to every endpoint. Summarization only applies to the Anthropic
+// Messages and OpenAI Chat Completions endpoints: Responses and Google requests that
+// no service fits are forwarded as is.
+type ContextOverflowConfig struct {
+	Enabled         bool                    `json:"enabled" yaml:"enabled"`                                           // Whether overflow protection is enabled for the rule
+	Strategy        ContextOverflowStrategy `json:"strategy,omitempty" yaml:"strategy,omitempty"`                     // What to do on overflow (default: auto)
+	ContextWindow   int                     `json:"context_window,omitempty" yaml:"context_window,omitempty"`         // Context window in tokens for services unknown to the templates
+	SummarizerRule  string                  `json:"summarizer_rule,omitempty" yaml:"summarizer_rule,omitempty"`       // UUID of the rule serving the (cheap) summarizer model
+	KeepLastNRounds int                     `json:"keep_last_n_rounds,omitempty" yaml:"keep_last_n_rounds,omitempty"` // Recent rounds kept verbatim (default: 2)
+}
+
+// IsEnabled reports whether overflow protection is configured and enabled
+func (o *ContextOverflowConfig) IsEnabled() bool {
+	return o != nil && o.Enabled
+}
+
+// GetStrategy returns the effective overflow strategy
+func (o *ContextOverflowConfig) GetStrategy() ContextOverflowStrategy {
+	if o == nil || o.Strategy == "" {
+		return ContextOverflowAuto
+	}
+	return o.Strategy
+}
+
+// CanRoute reports whether an overflowing request may be routed to a larger window
+func (o *ContextOverflowConfig) CanRoute() bool {
+	strategy := o.GetStrategy()
+	return strategy == ContextOverflowAuto || strategy == ContextOverflowRoute
+}
+
+// CanSummarize reports whether an overflowing request may be summarized
+func (o *ContextOverflowConfig) CanSummarize() bool {
+	strategy := o.GetStrategy()
+	return o != nil && o.SummarizerRule != "" && (strategy == ContextOverflowAuto || strategy == ContextOverflowSummarize)
+}
+
+// GetKeepLastNRounds returns the effective number of rounds kept verbatim
+func (o *ContextOverflowConfig) GetKeepLastNRounds() int {
+	if o == nil || o.KeepLastNRounds < 1 {
+		return DefaultContextOverflowKeepRounds
+	}
+	return o.KeepLastNRounds
+}
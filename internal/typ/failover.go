@@ -0,0 +1,54 @@
+package typ
+
+import (
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+)
+
+// FailoverConfig controls automatic failover to the next service of a rule
+// when the selected upstream fails before any response reaches the client.
+type FailoverConfig struct {
+	Enabled     bool `json:"enabled" yaml:"enabled"`                               // Whether failover is enabled for the rule
+	MaxAttempts int  `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"` // Max attempts including the first one (0 = one per active service)
+}
+
+// IsEnabled reports whether failover is configured and enabled
+func (f *FailoverConfig) IsEnabled() bool {
+	return f != nil && f.Enabled
+}
+
+// GetMaxAttempts returns the effective attempt budget given the number of candidate services
+func (f *FailoverConfig) GetMaxAttempts(candidates int) int {
+	if f == nil || f.MaxAttempts <= 0 || f.MaxAttempts > candidates {
+		return candidates
+	}
+	return f.MaxAttempts
+}
+
+// FailoverCandidates returns the available services to try after the given one,
+// in rule order, starting with the service that follows it.
+func (r *Rule) FailoverCandidates(current *loadbalance.Service) []*loadbalance.Service {
+	activeServices := r.GetAvailableServices()
+	if len(activeServices) == 0 {
+		return nil
+	}
+
+	start := 0
+	if current != nil {
+		for i, svc := range activeServices {
+			if svc.ServiceID() == current.ServiceID() {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	candidates := make([]*loadbalance.Service, 0, len(activeServices))
+	for i := 0; i < len(activeServices); i++ {
+		svc := activeServices[(start+i)%len(activeServices)]
+		if current != nil && svc.ServiceID() == current.ServiceID() {
+			continue
+		}
+		candidates = append(candidates, svc)
+	}
+	return candidates
+}
@@ -0,0 +1,89 @@
+package typ
+
+import (
+	"sort"
+	"strings"
+)
+
+// tokensPerPricingUnit is the number of tokens prices are quoted for
+const tokensPerPricingUnit = 1_000_000
+
+// ModelPricing holds the price of a model in USD per million tokens.
+// Cache prices of zero fall back to the input price.
+type ModelPricing struct {
+	Input      float64 `json:"input" yaml:"input"`                                 // Price per 1M uncached input tokens
+	Output     float64 `json:"output" yaml:"output"`                               // Price per 1M output tokens
+	CacheRead  float64 `json:"cache_read,omitempty" yaml:"cache_read,omitempty"`   // Price per 1M input tokens read from the prompt cache
+	CacheWrite float64 `json:"cache_write,omitempty" yaml:"cache_write,omitempty"` // Price per 1M input tokens written to the prompt cache
+}
+
+// Cost returns the cost in USD of a request with the given token counts.
+// inputTokens must not include the cache read/write tokens.
+func (p *ModelPricing) Cost(inputTokens, outputTokens, cacheReadTokens, cacheWriteTokens int) float64 {
+	if p == nil {
+		return 0
+	}
+
+	cacheRead := p.CacheRead
+	if cacheRead == 0 {
+		cacheRead = p.Input
+	}
+	cacheWrite := p.CacheWrite
+	if cacheWrite == 0 {
+		cacheWrite = p.Input
+	}
+
+	return (float64(inputTokens)*p.Input +
+		float64(outputTokens)*p.Output +
+		float64(cacheReadTokens)*cacheRead +
+		float64(cacheWriteTokens)*cacheWrite) / tokensPerPricingUnit
+}
+
+// BlendedPrice returns a single per-1M-token price assuming a 3:1 input to output
+// ratio, which is how services are ranked by the lowest_cost tactic.
+func (p *ModelPricing) BlendedPrice() float64 {
+	if p == nil {
+		return 0
+	}
+	return (3*p.Input + p.Output) / 4
+}
+
+// LookupModelPricing finds the pricing of a model in a pricing table.
+// It checks in order:
+// 1. Exact model match
+// 2. Model without a vendor prefix (e.g. "anthropic/claude-sonnet-4" -> "claude-sonnet-4")
+// 3. Longest key that prefixes the model, so dated variants match their family
+func LookupModelPricing(table map[string]*ModelPricing, model string) *ModelPricing {
+	if len(table) == 0 || model == "" {
+		return nil
+	}
+
+	if p, ok := table[model]; ok {
+		return p
+	}
+
+	if idx := strings.LastIndex(model, "/"); idx >= 0 {
+		model = model[idx+1:]
+		if p, ok := table[model]; ok {
+			return p
+		}
+	}
+
+	keys := make([]string, 0, len(table))
+	for k := range table {
+		keys = append(keys, k)
+	}
+	// Longest first so "claude-opus-4-5" wins over "claude-opus-4"
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	for _, k := range keys {
+		if strings.HasPrefix(model, k) {
+			return table[k]
+		}
+	}
+	return nil
+}
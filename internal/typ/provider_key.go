@@ -0,0 +1,81 @@
+package typ
+
+import "fmt"
+
+// DefaultProviderKeyID identifies the provider Token among the rotation keys
+const DefaultProviderKeyID = "default"
+
+// ProviderKey is an additional API key of a provider. Requests rotate among the
+// provider Token and its keys in proportion to their weights.
+type ProviderKey struct {
+	ID       string `json:"id"`                 // Stable identifier, shown in the key stats
+	Name     string `json:"name,omitempty"`     // Optional label, e.g. the account the key belongs to
+	Token    string `json:"token"`              // API key or secret reference (env:, file:, exec:)
+	Weight   int    `json:"weight,omitempty"`   // Relative share of requests (default: 1)
+	Disabled bool   `json:"disabled,omitempty"` // Excluded from rotation
+}
+
+// GetWeight returns the effective weight of the key
+func (k *ProviderKey) GetWeight() int {
+	if k.Weight <= 0 {
+		return 1
+	}
+	return k.Weight
+}
+
+// HasKeys reports whether requests to the provider rotate among several keys
+func (p *Provider) HasKeys() bool {
+	if p.AuthType == AuthTypeOAuth {
+		return false
+	}
+	for _, k := range p.Keys {
+		if k != nil && !k.Disabled && k.Token != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// RotationKeys returns the keys requests rotate among: the provider Token as the
+// "default" key, followed by the enabled additional keys
+func (p *Provider) RotationKeys() []*ProviderKey {
+	keys := make([]*ProviderKey, 0, len(p.Keys)+1)
+	if p.Token != "" {
+		keys = append(keys, &ProviderKey{ID: DefaultProviderKeyID, Token: p.Token, Weight: 1})
+	}
+	for _, k := range p.Keys {
+		if k != nil && !k.Disabled && k.Token != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// WithKey returns a copy of the provider that authenticates with the given key
+func (p *Provider) WithKey(key *ProviderKey) *Provider {
+	bound := *p
+	bound.Token = key.Token
+	bound.KeyID = key.ID
+	return &bound
+}
+
+// NormalizeKeys assigns identifiers to keys that have none
+func (p *Provider) NormalizeKeys() {
+	used := map[string]bool{DefaultProviderKeyID: true}
+	for _, k := range p.Keys {
+		if k != nil && k.ID != "" {
+			used[k.ID] = true
+		}
+	}
+	next := 1
+	for _, k := range p.Keys {
+		if k == nil || k.ID != "" {
+			continue
+		}
+		for used[fmt.Sprintf("key-%d", next)] {
+			next++
+		}
+		k.ID = fmt.Sprintf("key-%d", next)
+		used[k.ID] = true
+	}
+}
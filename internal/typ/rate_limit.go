@@ -0,0 +1,35 @@
+package typ
+
+// RateLimitScope is what a rate limit rule buckets requests by
+type RateLimitScope string
+
+const (
+	RateLimitScopeAPIKey   RateLimitScope = "api_key"  // One bucket per client API key
+	RateLimitScopeScenario RateLimitScope = "scenario" // One bucket per scenario
+	RateLimitScopeModel    RateLimitScope = "model"    // One bucket per request model
+)
+
+// RateLimit is a token-bucket limit on the model endpoints. Zero values are unlimited.
+type RateLimit struct {
+	RequestsPerMinute int `json:"requests_per_minute,omitempty" yaml:"requests_per_minute,omitempty"` // RPM
+	TokensPerMinute   int `json:"tokens_per_minute,omitempty" yaml:"tokens_per_minute,omitempty"`     // TPM (input + output tokens)
+}
+
+// IsZero reports whether no limit is set
+func (r *RateLimit) IsZero() bool {
+	return r == nil || (r.RequestsPerMinute <= 0 && r.TokensPerMinute <= 0)
+}
+
+// RateLimitRule applies a rate limit to the requests of a scope. An empty target
+// gives every key, scenario or model of the scope its own bucket with this limit;
+// a non-empty target only limits that one.
+type RateLimitRule struct {
+	Scope  RateLimitScope `json:"scope" yaml:"scope"`
+	Target string         `json:"target,omitempty" yaml:"target,omitempty"`
+	RateLimit
+}
+
+// Matches reports whether the rule applies to the given scope value
+func (r *RateLimitRule) Matches(value string) bool {
+	return value != "" && (r.Target == "" || r.Target == value)
+}
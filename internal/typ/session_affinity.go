@@ -0,0 +1,81 @@
+package typ
+
+import (
+	"time"
+
+	"github.com/tingly-dev/tingly-box/internal/loadbalance"
+)
+
+// SessionKeySource selects how the conversation key of a request is derived
+type SessionKeySource string
+
+const (
+	SessionKeyAuto         SessionKeySource = "auto"          // Header, then client metadata, then first message
+	SessionKeyHeader       SessionKeySource = "header"        // Client header, see SessionAffinityConfig.Header
+	SessionKeyMetadata     SessionKeySource = "metadata"      // Session metadata sent by the client (Claude Code metadata.user_id, OpenAI user, Responses prompt_cache_key)
+	SessionKeyFirstMessage SessionKeySource = "first_message" // Hash of the system prompt and the first user message
+)
+
+// Session affinity defaults
+const (
+	DefaultSessionHeader     = "x-session-id"
+	DefaultSessionTTLSeconds = 3600
+)
+
+// SessionAffinityConfig pins the requests of a conversation to the service that
+// served its first request, so that upstream prompt caches stay warm. The pin
+// expires after TTLSeconds without requests and is released early when the
+// service becomes unavailable.
+type SessionAffinityConfig struct {
+	Enabled    bool             `json:"enabled" yaml:"enabled"`                             // Whether session affinity is enabled for the rule
+	Key        SessionKeySource `json:"key,omitempty" yaml:"key,omitempty"`                 // How the conversation key is derived (default: auto)
+	Header     string           `json:"header,omitempty" yaml:"header,omitempty"`           // Header carrying the conversation key (default: x-session-id)
+	TTLSeconds int              `json:"ttl_seconds,omitempty" yaml:"ttl_seconds,omitempty"` // Idle time before the pin expires (default: 3600)
+}
+
+// IsEnabled reports whether session affinity is configured and enabled
+func (s *SessionAffinityConfig) IsEnabled() bool {
+	return s != nil && s.Enabled
+}
+
+// GetKey returns the effective conversation key source
+func (s *SessionAffinityConfig) GetKey() SessionKeySource {
+	if s == nil || s.Key == "" {
+		return SessionKeyAuto
+	}
+	return s.Key
+}
+
+// GetHeader returns the effective conversation key header
+func (s *SessionAffinityConfig) GetHeader() string {
+	if s == nil || s.Header == "" {
+		return DefaultSessionHeader
+	}
+	return s.Header
+}
+
+// GetTTL returns the effective idle time before a pin expires
+func (s *SessionAffinityConfig) GetTTL() time.Duration {
+	if s == nil || s.TTLSeconds <= 0 {
+		return DefaultSessionTTLSeconds * time.Second
+	}
+	return time.Duration(s.TTLSeconds) * time.Second
+}
+
+// FindService returns the service with the given ID, looking at the services of the
+// rule and of its smart routing blocks, or nil when it is no longer configured
+func (r *Rule) FindService(serviceID string) *loadbalance.Service {
+	for _, svc := range r.Services {
+		if svc.ServiceID() == serviceID {
+			return svc
+		}
+	}
+	for _, sr := range r.SmartRouting {
+		for _, svc := range sr.Services {
+			if svc.ServiceID() == serviceID {
+				return svc
+			}
+		}
+	}
+	return nil
+}
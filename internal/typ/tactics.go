@@ -45,6 +45,10 @@ func (tc *Tactic) UnmarshalJSON(data []byte) error {
 		tc.Params = &HybridParams{}
 	case loadbalance.TacticRandom:
 		tc.Params = &RandomParams{}
+	case loadbalance.TacticLeastLatency:
+		tc.Params = &LeastLatencyParams{}
+	case loadbalance.TacticLowestCost:
+		tc.Params = &LowestCostParams{}
 	default:
 		return nil
 	}
@@ -95,6 +99,22 @@ func ParseTacticFromMap(tacticType loadbalance.TacticType, params map[string]int
 		} else {
 			tacticParams = DefaultHybridParams()
 		}
+	case loadbalance.TacticLeastLatency:
+		if params != nil {
+			tacticParams = &LeastLatencyParams{
+				ToleranceMs: getIntParamFromMap(params, "tolerance_ms", constant.DefaultLatencyToleranceMs),
+			}
+		} else {
+			tacticParams = DefaultLeastLatencyParams()
+		}
+	case loadbalance.TacticLowestCost:
+		if params != nil {
+			tacticParams = &LowestCostParams{
+				Prices: getFloatMapParamFromMap(params, "prices"),
+			}
+		} else {
+			tacticParams = DefaultLowestCostParams()
+		}
 	default:
 		tacticParams = DefaultRoundRobinParams()
 	}
@@ -121,6 +141,27 @@ func getIntParamFromMap(params map[string]interface{}, key string, defaultValue
 	return defaultValue
 }
 
+// getFloatMapParamFromMap safely extracts a map of float64 values from a map.
+// Non-numeric entries are ignored.
+func getFloatMapParamFromMap(params map[string]interface{}, key string) map[string]float64 {
+	raw, ok := params[key].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	result := make(map[string]float64, len(raw))
+	for k, val := range raw {
+		switch v := val.(type) {
+		case float64:
+			result[k] = v
+		case int:
+			result[k] = float64(v)
+		case int64:
+			result[k] = float64(v)
+		}
+	}
+	return result
+}
+
 // TacticParams represents parameters for different load balancing tactics
 // This is a sealed type that can only be one of the specific tactic parameter types
 type TacticParams interface {
@@ -155,6 +196,20 @@ type RandomParams struct{}
 
 func (r RandomParams) isTacticParams() {}
 
+// LeastLatencyParams holds parameters for least-latency tactic
+type LeastLatencyParams struct {
+	ToleranceMs int64 `json:"tolerance_ms"` // Services within this margin of the fastest are treated as equally fast
+}
+
+func (l LeastLatencyParams) isTacticParams() {}
+
+// LowestCostParams holds parameters for lowest-cost tactic
+type LowestCostParams struct {
+	Prices map[string]float64 `json:"prices,omitempty"` // Optional price overrides in USD per 1M tokens, keyed by "provider:model" or model
+}
+
+func (l LowestCostParams) isTacticParams() {}
+
 // Helper constructors for creating tactic parameters
 func NewRoundRobinParams(threshold int64) TacticParams {
 	return RoundRobinParams{RequestThreshold: threshold}
@@ -175,6 +230,14 @@ func NewRandomParams() TacticParams {
 	return RandomParams{}
 }
 
+func NewLeastLatencyParams(toleranceMs int64) TacticParams {
+	return LeastLatencyParams{ToleranceMs: toleranceMs}
+}
+
+func NewLowestCostParams(prices map[string]float64) TacticParams {
+	return LowestCostParams{Prices: prices}
+}
+
 // DefaultParams returns default parameters for each tactic type
 func DefaultRoundRobinParams() TacticParams {
 	return RoundRobinParams{RequestThreshold: constant.DefaultRequestThreshold}
@@ -195,6 +258,14 @@ func DefaultRandomParams() TacticParams {
 	return RandomParams{}
 }
 
+func DefaultLeastLatencyParams() TacticParams {
+	return LeastLatencyParams{ToleranceMs: constant.DefaultLatencyToleranceMs}
+}
+
+func DefaultLowestCostParams() TacticParams {
+	return LowestCostParams{}
+}
+
 // Type assertion helpers for TacticParams
 func AsRoundRobinParams(p TacticParams) (RoundRobinParams, bool) {
 	rp, ok := p.(RoundRobinParams)
@@ -216,6 +287,16 @@ func AsRandomParams(p TacticParams) (RandomParams, bool) {
 	return rp, ok
 }
 
+func AsLeastLatencyParams(p TacticParams) (LeastLatencyParams, bool) {
+	lp, ok := p.(LeastLatencyParams)
+	return lp, ok
+}
+
+func AsLowestCostParams(p TacticParams) (LowestCostParams, bool) {
+	lp, ok := p.(LowestCostParams)
+	return lp, ok
+}
+
 // LoadBalancingTactic defines the interface for load balancing strategies
 type LoadBalancingTactic interface {
 	SelectService(rule *Rule) *loadbalance.Service
@@ -223,6 +304,20 @@ type LoadBalancingTactic interface {
 	GetType() loadbalance.TacticType
 }
 
+// PreviewService returns the service the tactic would select for the rule without
+// changing the rule or the shared round-robin state. Tactics that pick at random
+// return one possible choice.
+func PreviewService(tactic LoadBalancingTactic, rule *Rule) *loadbalance.Service {
+	preview := *rule
+	preview.UUID = fmt.Sprintf("preview:%s:%p", rule.UUID, &preview)
+	if streak, ok := globalRoundRobinStreaks.Load(rule.UUID); ok {
+		globalRoundRobinStreaks.Store(preview.UUID, streak)
+	}
+	defer globalRoundRobinStreaks.Delete(preview.UUID)
+
+	return tactic.SelectService(&preview)
+}
+
 // RoundRobinTactic implements round-robin load balancing based on request count
 type RoundRobinTactic struct {
 	RequestThreshold int64 // Number of requests per service before switching
@@ -239,8 +334,8 @@ func NewRoundRobinTactic(requestThreshold ...int64) *RoundRobinTactic {
 
 // SelectService selects the next service based on round-robin with request threshold
 func (rr *RoundRobinTactic) SelectService(rule *Rule) *loadbalance.Service {
-	// Get active services once to avoid duplicate filtering
-	activeServices := rule.GetActiveServices()
+	// Get available services once to avoid duplicate filtering (skips tripped circuits)
+	activeServices := rule.GetAvailableServices()
 	if len(activeServices) == 0 {
 		return nil
 	}
@@ -325,8 +420,8 @@ func NewTokenBasedTactic(tokenThreshold int64) *TokenBasedTactic {
 
 // SelectService selects service based on token consumption thresholds
 func (tb *TokenBasedTactic) SelectService(rule *Rule) *loadbalance.Service {
-	// Get active services once to avoid duplicate filtering
-	activeServices := rule.GetActiveServices()
+	// Get available services once to avoid duplicate filtering (skips tripped circuits)
+	activeServices := rule.GetAvailableServices()
 	if len(activeServices) == 0 {
 		return nil
 	}
@@ -401,8 +496,8 @@ func NewHybridTactic(requestThreshold, tokenThreshold int64) *HybridTactic {
 
 // SelectService selects service based on both request count and token consumption
 func (ht *HybridTactic) SelectService(rule *Rule) *loadbalance.Service {
-	// Get active services once to avoid duplicate filtering
-	activeServices := rule.GetActiveServices()
+	// Get available services once to avoid duplicate filtering (skips tripped circuits)
+	activeServices := rule.GetAvailableServices()
 	if len(activeServices) == 0 {
 		return nil
 	}
@@ -468,8 +563,8 @@ func NewRandomTactic() *RandomTactic {
 
 // SelectService selects a service randomly based on weights
 func (rt *RandomTactic) SelectService(rule *Rule) *loadbalance.Service {
-	// Use the rule's method to get active services
-	activeServices := rule.GetActiveServices()
+	// Use the rule's method to get available services (skips tripped circuits)
+	activeServices := rule.GetAvailableServices()
 	if len(activeServices) == 0 {
 		return nil
 	}
@@ -509,22 +604,194 @@ func (rt *RandomTactic) GetType() loadbalance.TacticType {
 	return loadbalance.TacticRandom
 }
 
+// LeastLatencyTactic selects the service with the lowest moving average of time-to-first-token
+type LeastLatencyTactic struct {
+	ToleranceMs int64 // Services within this margin of the fastest are treated as equally fast
+}
+
+// NewLeastLatencyTactic creates a new least-latency tactic
+func NewLeastLatencyTactic(toleranceMs int64) *LeastLatencyTactic {
+	if toleranceMs < 0 {
+		toleranceMs = constant.DefaultLatencyToleranceMs
+	}
+	return &LeastLatencyTactic{ToleranceMs: toleranceMs}
+}
+
+// SelectService selects the fastest service by observed time-to-first-token.
+// Services that have not been measured yet are tried once per window so they
+// get samples; with no samples at all it falls back to round-robin.
+func (ll *LeastLatencyTactic) SelectService(rule *Rule) *loadbalance.Service {
+	// Get available services once to avoid duplicate filtering (skips tripped circuits)
+	activeServices := rule.GetAvailableServices()
+	if len(activeServices) == 0 {
+		return nil
+	}
+	if len(activeServices) == 1 {
+		return activeServices[0]
+	}
+
+	var unmeasured *loadbalance.Service
+	bestTTFT := -1.0
+	for _, service := range activeServices {
+		ttft, ok := service.Stats.GetAvgTTFT()
+		if !ok {
+			if requests, _ := service.GetWindowStats(); requests == 0 && unmeasured == nil {
+				unmeasured = service
+			}
+			continue
+		}
+		if bestTTFT < 0 || ttft < bestTTFT {
+			bestTTFT = ttft
+		}
+	}
+
+	// No latency data yet
+	if bestTTFT < 0 {
+		return defaultRoundRobinTactic.SelectService(rule)
+	}
+
+	// Give an unmeasured service a chance to report its latency
+	if unmeasured != nil {
+		return unmeasured
+	}
+
+	// Among services within tolerance of the fastest, spread load by request count
+	var selectedService *loadbalance.Service
+	var lowestRequests int64 = -1
+	for _, service := range activeServices {
+		ttft, ok := service.Stats.GetAvgTTFT()
+		if !ok || ttft > bestTTFT+float64(ll.ToleranceMs) {
+			continue
+		}
+		requests, _ := service.GetWindowStats()
+		if lowestRequests == -1 || requests < lowestRequests {
+			lowestRequests = requests
+			selectedService = service
+		}
+	}
+
+	return selectedService
+}
+
+func (ll *LeastLatencyTactic) GetName() string {
+	return "Least Latency"
+}
+
+func (ll *LeastLatencyTactic) GetType() loadbalance.TacticType {
+	return loadbalance.TacticLeastLatency
+}
+
+// ServicePricer returns the price of a service in USD per 1M tokens, or false if unknown
+type ServicePricer func(service *loadbalance.Service) (float64, bool)
+
+// Global price lookup used by the lowest-cost tactic (set by the server from the pricing catalog)
+var (
+	globalServicePricer   ServicePricer
+	globalServicePricerMu sync.RWMutex
+)
+
+// SetServicePricer registers the price lookup used by the lowest-cost tactic
+func SetServicePricer(pricer ServicePricer) {
+	globalServicePricerMu.Lock()
+	defer globalServicePricerMu.Unlock()
+	globalServicePricer = pricer
+}
+
+func getServicePricer() ServicePricer {
+	globalServicePricerMu.RLock()
+	defer globalServicePricerMu.RUnlock()
+	return globalServicePricer
+}
+
+// LowestCostTactic selects the cheapest service by per-model pricing
+type LowestCostTactic struct {
+	Prices map[string]float64 // Price overrides in USD per 1M tokens, keyed by "provider:model" or model
+}
+
+// NewLowestCostTactic creates a new lowest-cost tactic
+func NewLowestCostTactic(prices map[string]float64) *LowestCostTactic {
+	return &LowestCostTactic{Prices: prices}
+}
+
+// priceFor resolves a service price from the tactic overrides, then the global pricer
+func (lc *LowestCostTactic) priceFor(service *loadbalance.Service) (float64, bool) {
+	if price, ok := lc.Prices[service.ServiceID()]; ok {
+		return price, true
+	}
+	if price, ok := lc.Prices[service.Model]; ok {
+		return price, true
+	}
+	if pricer := getServicePricer(); pricer != nil {
+		return pricer(service)
+	}
+	return 0, false
+}
+
+// SelectService selects the cheapest priced service; ties go to the one with the
+// fewest tokens in the current window. Services without a known price are only
+// used when no service is priced, in which case it falls back to round-robin.
+func (lc *LowestCostTactic) SelectService(rule *Rule) *loadbalance.Service {
+	// Get available services once to avoid duplicate filtering (skips tripped circuits)
+	activeServices := rule.GetAvailableServices()
+	if len(activeServices) == 0 {
+		return nil
+	}
+	if len(activeServices) == 1 {
+		return activeServices[0]
+	}
+
+	var selectedService *loadbalance.Service
+	var lowestPrice float64
+	var lowestTokens int64
+	for _, service := range activeServices {
+		price, ok := lc.priceFor(service)
+		if !ok {
+			continue
+		}
+		_, tokens := service.GetWindowStats()
+		if selectedService == nil || price < lowestPrice || (price == lowestPrice && tokens < lowestTokens) {
+			selectedService = service
+			lowestPrice = price
+			lowestTokens = tokens
+		}
+	}
+
+	// No pricing data for any service
+	if selectedService == nil {
+		return defaultRoundRobinTactic.SelectService(rule)
+	}
+
+	return selectedService
+}
+
+func (lc *LowestCostTactic) GetName() string {
+	return "Lowest Cost"
+}
+
+func (lc *LowestCostTactic) GetType() loadbalance.TacticType {
+	return loadbalance.TacticLowestCost
+}
+
 // Pre-created singleton tactic instances
 var (
-	defaultRoundRobinTactic = NewRoundRobinTactic()
-	defaultTokenBasedTactic = NewTokenBasedTactic(constant.DefaultTokenThreshold)
-	defaultHybridTactic     = NewHybridTactic(constant.DefaultRequestThreshold, constant.DefaultTokenThreshold)
-	defaultRandomTactic     = NewRandomTactic()
+	defaultRoundRobinTactic   = NewRoundRobinTactic()
+	defaultTokenBasedTactic   = NewTokenBasedTactic(constant.DefaultTokenThreshold)
+	defaultHybridTactic       = NewHybridTactic(constant.DefaultRequestThreshold, constant.DefaultTokenThreshold)
+	defaultRandomTactic       = NewRandomTactic()
+	defaultLeastLatencyTactic = NewLeastLatencyTactic(constant.DefaultLatencyToleranceMs)
+	defaultLowestCostTactic   = NewLowestCostTactic(nil)
 )
 
 // IsValidTactic checks if the given tactic string is valid
 func IsValidTactic(tacticStr string) bool {
 	// Map of valid tactic names
 	validTactics := map[string]bool{
-		"round_robin": true,
-		"token_based": true,
-		"hybrid":      true,
-		"random":      true,
+		"round_robin":   true,
+		"token_based":   true,
+		"hybrid":        true,
+		"random":        true,
+		"least_latency": true,
+		"lowest_cost":   true,
 	}
 
 	// Convert to lowercase for case-insensitive comparison
@@ -548,6 +815,14 @@ func CreateTacticWithTypedParams(tacticType loadbalance.TacticType, params Tacti
 		}
 	case loadbalance.TacticRandom:
 		return defaultRandomTactic
+	case loadbalance.TacticLeastLatency:
+		if lp, ok := params.(*LeastLatencyParams); ok {
+			return NewLeastLatencyTactic(lp.ToleranceMs)
+		}
+	case loadbalance.TacticLowestCost:
+		if lp, ok := params.(*LowestCostParams); ok {
+			return NewLowestCostTactic(lp.Prices)
+		}
 	}
 	return GetDefaultTactic(tacticType)
 }
@@ -560,6 +835,10 @@ func GetDefaultTactic(tType loadbalance.TacticType) LoadBalancingTactic {
 		return defaultHybridTactic
 	case loadbalance.TacticRandom:
 		return defaultRandomTactic
+	case loadbalance.TacticLeastLatency:
+		return defaultLeastLatencyTactic
+	case loadbalance.TacticLowestCost:
+		return defaultLowestCostTactic
 	default:
 		return defaultRoundRobinTactic
 	}
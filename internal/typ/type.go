@@ -17,7 +17,9 @@ const (
 	ScenarioClaudeCode RuleScenario = "claude_code"
 	ScenarioOpenCode   RuleScenario = "opencode"
 	ScenarioXcode      RuleScenario = "xcode"
-	ScenarioGlobal     RuleScenario = "_global" // Global flags that apply to all scenarios
+	ScenarioGoogle     RuleScenario = "google"     // Gemini SDK and Gemini CLI clients
+	ScenarioEmbeddings RuleScenario = "embeddings" // Embedding models behind /v1/embeddings
+	ScenarioGlobal     RuleScenario = "_global"    // Global flags that apply to all scenarios
 )
 
 // ScenarioFlags represents configuration flags for a scenario
@@ -27,8 +29,9 @@ type ScenarioFlags struct {
 	Smart    bool `json:"smart" yaml:"smart"`       // Smart mode with automatic optimization
 
 	// Experimental feature flags (scenario-based opt-in)
-	SmartCompact bool `json:"smart_compact,omitempty" yaml:"smart_compact,omitempty"` // Enable smart compact (remove thinking blocks)
-	Recording    bool `json:"recording,omitempty" yaml:"recording,omitempty"`         // Enable scenario recording
+	SmartCompact       bool `json:"smart_compact,omitempty" yaml:"smart_compact,omitempty"`               // Enable smart compact (remove thinking blocks)
+	CompactToolResults bool `json:"compact_tool_results,omitempty" yaml:"compact_tool_results,omitempty"` // Enable tool result compaction (supersede and truncate stale outputs)
+	Recording          bool `json:"recording,omitempty" yaml:"recording,omitempty"`                       // Enable scenario recording
 }
 
 // ScenarioConfig represents configuration for a specific scenario
@@ -220,8 +223,10 @@ type Provider struct {
 	UUID          string            `json:"uuid"`
 	Name          string            `json:"name"`
 	APIBase       string            `json:"api_base"`
-	APIStyle      protocol.APIStyle `json:"api_style"` // "openai" or "anthropic", defaults to "openai"
-	Token         string            `json:"token"`     // API key for api_key auth type
+	APIStyle      protocol.APIStyle `json:"api_style"`      // "openai" or "anthropic", defaults to "openai"
+	Token         string            `json:"token"`          // API key for api_key auth type
+	Keys          []*ProviderKey    `json:"keys,omitempty"` // Additional API keys rotated with Token (api_key auth type)
+	KeyID         string            `json:"-"`              // Key a per-request copy is bound to, see WithKey
 	NoKeyRequired bool              `json:"no_key_required"`
 	Enabled       bool              `json:"enabled"`
 	ProxyURL      string            `json:"proxy_url"`              // HTTP or SOCKS proxy URL (e.g., "http://127.0.0.1:7890" or "socks5://127.0.0.1:1080")
@@ -276,6 +281,37 @@ type Rule struct {
 	// Smart Routing Configuration
 	SmartEnabled bool                        `json:"smart_enabled" yaml:"smart_enabled"`
 	SmartRouting []smartrouting.SmartRouting `json:"smart_routing,omitempty" yaml:"smart_routing,omitempty"`
+	// Failover Configuration
+	Failover *FailoverConfig `json:"failover,omitempty" yaml:"failover,omitempty"`
+	// Session Affinity Configuration
+	SessionAffinity *SessionAffinityConfig `json:"session_affinity,omitempty" yaml:"session_affinity,omitempty"`
+	// Context Window Overflow Protection
+	ContextOverflow *ContextOverflowConfig `json:"context_overflow,omitempty" yaml:"context_overflow,omitempty"`
+
+	// smartRouter is the smart routing compiled by CompileSmartRouting, shared by the copies of the rule
+	smartRouter *compiledSmartRouting
+}
+
+// compiledSmartRouting is the outcome of compiling the smart routing of a rule
+type compiledSmartRouting struct {
+	router *smartrouting.Router
+	err    error
+}
+
+// CompileSmartRouting validates and compiles the smart routing of the rule once, so that
+// requests do not compile it again. Called when the rule is loaded or changed.
+func (r *Rule) CompileSmartRouting() {
+	router, err := smartrouting.NewRouter(r.SmartRouting)
+	r.smartRouter = &compiledSmartRouting{router: router, err: err}
+}
+
+// SmartRouter returns the smart routing router of the rule, as compiled by
+// CompileSmartRouting, or compiled now when the rule was not compiled
+func (r *Rule) SmartRouter() (*smartrouting.Router, error) {
+	if r.smartRouter != nil {
+		return r.smartRouter.router, r.smartRouter.err
+	}
+	return smartrouting.NewRouter(r.SmartRouting)
 }
 
 // ToJSON implementation
@@ -285,16 +321,19 @@ func (r *Rule) ToJSON() interface{} {
 
 	// Create the JSON representation (note: current_service_index is persisted to SQLite, not JSON)
 	jsonRule := map[string]interface{}{
-		"uuid":           r.UUID,
-		"scenario":       r.GetScenario(),
-		"request_model":  r.RequestModel,
-		"response_model": r.ResponseModel,
-		"description":    r.Description,
-		"services":       services,
-		"lb_tactic":      r.LBTactic,
-		"active":         r.Active,
-		"smart_enabled":  r.SmartEnabled,
-		"smart_routing":  r.SmartRouting,
+		"uuid":             r.UUID,
+		"scenario":         r.GetScenario(),
+		"request_model":    r.RequestModel,
+		"response_model":   r.ResponseModel,
+		"description":      r.Description,
+		"services":         services,
+		"lb_tactic":        r.LBTactic,
+		"active":           r.Active,
+		"smart_enabled":    r.SmartEnabled,
+		"smart_routing":    r.SmartRouting,
+		"failover":         r.Failover,
+		"session_affinity": r.SessionAffinity,
+		"context_overflow": r.ContextOverflow,
 	}
 
 	return jsonRule
@@ -343,6 +382,34 @@ func (r *Rule) GetActiveServices() []*loadbalance.Service {
 	return activeServices
 }
 
+// GetAvailableServices returns active services whose circuit breaker is not open
+// and that are not inside an upstream retry-after window. Services near their
+// upstream rate limit are only returned when no other service has headroom.
+// If every active service is tripped, all active services are returned so the
+// rule keeps serving requests instead of failing outright.
+func (r *Rule) GetAvailableServices() []*loadbalance.Service {
+	activeServices := r.GetActiveServices()
+	availableServices := make([]*loadbalance.Service, 0, len(activeServices))
+	var nearLimit []*loadbalance.Service
+	for _, svc := range activeServices {
+		if !svc.IsAvailable() {
+			continue
+		}
+		if svc.IsNearRateLimit() {
+			nearLimit = append(nearLimit, svc)
+			continue
+		}
+		availableServices = append(availableServices, svc)
+	}
+	if len(availableServices) == 0 {
+		availableServices = nearLimit
+	}
+	if len(availableServices) == 0 {
+		return activeServices
+	}
+	return availableServices
+}
+
 // GetTacticType returns the load balancing tactic type
 func (r *Rule) GetTacticType() loadbalance.TacticType {
 	if r.LBTactic.Type != 0 {